@@ -51,6 +51,14 @@ keep it in a different directory.
 Options:
   --config-dir <dir>  Directory for all of Rune's files.
                       (default: %s)
+  --profile <name>    Load <dir>/profiles/<name>/init.lua after
+                      init.lua. Connecting to a world can also
+                      select one (see /profile).
+  --tcp-nodelay       Disable Nagle's algorithm so each command is
+                      sent immediately. (default: true)
+  --tcp-keepalive <d> Keepalive probe period, e.g. 15s; 0 disables.
+                      Shorter periods notice dropped links sooner.
+                      (default: 30s)
   --version           Print version and exit.
   -h, --help          Show this help.
 `, defaultDir, defaultDir)
@@ -60,6 +68,10 @@ func main() {
 	defaultDir := config.Dir()
 	showVersion := flag.Bool("version", false, "print version and exit")
 	configDir := flag.String("config-dir", "", "directory for all of Rune's files")
+	profile := flag.String("profile", "", "profile to load from the config dir's profiles/")
+	tcpDefaults := network.DefaultTCPOptions()
+	noDelay := flag.Bool("tcp-nodelay", tcpDefaults.NoDelay, "disable Nagle's algorithm")
+	keepAlive := flag.Duration("tcp-keepalive", tcpDefaults.KeepAlive, "TCP keepalive period (0 disables)")
 	flag.Usage = func() {
 		fmt.Fprint(flag.CommandLine.Output(), usageText(defaultDir))
 	}
@@ -81,11 +93,22 @@ func main() {
 	defer cancel()
 
 	tcpClient := network.NewTCPClient()
+	// RUNE_TRACE records raw socket traffic from the first connect,
+	// before init.lua could run /trace (protocol debugging).
+	if path := os.Getenv("RUNE_TRACE"); path != "" {
+		if _, err := tcpClient.StartTrace(path); err != nil {
+			fmt.Fprintln(os.Stderr, "RUNE_TRACE:", err)
+			os.Exit(1)
+		}
+	}
 	tuiInstance := tui.NewBubbleTeaUI()
 	sess := session.New(tcpClient, tuiInstance, session.Config{
 		CoreScripts:   lua.CoreScripts,
 		ConfigDir:     config.ResolveDir(*configDir),
 		ConnectTarget: target,
+		Profile:       *profile,
+		Mouse:         true, // the TUI always enables mouse cell motion
+		TCP:           &network.TCPOptions{NoDelay: *noDelay, KeepAlive: *keepAlive},
 	})
 
 	if err := sess.Run(ctx); err != nil {
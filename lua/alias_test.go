@@ -5,7 +5,11 @@ package lua
 // semantics (upsert, once) live in registry_test.go; the e2e wiring
 // proof in test/e2e/scenarios/aliases.json.
 
-import "testing"
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
 
 func TestAliasMatching(t *testing.T) {
 	runFeatureCases(t, []featureCase{
@@ -72,6 +76,18 @@ func TestAliasMatching(t *testing.T) {
 			input: "say hello",
 			want:  []string{"say HELLO"},
 		},
+		{
+			name:  "regex whole match",
+			setup: `rune.alias.regex('^kill (.+)$', "cast 'fireball' %1;say %0")`,
+			input: "kill rat",
+			want:  []string{"cast 'fireball' rat", "say kill rat"},
+		},
+		{
+			name:  "regex function whole match",
+			setup: `rune.alias.regex('^zap\\s+\\w+', function(matches) rune.send_raw(matches[0] .. '/' .. #matches) end)`,
+			input: "zap orc now",
+			want:  []string{"zap orc/0"},
+		},
 		{
 			name:  "regex alternation",
 			setup: `rune.alias.regex('^(n|s|e|w)$', 'go %1')`,
@@ -137,3 +153,59 @@ func TestAliasHandles(t *testing.T) {
 		},
 	})
 }
+
+// TestAliasStats verifies exact and regex aliases count their matches.
+func TestAliasStats(t *testing.T) {
+	engine, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		local n = rune.alias.exact("n", "north")
+		rune.alias.regex("^go (\\w+)$", "walk %1", { name = "go" })
+		rune.send("n"); rune.send("n east"); rune.send("go up"); rune.send("look")
+		assert(rune.alias.stats(n).hits == 2)
+		assert(rune.alias.stats("go").hits == 1 and rune.alias.stats("go").last_hit)
+	`)
+}
+
+// TestAliasExportImport verifies value aliases round-trip through a
+// file, function aliases are skipped, and imports outlive a reload
+// until forgotten.
+func TestAliasExportImport(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+	path := filepath.Join(t.TempDir(), "aliases.json")
+
+	assertLua(t, engine, fmt.Sprintf(`
+		local path = %q
+		rune.alias.exact("n", "north", { group = "walk" })
+		rune.alias.regex("^k (\\w+)$", "kill %%1", { name = "kill", priority = 10 })
+		rune.alias.exact("hi", function() end)
+		local n, skipped = rune.alias.export(path)
+		assert(n == 2 and skipped == 1, tostring(n) .. " " .. tostring(skipped))
+
+		rune.alias.clear()
+		assert(rune.alias.import(path) == 2)
+		local kill = rune.alias.list()[2]
+		assert(kill.name == "kill" and kill.mode == "regex", kill.match)
+		assert(rune.alias.list()[1].group == "walk")
+
+		local ok, err = rune.alias.import(path .. ".missing")
+		assert(not ok and err)
+	`, path))
+
+	if err := engine.Init(); err != nil {
+		t.Fatal(err)
+	}
+	loadCoreScripts(engine)
+	host.DrainNetworkCalls()
+	engine.OnInput("k rat")
+	if calls := host.DrainNetworkCalls(); len(calls) != 1 || calls[0] != "kill rat" {
+		t.Fatalf("imported alias after reload: sent %q", calls)
+	}
+
+	assertLua(t, engine, `
+		assert(rune.alias.forget_imported() == 2)
+		assert(rune.alias.count() == 0 and #rune.alias.imported() == 0)
+	`)
+}
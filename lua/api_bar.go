@@ -7,21 +7,29 @@ import (
 )
 
 // Bar renderers are owned by the Lua bar module (35_bars.lua), which
-// also applies the standard failure quarantine. Go's role is calling
-// rune.bars._render_all on the tick and marshaling the result.
+// also applies the standard failure quarantine and tracks which bars
+// are dirty. Go's role is deciding when a render is due, calling
+// rune.bars._render_all on the tick, and marshaling the result.
 
 // registerBarFuncs registers layout/refresh primitives on rune._ui.
 // The public rune.ui wrappers are defined in Lua (00_init.lua).
 func (e *Engine) registerBarFuncs() {
 	internal := e.L.GetField(e.runeTable, "_ui").(*glua.LTable)
 
-	// rune._ui.refresh_bars() - Force immediate bar refresh
-	// Use when bar state changes and you don't want to wait for the 250ms ticker
+	// rune._ui.refresh_bars() - Re-render every bar now
+	// Use when bar state changes and you don't want to wait for the tick
 	e.L.SetField(internal, "refresh_bars", e.L.NewFunction(func(L *glua.LState) int {
 		e.host.RefreshBars()
 		return 0
 	}))
 
+	// rune._ui.bars_dirty() - Some bar was marked dirty in Lua; render
+	// the dirty ones on the next tick
+	e.L.SetField(internal, "bars_dirty", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.MarkBarsDirty()
+		return 0
+	}))
+
 	// rune._ui.layout(config) - Set the layout configuration
 	// config = { top = {"bar1", {name="pane", height=10}}, bottom = {"input", "status"} }
 	e.L.SetField(internal, "layout", e.L.NewFunction(func(L *glua.LState) int {
@@ -49,7 +57,9 @@ func (e *Engine) registerBarFuncs() {
 }
 
 // parseLayoutArray converts a Lua array table to LayoutEntry slice.
-// Supports both strings ("name") and tables ({name="name", height=10}).
+// Supports both strings ("name") and tables ({name="name", height=10}),
+// where a pane's table may also set border ("top", "bottom", "both",
+// "none") and title = false. An unknown border raises.
 func parseLayoutArray(L *glua.LState, tbl *glua.LTable) []ui.LayoutEntry {
 	var result []ui.LayoutEntry
 	tbl.ForEach(func(k, v glua.LValue) {
@@ -68,6 +78,15 @@ func parseLayoutArray(L *glua.LState, tbl *glua.LTable) []ui.LayoutEntry {
 					entry.Height = int(h)
 				}
 			}
+			if border := L.GetField(val, "border"); border != glua.LNil {
+				switch b := border.String(); b {
+				case "top", "bottom", "both", "none":
+					entry.Border = b
+				default:
+					L.RaiseError("rune.ui.layout: border must be \"top\", \"bottom\", \"both\" or \"none\", got %q", b)
+				}
+			}
+			entry.NoTitle = L.GetField(val, "title") == glua.LFalse
 			if entry.Name != "" {
 				result = append(result, entry)
 			}
@@ -76,11 +95,12 @@ func parseLayoutArray(L *glua.LState, tbl *glua.LTable) []ui.LayoutEntry {
 	return result
 }
 
-// RenderBars asks the Lua bar module to render every active bar at
-// the given width. Returns nil when no bars produced content or the
-// module is unavailable (degraded mode).
+// RenderBars asks the Lua bar module for every active bar's content at
+// the given width: dirty bars render again, clean ones reuse their
+// last result, and full re-renders all of them. Returns nil when no
+// bars produced content or the module is unavailable (degraded mode).
 // Must be called from the Session goroutine (single Lua owner).
-func (e *Engine) RenderBars(width int) map[string]ui.BarContent {
+func (e *Engine) RenderBars(width int, full bool) map[string]ui.BarContent {
 	if e.L == nil {
 		return nil
 	}
@@ -94,7 +114,7 @@ func (e *Engine) RenderBars(width int) map[string]ui.BarContent {
 			Fn:      render,
 			NRet:    1,
 			Protect: true,
-		}, glua.LNumber(width))
+		}, glua.LNumber(width), glua.LBool(full))
 	}); err != nil {
 		e.reportError("bar render", err)
 		return nil
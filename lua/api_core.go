@@ -25,13 +25,37 @@ func (e *Engine) registerCoreFuncs() {
 		return 1
 	}))
 
-	// rune._echo(text): Outputs text to the local game window
+	// rune._echo(text): Outputs text to the local game window, through
+	// the "print" hook
 	e.L.SetField(e.runeTable, "_echo", e.L.NewFunction(func(L *glua.LState) int {
-		msg := L.CheckString(1)
-		e.host.Print(msg)
+		e.printScript(L.CheckString(1))
 		return 0
 	}))
 
+	// rune._notify(title, body): Show a desktop notification.
+	// Returns true, or nil + error message.
+	e.L.SetField(e.runeTable, "_notify", e.L.NewFunction(func(L *glua.LState) int {
+		if err := e.host.Notify(L.CheckString(1), L.OptString(2, "")); err != nil {
+			L.Push(glua.LNil)
+			L.Push(glua.LString(err.Error()))
+			return 2
+		}
+		L.Push(glua.LTrue)
+		return 1
+	}))
+
+	// rune._sound(path): Play an audio file.
+	// Returns true, or nil + error message.
+	e.L.SetField(e.runeTable, "_sound", e.L.NewFunction(func(L *glua.LState) int {
+		if err := e.host.PlaySound(L.CheckString(1)); err != nil {
+			L.Push(glua.LNil)
+			L.Push(glua.LString(err.Error()))
+			return 2
+		}
+		L.Push(glua.LTrue)
+		return 1
+	}))
+
 	// rune._quit(): Exit the client
 	e.L.SetField(e.runeTable, "_quit", e.L.NewFunction(func(L *glua.LState) int {
 		e.host.Quit()
@@ -57,6 +81,16 @@ func (e *Engine) registerCoreFuncs() {
 		return 0
 	}))
 
+	// rune._autoreload(on?): with an argument, turn reloading on
+	// script changes on or off. Returns the setting.
+	e.L.SetField(e.runeTable, "_autoreload", e.L.NewFunction(func(L *glua.LState) int {
+		if L.GetTop() > 0 {
+			e.host.SetAutoReload(L.ToBool(1))
+		}
+		L.Push(glua.LBool(e.host.AutoReload()))
+		return 1
+	}))
+
 	// rune._strip_ansi(text): Remove ANSI escape sequences.
 	// Used by rune.line.new so Lua-built line objects get the same
 	// clean text as lines arriving from the server.
@@ -66,6 +100,32 @@ func (e *Engine) registerCoreFuncs() {
 		return 1
 	}))
 
+	// rune._visible_width(text): Display width in terminal cells,
+	// ANSI-aware and wide-rune aware (rune.string.width/pad).
+	e.L.SetField(e.runeTable, "_visible_width", e.L.NewFunction(func(L *glua.LState) int {
+		L.Push(glua.LNumber(text.Width(L.CheckString(1))))
+		return 1
+	}))
+
+	// rune._truncate(text, width, tail): text cut to at most width
+	// cells, ending with tail when cut (rune.string.truncate).
+	e.L.SetField(e.runeTable, "_truncate", e.L.NewFunction(func(L *glua.LState) int {
+		L.Push(glua.LString(text.Truncate(L.CheckString(1), L.CheckInt(2), L.OptString(3, ""))))
+		return 1
+	}))
+
+	// rune._find_urls(text): Array of the bare http(s) URLs in plain
+	// text - the same detection that makes them clickable in the UI.
+	e.L.SetField(e.runeTable, "_find_urls", e.L.NewFunction(func(L *glua.LState) int {
+		s := L.CheckString(1)
+		urls := L.NewTable()
+		for _, span := range text.FindURLs(s) {
+			urls.Append(glua.LString(s[span[0]:span[1]]))
+		}
+		L.Push(urls)
+		return 1
+	}))
+
 	// rune._load(path): Load a Lua script (runs immediately, no round-trip).
 	// Returns true, or nil + error message.
 	e.L.SetField(e.runeTable, "_load", e.L.NewFunction(func(L *glua.LState) int {
@@ -0,0 +1,96 @@
+package lua
+
+import (
+	glua "github.com/yuin/gopher-lua"
+)
+
+// registerExecFuncs registers rune._exec.* primitives.
+//
+// Same split as rune._http: Go only runs the program (off the session
+// goroutine) and delivers the outcome through Engine.OnExecResult; the
+// Lua exec module (81_exec.lua) owns the id -> callback mapping. The
+// allowlist is checked on both sides: 81_exec.lua raises a readable
+// error, and the host refuses a run the primitive was called for
+// directly.
+func (e *Engine) registerExecFuncs() {
+	execTable := e.L.NewTable()
+	e.L.SetField(e.runeTable, "_exec", execTable)
+
+	// rune._exec.run(id, {command, args?, timeout?})
+	e.L.SetField(execTable, "run", e.L.NewFunction(func(L *glua.LState) int {
+		id := int(L.CheckNumber(1))
+		opts := L.CheckTable(2)
+
+		req := ExecRequest{Command: glua.LVAsString(opts.RawGetString("command"))}
+		if req.Command == "" {
+			L.RaiseError("rune._exec.run: command is required")
+		}
+		if args, ok := opts.RawGetString("args").(*glua.LTable); ok {
+			for i := 1; i <= args.Len(); i++ {
+				req.Args = append(req.Args, glua.LVAsString(args.RawGetInt(i)))
+			}
+		}
+		if timeout, ok := opts.RawGetString("timeout").(glua.LNumber); ok {
+			req.Timeout = toDuration(timeout)
+		}
+		req.Allow = e.execAllow()
+
+		e.host.Exec(id, req)
+		return 0
+	}))
+}
+
+// execAllow reads rune.config.exec_allow, skipping anything that is
+// not a string.
+func (e *Engine) execAllow() []string {
+	config, ok := e.L.GetField(e.runeTable, "config").(*glua.LTable)
+	if !ok {
+		return nil
+	}
+	list, ok := config.RawGetString("exec_allow").(*glua.LTable)
+	if !ok {
+		return nil
+	}
+	var allow []string
+	for i := 1; i <= list.Len(); i++ {
+		if name, ok := list.RawGetInt(i).(glua.LString); ok {
+			allow = append(allow, string(name))
+		}
+	}
+	return allow
+}
+
+// OnExecResult delivers a finished program run into Lua
+// (rune.execs._deliver). Exactly one of res/errMsg is set. Called on
+// the session goroutine, like every other Go -> Lua entry.
+func (e *Engine) OnExecResult(id int, res *ExecResult, errMsg string) {
+	if e.L == nil {
+		return
+	}
+	deliver, ok := e.getRuneFunc("execs", "_deliver")
+	if !ok {
+		return // exec module unavailable (core failed to load)
+	}
+
+	resVal := glua.LValue(glua.LNil)
+	errVal := glua.LValue(glua.LNil)
+	if errMsg != "" {
+		errVal = glua.LString(errMsg)
+	} else if res != nil {
+		t := e.L.NewTable()
+		t.RawSetString("stdout", glua.LString(res.Stdout))
+		t.RawSetString("stderr", glua.LString(res.Stderr))
+		t.RawSetString("code", glua.LNumber(res.Code))
+		resVal = t
+	}
+
+	if err := e.guard(func() error {
+		return e.L.CallByParam(glua.P{
+			Fn:      deliver,
+			NRet:    0,
+			Protect: true,
+		}, glua.LNumber(id), resVal, errVal)
+	}); err != nil {
+		e.reportError("exec callback", err)
+	}
+}
@@ -0,0 +1,73 @@
+package lua
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecAllowlist(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	// Nothing is allowed until the user lists it.
+	err := engine.DoString("test", `rune.exec("fortune", function() end)`)
+	if err == nil || !strings.Contains(err.Error(), "exec_allow") {
+		t.Fatalf("expected allowlist error, got %v", err)
+	}
+	if len(host.ExecCalls) != 0 {
+		t.Fatalf("denied run reached the host: %+v", host.ExecCalls)
+	}
+
+	err = engine.DoString("test", `
+		rune.config.exec_allow = { "fortune" }
+		rune.exec("fortune", { "-s", 3 }, function() end, { timeout = 2 })
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(host.ExecCalls) != 1 {
+		t.Fatalf("expected 1 exec call, got %d", len(host.ExecCalls))
+	}
+	req := host.ExecCalls[0].Req
+	if req.Command != "fortune" || strings.Join(req.Args, " ") != "-s 3" {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if req.Timeout != 2*time.Second {
+		t.Errorf("timeout not forwarded: %v", req.Timeout)
+	}
+	if strings.Join(req.Allow, " ") != "fortune" {
+		t.Errorf("allowlist not forwarded: %v", req.Allow)
+	}
+}
+
+func TestExecDeliver(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	err := engine.DoString("test", `
+		rune.config.exec_allow = { "date" }
+		rune.exec("date", function(res, err)
+			got_out, got_code, got_err = res.stdout, res.code, err
+		end)
+		rune.exec("date", function(res, err)
+			failed_res, failed_err = res, err
+		end)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine.OnExecResult(host.ExecCalls[0].ID, &ExecResult{Stdout: "today\n", Code: 1}, "")
+	engine.OnExecResult(host.ExecCalls[1].ID, nil, "date: timed out after 10s")
+	// A stale id (fire-and-forget, or from before /reload) is dropped.
+	engine.OnExecResult(999, nil, "late")
+
+	assertLua(t, engine, `
+		assert(got_out == "today\n", "stdout: " .. tostring(got_out))
+		assert(got_code == 1, "code: " .. tostring(got_code))
+		assert(got_err == nil, "err should be nil")
+		assert(failed_res == nil, "res should be nil")
+		assert(failed_err == "date: timed out after 10s", "err: " .. tostring(failed_err))
+	`)
+}
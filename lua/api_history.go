@@ -34,6 +34,19 @@ func (e *Engine) registerHistoryFuncs() {
 		return 1
 	}))
 
+	// rune._history.policy(size, dedupe) - Set the size limit and
+	// dedupe mode (validated by rune.history.config in 00_init.lua)
+	e.L.SetField(hist, "policy", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.SetHistoryPolicy(L.CheckInt(1), L.CheckString(2))
+		return 0
+	}))
+
+	// rune._history.forget(text) - Drop the newest entry with this text
+	e.L.SetField(hist, "forget", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.ForgetHistory(L.CheckString(1))
+		return 0
+	}))
+
 	// rune._history.add(cmd) - Add a command to history
 	e.L.SetField(hist, "add", e.L.NewFunction(func(L *glua.LState) int {
 		cmd := L.CheckString(1)
@@ -52,6 +52,36 @@ func (e *Engine) registerInputFuncs() {
 		return 0
 	}))
 
+	e.L.SetField(inp, "set_prompt", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.InputSetPrompt(L.CheckString(1))
+		return 0
+	}))
+
+	e.L.SetField(inp, "set_placeholder", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.InputSetPlaceholder(L.CheckString(1))
+		return 0
+	}))
+
+	e.L.SetField(inp, "set_mask", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.InputSetMask(L.ToBool(1))
+		return 0
+	}))
+
+	e.L.SetField(inp, "set_ghost", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.InputSetGhost(L.CheckString(1))
+		return 0
+	}))
+
+	e.L.SetField(inp, "set_word_break", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.InputSetWordBreak(L.CheckString(1))
+		return 0
+	}))
+
+	e.L.SetField(inp, "set_queue", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.InputSetQueue(L.CheckInt(1))
+		return 0
+	}))
+
 	// Editor mode primitive. The host call blocks in $EDITOR for as
 	// long as the user edits, so it runs outside the watchdog deadline.
 	e.L.SetField(inp, "open_editor", e.L.NewFunction(func(L *glua.LState) int {
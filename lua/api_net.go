@@ -0,0 +1,116 @@
+package lua
+
+import (
+	"time"
+
+	glua "github.com/yuin/gopher-lua"
+)
+
+// registerNetFuncs registers rune._net.* primitives. The public
+// rune.net API and the periodic ping policy live in Lua (72_net.lua);
+// the measurement itself happens in the network layer.
+func (e *Engine) registerNetFuncs() {
+	net := e.L.NewTable()
+	e.L.SetField(e.runeTable, "_net", net)
+
+	// rune._net.stats(): returns { bytes_in, bytes_out, latency_ms };
+	// latency_ms is nil until a ping has been answered.
+	e.L.SetField(net, "stats", e.L.NewFunction(func(L *glua.LState) int {
+		st := e.host.NetStats()
+		t := L.NewTable()
+		L.SetField(t, "bytes_in", glua.LNumber(st.BytesIn))
+		L.SetField(t, "bytes_out", glua.LNumber(st.BytesOut))
+		if st.Latency > 0 {
+			L.SetField(t, "latency_ms", glua.LNumber(float64(st.Latency.Microseconds())/1000))
+		}
+		L.Push(t)
+		return 1
+	}))
+
+	// rune._net.ping(): send a GMCP Core.Ping. Returns true, or nil +
+	// error message (not connected, GMCP not negotiated).
+	e.L.SetField(net, "ping", e.L.NewFunction(func(L *glua.LState) int {
+		if err := e.host.NetPing(); err != nil {
+			L.Push(glua.LNil)
+			L.Push(glua.LString(err.Error()))
+			return 2
+		}
+		L.Push(glua.LTrue)
+		return 1
+	}))
+
+	// rune._net.set_environ(vars): replace the NEW-ENVIRON variables
+	// scripts report, a table of name -> string value (73_env.lua owns
+	// the set and pushes all of it on every change).
+	e.L.SetField(net, "set_environ", e.L.NewFunction(func(L *glua.LState) int {
+		tbl := L.CheckTable(1)
+		vars := map[string]string{}
+		tbl.ForEach(func(k, v glua.LValue) {
+			if name, ok := k.(glua.LString); ok {
+				vars[string(name)] = v.String()
+			}
+		})
+		e.host.SetEnviron(vars)
+		return 0
+	}))
+
+	// rune._net.max_line(bytes): split server lines longer than bytes
+	// (0: never).
+	e.L.SetField(net, "max_line", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.SetMaxLine(L.CheckInt(1))
+		return 0
+	}))
+
+	// rune._net.mxp(on): accept MXP on the next connect (rune.config.mxp).
+	e.L.SetField(net, "mxp", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.SetMXP(L.ToBool(1))
+		return 0
+	}))
+
+	// rune._net.negotiate(command, option): initiate negotiation of a
+	// telnet option (rune.telnet.will/wont/do/dont in 71_telnet.lua).
+	// Returns whether anything was sent, or nil + error message.
+	e.L.SetField(net, "negotiate", e.L.NewFunction(func(L *glua.LState) int {
+		command := L.CheckString(1)
+		option := L.CheckInt(2)
+		if option < 0 || option > 255 {
+			L.ArgError(2, "telnet options are 0-255")
+		}
+		sent, err := e.host.TelnetNegotiate(command, byte(option))
+		if err != nil {
+			L.Push(glua.LNil)
+			L.Push(glua.LString(err.Error()))
+			return 2
+		}
+		L.Push(glua.LBool(sent))
+		return 1
+	}))
+
+	// rune._net.abandon(option): give up on an unanswered WILL or DO
+	// (the negotiation timeout in 71_telnet.lua). Returns whether one
+	// was outstanding.
+	e.L.SetField(net, "abandon", e.L.NewFunction(func(L *glua.LState) int {
+		option := L.CheckInt(1)
+		if option < 0 || option > 255 {
+			L.ArgError(1, "telnet options are 0-255")
+		}
+		L.Push(glua.LBool(e.host.TelnetAbandon(byte(option))))
+		return 1
+	}))
+
+	// rune._net.prompt_detect(mode, pattern, ms): choose how prompts
+	// are found without GA/EOR marks (rune.prompt.detect in 95_ui.lua).
+	// Returns true, or nil + error message.
+	e.L.SetField(net, "prompt_detect", e.L.NewFunction(func(L *glua.LState) int {
+		mode := L.CheckString(1)
+		pattern := L.OptString(2, "")
+		stable := time.Duration(float64(L.OptNumber(3, 0)) * float64(time.Millisecond))
+		if err := e.host.SetPromptDetect(mode, pattern, stable); err != nil {
+			L.Push(glua.LNil)
+			L.Push(glua.LString(err.Error()))
+			return 2
+		}
+		L.Push(glua.LTrue)
+		return 1
+	}))
+}
@@ -20,6 +20,9 @@ func (e *Engine) registerPickerFuncs() {
 	//   mode = "inline"                     -- optional: "inline" or "modal" (default)
 	//   match_description = true            -- optional: include description in fuzzy matching
 	//   dismiss_on_space = true             -- optional (inline): close once input contains a space
+	//   max_results = 50                    -- optional: keep only the best N matches per query
+	//   query = "look"                      -- optional (modal): initial search text
+	//   match = "prefix"                    -- optional (modal): "fuzzy" or "prefix"; Ctrl+R toggles
 	// }
 	// Modal mode: picker captures keyboard and has its own search field.
 	// Inline mode: user types in main input, picker filters based on input content.
@@ -51,6 +54,34 @@ func (e *Engine) registerPickerFuncs() {
 			dismissOnSpace = glua.LVAsBool(dsVal)
 		}
 
+		// Parse max_results (optional - cap fuzzy matches per query)
+		maxResults := 0
+		if mrVal := L.GetField(opts, "max_results"); mrVal != glua.LNil {
+			n, ok := mrVal.(glua.LNumber)
+			if !ok || n < 0 {
+				L.RaiseError("picker: max_results must be a non-negative number")
+				return 0
+			}
+			maxResults = int(n)
+		}
+
+		// Parse query (optional - seeds a modal picker's search field)
+		query := ""
+		if qVal := L.GetField(opts, "query"); qVal != glua.LNil {
+			query = qVal.String()
+		}
+
+		// Parse match (optional - "fuzzy" or "prefix"; enables the
+		// Ctrl+R toggle in modal mode)
+		match := ""
+		if mVal := L.GetField(opts, "match"); mVal != glua.LNil {
+			match = mVal.String()
+			if match != "fuzzy" && match != "prefix" {
+				L.RaiseError("picker: match must be \"fuzzy\" or \"prefix\"")
+				return 0
+			}
+		}
+
 		// Parse items
 		itemsVal := L.GetField(opts, "items")
 		itemsTbl, ok := itemsVal.(*glua.LTable)
@@ -78,6 +109,9 @@ func (e *Engine) registerPickerFuncs() {
 			CallbackID:     callbackID,
 			Inline:         inline,
 			DismissOnSpace: dismissOnSpace,
+			MaxResults:     maxResults,
+			Query:          query,
+			Match:          match,
 		})
 		return 0
 	}))
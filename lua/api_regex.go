@@ -29,6 +29,8 @@ func checkRegex(L *glua.LState, n int) *regexp.Regexp {
 // regexMethods defines the methods available on Regex objects in Lua.
 var regexMethods = map[string]glua.LGFunction{
 	"match":   regexMatch,
+	"gmatch":  regexGmatch,
+	"gsub":    regexGsub,
 	"pattern": regexPattern,
 }
 
@@ -50,6 +52,42 @@ func regexMatch(L *glua.LState) int {
 	return 1
 }
 
+// regexGmatch returns every non-overlapping match, each shaped like
+// re:match's result, as an array (empty when nothing matches).
+// Usage: re:gmatch(text)
+func regexGmatch(L *glua.LState) int {
+	re := checkRegex(L, 1)
+	text := L.CheckString(2)
+	all := L.NewTable()
+	for i, matches := range re.FindAllStringSubmatch(text, -1) {
+		tbl := L.NewTable()
+		for j, m := range matches {
+			tbl.RawSetInt(j+1, glua.LString(m))
+		}
+		all.RawSetInt(i+1, tbl)
+	}
+	L.Push(all)
+	return 1
+}
+
+// regexGsub replaces every match, expanding $1 / ${name} group
+// references in the replacement, and returns the result and the
+// number of matches replaced.
+// Usage: re:gsub(text, replacement)
+func regexGsub(L *glua.LState) int {
+	re := checkRegex(L, 1)
+	text := L.CheckString(2)
+	repl := L.CheckString(3)
+	n := len(re.FindAllStringIndex(text, -1))
+	if n == 0 {
+		L.Push(glua.LString(text))
+	} else {
+		L.Push(glua.LString(re.ReplaceAllString(text, repl)))
+	}
+	L.Push(glua.LNumber(n))
+	return 2
+}
+
 // regexPattern returns the source pattern string.
 // Usage: re:pattern()
 func regexPattern(L *glua.LState) int {
@@ -61,6 +99,7 @@ func regexPattern(L *glua.LState) int {
 // registerRegexFuncs registers internal rune._regex.* primitives
 func (e *Engine) registerRegexFuncs() {
 	registerRegexType(e.L)
+	registerLiteralSetType(e.L)
 
 	regexTable := e.L.NewTable()
 	e.L.SetField(e.runeTable, "_regex", regexTable)
@@ -82,4 +121,30 @@ func (e *Engine) registerRegexFuncs() {
 		L.Push(ud)
 		return 1
 	}))
+
+	// rune._regex.literal(pattern): A literal every match of pattern
+	// contains, or nil when there is none to rely on.
+	e.L.SetField(regexTable, "literal", e.L.NewFunction(func(L *glua.LState) int {
+		if lit := requiredLiteral(L.CheckString(1)); lit != "" {
+			L.Push(glua.LString(lit))
+		} else {
+			L.Push(glua.LNil)
+		}
+		return 1
+	}))
+
+	// rune._regex.literal_set(literals): A LiteralSet userdata whose
+	// :scan(text) returns {[i] = true} for each literals[i] in text.
+	e.L.SetField(regexTable, "literal_set", e.L.NewFunction(func(L *glua.LState) int {
+		tbl := L.CheckTable(1)
+		literals := make([]string, tbl.Len())
+		for i := range literals {
+			literals[i] = glua.LVAsString(tbl.RawGetInt(i + 1))
+		}
+		ud := L.NewUserData()
+		ud.Value = newLiteralSet(literals)
+		L.SetMetatable(ud, L.GetTypeMetatable(luaLiteralSetTypeName))
+		L.Push(ud)
+		return 1
+	}))
 }
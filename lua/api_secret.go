@@ -0,0 +1,56 @@
+package lua
+
+import glua "github.com/yuin/gopher-lua"
+
+// registerSecretFuncs registers rune._secret.* primitives.
+// The public rune.secret API is defined in Lua (00_init.lua).
+func (e *Engine) registerSecretFuncs() {
+	secret := e.L.NewTable()
+	e.L.SetField(e.runeTable, "_secret", secret)
+
+	// rune._secret.set(name, value): store a credential.
+	// Returns true, or nil + error message.
+	e.L.SetField(secret, "set", e.L.NewFunction(func(L *glua.LState) int {
+		name := L.CheckString(1)
+		value := L.CheckString(2)
+		if err := e.host.SecretSet(name, value); err != nil {
+			L.Push(glua.LNil)
+			L.Push(glua.LString(err.Error()))
+			return 2
+		}
+		L.Push(glua.LTrue)
+		return 1
+	}))
+
+	// rune._secret.get(name): returns the value, or nil.
+	e.L.SetField(secret, "get", e.L.NewFunction(func(L *glua.LState) int {
+		value, ok := e.host.SecretGet(L.CheckString(1))
+		if !ok {
+			L.Push(glua.LNil)
+			return 1
+		}
+		L.Push(glua.LString(value))
+		return 1
+	}))
+
+	// rune._secret.delete(name): returns true, or nil + error message.
+	e.L.SetField(secret, "delete", e.L.NewFunction(func(L *glua.LState) int {
+		if err := e.host.SecretDelete(L.CheckString(1)); err != nil {
+			L.Push(glua.LNil)
+			L.Push(glua.LString(err.Error()))
+			return 2
+		}
+		L.Push(glua.LTrue)
+		return 1
+	}))
+
+	// rune._secret.names(): sorted array of stored names.
+	e.L.SetField(secret, "names", e.L.NewFunction(func(L *glua.LState) int {
+		tbl := L.NewTable()
+		for i, name := range e.host.SecretNames() {
+			tbl.RawSetInt(i+1, glua.LString(name))
+		}
+		L.Push(tbl)
+		return 1
+	}))
+}
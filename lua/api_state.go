@@ -1,15 +1,24 @@
 package lua
 
-import glua "github.com/yuin/gopher-lua"
+import (
+	"time"
+
+	glua "github.com/yuin/gopher-lua"
+)
 
 // ClientState holds the current client state for Lua access.
 type ClientState struct {
-	Connected   bool
-	Address     string
-	ScrollMode  string // "live" or "scrolled"
-	ScrollLines int    // Lines behind live (when scrolled)
-	Width       int    // Terminal width
-	Height      int    // Terminal height
+	Connected        bool
+	Address          string
+	Connection       string    // "disconnected", "connecting", or "connected"
+	ScrollMode       string    // "live" or "scrolled"
+	ScrollLines      int       // Lines behind live (when scrolled)
+	ScrollPercent    int       // Position in the scrollback: 0 oldest, 100 newest
+	Width            int       // Terminal width
+	Height           int       // Terminal height
+	InputDropped     int       // Submissions dropped with the input queue full
+	DisconnectReason string    // Why the last connection ended ("user", "closed", "reset", "timeout", "error"); "" while connected
+	ConnectedAt      time.Time // When the current connection opened; zero while disconnected
 }
 
 // registerStateFuncs creates the rune._state table that Go pushes
@@ -22,10 +31,15 @@ func (e *Engine) registerStateFuncs() {
 	// Initialize with defaults
 	e.L.SetField(stateTable, "connected", glua.LFalse)
 	e.L.SetField(stateTable, "address", glua.LString(""))
+	e.L.SetField(stateTable, "connection", glua.LString("disconnected"))
 	e.L.SetField(stateTable, "scroll_mode", glua.LString("live"))
 	e.L.SetField(stateTable, "scroll_lines", glua.LNumber(0))
+	e.L.SetField(stateTable, "scroll_percent", glua.LNumber(100))
 	e.L.SetField(stateTable, "width", glua.LNumber(0))
 	e.L.SetField(stateTable, "height", glua.LNumber(0))
+	e.L.SetField(stateTable, "input_dropped", glua.LNumber(0))
+	e.L.SetField(stateTable, "disconnect_reason", glua.LString(""))
+	e.L.SetField(stateTable, "connected_at", glua.LNumber(0))
 }
 
 // UpdateState pushes new client state to the Lua rune._state table.
@@ -43,8 +57,30 @@ func (e *Engine) UpdateState(state ClientState) {
 	}
 	e.L.SetField(t, "connected", glua.LBool(state.Connected))
 	e.L.SetField(t, "address", glua.LString(state.Address))
+	e.L.SetField(t, "connection", glua.LString(state.connection()))
 	e.L.SetField(t, "scroll_mode", glua.LString(state.ScrollMode))
 	e.L.SetField(t, "scroll_lines", glua.LNumber(state.ScrollLines))
+	e.L.SetField(t, "scroll_percent", glua.LNumber(state.ScrollPercent))
 	e.L.SetField(t, "width", glua.LNumber(state.Width))
 	e.L.SetField(t, "height", glua.LNumber(state.Height))
+	e.L.SetField(t, "input_dropped", glua.LNumber(state.InputDropped))
+	e.L.SetField(t, "disconnect_reason", glua.LString(state.DisconnectReason))
+	var connectedAt int64
+	if !state.ConnectedAt.IsZero() {
+		connectedAt = state.ConnectedAt.Unix()
+	}
+	e.L.SetField(t, "connected_at", glua.LNumber(connectedAt))
+}
+
+// connection is the connection phase to report, derived from
+// Connected when the caller left Connection unset.
+func (s ClientState) connection() string {
+	switch {
+	case s.Connection != "":
+		return s.Connection
+	case s.Connected:
+		return "connected"
+	default:
+		return "disconnected"
+	}
 }
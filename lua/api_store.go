@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"os"
 
 	glua "github.com/yuin/gopher-lua"
 )
@@ -186,6 +187,48 @@ func (e *Engine) registerStoreFuncs() {
 		return 1
 	}))
 
+	// rune._store.write_file(path, value): write value as indented
+	// JSON to path ("~" expands), for files meant to be shared or
+	// read. Returns true, or nil + error message.
+	e.L.SetField(store, "write_file", e.L.NewFunction(func(L *glua.LState) int {
+		path := expandTilde(L.CheckString(1))
+		gv, err := luaToGo(L.Get(2), make(map[*glua.LTable]bool), 0)
+		if err == nil {
+			var raw []byte
+			raw, err = json.MarshalIndent(gv, "", "  ")
+			if err == nil {
+				err = os.WriteFile(path, append(raw, '\n'), 0o644)
+			}
+		}
+		if err != nil {
+			L.Push(glua.LNil)
+			L.Push(glua.LString(err.Error()))
+			return 2
+		}
+		L.Push(glua.LTrue)
+		return 1
+	}))
+
+	// rune._store.read_file(path): the decoded JSON in path ("~"
+	// expands), or nil + error message.
+	e.L.SetField(store, "read_file", e.L.NewFunction(func(L *glua.LState) int {
+		path := expandTilde(L.CheckString(1))
+		raw, err := os.ReadFile(path)
+		var v any
+		if err == nil {
+			if err = json.Unmarshal(raw, &v); err != nil {
+				err = fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		if err != nil {
+			L.Push(glua.LNil)
+			L.Push(glua.LString(err.Error()))
+			return 2
+		}
+		L.Push(goToLua(L, v))
+		return 1
+	}))
+
 	// rune._store.delete(key): returns true, or nil + error message.
 	e.L.SetField(store, "delete", e.L.NewFunction(func(L *glua.LState) int {
 		key := L.CheckString(1)
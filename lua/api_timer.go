@@ -43,6 +43,27 @@ func (e *Engine) registerTimerFuncs() {
 	}))
 }
 
+// registerTickFuncs registers rune._tick.* primitives for the shared
+// heartbeat (rune.tick in 40_timers.lua).
+func (e *Engine) registerTickFuncs() {
+	tickTable := e.L.NewTable()
+	e.L.SetField(e.runeTable, "_tick", tickTable)
+
+	// rune._tick.set(seconds, paused): Reconfigure the heartbeat
+	e.L.SetField(tickTable, "set", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.SetTick(toDuration(L.CheckNumber(1)), L.ToBool(2))
+		return 0
+	}))
+
+	// rune._tick.status(): Returns seconds, paused
+	e.L.SetField(tickTable, "status", e.L.NewFunction(func(L *glua.LState) int {
+		interval, paused := e.host.TickStatus()
+		L.Push(glua.LNumber(interval.Seconds()))
+		L.Push(glua.LBool(paused))
+		return 2
+	}))
+}
+
 // toDuration converts Lua number seconds to Go duration
 func toDuration(seconds glua.LNumber) time.Duration {
 	return time.Duration(float64(seconds) * float64(time.Second))
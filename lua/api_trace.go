@@ -0,0 +1,42 @@
+package lua
+
+import glua "github.com/yuin/gopher-lua"
+
+// registerTraceFuncs registers rune._trace.* primitives. The public
+// rune.trace API and the /trace command live in Lua (62_trace.lua).
+// The trace itself is recorded by the network layer, below telnet
+// parsing, so these are thin handles on it.
+func (e *Engine) registerTraceFuncs() {
+	trace := e.L.NewTable()
+	e.L.SetField(e.runeTable, "_trace", trace)
+
+	// rune._trace.start(path): begin recording raw traffic (append,
+	// parents created). Returns the resolved path, or nil + error.
+	e.L.SetField(trace, "start", e.L.NewFunction(func(L *glua.LState) int {
+		path := L.CheckString(1)
+		resolved, err := e.host.TraceStart(path)
+		if err != nil {
+			L.Push(glua.LNil)
+			L.Push(glua.LString(err.Error()))
+			return 2
+		}
+		L.Push(glua.LString(resolved))
+		return 1
+	}))
+
+	// rune._trace.stop(): end the trace. Returns true if one was open.
+	e.L.SetField(trace, "stop", e.L.NewFunction(func(L *glua.LState) int {
+		L.Push(glua.LBool(e.host.TraceStop()))
+		return 1
+	}))
+
+	// rune._trace.status(): returns the active trace path, or nil.
+	e.L.SetField(trace, "status", e.L.NewFunction(func(L *glua.LState) int {
+		if path, active := e.host.TraceStatus(); active {
+			L.Push(glua.LString(path))
+		} else {
+			L.Push(glua.LNil)
+		}
+		return 1
+	}))
+}
@@ -1,6 +1,15 @@
 package lua
 
-import glua "github.com/yuin/gopher-lua"
+import (
+	"math"
+	"slices"
+	"time"
+
+	glua "github.com/yuin/gopher-lua"
+
+	"github.com/mmcdole/rune/text"
+	"github.com/mmcdole/rune/ui"
+)
 
 // registerUIFuncs registers all UI-related API functions
 func (e *Engine) registerUIFuncs() {
@@ -27,6 +36,166 @@ func (e *Engine) registerUIInternalFuncs() {
 		e.host.ClipboardSet(L.CheckString(1))
 		return 0
 	}))
+
+	// rune._ui.clear_prompt(): drop the prompt overlay.
+	e.L.SetField(internal, "clear_prompt", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.ClearPrompt()
+		return 0
+	}))
+
+	// rune._ui.bell(audible, visual): ring and/or flash.
+	e.L.SetField(internal, "bell", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.Bell(L.ToBool(1), L.ToBool(2))
+		return 0
+	}))
+
+	// rune._ui.copy_mode(): start copy mode in the output viewport.
+	e.L.SetField(internal, "copy_mode", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.CopyMode()
+		return 0
+	}))
+
+	// rune._ui.jump_to_input(): scroll output back to the last command.
+	e.L.SetField(internal, "jump_to_input", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.JumpToInput()
+		return 0
+	}))
+
+	// rune._ui.clear_screen(): scroll visible output out of view.
+	e.L.SetField(internal, "clear_screen", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.ClearScreen()
+		return 0
+	}))
+
+	// rune._ui.clear_scrollback(): drop all output, scrollback included.
+	e.L.SetField(internal, "clear_scrollback", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.ClearScrollback()
+		return 0
+	}))
+
+	// rune._ui.wrap(on): soft-wrap new output rows, or clip them.
+	e.L.SetField(internal, "wrap", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.SetWrap(L.ToBool(1))
+		return 0
+	}))
+
+	// rune._ui.split(on): show live output below a divider while
+	// scrolled back.
+	e.L.SetField(internal, "split", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.SetSplit(L.ToBool(1))
+		return 0
+	}))
+
+	// rune._ui.theme(colors): recolor the UI chrome from a { key =
+	// color } table (see ui.Theme.Set). Returns true, or nil + error
+	// message for an unknown key or color.
+	e.L.SetField(internal, "theme", e.L.NewFunction(func(L *glua.LState) int {
+		var theme ui.Theme
+		var err error
+		L.CheckTable(1).ForEach(func(k, v glua.LValue) {
+			if err == nil {
+				err = theme.Set(k.String(), v.String())
+			}
+		})
+		if err != nil {
+			L.Push(glua.LNil)
+			L.Push(glua.LString(err.Error()))
+			return 2
+		}
+		e.host.SetTheme(theme)
+		L.Push(glua.LTrue)
+		return 1
+	}))
+
+	// rune._ui.throttle(rate?): cap server lines shown per second (0 =
+	// off). Returns the cap in effect.
+	e.L.SetField(internal, "throttle", e.L.NewFunction(func(L *glua.LState) int {
+		if L.GetTop() > 0 {
+			e.host.SetOutputRate(L.CheckInt(1))
+		}
+		L.Push(glua.LNumber(e.host.OutputRate()))
+		return 1
+	}))
+
+	// rune._ui.dedupe(on): collapse repeated server lines.
+	e.L.SetField(internal, "dedupe", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.SetDedupe(L.ToBool(1))
+		return 0
+	}))
+
+	// rune._ui.flush_interval(ms): set the output batch window.
+	e.L.SetField(internal, "flush_interval", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.SetFlushInterval(time.Duration(L.CheckInt(1)) * time.Millisecond)
+		return 0
+	}))
+
+	// rune._ui.lines(n, clean, origin?): the newest n output lines,
+	// oldest first; with clean, escape sequences stripped; with origin,
+	// only lines from there ("server", "echo", "script").
+	e.L.SetField(internal, "lines", e.L.NewFunction(func(L *glua.LState) int {
+		n := L.CheckInt(1)
+		clean := L.ToBool(2)
+		lines := e.host.RecentLines(n)
+		if name := L.OptString(3, ""); name != "" {
+			origin, ok := text.ParseOrigin(name)
+			if !ok {
+				L.ArgError(3, `origin must be "server", "echo", or "script"`)
+			}
+			// The newest n of that origin, so read the whole mirror.
+			lines = slices.DeleteFunc(e.host.RecentLines(math.MaxInt), func(l text.Line) bool {
+				return l.Origin != origin
+			})
+			lines = lines[max(len(lines)-n, 0):]
+		}
+		tbl := L.NewTable()
+		for _, line := range lines {
+			if clean {
+				tbl.Append(glua.LString(line.Clean))
+			} else {
+				tbl.Append(glua.LString(line.Raw))
+			}
+		}
+		L.Push(tbl)
+		return 1
+	}))
+
+	// rune._ui.term(): { colors, mouse, width, height } for the
+	// terminal the client draws on.
+	e.L.SetField(internal, "term", e.L.NewFunction(func(L *glua.LState) int {
+		term := e.host.Terminal()
+		t := L.NewTable()
+		L.SetField(t, "colors", glua.LNumber(term.Colors))
+		L.SetField(t, "mouse", glua.LBool(term.Mouse))
+		L.SetField(t, "width", glua.LNumber(term.Width))
+		L.SetField(t, "height", glua.LNumber(term.Height))
+		L.Push(t)
+		return 1
+	}))
+
+	// rune._ui.grep(regex, highlight, label, pane, limit): dump the
+	// scrollback rows matching regex to pane ("" for the output).
+	e.L.SetField(internal, "grep", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.GrepScrollback(ui.GrepScrollbackMsg{
+			Pattern:   checkRegex(L, 1),
+			Highlight: L.CheckString(2),
+			Label:     L.CheckString(3),
+			Pane:      L.OptString(4, ""),
+			Limit:     L.CheckInt(5),
+		})
+		return 0
+	}))
+
+	// rune._ui.open_url(url): open an http(s) URL with the OS opener.
+	// Returns true, or nil + error message.
+	e.L.SetField(internal, "open_url", e.L.NewFunction(func(L *glua.LState) int {
+		if err := e.host.OpenURL(L.CheckString(1)); err != nil {
+			L.Push(glua.LNil)
+			L.Push(glua.LString(err.Error()))
+			return 2
+		}
+		L.Push(glua.LTrue)
+		return 1
+	}))
 }
 
 // registerPaneFuncs registers internal rune._pane.* primitives (wrapped by Lua)
@@ -71,6 +240,53 @@ func (e *Engine) registerPaneFuncs() {
 		return 0
 	}))
 
+	// rune._pane.resize(name, height): Set pane height ("" = focused)
+	e.L.SetField(paneTable, "resize", e.L.NewFunction(func(L *glua.LState) int {
+		name := L.CheckString(1)
+		height := L.CheckInt(2)
+		e.host.PaneResize(name, height)
+		return 0
+	}))
+
+	// rune._pane.grow(name, rows): Grow or shrink a pane ("" = focused)
+	e.L.SetField(paneTable, "grow", e.L.NewFunction(func(L *glua.LState) int {
+		name := L.CheckString(1)
+		rows := L.CheckInt(2)
+		e.host.PaneGrow(name, rows)
+		return 0
+	}))
+
+	// rune._pane.limit(name, lines, bytes): Set a pane's buffer budget
+	// (0 keeps that budget)
+	e.L.SetField(paneTable, "limit", e.L.NewFunction(func(L *glua.LState) int {
+		e.host.PaneLimit(L.CheckString(1), L.CheckInt(2), L.CheckInt(3))
+		return 0
+	}))
+
+	// rune._pane.visible(name): Whether a pane is showing
+	e.L.SetField(paneTable, "visible", e.L.NewFunction(func(L *glua.LState) int {
+		L.Push(glua.LBool(e.host.PaneVisible(L.CheckString(1))))
+		return 1
+	}))
+
+	// rune._pane.focus(name): Target a pane for unnamed resizes
+	e.L.SetField(paneTable, "focus", e.L.NewFunction(func(L *glua.LState) int {
+		name := L.CheckString(1)
+		e.host.PaneFocus(name)
+		return 0
+	}))
+
+	// rune._pane.unread(): {name = count} for hidden panes written to
+	// since they were last shown
+	e.L.SetField(paneTable, "unread", e.L.NewFunction(func(L *glua.LState) int {
+		t := L.NewTable()
+		for name, n := range e.host.PaneUnread() {
+			t.RawSetString(name, glua.LNumber(n))
+		}
+		L.Push(t)
+		return 1
+	}))
+
 	// rune._pane.scroll_up(name, lines): Scroll pane up
 	e.L.SetField(paneTable, "scroll_up", e.L.NewFunction(func(L *glua.LState) int {
 		name := L.CheckString(1)
@@ -87,6 +303,24 @@ func (e *Engine) registerPaneFuncs() {
 		return 0
 	}))
 
+	// rune._pane.scroll_columns(name, cols): Scroll pane sideways
+	// (negative = left)
+	e.L.SetField(paneTable, "scroll_columns", e.L.NewFunction(func(L *glua.LState) int {
+		name := L.CheckString(1)
+		cols := L.CheckInt(2)
+		e.host.PaneScrollColumns(name, cols)
+		return 0
+	}))
+
+	// rune._pane.scroll_pages(name, pages): Scroll by a fraction of
+	// the pane's height (negative = up)
+	e.L.SetField(paneTable, "scroll_pages", e.L.NewFunction(func(L *glua.LState) int {
+		name := L.CheckString(1)
+		pages := float64(L.CheckNumber(2))
+		e.host.PaneScrollPages(name, pages)
+		return 0
+	}))
+
 	// rune._pane.scroll_to_top(name): Scroll pane to top
 	e.L.SetField(paneTable, "scroll_to_top", e.L.NewFunction(func(L *glua.LState) int {
 		name := L.CheckString(1)
@@ -0,0 +1,65 @@
+package lua
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mmcdole/rune/text"
+)
+
+func TestBellModes(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	err := engine.DoString("test", `
+		rune.bell()
+		rune.bell("audible")
+		rune.bell("visual")
+		rune.bell("none")
+		rune.config.bell = "visual"
+		rune.bell()
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ Audible, Visual bool }{
+		{true, true}, {true, false}, {false, true}, {false, true},
+	}
+	if len(host.BellCalls) != len(want) {
+		t.Fatalf("got bell calls %+v, want %+v", host.BellCalls, want)
+	}
+	for i, w := range want {
+		if host.BellCalls[i] != w {
+			t.Errorf("bell %d = %+v, want %+v", i, host.BellCalls[i], w)
+		}
+	}
+}
+
+func TestBellRejectsUnknownMode(t *testing.T) {
+	engine, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	err := engine.DoString("test", `rune.bell("loud")`)
+	if err == nil || !strings.Contains(err.Error(), "rune.bell: mode must be") {
+		t.Errorf("expected mode error, got %v", err)
+	}
+}
+
+func TestTriggerBellOption(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	err := engine.DoString("test", `
+		rune.trigger.regex("^\\w+ tells you: ", nil, { bell = true })
+		rune.trigger.starts("BEGIN", nil, { bell = true, span = { to = "^END" } })
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"Bob tells you: hi", "You say hi", "BEGIN", "middle", "END"} {
+		engine.OnOutput(text.NewLine(line))
+	}
+	if len(host.BellCalls) != 2 {
+		t.Errorf("got %d bells, want 2 (tell, then span once)", len(host.BellCalls))
+	}
+}
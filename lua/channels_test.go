@@ -0,0 +1,66 @@
+package lua
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mmcdole/rune/text"
+)
+
+// TestChannelsRouteToPanes verifies rune.channel copies or moves
+// matching lines into panes with the channel's formatting, and that
+// the default channel gets only the lines no channel claimed.
+func TestChannelsRouteToPanes(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	setup := `
+		rune.channel("chat", "tells you:")
+		rune.channel("chat", "^\\[Gossip\\]")
+		rune.channel("combat", "^You (hit|miss)", { gag = true, color = "red", timestamp = "T" })
+		rune.channels.default("misc")
+	`
+	if err := engine.DoString("setup", setup); err != nil {
+		t.Fatal(err)
+	}
+
+	shown := map[string]bool{}
+	for _, line := range []string{
+		"Bob tells you: hi",
+		"[Gossip] Ann: lag?",
+		"You hit the rat.",
+		"The rat squeaks.",
+	} {
+		_, show := engine.OnOutput(text.NewLine(line))
+		shown[line] = show
+	}
+
+	if !shown["Bob tells you: hi"] || shown["You hit the rat."] {
+		t.Errorf("shown = %v; want chat copied, combat moved", shown)
+	}
+
+	var writes []string
+	for _, c := range host.PaneCalls {
+		if c.Op == "write" {
+			writes = append(writes, c.Name+"|"+c.Data)
+		}
+	}
+	want := []string{
+		"chat|Bob tells you: hi",
+		"chat|[Gossip] Ann: lag?",
+		"combat|\x1b[90mT\x1b[0m \x1b[31mYou hit the rat.\x1b[0m",
+		"misc|The rat squeaks.",
+	}
+	if strings.Join(writes, "\n") != strings.Join(want, "\n") {
+		t.Errorf("pane writes:\n%s\nwant:\n%s", strings.Join(writes, "\n"), strings.Join(want, "\n"))
+	}
+
+	assertLua(t, engine, `
+		local list = rune.channels.list()
+		assert(#list == 3, "want chat, combat, misc")
+		assert(#list[1].patterns == 2 and list[2].gag and list[3].default)
+		assert(rune.channels.remove("chat"))
+		assert(rune.trigger.count() == 1, "chat triggers not removed")
+		assert(not pcall(rune.channel, "x", "y", { color = "nosuchcolor" }))
+	`)
+}
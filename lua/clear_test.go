@@ -0,0 +1,70 @@
+package lua
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClearHookPolicy(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	// Default: the clear is stripped and nothing happens.
+	engine.CallHook("clear", "\x1b[2J")
+	if host.ClearScreenCalls != 0 {
+		t.Fatalf("default policy cleared the screen")
+	}
+
+	err := engine.DoString("config", `rune.config.clear = "screen"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.CallHook("clear", "\x1b[2J")
+	if host.ClearScreenCalls != 1 {
+		t.Fatalf("screen policy: %d clears, want 1", host.ClearScreenCalls)
+	}
+
+	// A world entry overrides the config while connected to it.
+	err = engine.DoString("world", `rune.world.add("plain", "mud.example.com:4000", { clear = "strip" })`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.UpdateState(ClientState{Connected: true, Address: "mud.example.com:4000"})
+	engine.CallHook("clear", "\x1b[2J")
+	if host.ClearScreenCalls != 1 {
+		t.Errorf("world clear = \"strip\" should override the config")
+	}
+}
+
+// TestUserClearModes verifies ctrl+l follows rune.config.clear_key:
+// a dated divider by default, a screen clear, or a hard clear.
+func TestUserClearModes(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+	host.Term = Terminal{Width: 40}
+	host.DrainPrintCalls()
+
+	engine.HandleKeyBind("ctrl+l")
+	prints := host.DrainPrintCalls()
+	if len(prints) != 1 || !strings.Contains(prints[0], "──") {
+		t.Fatalf("divider: prints = %q", prints)
+	}
+	if host.ClearScreenCalls != 0 || host.ClearScrollbackCalls != 0 {
+		t.Fatalf("divider cleared output")
+	}
+
+	assertLua(t, engine, `rune.config.clear_key = "hard"`)
+	engine.HandleKeyBind("ctrl+l")
+	if host.ClearScrollbackCalls != 1 {
+		t.Errorf("hard: %d scrollback clears, want 1", host.ClearScrollbackCalls)
+	}
+
+	assertLua(t, engine, `rune.ui.clear("screen")`)
+	if host.ClearScreenCalls != 1 {
+		t.Errorf("screen: %d clears, want 1", host.ClearScreenCalls)
+	}
+
+	if err := engine.DoString("bad", `rune.ui.clear("wipe")`); err == nil {
+		t.Error("unknown mode should raise")
+	}
+}
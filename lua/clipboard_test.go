@@ -1,6 +1,7 @@
 package lua
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/mmcdole/rune/text"
@@ -60,3 +61,42 @@ func TestClipboardSetReachesHost(t *testing.T) {
 		t.Errorf("got clipboard calls %q, want [\"note contents\"]", host.ClipboardCalls)
 	}
 }
+
+func TestCopyModeBindStartsCopyMode(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	engine.HandleKeyBind("alt+c")
+	if host.CopyModeCalls != 1 {
+		t.Errorf("alt+c started copy mode %d times, want 1", host.CopyModeCalls)
+	}
+}
+
+func TestCopyHookSetsClipboard(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	engine.CallHook("copy", "a room\nwith exits")
+	if len(host.ClipboardCalls) != 1 || host.ClipboardCalls[0] != "a room\nwith exits" {
+		t.Errorf("got clipboard calls %q", host.ClipboardCalls)
+	}
+	if last := host.PrintCalls[len(host.PrintCalls)-1]; !strings.Contains(last, "2 lines copied") {
+		t.Errorf("missing copy notice, last print %q", last)
+	}
+}
+
+func TestCopyHookPrintsWithoutClipboard(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("test", `rune.config.clipboard = false`); err != nil {
+		t.Fatal(err)
+	}
+	engine.CallHook("copy", "a room")
+	if len(host.ClipboardCalls) != 0 {
+		t.Errorf("clipboard written with clipboard disabled: %q", host.ClipboardCalls)
+	}
+	if last := host.PrintCalls[len(host.PrintCalls)-1]; last != "a room" {
+		t.Errorf("selection not printed, last print %q", last)
+	}
+}
@@ -85,3 +85,90 @@ func TestErrorTagIsRed(t *testing.T) {
 		}
 	}
 }
+
+// TestCommandArgSpecs verifies opts.args: words and rest-of-line split
+// into named fields, arity mismatches echo the generated usage without
+// running the handler, and /help <name> prints the same usage.
+func TestCommandArgSpecs(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("setup", `
+		calls = {}
+		rune.command.add("tell", function(args, raw)
+			calls[#calls + 1] = args.who .. "|" .. tostring(args.message) .. "|" .. raw
+		end, "Send a tell", { args = { "who", "message...?" } })
+		rune.command.add("pair", function(args)
+			calls[#calls + 1] = args.a .. "+" .. args.b
+		end, nil, { args = { "a", "b" } })
+	`); err != nil {
+		t.Fatal(err)
+	}
+	host.DrainPrintCalls()
+
+	engine.OnInput("/tell bob  hello  there ")
+	engine.OnInput("/tell bob")
+	engine.OnInput("/pair x y")
+	engine.OnInput("/pair x")
+	engine.OnInput("/pair x y z")
+	assertLua(t, engine, `assert(#calls == 3, #calls)`)
+	assertLua(t, engine, `assert(calls[1] == "bob|hello  there |bob  hello  there ", calls[1])`)
+	assertLua(t, engine, `assert(calls[2] == "bob|nil|bob", calls[2])`)
+	assertLua(t, engine, `assert(calls[3] == "x+y", calls[3])`)
+
+	printed := host.DrainPrintCalls()
+	if len(printed) != 2 || printed[0] != "[Usage] /pair <a> <b>" || printed[1] != printed[0] {
+		t.Errorf("arity mismatches printed %q, want the usage twice", printed)
+	}
+
+	engine.OnInput("/help /tell")
+	if help := strings.Join(host.DrainPrintCalls(), "\n"); !strings.Contains(help, "/tell <who> [message...]") ||
+		!strings.Contains(help, "Send a tell") {
+		t.Errorf("/help tell = %q", help)
+	}
+
+	for _, spec := range []string{`{ "rest...", "word" }`, `{ "opt?", "req" }`, `{ "bad name" }`, `"who"`} {
+		err := engine.DoString("bad", `rune.command.add("bad", function() end, nil, { args = `+spec+` })`)
+		if err == nil {
+			t.Errorf("args = %s: expected an error", spec)
+		}
+	}
+}
+
+// TestCommandAbbreviation verifies a unique prefix runs its command,
+// an exact name beats a longer one it prefixes, an ambiguous prefix is
+// reported without running anything, and abbrev(false) turns matching
+// off.
+func TestCommandAbbreviation(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("setup", `
+		calls = {}
+		for _, name in ipairs({ "zap", "zapall", "zorch" }) do
+			rune.command.add(name, function(args)
+				calls[#calls + 1] = name .. ":" .. args
+			end)
+		end
+	`); err != nil {
+		t.Fatal(err)
+	}
+	host.DrainPrintCalls()
+
+	engine.OnInput("/zor now")
+	engine.OnInput("/zap")
+	engine.OnInput("/zapa")
+	assertLua(t, engine, `assert(table.concat(calls, ",") == "zorch:now,zap:,zapall:", table.concat(calls, ","))`)
+
+	engine.OnInput("/z")
+	if printed := strings.Join(host.DrainPrintCalls(), "\n"); !strings.Contains(printed, "ambiguous: /zap, /zapall, /zorch") {
+		t.Errorf("ambiguous prefix printed %q", printed)
+	}
+
+	assertLua(t, engine, `assert(rune.command.abbrev(false) == false)`)
+	engine.OnInput("/zor")
+	if printed := strings.Join(host.DrainPrintCalls(), "\n"); !strings.Contains(printed, "Unknown command: /zor") {
+		t.Errorf("with abbrev off, /zor printed %q", printed)
+	}
+	assertLua(t, engine, `assert(#calls == 3)`)
+}
@@ -4,7 +4,53 @@
 -- which the telnet TTYPE/MNES responders also report) - data, not API.
 
 rune.config = {
-    delimiter = ";"
+    delimiter = ";",
+    -- Open bare http(s) URLs on click, not just OSC 8 hyperlinks
+    autolink = true,
+    -- How many recent bare URLs from server output rune.link.recent()
+    -- and the alt+u picker keep; 0 stops collecting
+    urls = 50,
+    -- Copy-mode selections go to the clipboard (OSC 52); set false
+    -- when the terminal lacks it to print selections instead
+    clipboard = true,
+    -- What rune.bell() does by default: "audible" (terminal bell),
+    -- "visual" (flash the bars), "both", or "none"
+    bell = "both",
+    -- What a multi-line paste does: "compose" (edit it in the verbatim
+    -- composer, sent line by line as typed), "join" (join the lines with
+    -- the delimiter into one command line, aliases and all), or
+    -- "editor" (open the draft in $EDITOR first)
+    paste = "compose",
+    -- What a server clear-screen sequence does: "strip" (ignore it) or
+    -- "screen" (scroll the output viewport clear); a world's `clear`
+    -- field overrides this for that connection
+    clear = "strip",
+    -- What ctrl+l (rune.ui.clear) does: "divider" (draw a rule across
+    -- the output, keeping everything for scrollback and search),
+    -- "screen" (scroll the output viewport clear), or "hard" (drop all
+    -- output, scrollback included)
+    clear_key = "divider",
+    -- The rule drawn across the output (and written to an open log)
+    -- when a connection comes up or drops: {what} is "Connected to
+    -- <address>" or "Disconnected (<reason>)", {time} the local time,
+    -- and the rest of the width fills with ─. false prints one-line
+    -- [System] notices instead
+    banner = "──── {what} ── {time} ",
+    -- Seconds between latency probes (GMCP Core.Ping) while GMCP is
+    -- up, feeding rune.net.stats().latency_ms; 0 disables
+    ping = 30,
+    -- Seconds between commands when a macro plays back (rune.macro);
+    -- 0 sends them all at once
+    macro_delay = 0.5,
+    -- Programs rune.exec may run, by name as passed to it ("fortune",
+    -- "/usr/bin/say"); empty allows none
+    exec_allow = {},
+    -- Longest server line in bytes; one running longer without a
+    -- newline is split into several. Read on connect; 0 never splits
+    max_line = 65536,
+    -- Accept MXP when the server offers it: <send> and <a> tags
+    -- become clickable links, other tags are stripped. Read on connect
+    mxp = false
 }
 
 rune.debug = false
@@ -65,26 +111,61 @@ function rune.caller_source(level)
 end
 
 -- Line objects
--- Server output arrives as objects with :raw() and :clean() methods
--- (raw keeps ANSI codes, clean strips them). rune.line.new builds a
--- compatible object from plain text - used when a handler rewrites a
--- line so the rewritten text flows to the next handler, and by /test.
+-- Server output arrives as line objects (Go userdata): :raw() keeps
+-- ANSI codes, :clean() (alias :text()) strips them. Mutators change
+-- the object in place so a chain of handlers composes: :replace(s)
+-- swaps the text, :color(pattern, color) highlights matches, :gag()
+-- hides the line once the current handler returns. :origin() says
+-- where it came from ("server", "echo", "script"). rune.line.new builds
+-- one from plain text - used by /test and synthetic lines.
 rune.line = {}
 
-function rune.line.new(raw)
-    local clean = nil
-    return {
-        raw = function() return raw end,
-        clean = function()
-            if clean == nil then
-                clean = rune._strip_ansi(raw)
-            end
-            return clean
-        end,
-    }
+function rune.line.new(raw, origin)
+    return rune._line.new(raw, origin)
 end
 
--- Substitute %1..%N capture references in a template string.
+-- INTERNAL: the set of origins spec names - one origin, or a list of
+-- them - for options that filter lines by origin. Returns nil and a
+-- message for anything else; the option's owner raises it.
+local line_origins = { server = true, echo = true, script = true }
+function rune.line._origins(spec)
+    local list = type(spec) == "table" and spec or { spec }
+    local set = {}
+    for _, origin in ipairs(list) do
+        if not line_origins[origin] then
+            return nil, 'origin must be "server", "echo", "script", or a list of them'
+        end
+        set[origin] = true
+    end
+    if next(set) == nil then
+        return nil, "origin list is empty"
+    end
+    return set
+end
+
+-- line:color(pattern, color, opts?): Wrap every match of the Go regex
+-- pattern in the line's clean text with a color spec (as
+-- rune.text.color takes), keeping the server's colors around it.
+-- opts.whole_word wraps the pattern in \b, so "or" skips "sword".
+-- Returns the line. Unknown colors raise; an invalid pattern is
+-- reported once and leaves the line unchanged.
+function rune._line.methods.color(line, pattern, color, opts)
+    local ok, on = pcall(rune.text.color, color)
+    if not ok then
+        error("line:color: unknown color " .. tostring(color), 2)
+    end
+    if opts and opts.whole_word then
+        pattern = "\\b(?:" .. pattern .. ")\\b"
+    end
+    local re = rune.regex._compiled(pattern)
+    if re then
+        rune._line.highlight(line, re, on)
+    end
+    return line
+end
+
+-- Substitute %1..%N capture references in a template string; %0 is
+-- the whole match (rune.regex.match keeps it at matches[0]).
 -- Single-pass with greedy digits, so %10 means capture 10, not
 -- capture 1 followed by "0". Unknown indices stay literal. The
 -- function replacement inserts captured text literally, so "%" in
@@ -97,13 +178,33 @@ end
 
 -- Core function wrappers around Go primitives (rune._*)
 
--- Send raw text to the server, bypassing alias processing.
--- Echoes send failures (e.g. not connected) rather than raising.
--- Returns true, or nil + error message.
+-- Send raw text to the server, bypassing alias processing. The line
+-- passes the "send" hook first, so a handler sees each network send
+-- exactly once - typed, aliased, queued, or sent by a script - and may
+-- rewrite or cancel it. Lines a send handler sends itself skip the
+-- hook, so a handler cannot loop. Send failures (e.g. not connected)
+-- are echoed rather than raised.
+-- Returns true, false when a handler cancelled the line, or nil +
+-- error message when the send failed.
+local in_send_hook = false
 function rune.send_raw(text)
+    if rune.hooks and not in_send_hook then
+        in_send_hook = true
+        local ok, result, keep = pcall(rune.hooks.call, "send", text)
+        in_send_hook = false
+        if not ok then
+            error(result, 0)
+        end
+        if not keep then
+            return false
+        end
+        text = result
+    end
     local ok, err = rune._send_raw(text)
     if not ok then
         rune.echo(rune.style.red("[Error]") .. " " .. tostring(err))
+    elseif rune.idles then
+        rune.idles._activity()
     end
     return ok, err
 end
@@ -116,7 +217,42 @@ function rune.quit()
     rune._quit()
 end
 
+-- Desktop notification (for tells while tabbed away). Rate-limited by
+-- the client; a missing backend or a dropped notification is warned
+-- about once per kind, not on every call. Returns true, or nil + error.
+local notify_warned = {}
+function rune.notify(title, body)
+    local ok, err = rune._notify(tostring(title), body ~= nil and tostring(body) or "")
+    if not ok and not notify_warned[err] then
+        notify_warned[err] = true
+        rune.echo(rune.style.yellow("[Notify]") .. " " .. err)
+    end
+    return ok, err
+end
+
+-- Play a short audio file (relative paths resolve against the config
+-- directory) with the OS player. One sound at a time: a call while one
+-- plays is dropped. Failures are warned about once per kind, like
+-- rune.notify. Returns true, or nil + error.
+local sound_warned = {}
+function rune.sound(path)
+    if type(path) ~= "string" or path == "" then
+        error("rune.sound: expected a file path", 2)
+    end
+    local ok, err = rune._sound(path)
+    if not ok and not sound_warned[err] then
+        sound_warned[err] = true
+        rune.echo(rune.style.yellow("[Sound]") .. " " .. err)
+    end
+    return ok, err
+end
+
 function rune.connect(address)
+    local max_line = tonumber(rune.config.max_line)
+    if max_line then
+        rune._net.max_line(math.max(math.floor(max_line), 0))
+    end
+    rune._net.mxp(rune.config.mxp == true)
     rune._connect(address)
 end
 
@@ -128,6 +264,19 @@ function rune.reload()
     rune._reload()
 end
 
+-- rune.autoreload(on?) -> bool: reload when init.lua, a file loaded
+-- with rune.load, or a .lua file beside one changes on disk. The
+-- setting is Go-owned, so it stays on across the reloads it causes.
+function rune.autoreload(on)
+    if on == nil then
+        return rune._autoreload()
+    end
+    if type(on) ~= "boolean" then
+        error("rune.autoreload: expected a boolean", 2)
+    end
+    return rune._autoreload(on)
+end
+
 -- Load a Lua script. Returns true, or nil + error message.
 function rune.load(path)
     return rune._load(path)
@@ -136,9 +285,36 @@ end
 -- Client state (read-only view)
 -- Go pushes updates into rune._state; rune.state is a read-only proxy
 -- so scripts cannot corrupt Go-owned state. Fields: connected,
--- address, scroll_mode, scroll_lines, width, height.
+-- address, connection, scroll_mode, scroll_lines, scroll_percent,
+-- width, height, input_dropped, disconnect_reason, connected_at.
+-- uptime (seconds connected, 0 while disconnected) and uptime_text
+-- ("01:23:45", "" while disconnected) are derived on each read, so a
+-- bar showing them ticks with the once-a-second bar refresh.
+local function uptime()
+    local at = rune._state.connected_at
+    if not at or at == 0 then
+        return 0
+    end
+    return math.max(os.time() - at, 0)
+end
+
+local derived_state = {
+    uptime = uptime,
+    uptime_text = function()
+        if rune._state.connected_at == 0 then
+            return ""
+        end
+        local s = uptime()
+        return string.format("%02d:%02d:%02d", math.floor(s / 3600), math.floor(s % 3600 / 60), s % 60)
+    end,
+}
+
 rune.state = setmetatable({}, {
     __index = function(_, key)
+        local derive = derived_state[key]
+        if derive then
+            return derive()
+        end
         return rune._state[key]
     end,
     __newindex = function()
@@ -146,6 +322,18 @@ rune.state = setmetatable({}, {
     end,
 })
 
+-- Terminal capabilities (read-only view)
+-- Fields: colors (0, 16, 256, or 16777216 for truecolor), mouse, and
+-- width/height in cells. Read live from Go, so a resize shows at once.
+rune.term = setmetatable({}, {
+    __index = function(_, key)
+        return rune._ui.term()[key]
+    end,
+    __newindex = function()
+        error("rune.term is read-only (the client detects it)", 2)
+    end,
+})
+
 -- Session store
 -- A small Go-owned string store scoped to this client session: it
 -- survives /reload (the Lua VM is torn down and rebuilt) but not
@@ -191,6 +379,42 @@ function rune.store.delete(key)
     return rune._store.delete(key)
 end
 
+-- Secret store
+-- Credentials kept in secrets.json in rune.config_dir, apart from
+-- init.lua and store.json: written owner-only (0600) and base64-
+-- encoded, which keeps a password out of a casual glance but is not
+-- encryption. The core never echoes a value; /secret set reads one
+-- from the input line without showing or remembering it.
+
+rune.secret = {}
+
+-- Returns true, or nil + error message. Values are strings. Any
+-- history entry that is the value itself is dropped.
+function rune.secret.set(name, value)
+    if type(name) ~= "string" or name == "" then
+        error("rune.secret.set: name must be a non-empty string", 2)
+    end
+    if type(value) ~= "string" or value == "" then
+        error("rune.secret.set: value must be a non-empty string", 2)
+    end
+    return rune._secret.set(name, value)
+end
+
+-- Returns the stored string, or nil if unset.
+function rune.secret.get(name)
+    return rune._secret.get(name)
+end
+
+-- Returns true, or nil + error message.
+function rune.secret.delete(name)
+    return rune._secret.delete(name)
+end
+
+-- Returns a sorted array of names (never the values).
+function rune.secret.list()
+    return rune._secret.names()
+end
+
 -- Input history (Go owns the ring buffer so it survives reloads)
 
 rune.history = {}
@@ -203,6 +427,36 @@ function rune.history.add(cmd)
     rune._history.add(cmd)
 end
 
+-- How much history is kept and which repeats are dropped: "adjacent"
+-- (the same entry twice in a row), "global" (any earlier copy, so the
+-- entry moves to the newest position), or "none". Up/Down and Ctrl+R
+-- read the same store, so both honor it. Pushed to Go at load, so
+-- /reload resets a policy the scripts no longer set.
+local history_policy = { size = 10000, dedupe = "adjacent" }
+local dedupe_modes = { adjacent = true, global = true, none = true }
+
+-- rune.history.config({size?, dedupe?}) -> {size, dedupe}
+function rune.history.config(opts)
+    if opts ~= nil then
+        if type(opts) ~= "table" then
+            error("rune.history.config: expected a table", 2)
+        end
+        local size = opts.size
+        if size ~= nil and (type(size) ~= "number" or size < 1 or size ~= math.floor(size)) then
+            error("rune.history.config: size must be a positive integer", 2)
+        end
+        if opts.dedupe ~= nil and not dedupe_modes[opts.dedupe] then
+            error("rune.history.config: dedupe must be \"adjacent\", \"global\" or \"none\"", 2)
+        end
+        history_policy.size = size or history_policy.size
+        history_policy.dedupe = opts.dedupe or history_policy.dedupe
+        rune._history.policy(history_policy.size, history_policy.dedupe)
+    end
+    return { size = history_policy.size, dedupe = history_policy.dedupe }
+end
+
+rune._history.policy(history_policy.size, history_policy.dedupe)
+
 -- UI namespace
 -- rune.ui.bar is added by 35_bars.lua, which owns the bar registry.
 
@@ -214,7 +468,7 @@ function rune.ui.layout(config)
     rune._ui.layout(config)
 end
 
--- Force an immediate bar refresh instead of waiting for the ticker.
+-- Re-render every bar now instead of waiting for the bar tick (35_bars.lua).
 function rune.ui.refresh_bars()
     rune._ui.refresh_bars()
 end
@@ -228,6 +482,9 @@ rune.ui.picker = {}
 --   on_select = function(value) end,
 --   mode = "inline",                -- optional: "inline" or "modal" (default)
 --   match_description = true,       -- optional: fuzzy-match descriptions too
+--   max_results = 50,               -- optional: keep the best N matches per query
+--   query = "look",                 -- optional (modal): initial search text
+--   match = "prefix",               -- optional (modal): "fuzzy" or "prefix"; Ctrl+R toggles
 -- }
 function rune.ui.picker.show(opts)
     rune._ui.picker_show(opts)
@@ -32,3 +32,79 @@ rune.style = {
     dim     = wrap(2),
     inverse = wrap(7),
 }
+
+-- Text helpers
+-- rune.text builds styled output from color specs instead of fixed
+-- helpers: a name ("red"), a bright variant ("bright_red"), or a
+-- 256-color index (0-255). rune.style covers the common cases;
+-- rune.text is for colors chosen at runtime (config, GMCP, themes).
+
+local ANSI_COLORS = {
+    black = 0, red = 1, green = 2, yellow = 3,
+    blue = 4, magenta = 5, cyan = 6, white = 7,
+}
+
+local ATTRIBUTES = { bold = "1", dim = "2", inverse = "7" }
+
+-- SGR parameter for a color spec, or nil when the spec is unknown.
+local function color_code(spec)
+    if type(spec) == "number" then
+        if spec ~= math.floor(spec) or spec < 0 or spec > 255 then
+            return nil
+        end
+        return "38;5;" .. spec
+    end
+    if type(spec) ~= "string" then
+        return nil
+    end
+    local name = spec:lower()
+    if name == "reset" then
+        return "0"
+    end
+    if name == "gray" or name == "grey" then
+        return "90"
+    end
+    if ATTRIBUTES[name] then
+        return ATTRIBUTES[name]
+    end
+    local base = name:match("^bright_(%a+)$")
+    if base then
+        return ANSI_COLORS[base] and tostring(90 + ANSI_COLORS[base])
+    end
+    if ANSI_COLORS[name] then
+        return tostring(30 + ANSI_COLORS[name])
+    end
+    local index = tonumber(name)
+    if index then
+        return color_code(index)
+    end
+    return nil
+end
+
+rune.text = {}
+
+-- Remove ANSI escape sequences (the same stripper triggers match with).
+function rune.text.strip(s)
+    return rune._strip_ansi(tostring(s))
+end
+
+-- The escape sequence that switches to a color: a name ("red",
+-- "gray"), a bright variant ("bright_red"), a 256-color index (0-255,
+-- as a number or numeric string), an attribute ("bold", "dim",
+-- "inverse"), or "reset". Unknown specs raise.
+function rune.text.color(spec)
+    local code = color_code(spec)
+    if not code then
+        error("rune.text.color: unknown color " .. tostring(spec), 2)
+    end
+    return "\027[" .. code .. "m"
+end
+
+-- Wrap s in a color and a trailing reset, like the rune.style helpers.
+function rune.text.wrap(s, spec)
+    local code = color_code(spec)
+    if not code then
+        error("rune.text.wrap: unknown color " .. tostring(spec), 2)
+    end
+    return "\027[" .. code .. "m" .. tostring(s) .. "\027[0m"
+end
@@ -0,0 +1,91 @@
+-- String Utilities
+-- The helpers scripts otherwise reimplement on top of gopher-lua's
+-- thin string library. Matching is plain text, never Lua patterns, so
+-- separators and prefixes like "." or "%" mean themselves. Width is
+-- measured in terminal cells by Go (rune._visible_width): ANSI codes
+-- are zero-width and wide runes take two cells, the same measure the
+-- UI renders with - so padded and truncated bar segments line up.
+
+rune.string = {}
+
+-- Split s on the plain separator sep, keeping empty fields
+-- ("a,,b" -> {"a", "", "b"}). Without sep, split on runs of
+-- whitespace and drop empty fields ("  look   north " -> {"look", "north"}).
+function rune.string.split(s, sep)
+    s = tostring(s)
+    local parts = {}
+    if sep == nil then
+        for word in s:gmatch("%S+") do
+            parts[#parts + 1] = word
+        end
+        return parts
+    end
+    if type(sep) ~= "string" or sep == "" then
+        error("rune.string.split: sep must be a non-empty string", 2)
+    end
+    local start = 1
+    while true do
+        local i, j = s:find(sep, start, true)
+        if not i then
+            parts[#parts + 1] = s:sub(start)
+            return parts
+        end
+        parts[#parts + 1] = s:sub(start, i - 1)
+        start = j + 1
+    end
+end
+
+-- Remove leading and trailing whitespace.
+function rune.string.trim(s)
+    return (tostring(s):gsub("^%s+", ""):gsub("%s+$", ""))
+end
+
+function rune.string.starts_with(s, prefix)
+    s, prefix = tostring(s), tostring(prefix)
+    return s:sub(1, #prefix) == prefix
+end
+
+function rune.string.ends_with(s, suffix)
+    s, suffix = tostring(s), tostring(suffix)
+    return suffix == "" or s:sub(-#suffix) == suffix
+end
+
+-- Display width of s in terminal cells (ANSI-aware).
+function rune.string.width(s)
+    return rune._visible_width(tostring(s))
+end
+
+-- Cut s to at most n display cells, ending with tail (default "...")
+-- when it cuts. Never splits a multibyte or wide character; text that
+-- already fits is returned unchanged.
+function rune.string.truncate(s, n, tail)
+    s = tostring(s)
+    if type(n) ~= "number" then
+        error("rune.string.truncate: width must be a number", 2)
+    end
+    return rune._truncate(s, n, tail or "...")
+end
+
+-- Pad s with spaces to a display width of n cells. align is "left"
+-- (default: text left, padding right), "right", or "center". Text
+-- already n cells or wider is returned unchanged, never truncated.
+function rune.string.pad(s, n, align)
+    s = tostring(s)
+    if type(n) ~= "number" then
+        error("rune.string.pad: width must be a number", 2)
+    end
+    local gap = n - rune._visible_width(s)
+    if gap <= 0 then
+        return s
+    end
+    align = align or "left"
+    if align == "left" then
+        return s .. string.rep(" ", gap)
+    elseif align == "right" then
+        return string.rep(" ", gap) .. s
+    elseif align == "center" then
+        local left = math.floor(gap / 2)
+        return string.rep(" ", left) .. s .. string.rep(" ", gap - left)
+    end
+    error("rune.string.pad: align must be \"left\", \"right\", or \"center\"", 2)
+end
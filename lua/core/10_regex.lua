@@ -1,10 +1,13 @@
 -- Regex API
+-- requires: init, style
 -- Cached pattern matching using Go's regexp engine.
 --
 -- API:
 --   rune.regex.compile(pattern)     -- Compile regex, returns userdata or nil+error
 --   rune.regex.validate(pattern)    -- Check a pattern, returns true or nil+error
 --   rune.regex.match(pattern, text) -- Match with caching, returns captures array or nil
+--   rune.regex.gmatch(pattern, text) -- Every match, as an array of captures arrays
+--   rune.regex.gsub(pattern, text, replacement) -- Replace every match ($1 refs); text, count
 
 rune.regex = {}
 
@@ -53,9 +56,9 @@ function rune.regex.validate(pattern)
     return true
 end
 
--- Match pattern against text, return captures array or nil
--- Caches compiled patterns for performance
-function rune.regex.match(pattern, text)
+-- The cached Regex userdata for pattern, or nil when it is invalid
+-- (reported once). Shared by rune.regex.match and line:color.
+function rune.regex._compiled(pattern)
     local entry = lookup(pattern)
     if entry.err then
         if not entry.reported then
@@ -65,17 +68,60 @@ function rune.regex.match(pattern, text)
         end
         return nil
     end
+    return entry.re
+end
+
+-- re:match's result (whole match at 1) reshaped for scripts: the
+-- captures (index 2+) as the array, or empty if there are none; the
+-- whole match rides at [0], outside # and ipairs, for %0.
+local function captures_of(matches)
+    local captures = { [0] = matches[1] }
+    for i = 2, #matches do
+        captures[i - 1] = matches[i]
+    end
+    return captures
+end
+
+-- Match pattern against text, return captures array or nil
+-- Caches compiled patterns for performance
+function rune.regex.match(pattern, text)
+    local re = rune.regex._compiled(pattern)
+    if not re then
+        return nil
+    end
 
     -- Match and extract captures (skip index 1 which is full match)
-    local matches = entry.re:match(text)
+    local matches = re:match(text)
     if not matches then
         return nil
     end
 
-    -- Return captures only (index 2+), or empty table if no captures
-    local captures = {}
-    for i = 2, #matches do
-        captures[i - 1] = matches[i]
+    return captures_of(matches)
+end
+
+-- Every non-overlapping match of pattern in text, each shaped like
+-- rune.regex.match's result. Empty when nothing matches (or the
+-- pattern is invalid).
+function rune.regex.gmatch(pattern, text)
+    local re = rune.regex._compiled(pattern)
+    if not re then
+        return {}
     end
-    return captures
+    local all = re:gmatch(text)
+    for i, matches in ipairs(all) do
+        all[i] = captures_of(matches)
+    end
+    return all
+end
+
+-- Replace every match of pattern in text. The replacement may refer
+-- to groups as $1 or ${name} ($$ for a literal $). Returns the new
+-- text and the number of replacements; an invalid pattern returns
+-- text unchanged and 0.
+function rune.regex.gsub(pattern, text, replacement)
+    local re = rune.regex._compiled(pattern)
+    if not re then
+        return text, 0
+    end
+    return re:gsub(text, replacement)
 end
@@ -21,6 +21,8 @@
 --   name     -- opts.name, unique: adding a duplicate replaces the old
 --   group    -- opts.group, master-switch membership (25_groups.lua)
 --   once     -- opts.once, module removes the item after first fire
+--   hits     -- times the module reported a match (reg:hit)
+--   last_hit -- os.time() of the latest match, nil before the first
 --   _handle  -- back-reference to the handle
 --
 -- Handle API: :enable() :disable() :remove() :name() :group()
@@ -91,6 +93,7 @@ function Registry:add(data, opts)
     data.name = opts.name
     data.group = opts.group
     data.once = opts.once or false
+    data.hits = 0
 
     local handle = setmetatable({
         _data = data,
@@ -175,6 +178,27 @@ function Registry:remove(name)
     return false
 end
 
+-- Count a match: modules call this when an item matches, whatever
+-- its action then does.
+function Registry:hit(data)
+    data.hits = data.hits + 1
+    data.last_hit = os.time()
+end
+
+-- Match counters for an item given by name or handle:
+-- {hits = n, last_hit = seconds or nil}, or nil when there is none.
+function Registry:stats(key)
+    local handle = key
+    if type(key) == "string" then
+        handle = self.by_name[key]
+    end
+    local data = type(handle) == "table" and handle._data
+    if not data or data.removed or handle._registry ~= self then
+        return nil
+    end
+    return { hits = data.hits, last_hit = data.last_hit }
+end
+
 -- The sorted item list, for dispatch iteration. Do not mutate;
 -- removal during iteration must go through handles after the loop.
 function Registry:items()
@@ -1,4 +1,5 @@
 -- Hook Registry System
+-- requires: init, registry
 -- Allows multiple handlers per event with priority ordering.
 -- Built on rune.registry (15_registry.lua) for handles, names,
 -- groups, and priorities.
@@ -17,22 +18,40 @@
 --   "input"        -- User input: (text, context); return false to consume
 --   "output"       -- Server output line object (false gags, string rewrites)
 --   "prompt"       -- Server prompt line object (false gags, string rewrites)
+--   "print"        -- Line object of script output (rune.echo), origin
+--                     "script" (false hides, string rewrites)
 --   "echo"         -- Local echo of typed input, plain string (false hides,
 --                     string rewrites; core handler adds the "> " styling)
+--   "compose"      -- Text finished with rune.input.compose: (text, prefix);
+--                     false cancels the send, string rewrites
+--   "send"         -- Each line about to go to the server, after aliases
+--                     and splitting (false cancels it, string rewrites)
 -- Events (notifications):
 --   "ready"        -- Boot complete
 --   "connecting"   -- Dial started
 --   "connected"    -- After connection established
+--   "reconnected"  -- After "connected", when the address matches the
+--                     last connection; script state was kept
 --   "disconnecting"-- Disconnect requested
---   "disconnected" -- After disconnection
---   "reloading"    -- Before script reload
+--   "disconnected" -- After disconnection: (reason, error); reason is
+--                     "user", "closed", "reset", "timeout" or "error"
+--   "reloading"    -- Before script reload (path of the changed script
+--                     under rune.autoreload, "" for /reload)
 --   "reloaded"     -- After script reload
 --   "loaded"       -- After a script file loads
 --   "error"        -- On system error
 --   "input_changed"-- Input line content changed while typing
+--   "throttled"    -- Server lines rune.ui.throttle kept off screen in
+--                     the last second: (count), as a string
+--   "paste"        -- Multi-line paste landed in the composer (text);
+--                     the core handler applies rune.config.paste
+--   "clear"        -- Server line tried to clear the screen (raw text);
+--                     the core handler applies rune.config.clear
 --   "gmcp"         -- Every GMCP message: (package, data, raw);
 --                     catch-all alongside rune.gmcp.on (70_gmcp.lua)
 --   "gmcp_enabled" -- GMCP negotiated; the core handler sends Core.Hello
+--   "telnet_timeout" -- A WILL/DO went unanswered: (option, command);
+--                     the core handler prints a diagnostic (71_telnet.lua)
 
 -- Per-event dispatch index, maintained alongside the registry so
 -- rune.hooks.call doesn't scan unrelated events on every line.
@@ -140,7 +159,7 @@ local function input_context(mode)
 end
 
 -- Call all handlers for an event
--- For output/prompt: chains modifications (each handler sees the
+-- For output/prompt/print: chains modifications (each handler sees the
 --   previous handler's rewrite), false gags
 -- For echo: like output/prompt, but the argument is a plain string
 --   (the text the user typed), not a line object
@@ -155,10 +174,10 @@ function rune.hooks.call(event, ...)
     local live = by_event[event]
     if not live or #live == 0 then
         -- No handlers registered
-        if event == "output" or event == "prompt" then
+        if event == "output" or event == "prompt" or event == "print" then
             local line = select(1, ...)
             return line:raw(), true
-        elseif event == "echo" then
+        elseif event == "echo" or event == "compose" or event == "send" then
             return select(1, ...), true
         elseif event == "input" then
             return true
@@ -174,34 +193,38 @@ function rune.hooks.call(event, ...)
         handlers[i] = entry
     end
 
-    if event == "output" or event == "prompt" then
-        -- Output/prompt receive a Line object (:raw() and :clean()).
-        -- True chaining: a handler returning a string replaces the line
-        -- for every subsequent handler, so rewrites compose in priority
-        -- order instead of last-writer-wins on the original text.
+    if event == "output" or event == "prompt" or event == "print" then
+        -- Output/prompt/print receive a Line object (:raw() and :clean()).
+        -- True chaining: every handler sees the same object, so a
+        -- returned string (same as line:replace) and the in-place
+        -- mutators compose in priority order instead of
+        -- last-writer-wins on the original text. A gag - false or
+        -- line:gag() - takes effect when the handler returns.
         local line = select(1, ...)
 
         for _, entry in ipairs(handlers) do
             if registry:active(entry) then
                 local result = run_handler(entry, line)
-                if result == false then
+                if result == false or line:gagged() then
                     return "", false  -- gagged
                 elseif type(result) == "string" then
-                    line = rune.line.new(result)
+                    line:replace(result)
                 end
-                -- nil = pass through unchanged
+                -- nil = pass through (with any in-place mutations)
             end
         end
 
         return line:raw(), true
 
-    elseif event == "echo" then
-        -- Echo receives the typed text as a plain string. Rewrites
-        -- chain like output/prompt; false hides the echo entirely.
-        local text = select(1, ...)
+    elseif event == "echo" or event == "compose" or event == "send" then
+        -- Echo receives the typed text as a plain string, compose the
+        -- composed text and its prefix, send the outgoing line.
+        -- Rewrites chain like output/prompt; false hides the echo or
+        -- cancels the send.
+        local text, extra = ...
         for _, entry in ipairs(handlers) do
             if registry:active(entry) then
-                local result = run_handler(entry, text)
+                local result = run_handler(entry, text, extra)
                 if result == false then
                     return "", false
                 elseif type(result) == "string" then
@@ -1,4 +1,5 @@
 -- Group System (Control Only)
+-- requires: hooks
 -- Manages the master enable/disable state for groups.
 -- Item deletion is handled by each module (alias, trigger, timer, hooks).
 --
@@ -42,7 +43,7 @@ function rune.group.list()
     local seen = {}
 
     local modules = {
-        rune.alias, rune.trigger, rune.timer, rune.hooks,
+        rune.alias, rune.trigger, rune.timer, rune.idles, rune.hooks,
         rune.binds, rune.bars, rune.command,
     }
     for _, mod in ipairs(modules) do
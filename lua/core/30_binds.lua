@@ -1,4 +1,5 @@
 -- Key Binding System
+-- requires: init, registry
 -- Built on rune.registry (15_registry.lua), so binds get the same
 -- names, groups, source attribution, quarantine, and listings as
 -- hooks/triggers/aliases/timers.
@@ -1,19 +1,39 @@
 -- Bar Renderer System
+-- requires: init, registry
 -- Built on rune.registry (15_registry.lua). Renderers get the same
 -- quarantine as every other callback: three consecutive failures
--- disable the bar instead of erroring 4x/second forever.
+-- disable the bar instead of erroring on every refresh forever.
 --
 -- API:
---   rune.ui.bar(name, render_fn, opts?) -- Register a bar renderer
---   rune.bars.list()                    -- For /bars
+--   rune.ui.bar(name, render_fn, opts?)         -- Register a bar renderer
+--   rune.ui.bar_dirty(name?)                    -- Re-render a bar (nil: all)
+--   rune.ui.segment(bar, name, text, opts?)     -- Set one named piece of a bar
+--   rune.ui.segments(bar, opts)                 -- Configure a segment bar
+--   rune.bars.list()                            -- For /bars
 --
 -- render_fn receives the terminal width and returns a string or a
--- table {left, center, right}. Go calls rune.bars._render_all on its
--- tick and pushes the results to the UI; re-registering a name gives
--- the renderer a fresh start.
+-- table {left, center, right}; re-registering a name gives the
+-- renderer a fresh start. Rendering is coalesced: a renderer runs
+-- again only once its bar is marked dirty, and clean bars reuse their
+-- last result. Go calls rune.bars._render_all on its tick (at most 4x
+-- a second) when something is dirty, and with full = true - every
+-- renderer runs - when client state, the width, or GMCP data changed,
+-- and once a second regardless so clocks keep ticking.
+--
+-- Segments are the push-style alternative: scripts set named pieces
+-- as their data changes, and the bar is the pieces joined per side.
+-- An update marks its bar dirty; the join is rebuilt on the next
+-- tick. A registered renderer for the same bar name takes precedence
+-- over its segments.
 
 local by_bar = {} -- bar name -> data
 
+-- Ask Go for a render on its next tick. The set of bars changing
+-- (register, remove, enable, disable) needs one as much as new text.
+local function schedule()
+    rune._ui.bars_dirty()
+end
+
 local registry = rune.registry.new{
     kind = "bar",
     on_add = function(data)
@@ -24,11 +44,13 @@ local registry = rune.registry.new{
             old._handle:remove()
         end
         by_bar[data.bar] = data
+        schedule()
     end,
     on_remove = function(data)
         if by_bar[data.bar] == data then
             by_bar[data.bar] = nil
         end
+        schedule()
     end,
 }
 
@@ -41,23 +63,167 @@ function rune.ui.bar(name, render_fn, opts)
         bar = name,
         renderer = render_fn,
         source = rune.caller_source(1),
+        dirty = true,
     }, opts)
 end
 
--- INTERNAL: called by Go on the render tick.
--- Returns { [name] = string | {left, center, right} } for active bars.
-function rune.bars._render_all(width)
+-- Segment bars: bar name -> {
+--   separator = " ",
+--   segments  = { [name] = {text, side, order} },
+--   seq       = insertion counter (default order),
+--   dirty     = true when the cached join is stale,
+--   cached    = {left, center, right} from the last join,
+-- }
+local segment_bars = {}
+
+local SIDES = { left = true, center = true, right = true }
+
+local function segment_bar(bar)
+    local sb = segment_bars[bar]
+    if not sb then
+        sb = { separator = " ", segments = {}, seq = 0, dirty = true }
+        segment_bars[bar] = sb
+    end
+    return sb
+end
+
+-- Set (or with text = nil, remove) the segment `name` of `bar`.
+-- opts: { side = "left"|"center"|"right" (default "left"),
+--         order = number (default: first-set order) }.
+-- Side and order stick once set, so updates need only the text.
+function rune.ui.segment(bar, name, text, opts)
+    if type(bar) ~= "string" or type(name) ~= "string" then
+        error("rune.ui.segment: bar and name must be strings", 2)
+    end
+    if opts ~= nil and type(opts) ~= "table" then
+        error("rune.ui.segment: opts must be a table", 2)
+    end
+    local side = opts and opts.side
+    if side ~= nil and not SIDES[side] then
+        error('rune.ui.segment: side must be "left", "center", or "right"', 2)
+    end
+
+    local sb = segment_bar(bar)
+    if text == nil then
+        if sb.segments[name] then
+            sb.segments[name] = nil
+            sb.dirty = true
+            schedule()
+        end
+        return
+    end
+
+    local seg = sb.segments[name]
+    if not seg then
+        sb.seq = sb.seq + 1
+        seg = { side = "left", order = sb.seq }
+        sb.segments[name] = seg
+    end
+    text = tostring(text)
+    if side then
+        seg.side = side
+    end
+    if opts and opts.order ~= nil then
+        seg.order = opts.order
+    end
+    if seg.text ~= text or side or (opts and opts.order ~= nil) then
+        seg.text = text
+        sb.dirty = true
+        schedule()
+    end
+end
+
+-- Configure a segment bar. opts: { separator = string } - the text
+-- placed between adjacent segments on the same side (default " ").
+function rune.ui.segments(bar, opts)
+    if type(bar) ~= "string" or type(opts) ~= "table" then
+        error("rune.ui.segments: expected (bar, opts)", 2)
+    end
+    local sb = segment_bar(bar)
+    if opts.separator ~= nil then
+        sb.separator = tostring(opts.separator)
+        sb.dirty = true
+        schedule()
+    end
+end
+
+-- Mark a bar dirty so its renderer (or segment join) runs again on
+-- the next tick, for when the data it shows changed outside client
+-- state and GMCP - a trigger capture, a timer. nil marks every bar.
+function rune.ui.bar_dirty(name)
+    if name == nil then
+        for _, data in pairs(by_bar) do
+            data.dirty = true
+        end
+        for _, sb in pairs(segment_bars) do
+            sb.dirty = true
+        end
+    else
+        if by_bar[name] then
+            by_bar[name].dirty = true
+        end
+        if segment_bars[name] then
+            segment_bars[name].dirty = true
+        end
+    end
+    schedule()
+end
+
+-- Join a segment bar's pieces per side, in order.
+local function join_segments(sb)
+    local sides = { left = {}, center = {}, right = {} }
+    for name, seg in pairs(sb.segments) do
+        table.insert(sides[seg.side], { name = name, seg = seg })
+    end
+    local out = {}
+    for side, list in pairs(sides) do
+        table.sort(list, function(a, b)
+            if a.seg.order ~= b.seg.order then
+                return a.seg.order < b.seg.order
+            end
+            return a.name < b.name
+        end)
+        local parts = {}
+        for i, item in ipairs(list) do
+            parts[i] = item.seg.text
+        end
+        out[side] = table.concat(parts, sb.separator)
+    end
+    return out
+end
+
+-- INTERNAL: called by Go on the render tick. Runs the renderers of
+-- dirty bars (all of them when full) and reuses the last result of
+-- the rest; a renderer with no result yet - new, or failing - always
+-- runs. Returns { [name] = string | {left, center, right} } for
+-- active bars.
+function rune.bars._render_all(width, full)
     local out = {}
     -- Snapshot: a renderer that (re)registers bars must not perturb
     -- this render pass.
     for _, data in ipairs(registry:snapshot()) do
         if registry:active(data) then
-            local label = 'Bar "' .. data.bar .. '"' ..
-                (data.source and (" @" .. data.source) or "")
-            local ok, result = rune.guarded_call(label, data, data.renderer, width)
-            if ok and (type(result) == "string" or type(result) == "table") then
-                out[data.bar] = result
+            if full or data.dirty or data.cached == nil then
+                data.dirty = false
+                data.cached = nil
+                local label = 'Bar "' .. data.bar .. '"' ..
+                    (data.source and (" @" .. data.source) or "")
+                local ok, result = rune.guarded_call(label, data, data.renderer, width)
+                if ok and (type(result) == "string" or type(result) == "table") then
+                    data.cached = result
+                end
+            end
+            out[data.bar] = data.cached
+        end
+    end
+    for bar, sb in pairs(segment_bars) do
+        local renderer = by_bar[bar]
+        if not (renderer and registry:active(renderer)) and next(sb.segments) then
+            if sb.dirty or not sb.cached then
+                sb.cached = join_segments(sb)
+                sb.dirty = false
             end
+            out[bar] = sb.cached
         end
     end
     return out
@@ -65,10 +231,12 @@ end
 
 -- Management by name (registry name, not bar name)
 function rune.bars.disable(name)
+    schedule()
     return registry:disable(name)
 end
 
 function rune.bars.enable(name)
+    schedule()
     return registry:enable(name)
 end
 
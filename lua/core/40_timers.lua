@@ -1,4 +1,5 @@
 -- Timer System
+-- requires: init, registry
 -- Timers execute actions after a delay or repeatedly at intervals.
 -- Built on rune.registry (15_registry.lua).
 --
@@ -109,6 +110,23 @@ function rune.timer._fire(id)
     end
 end
 
+-- INTERNAL: a bare one-shot wake-up outside the registry, for core
+-- modules that keep their own schedules (42_idle.lua). Returns the
+-- id to pass to rune.timer._cancel.
+function rune.timer._after(seconds, callback)
+    local id = rune._timer.after(seconds)
+    pending[id] = function()
+        pending[id] = nil
+        callback()
+    end
+    return id
+end
+
+function rune.timer._cancel(id)
+    rune._timer.cancel(id)
+    pending[id] = nil
+end
+
 -- One-shot timer (fires once after delay)
 function rune.timer.after(seconds, action, opts)
     return create_timer(seconds, action, opts, false)
@@ -166,3 +184,36 @@ end
 function rune.timer.remove_group(group_name)
     return registry:remove_group(group_name)
 end
+
+-- Heartbeat
+-- A shared ticker that fires the "tick" hook with a running count, so
+-- periodic scripts can share one beat instead of each running its own
+-- rune.timer.every. Go owns the ticker, so the interval and pause
+-- survive /reload (default: every second).
+rune.tick = {}
+
+-- Get the heartbeat interval in seconds, or set it (keeps the pause).
+function rune.tick.interval(seconds)
+    local current, paused = rune._tick.status()
+    if seconds == nil then
+        return current
+    end
+    if type(seconds) ~= "number" or seconds <= 0 then
+        error("rune.tick.interval: seconds must be a positive number", 2)
+    end
+    rune._tick.set(seconds, paused)
+end
+
+-- Stop firing "tick" until resumed.
+function rune.tick.pause()
+    rune._tick.set(rune._tick.status(), true)
+end
+
+function rune.tick.resume()
+    rune._tick.set(rune._tick.status(), false)
+end
+
+function rune.tick.paused()
+    local _, paused = rune._tick.status()
+    return paused
+end
@@ -0,0 +1,136 @@
+-- Idle Rules
+-- requires: init, registry, timers
+-- Run an action after a stretch with nothing sent to the server - the
+-- classic anti-idle. Every line sent (typed, aliased, or from a
+-- script) restarts each rule's countdown; a rule's own send does too,
+-- so a rule keeps firing every `seconds` for as long as you stay idle.
+-- Built on rune.registry (15_registry.lua) and the timer service.
+--
+-- API:
+--   rune.idle(seconds, action, opts?)  -- Add an idle rule
+--   rune.idles.enable/disable/remove/list/count/clear/remove_group
+--
+-- Action can be:
+--   - String: sent as command (skipped while disconnected)
+--   - Function: function(ctx)
+--       ctx = {name, group, type, seconds, remove()}
+--
+-- Returns a handle with :disable(), :enable(), :remove(), :name(), :group()
+
+rune.idles = {}
+
+local registry = rune.registry.new{
+    kind = "idle",
+    on_remove = function(data)
+        if data.timer_id then
+            rune.timer._cancel(data.timer_id)
+            data.timer_id = nil
+        end
+    end,
+}
+
+local arm
+
+local function fire(data)
+    data.timer_id = nil
+    if registry:active(data) then
+        local ctx = {
+            name = data.name,
+            group = data.group,
+            type = "idle",
+            seconds = data.seconds,
+        }
+        function ctx:remove()
+            data._handle:remove()
+        end
+
+        if type(data.action) == "function" then
+            local label = (data.name and ('Idle "' .. data.name .. '"') or "Idle") ..
+                (data.source and (" @" .. data.source) or "")
+            rune.guarded_call(label, data, data.action, ctx)
+        elseif type(data.action) == "string" and data.action ~= "" and rune.state.connected then
+            rune.send(data.action)
+        end
+    end
+    -- Still registered and not re-armed by a send: count the next
+    -- idle stretch from now.
+    if not data.removed and not data.timer_id then
+        arm(data)
+    end
+end
+
+arm = function(data)
+    if data.timer_id then
+        rune.timer._cancel(data.timer_id)
+    end
+    data.timer_id = rune.timer._after(data.seconds, function()
+        fire(data)
+    end)
+end
+
+-- Add an idle rule: run action after seconds with nothing sent.
+function rune.idle(seconds, action, opts)
+    if type(seconds) ~= "number" or seconds <= 0 then
+        error("rune.idle: seconds must be a positive number", 2)
+    end
+    if type(action) ~= "string" and type(action) ~= "function" then
+        error("rune.idle: action must be a string or function", 2)
+    end
+    local data = {
+        seconds = seconds,
+        action = action,
+        source = rune.caller_source(1),
+    }
+    local handle = registry:add(data, opts)
+    arm(data)
+    return handle
+end
+
+-- INTERNAL: called by rune.send_raw after each line sent; restarts
+-- every rule's countdown.
+function rune.idles._activity()
+    for _, data in ipairs(registry:items()) do
+        arm(data)
+    end
+end
+
+-- Management by name
+function rune.idles.disable(name)
+    return registry:disable(name)
+end
+
+function rune.idles.enable(name)
+    return registry:enable(name)
+end
+
+function rune.idles.remove(name)
+    return registry:remove(name)
+end
+
+-- List all idle rules - returns array of {seconds, value, name, enabled, group, source}
+function rune.idles.list()
+    local result = {}
+    for _, data in ipairs(registry:items()) do
+        table.insert(result, {
+            seconds = data.seconds,
+            value = type(data.action) == "function" and "(function)" or tostring(data.action),
+            name = data.name,
+            enabled = data.enabled,
+            group = data.group,
+            source = data.source,
+        })
+    end
+    return result
+end
+
+function rune.idles.clear()
+    registry:clear()
+end
+
+function rune.idles.count()
+    return registry:count()
+end
+
+function rune.idles.remove_group(group_name)
+    return registry:remove_group(group_name)
+end
@@ -1,4 +1,5 @@
 -- Alias System
+-- requires: init, style, regex, registry
 -- Aliases match user input and transform/expand it.
 -- Built on rune.registry (15_registry.lua).
 --
@@ -17,9 +18,11 @@
 --   priority = 50         -- Execution order for regex aliases (lower = first)
 --
 -- Action can be:
---   - String: expansion text, %1 %2 etc substituted from captures (regex only)
+--   - String: expansion text, %1 %2 etc substituted from captures and
+--     %0 from the whole match (regex only)
 --   - Function (exact):  function(args, ctx)  -- args = string after command word
---   - Function (regex):  function(matches, ctx) -- matches = array of captures
+--   - Function (regex):  function(matches, ctx) -- matches = array of
+--     captures, whole match at matches[0]
 --
 -- Context object:
 --   ctx.line  = full input line
@@ -107,6 +110,8 @@ function rune.alias.list()
             group = data.group,
             once = data.once,
             source = data.source,
+            hits = data.hits,
+            last_hit = data.last_hit,
         }
     end
 
@@ -130,6 +135,12 @@ function rune.alias.list()
     return result
 end
 
+-- Match counters for an alias, by name or handle: {hits, last_hit},
+-- or nil when there is no such alias.
+function rune.alias.stats(alias)
+    return registry:stats(alias)
+end
+
 -- Clear all aliases
 function rune.alias.clear()
     registry:clear()
@@ -164,6 +175,7 @@ function rune.alias.process(input)
         if not data.is_exact and registry:active(data) then
             local matches = rune.regex.match(data.pattern, input)
             if matches then
+                registry:hit(data)
                 local result = nil
 
                 if type(data.action) == "function" then
@@ -193,6 +205,7 @@ function rune.alias.process(input)
     if cmd then
         local data = exact[cmd]
         if data and registry:active(data) then
+            registry:hit(data)
             local result = nil
 
             if type(data.action) == "function" then
@@ -229,3 +242,165 @@ end
 function rune.alias.remove_group(group_name)
     return registry:remove_group(group_name)
 end
+
+-- Export and import
+-- Value aliases (a string action) travel as a JSON file for backup or
+-- sharing; function aliases are code and stay in scripts, so export
+-- skips them. Imported aliases are kept in rune.store under "aliases"
+-- and recreated whenever this file loads, ahead of init.lua, so they
+-- survive /reload and restarts and a script's own alias of the same
+-- name or command word still wins.
+
+local IMPORTED = "aliases"
+
+-- A relative path is taken from the config dir, where init.lua is.
+local function resolve(path)
+    if path:find("^[/~]") or not rune.config_dir then
+        return path
+    end
+    return rune.config_dir .. "/" .. path
+end
+
+-- The portable fields of one alias, or nil for a function alias.
+local function portable(data)
+    if type(data.action) ~= "string" then
+        return nil
+    end
+    return {
+        match = data.pattern,
+        mode = data.is_exact and "exact" or "regex",
+        value = data.action,
+        name = data.name,
+        group = data.group,
+        priority = (not data.is_exact and data.priority ~= 50) and data.priority or nil,
+        once = data.once or nil,
+    }
+end
+
+-- Create an alias from an exported entry. Returns the handle, or nil +
+-- error message for a malformed entry.
+local function recreate(entry)
+    if type(entry) ~= "table" or type(entry.match) ~= "string" or type(entry.value) ~= "string" then
+        return nil, "entries need a match and a value string"
+    end
+    local opts = { name = entry.name, group = entry.group, priority = entry.priority, once = entry.once }
+    if entry.mode == "exact" then
+        return rune.alias.exact(entry.match, entry.value, opts)
+    elseif entry.mode == "regex" then
+        local ok, err = rune.regex.validate(entry.match)
+        if not ok then
+            return nil, "invalid pattern '" .. entry.match .. "': " .. tostring(err)
+        end
+        return rune.alias.regex(entry.match, entry.value, opts)
+    end
+    return nil, "unknown mode '" .. tostring(entry.mode) .. "'"
+end
+
+-- Write every value alias to path as JSON. Returns the number written
+-- and the number of function aliases skipped, or nil + error message.
+function rune.alias.export(path)
+    local entries, skipped = {}, 0
+    local list = {}
+    for _, data in pairs(exact) do
+        list[#list + 1] = data
+    end
+    table.sort(list, function(a, b) return a.pattern < b.pattern end)
+    for _, data in ipairs(registry:items()) do
+        if not data.is_exact then
+            list[#list + 1] = data
+        end
+    end
+    for _, data in ipairs(list) do
+        local entry = portable(data)
+        if entry then
+            entries[#entries + 1] = entry
+        else
+            skipped = skipped + 1
+        end
+    end
+    local ok, err = rune._store.write_file(resolve(path), { version = 1, aliases = entries })
+    if not ok then
+        return nil, err
+    end
+    return #entries, skipped
+end
+
+-- Create the aliases in an exported file and keep them: an entry
+-- replaces a kept one with the same mode and match. The whole file is
+-- checked before anything changes. Returns the number imported, or
+-- nil + error message.
+function rune.alias.import(path)
+    local doc, err = rune._store.read_file(resolve(path))
+    if not doc then
+        return nil, err
+    end
+    if type(doc) ~= "table" or type(doc.aliases) ~= "table" then
+        return nil, "not an alias export (no aliases list)"
+    end
+    for i, entry in ipairs(doc.aliases) do
+        if type(entry) ~= "table" or (entry.mode ~= "exact" and entry.mode ~= "regex")
+            or type(entry.match) ~= "string" or type(entry.value) ~= "string" then
+            return nil, "entry " .. i .. ": needs mode, match, and value"
+        end
+        if entry.mode == "regex" then
+            local ok, perr = rune.regex.validate(entry.match)
+            if not ok then
+                return nil, "entry " .. i .. ": invalid pattern '" .. entry.match .. "': " .. tostring(perr)
+            end
+        end
+    end
+
+    local kept = rune.store.get(IMPORTED)
+    kept = type(kept) == "table" and kept or {}
+    for _, entry in ipairs(doc.aliases) do
+        recreate(entry)
+        local slot = #kept + 1
+        for i, old in ipairs(kept) do
+            if old.mode == entry.mode and old.match == entry.match then
+                slot = i
+                break
+            end
+        end
+        kept[slot] = entry
+    end
+    local ok, serr = rune.store.set(IMPORTED, kept)
+    if not ok then
+        return nil, serr
+    end
+    return #doc.aliases
+end
+
+-- The aliases kept from imports, as exported entries.
+function rune.alias.imported()
+    local kept = rune.store.get(IMPORTED)
+    return type(kept) == "table" and kept or {}
+end
+
+-- Stop keeping imported aliases and remove the ones still as imported.
+-- Returns how many were kept.
+function rune.alias.forget_imported()
+    local kept = rune.alias.imported()
+    for _, entry in ipairs(kept) do
+        local data = entry.mode == "exact" and exact[entry.match]
+        if not data then
+            for _, d in ipairs(registry:items()) do
+                if not d.is_exact and d.pattern == entry.match then
+                    data = d
+                    break
+                end
+            end
+        end
+        if data and data.action == entry.value then
+            data._handle:remove()
+        end
+    end
+    rune.store.delete(IMPORTED)
+    return #kept
+end
+
+for _, entry in ipairs(rune.alias.imported()) do
+    local ok, err = recreate(entry)
+    if not ok then
+        rune.echo(rune.style.red("[Aliases]") .. " imported alias '" .. tostring(entry.match) .. "' skipped: " .. tostring(err))
+    end
+end
@@ -1,5 +1,8 @@
 -- Trigger System
--- Triggers match server output and execute actions.
+-- requires: init, regex, registry, timers
+-- Triggers match server output and execute actions. Opted in with
+-- the origin option, they also match local echoes and script output
+-- (75_send.lua feeds those in).
 -- Built on rune.registry (15_registry.lua).
 --
 -- API (literal matching):
@@ -9,6 +12,8 @@
 --
 -- API (regex matching):
 --   rune.trigger.regex(pattern, action, opts?)   -- Go regexp match
+--   rune.trigger.once(pattern, action, opts?)    -- regex, removed after one match
+--   rune.trigger.wait(pattern, seconds, fn, opts?) -- once, fn(nil) on timeout
 --
 -- Returns a handle with :disable(), :enable(), :remove(), :name(), :group()
 --
@@ -18,7 +23,12 @@
 --   once     = true       -- Auto-remove after first match (spans: first fire)
 --   priority = 50         -- Execution order (lower = first)
 --   gag      = true       -- Hide matching line (spans: every collected line)
+--   bell     = true       -- Ring rune.bell() when the trigger fires
+--   sound    = "path"     -- rune.sound(path) when the trigger fires
 --   raw      = true       -- Match against raw line (with ANSI codes)
+--   whole_word = true     -- Only match at word boundaries ("or" skips "sword")
+--   origin   = "server"   -- Lines to match by origin: "server" (default),
+--                            "echo", "script", or a list of them
 --   span     = {          -- Collect a multi-line message; action fires once
 --     to  = "regex",      --   line that ends the span, inclusive (optional)
 --     raw = true,         --   match `to` against the raw line
@@ -34,7 +44,26 @@
 --       (collected line objects); their return values are ignored, since
 --       the collected lines have already been displayed.
 
-local registry = rune.registry.new{ kind = "trigger" }
+-- Literal index over the registry (see literal_index below); any
+-- change to the trigger set drops it, and the next line rebuilds it.
+local index
+
+local registry = rune.registry.new{
+    kind = "trigger",
+    on_add = function()
+        index = nil
+    end,
+    on_remove = function(data)
+        index = nil
+        -- A wait trigger's timeout dies with it, however it goes:
+        -- matched, removed by hand, cleared, or its group removed.
+        if data.timeout then
+            local timeout = data.timeout
+            data.timeout = nil
+            timeout:cancel()
+        end
+    end,
+}
 
 -- Match modes
 local MODE_EXACT = "exact"
@@ -42,10 +71,34 @@ local MODE_STARTS = "starts"
 local MODE_CONTAINS = "contains"
 local MODE_REGEX = "regex"
 
+-- The regex a whole_word trigger matches with. A regex is wrapped in
+-- \b as given; a literal is quoted, with \b only on an edge that is a
+-- word character - \b beside "!" would demand a word on its far side.
+-- Exact triggers already match whole lines and are left alone.
+local function word_pattern(pattern, mode)
+    if mode == MODE_REGEX then
+        return "\\b(?:" .. pattern .. ")\\b"
+    elseif mode == MODE_EXACT then
+        return nil
+    end
+    local quoted = pattern:gsub("[%^%$%(%)%.%[%]%*%+%-%?{}|\\]", "\\%0")
+    local head = mode == MODE_STARTS and "^" or (pattern:find("^[%w_]") and "\\b" or "")
+    return head .. quoted .. (pattern:find("[%w_]$") and "\\b" or "")
+end
+
+local function trigger_label(data)
+    return (data.name and ('Trigger "' .. data.name .. '"') or "Trigger") ..
+        (data.source and (" @" .. data.source) or "")
+end
+
 -- Create a trigger (internal)
 local function create_trigger(pattern, action, opts, mode)
     opts = opts or {}
 
+    if opts.sound ~= nil and (type(opts.sound) ~= "string" or opts.sound == "") then
+        error("trigger sound must be a file path", 3)
+    end
+
     local span = nil
     if opts.span ~= nil then
         if type(opts.span) ~= "table" then
@@ -72,13 +125,27 @@ local function create_trigger(pattern, action, opts, mode)
         span = { to = to, raw = not not opts.span.raw, max = max }
     end
 
+    local origins = { server = true }
+    if opts.origin ~= nil then
+        local err
+        origins, err = rune.line._origins(opts.origin)
+        if not origins then
+            error("trigger " .. err, 3)
+        end
+    end
+
     return registry:add({
         pattern = pattern,
         action = action,
         mode = mode,
         gag = opts.gag or false,
+        bell = opts.bell or false,
+        sound = opts.sound,
         raw = opts.raw or false,
+        whole_word = opts.whole_word or false,
+        word = opts.whole_word and word_pattern(pattern, mode) or nil,
         span = span,
+        origins = origins,
         source = rune.caller_source(2),
     }, opts)
 end
@@ -112,6 +179,55 @@ function rune.trigger.regex(pattern, action, opts)
     return create_trigger(pattern, action, opts, MODE_REGEX)
 end
 
+-- One-shot Go regexp match: removed after its first match. Shorthand
+-- for rune.trigger.regex(pattern, action, { once = true }).
+function rune.trigger.once(pattern, action, opts)
+    local ok, err = rune.regex.validate(pattern)
+    if not ok then
+        error("invalid trigger pattern '" .. tostring(pattern) .. "': " .. tostring(err), 2)
+    end
+    local o = {}
+    for k, v in pairs(opts or {}) do
+        o[k] = v
+    end
+    o.once = true
+    return create_trigger(pattern, action, o, MODE_REGEX)
+end
+
+-- Wait up to `seconds` for a line matching pattern. On a match, fn
+-- runs as a one-shot trigger action (matches, ctx); if the time runs
+-- out first, the trigger is removed and fn(nil) runs. The timeout is
+-- an ordinary rune.timer owned by the trigger, so removing the handle
+-- cancels it and /reload drops both.
+function rune.trigger.wait(pattern, seconds, fn, opts)
+    if type(seconds) ~= "number" or seconds <= 0 then
+        error("rune.trigger.wait: timeout must be a positive number of seconds", 2)
+    end
+    if type(fn) ~= "function" then
+        error("rune.trigger.wait: callback must be a function", 2)
+    end
+    local ok, err = rune.regex.validate(pattern)
+    if not ok then
+        error("invalid trigger pattern '" .. tostring(pattern) .. "': " .. tostring(err), 2)
+    end
+    if opts and opts.span then
+        error("rune.trigger.wait: span is not supported", 2)
+    end
+    local o = {}
+    for k, v in pairs(opts or {}) do
+        o[k] = v
+    end
+    o.once = true
+    local handle = create_trigger(pattern, fn, o, MODE_REGEX)
+    local data = handle._data
+    data.timeout = rune.timer.after(seconds, function()
+        data.timeout = nil
+        handle:remove()
+        rune.guarded_call(trigger_label(data), data, fn, nil)
+    end)
+    return handle
+end
+
 -- Management by name
 function rune.trigger.disable(name)
     return registry:disable(name)
@@ -137,15 +253,26 @@ function rune.trigger.list()
             enabled = data.enabled,
             group = data.group,
             gag = data.gag,
+            bell = data.bell,
+            sound = data.sound,
             once = data.once,
             raw = data.raw,
+            whole_word = data.whole_word,
             span = data.span and { to = data.span.to, raw = data.span.raw, max = data.span.max } or nil,
             source = data.source,
+            hits = data.hits,
+            last_hit = data.last_hit,
         })
     end
     return result
 end
 
+-- Match counters for a trigger, by name or handle: {hits, last_hit},
+-- or nil when there is no such trigger. A span counts once per message.
+function rune.trigger.stats(trigger)
+    return registry:stats(trigger)
+end
+
 -- Clear all triggers
 function rune.trigger.clear()
     registry:clear()
@@ -159,6 +286,13 @@ end
 -- Match one trigger against a line; returns the captures array on a
 -- match (empty for literal modes), or nil.
 local function match_header(data, match_line)
+    if data.word then
+        local matches = rune.regex.match(data.word, match_line)
+        if matches and data.mode ~= MODE_REGEX then
+            return {}
+        end
+        return matches
+    end
     if data.mode == MODE_EXACT then
         if match_line == data.pattern then
             return {}
@@ -177,6 +311,40 @@ local function match_header(data, match_line)
     return nil
 end
 
+-- The dispatch list plus a pre-filter. Each trigger's required
+-- literal - the pattern itself for literal modes, a run of plain text
+-- every match must contain for regex (rune._regex.literal) - goes into
+-- one LiteralSet, so a single scan of the line finds which triggers
+-- could match at all; the rest are skipped without running their
+-- pattern. Triggers with no usable literal are always tried. The list
+-- is a copy, so like a snapshot it is not perturbed by actions that
+-- add or remove triggers mid-dispatch.
+local function literal_index()
+    if index then
+        return index
+    end
+    local entries, literals, slot = {}, {}, {}
+    for i, data in ipairs(registry:items()) do
+        entries[i] = data
+        local lit
+        if data.mode == MODE_REGEX then
+            lit = rune._regex.literal(data.pattern)
+        elseif data.pattern ~= "" then
+            lit = data.pattern
+        end
+        if lit then
+            literals[#literals + 1] = lit
+            slot[data] = #literals
+        end
+    end
+    index = {
+        entries = entries,
+        set = rune._regex.literal_set(literals),
+        slot = slot,
+    }
+    return index
+end
+
 local function trim(s)
     return (s:gsub("%s+$", ""))
 end
@@ -185,16 +353,13 @@ end
 -- state, so /reload drops any in-progress span with the VM.
 local open = {}
 
-local function trigger_label(data)
-    return (data.name and ('Trigger "' .. data.name .. '"') or "Trigger") ..
-        (data.source and (" @" .. data.source) or "")
-end
-
 -- Process triggers against a line (called by hooks)
 -- Returns: modified_text (string), show (bool)
 --
 -- A function action returning a string rewrites the line: later
--- triggers match against (and receive) the rewritten text. Span
+-- triggers match against (and receive) the rewritten text. Mutating
+-- ctx.line in place (:replace, :color, :gag) carries forward the same
+-- way. Span
 -- triggers collect lines instead of firing per line: the header match
 -- opens a span, following lines are appended, and the action fires
 -- once when span.to matches, span.max lines arrive, a prompt flushes
@@ -203,10 +368,10 @@ end
 -- from higher-priority triggers are included.
 function rune.trigger.process(line, is_prompt)
     local gagged = false
-    local modified_text = nil
 
     local raw_line = line:raw()
     local clean_line = line:clean()
+    local origin = line:origin()
 
     -- Collect triggers to remove after processing (for once)
     local to_remove = {}
@@ -225,6 +390,7 @@ function rune.trigger.process(line, is_prompt)
     -- the whole message. Return values are deliberately ignored - the
     -- collected lines have already been displayed.
     local function fire_span(data, st)
+        registry:hit(data)
         local ctx = {
             line = st.lines[1],
             lines = st.lines,
@@ -234,6 +400,12 @@ function rune.trigger.process(line, is_prompt)
             type = "trigger",
             matches = st.matches,
         }
+        if data.bell then
+            rune.bell()
+        end
+        if data.sound then
+            rune.sound(data.sound)
+        end
         if type(data.action) == "function" then
             rune.guarded_call(trigger_label(data), data, data.action, st.matches, ctx)
         elseif type(data.action) == "string" and data.action ~= "" then
@@ -280,19 +452,35 @@ function rune.trigger.process(line, is_prompt)
         end
     end
 
-    -- Snapshot: a trigger action that adds/removes triggers must not
-    -- perturb this dispatch pass (removals still honored via active()).
-    for _, data in ipairs(registry:snapshot()) do
-        if registry:active(data) then
-            local match_line = data.raw and raw_line or clean_line
+    -- Literal scans, one per distinct text: raw and clean lines
+    -- differ, and a rewrite produces a new text to scan.
+    local idx = literal_index()
+    local slots = idx.slot
+    local scans = {}
+    local function scan(text)
+        local found = scans[text]
+        if not found then
+            found = idx.set:scan(text)
+            scans[text] = found
+        end
+        return found
+    end
 
+    -- The index list is a copy: a trigger action that adds/removes
+    -- triggers must not perturb this dispatch pass (removals still
+    -- honored via active()). The literal check runs first: it is the
+    -- cheap one, and it rules out nearly every trigger on most lines.
+    for _, data in ipairs(idx.entries) do
+        local match_line = data.raw and raw_line or clean_line
+        local slot = slots[data]
+        if data.origins[origin] and (not slot or open[data] or scan(match_line)[slot]) and registry:active(data) then
             if open[data] then
                 -- This line belongs to the open span; it cannot also
                 -- header-match the same trigger in this pass.
                 if data.gag then
                     gagged = true
                 end
-                local matches = match_header(data, match_line)
+                local matches = (not slot or scan(match_line)[slot]) and match_header(data, match_line)
                 local st
                 if matches then
                     -- New header mid-span: flush the previous message
@@ -328,9 +516,16 @@ function rune.trigger.process(line, is_prompt)
                             open[data] = st
                         end
                     else
+                        registry:hit(data)
                         if data.gag then
                             gagged = true
                         end
+                        if data.bell then
+                            rune.bell()
+                        end
+                        if data.sound then
+                            rune.sound(data.sound)
+                        end
 
                         local ctx = {
                             line = line,  -- Line object with :raw() and :clean()
@@ -348,12 +543,16 @@ function rune.trigger.process(line, is_prompt)
                                         gagged = true
                                     elseif type(result) == "string" then
                                         -- Rewrite: later triggers see the new text
-                                        modified_text = result
-                                        line = rune.line.new(result)
-                                        raw_line = line:raw()
-                                        clean_line = line:clean()
+                                        line = rune.line.new(result, origin)
                                     end
                                 end
+                                -- The action may also have mutated
+                                -- ctx.line in place (gag, replace, color).
+                                if line:gagged() then
+                                    gagged = true
+                                end
+                                raw_line = line:raw()
+                                clean_line = line:clean()
                             elseif type(data.action) == "string" and data.action ~= "" then
                                 rune.send(rune.substitute_captures(data.action, matches))
                             end
@@ -376,7 +575,7 @@ function rune.trigger.process(line, is_prompt)
     if gagged then
         return "", false
     end
-    return modified_text or raw_line, true
+    return raw_line, true
 end
 
 -- Group operations
@@ -1,4 +1,5 @@
 -- Slash Command System
+-- requires: init, style, registry, hooks, groups, binds, bars, timers, aliases, triggers
 -- Built on rune.registry (15_registry.lua), so commands get the same
 -- upsert-by-name, source attribution, and failure quarantine as every
 -- other callback registry. A command that keeps throwing is disabled
@@ -30,13 +31,84 @@ local registry = rune.registry.new{
 
 rune.command = {}
 
--- Add a slash command. opts: group (see 15_registry.lua).
+-- Argument specs: opts.args is an array of names, each a positional
+-- word ("world"), optional with a trailing "?" ("port?"), or taking
+-- the rest of the line with a trailing "..." ("message..."; last only).
+-- Compiled once at add time into {name, optional, rest} entries.
+local function compile_args(spec)
+    if type(spec) ~= "table" then
+        error("rune.command.add: opts.args must be an array of argument names", 3)
+    end
+    local params = {}
+    for i, s in ipairs(spec) do
+        local name, dots, q = tostring(s):match("^([%w_%-]+)(%.*)(%??)$")
+        if not name or (dots ~= "" and dots ~= "...") then
+            error('rune.command.add: bad argument spec "' .. tostring(s) .. '"', 3)
+        end
+        if dots ~= "" and i ~= #spec then
+            error('rune.command.add: only the last argument can take the rest ("' .. s .. '")', 3)
+        end
+        if q == "" and i > 1 and params[i - 1].optional then
+            error('rune.command.add: required argument "' .. name .. '" follows an optional one', 3)
+        end
+        params[i] = { name = name, optional = q == "?", rest = dots ~= "" }
+    end
+    return params
+end
+
+-- "/name <world> [port] <message...>"
+local function usage_of(name, params)
+    local parts = { "/" .. name }
+    for _, p in ipairs(params or {}) do
+        local label = p.name .. (p.rest and "..." or "")
+        parts[#parts + 1] = p.optional and ("[" .. label .. "]") or ("<" .. label .. ">")
+    end
+    return table.concat(parts, " ")
+end
+
+-- Split the raw argument string per params. Returns a table keyed by
+-- argument name (optional ones absent when not given), or nil when
+-- the arity is wrong.
+local function parse_args(params, raw)
+    local result = {}
+    local rest = raw:match("^%s*(.-)$")
+    for _, p in ipairs(params) do
+        if not rest:find("%S") then
+            if not p.optional then
+                return nil
+            end
+            break
+        end
+        if p.rest then
+            -- Verbatim, trailing spaces and all (/raw, /lua)
+            result[p.name] = rest
+            rest = ""
+        else
+            local word, tail = rest:match("^(%S+)%s*(.*)$")
+            result[p.name] = word
+            rest = tail
+        end
+    end
+    if rest:find("%S") then
+        return nil
+    end
+    return result
+end
+
+-- Add a slash command. opts: group (see 15_registry.lua), and args -
+-- an argument spec (see compile_args). Without args the handler gets
+-- the raw argument string; with args, dispatch checks arity (echoing
+-- the generated usage on a mismatch) and calls handler(args, raw)
+-- with args keyed by name.
 -- Returns a handle with :enable/:disable/:remove.
 function rune.command.add(name, handler, description, opts)
+    local params = opts and opts.args ~= nil and compile_args(opts.args) or nil
     return registry:add({
         command = name,
         handler = handler,
         description = description or "",
+        params = params,
+        usage = usage_of(name, params),
         source = rune.caller_source(1),
     }, { name = name, group = opts and opts.group })
 end
@@ -52,11 +124,51 @@ function rune.command.get(name)
     return data and data.handler or nil
 end
 
+-- Shortest-unique matching: "/con" runs /connect when no other
+-- enabled command starts with "con". An exact name always wins.
+local abbrev = true
+
+-- Turn abbreviated command names on or off. nil keeps the setting.
+-- Returns it.
+function rune.command.abbrev(enabled)
+    if enabled ~= nil and type(enabled) ~= "boolean" then
+        error("rune.command.abbrev: expected true, false, or nil", 2)
+    end
+    if enabled ~= nil then
+        abbrev = enabled
+    end
+    return abbrev
+end
+
+-- The enabled commands whose names start with prefix, sorted.
+local function completions(prefix)
+    local names = {}
+    for _, c in ipairs(rune.command.list()) do
+        if c.enabled and c.name:sub(1, #prefix) == prefix then
+            names[#names + 1] = c.name
+        end
+    end
+    return names
+end
+
 -- INTERNAL: run a command protected (called by the core input hook).
 -- Returns true if the name was a known command, even when it is
 -- disabled or its handler failed - the input is consumed either way.
+-- An ambiguous abbreviation is reported and consumed too.
 function rune.command.dispatch(name, args)
     local data = by_cmd[name]
+    if not data and abbrev then
+        local names = completions(name)
+        if #names > 1 then
+            rune.echo(red("[Error]") .. " /" .. name .. " is ambiguous: /" ..
+                table.concat(names, ", /"))
+            return true
+        end
+        if names[1] then
+            name = names[1]
+            data = by_cmd[name]
+        end
+    end
     if not data then
         return false
     end
@@ -67,7 +179,16 @@ function rune.command.dispatch(name, args)
     end
     local label = 'Command "/' .. name .. '"' ..
         (data.source and (" @" .. data.source) or "")
-    rune.guarded_call(label, data, data.handler, args)
+    if data.params then
+        local parsed = parse_args(data.params, args)
+        if not parsed then
+            rune.echo("[Usage] " .. data.usage)
+            return true
+        end
+        rune.guarded_call(label, data, data.handler, parsed, args)
+    else
+        rune.guarded_call(label, data, data.handler, args)
+    end
     return true
 end
 
@@ -81,13 +202,14 @@ function rune.command.disable(name)
 end
 
 -- List all commands for the picker and /help.
--- Returns array of {name, description, enabled, group, source}.
+-- Returns array of {name, description, usage, enabled, group, source}.
 function rune.command.list()
     local result = {}
     for _, data in ipairs(registry:items()) do
         table.insert(result, {
             name = data.command,
             description = data.description,
+            usage = data.usage,
             enabled = data.enabled,
             group = data.group,
             source = data.source,
@@ -178,17 +300,13 @@ end, "Reconnect to last server")
 
 -- /load <path> - Load a Lua script
 rune.command.add("load", function(args)
-    if args == "" then
-        rune.echo("[Usage] /load <path>")
-        return
-    end
-    local ok, err = rune.load(args)
+    local ok, err = rune.load(args.path)
     if ok then
-        rune.echo(green("[Loaded]") .. " " .. args)
+        rune.echo(green("[Loaded]") .. " " .. args.path)
     else
         rune.echo(red("[Error]") .. " " .. tostring(err))
     end
-end, "Load a Lua script file")
+end, "Load a Lua script file", { args = { "path..." } })
 
 -- /reload - Clear state and reload init.lua
 rune.command.add("reload", function(args)
@@ -197,12 +315,7 @@ end, "Reload all scripts")
 
 -- /lua <code> - Execute Lua code directly
 rune.command.add("lua", function(args)
-    if args == "" then
-        rune.echo("[Usage] /lua <code>")
-        return
-    end
-
-    local fn, err = loadstring(args)
+    local fn, err = loadstring(args.code)
     if fn then
         local ok, result = pcall(fn)
         if ok then
@@ -215,11 +328,99 @@ rune.command.add("lua", function(args)
     else
         rune.echo(red("[Error]") .. " " .. tostring(err))
     end
-end, "Execute Lua code")
+end, "Execute Lua code", { args = { "code..." } })
 
--- /aliases - List all aliases
+-- "never", "12s ago", "5m ago", ... for a last_hit timestamp.
+local function ago(t)
+    if not t then
+        return "never"
+    end
+    local s = math.max(os.time() - t, 0)
+    if s < 60 then
+        return s .. "s ago"
+    elseif s < 3600 then
+        return math.floor(s / 60) .. "m ago"
+    elseif s < 86400 then
+        return math.floor(s / 3600) .. "h ago"
+    end
+    return math.floor(s / 86400) .. "d ago"
+end
+
+-- A picker of list() entries, most matched first, each described by
+-- its hit count and when it last matched. Picking one prints where it
+-- was registered.
+local function stats_picker(title, entries)
+    local sorted = {}
+    for i, e in ipairs(entries) do
+        sorted[i] = e
+    end
+    table.sort(sorted, function(a, b)
+        if a.hits ~= b.hits then
+            return a.hits > b.hits
+        end
+        return (a.last_hit or 0) > (b.last_hit or 0)
+    end)
+    local items = {}
+    for i, e in ipairs(sorted) do
+        items[i] = {
+            text = (e.name and e.name .. ": " or "") .. e.match,
+            desc = e.hits .. (e.hits == 1 and " hit, " or " hits, ") .. ago(e.last_hit),
+            value = tostring(i),
+        }
+    end
+    rune.ui.picker.show{
+        title = title,
+        items = items,
+        on_select = function(value)
+            local e = sorted[tonumber(value)]
+            rune.echo(string.format("%s %s %s  %s%s", yellow('"' .. e.match .. '"'), dim("->"), e.value,
+                dim(items[tonumber(value)].desc), e.source and ("  " .. dim("@" .. e.source)) or ""))
+        end,
+    }
+end
+
+-- /aliases export|import <file> - share value aliases as JSON;
+-- /aliases forget - stop keeping imported ones
+local function alias_transfer(verb, path)
+    if verb == "forget" then
+        local n = rune.alias.forget_imported()
+        rune.echo(green("[Aliases]") .. " Forgot " .. n .. " imported alias" .. (n == 1 and "" or "es"))
+        return
+    end
+    if path == "" then
+        rune.echo("[Usage] /aliases " .. verb .. " <file>")
+        return
+    end
+    if verb == "export" then
+        local n, skipped = rune.alias.export(path)
+        if not n then
+            rune.echo(red("[Aliases]") .. " Export failed: " .. skipped)
+            return
+        end
+        rune.echo(green("[Aliases]") .. " Exported " .. n .. " to " .. path ..
+            (skipped > 0 and dim(" (" .. skipped .. " function aliases skipped)") or ""))
+    else
+        local n, err = rune.alias.import(path)
+        if not n then
+            rune.echo(red("[Aliases]") .. " Import failed: " .. err)
+            return
+        end
+        rune.echo(green("[Aliases]") .. " Imported " .. n .. " from " .. path)
+    end
+end
+
+-- /aliases - List all aliases; /aliases stats - pick by match count
 rune.command.add("aliases", function(args)
+    local verb, path = args:match("^(%S+)%s*(.-)%s*$")
+    if verb == "export" or verb == "import" or verb == "forget" then
+        alias_transfer(verb, path)
+        return
+    end
     local aliases = rune.alias.list()
+    if args == "stats" and #aliases > 0 then
+        stats_picker("Aliases", aliases)
+        return
+    end
     rune.echo(green("[Aliases]") .. dim(" (" .. #aliases .. " total)"))
     if #aliases == 0 then
         rune.echo("  " .. dim("(none)"))
@@ -233,14 +434,19 @@ rune.command.add("aliases", function(args)
         local name_str = a.name and (" " .. dim("name:") .. a.name) or ""
         local flags_str = #flags > 0 and ("  " .. dim("(" .. table.concat(flags, ", ") .. ")")) or ""
         local src_str = a.source and ("  " .. dim("@" .. a.source)) or ""
-        rune.echo(string.format("  %s %-8s %s %s %s %s%s%s%s",
-            status, a.mode, yellow('"' .. a.match .. '"'), dim("->"), a.value, name_str, group_str, flags_str, src_str))
+        rune.echo(string.format("  %s %-8s %s %s %s %s%s%s%s%s",
+            status, a.mode, yellow('"' .. a.match .. '"'), dim("->"), a.value, name_str, group_str, flags_str,
+            "  " .. dim("hits:" .. a.hits), src_str))
     end
-end, "List all aliases")
+end, "List all aliases (/aliases stats: by match count; export|import <file>; forget)")
 
--- /triggers - List all triggers
+-- /triggers - List all triggers; /triggers stats - pick by match count
 rune.command.add("triggers", function(args)
     local triggers = rune.trigger.list()
+    if args == "stats" and #triggers > 0 then
+        stats_picker("Triggers", triggers)
+        return
+    end
     rune.echo(green("[Triggers]") .. dim(" (" .. #triggers .. " total)"))
     if #triggers == 0 then
         rune.echo("  " .. dim("(none)"))
@@ -253,31 +459,28 @@ rune.command.add("triggers", function(args)
         if t.gag then flags[#flags + 1] = "gag" end
         if t.once then flags[#flags + 1] = "once" end
         if t.raw then flags[#flags + 1] = "raw" end
+        if t.whole_word then flags[#flags + 1] = "whole_word" end
         if t.span then flags[#flags + 1] = "span" end
         local name_str = t.name and (" " .. dim("name:") .. t.name) or ""
         local flags_str = #flags > 0 and ("  " .. dim("(" .. table.concat(flags, ", ") .. ")")) or ""
         local src_str = t.source and ("  " .. dim("@" .. t.source)) or ""
-        rune.echo(string.format("  %s %-8s %s %s %s%s%s%s%s",
-            status, t.mode, yellow('"' .. t.match .. '"'), dim("->"), t.value, name_str, group_str, flags_str, src_str))
+        rune.echo(string.format("  %s %-8s %s %s %s%s%s%s%s%s",
+            status, t.mode, yellow('"' .. t.match .. '"'), dim("->"), t.value, name_str, group_str, flags_str,
+            "  " .. dim("hits:" .. t.hits), src_str))
     end
-end, "List all triggers")
+end, "List all triggers (/triggers stats: by match count)")
 
 -- /test <line> - Simulate server output (test triggers)
 rune.command.add("test", function(args)
-    if args == "" then
-        rune.echo("[Usage] /test <line>")
-        return
-    end
-
-    rune.echo("[Test Input] " .. args)
+    rune.echo("[Test Input] " .. args.line)
 
-    local modified, show = rune.trigger.process(rune.line.new(args))
+    local modified, show = rune.trigger.process(rune.line.new(args.line))
     if show and modified ~= "" then
         rune.echo("[Test Output] " .. modified)
     else
         rune.echo("[Test Output] (gagged)")
     end
-end, "Test triggers with simulated line")
+end, "Test triggers with simulated line", { args = { "line..." } })
 
 -- /timers - List all timers
 rune.command.add("timers", function(args)
@@ -369,8 +572,8 @@ end, "List all groups")
 -- /group <name> on|off - Master switch for a group, so a pack of
 -- triggers/aliases can be toggled mid-game without typing Lua.
 rune.command.add("group", function(args)
-    local name, action = args:match("^(%S+)%s+(%S+)$")
-    if not name or (action ~= "on" and action ~= "off") then
+    local name, action = args.name, args.state
+    if action ~= "on" and action ~= "off" then
         rune.echo("[Usage] /group <name> on|off")
         return
     end
@@ -387,16 +590,12 @@ rune.command.add("group", function(args)
         rune.echo(yellow("[Group]") .. " " .. name .. " disabled" ..
             (known and "" or dim("  (no items in this group yet)")))
     end
-end, "Enable/disable a group (/group <name> on|off)")
+end, "Enable/disable a group (/group <name> on|off)", { args = { "name", "state" } })
 
 -- /raw <text> - Send without alias expansion
 rune.command.add("raw", function(args)
-    if args == "" then
-        rune.echo("[Usage] /raw <text>")
-        return
-    end
-    rune.send_raw(args)
-end, "Send text without alias expansion")
+    rune.send_raw(args.text)
+end, "Send text without alias expansion", { args = { "text..." } })
 
 -- /echo <text> - Print to the local screen (never sent to the server).
 -- Handy for testing and for use in alias/bind command strings.
@@ -417,8 +616,27 @@ end, "Exit the client")
 
 -- /help - Show available commands, generated from the registry so
 -- user-added commands appear automatically and descriptions cannot
--- drift from what the picker shows.
+-- drift from what the picker shows. /help <command> shows one
+-- command's usage line, generated from its argument spec.
 rune.command.add("help", function(args)
+    if args.command then
+        local name = args.command:gsub("^/", "")
+        local data = by_cmd[name]
+        if not data then
+            rune.echo(red("[Error]") .. " Unknown command: /" .. name)
+            return
+        end
+        rune.echo(green("[Help]") .. " " .. yellow(data.usage) ..
+            (registry:active(data) and "" or (" " .. red("[off]"))))
+        if data.description ~= "" then
+            rune.echo("  " .. data.description)
+        end
+        if data.source then
+            rune.echo("  " .. dim("@" .. data.source))
+        end
+        return
+    end
+
     local cmds = rune.command.list()
     rune.echo(green("[Commands]") .. dim(" (" .. #cmds .. " total)"))
     for _, c in ipairs(cmds) do
@@ -426,4 +644,4 @@ rune.command.add("help", function(args)
         rune.echo(string.format("  %-12s %s%s",
             "/" .. c.name, c.description, status))
     end
-end, "Show available commands")
+end, "Show available commands, or one command's usage", { args = { "command?" } })
@@ -1,4 +1,5 @@
 -- Session Logging
+-- requires: init, style, hooks, commands
 -- Go owns the file handle (rune._log), so an active log survives
 -- /reload and is closed on exit. This module owns the policy: WHAT
 -- gets written, ANSI stripping, and the header/footer lines.
@@ -8,10 +9,12 @@
 -- input. Plain mode (the default) strips ANSI so the log reads like
 -- the screen; raw mode (opts.raw / "/log start raw") keeps the codes
 -- for color-faithful transcripts (view with `less -R`). Prompts are
--- skipped (in unterminated mode they repeat on every flush). Client
--- chrome (rune.echo, /help output) is not logged. Register your own
--- hooks against rune._log.write for a different policy; echo does not
--- fire while the server hides input (passwords stay out of logs).
+-- skipped (in unterminated mode they repeat on every flush). Script
+-- output (rune.echo, /help output) is logged only when opts.origin
+-- asks for "script"; opts.origin picks among all three origins.
+-- Register your own hooks against rune._log.write for a different
+-- policy; echo does not fire while the server hides input (passwords
+-- stay out of logs).
 
 local green, red, dim = rune.style.green, rune.style.red, rune.style.gray
 
@@ -26,6 +29,16 @@ if rune._log.status() and rune.session.get("log_raw") then
     raw_mode = true
 end
 
+-- The origins that reach the log, mirrored the same way.
+local DEFAULT_ORIGINS = { server = true, echo = true }
+local origins = DEFAULT_ORIGINS
+if rune._log.status() and rune.session.get("log_origins") then
+    origins = {}
+    for origin in rune.session.get("log_origins"):gmatch("%a+") do
+        origins[origin] = true
+    end
+end
+
 local function default_path()
     return rune.config_dir .. "/logs/" .. os.date("%Y-%m-%d_%H-%M-%S") .. ".log"
 end
@@ -35,12 +48,22 @@ local function stamp(what)
 end
 
 -- Start logging. path defaults to config_dir/logs/<timestamp>.log.
--- opts: { raw = true } keeps ANSI codes instead of stripping them.
+-- opts: { raw = true } keeps ANSI codes instead of stripping them;
+-- { origin = ... } names the lines to log by origin (default
+-- { "server", "echo" }).
 -- Returns the resolved path, or nil + error message.
 function rune.log.start(path, opts)
     if opts ~= nil and type(opts) ~= "table" then
         error("rune.log.start: opts must be a table", 2)
     end
+    local wanted = DEFAULT_ORIGINS
+    if opts and opts.origin ~= nil then
+        local err
+        wanted, err = rune.line._origins(opts.origin)
+        if not wanted then
+            error("rune.log.start: " .. err, 2)
+        end
+    end
     if path == nil or path == "" then
         path = default_path()
     end
@@ -54,6 +77,12 @@ function rune.log.start(path, opts)
     else
         rune.session.delete("log_raw")
     end
+    origins = wanted
+    local names = {}
+    for origin in pairs(origins) do
+        names[#names + 1] = origin
+    end
+    rune.session.set("log_origins", table.concat(names, ","))
     stamp("started")
     return resolved
 end
@@ -65,7 +94,9 @@ function rune.log.stop()
     end
     stamp("stopped")
     raw_mode = false
+    origins = DEFAULT_ORIGINS
     rune.session.delete("log_raw")
+    rune.session.delete("log_origins")
     return rune._log.stop()
 end
 
@@ -80,17 +111,33 @@ function rune.log.write(text)
     rune._log.write(text)
 end
 
+-- True when lines of origin ("server", "echo", or "script") reach the
+-- open log, so core output can avoid writing a line twice.
+function rune.log._logs(origin)
+    return rune._log.status() ~= nil and origins[origin] == true
+end
+
 -- Priority 200: runs after the core trigger handlers (priority 100),
 -- so the log sees the final rewritten text and never sees gagged
 -- lines. rune._log.write is a no-op while no log is open.
 rune.hooks.on("output", function(line)
-    rune._log.write(raw_mode and line:raw() or line:clean())
+    if origins.server then
+        rune._log.write(raw_mode and line:raw() or line:clean())
+    end
 end, { name = "log-output", priority = 200 })
 
 rune.hooks.on("echo", function(text)
-    rune._log.write(raw_mode and text or rune._strip_ansi(text))
+    if origins.echo then
+        rune._log.write(raw_mode and text or rune._strip_ansi(text))
+    end
 end, { name = "log-echo", priority = 200 })
 
+rune.hooks.on("print", function(line)
+    if origins.script then
+        rune._log.write(raw_mode and line:raw() or line:clean())
+    end
+end, { name = "log-print", priority = 200 })
+
 -- /log - registered here rather than in 55_commands.lua so the whole
 -- logging feature lives in one file.
 rune.command.add("log", function(args)
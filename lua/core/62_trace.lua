@@ -0,0 +1,64 @@
+-- Raw Traffic Trace
+-- requires: init, style, commands
+-- Protocol debugging: records every byte read from and written to the
+-- socket, before telnet parsing, with direction markers (< in, > out)
+-- and hex escapes for non-printable bytes. Unlike /log, nothing here
+-- is policy - the network layer owns the file and writes every byte,
+-- so a trace spans reconnects and /reload until stopped. Set
+-- RUNE_TRACE=<file> to trace from startup (before init.lua runs).
+
+local green, red, dim = rune.style.green, rune.style.red, rune.style.gray
+
+rune.trace = {}
+
+local function default_path()
+    return rune.config_dir .. "/logs/trace-" .. os.date("%Y-%m-%d_%H-%M-%S") .. ".log"
+end
+
+-- Start tracing. path defaults to config_dir/logs/trace-<timestamp>.log.
+-- Returns the resolved path, or nil + error message.
+function rune.trace.start(path)
+    if path == nil or path == "" then
+        path = default_path()
+    end
+    return rune._trace.start(path)
+end
+
+-- Stop tracing. Returns true if a trace was open.
+function rune.trace.stop()
+    return rune._trace.stop()
+end
+
+-- Returns the active trace path, or nil.
+function rune.trace.status()
+    return rune._trace.status()
+end
+
+rune.command.add("trace", function(args)
+    local sub, rest = args:match("^(%S*)%s*(.*)$")
+    if sub == "" or sub == "status" then
+        local path = rune.trace.status()
+        if path then
+            rune.echo(green("[Trace]") .. " tracing to " .. path)
+        else
+            rune.echo(dim("[Trace] not tracing") ..
+                "  (/trace start [file], /trace stop)")
+        end
+    elseif sub == "start" then
+        local path, err = rune.trace.start(rest ~= "" and rest or nil)
+        if path then
+            rune.echo(green("[Trace]") .. " tracing to " .. path)
+        else
+            rune.echo(red("[Error]") .. " " .. tostring(err))
+        end
+    elseif sub == "stop" then
+        local path = rune.trace.status()
+        if rune.trace.stop() then
+            rune.echo(green("[Trace]") .. " stopped (" .. path .. ")")
+        else
+            rune.echo(dim("[Trace] not tracing"))
+        end
+    else
+        rune.echo("[Usage] /trace [status] | /trace start [file] | /trace stop")
+    end
+end, "Trace raw socket traffic to a file (/trace start [file], /trace stop)")
@@ -1,4 +1,5 @@
 -- World Bookmarks
+-- requires: init, style, regex, hooks, timers, triggers, commands
 -- Named servers, stored durably in rune.store under the "worlds" key
 -- (so they live in <config>/store.json and survive restarts).
 -- /connect resolves world names before host:port parsing, and with no
@@ -16,7 +17,7 @@ local function load_worlds()
 end
 
 -- Add or replace a world. Extra opts keys are stored verbatim
--- alongside the address (room for future fields like on_connect).
+-- alongside the address (clear, profile, on_connect).
 -- Returns true, or nil + error message.
 function rune.world.add(name, address, opts)
     if type(name) ~= "string" or name == "" or name:find("[%s:/]") then
@@ -131,3 +132,211 @@ end, "Manage world bookmarks (/world add|remove|list)")
 rune.command.add("worlds", function(args)
     print_worlds()
 end, "List saved worlds")
+
+-- ============================================================
+-- LOGIN SEQUENCES
+-- Commands sent on their own after connecting: a world's on_connect
+-- field, or any number registered with rune.on_connect_send. A step
+-- is a command string, or {wait = pattern, send = command, timeout =
+-- seconds} to hold until a server line matches first; secret = name
+-- in place of send sends a rune.secret value. Commands go out
+-- through rune.send_raw (a password with ";" in it stays whole), delay
+-- seconds apart; a wait step starts listening straight away so a
+-- quick prompt is not missed. Sequences run side by side, and a
+-- disconnect stops them. A world's sequence survives the reload a
+-- profile switch on connect causes: its progress is kept in the
+-- session store and resumed on "ready".
+-- ============================================================
+
+local LOGIN_KEY = "login"
+local DEFAULT_DELAY = 0.5
+
+local running = {} -- live sequences: run -> true
+
+local function check_steps(fn, steps, level)
+    if type(steps) ~= "table" or #steps == 0 then
+        error(fn .. ": expected a non-empty list of steps", level)
+    end
+    for i, step in ipairs(steps) do
+        local ok = type(step) == "string"
+        if type(step) == "table" then
+            ok = (step.send == nil or type(step.send) == "string")
+                and (step.secret == nil or (type(step.secret) == "string" and step.send == nil))
+                and (step.wait == nil or (type(step.wait) == "string" and rune.regex.validate(step.wait)))
+                and (step.timeout == nil or (type(step.timeout) == "number" and step.timeout > 0))
+                and (step.send or step.secret or step.wait) ~= nil
+        end
+        if not ok then
+            error(fn .. ": step " .. i .. " must be a command or {wait = pattern, send = command | secret = name, timeout = seconds}", level)
+        end
+    end
+end
+
+-- Stop one sequence; a world's also forgets its saved progress.
+local function stop_login(run)
+    if not running[run] then
+        return
+    end
+    running[run] = nil
+    if run.timer then
+        run.timer:cancel()
+    end
+    if run.trigger then
+        run.trigger:remove()
+    end
+    if run.persist then
+        rune.session.delete(LOGIN_KEY)
+    end
+end
+
+local run_step
+
+-- Move on to step i: a wait step starts at once, anything else after
+-- the delay.
+local function advance(run, i)
+    local step = run.steps[i]
+    if not step then
+        stop_login(run)
+        return
+    end
+    if run.persist then
+        rune.session.set(LOGIN_KEY, i .. " " .. run.persist)
+    end
+    if type(step) == "table" and step.wait then
+        run_step(run, i)
+        return
+    end
+    run.timer = rune.timer.after(run.delay, function()
+        run.timer = nil
+        run_step(run, i)
+    end)
+end
+
+-- Send a table step's command, or its secret's value. A missing
+-- secret stops the sequence rather than send the wrong thing.
+local function send_step(run, step)
+    if step.secret then
+        local value = rune.secret.get(step.secret)
+        if not value then
+            rune.echo(red("[Login]") .. " no secret named " .. step.secret .. " (/secret set " .. step.secret .. ")")
+            stop_login(run)
+            return false
+        end
+        rune.send_raw(value)
+    elseif step.send then
+        rune.send_raw(step.send)
+    end
+    return true
+end
+
+run_step = function(run, i)
+    if not running[run] then
+        return
+    end
+    local step = run.steps[i]
+    if type(step) == "string" then
+        rune.send_raw(step)
+        advance(run, i + 1)
+        return
+    end
+    if not step.wait then
+        if send_step(run, step) then
+            advance(run, i + 1)
+        end
+        return
+    end
+    run.trigger = rune.trigger.wait(step.wait, step.timeout or 10, function(matches)
+        run.trigger = nil
+        if not running[run] then
+            return
+        end
+        if not matches then
+            rune.echo(red("[Login]") .. " gave up waiting for " .. step.wait)
+            stop_login(run)
+            return
+        end
+        if send_step(run, step) then
+            advance(run, i + 1)
+        end
+    end)
+end
+
+-- Start a sequence at step from. persist is the address a world's
+-- sequence resumes on after a reload, or nil.
+local function start_login(steps, delay, from, persist)
+    local run = { steps = steps, delay = delay, persist = persist }
+    running[run] = true
+    if persist then
+        rune.session.set(LOGIN_KEY, from .. " " .. persist)
+    end
+    run_step(run, from)
+end
+
+-- The on_connect steps of the world saved at address, or nil.
+local function world_login(address)
+    for _, w in ipairs(rune.world.list()) do
+        if w.address == address then
+            local steps = rune.world.get(w.name).on_connect
+            if type(steps) == "table" and pcall(check_steps, "on_connect", steps, 1) then
+                return steps
+            end
+            return nil
+        end
+    end
+    return nil
+end
+
+-- Send steps after connecting. opts: delay (seconds between commands,
+-- default 0.5), address or world (only connections there), and the
+-- hook options name and group. Returns the "connected" hook's handle.
+function rune.on_connect_send(steps, opts)
+    check_steps("rune.on_connect_send", steps, 3)
+    opts = opts or {}
+    if opts.delay ~= nil and (type(opts.delay) ~= "number" or opts.delay < 0) then
+        error("rune.on_connect_send: delay must be a number of seconds", 2)
+    end
+    local copy = {}
+    for i, step in ipairs(steps) do
+        copy[i] = step
+    end
+    return rune.hooks.on("connected", function(addr)
+        if opts.address and addr ~= opts.address then
+            return
+        end
+        if opts.world then
+            local entry = rune.world.get(opts.world)
+            if not entry or entry.address ~= addr then
+                return
+            end
+        end
+        start_login(copy, opts.delay or DEFAULT_DELAY, 1, nil)
+    end, { name = opts.name, group = opts.group, priority = 60 })
+end
+
+-- After profile-select (priority 50), which may reload the VM.
+rune.hooks.on("connected", function(addr)
+    local steps = world_login(addr)
+    if steps then
+        start_login(steps, DEFAULT_DELAY, 1, addr)
+    end
+end, { name = "world-login", priority = 60 })
+
+rune.hooks.on("ready", function()
+    local saved = rune.session.get(LOGIN_KEY)
+    if not saved then
+        return
+    end
+    local i, addr = saved:match("^(%d+) (.+)$")
+    local steps = addr and rune.state.connected and rune.state.address == addr and world_login(addr)
+    if steps and tonumber(i) <= #steps then
+        start_login(steps, DEFAULT_DELAY, tonumber(i), addr)
+    else
+        rune.session.delete(LOGIN_KEY)
+    end
+end, { name = "world-login-resume" })
+
+rune.hooks.on("disconnected", function()
+    for run in pairs(running) do
+        stop_login(run)
+    end
+end, { name = "login-stop" })
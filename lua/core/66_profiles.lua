@@ -0,0 +1,135 @@
+-- Profiles
+-- requires: init, style, hooks, commands, worlds
+-- Per-MUD configuration: a profile is a subdirectory of the config dir
+-- whose init.lua loads after the main one, so each game can keep its
+-- own layout, bars, and bindings. The active profile is held in the
+-- session store under "profile" (it survives /reload; the --profile
+-- flag seeds it), and switching reloads so the old profile's state is
+-- torn down cleanly.
+--
+-- API:
+--   rune.profile(name)       -- switch to profile name (false = none)
+--   rune.profiles.current()  -- the active profile name, or nil
+--   rune.profiles.dir(name?) -- a profile's directory path
+--
+-- On connect, a profile is selected by the world whose address
+-- matched: its profile field, or else the world name when
+-- <config>/profiles/<world>/init.lua exists.
+
+local green, red, dim = rune.style.green, rune.style.red, rune.style.gray
+
+rune.profiles = {}
+
+local KEY = "profile"
+
+local function file_exists(path)
+    local f = io.open(path, "r")
+    if f then
+        f:close()
+        return true
+    end
+    return false
+end
+
+local function init_path(name)
+    return rune.profiles.dir(name) .. "/init.lua"
+end
+
+-- The active profile name, or nil.
+function rune.profiles.current()
+    return rune.session.get(KEY)
+end
+
+-- The directory of profile name (default: the active one), or nil
+-- when no name is given and no profile is active.
+function rune.profiles.dir(name)
+    name = name or rune.profiles.current()
+    if not name then
+        return nil
+    end
+    return (rune.config_dir or ".") .. "/profiles/" .. name
+end
+
+-- Switch to profile name, or back to the main config alone with
+-- false. Reloads when the profile changes; returns true, or nil +
+-- error message for a bad name.
+function rune.profile(name)
+    if name == false then
+        name = nil
+    elseif type(name) ~= "string" or name == "" or name:find("[%s/\\]") or name:find("^%.") then
+        return nil, "profile names cannot be empty, start with '.', or contain spaces or slashes"
+    end
+    if name == rune.profiles.current() then
+        return true
+    end
+    if name then
+        rune.session.set(KEY, name)
+    else
+        rune.session.delete(KEY)
+    end
+    rune.reload()
+    return true
+end
+
+-- The profile a connected address selects, or nil.
+local function profile_for(address)
+    for _, w in ipairs(rune.world.list()) do
+        if w.address == address then
+            local entry = rune.world.get(w.name)
+            if type(entry.profile) == "string" then
+                return entry.profile
+            end
+            if file_exists(init_path(w.name)) then
+                return w.name
+            end
+            return nil
+        end
+    end
+    return nil
+end
+
+-- Before user "ready" handlers, so they see the profile's setup.
+rune.hooks.on("ready", function()
+    local name = rune.profiles.current()
+    if not name then
+        return
+    end
+    local path = init_path(name)
+    if not file_exists(path) then
+        rune.echo(red("[Profile]") .. " " .. name .. ": no " .. path)
+        return
+    end
+    local ok, err = rune.load(path)
+    if not ok then
+        rune.echo(red("[Script Error] profiles/" .. name .. "/init.lua: " .. tostring(err)))
+    end
+end, { name = "profile-load", priority = 1 })
+
+rune.hooks.on("connected", function(addr)
+    local name = profile_for(addr)
+    if name and name ~= rune.profiles.current() then
+        rune.echo(green("[Profile]") .. " " .. name .. dim(" (selected by world)"))
+        rune.profile(name)
+    end
+end, { name = "profile-select" })
+
+-- /profile [name|off] - show or switch the active profile
+rune.command.add("profile", function(args)
+    local name = args:match("^(%S+)$")
+    if args == "" then
+        local current = rune.profiles.current()
+        if current then
+            rune.echo(green("[Profile]") .. " " .. current .. dim(" (" .. rune.profiles.dir() .. ")"))
+        else
+            rune.echo(green("[Profile]") .. dim(" none"))
+        end
+        return
+    elseif name == "off" then
+        rune.profile(false)
+        return
+    end
+    local ok, err = rune.profile(name or args)
+    if not ok then
+        rune.echo(red("[Error]") .. " " .. err)
+    end
+end, "Show or switch the active profile (/profile [name|off])")
@@ -1,4 +1,5 @@
 -- GMCP (Generic MUD Communication Protocol)
+-- requires: init, style, registry, hooks, commands, worlds
 -- Go owns the transport (option 201 framing, JSON encode/decode via
 -- the shared bridge); this module owns the policy: the Core.Hello
 -- handshake, package subscriptions, and handler dispatch.
@@ -183,6 +184,105 @@ rune.hooks.on("gmcp_enabled", function()
     send_supports()
 end, { name = "gmcp-hello", priority = 100 })
 
+-- Char.Login: servers that offer GMCP authentication send
+-- Char.Login.Default naming the methods they accept; for
+-- "password-credentials" the client answers Char.Login.Credentials
+-- {account, password}. Opt-in per server: a world's gmcp_login field,
+-- or rune.gmcp.login, gives {account = name, secret = name} with the
+-- password kept in rune.secret. Char.Login is only offered in
+-- Core.Supports.Set while credentials exist for the connection, so
+-- other servers keep their usual login.
+
+local logins = {} -- address -> {account, secret}
+
+-- Give GMCP login credentials for address ("host:port"); false in
+-- place of the table forgets them. There is no catch-all: a password
+-- only goes to a server it was given for.
+function rune.gmcp.login(creds, address)
+    if creds ~= false and (type(creds) ~= "table" or type(creds.account) ~= "string"
+        or type(creds.secret) ~= "string") then
+        error("rune.gmcp.login: expected {account = name, secret = name} or false", 2)
+    end
+    if type(address) ~= "string" or address == "" then
+        error("rune.gmcp.login: expected a server address (host:port)", 2)
+    end
+    logins[address] = creds or nil
+end
+
+-- The credentials for the current connection: its world's gmcp_login,
+-- then ones given for its address.
+local function login_for(address)
+    for _, w in ipairs(rune.world and rune.world.list() or {}) do
+        if w.address == address then
+            local creds = rune.world.get(w.name).gmcp_login
+            if type(creds) == "table" and type(creds.account) == "string" and type(creds.secret) == "string" then
+                return creds
+            end
+        end
+    end
+    return logins[address]
+end
+
+-- Whether Char.Login is in the set because of credentials (rather
+-- than a script's own subscribe).
+local offered = false
+
+-- Whether this connection sent credentials, so only a result for our
+-- own login is reported.
+local sent = false
+
+-- Runs before gmcp-hello, which sends the set.
+rune.hooks.on("gmcp_enabled", function()
+    sent = false
+    if login_for(rune.state.address) then
+        offered = offered or subscriptions["Char.Login"] == nil
+        subscriptions["Char.Login"] = subscriptions["Char.Login"] or 1
+    elseif offered then
+        subscriptions["Char.Login"] = nil
+        offered = false
+    end
+end, { name = "gmcp-login-offer", priority = 90 })
+
+local function accepts(types, method)
+    if type(types) == "string" then
+        return types == method
+    end
+    for _, t in ipairs(type(types) == "table" and types or {}) do
+        if t == method then
+            return true
+        end
+    end
+    return false
+end
+
+rune.gmcp.on("Char.Login.Default", function(data)
+    local creds = login_for(rune.state.address)
+    if not creds or not accepts(type(data) == "table" and data.type, "password-credentials") then
+        return
+    end
+    local password = rune.secret.get(creds.secret)
+    if not password then
+        rune.echo(red("[Login]") .. " no secret named " .. creds.secret .. " (/secret set " .. creds.secret .. ")")
+        return
+    end
+    rune.gmcp.send("Char.Login.Credentials", { account = creds.account, password = password })
+    sent = true
+end, { name = "gmcp-login" })
+
+rune.gmcp.on("Char.Login.Result", function(data)
+    if not sent then
+        return
+    end
+    sent = false
+    local result = type(data) == "table" and data or {}
+    if result.success then
+        rune.echo(green("[Login]") .. " logged in over GMCP")
+    else
+        local why = type(result.message) == "string" and result.message ~= "" and (": " .. result.message) or ""
+        rune.echo(red("[Login]") .. " GMCP login refused" .. why)
+    end
+end, { name = "gmcp-login-result" })
+
 -- /gmcp - status, or send a raw message for debugging
 rune.command.add("gmcp", function(args)
     local sub, rest = args:match("^(%S*)%s*(.*)$")
@@ -0,0 +1,224 @@
+-- Telnet Option Negotiation
+-- requires: init, style, registry, hooks, timers
+-- Go owns the protocol (the parser's option table, the replies the
+-- built-in options need); this module lets scripts watch and drive
+-- negotiation for any option by number, so a niche option needs no
+-- Go changes.
+--
+-- Handlers see the negotiations the parser acts on: a WILL or DO it
+-- accepts, and every WONT and DONT. A WILL or DO for an option nobody
+-- declared is refused before it gets here - rune.telnet.will/do
+-- declare support for the connection, so the server's answer is
+-- accepted and reported. Go refuses to negotiate the options it
+-- handles itself, and WILL/DO for ones nothing can honor; the calls
+-- then return nil + error.
+--
+-- API:
+--   rune.telnet.on(option, fn, opts)  -- fn(command, option) on negotiation
+--   rune.telnet.will(option)          -- offer to use an option ourselves
+--   rune.telnet.wont(option)          -- stop using it
+--   rune.telnet.do_(option)           -- ask the server to use it
+--   rune.telnet.dont(option)          -- ask the server to stop
+--   rune.telnet.timeout(seconds?)     -- how long a WILL/DO waits for an answer
+--
+-- A WILL or DO left unanswered for rune.telnet.timeout() seconds is
+-- abandoned: the option counts as unsupported for the rest of the
+-- connection, handlers get "timeout" as the command, and the
+-- "telnet_timeout" hook fires (option, command) - the core handler
+-- prints a diagnostic.
+
+rune.telnet = {}
+
+-- Per-option dispatch index (mirrors 70_gmcp.lua's by_package)
+local by_option = {} -- option -> sorted array of data
+
+local function sort_handlers(handlers)
+    table.sort(handlers, function(a, b)
+        if a.priority ~= b.priority then
+            return a.priority < b.priority
+        end
+        return a.id < b.id
+    end)
+end
+
+local registry = rune.registry.new{
+    kind = "telnet",
+    on_add = function(data)
+        local handlers = by_option[data.option]
+        if not handlers then
+            handlers = {}
+            by_option[data.option] = handlers
+        end
+        table.insert(handlers, data)
+        sort_handlers(handlers)
+    end,
+    on_remove = function(data)
+        local handlers = by_option[data.option]
+        if not handlers then
+            return
+        end
+        for i, entry in ipairs(handlers) do
+            if entry == data then
+                table.remove(handlers, i)
+                break
+            end
+        end
+    end,
+}
+
+local function check_option(option)
+    if type(option) ~= "number" or option ~= math.floor(option) or option < 0 or option > 255 then
+        error("telnet option must be a number 0-255, got " .. tostring(option), 3)
+    end
+end
+
+-- Attach a handler to a telnet option (0-255). handler receives
+-- (command, option): command is "will", "wont", "do" or "dont" as the
+-- server sent it.
+-- opts: name, group, priority (see 15_registry.lua).
+-- Returns a handle with :enable/:disable/:remove.
+function rune.telnet.on(option, handler, opts)
+    check_option(option)
+    return registry:add({
+        option = option,
+        handler = handler,
+        source = rune.caller_source(1),
+    }, opts)
+end
+
+function rune.telnet.remove(name)
+    return registry:remove(name)
+end
+
+function rune.telnet.enable(name)
+    return registry:enable(name)
+end
+
+function rune.telnet.disable(name)
+    return registry:disable(name)
+end
+
+-- Seconds a WILL or DO waits for the server's answer.
+local timeout = 10
+
+-- Negotiations awaiting an answer: option -> { command, timer }, one
+-- timer per option. A reply of either kind (DO/DONT to our WILL,
+-- WILL/WONT to our DO) settles it.
+local waiting = {}
+
+local answers = {
+    will = { ["do"] = true, dont = true },
+    ["do"] = { will = true, wont = true },
+}
+
+local dispatch
+
+local function settle(option)
+    local w = waiting[option]
+    if w then
+        waiting[option] = nil
+        rune.timer._cancel(w.timer)
+    end
+end
+
+local function await(command, option)
+    settle(option)
+    local w = { command = command }
+    w.timer = rune.timer._after(timeout, function()
+        if waiting[option] ~= w then
+            return
+        end
+        waiting[option] = nil
+        -- Go has the final word: the answer may have arrived while
+        -- this wake-up was queued.
+        if rune._net.abandon(option) then
+            dispatch(option, "timeout")
+            rune.hooks.call("telnet_timeout", option, command)
+        end
+    end)
+    waiting[option] = w
+end
+
+-- Set how many seconds a WILL or DO waits for an answer (default 10);
+-- with no argument, return it. Applies to negotiations started after.
+function rune.telnet.timeout(seconds)
+    if seconds == nil then
+        return timeout
+    end
+    if type(seconds) ~= "number" or seconds <= 0 then
+        error("rune.telnet.timeout: expected a positive number of seconds", 2)
+    end
+    timeout = seconds
+    return timeout
+end
+
+-- Initiate negotiation. Returns true when the request went out, false
+-- when the option is already in that state, or nil + error message
+-- (not connected).
+local function negotiate(command)
+    return function(option)
+        check_option(option)
+        local sent, err = rune._net.negotiate(command, option)
+        if sent and answers[command] then
+            await(command, option)
+        end
+        return sent, err
+    end
+end
+
+-- WILL: offer to use the option on our side.
+rune.telnet.will = negotiate("will")
+
+-- WONT: stop using the option on our side.
+rune.telnet.wont = negotiate("wont")
+
+-- DO: ask the server to use the option. "do" is a Lua keyword, so
+-- call it as rune.telnet["do"](option) or through the do_ alias.
+rune.telnet["do"] = negotiate("do")
+rune.telnet.do_ = rune.telnet["do"]
+
+-- DONT: ask the server to stop using the option.
+rune.telnet.dont = negotiate("dont")
+
+-- Run the handlers for an option.
+function dispatch(option, command)
+    local live = by_option[option]
+    if not live or #live == 0 then
+        return
+    end
+    -- Snapshot: a handler may add/remove handlers mid-dispatch.
+    local handlers = {}
+    for i, entry in ipairs(live) do
+        handlers[i] = entry
+    end
+    for _, entry in ipairs(handlers) do
+        if registry:active(entry) then
+            local label = "telnet " .. command:upper() .. " " .. option ..
+                (entry.name and (' "' .. entry.name .. '"') or "") ..
+                (entry.source and (" @" .. entry.source) or "")
+            rune.guarded_call(label, entry, entry.handler, command, option)
+        end
+    end
+end
+
+-- INTERNAL: called by Go (Engine.OnTelnet) with the option and the
+-- command received.
+function rune.telnet._dispatch(option, command)
+    local w = waiting[option]
+    if w and answers[w.command][command] then
+        settle(option)
+    end
+    dispatch(option, command)
+end
+
+-- A new connection starts negotiation from scratch.
+rune.hooks.on("disconnected", function()
+    for option in pairs(waiting) do
+        settle(option)
+    end
+end, { name = "telnet-waiting", priority = 100 })
+
+rune.hooks.on("telnet_timeout", function(option, command)
+    rune.echo(rune.style.yellow("[Telnet]") .. " no answer to " .. command:upper() .. " " ..
+        option .. " after " .. timeout .. "s; treating it as unsupported")
+end, { name = "telnet-timeout", priority = 100 })
@@ -0,0 +1,60 @@
+-- Connection Stats and Latency
+-- requires: init, hooks, timers
+-- Go measures (byte counters, the GMCP Core.Ping round trip); this
+-- module owns when to probe. Pings are GMCP, out of band, so they
+-- never reach the text stream or disturb prompt detection - and a
+-- server without GMCP simply reports no latency.
+
+rune.net = {}
+
+-- Snapshot of the current connection: { bytes_in, bytes_out,
+-- latency_ms }. latency_ms is nil until the server has answered a
+-- ping; everything reads zero/nil when disconnected.
+function rune.net.stats()
+    return rune._net.stats()
+end
+
+-- Short descriptions of a disconnect reason (the "disconnected" hook's
+-- first argument, rune.state.disconnect_reason) for notices and status
+-- bars. "user" has none: the user asked for it.
+rune.net.disconnect_labels = {
+    closed = "closed by server",
+    reset = "connection reset",
+    timeout = "timed out",
+    error = "connection error",
+}
+
+-- Send a latency probe now; the reply updates stats().latency_ms.
+-- Returns true, or nil + error message (not connected, no GMCP).
+function rune.net.ping()
+    return rune._net.ping()
+end
+
+-- Probe every rune.config.ping seconds while GMCP is up (read when
+-- the connection negotiates it). The first probe goes out at once so
+-- a status bar has a number within one round trip.
+local function start_pinging()
+    local every = rune.config.ping
+    if type(every) ~= "number" or every <= 0 then
+        return
+    end
+    if not rune._net.ping() then
+        return
+    end
+    rune.timer.every(every, function(ctx)
+        if not rune._net.ping() then
+            ctx:remove()
+        end
+    end, { name = "net-ping" })
+end
+
+rune.hooks.on("gmcp_enabled", start_pinging, { name = "net-ping", priority = 100 })
+
+rune.hooks.on("disconnected", function()
+    rune.timer.remove("net-ping")
+end, { name = "net-ping-stop", priority = 100 })
+
+-- /reload drops the old VM's timers; resume probing on a live link.
+if rune._gmcp.is_active() then
+    start_pinging()
+end
@@ -0,0 +1,42 @@
+-- NEW-ENVIRON Variables
+-- Go answers the server's NEW-ENVIRON SEND (option 39) with the MNES
+-- built-ins (CLIENT_NAME, CLIENT_VERSION, CHARSET, MTTS,
+-- TERMINAL_TYPE); this module owns the variables scripts add. The set
+-- lives here and is pushed to Go whole on every change, so /reload
+-- starts from the built-ins and init.lua declares its own again.
+
+rune.env = {}
+
+local vars = {} -- upper-case name -> string value
+
+-- Report name to the server with value (a string or number), or stop
+-- reporting it when value is nil. Names are case-insensitive, as MNES
+-- servers request them upper-case; a script-set name overrides the
+-- built-in of the same name. Applies from the server's next request.
+function rune.env.set(name, value)
+    if type(name) ~= "string" or name == "" then
+        error("rune.env.set: name must be a non-empty string", 2)
+    end
+    if value ~= nil and type(value) ~= "string" and type(value) ~= "number" then
+        error("rune.env.set: value must be a string, number, or nil", 2)
+    end
+    vars[name:upper()] = value ~= nil and tostring(value) or nil
+    rune._net.set_environ(vars)
+end
+
+-- The value a script set for name, or nil (built-ins are not listed).
+function rune.env.get(name)
+    return vars[tostring(name):upper()]
+end
+
+-- All script-set variables, as a name -> value copy.
+function rune.env.list()
+    local out = {}
+    for name, value in pairs(vars) do
+        out[name] = value
+    end
+    return out
+end
+
+-- Drop whatever the previous VM pushed.
+rune._net.set_environ(vars)
@@ -0,0 +1,196 @@
+-- Vitals Gauges
+-- requires: init, style, hooks, bars, gmcp
+-- A convenience layer over rune.gmcp and the bar registry: merge the
+-- fields of Char.Vitals-style packages into one table, and render the
+-- configured gauges as proportional block graphs. Nothing is active
+-- until rune.vitals.setup() is called; calling it again replaces the
+-- previous configuration.
+
+rune.vitals = {}
+
+-- Gauges used when setup() is given none: the common Char.Vitals
+-- field names (IRE, Achaea-likes). Games differ; /gmcp and the
+-- catch-all "gmcp" hook show what yours sends.
+local DEFAULT_GAUGES = {
+    { name = "hp", label = "HP", current = "hp", max = "maxhp", color = "green" },
+    { name = "mp", label = "MP", current = "mp", max = "maxmp", color = "blue" },
+}
+
+local fields = {}  -- merged field -> raw value from the server
+local gauges = {}  -- configured gauges, in display order
+local config = nil -- { packages, bar, separator } once set up
+
+-- Decoded GMCP values are often strings ("hp": "1200"); a max may
+-- also be a constant number in the gauge spec.
+local function resolve(source)
+    if type(source) == "number" then
+        return source
+    end
+    return tonumber(fields[source])
+end
+
+-- A block graph of cur/max, width cells wide: filled cells in color,
+-- the rest gray. Out-of-range values clamp to empty or full.
+function rune.vitals.gauge(cur, max, width, color)
+    width = width or 10
+    local filled = 0
+    if max and max > 0 then
+        filled = math.floor(width * cur / max + 0.5)
+        filled = math.max(0, math.min(width, filled))
+    end
+    return rune.text.wrap(string.rep("█", filled), color or "green") ..
+        rune.style.gray(string.rep("░", width - filled))
+end
+
+-- Current values: { [gauge] = { current, max } } for every gauge the
+-- server has reported, or one entry (nil when unknown) given a name.
+function rune.vitals.get(name)
+    local out = {}
+    for _, g in ipairs(gauges) do
+        local cur, max = resolve(g.current), resolve(g.max)
+        if cur and max then
+            out[g.name] = { current = cur, max = max }
+        end
+    end
+    if name ~= nil then
+        return out[name]
+    end
+    return out
+end
+
+-- The raw merged fields, for values no gauge covers (a copy).
+function rune.vitals.fields()
+    local out = {}
+    for k, v in pairs(fields) do
+        out[k] = v
+    end
+    return out
+end
+
+-- The bar renderer setup() registers: each reported gauge as
+-- "LABEL graph cur/max", left to right. Usable directly when a
+-- custom bar wants the gauges as one piece.
+function rune.vitals.render()
+    if not config then
+        return ""
+    end
+    local parts = {}
+    for _, g in ipairs(gauges) do
+        local cur, max = resolve(g.current), resolve(g.max)
+        if cur and max then
+            local color = g.color
+            if g.low and max > 0 and cur < max * g.low then
+                color = g.low_color
+            end
+            table.insert(parts, string.format("%s %s %d/%d",
+                g.label, rune.vitals.gauge(cur, max, g.width, color), cur, max))
+        end
+    end
+    return table.concat(parts, config.separator)
+end
+
+local function check_gauge(i, g)
+    if type(g) ~= "table" then
+        error("rune.vitals.setup: gauge " .. i .. " must be a table", 3)
+    end
+    if type(g.current) ~= "string" then
+        error("rune.vitals.setup: gauge " .. i .. " needs a current field name", 3)
+    end
+    if type(g.max) ~= "string" and type(g.max) ~= "number" then
+        error("rune.vitals.setup: gauge " .. i .. " needs a max field name or number", 3)
+    end
+    local color = g.color or "green"
+    local low_color = g.low_color or "red"
+    -- Validate specs now rather than erroring on every render tick.
+    for _, spec in ipairs({ color, low_color }) do
+        if not pcall(rune.text.color, spec) then
+            error("rune.vitals.setup: unknown color " .. tostring(spec), 3)
+        end
+    end
+    local low = g.low
+    if low == nil then
+        low = 0.25
+    elseif low == false then
+        low = nil
+    end
+    local name = g.name or g.current
+    return {
+        name = name,
+        label = g.label or name:upper(),
+        current = g.current,
+        max = g.max,
+        color = color,
+        low = low,
+        low_color = low_color,
+        width = g.width,
+    }
+end
+
+-- Configure and start tracking. opts (all optional):
+--   packages  - GMCP packages whose fields are merged (default
+--               { "Char.Vitals" }); each package's top-level namespace
+--               is subscribed
+--   gauges    - array of { current = field, max = field | number,
+--               name?, label?, color?, low?, low_color?, width? }
+--   bar       - bar name to register the renderer as (default
+--               "vitals"; false registers none)
+--   width     - default gauge width in cells (default 10)
+--   separator - text between gauges (default "  ")
+function rune.vitals.setup(opts)
+    opts = opts or {}
+    if type(opts) ~= "table" then
+        error("rune.vitals.setup: opts must be a table", 2)
+    end
+    local packages = opts.packages or { "Char.Vitals" }
+    if type(packages) == "string" then
+        packages = { packages }
+    end
+    local specs = opts.gauges or DEFAULT_GAUGES
+    if type(specs) ~= "table" then
+        error("rune.vitals.setup: gauges must be an array", 2)
+    end
+
+    local width = opts.width or 10
+    local list = {}
+    for i, spec in ipairs(specs) do
+        local g = check_gauge(i, spec)
+        g.width = g.width or width
+        list[i] = g
+    end
+
+    if config then
+        for _, package in ipairs(config.packages) do
+            rune.gmcp.remove("vitals:" .. package)
+        end
+        rune.bars.remove("vitals")
+    end
+    gauges = list
+    fields = {}
+    config = {
+        packages = packages,
+        bar = opts.bar == nil and "vitals" or opts.bar,
+        separator = opts.separator or "  ",
+    }
+
+    for _, package in ipairs(packages) do
+        rune.gmcp.subscribe(package:match("^[^.]+"))
+        rune.gmcp.on(package, function(data)
+            if type(data) ~= "table" then
+                return
+            end
+            for k, v in pairs(data) do
+                fields[k] = v
+            end
+            rune.ui.refresh_bars()
+        end, { name = "vitals:" .. package, group = "vitals" })
+    end
+
+    if config.bar then
+        rune.ui.bar(config.bar, rune.vitals.render, { name = "vitals", group = "vitals" })
+    end
+end
+
+-- A stale gauge is worse than none: the next connection reports anew.
+rune.hooks.on("disconnected", function()
+    fields = {}
+end, { name = "vitals-reset", priority = 100 })
@@ -1,4 +1,5 @@
 -- Command Processing System
+-- requires: init, style, hooks, aliases, triggers, commands
 -- Simple recursion-based design: no queues, no global state.
 
 local MAX_RECURSION_DEPTH = 100
@@ -149,6 +150,25 @@ rune.hooks.on("output", function(line)
     return modified
 end, { priority = 100 })
 
+-- Local echoes and script output reach only the triggers that opted
+-- in with the origin option. Echo triggers run after secret-mask
+-- (priority 50), on the typed text before its "> " styling (100).
+rune.hooks.on("echo", function(text)
+    local modified, show = rune.trigger.process(rune.line.new(text, "echo"))
+    if not show then
+        return false
+    end
+    return modified
+end, { priority = 75 })
+
+rune.hooks.on("print", function(line)
+    local modified, show = rune.trigger.process(line)
+    if not show then
+        return false
+    end
+    return modified
+end, { priority = 100 })
+
 -- Register prompt handler. is_prompt = true: a prompt is never part
 -- of a multi-line span, so any open span flushes first.
 rune.hooks.on("prompt", function(line)
@@ -0,0 +1,174 @@
+-- Output Channels
+-- requires: init, style, hooks, triggers
+-- Named routes from server output into panes, built on triggers: each
+-- rune.channel() pattern is a regex trigger that writes the matching
+-- line to the channel's pane, and optionally gags it from the main
+-- window. A default channel catches the lines no channel claimed.
+--
+-- API:
+--   rune.channel(name, pattern, opts?)  -- route matching lines to name
+--   rune.channels.default(name?, opts?) -- where unmatched lines go (nil = off)
+--   rune.channels.remove(name)          -- drop a channel and its patterns
+--   rune.channels.list() / .clear()
+--
+-- Channel options (merged across calls for the same name):
+--   pane      = "string"       -- pane to write to (default: the name)
+--   gag       = true           -- move instead of copy: hide from main
+--   timestamp = true | "fmt"   -- os.date prefix (true = "%H:%M")
+--   color     = spec           -- recolor the line (rune.text.color specs)
+-- plus group and priority for the routing trigger.
+
+rune.channels = {}
+
+local channels = {} -- name -> { pane, gag, timestamp, color, handles }
+local fallback = nil -- the default channel's settings, or nil
+
+-- Whether a channel pattern routed the current output line. Reset by
+-- the first output handler, read by the default channel's.
+local routed = false
+
+local function apply(ch, opts)
+    for _, key in ipairs({ "pane", "gag", "timestamp", "color" }) do
+        if opts[key] ~= nil then
+            ch[key] = opts[key]
+        end
+    end
+    if ch.color ~= nil and not pcall(rune.text.color, ch.color) then
+        error("rune.channel: unknown color " .. tostring(ch.color), 3)
+    end
+end
+
+-- Write a line object to a channel's pane with its formatting.
+local function route(name, ch, line)
+    local text = line:raw()
+    if ch.color ~= nil then
+        text = rune.text.wrap(line:clean(), ch.color)
+    end
+    if ch.timestamp then
+        local fmt = ch.timestamp == true and "%H:%M" or ch.timestamp
+        text = rune.style.gray(os.date(fmt)) .. " " .. text
+    end
+    rune.pane.write(ch.pane or name, text)
+end
+
+-- Route output lines matching pattern (a Go regex, matched against
+-- the clean line) to channel name. A channel may have any number of
+-- patterns; call once per pattern. Returns the routing trigger's
+-- handle.
+function rune.channel(name, pattern, opts)
+    if type(name) ~= "string" or name == "" then
+        error("rune.channel: name must be a non-empty string", 2)
+    end
+    if type(pattern) ~= "string" then
+        error("rune.channel: pattern must be a string", 2)
+    end
+    opts = opts or {}
+    local ch = channels[name]
+    if not ch then
+        ch = { handles = {} }
+        channels[name] = ch
+    end
+    apply(ch, opts)
+
+    local handle = rune.trigger.regex(pattern, function(_, ctx)
+        route(name, ch, ctx.line)
+        routed = true
+        if ch.gag then
+            return false
+        end
+    end, {
+        name = "channel:" .. name .. ":" .. pattern,
+        group = opts.group,
+        priority = opts.priority,
+    })
+    ch.handles[pattern] = handle
+    return handle
+end
+
+-- Send lines no channel matched to channel name (with the same
+-- options as rune.channel), or stop with nil. Lines gagged by another
+-- handler are not routed.
+function rune.channels.default(name, opts)
+    if name == nil then
+        fallback = nil
+        return
+    end
+    if type(name) ~= "string" or name == "" then
+        error("rune.channels.default: name must be a non-empty string or nil", 2)
+    end
+    local ch = { name = name }
+    apply(ch, opts or {})
+    fallback = ch
+end
+
+-- Remove a channel and its routing triggers. Returns true if it
+-- existed. The pane and what it holds are left alone.
+function rune.channels.remove(name)
+    local ch = channels[name]
+    if not ch then
+        return false
+    end
+    for _, handle in pairs(ch.handles) do
+        handle:remove()
+    end
+    channels[name] = nil
+    return true
+end
+
+-- All channels: array of { name, pane, patterns, gag, timestamp,
+-- color }, sorted by name. The default channel is listed with
+-- default = true.
+function rune.channels.list()
+    local result = {}
+    for name, ch in pairs(channels) do
+        local patterns = {}
+        for pattern in pairs(ch.handles) do
+            patterns[#patterns + 1] = pattern
+        end
+        table.sort(patterns)
+        result[#result + 1] = {
+            name = name,
+            pane = ch.pane or name,
+            patterns = patterns,
+            gag = ch.gag == true,
+            timestamp = ch.timestamp,
+            color = ch.color,
+        }
+    end
+    if fallback then
+        result[#result + 1] = {
+            name = fallback.name,
+            pane = fallback.pane or fallback.name,
+            patterns = {},
+            gag = fallback.gag == true,
+            timestamp = fallback.timestamp,
+            color = fallback.color,
+            default = true,
+        }
+    end
+    table.sort(result, function(a, b) return a.name < b.name end)
+    return result
+end
+
+-- Remove every channel, and turn off the default.
+function rune.channels.clear()
+    for name in pairs(channels) do
+        rune.channels.remove(name)
+    end
+    fallback = nil
+end
+
+rune.hooks.on("output", function()
+    routed = false
+end, { name = "channels-reset", priority = 1 })
+
+-- After triggers (priority 100), so channel patterns have had their
+-- chance; lines gagged earlier never get here.
+rune.hooks.on("output", function(line)
+    if fallback and not routed then
+        route(fallback.name, fallback, line)
+        if fallback.gag then
+            return false
+        end
+    end
+end, { name = "channels-default", priority = 110 })
@@ -0,0 +1,256 @@
+-- Macros
+-- requires: init, style, hooks, binds, timers, commands, send
+-- Record the lines you type and play them back later. While a macro
+-- is recording, every submitted input line (except slash commands and
+-- verbatim composer drafts) is captured as typed; playback runs each
+-- one through rune.send, so aliases, ";" and #N repeats apply again,
+-- spaced rune.config.macro_delay seconds apart. Saved macros live in
+-- rune.store under "macros" and survive restarts. A recording in
+-- progress is held in the VM, so /reload ends it unsaved.
+--
+-- API:
+--   rune.macro.record(name)  -- start recording into name
+--   rune.macro.stop()        -- save the recording, or halt playback
+--   rune.macro.play(name)    -- replay a saved macro
+--   rune.macro.get(name)     -- a macro's lines, or nil
+--   rune.macro.list()        -- {name, count} for each saved macro
+--   rune.macro.remove(name)  -- delete a saved macro
+--   rune.macro.recording()   -- the name being recorded, or nil
+
+local green, red, dim = rune.style.green, rune.style.red, rune.style.gray
+
+rune.macro = {}
+
+local KEY = "macros"
+
+-- The macro the default record/stop/play keys use.
+local QUICK = "quick"
+
+local recording = nil -- { name, lines } while recording
+local playback = nil  -- { timer_id } while a delayed playback runs
+
+local function saved()
+    local macros = rune.store.get(KEY)
+    return type(macros) == "table" and macros or {}
+end
+
+local function check_name(name)
+    if type(name) ~= "string" or name == "" or name:find("%s") then
+        return nil, "macro names must be non-empty and contain no spaces"
+    end
+    return true
+end
+
+-- Start recording into name, replacing any recording in progress.
+-- Returns true, or nil + error message.
+function rune.macro.record(name)
+    local ok, err = check_name(name)
+    if not ok then
+        return nil, err
+    end
+    recording = { name = name, lines = {} }
+    return true
+end
+
+-- The name being recorded, or nil.
+function rune.macro.recording()
+    return recording and recording.name
+end
+
+local function halt_playback()
+    if not playback then
+        return false
+    end
+    if playback.timer_id then
+        rune.timer._cancel(playback.timer_id)
+    end
+    playback = nil
+    return true
+end
+
+-- Stop recording and save the macro; an empty recording leaves any
+-- saved macro of that name alone. Returns the name and line count.
+-- With nothing recording, halts a playback in progress instead and
+-- returns nil. Returns nil + error message when neither is running.
+function rune.macro.stop()
+    if not recording then
+        if halt_playback() then
+            return nil
+        end
+        return nil, "not recording"
+    end
+    local name, lines = recording.name, recording.lines
+    recording = nil
+    if #lines > 0 then
+        local macros = saved()
+        macros[name] = lines
+        local ok, err = rune.store.set(KEY, macros)
+        if not ok then
+            return nil, err
+        end
+    end
+    return name, #lines
+end
+
+-- A copy of the lines in macro name, or nil.
+function rune.macro.get(name)
+    local lines = saved()[name]
+    if type(lines) ~= "table" then
+        return nil
+    end
+    local copy = {}
+    for i, line in ipairs(lines) do
+        copy[i] = line
+    end
+    return copy
+end
+
+-- Replay macro name through rune.send, rune.config.macro_delay seconds
+-- apart (all at once when 0). Starting a playback halts any other.
+-- Returns true, or nil + error message.
+function rune.macro.play(name)
+    local lines = rune.macro.get(name)
+    if not lines then
+        return nil, "no macro named " .. tostring(name)
+    end
+    halt_playback()
+
+    local delay = tonumber(rune.config.macro_delay) or 0
+    if delay <= 0 then
+        for _, line in ipairs(lines) do
+            rune.send(line)
+        end
+        return true
+    end
+
+    local state = {}
+    playback = state
+    local i = 0
+    local function step()
+        state.timer_id = nil
+        if playback ~= state then
+            return
+        end
+        i = i + 1
+        rune.send(lines[i])
+        if i < #lines then
+            state.timer_id = rune.timer._after(delay, step)
+        else
+            playback = nil
+        end
+    end
+    step()
+    return true
+end
+
+-- List saved macros - returns array of {name, count}, sorted by name.
+function rune.macro.list()
+    local result = {}
+    for name, lines in pairs(saved()) do
+        if type(lines) == "table" then
+            table.insert(result, { name = name, count = #lines })
+        end
+    end
+    table.sort(result, function(a, b) return a.name < b.name end)
+    return result
+end
+
+-- Delete a saved macro. Returns true if one existed.
+function rune.macro.remove(name)
+    local macros = saved()
+    if macros[name] == nil then
+        return false
+    end
+    macros[name] = nil
+    if next(macros) == nil then
+        rune.store.delete(KEY)
+    else
+        rune.store.set(KEY, macros)
+    end
+    return true
+end
+
+-- Capture typed lines ahead of other input handlers, so a line an
+-- alias or script consumes is still recorded as you typed it.
+rune.hooks.on("input", function(input, context)
+    if recording and context.mode ~= "verbatim" and not input:match("^/") then
+        table.insert(recording.lines, input)
+    end
+end, { name = "macro-record", priority = 1 })
+
+local function record(name)
+    local ok, err = rune.macro.record(name)
+    if ok then
+        rune.echo(green("[Macro]") .. " recording " .. name .. dim(" (/macro stop to save)"))
+    else
+        rune.echo(red("[Error]") .. " " .. err)
+    end
+end
+
+local function stop()
+    local name, count = rune.macro.stop()
+    if not name then
+        if count then
+            rune.echo(red("[Error]") .. " " .. count)
+        else
+            rune.echo(green("[Macro]") .. " playback stopped")
+        end
+    elseif count > 0 then
+        rune.echo(green("[Macro]") .. " saved " .. name .. dim(" (" .. count .. " lines)"))
+    else
+        rune.echo(dim("[Macro] nothing recorded for " .. name))
+    end
+end
+
+local function play(name)
+    local ok, err = rune.macro.play(name)
+    if not ok then
+        rune.echo(red("[Error]") .. " " .. err)
+    end
+end
+
+rune.bind("alt+r", function() record(QUICK) end)
+rune.bind("alt+s", stop)
+rune.bind("alt+p", function() play(QUICK) end)
+
+rune.command.add("macro", function(args)
+    local sub, rest = args:match("^(%S*)%s*(.-)%s*$")
+    if sub == "" or sub == "list" then
+        local current = rune.macro.recording()
+        if current then
+            rune.echo(green("[Macro]") .. " recording " .. current)
+        end
+        local macros = rune.macro.list()
+        if #macros == 0 then
+            rune.echo(dim("[Macro] no saved macros") ..
+                "  (/macro record <name>, /macro stop, /macro play <name>)")
+        end
+        for _, m in ipairs(macros) do
+            rune.echo("  " .. m.name .. dim(" (" .. m.count .. " lines)"))
+        end
+    elseif sub == "record" and rest ~= "" then
+        record(rest)
+    elseif sub == "stop" then
+        stop()
+    elseif sub == "play" and rest ~= "" then
+        play(rest)
+    elseif sub == "show" and rest ~= "" then
+        local lines = rune.macro.get(rest)
+        if not lines then
+            rune.echo(red("[Error]") .. " no macro named " .. rest)
+            return
+        end
+        for i, line in ipairs(lines) do
+            rune.echo(dim(string.format("%3d ", i)) .. line)
+        end
+    elseif sub == "remove" and rest ~= "" then
+        if rune.macro.remove(rest) then
+            rune.echo(green("[Macro]") .. " removed " .. rest)
+        else
+            rune.echo(red("[Error]") .. " no macro named " .. rest)
+        end
+    else
+        rune.echo("[Usage] /macro [list] | /macro record <name> | /macro stop | " ..
+            "/macro play <name> | /macro show <name> | /macro remove <name>")
+    end
+end, "Record and replay input lines (/macro record <name>, /macro stop, /macro play <name>)")
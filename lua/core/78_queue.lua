@@ -0,0 +1,210 @@
+-- Command Queue
+-- requires: init, style, string, binds, bars, commands, send
+-- Commands waiting for a moment a script picks - regaining balance, a
+-- cooldown ending - rather than a fixed delay. Scripts add() commands
+-- and call next(), typically from a trigger, to send the oldest
+-- through rune.send. The "queue" bar previews what is about to fire
+-- (place it with rune.ui.layout), and alt+q opens a picker to cancel,
+-- fire, or reorder a queued command. The queue is held in the VM, so
+-- /reload empties it.
+--
+-- API:
+--   rune.queue.add(command, opts?)  -- queue a command; opts.front jumps the line
+--   rune.queue.next()               -- send the oldest command
+--   rune.queue.list()               -- copy of the queued commands, oldest first
+--   rune.queue.count()              -- how many are queued
+--   rune.queue.remove(index)        -- cancel one
+--   rune.queue.move(from, to)       -- reorder
+--   rune.queue.clear()              -- cancel all
+--   rune.queue.edit()               -- pick and act on a queued command
+
+local green, red, dim = rune.style.green, rune.style.red, rune.style.gray
+
+rune.queue = {}
+
+local queue = {}
+
+local function changed()
+    rune.ui.bar_dirty("queue")
+end
+
+local function check_index(fn, index)
+    if type(index) ~= "number" or index ~= math.floor(index) then
+        error("rune.queue." .. fn .. ": expected an integer index", 3)
+    end
+end
+
+-- Queue a command (sent later through rune.send, so aliases, ";" and
+-- #N repeats apply when it fires). opts.front = true puts it first.
+-- Returns its position.
+function rune.queue.add(command, opts)
+    if type(command) ~= "string" or command == "" then
+        error("rune.queue.add: expected a non-empty string", 2)
+    end
+    local pos = #queue + 1
+    if opts and opts.front then
+        pos = 1
+    end
+    table.insert(queue, pos, command)
+    changed()
+    return pos
+end
+
+-- Send the oldest queued command. Returns it, or nil when the queue is
+-- empty.
+function rune.queue.next()
+    local command = table.remove(queue, 1)
+    if not command then
+        return nil
+    end
+    changed()
+    rune.send(command)
+    return command
+end
+
+function rune.queue.list()
+    local copy = {}
+    for i, command in ipairs(queue) do
+        copy[i] = command
+    end
+    return copy
+end
+
+function rune.queue.count()
+    return #queue
+end
+
+-- Cancel the command at index. Returns it, or nil when there is none.
+function rune.queue.remove(index)
+    check_index("remove", index)
+    if index < 1 or index > #queue then
+        return nil
+    end
+    local command = table.remove(queue, index)
+    changed()
+    return command
+end
+
+-- Move the command at from to position to (clamped to the queue).
+-- Returns true, or false when from is out of range.
+function rune.queue.move(from, to)
+    check_index("move", from)
+    check_index("move", to)
+    if from < 1 or from > #queue then
+        return false
+    end
+    local command = table.remove(queue, from)
+    to = math.max(1, math.min(to, #queue + 1))
+    table.insert(queue, to, command)
+    changed()
+    return true
+end
+
+function rune.queue.clear()
+    local n = #queue
+    queue = {}
+    if n > 0 then
+        changed()
+    end
+    return n
+end
+
+-- Actions offered for a picked command, in menu order.
+local actions = {
+    { text = "Cancel", run = function(i) rune.queue.remove(i) end },
+    { text = "Fire now", run = function(i)
+        local command = rune.queue.remove(i)
+        if command then
+            rune.send(command)
+        end
+    end },
+    { text = "Move to front", run = function(i) rune.queue.move(i, 1) end },
+    { text = "Move up", run = function(i) rune.queue.move(i, i - 1) end },
+    { text = "Move down", run = function(i) rune.queue.move(i, i + 1) end },
+    { text = "Move to back", run = function(i) rune.queue.move(i, #queue) end },
+}
+
+local function pick_action(index, command)
+    local items = {}
+    for i, action in ipairs(actions) do
+        items[i] = { text = action.text, value = tostring(i) }
+    end
+    rune.ui.picker.show({
+        title = command,
+        items = items,
+        on_select = function(value)
+            -- The queue may have moved on while the menu was open.
+            if queue[index] ~= command then
+                return
+            end
+            actions[tonumber(value)].run(index)
+        end,
+    })
+end
+
+-- Pick a queued command, then what to do with it: cancel it, fire it
+-- now, or move it.
+function rune.queue.edit()
+    if #queue == 0 then
+        rune.echo(dim("[Queue] empty"))
+        return
+    end
+    local items = {}
+    for i, command in ipairs(queue) do
+        items[i] = { text = command, desc = "#" .. i, value = tostring(i) }
+    end
+    rune.ui.picker.show({
+        title = "Queue",
+        items = items,
+        on_select = function(value)
+            local index = tonumber(value)
+            if queue[index] then
+                pick_action(index, queue[index])
+            end
+        end,
+    })
+end
+
+-- The preview bar: "Queue 3: kill rat | bash | loot", cut to fit.
+rune.ui.bar("queue", function(width)
+    if #queue == 0 then
+        return ""
+    end
+    local text = "Queue " .. #queue .. ": " .. table.concat(queue, " | ")
+    text = rune.string.truncate(text, width)
+    return { left = dim(text) }
+end)
+
+rune.bind("alt+q", rune.queue.edit)
+
+rune.command.add("queue", function(args)
+    local sub, rest = args:match("^(%S*)%s*(.-)%s*$")
+    if sub == "" or sub == "list" then
+        if #queue == 0 then
+            rune.echo(dim("[Queue] empty") .. "  (/queue add <command>, /queue next)")
+        end
+        for i, command in ipairs(queue) do
+            rune.echo(dim(string.format("%3d ", i)) .. command)
+        end
+    elseif sub == "add" and rest ~= "" then
+        rune.echo(green("[Queue]") .. " #" .. rune.queue.add(rest) .. " " .. rest)
+    elseif sub == "next" then
+        if not rune.queue.next() then
+            rune.echo(dim("[Queue] empty"))
+        end
+    elseif sub == "remove" and tonumber(rest) then
+        local command = rune.queue.remove(math.floor(tonumber(rest)))
+        if command then
+            rune.echo(green("[Queue]") .. " cancelled " .. command)
+        else
+            rune.echo(red("[Error]") .. " nothing queued at #" .. rest)
+        end
+    elseif sub == "clear" then
+        rune.echo(green("[Queue]") .. " cleared " .. rune.queue.clear())
+    elseif sub == "edit" then
+        rune.queue.edit()
+    else
+        rune.echo("[Usage] /queue [list] | /queue add <command> | /queue next | " ..
+            "/queue remove <n> | /queue clear | /queue edit")
+    end
+end, "Queue commands to send later (/queue add <command>, /queue next)")
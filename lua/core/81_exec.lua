@@ -0,0 +1,81 @@
+-- Exec
+-- requires: init
+-- Run an external program and get its output back. Go owns the
+-- process (rune._exec): it runs off the session goroutine under a
+-- timeout, and stdout, stderr and the exit code come back through
+-- rune.execs._deliver on the session goroutine, like rune.http. This
+-- module owns the id -> callback map and checks the allowlist
+-- (rune.config.exec_allow) up front; Go checks it again before
+-- starting anything. Pending callbacks die with the VM on /reload.
+
+rune.execs = {}
+
+local pending = {}
+local next_id = 0
+
+local function allowed(cmd)
+    for _, name in ipairs(rune.config.exec_allow or {}) do
+        if name == cmd then
+            return true
+        end
+    end
+    return false
+end
+
+-- rune.exec(cmd, args?, callback?, opts?)
+-- args: list of strings, passed to the program as-is (no shell).
+-- callback: function(result, err). result is { stdout, stderr, code }
+-- when the program ran (any exit code); err is a message when it could
+-- not start, timed out, or wrote more than the output cap.
+-- opts: { timeout = seconds } (default 10).
+-- cmd must appear in rune.config.exec_allow.
+function rune.exec(cmd, args, callback, opts)
+    if type(args) == "function" and callback == nil then
+        callback = args
+        args = nil
+    end
+    if type(cmd) ~= "string" or cmd == "" then
+        error("rune.exec: cmd must be a non-empty string", 2)
+    end
+    if args ~= nil and type(args) ~= "table" then
+        error("rune.exec: args must be a list of strings", 2)
+    end
+    if callback ~= nil and type(callback) ~= "function" then
+        error("rune.exec: callback must be a function", 2)
+    end
+    if opts ~= nil and type(opts) ~= "table" then
+        error("rune.exec: opts must be a table", 2)
+    end
+    opts = opts or {}
+    if opts.timeout ~= nil and (type(opts.timeout) ~= "number" or opts.timeout <= 0) then
+        error("rune.exec: opts.timeout must be a positive number (seconds)", 2)
+    end
+    if not allowed(cmd) then
+        error("rune.exec: " .. cmd .. " is not in rune.config.exec_allow", 2)
+    end
+    local argv = {}
+    for i, a in ipairs(args or {}) do
+        if type(a) ~= "string" and type(a) ~= "number" then
+            error("rune.exec: args[" .. i .. "] must be a string", 2)
+        end
+        argv[i] = tostring(a)
+    end
+
+    next_id = next_id + 1
+    if callback then
+        pending[next_id] = callback
+    end
+    rune._exec.run(next_id, { command = cmd, args = argv, timeout = opts.timeout })
+end
+
+-- INTERNAL: called by Go when a program finishes. Exactly one of
+-- result/err is set. Unknown ids - callback-less runs, or runs started
+-- before a /reload - are dropped.
+function rune.execs._deliver(id, result, err)
+    local cb = pending[id]
+    if not cb then
+        return
+    end
+    pending[id] = nil
+    cb(result, err)
+end
@@ -1,15 +1,9 @@
 -- Default System Event Handlers
+-- requires: init, style, string, hooks, log, net
 -- Users can add handlers or override with lower priority.
 -- The status bar (95_ui.lua) renders reactively from rune.state, so
 -- these handlers only produce the scrollback notices.
 
--- Local echo styling. This is the only place the "> " prefix and its
--- color exist; return false from an earlier-priority handler to hide
--- an echo, or a string to restyle it.
-rune.hooks.on("echo", function(text)
-    return rune.style.green("> " .. text)
-end, { priority = 100 })
-
 -- First-run welcome: shown only while no init.lua exists, so new
 -- users learn where config lives and how to connect; it disappears
 -- the moment they create one.
@@ -36,22 +30,63 @@ rune.hooks.on("connecting", function(addr)
     rune.echo("[System] Connecting to " .. addr .. "...")
 end, { priority = 100 })
 
+-- Connection banners rule the transcript off at each connect and
+-- disconnect, so a scrollback or log spanning several sessions shows
+-- where each began and ended. Script output is logged only when the log
+-- asks for it, so otherwise the banner is written explicitly, in plain
+-- text - once either way.
+local function banner(what, color)
+    local fmt = rune.config.banner
+    if not fmt then
+        rune.echo("[System] " .. what)
+        return
+    end
+    local line = tostring(fmt):gsub("{(%a+)}", function(key)
+        if key == "what" then
+            return what
+        elseif key == "time" then
+            return os.date("%H:%M:%S")
+        end
+    end)
+    local fill = math.max((rune.term.width or 0) - rune.string.width(line), 4)
+    line = line .. string.rep("─", fill)
+    rune.echo(color(line))
+    if not rune.log._logs("script") then
+        rune.log.write(line)
+    end
+end
+
 rune.hooks.on("connected", function(addr)
-    rune.echo("[System] Connected to " .. addr)
     -- Remember durably so /reconnect works across /reload AND restarts
     rune.store.set("last_address", addr)
 end, { priority = 100 })
 
+rune.hooks.on("connected", function(addr)
+    banner("Connected to " .. addr, rune.style.cyan)
+end, { name = "connect-banner", priority = 100 })
+
 rune.hooks.on("disconnecting", function()
     rune.echo("[System] Disconnecting...")
 end, { priority = 100 })
 
-rune.hooks.on("disconnected", function()
-    rune.echo("[System] Disconnected")
-end, { priority = 100 })
+rune.hooks.on("disconnected", function(reason, err)
+    local label = rune.net.disconnect_labels[reason]
+    if not label then
+        banner("Disconnected", rune.style.yellow)
+    elseif err and err ~= "" then
+        banner("Disconnected (" .. label .. ": " .. err .. ")", rune.style.yellow)
+    else
+        banner("Disconnected (" .. label .. ")", rune.style.yellow)
+    end
+end, { name = "disconnect-banner", priority = 100 })
 
-rune.hooks.on("reloading", function()
-    rune.echo("[System] Reloading scripts...")
+-- path is the script whose change set off rune.autoreload, "" for /reload.
+rune.hooks.on("reloading", function(path)
+    if path and path ~= "" then
+        rune.echo("[System] " .. (path:match("[^/\\]+$") or path) .. " changed - reloading")
+    else
+        rune.echo("[System] Reloading scripts...")
+    end
 end, { priority = 100 })
 
 rune.hooks.on("reloaded", function()
@@ -1,4 +1,5 @@
 -- Input Field Enhancements
+-- requires: init, style, string, hooks, binds, commands
 -- Everything that happens WHILE typing (before Enter):
 --   - History navigation (Up/Down arrows)
 --   - Word navigation (Ctrl/Alt + Left/Right)
@@ -28,12 +29,196 @@ function rune.input.set_cursor(pos)
     rune._input.set_cursor(pos)
 end
 
+-- Insert text at the cursor, leaving the cursor after it.
+function rune.input.insert(text)
+    if type(text) ~= "string" then
+        error("rune.input.insert: expected a string", 2)
+    end
+    local current = rune.input.get()
+    local pos = rune.input.get_cursor()
+    rune.input.set(current:sub(1, pos) .. text .. current:sub(pos + 1))
+    rune.input.set_cursor(pos + #text)
+end
+
+-- Word boundaries. Whitespace always ends a word; rune.input.word_break
+-- adds punctuation, readline style. Every word operation - word(),
+-- word motion, delete_word, tab completion, and the composer's own
+-- word keys in Go - goes through this one set, so they agree.
+local word_break = ""
+local break_class = nil -- Lua pattern class for word_break, nil when empty
+
+-- Whether the one-byte string c ends a word.
+local function is_break(c)
+    return c:match("%s") ~= nil or (break_class ~= nil and c:match(break_class) ~= nil)
+end
+
+-- Set the characters that end a word besides whitespace ("" for
+-- whitespace only, the default), e.g. "'\"-./" so ctrl+w in
+-- `cast 'word-of-recall'` stops at each punctuation mark. Returns the
+-- current set when called without an argument.
+function rune.input.word_break(chars)
+    if chars == nil then
+        return word_break
+    end
+    if type(chars) ~= "string" then
+        error("rune.input.word_break: expected a string", 2)
+    end
+    if chars:find("[^\33-\126]") then
+        error("rune.input.word_break: expected printable ASCII characters", 2)
+    end
+    word_break = chars
+    break_class = chars ~= "" and "[" .. chars:gsub("%p", "%%%0") .. "]" or nil
+    rune._input.set_word_break(chars)
+end
+
+-- The set lives in this VM, so /reload starts the composer back at
+-- whitespace-only too rather than leaving it on a stale set.
+rune._input.set_word_break(word_break)
+
+-- The word under the cursor (or ending at it), bounded by whitespace
+-- and rune.input.word_break: word, start, finish as byte offsets with
+-- finish exclusive, so text:sub(start + 1, finish) == word. Between
+-- words the word is "" and start == finish == the cursor.
+function rune.input.word()
+    local text = rune.input.get()
+    local pos = rune.input.get_cursor()
+    local start, finish = pos, pos
+    while start > 0 and not is_break(text:sub(start, start)) do
+        start = start - 1
+    end
+    while finish < #text and not is_break(text:sub(finish + 1, finish + 1)) do
+        finish = finish + 1
+    end
+    return text:sub(start + 1, finish), start, finish
+end
+
+-- Replace the word under the cursor (see rune.input.word) with text,
+-- leaving the cursor after it. Between words this inserts.
+function rune.input.replace_word(text)
+    if type(text) ~= "string" then
+        error("rune.input.replace_word: expected a string", 2)
+    end
+    local current = rune.input.get()
+    local _, start, finish = rune.input.word()
+    rune.input.set(current:sub(1, start) .. text .. current:sub(finish + 1))
+    rune.input.set_cursor(start + #text)
+end
+
+-- Theme the input line: the text drawn before it (default "> ",
+-- may be styled) and the hint shown while it is empty. Both must be
+-- one line.
+local function check_line(fn, value)
+    if type(value) ~= "string" then
+        error("rune.input." .. fn .. ": expected a string", 3)
+    end
+    if value:find("[\r\n]") then
+        error("rune.input." .. fn .. ": must not contain line breaks", 3)
+    end
+end
+
+local input_prompt = "> " -- the last rune.input.prompt, for restoring
+
+function rune.input.prompt(text)
+    check_line("prompt", text)
+    input_prompt = text
+    rune._input.set_prompt(text)
+end
+
+-- The prompt rune.input.prompt last set. Pane input (95_ui.lua) swaps
+-- in its own while a pane is focused and puts this one back.
+function rune.input._prompt()
+    return input_prompt
+end
+
+function rune.input.placeholder(text)
+    check_line("placeholder", text)
+    rune._input.set_placeholder(text)
+end
+
+-- Mask the input line: while on, what is typed draws as '*'. For
+-- password entry; /secret set (below) uses it.
+function rune.input.mask(on)
+    rune._input.set_mask(on and true or false)
+end
+
+-- Suggest a completion of the input line, drawn dimmed past what is
+-- typed and accepted with Right or Tab at the end of the line. text is
+-- the whole suggested line; it shows only while it extends the input.
+-- nil or "" clears it.
+function rune.input.ghost(text)
+    text = text or ""
+    check_line("ghost", text)
+    rune._input.set_ghost(text)
+end
+
+-- Set how many submitted lines may wait while the engine is busy
+-- (default 256); lines past that are dropped with a warning and
+-- counted in rune.state.input_dropped. 0 drops at once.
+function rune.input.queue(limit)
+    if type(limit) ~= "number" or limit ~= math.floor(limit) or limit < 0 then
+        error("rune.input.queue: expected a non-negative integer", 2)
+    end
+    rune._input.set_queue(limit)
+end
+
 -- Open $EDITOR with the given initial text.
 -- Returns edited_text, ok.
 function rune.input.open_editor(initial)
     return rune._input.open_editor(initial)
 end
 
+-- ============================================================
+-- LOCAL ECHO
+-- How typed commands show in the output. This is the only place the
+-- "> " prefix and its color exist; rune.input.echo changes them, and
+-- an "echo" hook at a lower priority can still restyle or hide one
+-- echo. While the server suppresses echo (passwords) nothing is shown
+-- whatever these say.
+-- ============================================================
+
+local echo = { enabled = true, prefix = "> ", color = "green" }
+
+-- Turn local echo on or off and set its prefix and color (a rune.text
+-- color spec, or false for none). nil keeps a setting as it is.
+-- Returns the settings.
+function rune.input.echo(enabled, prefix, color)
+    if enabled ~= nil and type(enabled) ~= "boolean" then
+        error("rune.input.echo: expected true, false, or nil", 2)
+    end
+    if prefix ~= nil and type(prefix) ~= "string" then
+        error("rune.input.echo: prefix must be a string", 2)
+    end
+    if color and not pcall(rune.text.color, color) then
+        error("rune.input.echo: unknown color " .. tostring(color), 2)
+    end
+    if enabled ~= nil then
+        echo.enabled = enabled
+    end
+    if prefix ~= nil then
+        echo.prefix = prefix
+    end
+    if color ~= nil then
+        echo.color = color
+    end
+    return echo.enabled, echo.prefix, echo.color
+end
+
+rune.hooks.on("echo", function(text)
+    text = echo.prefix .. text
+    if echo.color then
+        text = rune.text.wrap(text, echo.color)
+    end
+    return text
+end, { priority = 100 })
+
+-- Hiding runs after logging (priority 200), so a suppressed echo is
+-- still written to the log.
+rune.hooks.on("echo", function()
+    if not echo.enabled then
+        return false
+    end
+end, { priority = 300 })
+
 -- ============================================================
 -- HISTORY NAVIGATION
 -- Implements zsh-style prefix-matching history navigation.
@@ -154,7 +339,9 @@ rune.bind("down", history_down)
 
 -- History search (Ctrl+R). Keeping selection beside the navigation state
 -- lets an entry chosen from the picker continue naturally with Up/Down and
--- return to the draft that was present before the picker opened.
+-- return to the draft that was present before the picker opened. Text
+-- already typed seeds the search, matched as a prefix like Up does;
+-- Ctrl+R inside the picker flips between prefix and fuzzy matching.
 rune.bind("ctrl+r", function()
     local history = rune._history.entries()
     local draft = rune.input.get()
@@ -173,6 +360,9 @@ rune.bind("ctrl+r", function()
     rune.ui.picker.show({
         title = "History",
         items = items,
+        max_results = 50,
+        query = draft,
+        match = draft ~= "" and "prefix" or "fuzzy",
         on_select = function(index)
             local history_index = tonumber(index)
             local entry = history[history_index]
@@ -206,12 +396,12 @@ rune.history_nav = {
 local function find_word_left(text, pos)
     if pos <= 0 then return 0 end
     local newPos = pos
-    -- Skip spaces (going backwards)
-    while newPos > 0 and text:sub(newPos, newPos) == " " do
+    -- Skip breaks (going backwards)
+    while newPos > 0 and is_break(text:sub(newPos, newPos)) do
         newPos = newPos - 1
     end
     -- Skip word characters
-    while newPos > 0 and text:sub(newPos, newPos) ~= " " do
+    while newPos > 0 and not is_break(text:sub(newPos, newPos)) do
         newPos = newPos - 1
     end
     return newPos
@@ -223,11 +413,11 @@ local function find_word_right(text, pos)
     if pos >= len then return len end
     local newPos = pos + 1
     -- Skip current word characters
-    while newPos <= len and text:sub(newPos, newPos) ~= " " do
+    while newPos <= len and not is_break(text:sub(newPos, newPos)) do
         newPos = newPos + 1
     end
-    -- Skip spaces
-    while newPos <= len and text:sub(newPos, newPos) == " " do
+    -- Skip breaks
+    while newPos <= len and is_break(text:sub(newPos, newPos)) do
         newPos = newPos + 1
     end
     return newPos - 1
@@ -249,6 +439,35 @@ function rune.input.word_right()
     rune.input.set_cursor(newPos)
 end
 
+-- ============================================================
+-- KILL RING
+-- Deleting a word or the line saves the text; yank puts the newest
+-- kill back at the cursor, and yank_pop - only straight after a yank
+-- - swaps it for the kill before, cycling (readline's Alt+Y).
+-- ============================================================
+
+local KILL_RING_SIZE = 16
+local kills = {}       -- newest last
+local last_yank = nil  -- {start, index, text, cursor} of the latest yank
+
+local function push_kill(s)
+    if s == "" then return end
+    kills[#kills + 1] = s
+    if #kills > KILL_RING_SIZE then
+        table.remove(kills, 1)
+    end
+end
+
+-- Replace text[start+1 .. start+#old] with new, leaving the cursor
+-- after it, and remember the result for yank_pop.
+local function put_yank(text, start, old_len, index)
+    local s = kills[index]
+    local result = text:sub(1, start) .. s .. text:sub(start + old_len + 1)
+    rune.input.set(result)
+    rune.input.set_cursor(start + #s)
+    last_yank = { start = start, index = index, text = result, cursor = start + #s }
+end
+
 -- Delete word before cursor
 function rune.input.delete_word()
     local text = rune.input.get()
@@ -258,23 +477,59 @@ function rune.input.delete_word()
     local newPos = find_word_left(text, pos)
     local before = text:sub(1, newPos)
     local after = text:sub(pos + 1)
+    push_kill(text:sub(newPos + 1, pos))
     rune.input.set(before .. after)
     rune.input.set_cursor(newPos)
 end
 
+-- Clear the whole input line, saving it to the kill ring.
+function rune.input.kill_line()
+    push_kill(rune.input.get())
+    rune.input.set("")
+end
+
+-- Insert the most recent kill at the cursor.
+function rune.input.yank()
+    if #kills == 0 then return end
+    put_yank(rune.input.get(), rune.input.get_cursor(), 0, #kills)
+end
+
+-- Straight after a yank, replace the yanked text with the previous
+-- kill, wrapping to the newest. Does nothing once the input has been
+-- edited or the cursor moved since.
+function rune.input.yank_pop()
+    local y = last_yank
+    if not y or #kills < 2 or kills[y.index] == nil
+        or rune.input.get() ~= y.text or rune.input.get_cursor() ~= y.cursor then
+        last_yank = nil
+        return
+    end
+    local index = y.index - 1
+    if index < 1 then
+        index = #kills
+    end
+    put_yank(y.text, y.start, #kills[y.index], index)
+end
+
 -- Escape: clear input
 rune.bind("escape", function()
     rune.input.set("")
 end)
 
 -- Line editing
-rune.bind("ctrl+u", function() rune.input.set("") end)
+rune.bind("ctrl+u", function() rune.input.kill_line() end)
+rune.bind("ctrl+y", function() rune.input.yank() end)
+rune.bind("alt+y", function() rune.input.yank_pop() end)
 
 -- Word navigation keybindings
 rune.bind("alt+left", function() rune.input.word_left() end)
 rune.bind("alt+right", function() rune.input.word_right() end)
 rune.bind("ctrl+left", function() rune.input.word_left() end)
 rune.bind("ctrl+right", function() rune.input.word_right() end)
+-- Readline's alt+b / alt+f. Bound here (not left to the text widget's
+-- own word motion) so every word key stops at the same boundaries.
+rune.bind("alt+b", function() rune.input.word_left() end)
+rune.bind("alt+f", function() rune.input.word_right() end)
 
 -- Delete word keybindings. Most terminals send ctrl+backspace as
 -- ctrl+h, so that combination cannot be bound distinctly.
@@ -282,24 +537,278 @@ rune.bind("ctrl+w", function() rune.input.delete_word() end)
 rune.bind("alt+backspace", function() rune.input.delete_word() end)
 
 -- Editor mode (Ctrl+E opens $EDITOR)
-rune.bind("ctrl+e", function()
+local function edit_input()
     local current = rune.input.get()
     local result, ok = rune.input.open_editor(current)
     if ok then
         rune.input.set(result)
     end
-end)
+end
+
+rune.bind("ctrl+e", edit_input)
+
+-- Multi-line paste policy. The paste is already in the verbatim
+-- composer (the "compose" default); "join" turns the draft into one
+-- command line for the usual delimiter splitting and aliases, and
+-- "editor" opens it in $EDITOR.
+rune.hooks.on("paste", function()
+    local mode = rune.config.paste
+    if mode == "join" then
+        local lines = {}
+        for _, line in ipairs(rune.string.split(rune.input.get(), "\n")) do
+            line = rune.string.trim(line)
+            if line ~= "" then
+                lines[#lines + 1] = line
+            end
+        end
+        rune._input.restore(table.concat(lines, rune.config.delimiter), "command")
+    elseif mode == "editor" then
+        edit_input()
+    end
+end, { name = "paste-mode", priority = 100 })
+
+-- ============================================================
+-- COMPOSE
+-- Long-form writing sent as one line: each submitted line joins a
+-- buffer until the sentinel ("." alone) ends it. The "compose" hook
+-- may rewrite or cancel the text (lines still joined by "\n"); any
+-- line breaks left become opts.join, the server's convention for
+-- them, and the result goes out after opts.prefix through
+-- rune.send_raw - no aliases or delimiter splitting. Slash commands
+-- still run while composing.
+-- ============================================================
+
+local compose = nil -- { prefix, join, sentinel, lines }
+
+local function compose_send(text, prefix, join)
+    local result, ok = rune.hooks.call("compose", text, prefix)
+    if not ok then
+        return nil
+    end
+    local line = result:gsub("\n", function() return join end)
+    if prefix ~= "" then
+        line = prefix .. " " .. line
+    end
+    rune.send_raw(line)
+    return line
+end
+
+-- Start composing. opts: prefix (command the text follows, e.g.
+-- "emote"), join (what replaces line breaks, default " "), sentinel
+-- (the line that ends it, default "."), editor (write it in $EDITOR
+-- instead). Returns the sent line when the editor finishes it.
+function rune.input.compose(opts)
+    opts = opts or {}
+    for _, key in ipairs({ "prefix", "join", "sentinel" }) do
+        if opts[key] ~= nil and type(opts[key]) ~= "string" then
+            error("rune.input.compose: " .. key .. " must be a string", 2)
+        end
+    end
+    if compose then
+        error("rune.input.compose: already composing", 2)
+    end
+    local prefix, join = opts.prefix or "", opts.join or " "
+    if opts.editor then
+        local text, ok = rune.input.open_editor("")
+        if ok and text:find("%S") then
+            return compose_send(text, prefix, join)
+        end
+        return nil
+    end
+    local sentinel = opts.sentinel or "."
+    compose = { prefix = prefix, join = join, sentinel = sentinel, lines = {} }
+    rune.echo(rune.style.green("[Compose]") .. " " ..
+        (prefix ~= "" and prefix .. " " or "") ..
+        rune.style.gray("(" .. sentinel .. " on its own line sends, /compose cancel discards)"))
+end
+
+local function compose_finish(pending)
+    local c = compose
+    compose = nil
+    if pending ~= "" then
+        table.insert(c.lines, pending)
+    end
+    local text = table.concat(c.lines, "\n")
+    if not text:find("%S") then
+        rune.echo(rune.style.gray("[Compose] nothing to send"))
+        return nil
+    end
+    return compose_send(text, c.prefix, c.join)
+end
+
+-- Send what has been composed, plus any text waiting in the input
+-- line, so a keybinding can end composition too. Returns the sent
+-- line, or nil when there was nothing to send.
+function rune.input.compose_finish()
+    if not compose then
+        return nil
+    end
+    local pending = rune.input.get()
+    rune.input.set("")
+    return compose_finish(pending)
+end
+
+-- Discard the composition. Returns how many lines were dropped, or nil
+-- when not composing.
+function rune.input.compose_cancel()
+    if not compose then
+        return nil
+    end
+    local n = #compose.lines
+    compose = nil
+    return n
+end
+
+-- Collect lines ahead of every other input handler but secret entry,
+-- so neither macros nor aliases see them. A multi-line paste adds each of its lines.
+rune.hooks.on("input", function(input, context)
+    if not compose or input:match("^/") then
+        return
+    end
+    if input == compose.sentinel then
+        compose_finish("")
+        return false
+    end
+    for _, line in ipairs(rune.string.split(input, "\n")) do
+        table.insert(compose.lines, line)
+    end
+    return false
+end, { name = "compose", priority = 0 })
+
+rune.command.add("compose", function(args)
+    if args == "cancel" then
+        local n = rune.input.compose_cancel()
+        if n then
+            rune.echo(rune.style.green("[Compose]") .. " discarded " .. n .. (n == 1 and " line" or " lines"))
+        else
+            rune.echo(rune.style.gray("[Compose] not composing"))
+        end
+    elseif args == "send" then
+        rune.input.compose_finish()
+    elseif compose then
+        rune.echo(rune.style.red("[Error]") .. " already composing (/compose send, /compose cancel)")
+    else
+        rune.input.compose({ prefix = args })
+    end
+end, "Compose a long line, e.g. /compose emote (. sends, /compose cancel)")
+
+-- ============================================================
+-- SECRET ENTRY
+-- /secret set <name> takes the next line typed as the value: it is
+-- masked while typed, stored rather than sent, echoed masked, and its
+-- history entry is dropped.
+-- Any echoed line that is exactly a stored secret is masked too, so a
+-- password typed at a server that leaves echo on stays out of
+-- scrollback and the log (the mask runs before log-echo).
+-- ============================================================
+
+local MASK = "********"
+local secret_entry = nil -- name awaiting a value, or nil
+
+-- The mask outlives the VM; a /reload drops any pending entry.
+rune.input.mask(false)
+
+rune.hooks.on("echo", function(text)
+    if secret_entry then
+        return MASK
+    end
+    for _, name in ipairs(rune.secret.list()) do
+        if rune.secret.get(name) == text then
+            return MASK
+        end
+    end
+end, { name = "secret-mask", priority = 50 })
+
+-- Priority -1: the value is read ahead of /compose (priority 0), so a
+-- secret set while composing is stored rather than composed.
+rune.hooks.on("input", function(text)
+    if not secret_entry then
+        return
+    end
+    local name = secret_entry
+    secret_entry = nil
+    rune.input.mask(false)
+    rune._history.forget(text)
+    if text == "" then
+        rune.echo(rune.style.gray("[Secret] nothing stored"))
+        return false
+    end
+    local ok, err = rune.secret.set(name, text)
+    if ok then
+        rune.echo(rune.style.green("[Secret]") .. " stored " .. name)
+    else
+        rune.echo(rune.style.red("[Error]") .. " " .. tostring(err))
+    end
+    return false
+end, { name = "secret-entry", priority = -1 })
+
+rune.command.add("secret", function(args)
+    local sub, name = args:match("^(%S*)%s*(%S*)$")
+    if sub == "" or sub == "list" then
+        local names = rune.secret.list()
+        if #names == 0 then
+            rune.echo(rune.style.gray("[Secret] none stored"))
+        else
+            rune.echo(rune.style.green("[Secret]") .. " " .. table.concat(names, ", "))
+        end
+    elseif sub == "set" and name ~= "" then
+        secret_entry = name
+        rune.input.mask(true)
+        rune.echo(rune.style.green("[Secret]") .. " type the value for " .. name .. " and press Enter")
+    elseif sub == "delete" and name ~= "" then
+        if not rune.secret.get(name) then
+            rune.echo(rune.style.red("[Error]") .. " no secret named " .. name)
+            return
+        end
+        local ok, err = rune.secret.delete(name)
+        if ok then
+            rune.echo(rune.style.green("[Secret]") .. " deleted " .. name)
+        else
+            rune.echo(rune.style.red("[Error]") .. " " .. tostring(err))
+        end
+    else
+        rune.echo("[Usage] /secret [list] | /secret set <name> | /secret delete <name>")
+    end
+end, "Store login credentials: /secret set <name> reads the value unechoed")
+
+-- ============================================================
+-- COMMAND GHOST
+-- A partly typed /command suggests the first registered command it
+-- prefixes as ghost text (rune.input.ghost). Only a ghost this
+-- handler set is cleared, so script ghosts are left alone.
+-- ============================================================
+
+local command_ghost = false
+
+rune.hooks.on("input_changed", function()
+    local partial = rune.input.get():match("^/(%S+)$")
+    if partial then
+        for _, c in ipairs(rune.command.list()) do
+            if c.enabled and #c.name > #partial and c.name:sub(1, #partial) == partial then
+                rune.input.ghost("/" .. c.name)
+                command_ghost = true
+                return
+            end
+        end
+    end
+    if command_ghost then
+        rune.input.ghost(nil)
+        command_ghost = false
+    end
+end, { name = "command-ghost", priority = 100 })
 
 -- ============================================================
 -- TAB COMPLETION
 -- Word cache from server output + Tab cycling
 -- ============================================================
 
-rune.completion = rune.completion or {}
+rune.completion = {}
 
--- Configuration
+-- Configuration (rune.completion.config tunes all but MIN_WORD_LEN)
 local MAX_WORDS = 5000
 local MIN_WORD_LEN = 3
+local min_prefix = 2   -- typed characters before matches are offered
+local enabled = true
 
 -- Data structures for word cache
 local cache = {}      -- lower -> {word=original, order=int}
@@ -311,6 +820,7 @@ local prefix_idx = {} -- 2-char -> set of lowercase words
 local order_counter = 0
 
 local function cache_add(word)
+    if not enabled then return end
     local lower = word:lower()
     if #lower < MIN_WORD_LEN then return end
 
@@ -343,7 +853,7 @@ local function cache_add(word)
 end
 
 local function cache_find(prefix)
-    if #prefix < 2 then return {} end
+    if #prefix < min_prefix then return {} end
 
     local lower_prefix = prefix:lower()
     local bucket = prefix_idx[lower_prefix:sub(1, 2)]
@@ -403,7 +913,8 @@ local function find_word_at_cursor()
 
     -- Find word start (scan backwards from cursor)
     local word_start = cursor
-    while word_start > 0 and text:sub(word_start, word_start):match("[%w_'%-]") do
+    while word_start > 0 and text:sub(word_start, word_start):match("[%w_'%-]")
+        and not is_break(text:sub(word_start, word_start)) do
         word_start = word_start - 1
     end
     word_start = word_start + 1
@@ -431,7 +942,7 @@ end
 local function update_matches()
     local word_start, word_end, prefix = find_word_at_cursor()
 
-    if #prefix < 2 then
+    if not enabled or #prefix < min_prefix then
         completion_reset()
         return
     end
@@ -448,9 +959,19 @@ local function update_matches()
     completion_state.word_end = word_end
 end
 
+-- The completion words in s: runs of letters, digits, _, ' and -,
+-- split again at any rune.input.word_break character so cached words
+-- match the prefixes find_word_at_cursor takes.
+local function words_of(s)
+    if break_class then
+        s = s:gsub(break_class, " ")
+    end
+    return s:gmatch("[%w_'%-]+")
+end
+
 -- Add words from server output
 rune.hooks.on("output", function(line)
-    for word in line:clean():gmatch("[%w_'%-]+") do
+    for word in words_of(line:clean()) do
         cache_add(word)
     end
 end, { name = "_completion_cache", priority = 200 })
@@ -458,7 +979,7 @@ end, { name = "_completion_cache", priority = 200 })
 -- Add words from user input. Must run below priority 100: the core
 -- send handler consumes every input, which ends the hook chain.
 rune.hooks.on("input", function(text)
-    for word in text:gmatch("[%w_'%-]+") do
+    for word in words_of(text) do
         cache_add(word)
     end
 end, { name = "_completion_input", priority = 50 })
@@ -516,3 +1037,59 @@ function rune.completion.clear_cache()
     prefix_idx = {}
     order_counter = 0
 end
+
+-- Re-size the word cache, keeping the most recent words that fit.
+local function resize_cache(size)
+    local words = {}
+    for lower, entry in pairs(cache) do
+        words[#words + 1] = { lower = lower, word = entry.word, order = entry.order }
+    end
+    table.sort(words, function(a, b) return a.order < b.order end)
+
+    MAX_WORDS = size
+    rune.completion.clear_cache()
+    for i = math.max(1, #words - size + 1), #words do
+        cache_add(words[i].word)
+    end
+end
+
+-- Tune completion. opts (all optional):
+--   min_len - typed characters before matches are offered (>= 2)
+--   cache   - how many words to remember (>= 1)
+--   enabled - false stops caching words and offering matches
+-- Returns the settings now in force.
+function rune.completion.config(opts)
+    if opts ~= nil and type(opts) ~= "table" then
+        error("rune.completion.config: expected a table", 2)
+    end
+    opts = opts or {}
+    local function check_int(key, min)
+        local v = opts[key]
+        if v ~= nil and (type(v) ~= "number" or v ~= math.floor(v) or v < min) then
+            error("rune.completion.config: " .. key .. " must be an integer >= " .. min, 3)
+        end
+        return v
+    end
+    local min_len = check_int("min_len", 2)
+    local size = check_int("cache", 1)
+    if opts.enabled ~= nil and type(opts.enabled) ~= "boolean" then
+        error("rune.completion.config: enabled must be a boolean", 2)
+    end
+
+    if min_len then
+        min_prefix = min_len
+    end
+    if opts.enabled ~= nil then
+        enabled = opts.enabled
+    end
+    if size and size ~= MAX_WORDS then
+        resize_cache(size)
+    end
+    if not enabled then
+        rune.completion.clear_cache()
+    end
+    if min_len or opts.enabled ~= nil then
+        completion_reset()
+    end
+    return { min_len = min_prefix, cache = MAX_WORDS, enabled = enabled }
+end
@@ -1,4 +1,5 @@
 -- UI: Panes, Status Bar, and Keybindings
+-- requires: init, style, regex, registry, hooks, binds, bars, timers, aliases, commands, worlds, net, send, input
 -- Everything visual: pane management, status rendering, picker bindings
 
 -- ============================================================
@@ -32,6 +33,54 @@ function rune.pane.clear(name)
     rune._pane.clear(name)
 end
 
+-- Cap a pane's buffer at lines lines and bytes bytes between them
+-- (defaults 1000 and 1 MiB); past either, the oldest lines go. nil
+-- leaves that cap as it was.
+function rune.pane.limit(name, lines, bytes)
+    for _, v in ipairs({ lines or 1, bytes or 1 }) do
+        if type(v) ~= "number" or v < 1 then
+            error("rune.pane.limit: lines and bytes must be positive numbers or nil", 2)
+        end
+    end
+    rune._pane.limit(name, math.floor(lines or 0), math.floor(bytes or 0))
+end
+
+-- Pin a pane's height in lines (header and border included, like a
+-- layout entry's height); nil hands it back to the layout. Clamped so
+-- the output viewport keeps at least one row.
+function rune.pane.resize(name, height)
+    if height ~= nil and (type(height) ~= "number" or height < 1) then
+        error("rune.pane.resize: height must be a positive number or nil", 2)
+    end
+    rune._pane.resize(name, height or 0)
+end
+
+-- Grow (negative rows shrink) a pane; nil name targets the focused
+-- pane - the one last shown, or set with rune.pane.focus.
+function rune.pane.grow(name, rows)
+    rune._pane.grow(name or "", rows or 1)
+end
+
+function rune.pane.shrink(name, rows)
+    rune._pane.grow(name or "", -(rows or 1))
+end
+
+function rune.pane.focus(name)
+    rune._pane.focus(name)
+end
+
+-- Activity on hidden panes: with a name, the number of writes since
+-- that pane was last shown (0 if none); without, a { name = count }
+-- table of every hidden pane with unread writes. The default status
+-- bar lists them.
+function rune.pane.unread(name)
+    local unread = rune._pane.unread()
+    if name == nil then
+        return unread
+    end
+    return unread[name] or 0
+end
+
 function rune.pane.scroll_up(name, lines)
     rune._pane.scroll_up(name, lines or 1)
 end
@@ -40,6 +89,27 @@ function rune.pane.scroll_down(name, lines)
     rune._pane.scroll_down(name, lines or 1)
 end
 
+-- Horizontal scrolling applies to "main" only, and only matters for
+-- rows wider than the window (see rune.ui.wrap).
+function rune.pane.scroll_left(name, cols)
+    rune._pane.scroll_columns(name, -(cols or 1))
+end
+
+function rune.pane.scroll_right(name, cols)
+    rune._pane.scroll_columns(name, cols or 1)
+end
+
+-- Scroll by a fraction of the viewport's height (default 1, a whole
+-- page; 0.5 is half a page), whatever the layout leaves it. "main"
+-- only, like horizontal scrolling.
+function rune.pane.page_up(name, pages)
+    rune._pane.scroll_pages(name, -(pages or 1))
+end
+
+function rune.pane.page_down(name, pages)
+    rune._pane.scroll_pages(name, pages or 1)
+end
+
 function rune.pane.scroll_to_top(name)
     rune._pane.scroll_to_top(name)
 end
@@ -48,6 +118,132 @@ function rune.pane.scroll_to_bottom(name)
     rune._pane.scroll_to_bottom(name)
 end
 
+function rune.pane.visible(name)
+    return rune._pane.visible(name)
+end
+
+-- ============================================================
+-- PANE INPUT
+-- A pane given an input target can take the input line: while it is
+-- focused, each line typed goes to its handler, or is sent with its
+-- command prefix ahead of it (aliases and ; still apply). Slash
+-- commands and empty lines still go through normal dispatch. A
+-- handler that keeps failing is disabled on its own, like any other
+-- callback, and the pane's lines are then dropped until the target is
+-- set again rather than reaching the server unprefixed. Alt+O
+-- cycles the main input and the visible panes that take input; the
+-- input prompt names the focused pane. The focus is kept in
+-- rune.session, so it survives /reload once the pane's target is
+-- registered again.
+-- ============================================================
+
+local FOCUS_KEY = "pane_input"
+
+local pane_inputs = {} -- pane name -> { target = prefix or handler, enabled, source }
+local input_pane = nil -- focused pane name, or nil for the main input
+
+local function set_input_pane(name)
+    input_pane = name
+    if name then
+        rune.session.set(FOCUS_KEY, name)
+        rune._pane.focus(name)
+        rune._input.set_prompt(rune.style.cyan(name) .. "> ")
+    else
+        rune.session.delete(FOCUS_KEY)
+        rune._input.set_prompt(rune.input._prompt())
+    end
+end
+
+-- Give pane an input target: a command prefix (a string) or a
+-- function(text) that handles each line. nil removes it, and moves
+-- the input back to main if the pane had it.
+function rune.pane.input(name, target)
+    if type(name) ~= "string" or name == "" or name == "main" then
+        error("rune.pane.input: expected a pane name", 2)
+    end
+    if target ~= nil and type(target) ~= "function" and (type(target) ~= "string" or target == "") then
+        error("rune.pane.input: target must be a command prefix or a function", 2)
+    end
+    pane_inputs[name] = target ~= nil
+        and { target = target, enabled = true, source = rune.caller_source(1) }
+        or nil
+    if target == nil and input_pane == name then
+        set_input_pane(nil)
+    end
+end
+
+-- With a name, focus that pane's input (showing the pane); false
+-- returns the input to main. Returns the focused pane, or nil for
+-- main.
+function rune.pane.input_focus(name)
+    if name == false then
+        set_input_pane(nil)
+    elseif name ~= nil then
+        if not pane_inputs[name] then
+            error("rune.pane.input_focus: pane " .. tostring(name) .. " has no input target", 2)
+        end
+        rune.pane.show(name)
+        set_input_pane(name)
+    end
+    return input_pane
+end
+
+-- Move the input to the next (step 1) or previous (-1) of: the main
+-- input, then each visible pane with an input target, by name.
+function rune.pane.cycle_input(step)
+    local order = { false }
+    local names = {}
+    for name in pairs(pane_inputs) do
+        if rune.pane.visible(name) then
+            names[#names + 1] = name
+        end
+    end
+    table.sort(names)
+    local at = 1
+    for i, name in ipairs(names) do
+        order[i + 1] = name
+        if name == input_pane then
+            at = i + 1
+        end
+    end
+    local next = order[(at - 1 + (step or 1)) % #order + 1]
+    set_input_pane(next or nil)
+    return input_pane
+end
+
+rune.hooks.on("input", function(text)
+    if not input_pane or text == "" or text:sub(1, 1) == "/" then
+        return
+    end
+    local entry = pane_inputs[input_pane]
+    if type(entry.target) ~= "function" then
+        rune.send(entry.target .. " " .. text)
+    elseif entry.enabled then
+        -- Guarded per pane, so a failing handler is charged to itself
+        -- rather than to this hook.
+        local label = 'Pane input "' .. input_pane .. '"' ..
+            (entry.source and (" @" .. entry.source) or "")
+        rune.guarded_call(label, entry, entry.target, text)
+    else
+        rune.echo(rune.style.red("[Error]") .. " the input handler of pane " .. input_pane ..
+            " is disabled (set it again with rune.pane.input)")
+    end
+    return false
+end, { name = "pane-input", priority = 5 })
+
+-- Reload: the fresh VM starts on main; take the pane back once the
+-- user's scripts have registered its target again.
+rune.hooks.on("ready", function()
+    local name = rune.session.get(FOCUS_KEY)
+    if name and pane_inputs[name] then
+        set_input_pane(name)
+    elseif name then
+        set_input_pane(nil)
+    end
+end, { name = "pane-input-restore", priority = 90 })
+
+rune.bind("alt+o", function() rune.pane.cycle_input(1) end)
+
 -- ============================================================
 -- CLIPBOARD
 -- ============================================================
@@ -60,16 +256,604 @@ function rune.clipboard.set(text)
     rune._ui.set_clipboard(text)
 end
 
+-- ============================================================
+-- PROMPT
+-- The server prompt shows as an overlay on the last output row until
+-- the next server line or your input commits it. rune.on_prompt is the
+-- "prompt" hook under its own name (line object in; false gags, a
+-- string rewrites). rune.prompt.gag() hides every prompt - for a
+-- status bar built from GMCP vitals - while prompt triggers and
+-- handlers still see each one before the gag.
+-- ============================================================
+
+function rune.on_prompt(handler, opts)
+    if type(handler) ~= "function" then
+        error("rune.on_prompt: handler must be a function", 2)
+    end
+    return rune.hooks.on("prompt", handler, opts)
+end
+
+rune.prompt = {}
+
+local prompt_gagged = false
+
+-- Hide server prompts, including the one showing now.
+function rune.prompt.gag()
+    prompt_gagged = true
+    rune._ui.clear_prompt()
+end
+
+-- Show server prompts again, from the next one.
+function rune.prompt.ungag()
+    prompt_gagged = false
+end
+
+function rune.prompt.gagged()
+    return prompt_gagged
+end
+
+-- Choose how prompts are found on servers that send no GA/EOR marks
+-- (a mark, once seen, always wins):
+--   "auto"    - any text left after a read is the prompt (default)
+--   "ga"      - only GA/EOR-terminated text is a prompt
+--   "regex"   - leftover text matching opts.pattern (a Go regex,
+--               matched against the clean text) is the prompt
+--   "timeout" - leftover text unchanged for opts.ms (default 250)
+-- Network-owned: applies to every connection and survives /reload.
+-- Returns true, or nil + error message.
+function rune.prompt.detect(mode, opts)
+    opts = opts or {}
+    if mode == "regex" and type(opts.pattern) ~= "string" then
+        return nil, "regex prompt detection needs opts.pattern"
+    end
+    return rune._net.prompt_detect(tostring(mode), opts.pattern or "", opts.ms or 250)
+end
+
+-- Prompt triggers: Go regexes matched against each prompt's clean
+-- text, and nothing else - for text prompts that carry vitals
+-- ("[HP=100 MP=50]>") on servers without GMCP. The action gets the
+-- captures (whole match at [0]) and a ctx with the line; it only
+-- reads, so what it returns is ignored. Ordinary triggers see prompts
+-- too, among all other lines.
+local prompt_triggers = rune.registry.new{ kind = "prompt trigger" }
+
+function rune.prompt.trigger(pattern, action, opts)
+    local ok, err = rune.regex.validate(pattern)
+    if not ok then
+        error("invalid prompt trigger pattern '" .. tostring(pattern) .. "': " .. tostring(err), 2)
+    end
+    if type(action) ~= "function" then
+        error("rune.prompt.trigger: action must be a function", 2)
+    end
+    return prompt_triggers:add({
+        pattern = pattern,
+        action = action,
+        source = rune.caller_source(1),
+    }, opts)
+end
+
+function rune.prompt.remove(name)
+    return prompt_triggers:remove(name)
+end
+
+function rune.prompt.enable(name)
+    return prompt_triggers:enable(name)
+end
+
+function rune.prompt.disable(name)
+    return prompt_triggers:disable(name)
+end
+
+-- Prompt triggers as {match, name, enabled, group, source, hits}.
+function rune.prompt.list()
+    local result = {}
+    for _, data in ipairs(prompt_triggers:items()) do
+        result[#result + 1] = {
+            match = data.pattern,
+            name = data.name,
+            enabled = data.enabled,
+            group = data.group,
+            source = data.source,
+            hits = data.hits,
+        }
+    end
+    return result
+end
+
+-- Before the core trigger pass (100) and the gag (1000).
+rune.hooks.on("prompt", function(line)
+    local clean = line:clean()
+    for _, data in ipairs(prompt_triggers:snapshot()) do
+        if prompt_triggers:active(data) then
+            local matches = rune.regex.match(data.pattern, clean)
+            if matches then
+                prompt_triggers:hit(data)
+                local label = 'Prompt trigger "' .. tostring(data.name or data.pattern) .. '"' ..
+                    (data.source and (" @" .. data.source) or "")
+                rune.guarded_call(label, data, data.action, matches, {
+                    line = line, name = data.name, group = data.group, type = "prompt",
+                })
+                if data.once then
+                    data._handle:remove()
+                end
+            end
+        end
+    end
+end, { name = "prompt-triggers", priority = 90 })
+
+rune.hooks.on("prompt", function()
+    if prompt_gagged then
+        return false
+    end
+end, { name = "prompt-gag", priority = 1000 })
+
+-- ============================================================
+-- BELL
+-- ============================================================
+
+-- Ring the bell. mode is "audible" (BEL to the terminal), "visual"
+-- (briefly invert the bars - for terminals that mute the bell),
+-- "both", or "none"; it defaults to rune.config.bell.
+function rune.bell(mode)
+    mode = mode or rune.config.bell
+    if mode == "none" then
+        return
+    end
+    local audible = mode == "audible" or mode == "both"
+    local visual = mode == "visual" or mode == "both"
+    if not audible and not visual then
+        error('rune.bell: mode must be "audible", "visual", "both", or "none"', 2)
+    end
+    rune._ui.bell(audible, visual)
+end
+
+-- ============================================================
+-- COPY MODE
+-- Keyboard selection over the output: arrows or j/k move, v marks,
+-- Enter/y copies, Esc cancels. Go owns the keys while it is active;
+-- the selected rows arrive as the copy hook, and where they go is
+-- policy here.
+-- ============================================================
+
+function rune.ui.copy_mode()
+    rune._ui.copy_mode()
+end
+
+rune.hooks.on("copy", function(text)
+    local gray = rune.style.gray
+    if not rune.config.clipboard then
+        rune.echo(gray("[Copy] clipboard disabled; selection:"))
+        rune.echo(text)
+        return
+    end
+    rune.clipboard.set(text)
+    local n = select(2, text:gsub("\n", "")) + 1
+    rune.echo(gray("[Copy] " .. n .. (n == 1 and " line" or " lines") .. " copied"))
+end, { name = "copy-selection", priority = 100 })
+
+rune.bind("alt+c", function() rune.ui.copy_mode() end)
+
+-- ============================================================
+-- LINKS
+-- The UI reports clicks on URLs (OSC 8 hyperlinks, or bare http(s)
+-- text) and on MXP send links as the link_clicked hook; opening or
+-- sending them is policy, here.
+-- ============================================================
+
+rune.link = {}
+
+-- Open an http(s) URL in the system browser. Returns true, or
+-- nil + error message.
+function rune.link.open(url)
+    return rune._ui.open_url(url)
+end
+
+rune.hooks.on("link_clicked", function(url, source)
+    if source == "send" or source == "text" and not rune.config.autolink then
+        return
+    end
+    local ok, err = rune.link.open(url)
+    if not ok then
+        rune.echo(rune.style.red("[Error]") .. " " .. tostring(err))
+    end
+end, { name = "open-link", priority = 100 })
+
+-- An MXP <send> link carries a command, not a URL: send it as is.
+rune.hooks.on("link_clicked", function(cmd, source)
+    if source == "send" then
+        rune.send_raw(cmd)
+    end
+end, { name = "mxp-send", priority = 100 })
+
+-- Recent bare URLs from server output, newest first: { url, line }.
+-- A URL seen again moves back to the front with its new line.
+local recent_urls = {}
+
+local function remember_url(url, line)
+    for i, entry in ipairs(recent_urls) do
+        if entry.url == url then
+            table.remove(recent_urls, i)
+            break
+        end
+    end
+    table.insert(recent_urls, 1, { url = url, line = line })
+    local limit = tonumber(rune.config.urls) or 0
+    while #recent_urls > limit do
+        table.remove(recent_urls)
+    end
+end
+
+-- Priority 200: lines gagged by earlier handlers never reach here.
+rune.hooks.on("output", function(line)
+    if (tonumber(rune.config.urls) or 0) <= 0 then
+        return
+    end
+    local clean = line:clean()
+    if not clean:find("://", 1, true) then
+        return
+    end
+    for _, url in ipairs(rune._find_urls(clean)) do
+        remember_url(url, clean)
+    end
+end, { name = "collect-urls", priority = 200 })
+
+-- The URLs collected from server output, newest first, as an array
+-- of { url, line } (line is the clean text it appeared in).
+function rune.link.recent()
+    local out = {}
+    for i, entry in ipairs(recent_urls) do
+        out[i] = { url = entry.url, line = entry.line }
+    end
+    return out
+end
+
+-- Pick a recent URL and open it, or with action "copy" put it on
+-- the clipboard.
+function rune.link.pick(action)
+    action = action or "open"
+    if action ~= "open" and action ~= "copy" then
+        error('rune.link.pick: action must be "open" or "copy"', 2)
+    end
+    if #recent_urls == 0 then
+        rune.echo(rune.style.gray("[Link] no URLs seen yet"))
+        return
+    end
+    local items = {}
+    for _, entry in ipairs(recent_urls) do
+        table.insert(items, { text = entry.url, desc = entry.line, value = entry.url })
+    end
+    rune.ui.picker.show({
+        title = action == "copy" and "Copy URL" or "Open URL",
+        items = items,
+        on_select = function(url)
+            if action == "copy" then
+                rune.clipboard.set(url)
+                rune.echo(rune.style.gray("[Link] copied " .. url))
+                return
+            end
+            local ok, err = rune.link.open(url)
+            if not ok then
+                rune.echo(rune.style.red("[Error]") .. " " .. tostring(err))
+            end
+        end,
+    })
+end
+
+rune.bind("alt+u", function() rune.link.pick() end)
+
+rune.command.add("urls", function(args)
+    if args.action and args.action ~= "copy" then
+        rune.echo("[Usage] /urls [copy]")
+        return
+    end
+    rune.link.pick(args.action or "open")
+end, "Pick a recent URL from the output to open (/urls copy to copy it)",
+    { args = { "action?" } })
+
 -- ============================================================
 -- PANE SCROLLING BINDINGS
 -- ============================================================
 
-rune.bind("pageup", function() rune.pane.scroll_up("main", 20) end)
-rune.bind("pagedown", function() rune.pane.scroll_down("main", 20) end)
+-- Lines pageup/pagedown move the output viewport: a fixed step, so
+-- the lines you were reading stay on screen as context.
+local scroll_step = 20
+
+-- Set the pageup/pagedown step in lines; with no argument, return it.
+function rune.ui.scroll_step(n)
+    if n == nil then
+        return scroll_step
+    end
+    if type(n) ~= "number" or n < 1 or n ~= math.floor(n) then
+        error("rune.ui.scroll_step: expected a positive whole number", 2)
+    end
+    scroll_step = n
+    return scroll_step
+end
+
+rune.bind("pageup", function() rune.pane.scroll_up("main", scroll_step) end)
+rune.bind("pagedown", function() rune.pane.scroll_down("main", scroll_step) end)
+rune.bind("ctrl+pageup", function() rune.pane.page_up("main", 0.5) end)
+rune.bind("ctrl+pagedown", function() rune.pane.page_down("main", 0.5) end)
+rune.bind("shift+up", function() rune.pane.scroll_up("main", 1) end)
+rune.bind("shift+down", function() rune.pane.scroll_down("main", 1) end)
 -- Bare Home/End are deliberately unbound: they fall through to the
 -- input widget as cursor-to-start/end, matching the composer's keymap.
 rune.bind("ctrl+home", function() rune.pane.scroll_to_top("main") end)
 rune.bind("ctrl+end", function() rune.pane.scroll_to_bottom("main") end)
+rune.bind("shift+left", function() rune.pane.scroll_left("main", 8) end)
+rune.bind("shift+right", function() rune.pane.scroll_right("main", 8) end)
+-- Grow/shrink the focused pane (the one last shown).
+rune.bind("ctrl+up", function() rune.pane.grow(nil, 1) end)
+rune.bind("ctrl+down", function() rune.pane.shrink(nil, 1) end)
+
+-- Scroll back to where the last command's output starts, marking that
+-- row until you return to live - for finding your place after a flood.
+function rune.ui.jump_to_input()
+    rune._ui.jump_to_input()
+end
+
+rune.bind("alt+up", function() rune.ui.jump_to_input() end)
+
+-- ============================================================
+-- REPEATED LINES
+-- ============================================================
+
+-- Collapse runs of identical server lines ("It is pitch black.") into
+-- one row with an " (xN)" counter that updates in place. Off by
+-- default: the raw stream is shown. Logs always get every line.
+function rune.ui.dedupe(on)
+    if type(on) ~= "boolean" then
+        error("rune.ui.dedupe: expected true or false", 2)
+    end
+    rune._ui.dedupe(on)
+end
+
+-- Flood ceiling: at most rate server lines are shown each second.
+-- Lines past it still run triggers and reach the log; each second's
+-- count is reported through the "throttled" hook, which prints
+-- "(N lines suppressed)" unless opts.summary is false. 0 or false
+-- removes the cap; with no argument, return it. Go keeps the cap and
+-- the summary setting lives in rune.session, so both survive /reload.
+local SUMMARY_KEY = "throttle_summary"
+
+function rune.ui.throttle(rate, opts)
+    if rate == nil then
+        return rune._ui.throttle()
+    end
+    if rate == false then
+        rate = 0
+    end
+    if type(rate) ~= "number" or rate < 0 or rate ~= math.floor(rate) then
+        error("rune.ui.throttle: expected a non-negative whole number of lines per second, or false", 2)
+    end
+    if opts ~= nil and type(opts) ~= "table" then
+        error("rune.ui.throttle: opts must be a table", 2)
+    end
+    if opts and opts.summary ~= nil then
+        if opts.summary then
+            rune.session.delete(SUMMARY_KEY)
+        else
+            rune.session.set(SUMMARY_KEY, "off")
+        end
+    end
+    return rune._ui.throttle(rate)
+end
+
+rune.hooks.on("throttled", function(count)
+    if rune.session.get(SUMMARY_KEY) ~= "off" then
+        local n = tonumber(count)
+        rune.echo(rune.style.gray("(" .. n .. (n == 1 and " line" or " lines") .. " suppressed)"))
+    end
+end, { name = "throttle-summary" })
+
+-- How long server output is batched before it renders, in ms. A
+-- longer window redraws less often - kinder to slow terminals and SSH
+-- at the cost of some latency. A screenful of waiting lines flushes
+-- early whatever the window.
+local flush_interval = 16
+
+-- Set the batch window (4-1000 ms); with no argument, return it.
+function rune.ui.flush_interval(ms)
+    if ms == nil then
+        return flush_interval
+    end
+    if type(ms) ~= "number" or ms ~= math.floor(ms) or ms < 4 or ms > 1000 then
+        error("rune.ui.flush_interval: expected a whole number of milliseconds from 4 to 1000", 2)
+    end
+    flush_interval = ms
+    rune._ui.flush_interval(ms)
+    return flush_interval
+end
+
+-- ============================================================
+-- THEME
+-- ============================================================
+
+-- Recolor the chrome the client draws itself (picker overlays, pane
+-- headers, the input's rules) - server text and bars are styled by
+-- their own scripts. Each call replaces the whole theme; keys left
+-- out, or rune.ui.theme(nil), keep the built-in colors. Colors are
+-- ANSI names ("blue", "bright_red"), 0-255, or "#rrggbb".
+function rune.ui.theme(colors)
+    if colors == nil then
+        colors = {}
+    elseif type(colors) ~= "table" then
+        error("rune.ui.theme: expected a table or nil", 2)
+    end
+    local ok, err = rune._ui.theme(colors)
+    if not ok then
+        error("rune.ui.theme: " .. err, 2)
+    end
+end
+
+-- ============================================================
+-- LINE WRAPPING
+-- ============================================================
+
+-- Soft-wrap new output at the window width (the default), or with
+-- false keep each line on one row, clipped at the right edge, for
+-- ASCII maps and wide tables; shift+left/right scroll sideways.
+-- Applies to rows printed from now on.
+function rune.ui.wrap(on)
+    if type(on) ~= "boolean" then
+        error("rune.ui.wrap: expected true or false", 2)
+    end
+    rune._ui.wrap(on)
+end
+
+-- ============================================================
+-- SPLIT VIEW
+-- While scrolled back, keep the newest output showing in a live
+-- region below a divider, so reading scrollback misses nothing. The
+-- setting is held in the session store ("split") so it agrees with
+-- the UI, which keeps it, across /reload.
+-- ============================================================
+
+-- Turn the split view on or off; with no argument, return whether it
+-- is on.
+function rune.ui.split(on)
+    if on == nil then
+        return rune.session.get("split") == "on"
+    end
+    if type(on) ~= "boolean" then
+        error("rune.ui.split: expected true or false", 2)
+    end
+    if on then
+        rune.session.set("split", "on")
+    else
+        rune.session.delete("split")
+    end
+    rune._ui.split(on)
+end
+
+rune.bind("alt+v", function() rune.ui.split(not rune.ui.split()) end)
+
+-- ============================================================
+-- SCREEN CLEARS
+-- ============================================================
+
+-- Scroll everything visible out of view, as a terminal clear does;
+-- older output stays in scrollback.
+function rune.ui.clear_screen()
+    rune._ui.clear_screen()
+end
+
+-- Clear the output on request, as rune.config.clear_key or mode says:
+-- "divider" rules off what came before with the time, so it can still
+-- be scrolled back to and searched; "screen" scrolls it out of view;
+-- "hard" drops it, scrollback and all.
+function rune.ui.clear(mode)
+    mode = mode or rune.config.clear_key
+    if mode == "divider" then
+        local stamp = " " .. os.date("%H:%M:%S") .. " "
+        local width = math.max((rune.term.width or 0) - #stamp - 2, 4)
+        rune.echo(rune.style.gray("──" .. stamp .. string.rep("─", width)))
+    elseif mode == "screen" then
+        rune.ui.clear_screen()
+    elseif mode == "hard" then
+        rune._ui.clear_scrollback()
+    else
+        error("rune.ui.clear: unknown mode '" .. tostring(mode) .. "' (divider, screen, or hard)", 2)
+    end
+end
+
+rune.bind("ctrl+l", function() rune.ui.clear() end)
+
+-- Server clear-screen sequences never reach a row (Go strips them so
+-- they cannot wipe the bars); the "clear" hook reports them instead.
+-- The policy is rune.config.clear, overridden by a `clear` field on
+-- the world entry for the current address: "strip" ignores the clear,
+-- "screen" clears the viewport for full-screen MUD interfaces.
+local function clear_policy()
+    local address = rune.state.address
+    for _, w in ipairs(rune.world.list()) do
+        if w.address == address then
+            local entry = rune.world.get(w.name)
+            if entry.clear ~= nil then
+                return entry.clear
+            end
+        end
+    end
+    return rune.config.clear
+end
+
+rune.hooks.on("clear", function()
+    if clear_policy() == "screen" then
+        rune.ui.clear_screen()
+    end
+end, { name = "screen-clear", priority = 100 })
+
+-- ============================================================
+-- SCROLLBACK
+-- lines() reads back the newest output lines - server text after
+-- triggers, echoes, and script prints - from a 1000-line copy the
+-- session keeps. grep() is a filtered dump of the output: every row
+-- matching a pattern, highlighted, in a pane or the output itself.
+-- Rows are matched one at a time, so a soft-wrapped line matches only
+-- within a row.
+-- ============================================================
+
+rune.scrollback = {}
+
+local origins = { server = true, echo = true, script = true }
+
+-- The newest n output lines (at most 1000), oldest first, as shown:
+-- a gagged line is not there, a rewritten one is. opts.clean strips
+-- the escape sequences; opts.origin keeps only the lines from the
+-- server, the local echo of typed input ("echo"), or scripts and the
+-- client ("script").
+function rune.scrollback.lines(n, opts)
+    if type(n) ~= "number" or n ~= math.floor(n) or n < 0 then
+        error("rune.scrollback.lines: expected a non-negative integer", 2)
+    end
+    local origin = opts ~= nil and opts.origin or nil
+    if origin ~= nil and not origins[origin] then
+        error('rune.scrollback.lines: origin must be "server", "echo", or "script"', 2)
+    end
+    return rune._ui.lines(n, opts ~= nil and opts.clean == true, origin)
+end
+
+local GREP_LIMIT = 100
+
+-- Dump the output rows matching the Go regex pattern. opts: pane (a
+-- pane name; cleared and shown first - default the output), limit
+-- (newest matches kept, default 100), color (the highlight, a color
+-- spec; default "inverse"). Rows an earlier grep wrote to the output
+-- are skipped. An invalid pattern raises.
+function rune.scrollback.grep(pattern, opts)
+    opts = opts or {}
+    local ok, err = rune.regex.validate(pattern)
+    if not ok then
+        error("rune.scrollback.grep: " .. tostring(err), 2)
+    end
+    local limit = opts.limit or GREP_LIMIT
+    if type(limit) ~= "number" or limit ~= math.floor(limit) or limit < 1 then
+        error("rune.scrollback.grep: limit must be a positive integer", 2)
+    end
+    if opts.pane ~= nil and type(opts.pane) ~= "string" then
+        error("rune.scrollback.grep: pane must be a string", 2)
+    end
+    local valid, on = pcall(rune.text.color, opts.color or "inverse")
+    if not valid then
+        error("rune.scrollback.grep: unknown color " .. tostring(opts.color), 2)
+    end
+    if opts.pane then
+        rune.pane.create(opts.pane)
+        rune.pane.clear(opts.pane)
+        rune.pane.show(opts.pane)
+    end
+    local label = rune.style.green("[Grep]") .. " " .. pattern
+    rune._ui.grep(rune.regex._compiled(pattern), on, label, opts.pane, limit)
+end
+
+rune.command.add("grep", function(args)
+    local ok, err = rune.regex.validate(args.pattern)
+    if not ok then
+        rune.echo(rune.style.red("[Error]") .. " " .. tostring(err))
+        return
+    end
+    rune.scrollback.grep(args.pattern)
+end, "Show the output lines matching a pattern", { args = { "pattern..." } })
 
 -- ============================================================
 -- STATUS BAR
@@ -102,6 +886,23 @@ local function render_completion_bar(width)
     return { left = left, right = right }
 end
 
+-- "chat 3 tells 1": hidden panes with unread writes, by name.
+local function render_unread()
+    local unread = rune.pane.unread()
+    local names = {}
+    for name in pairs(unread) do
+        names[#names + 1] = name
+    end
+    if #names == 0 then
+        return nil
+    end
+    table.sort(names)
+    for i, name in ipairs(names) do
+        names[i] = yellow(name) .. " " .. dim(tostring(unread[name]))
+    end
+    return table.concat(names, "  ")
+end
+
 -- Ctrl+C double-tap quit state
 local quit_pending = false
 
@@ -125,7 +926,7 @@ rune.bind("ctrl+c", function()
 end)
 
 -- Register the status bar renderer
--- This function is called by Session every 250ms to get current bar content
+-- Re-rendered whenever client state changes (see 35_bars.lua)
 rune.ui.bar("status", function(width)
     -- Check if we should show completion bar instead (only when cycling with multiple matches)
     local comp = rune.completion and rune.completion.state
@@ -141,17 +942,25 @@ rune.ui.bar("status", function(width)
         left = yellow("Press Ctrl+C again to exit")
     elseif state.connected then
         left = green("●") .. " " .. gray(state.address)
+    elseif state.connection == "connecting" then
+        left = yellow("●") .. " " .. gray("Connecting...")
     else
-        left = gray("●") .. " " .. gray("Disconnected")
+        local label = rune.net.disconnect_labels[state.disconnect_reason]
+        left = gray("●") .. " " .. gray(label and ("Disconnected (" .. label .. ")") or "Disconnected")
     end
 
     -- Right side: scroll mode indicator
     local right
     if state.scroll_mode == "scrolled" then
-        right = yellow("SCROLL") .. " " .. dim("(" .. state.scroll_lines .. " new)")
+        local pos = state.scroll_percent == 0 and "TOP" or (state.scroll_percent .. "%")
+        right = yellow("SCROLL") .. " " .. pos .. " " .. dim("(" .. state.scroll_lines .. " new)")
     else
         right = dim("LIVE")
     end
+    local unread = render_unread()
+    if unread then
+        right = unread .. "  " .. right
+    end
 
     return { left = left, right = right }
 end)
@@ -201,9 +1010,11 @@ rune.bind("/", function()
     -- Format for picker (include "/" in text/value for matching)
     local items = {}
     for _, c in ipairs(cmds) do
+        -- Lead with the argument usage ("<path...>") when there is one
+        local params = c.usage:sub(#c.name + 3)
         table.insert(items, {
             text = "/" .. c.name,
-            desc = c.description,
+            desc = params ~= "" and (params .. "  " .. c.description) or c.description,
             value = "/" .. c.name
         })
     end
@@ -0,0 +1,134 @@
+package lua
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// CoreOrder returns the Lua files in dir of scripts in load order. A
+// file may declare what it builds on in its leading comment block:
+//
+//	-- requires: hooks, registry
+//
+// naming other files by stem ("20_hooks") or by stem without its
+// numeric prefix ("hooks"). Dependencies load first; otherwise files
+// keep lexical order, so a tree with no declarations loads exactly as
+// sorted. An unknown name or a cycle is an error.
+//
+// Each embedded core file names every other core file it uses, at load
+// or later, as long as that file sorts ahead of it; what it names (and
+// what those name) is all it needs to load.
+func CoreOrder(scripts fs.FS, dir string) ([]string, error) {
+	entries, err := fs.ReadDir(scripts, dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	byName := make(map[string]string)
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".lua" {
+			continue
+		}
+		files = append(files, e.Name())
+		stem := strings.TrimSuffix(e.Name(), ".lua")
+		byName[stem] = e.Name()
+		if short := moduleName(stem); short != stem {
+			byName[short] = e.Name()
+		}
+	}
+	sort.Strings(files)
+
+	deps := make(map[string][]string, len(files))
+	for _, file := range files {
+		content, err := fs.ReadFile(scripts, path.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range requiresHeader(string(content)) {
+			dep, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("%s: requires unknown module %q", file, name)
+			}
+			if dep != file {
+				deps[file] = append(deps[file], dep)
+			}
+		}
+	}
+
+	// Depth-first from each file in lexical order: a file's
+	// dependencies are placed just ahead of it, and nothing moves
+	// when none are declared.
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(files))
+	order := make([]string, 0, len(files))
+	var visit func(file string, chain []string) error
+	visit = func(file string, chain []string) error {
+		switch state[file] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(chain, file), " -> "))
+		}
+		state[file] = visiting
+		for _, dep := range deps[file] {
+			if err := visit(dep, append(chain, file)); err != nil {
+				return err
+			}
+		}
+		state[file] = done
+		order = append(order, file)
+		return nil
+	}
+	for _, file := range files {
+		if err := visit(file, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// moduleName strips a numeric load-order prefix: "20_hooks" -> "hooks".
+func moduleName(stem string) string {
+	i := 0
+	for i < len(stem) && stem[i] >= '0' && stem[i] <= '9' {
+		i++
+	}
+	if i > 0 && i < len(stem) && stem[i] == '_' {
+		return stem[i+1:]
+	}
+	return stem
+}
+
+// requiresHeader collects the names on "-- requires:" lines in the
+// comment block a file opens with.
+func requiresHeader(content string) []string {
+	var names []string
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		comment, ok := strings.CutPrefix(line, "--")
+		if !ok {
+			break
+		}
+		list, ok := strings.CutPrefix(strings.TrimSpace(comment), "requires:")
+		if !ok {
+			continue
+		}
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,132 @@
+package lua
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestCoreOrder verifies declared dependencies load first, by either
+// name form, and that everything else keeps lexical order.
+func TestCoreOrder(t *testing.T) {
+	scripts := fstest.MapFS{
+		"core/00_init.lua":  {Data: []byte("-- Init\nrune = {}\n")},
+		"core/10_queue.lua": {Data: []byte("-- Queue\n-- requires: 50_bars, hooks\n--\n-- requires: init\nlocal x = 1\n")},
+		"core/20_hooks.lua": {Data: []byte("-- Hooks\n")},
+		"core/50_bars.lua":  {Data: []byte("-- Bars\n-- requires: hooks\nlocal y\n-- requires: init\n")},
+		"core/README.md":    {Data: []byte("not a script")},
+	}
+	got, err := CoreOrder(scripts, "core")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"00_init.lua", "20_hooks.lua", "50_bars.lua", "10_queue.lua"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("order = %v, want %v", got, want)
+	}
+
+	scripts["core/20_hooks.lua"] = &fstest.MapFile{Data: []byte("-- requires: queue\n")}
+	if _, err := CoreOrder(scripts, "core"); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("cycle: err = %v", err)
+	}
+	scripts["core/20_hooks.lua"] = &fstest.MapFile{Data: []byte("-- requires: timers\n")}
+	if _, err := CoreOrder(scripts, "core"); err == nil || !strings.Contains(err.Error(), `"timers"`) {
+		t.Errorf("unknown module: err = %v", err)
+	}
+}
+
+// TestCoreOrderMatchesNumbering verifies the embedded core's declared
+// dependencies agree with its filename numbering, so contributors can
+// still read the load order off a directory listing.
+func TestCoreOrderMatchesNumbering(t *testing.T) {
+	got, err := CoreOrder(CoreScripts, "core")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.IsSorted(got) {
+		t.Fatalf("core loads out of filename order: %v", got)
+	}
+}
+
+// TestCoreRequiresDeclared loads each core module after only the
+// modules it declares it requires (and theirs), with every rune table
+// those left behind made strict, so a module that reads something an
+// undeclared module defines fails to load.
+func TestCoreRequiresDeclared(t *testing.T) {
+	order, err := CoreOrder(CoreScripts, "core")
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]string)
+	requires := make(map[string][]string)
+	for _, file := range order {
+		stem := strings.TrimSuffix(file, ".lua")
+		byName[stem], byName[moduleName(stem)] = file, file
+		content, err := CoreScripts.ReadFile("core/" + file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		requires[file] = requiresHeader(string(content))
+	}
+
+	for _, file := range order {
+		t.Run(file, func(t *testing.T) {
+			needed := make(map[string]bool)
+			var add func(file string)
+			add = func(file string) {
+				for _, name := range requires[file] {
+					if dep := byName[name]; !needed[dep] {
+						needed[dep] = true
+						add(dep)
+					}
+				}
+			}
+			add(file)
+
+			engine := NewEngine(NewMockHost())
+			if err := engine.Init(); err != nil {
+				t.Fatal(err)
+			}
+			defer engine.Close()
+			for _, dep := range order {
+				if needed[dep] {
+					loadCoreFile(t, engine, dep)
+				}
+			}
+			if err := engine.DoString("strict", strictRune); err != nil {
+				t.Fatal(err)
+			}
+			loadCoreFile(t, engine, file)
+		})
+	}
+}
+
+// strictRune makes reading a missing field of rune, or of any table
+// already in it, an error naming the field.
+const strictRune = `
+	local function strict(t, name)
+		if getmetatable(t) == nil then
+			setmetatable(t, { __index = function(_, k)
+				error("undeclared " .. name .. "." .. tostring(k), 2)
+			end })
+		end
+	end
+	for k, v in pairs(rune) do
+		if type(v) == "table" then
+			strict(v, "rune." .. k)
+		end
+	end
+	strict(rune, "rune")
+`
+
+func loadCoreFile(t *testing.T, engine *Engine, file string) {
+	t.Helper()
+	content, err := CoreScripts.ReadFile("core/" + file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.DoString(file, string(content)); err != nil {
+		t.Fatalf("%s: %v", file, err)
+	}
+}
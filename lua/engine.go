@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -36,6 +37,9 @@ type Engine struct {
 	// Layout config, marshaled from rune.ui.layout calls
 	barLayout ui.LayoutConfig
 
+	// Absolute paths of the files DoFile ran since Init, in load order
+	scripts []string
+
 	// Watchdog
 	CallTimeout time.Duration      // Time budget per Lua entry; see DefaultCallTimeout
 	inLua       bool               // True while inside a guarded Lua call (re-entrancy)
@@ -48,6 +52,10 @@ type Engine struct {
 	// True while dispatching the "error" event, so failures inside
 	// error handlers print directly instead of recursing.
 	reportingError bool
+
+	// True while dispatching the "print" event, so a handler that
+	// prints shows its text as is instead of recursing.
+	printing bool
 }
 
 // NewEngine creates an Engine with a Host interface.
@@ -136,6 +144,7 @@ func (e *Engine) Init() error {
 
 	e.barLayout = ui.DefaultLayoutConfig()
 	e.hooksBrokenReported = false
+	e.scripts = nil
 
 	registerLineType(e.L)
 	e.registerAPIs()
@@ -176,6 +185,26 @@ func (e *Engine) OnTimer(id int) {
 	}
 }
 
+// OnTick fires the "tick" hook for one beat of the shared heartbeat.
+// The count reaches Lua as a number, which CallHook cannot pass.
+func (e *Engine) OnTick(count int) {
+	hooksCall, ok := e.getHooksCall()
+	if !ok {
+		e.reportHooksBroken()
+		return
+	}
+
+	if err := e.guard(func() error {
+		return e.L.CallByParam(glua.P{
+			Fn:      hooksCall,
+			NRet:    0,
+			Protect: true,
+		}, glua.LString("tick"), glua.LNumber(count))
+	}); err != nil {
+		e.reportError("'tick' hook", err)
+	}
+}
+
 // RegisterPickerCallback stores a Lua function for later execution when the
 // picker selection is made. Returns a unique callback ID.
 func (e *Engine) RegisterPickerCallback(fn *glua.LFunction) string {
@@ -242,9 +271,19 @@ func (e *Engine) DoFile(path string) error {
 		defer e.L.SetField(pkg, "path", glua.LString(oldPath))
 	}
 
+	if !slices.Contains(e.scripts, absPath) {
+		e.scripts = append(e.scripts, absPath)
+	}
 	return e.guard(func() error { return e.L.DoFile(absPath) })
 }
 
+// LoadedScripts returns the absolute paths of the files DoFile ran
+// since the last Init (init.lua, rune.load), including ones that
+// failed, in load order.
+func (e *Engine) LoadedScripts() []string {
+	return slices.Clone(e.scripts)
+}
+
 // OnInput handles traditional command input. It remains as a convenience for
 // callers that do not need to construct an explicit submission.
 func (e *Engine) OnInput(text string) {
@@ -374,12 +413,14 @@ func (e *Engine) OnOutput(line text.Line) (string, bool) {
 	return modified.String(), true
 }
 
-// OnPrompt handles server prompts.
-func (e *Engine) OnPrompt(line text.Line) string {
+// OnPrompt handles server prompts. Like OnOutput, it returns the
+// (possibly rewritten) text and whether to show it; false means a
+// "prompt" hook gagged it.
+func (e *Engine) OnPrompt(line text.Line) (string, bool) {
 	hooksCall, ok := e.getHooksCall()
 	if !ok {
 		e.reportHooksBroken()
-		return line.Raw
+		return line.Raw, true
 	}
 
 	lineUD := newLine(e.L, line)
@@ -392,7 +433,7 @@ func (e *Engine) OnPrompt(line text.Line) string {
 		}, glua.LString("prompt"), lineUD)
 	}); err != nil {
 		e.reportError("prompt dispatch", err)
-		return line.Raw
+		return line.Raw, true
 	}
 
 	show := e.L.Get(-1)
@@ -400,9 +441,48 @@ func (e *Engine) OnPrompt(line text.Line) string {
 	e.L.Pop(2)
 
 	if show == glua.LFalse {
-		return ""
+		return "", false
+	}
+	return modified.String(), true
+}
+
+// printScript shows text a script printed (rune.echo) after the
+// "print" hook, which sees it as a line object with origin "script"
+// and may restyle or hide it. A print from inside that dispatch skips
+// the hook. Engine messages (errors, degraded-mode notices) go to the
+// host directly and never reach the hook.
+func (e *Engine) printScript(msg string) {
+	hooksCall, ok := e.getHooksCall()
+	if !ok || e.printing {
+		e.host.Print(msg)
+		return
+	}
+	e.printing = true
+	defer func() { e.printing = false }()
+
+	line := text.NewLine(msg)
+	line.Origin = text.OriginScript
+	lineUD := newLine(e.L, line)
+
+	if err := e.guard(func() error {
+		return e.L.CallByParam(glua.P{
+			Fn:      hooksCall,
+			NRet:    2,
+			Protect: true,
+		}, glua.LString("print"), lineUD)
+	}); err != nil {
+		e.reportError("print dispatch", err)
+		e.host.Print(msg)
+		return
+	}
+
+	show := e.L.Get(-1)
+	modified := e.L.Get(-2)
+	e.L.Pop(2)
+
+	if show != glua.LFalse {
+		e.host.Print(modified.String())
 	}
-	return modified.String()
 }
 
 // OnGMCP dispatches a GMCP message to Lua: the raw JSON is decoded
@@ -440,6 +520,27 @@ func (e *Engine) OnGMCP(pkg, raw string) {
 	}
 }
 
+// OnTelnet delivers a received option negotiation (command is "will",
+// "wont", "do" or "dont") to rune.telnet handlers (71_telnet.lua).
+func (e *Engine) OnTelnet(command string, option byte) {
+	if e.L == nil {
+		return
+	}
+	dispatch, ok := e.getRuneFunc("telnet", "_dispatch")
+	if !ok {
+		return
+	}
+	if err := e.guard(func() error {
+		return e.L.CallByParam(glua.P{
+			Fn:      dispatch,
+			NRet:    0,
+			Protect: true,
+		}, glua.LNumber(option), glua.LString(command))
+	}); err != nil {
+		e.reportError("telnet dispatch", err)
+	}
+}
+
 // escapeRawJSONControlsInStrings tolerates servers which put terminal control
 // bytes directly in JSON strings instead of encoding them. Aardwolf does this
 // with ANSI ESC bytes in colored Comm.Channel messages. Escaping them for the
@@ -528,7 +629,9 @@ func (e *Engine) registerAPIs() {
 	e.L.SetField(e.runeTable, "version", glua.LString(version.Number))
 
 	e.registerCoreFuncs()
+	e.registerLineFuncs()
 	e.registerTimerFuncs()
+	e.registerTickFuncs()
 	e.registerRegexFuncs()
 	e.registerUIFuncs()
 	e.registerStateFuncs()
@@ -538,20 +641,25 @@ func (e *Engine) registerAPIs() {
 	e.registerInputFuncs()
 	e.registerSessionFuncs()
 	e.registerStoreFuncs()
+	e.registerSecretFuncs()
 	e.registerLogFuncs()
+	e.registerTraceFuncs()
 	e.registerGMCPFuncs()
+	e.registerNetFuncs()
 	e.registerHTTPFuncs()
+	e.registerExecFuncs()
 }
 
 // getRuneFunc returns rune.<table>.<field> if it is a function.
 // Returns false when the module is unavailable - because a core
-// script failed to load, or a user script clobbered the table.
+// script failed to load, or a user script clobbered the table. The
+// lookups are raw, so no metamethod runs outside the watchdog.
 func (e *Engine) getRuneFunc(table, field string) (glua.LValue, bool) {
-	tbl, ok := e.L.GetField(e.runeTable, table).(*glua.LTable)
+	tbl, ok := e.runeTable.RawGetString(table).(*glua.LTable)
 	if !ok {
 		return glua.LNil, false
 	}
-	fn := e.L.GetField(tbl, field)
+	fn := tbl.RawGetString(field)
 	if fn.Type() != glua.LTFunction {
 		return glua.LNil, false
 	}
@@ -334,6 +334,36 @@ func TestEchoHookStylesAndGags(t *testing.T) {
 	}
 }
 
+// TestInputEchoSettings verifies rune.input.echo restyles the local
+// echo, hides it when disabled, and rejects bad arguments.
+func TestInputEchoSettings(t *testing.T) {
+	engine, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		local on, prefix, color = rune.input.echo(nil, "$ ", false)
+		assert(on == true and prefix == "$ " and color == false)
+	`)
+	if styled, show := engine.OnEcho("look"); !show || styled != "$ look" {
+		t.Errorf("restyled echo = %q, %v; want plain \"$ look\"", styled, show)
+	}
+
+	assertLua(t, engine, `rune.input.echo(true, nil, "cyan")`)
+	if styled, _ := engine.OnEcho("look"); styled != "\x1b[36m$ look\x1b[0m" {
+		t.Errorf("colored echo = %q", styled)
+	}
+
+	assertLua(t, engine, `
+		rune.input.echo(false)
+		assert(not pcall(rune.input.echo, "yes"))
+		assert(not pcall(rune.input.echo, nil, 5))
+		assert(not pcall(rune.input.echo, nil, nil, "nosuchcolor"))
+	`)
+	if _, show := engine.OnEcho("look"); show {
+		t.Error("echo disabled with rune.input.echo(false) still shown")
+	}
+}
+
 func TestEchoVisualizesTerminalControlsBeforeHooksAndFallback(t *testing.T) {
 	engine, _, cleanup := setupTest(t)
 	defer cleanup()
@@ -377,7 +407,7 @@ func TestFailingBarIsQuarantined(t *testing.T) {
 	}
 
 	for i := 0; i < 3; i++ {
-		engine.RenderBars(80)
+		engine.RenderBars(80, false)
 	}
 
 	disabled := false
@@ -391,7 +421,7 @@ func TestFailingBarIsQuarantined(t *testing.T) {
 	}
 
 	// The quarantined bar no longer renders or reports
-	if content := engine.RenderBars(80); content != nil {
+	if content := engine.RenderBars(80, false); content != nil {
 		if _, ok := content["hp"]; ok {
 			t.Error("quarantined bar still rendering")
 		}
@@ -406,12 +436,94 @@ func TestFailingBarIsQuarantined(t *testing.T) {
 	if err := engine.DoString("rereg", `rune.ui.bar("hp", function() return "HP 100" end)`); err != nil {
 		t.Fatalf("re-register failed: %v", err)
 	}
-	content := engine.RenderBars(80)
+	content := engine.RenderBars(80, false)
 	if content == nil || content["hp"].Left != "HP 100" {
 		t.Errorf("re-registered bar did not render, got %v", content)
 	}
 }
 
+// TestBarRendersWhenDirty verifies renderers run again only once
+// their bar is marked dirty or a full render is asked for, that clean
+// bars keep their last result, and that marking asks the host for a
+// render.
+func TestBarRendersWhenDirty(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		calls = { hp = 0, clock = 0 }
+		rune.ui.bar("hp", function() calls.hp = calls.hp + 1; return "HP " .. calls.hp end)
+		rune.ui.bar("clock", function() calls.clock = calls.clock + 1; return "tick" end)
+	`)
+	host.BarsDirty = 0
+
+	engine.RenderBars(80, false)
+	if got := engine.RenderBars(80, false)["hp"]; got.Left != "HP 1" {
+		t.Fatalf("clean bar = %+v, want its first result reused", got)
+	}
+	assertLua(t, engine, `assert(calls.hp == 1 and calls.clock == 1)`)
+
+	assertLua(t, engine, `rune.ui.bar_dirty("hp")`)
+	if host.BarsDirty != 1 {
+		t.Fatalf("BarsDirty = %d, want the host asked once", host.BarsDirty)
+	}
+	if got := engine.RenderBars(80, false)["hp"]; got.Left != "HP 2" {
+		t.Fatalf("dirty bar = %+v, want a fresh render", got)
+	}
+	assertLua(t, engine, `assert(calls.hp == 2 and calls.clock == 1, "clean bar re-rendered")`)
+
+	engine.RenderBars(80, true)
+	assertLua(t, engine, `assert(calls.hp == 3 and calls.clock == 2, "full render skipped a bar")`)
+}
+
+// TestSegmentBar verifies that a bar assembled from named segments
+// joins them per side with its separator, rebuilds only after an
+// update, and yields to a registered renderer of the same name.
+func TestSegmentBar(t *testing.T) {
+	engine, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("segments", `
+		rune.ui.segments("vitals", { separator = " | " })
+		rune.ui.segment("vitals", "hp", "HP 100")
+		rune.ui.segment("vitals", "sp", "SP 50")
+		rune.ui.segment("vitals", "clock", "12:00", { side = "right" })
+		rune.ui.segment("vitals", "exp", "XP 9", { order = 0 })
+
+		local first = rune.bars._render_all(80).vitals
+		assert(first.left == "XP 9 | HP 100 | SP 50", "left: " .. first.left)
+		assert(first.right == "12:00", "right: " .. first.right)
+
+		-- Clean bars reuse their last join; an update marks them dirty.
+		assert(rune.bars._render_all(80).vitals == first, "clean bar was rebuilt")
+		rune.ui.segment("vitals", "hp", "HP 100")
+		assert(rune.bars._render_all(80).vitals == first, "same text marked dirty")
+		rune.ui.segment("vitals", "hp", "HP 42")
+		rune.ui.segment("vitals", "exp", nil)
+		local second = rune.bars._render_all(80).vitals
+		assert(second ~= first and second.left == "HP 42 | SP 50", "left: " .. second.left)
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	content := engine.RenderBars(80, false)
+	if got := content["vitals"]; got.Left != "HP 42 | SP 50" || got.Right != "12:00" {
+		t.Errorf("vitals = %+v", got)
+	}
+
+	if err := engine.DoString("renderer", `rune.ui.bar("vitals", function() return "rendered" end)`); err != nil {
+		t.Fatal(err)
+	}
+	if got := engine.RenderBars(80, false)["vitals"]; got.Left != "rendered" {
+		t.Errorf("renderer should take precedence over segments, got %+v", got)
+	}
+
+	err := engine.DoString("bad side", `rune.ui.segment("vitals", "x", "y", { side = "top" })`)
+	if err == nil || !strings.Contains(err.Error(), "side must be") {
+		t.Errorf("expected side validation error, got %v", err)
+	}
+}
+
 // TestInvalidRegexFailsAtRegistration verifies that a bad pattern is a
 // loud error at trigger/alias creation, not a trigger that never fires.
 func TestInvalidRegexFailsAtRegistration(t *testing.T) {
@@ -470,6 +582,7 @@ func TestRegistrationsRecordSource(t *testing.T) {
 		rune.trigger.contains("x", "look", {name = "t"})
 		rune.alias.exact("n", "north", {name = "a"})
 		rune.timer.after(60, function() end, {name = "tm"})
+		rune.idle(300, "save", {name = "i"})
 
 		local function source_of(list, name)
 			for _, item in ipairs(list) do
@@ -484,6 +597,7 @@ func TestRegistrationsRecordSource(t *testing.T) {
 			{"trigger", rune.trigger.list(), "t"},
 			{"alias", rune.alias.list(), "a"},
 			{"timer", rune.timer.list(), "tm"},
+			{"idle", rune.idles.list(), "i"},
 		}) do
 			local src = source_of(entry[2], entry[3])
 			assert(src and src:find("attr_test"),
@@ -661,6 +775,48 @@ func TestTimerDispatchRoundTrip(t *testing.T) {
 	}
 }
 
+// TestTickHeartbeat verifies OnTick reaches "tick" handlers with a
+// numeric count, and rune.tick configures the Go-owned heartbeat.
+func TestTickHeartbeat(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+	host.TickInterval = time.Second
+
+	setup := `
+		rune.hooks.on("tick", function(count)
+			assert(type(count) == "number")
+			rune.send_raw("beat " .. count)
+		end)
+	`
+	if err := engine.DoString("setup", setup); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	engine.OnTick(3)
+	if sent := host.DrainNetworkCalls(); len(sent) != 1 || sent[0] != "beat 3" {
+		t.Errorf("tick handler got %v, want [beat 3]", sent)
+	}
+
+	script := `
+		assert(rune.tick.interval() == 1)
+		rune.tick.pause()
+		rune.tick.interval(5)
+		assert(rune.tick.paused() and rune.tick.interval() == 5)
+		assert(not pcall(rune.tick.interval, 0))
+	`
+	if err := engine.DoString("configure", script); err != nil {
+		t.Fatalf("configure failed: %v", err)
+	}
+	if host.TickInterval != 5*time.Second || !host.TickPaused {
+		t.Fatalf("host tick = %v paused=%v, want 5s paused", host.TickInterval, host.TickPaused)
+	}
+	if err := engine.DoString("resume", `rune.tick.resume()`); err != nil {
+		t.Fatal(err)
+	}
+	if host.TickInterval != 5*time.Second || host.TickPaused {
+		t.Errorf("after resume: %v paused=%v", host.TickInterval, host.TickPaused)
+	}
+}
+
 // TestWatchdogPausedDuringBlockingHostCall verifies that time spent in
 // a blocking host call (the user sitting in $EDITOR) does not count
 // against the watchdog deadline: the handler must survive an editor
@@ -5,8 +5,10 @@ package lua
 // wiring proof lives in test/e2e/scenarios/gmcp.json.
 
 import (
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/mmcdole/rune/version"
 )
@@ -299,3 +301,139 @@ func TestGMCPSendEncodesLuaValues(t *testing.T) {
 		t.Errorf("expected send failure echoed, got: %s", printed)
 	}
 }
+
+// TestNetPingPolicy verifies the latency probe schedule: one ping as
+// soon as GMCP is up, a repeating timer at rune.config.ping, and the
+// timer dropped once a probe fails or the connection goes away.
+func TestNetPingPolicy(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("stats", `assert(rune.net.stats().latency_ms == nil)`); err != nil {
+		t.Error(err)
+	}
+
+	host.GMCPNegotiated = true
+	engine.CallHook("gmcp_enabled")
+	if host.Pings != 1 {
+		t.Fatalf("pings after gmcp_enabled = %d, want 1", host.Pings)
+	}
+	timers := host.DrainScheduledTimers()
+	if len(timers) != 1 || timers[0].Duration != 30*time.Second || !timers[0].Repeat {
+		t.Fatalf("scheduled timers = %+v, want one repeating 30s timer", timers)
+	}
+
+	engine.OnTimer(timers[0].ID)
+	if host.Pings != 2 {
+		t.Errorf("pings after tick = %d, want 2", host.Pings)
+	}
+
+	host.Stats = NetStats{BytesIn: 120, BytesOut: 40, Latency: 1500 * time.Microsecond}
+	assertLua(t, engine, `assert(rune.net.stats().latency_ms == 1.5)`)
+	assertLua(t, engine, `assert(rune.net.stats().bytes_in == 120)`)
+
+	// A failed probe (GMCP dropped) stops the schedule.
+	host.PingErr = errors.New("GMCP not negotiated on this connection")
+	engine.OnTimer(timers[0].ID)
+	assertLua(t, engine, `assert(rune.timer.count() == 0)`)
+
+	// Disabled by config: no probe at all.
+	host.PingErr = nil
+	host.Pings = 0
+	if err := engine.DoString("off", `rune.config.ping = 0`); err != nil {
+		t.Fatal(err)
+	}
+	engine.CallHook("gmcp_enabled")
+	if host.Pings != 0 {
+		t.Errorf("pings with rune.config.ping = 0: %d, want 0", host.Pings)
+	}
+}
+
+// TestEnvSetPushesWholeSet verifies rune.env keeps the script-defined
+// NEW-ENVIRON variables upper-cased and pushes the full set to the
+// host on every change, with nil removing one.
+func TestEnvSetPushesWholeSet(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if len(host.Environ) != 0 {
+		t.Fatalf("boot pushed %v, want an empty set", host.Environ)
+	}
+	assertLua(t, engine, `
+		rune.env.set("ipaddress", "203.0.113.7")
+		rune.env.set("CLIENT", "rune")
+		rune.env.set("LEVEL", 42)
+		rune.env.set("CLIENT", nil)
+		assert(rune.env.get("IPADDRESS") == "203.0.113.7")
+		assert(rune.env.list().LEVEL == "42")
+		assert(not pcall(rune.env.set, "", "x"), "empty name accepted")
+	`)
+
+	want := map[string]string{"IPADDRESS": "203.0.113.7", "LEVEL": "42"}
+	if len(host.Environ) != len(want) {
+		t.Fatalf("host environ = %v, want %v", host.Environ, want)
+	}
+	for name, value := range want {
+		if host.Environ[name] != value {
+			t.Errorf("host environ[%s] = %q, want %q", name, host.Environ[name], value)
+		}
+	}
+}
+
+// TestGMCPLogin verifies Char.Login is offered only with credentials
+// for the connection, a password-credentials challenge is answered
+// from the secret store, a world's gmcp_login wins, and a result is
+// reported only for a login the client made.
+func TestGMCPLogin(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+	host.GMCPNegotiated = true
+	engine.UpdateState(ClientState{Connected: true, Address: "mud.example.com:4000"})
+
+	engine.CallHook("gmcp_enabled")
+	for _, s := range host.GMCPSends {
+		if strings.Contains(s.Data, "Char.Login") {
+			t.Fatalf("Char.Login offered without credentials: %v", host.GMCPSends)
+		}
+	}
+	host.DrainPrintCalls()
+	engine.OnGMCP("Char.Login.Result", `{"success":false}`)
+	if prints := host.DrainPrintCalls(); len(prints) != 0 {
+		t.Errorf("reported a login the client never made: %q", prints)
+	}
+
+	host.GMCPSends = nil
+	assertLua(t, engine, `
+		rune.secret.set("mud", "hunter2")
+		assert(not pcall(rune.gmcp.login, { account = "bob", secret = "mud" }),
+			"credentials need an address")
+		rune.gmcp.login({ account = "bob", secret = "mud" }, "mud.example.com:4000")
+	`)
+	engine.CallHook("gmcp_enabled")
+	if got := host.GMCPSends[len(host.GMCPSends)-1]; got.Package != "Core.Supports.Set" || !strings.Contains(got.Data, `"Char.Login 1"`) {
+		t.Fatalf("supports = %+v", got)
+	}
+
+	host.GMCPSends = nil
+	engine.OnGMCP("Char.Login.Default", `{"type":["password-credentials"]}`)
+	if len(host.GMCPSends) != 1 || host.GMCPSends[0].Package != "Char.Login.Credentials" ||
+		host.GMCPSends[0].Data != `{"account":"bob","password":"hunter2"}` {
+		t.Fatalf("credentials = %v", host.GMCPSends)
+	}
+
+	host.GMCPSends = nil
+	assertLua(t, engine, `
+		rune.secret.set("alt", "swordfish")
+		rune.world.add("mud", "mud.example.com:4000", { gmcp_login = { account = "alice", secret = "alt" } })
+	`)
+	engine.OnGMCP("Char.Login.Default", `{"type":"password-credentials"}`)
+	if len(host.GMCPSends) != 1 || !strings.Contains(host.GMCPSends[0].Data, `"alice"`) {
+		t.Fatalf("world credentials = %v", host.GMCPSends)
+	}
+
+	host.DrainPrintCalls()
+	engine.OnGMCP("Char.Login.Result", `{"success":false,"message":"bad password"}`)
+	if prints := host.DrainPrintCalls(); len(prints) != 1 || !strings.Contains(prints[0], "bad password") {
+		t.Errorf("result notice = %q", prints)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/mmcdole/rune/input"
+	"github.com/mmcdole/rune/text"
 	"github.com/mmcdole/rune/ui"
 )
 
@@ -26,15 +27,115 @@ type Host interface {
 	// caching it in the VM, where it would go stale across /reload.
 	GMCPActive() bool
 
+	// NetPing sends a latency probe (GMCP Core.Ping) on the current
+	// connection; NetStats reports its traffic counters and the last
+	// measured round trip. Both fail or read zero when disconnected.
+	NetPing() error
+	NetStats() NetStats
+
+	// SetEnviron replaces the script-defined NEW-ENVIRON variables
+	// reported to the server, from its next SEND on. The whole set is
+	// pushed on every change.
+	SetEnviron(vars map[string]string)
+	// SetMaxLine sets the length, in bytes, past which a server line
+	// is split into several; 0 never splits.
+	SetMaxLine(n int)
+	// SetMXP chooses whether MXP is accepted when the server offers
+	// it, from the next connect on.
+	SetMXP(on bool)
+
+	// SetPromptDetect chooses how prompts are found on connections
+	// that send no GA/EOR marks: "auto" (any leftover text), "ga"
+	// (marks only), "regex" (leftover text matching pattern), or
+	// "timeout" (leftover text unchanged for stable). Network-owned,
+	// so it applies to every connection and survives /reload.
+	SetPromptDetect(mode, pattern string, stable time.Duration) error
+
+	// TelnetNegotiate initiates option negotiation on the current
+	// connection: command is "will", "wont", "do" or "dont". Reports
+	// false when the option is already in that state and nothing was
+	// sent; fails when disconnected.
+	TelnetNegotiate(command string, option byte) (bool, error)
+
+	// TelnetAbandon gives up on an unanswered WILL or DO for option,
+	// treating it as unsupported for the rest of the connection.
+	// Reports whether one was outstanding.
+	TelnetAbandon(option byte) bool
+
+	// Notify shows an OS desktop notification. The spawn runs in the
+	// background (failures reach the "error" hook); the immediate error
+	// covers a missing backend or the rate limit.
+	Notify(title, body string) error
+
+	// PlaySound plays an audio file with the OS player, in the
+	// background like Notify; the immediate error covers a missing
+	// file or player, or a sound already playing.
+	PlaySound(path string) error
+
 	// UI
 	Print(text string)
+	// Terminal describes the terminal the client draws on.
+	Terminal() Terminal
+	// RecentLines returns up to n of the newest output lines, oldest
+	// first, ANSI intact and tagged with their origin.
+	RecentLines(n int) []text.Line
+	// ClearPrompt drops the current prompt overlay without committing
+	// it to scrollback.
+	ClearPrompt()
 	PaneCreate(name string)
 	PaneWrite(name, text string)
 	PaneToggle(name string)
 	PaneSetVisible(name string, visible bool)
 	PaneClear(name string)
+	PaneResize(name string, height int)      // "" = the focused pane
+	PaneGrow(name string, rows int)          // negative shrinks; "" = focused
+	PaneLimit(name string, lines, bytes int) // buffer budget; 0 keeps one
+	PaneFocus(name string)
+	PaneUnread() map[string]int // writes since last shown, per hidden pane
+	PaneVisible(name string) bool
 	ShowPicker(opts ui.ShowPickerMsg)
 	ClipboardSet(text string)
+	// Bell rings the terminal bell (audible) and/or flashes the bars
+	// (visual).
+	Bell(audible, visual bool)
+	// CopyMode starts keyboard selection over the output viewport; the
+	// selection comes back as the "copy" hook.
+	CopyMode()
+	// JumpToInput scrolls the output back to where the last submitted
+	// command's output starts.
+	JumpToInput()
+	// ClearScreen scrolls the visible output out of view, leaving it
+	// in scrollback - a terminal-style clear for the row model.
+	ClearScreen()
+	// ClearScrollback drops all output, scrollback included, so
+	// nothing before it can be scrolled back to or searched.
+	ClearScrollback()
+	// SetDedupe turns collapsing of repeated server lines into one
+	// row with an " (xN)" counter on or off.
+	SetDedupe(on bool)
+	// SetOutputRate caps the server lines printed per second (0 =
+	// no cap); the rest still reach hooks and logs, and each second's
+	// count is reported through the "throttled" hook.
+	SetOutputRate(linesPerSecond int)
+	OutputRate() int
+	// SetFlushInterval sets how long server output is batched before
+	// it renders.
+	SetFlushInterval(d time.Duration)
+	// GrepScrollback writes the output rows matching a pattern to a
+	// pane, or to the output.
+	GrepScrollback(req ui.GrepScrollbackMsg)
+	// SetWrap turns soft-wrapping of new output rows on or off; wide
+	// unwrapped rows scroll horizontally (PaneScrollColumns).
+	SetWrap(on bool)
+	// SetSplit turns the split output view on or off: while scrolled
+	// back, the newest output keeps showing below a divider.
+	SetSplit(on bool)
+	// SetTheme recolors the UI's own chrome (pickers, pane headers,
+	// rules); the zero Theme restores the defaults.
+	SetTheme(theme ui.Theme)
+	// OpenURL hands an http(s) URL to the OS opener (xdg-open, open,
+	// or the Windows URL handler). Other schemes are refused.
+	OpenURL(url string) error
 	GetInput() string
 	SetInput(text string)
 	SetInputSubmission(submission input.Submission)
@@ -43,11 +144,19 @@ type Host interface {
 	// Cursor positions are zero-based UTF-8 byte offsets.
 	InputGetCursor() int
 	InputSetCursor(pos int)
+	InputSetPrompt(prompt string)
+	InputSetPlaceholder(text string)
+	InputSetMask(on bool)
+	InputSetGhost(text string)
+	InputSetWordBreak(chars string)
+	InputSetQueue(limit int)
 	OpenEditor(initial string) (string, bool)
 
 	// Pane scrolling
 	PaneScrollUp(name string, lines int)
 	PaneScrollDown(name string, lines int)
+	PaneScrollColumns(name string, cols int)    // negative = left
+	PaneScrollPages(name string, pages float64) // negative = up
 	PaneScrollToTop(name string)
 	PaneScrollToBottom(name string)
 
@@ -57,15 +166,37 @@ type Host interface {
 	TimerCancel(id int)
 	TimerCancelAll()
 
+	// Heartbeat: the shared ticker that fires the "tick" hook
+	// (Engine.OnTick). Go owns it, so the interval and pause survive
+	// /reload; TickStatus reads them back for the fresh VM.
+	SetTick(interval time.Duration, paused bool)
+	TickStatus() (interval time.Duration, paused bool)
+
 	// System
 	Quit()
 	Reload()
-	RefreshBars() // Force immediate bar refresh
+	// Bars: renders are coalesced onto the bar tick. RefreshBars
+	// re-renders every bar there; MarkBarsDirty only the ones the Lua
+	// bar module marked dirty.
+	RefreshBars()
+	MarkBarsDirty()
+
+	// SetAutoReload turns on reloading when a loaded user script
+	// changes on disk; AutoReload reports the setting. It survives
+	// reloads.
+	SetAutoReload(on bool)
+	AutoReload() bool
 
 	// History
 	GetHistory() []string
 	GetHistoryEntries() []input.Submission
 	AddToHistory(cmd string)
+	ForgetHistory(text string) // drop the newest entry with this exact text
+	// SetHistoryPolicy sets how many entries history keeps and which
+	// repeats it drops: "adjacent" (a repeat of the newest entry),
+	// "global" (any earlier copy), or "none". It applies to the
+	// entries already kept, too.
+	SetHistoryPolicy(size int, dedupe string)
 
 	// Session store: a small Go-owned string store that survives
 	// script reloads (but not client exit). Lets Lua keep state
@@ -83,6 +214,14 @@ type Host interface {
 	StoreGet(key string) (string, bool)
 	StoreDelete(key string) error
 
+	// Secret store: credentials in <config>/secrets.json, apart from
+	// the durable store, written owner-only and never echoed by the
+	// core. Names list without values.
+	SecretSet(name, value string) error
+	SecretGet(name string) (string, bool)
+	SecretDelete(name string) error
+	SecretNames() []string
+
 	// Logging: Go owns the file handle so an active log survives
 	// /reload and is flushed/closed on exit. WHAT gets logged (which
 	// lines, stripping, headers) is Lua policy (lua/core/60_log.lua).
@@ -91,6 +230,13 @@ type Host interface {
 	LogWrite(text string)                 // appends one line; no-op when inactive
 	LogStatus() (string, bool)            // active log path, if any
 
+	// Raw traffic trace: socket bytes in both directions, below the
+	// telnet parser, for protocol debugging. Network-owned, so it
+	// spans reconnects and /reload (lua/core/62_trace.lua).
+	TraceStart(path string) (string, error) // opens (append); returns resolved path
+	TraceStop() bool                        // closes; reports whether a trace was open
+	TraceStatus() (string, bool)            // active trace path, if any
+
 	// HTTP: perform req off the session goroutine and deliver the
 	// outcome back on it via Engine.OnHTTPResult with the same id.
 	// The id -> callback mapping is Lua state (lua/core/80_http.lua),
@@ -98,10 +244,33 @@ type Host interface {
 	// for a stale id is dropped there.
 	HTTPRequest(id int, req HTTPRequest)
 
+	// Exec: run req's program off the session goroutine and deliver
+	// its output back on it via Engine.OnExecResult with the same id.
+	// As with HTTP, the id -> callback mapping (and the allowlist) is
+	// Lua state (lua/core/81_exec.lua).
+	Exec(id int, req ExecRequest)
+
 	// State
 	OnConfigChange()
 }
 
+// NetStats is the connection snapshot returned by Host.NetStats.
+// Latency is zero until the server has answered a ping.
+type NetStats struct {
+	BytesIn  int64
+	BytesOut int64
+	Latency  time.Duration
+}
+
+// Terminal is the snapshot returned by Host.Terminal. Colors is how
+// many the terminal can show: 0 (none), 16, 256, or 1<<24 (truecolor).
+type Terminal struct {
+	Colors int
+	Mouse  bool
+	Width  int
+	Height int
+}
+
 // HTTPRequest describes one request handed to Host.HTTPRequest.
 // Timeout <= 0 means the host's default.
 type HTTPRequest struct {
@@ -119,3 +288,23 @@ type HTTPResponse struct {
 	Body    string
 	Headers map[string]string
 }
+
+// ExecRequest describes one program run handed to Host.Exec. Args are
+// passed to the program as-is, never through a shell. Timeout <= 0
+// means the host's default. Allow is rune.config.exec_allow as the
+// engine read it, not as the caller passed it; the host refuses a
+// Command missing from it.
+type ExecRequest struct {
+	Command string
+	Args    []string
+	Timeout time.Duration
+	Allow   []string
+}
+
+// ExecResult is a finished program's output, delivered back to Lua
+// through Engine.OnExecResult.
+type ExecResult struct {
+	Stdout string
+	Stderr string
+	Code   int
+}
@@ -0,0 +1,50 @@
+package lua
+
+// Idle rules (42_idle.lua) driven against MockHost's timer capture.
+
+import "testing"
+
+// TestIdleRuleRestartsOnSend verifies an idle rule fires after its
+// countdown, that any send restarts it (stale wake-ups are ignored),
+// and that disabled rules stay quiet.
+func TestIdleRuleRestartsOnSend(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+	engine.UpdateState(ClientState{Connected: true, Address: "mud.example.com:4000"})
+
+	assertLua(t, engine, `idle = rune.idle(300, "look", { name = "anti-idle" })`)
+	first := host.DrainScheduledTimers()
+	if len(first) != 1 || first[0].Duration.Seconds() != 300 {
+		t.Fatalf("scheduled = %+v, want one 300s wake-up", first)
+	}
+
+	// A send restarts the countdown: the old wake-up is stale.
+	engine.OnInput("north")
+	host.DrainNetworkCalls()
+	rearmed := host.DrainScheduledTimers()
+	if len(rearmed) != 1 {
+		t.Fatalf("send re-armed %d timers, want 1", len(rearmed))
+	}
+	engine.OnTimer(first[0].ID)
+	if sent := host.DrainNetworkCalls(); len(sent) != 0 {
+		t.Fatalf("stale wake-up fired: %v", sent)
+	}
+
+	// Firing sends the action, and its own send re-arms the rule.
+	engine.OnTimer(rearmed[0].ID)
+	if sent := host.DrainNetworkCalls(); len(sent) != 1 || sent[0] != "look" {
+		t.Fatalf("sent = %v, want [look]", sent)
+	}
+	next := host.DrainScheduledTimers()
+	if len(next) != 1 {
+		t.Fatalf("after firing: %d wake-ups, want 1", len(next))
+	}
+
+	// Disabled rules keep counting but do nothing.
+	assertLua(t, engine, `idle:disable()`)
+	engine.OnTimer(next[0].ID)
+	if sent := host.DrainNetworkCalls(); len(sent) != 0 {
+		t.Fatalf("disabled rule sent %v", sent)
+	}
+	assertLua(t, engine, `assert(rune.idles.count() == 1 and rune.idles.remove("anti-idle"))`)
+}
@@ -7,6 +7,7 @@ package lua
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -159,6 +160,32 @@ func TestHistoryStructuredAndLegacyAPIs(t *testing.T) {
 	}
 }
 
+func TestHistoryConfigValidatesAndPushesPolicy(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if host.HistorySize != 10000 || host.HistoryDedupe != "adjacent" {
+		t.Fatalf("policy at load = (%d, %q), want (10000, adjacent)", host.HistorySize, host.HistoryDedupe)
+	}
+	script := `
+		local cfg = rune.history.config({ dedupe = "global" })
+		assert(cfg.size == 10000 and cfg.dedupe == "global")
+		cfg = rune.history.config({ size = 50 })
+		assert(cfg.size == 50 and cfg.dedupe == "global", "fields merge")
+		assert(rune.history.config().size == 50)
+		assert(not pcall(rune.history.config, { dedupe = "sometimes" }))
+		assert(not pcall(rune.history.config, { size = 0 }))
+		assert(not pcall(rune.history.config, { size = 2.5 }))
+		assert(rune.history.config().dedupe == "global", "a bad call changes nothing")
+	`
+	if err := engine.DoString("history_config", script); err != nil {
+		t.Fatal(err)
+	}
+	if host.HistorySize != 50 || host.HistoryDedupe != "global" {
+		t.Errorf("policy pushed = (%d, %q), want (50, global)", host.HistorySize, host.HistoryDedupe)
+	}
+}
+
 func TestHistoryPickerRestoresVerbatimMode(t *testing.T) {
 	engine, host, cleanup := setupTest(t)
 	defer cleanup()
@@ -192,6 +219,29 @@ func TestHistoryPickerRestoresVerbatimMode(t *testing.T) {
 	assertInputMode(t, host, input.ModeCommand)
 }
 
+// Text already typed seeds Ctrl+R's search as a prefix, like Up does;
+// an empty input opens a plain fuzzy search.
+func TestHistoryPickerSeedsPrefixSearch(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+	host.HistoryEntries = []input.Submission{input.Command("look")}
+
+	host.SetInput("lo")
+	engine.HandleKeyBind("ctrl+r")
+	host.SetInput("")
+	engine.HandleKeyBind("ctrl+r")
+
+	if len(host.PickerCalls) != 2 {
+		t.Fatalf("picker calls = %d, want 2", len(host.PickerCalls))
+	}
+	if p := host.PickerCalls[0]; p.Query != "lo" || p.Match != "prefix" {
+		t.Errorf("with a draft: query %q match %q, want \"lo\" prefix", p.Query, p.Match)
+	}
+	if p := host.PickerCalls[1]; p.Query != "" || p.Match != "fuzzy" {
+		t.Errorf("empty input: query %q match %q, want fuzzy", p.Query, p.Match)
+	}
+}
+
 func TestInputSetPreservesComposeButRestoreForcesMode(t *testing.T) {
 	engine, host, cleanup := setupTest(t)
 	defer cleanup()
@@ -268,6 +318,42 @@ func TestWordNavigationAndDeleteWithMultibyteInput(t *testing.T) {
 	assertCursor(t, host, 12)
 }
 
+func TestWordBreakSharedByWordOperations(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	// Default: whitespace only, so the quoted spell is one word.
+	host.SetInput("cast 'word-of-recall'")
+	engine.HandleKeyBind("ctrl+w")
+	assertInput(t, host, "cast ")
+
+	assertLua(t, engine, `rune.input.word_break("'-")`)
+	if host.InputWordBreak != "'-" {
+		t.Errorf("composer word break = %q, want '-", host.InputWordBreak)
+	}
+
+	host.SetInput("cast 'word-of-recall'")
+	engine.HandleKeyBind("ctrl+w")
+	assertInput(t, host, "cast 'word-of-")
+	engine.HandleKeyBind("alt+b")
+	assertCursor(t, host, len("cast 'word-"))
+	assertLua(t, engine, `
+		local word, start, finish = rune.input.word()
+		assert(word == "of", "word: " .. word)
+		assert(start == 11 and finish == 13, "span: " .. start .. "," .. finish)
+	`)
+
+	// Completion takes its prefix, and caches words, the same way.
+	engine.OnOutput(text.NewLine("You recall the 'gateway-spell' scroll"))
+	typeInput(engine, host, "read 'gat")
+	engine.HandleKeyBind("tab")
+	assertInput(t, host, "read 'gateway ")
+
+	if err := engine.DoString("test", `rune.input.word_break("é")`); err == nil {
+		t.Error("expected non-ASCII word break characters to be rejected")
+	}
+}
+
 func TestClearInputBinds(t *testing.T) {
 	engine, host, cleanup := setupTest(t)
 	defer cleanup()
@@ -432,6 +518,45 @@ func TestTabCompletionCacheEvictsOldestBeyondCap(t *testing.T) {
 	assertInput(t, host, "fill4999 ")
 }
 
+// rune.completion.config raises the prefix minimum, shrinks the cache
+// keeping the newest words, and turns completion off and on.
+func TestCompletionConfig(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	engine.OnOutput(text.NewLine("gold goblet goblin"))
+	assertLua(t, engine, `
+		local c = rune.completion.config({ min_len = 3, cache = 2 })
+		assert(c.min_len == 3 and c.cache == 2 and c.enabled)
+		assert(not pcall(rune.completion.config, { min_len = 1 }))
+	`)
+
+	typeInput(engine, host, "go")
+	engine.HandleKeyBind("tab")
+	assertInput(t, host, "go")
+
+	// The resize kept the two newest words; "gold" is gone.
+	typeInput(engine, host, "gol")
+	engine.HandleKeyBind("tab")
+	assertInput(t, host, "gol")
+	typeInput(engine, host, "gob")
+	engine.HandleKeyBind("tab")
+	assertInput(t, host, "goblin ")
+
+	assertLua(t, engine, `rune.completion.config({ enabled = false })`)
+	engine.OnOutput(text.NewLine("goblet"))
+	typeInput(engine, host, "gob")
+	engine.HandleKeyBind("tab")
+	assertInput(t, host, "gob")
+
+	// Re-enabled, the cache starts empty and fills again.
+	assertLua(t, engine, `rune.completion.config({ enabled = true })`)
+	engine.OnOutput(text.NewLine("goblet"))
+	typeInput(engine, host, "gob")
+	engine.HandleKeyBind("tab")
+	assertInput(t, host, "goblet ")
+}
+
 func TestCompletionMidLineInsertsWithoutTrailingSpace(t *testing.T) {
 	engine, host, cleanup := setupTest(t)
 	defer cleanup()
@@ -463,3 +588,273 @@ func TestCompletionMidLineWithMultibyteInput(t *testing.T) {
 	assertInput(t, host, "café goblin now")
 	assertCursor(t, host, len("café goblin"))
 }
+
+func TestKillRingYank(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	host.SetInput("cast fireball goblin")
+	engine.HandleKeyBind("ctrl+w")
+	assertInput(t, host, "cast fireball ")
+	engine.HandleKeyBind("ctrl+u")
+	assertInput(t, host, "")
+
+	host.SetInput("say ")
+	engine.HandleKeyBind("ctrl+y")
+	assertInput(t, host, "say cast fireball ")
+	assertCursor(t, host, 18)
+
+	// alt+y swaps the yank for the older kill, then wraps around.
+	engine.HandleKeyBind("alt+y")
+	assertInput(t, host, "say goblin")
+	assertCursor(t, host, 10)
+	engine.HandleKeyBind("alt+y")
+	assertInput(t, host, "say cast fireball ")
+
+	// Once the input is edited, alt+y does nothing.
+	host.SetInput("kill ")
+	engine.HandleKeyBind("alt+y")
+	assertInput(t, host, "kill ")
+}
+
+func TestInputInsertAndWordUnderCursor(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	host.SetInput("cast fire goblin")
+	host.InputSetCursor(7) // inside "fire"
+	assertLua(t, engine, `
+		local word, start, finish = rune.input.word()
+		assert(word == "fire" and start == 5 and finish == 9, word .. " " .. start .. " " .. finish)
+	`)
+
+	engine.DoString("replace", `rune.input.replace_word("fireball")`)
+	assertInput(t, host, "cast fireball goblin")
+	assertCursor(t, host, 13)
+
+	// At the end of a word, that word; between words, "" at the cursor.
+	host.InputSetCursor(20)
+	assertLua(t, engine, `assert(rune.input.word() == "goblin")`)
+	host.SetInput("say  hi")
+	host.InputSetCursor(4)
+	assertLua(t, engine, `
+		local word, start, finish = rune.input.word()
+		assert(word == "" and start == 4 and finish == 4)
+	`)
+
+	engine.DoString("insert", `rune.input.insert("héllo")`)
+	assertInput(t, host, "say héllo hi")
+	assertCursor(t, host, 10)
+}
+
+func TestReadlineWordBinds(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	host.SetInput("hello brave world")
+	engine.HandleKeyBind("alt+b")
+	assertCursor(t, host, 12)
+	engine.HandleKeyBind("alt+b")
+	assertCursor(t, host, 6)
+	engine.HandleKeyBind("alt+f")
+	assertCursor(t, host, 12)
+}
+
+func TestPastePolicy(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	// compose (default): the draft is left in the composer untouched.
+	host.SetInput("north\nsouth")
+	engine.CallHook("paste", "north\nsouth")
+	assertInput(t, host, "north\nsouth")
+
+	// join: non-blank lines become one command line.
+	if err := engine.DoString("cfg", `rune.config.paste = "join"`); err != nil {
+		t.Fatal(err)
+	}
+	host.SetInput("kill rat\r\n\n  get all corpse \n")
+	engine.CallHook("paste", "")
+	assertInput(t, host, "kill rat;get all corpse")
+	if host.InputMode != input.ModeCommand {
+		t.Errorf("joined paste mode = %s, want command", host.InputMode)
+	}
+
+	// editor: the draft goes through $EDITOR first.
+	if err := engine.DoString("cfg", `rune.config.paste = "editor"`); err != nil {
+		t.Fatal(err)
+	}
+	host.OpenEditorFn = func(initial string) (string, bool) { return "edited\n" + initial, true }
+	host.SetInput("a\nb")
+	engine.CallHook("paste", "a\nb")
+	assertInput(t, host, "edited\na\nb")
+}
+
+func TestInputPromptAndPlaceholder(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("test", `
+		rune.input.prompt(rune.style.cyan("» "))
+		rune.input.placeholder("type a command")
+	`); err != nil {
+		t.Fatalf("script failed: %v", err)
+	}
+	if host.InputPrompt != "\x1b[36m» \x1b[0m" {
+		t.Errorf("prompt = %q, want the styled »", host.InputPrompt)
+	}
+	if host.InputPlaceholder != "type a command" {
+		t.Errorf("placeholder = %q", host.InputPlaceholder)
+	}
+
+	for _, bad := range []string{`rune.input.prompt(nil)`, `rune.input.placeholder("two\nlines")`} {
+		if err := engine.DoString("test", bad); err == nil {
+			t.Errorf("%s should raise", bad)
+		}
+	}
+}
+
+// TestCommandGhost verifies a partly typed /command suggests the
+// registered command it prefixes, and that the handler clears only
+// the ghost it set.
+func TestCommandGhost(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `rune.command.add("zzcast", function() end, "test")`)
+	typeInput(engine, host, "/zzc")
+	if host.InputGhost != "/zzcast" {
+		t.Fatalf("ghost = %q, want /zzcast", host.InputGhost)
+	}
+	typeInput(engine, host, "/zzcast now")
+	if host.InputGhost != "" {
+		t.Fatalf("ghost after arguments = %q, want cleared", host.InputGhost)
+	}
+
+	assertLua(t, engine, `rune.input.ghost("kill goblin")`)
+	typeInput(engine, host, "kill g")
+	if host.InputGhost != "kill goblin" {
+		t.Fatalf("script ghost = %q, want it left alone", host.InputGhost)
+	}
+}
+
+// TestComposeSendsOneLine verifies composed lines (a pasted chunk
+// included) go out as one line after the prefix, with breaks joined
+// per opts.join and the compose hook's rewrite applied, and that
+// aliases and the delimiter never see them.
+func TestComposeSendsOneLine(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		rune.alias.exact("smiles", "grin")
+		rune.hooks.on("compose", function(text, prefix)
+			assert(prefix == "emote", prefix)
+			return (text:gsub("then", "then,"))
+		end)
+		rune.input.compose({ prefix = "emote", join = "%r" })
+	`)
+	engine.OnInput("smiles; then")
+	engine.OnSubmission(input.Verbatim("waves\nbows"))
+	if sent := host.DrainNetworkCalls(); len(sent) != 0 {
+		t.Fatalf("sent %v while composing", sent)
+	}
+	engine.OnInput(".")
+	if sent := host.DrainNetworkCalls(); len(sent) != 1 || sent[0] != "emote smiles; then,%rwaves%rbows" {
+		t.Fatalf("sent %v", sent)
+	}
+
+	// Back to normal commands once sent; a cancelled draft sends nothing.
+	engine.OnInput("smiles")
+	assertLua(t, engine, `
+		rune.input.compose()
+		rune.send("unused")
+	`)
+	engine.OnInput("draft")
+	engine.OnInput("/compose cancel")
+	engine.OnInput(".")
+	if sent := host.DrainNetworkCalls(); strings.Join(sent, "|") != "grin|unused|." {
+		t.Fatalf("sent %v", sent)
+	}
+
+	// A keybinding can finish it, taking the line still being typed.
+	assertLua(t, engine, `rune.input.compose({ prefix = "say" })`)
+	engine.OnInput("hello")
+	host.SetInput("there")
+	assertLua(t, engine, `assert(rune.input.compose_finish() == "say hello there")`)
+	assertInput(t, host, "")
+}
+
+// TestSecretEntry verifies /secret set masks the input line while the
+// next line is typed, stores that line instead of sending it, masks
+// its echo, and drops only its own history entry, and that a stored
+// value is masked wherever it is echoed.
+func TestSecretEntry(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	engine.OnInput("/secret set viking")
+	if !host.InputMasked {
+		t.Fatal("input not masked during entry")
+	}
+	host.History = []string{"hunter2", "look", "hunter2"}
+	if echo, show := engine.OnEcho("hunter2"); !show || strings.Contains(echo, "hunter2") {
+		t.Fatalf("entry echoed as %q", echo)
+	}
+	engine.OnInput("hunter2")
+	if sent := host.DrainNetworkCalls(); len(sent) != 0 {
+		t.Fatalf("sent %v", sent)
+	}
+	if host.Secrets["viking"] != "hunter2" {
+		t.Fatalf("secrets = %v", host.Secrets)
+	}
+	if host.InputMasked {
+		t.Fatal("input still masked after entry")
+	}
+	if !reflect.DeepEqual(host.History, []string{"hunter2", "look"}) {
+		t.Fatalf("history = %v, want only the entry's own line dropped", host.History)
+	}
+
+	engine.OnInput("hunter2")
+	if sent := host.DrainNetworkCalls(); !reflect.DeepEqual(sent, []string{"hunter2"}) {
+		t.Fatalf("entry did not end after one line: sent %v", sent)
+	}
+	if echo, _ := engine.OnEcho("hunter2"); strings.Contains(echo, "hunter2") {
+		t.Fatalf("stored value echoed as %q", echo)
+	}
+	if echo, _ := engine.OnEcho("look"); !strings.Contains(echo, "look") {
+		t.Fatalf("ordinary echo = %q", echo)
+	}
+	assertLua(t, engine, `
+		local names = rune.secret.list()
+		assert(#names == 1 and names[1] == "viking")
+		assert(rune.secret.delete("viking"))
+		assert(rune.secret.get("viking") == nil)
+		assert(rune.secret.set("viking", "look"))
+	`)
+	if !reflect.DeepEqual(host.History, []string{"hunter2", "look"}) {
+		t.Fatalf("rune.secret.set touched history: %v", host.History)
+	}
+}
+
+// TestSecretEntryWhileComposing verifies the value typed after
+// /secret set is stored even while /compose is collecting lines.
+func TestSecretEntryWhileComposing(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	engine.OnInput("/compose say")
+	engine.OnInput("/secret set viking")
+	engine.OnInput("hunter2")
+	if host.Secrets["viking"] != "hunter2" {
+		t.Fatalf("secrets = %v", host.Secrets)
+	}
+	if host.InputMasked {
+		t.Fatal("input still masked after entry")
+	}
+	engine.OnInput("hello")
+	engine.OnInput("/compose send")
+	if sent := host.DrainNetworkCalls(); !reflect.DeepEqual(sent, []string{"say hello"}) {
+		t.Fatalf("sent %v, want the composed line without the secret", sent)
+	}
+}
@@ -7,6 +7,14 @@ import (
 
 const luaLineTypeName = "line"
 
+// luaLine is the state behind a line object: the text, plus the gag
+// flag set by :gag(). Mutators change the object in place, so every
+// later hook and trigger handler holding it sees the result.
+type luaLine struct {
+	text.Line
+	gagged bool
+}
+
 // registerLineType registers the Line type with the Lua state.
 // Call this once during engine initialization.
 func registerLineType(L *glua.LState) {
@@ -17,15 +25,15 @@ func registerLineType(L *glua.LState) {
 // newLine creates a Line userdata from a text.Line and pushes it onto the Lua stack.
 func newLine(L *glua.LState, line text.Line) *glua.LUserData {
 	ud := L.NewUserData()
-	ud.Value = &line
+	ud.Value = &luaLine{Line: line}
 	L.SetMetatable(ud, L.GetTypeMetatable(luaLineTypeName))
 	return ud
 }
 
-// checkLine retrieves a text.Line from Lua userdata at the given stack position.
-func checkLine(L *glua.LState, n int) *text.Line {
+// checkLine retrieves a line from Lua userdata at the given stack position.
+func checkLine(L *glua.LState, n int) *luaLine {
 	ud := L.CheckUserData(n)
-	if v, ok := ud.Value.(*text.Line); ok {
+	if v, ok := ud.Value.(*luaLine); ok {
 		return v
 	}
 	L.ArgError(n, "line expected")
@@ -33,9 +41,16 @@ func checkLine(L *glua.LState, n int) *text.Line {
 }
 
 // lineMethods defines the methods available on Line objects in Lua.
+// color is added by the Lua core (00_init.lua), which owns color specs
+// and the pattern cache.
 var lineMethods = map[string]glua.LGFunction{
-	"raw":   lineRaw,
-	"clean": lineClean,
+	"raw":     lineRaw,
+	"clean":   lineClean,
+	"text":    lineClean,
+	"replace": lineReplace,
+	"gag":     lineGag,
+	"gagged":  lineGagged,
+	"origin":  lineOrigin,
 }
 
 // lineRaw returns the raw line with ANSI codes.
@@ -47,9 +62,94 @@ func lineRaw(L *glua.LState) int {
 }
 
 // lineClean returns the line without ANSI codes.
-// Usage: line:clean()
+// Usage: line:clean() or line:text()
 func lineClean(L *glua.LState) int {
 	line := checkLine(L, 1)
 	L.Push(glua.LString(line.Clean))
 	return 1
 }
+
+// lineReplace swaps in new raw text (ANSI allowed); returns the line.
+// Usage: line:replace(text)
+func lineReplace(L *glua.LState) int {
+	line := checkLine(L, 1)
+	line.Line = withOrigin(text.NewLine(L.CheckString(2)), line.Origin)
+	L.Push(L.Get(1))
+	return 1
+}
+
+// lineGag marks the line to be hidden; returns the line.
+// Usage: line:gag()
+func lineGag(L *glua.LState) int {
+	line := checkLine(L, 1)
+	line.gagged = true
+	L.Push(L.Get(1))
+	return 1
+}
+
+// lineGagged reports whether a handler has gagged the line.
+// Usage: line:gagged()
+func lineGagged(L *glua.LState) int {
+	line := checkLine(L, 1)
+	L.Push(glua.LBool(line.gagged))
+	return 1
+}
+
+// lineOrigin reports where the line came from: "server", "echo", or
+// "script".
+// Usage: line:origin()
+func lineOrigin(L *glua.LState) int {
+	line := checkLine(L, 1)
+	L.Push(glua.LString(line.Origin.String()))
+	return 1
+}
+
+// withOrigin returns line tagged with origin, for mutators that build
+// a new text.Line: rewriting a line does not change where it came from.
+func withOrigin(line text.Line, origin text.Origin) text.Line {
+	line.Origin = origin
+	return line
+}
+
+// registerLineFuncs registers internal rune._line.* primitives: the
+// constructor behind rune.line.new, and the pieces the Lua core uses
+// to add line:color().
+func (e *Engine) registerLineFuncs() {
+	lineTable := e.L.NewTable()
+	e.L.SetField(e.runeTable, "_line", lineTable)
+
+	// rune._line.new(raw, origin?): A line object from raw text,
+	// from the server unless origin names another source.
+	e.L.SetField(lineTable, "new", e.L.NewFunction(func(L *glua.LState) int {
+		origin, ok := text.ParseOrigin(L.OptString(2, "server"))
+		if !ok {
+			L.ArgError(2, `origin must be "server", "echo", or "script"`)
+		}
+		L.Push(newLine(L, withOrigin(text.NewLine(L.CheckString(1)), origin)))
+		return 1
+	}))
+
+	// rune._line.methods: The shared method table, so Lua can add
+	// methods that need Lua-side policy.
+	e.L.SetField(lineTable, "methods", e.L.GetField(e.L.GetTypeMetatable(luaLineTypeName), "__index"))
+
+	// rune._line.highlight(line, regex, on): Wrap every match of regex
+	// in the line's clean text with the escape sequence on, in raw.
+	// Returns the number of matches.
+	e.L.SetField(lineTable, "highlight", e.L.NewFunction(func(L *glua.LState) int {
+		line := checkLine(L, 1)
+		re := checkRegex(L, 2)
+		on := L.CheckString(3)
+		var spans [][2]int
+		for _, m := range re.FindAllStringIndex(line.Clean, -1) {
+			if m[0] < m[1] {
+				spans = append(spans, [2]int{m[0], m[1]})
+			}
+		}
+		if len(spans) > 0 {
+			line.Line = withOrigin(text.NewLine(text.Highlight(line.Raw, spans, on)), line.Origin)
+		}
+		L.Push(glua.LNumber(len(spans)))
+		return 1
+	}))
+}
@@ -0,0 +1,148 @@
+package lua
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mmcdole/rune/text"
+)
+
+func TestLinkClickedOpensURL(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	engine.CallHook("link_clicked", "https://example.com/a", "hyperlink")
+	engine.CallHook("link_clicked", "https://example.com/b", "text")
+
+	want := []string{"https://example.com/a", "https://example.com/b"}
+	if strings.Join(host.OpenURLCalls, " ") != strings.Join(want, " ") {
+		t.Errorf("got opens %q, want %q", host.OpenURLCalls, want)
+	}
+}
+
+func TestLinkClickedAutolinkOff(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("test", `rune.config.autolink = false`); err != nil {
+		t.Fatalf("script failed: %v", err)
+	}
+	engine.CallHook("link_clicked", "https://example.com/bare", "text")
+	engine.CallHook("link_clicked", "https://example.com/marked", "hyperlink")
+
+	if len(host.OpenURLCalls) != 1 || host.OpenURLCalls[0] != "https://example.com/marked" {
+		t.Errorf("got opens %q, want only the hyperlink", host.OpenURLCalls)
+	}
+}
+
+func TestLinkClickedReportsOpenFailure(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	host.OpenURLErr = errors.New("xdg-open: not found")
+	engine.CallHook("link_clicked", "https://example.com", "text")
+
+	if len(host.PrintCalls) == 0 || !strings.Contains(host.PrintCalls[len(host.PrintCalls)-1], "xdg-open: not found") {
+		t.Errorf("open failure not echoed, prints: %q", host.PrintCalls)
+	}
+}
+
+func TestLinkOpenReturnsError(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	host.OpenURLErr = errors.New("refused")
+	err := engine.DoString("test", `
+		local ok, err = rune.link.open("https://example.com")
+		assert(ok == nil, "expected nil on failure")
+		assert(err == "refused", "unexpected error: " .. tostring(err))
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The docs example on the rune.link reference page: replace the core
+// handler to copy links instead of opening them. Keep this in sync
+// with website/src/content/docs/reference/api/link.md.
+func TestLinkDocExampleCopyInsteadOfOpen(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	err := engine.DoString("test", `
+		rune.hooks.disable("open-link")
+		rune.hooks.on("link_clicked", function(url)
+		    rune.clipboard.set(url)
+		    rune.echo("[Link] copied " .. url)
+		end)
+	`)
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	engine.CallHook("link_clicked", "https://example.com", "hyperlink")
+
+	if len(host.OpenURLCalls) != 0 {
+		t.Errorf("disabled open-link still opened %q", host.OpenURLCalls)
+	}
+	if len(host.ClipboardCalls) != 1 || host.ClipboardCalls[0] != "https://example.com" {
+		t.Errorf("got clipboard calls %q", host.ClipboardCalls)
+	}
+}
+
+// TestRecentURLsPickerOpensAndCopies verifies bare URLs in output are
+// collected newest first (a repeat moves to the front, the list is
+// bounded by rune.config.urls), and that the alt+u picker opens the
+// selection while /urls copy copies it.
+func TestRecentURLsPickerOpensAndCopies(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("test", `rune.config.urls = 2`); err != nil {
+		t.Fatal(err)
+	}
+	engine.OnOutput(text.NewLine("Check our website: \x1b[36mhttps://mud.example.com\x1b[0m!"))
+	engine.OnOutput(text.NewLine("paste at https://paste.example.com/a1, thanks"))
+	engine.OnOutput(text.NewLine("Nothing to see here."))
+	engine.OnOutput(text.NewLine("again https://mud.example.com"))
+	engine.OnOutput(text.NewLine("old http://old.example.com and https://new.example.com"))
+
+	assertLua(t, engine, `
+		local r = rune.link.recent()
+		assert(#r == 2, "expected 2 recent URLs, got " .. #r)
+		assert(r[1].url == "https://new.example.com", "newest first: " .. r[1].url)
+		assert(r[2].url == "http://old.example.com", "bound not applied: " .. r[2].url)
+		assert(r[1].line == "old http://old.example.com and https://new.example.com")
+	`)
+
+	engine.HandleKeyBind("alt+u")
+	if len(host.PickerCalls) != 1 {
+		t.Fatalf("picker calls = %d, want 1", len(host.PickerCalls))
+	}
+	picker := host.PickerCalls[0]
+	engine.ExecutePickerCallback(picker.CallbackID, picker.Items[1].Value)
+	if len(host.OpenURLCalls) != 1 || host.OpenURLCalls[0] != "http://old.example.com" {
+		t.Errorf("got opens %q, want the picked URL", host.OpenURLCalls)
+	}
+
+	if err := engine.DoString("test", `rune.command.dispatch("urls", "copy")`); err != nil {
+		t.Fatal(err)
+	}
+	picker = host.PickerCalls[len(host.PickerCalls)-1]
+	engine.ExecutePickerCallback(picker.CallbackID, picker.Items[0].Value)
+	if len(host.ClipboardCalls) != 1 || host.ClipboardCalls[0] != "https://new.example.com" {
+		t.Errorf("got clipboard calls %q, want the picked URL", host.ClipboardCalls)
+	}
+}
+
+func TestLinkClickedSendsMXPCommand(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	engine.CallHook("link_clicked", "get sword", "send")
+
+	assertCommands(t, host, []string{"get sword"})
+	if len(host.OpenURLCalls) != 0 {
+		t.Errorf("send link opened as a URL: %q", host.OpenURLCalls)
+	}
+}
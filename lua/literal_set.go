@@ -0,0 +1,183 @@
+package lua
+
+import (
+	"regexp/syntax"
+
+	glua "github.com/yuin/gopher-lua"
+)
+
+const luaLiteralSetTypeName = "LiteralSet"
+
+// requiredLiteral returns a literal every match of pattern must
+// contain, or "" when there is none to rely on (alternations, optional
+// parts, case-insensitive text). Trigger dispatch uses it as a cheap
+// pre-filter: a line without the literal cannot match, so the regex
+// is never run against it.
+func requiredLiteral(pattern string) string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	return literalIn(re)
+}
+
+func literalIn(re *syntax.Regexp) string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return ""
+		}
+		return string(re.Rune)
+	case syntax.OpCapture, syntax.OpPlus:
+		return literalIn(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min >= 1 {
+			return literalIn(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		// Adjacent literals join into one run; zero-width assertions
+		// consume nothing, so they do not break it.
+		best, run := "", ""
+		for _, sub := range re.Sub {
+			switch {
+			case sub.Op == syntax.OpLiteral && sub.Flags&syntax.FoldCase == 0:
+				run += string(sub.Rune)
+				if len(run) > len(best) {
+					best = run
+				}
+				continue
+			case zeroWidth(sub.Op):
+				continue
+			}
+			run = ""
+			if lit := literalIn(sub); len(lit) > len(best) {
+				best = lit
+			}
+		}
+		return best
+	}
+	return ""
+}
+
+func zeroWidth(op syntax.Op) bool {
+	switch op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine,
+		syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return true
+	}
+	return false
+}
+
+// literalSet finds which of a fixed set of literals occur in a text in
+// one pass (Aho-Corasick), however many literals there are.
+type literalSet struct {
+	next []map[byte]int32 // node -> byte -> child
+	fail []int32          // node -> longest proper suffix node
+	out  [][]int32        // node -> literals ending here (1-based ids)
+}
+
+// newLiteralSet builds the automaton. Literal i (0-based) is reported
+// as id i+1; empty literals are never reported.
+func newLiteralSet(literals []string) *literalSet {
+	s := &literalSet{
+		next: []map[byte]int32{{}},
+		fail: []int32{0},
+		out:  [][]int32{nil},
+	}
+	for i, lit := range literals {
+		if lit == "" {
+			continue
+		}
+		node := int32(0)
+		for j := 0; j < len(lit); j++ {
+			child, ok := s.next[node][lit[j]]
+			if !ok {
+				child = int32(len(s.next))
+				s.next = append(s.next, map[byte]int32{})
+				s.fail = append(s.fail, 0)
+				s.out = append(s.out, nil)
+				s.next[node][lit[j]] = child
+			}
+			node = child
+		}
+		s.out[node] = append(s.out[node], int32(i+1))
+	}
+
+	// Breadth-first, so every fail target is finished before it is
+	// inherited from.
+	queue := make([]int32, 0, len(s.next))
+	for _, child := range s.next[0] {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for b, child := range s.next[node] {
+			f := s.fail[node]
+			for {
+				if to, ok := s.next[f][b]; ok {
+					s.fail[child] = to
+					break
+				}
+				if f == 0 {
+					break
+				}
+				f = s.fail[f]
+			}
+			s.out[child] = append(s.out[child], s.out[s.fail[child]]...)
+			queue = append(queue, child)
+		}
+	}
+	return s
+}
+
+// scan calls found once for each literal id present in text.
+func (s *literalSet) scan(text string, found func(id int32)) {
+	seen := make(map[int32]bool)
+	node := int32(0)
+	for i := 0; i < len(text); i++ {
+		for {
+			if to, ok := s.next[node][text[i]]; ok {
+				node = to
+				break
+			}
+			if node == 0 {
+				break
+			}
+			node = s.fail[node]
+		}
+		for _, id := range s.out[node] {
+			if !seen[id] {
+				seen[id] = true
+				found(id)
+			}
+		}
+	}
+}
+
+// registerLiteralSetType registers the LiteralSet userdata type.
+func registerLiteralSetType(L *glua.LState) {
+	mt := L.NewTypeMetatable(luaLiteralSetTypeName)
+	L.SetField(mt, "__index", L.SetFuncs(L.NewTable(), map[string]glua.LGFunction{
+		"scan": literalSetScan,
+	}))
+}
+
+// literalSetScan returns a set {[id] = true} of the literals present
+// in text. Usage: set:scan(text)
+func literalSetScan(L *glua.LState) int {
+	ud := L.CheckUserData(1)
+	s, ok := ud.Value.(*literalSet)
+	if !ok {
+		L.ArgError(1, "literal set expected")
+		return 0
+	}
+	text := L.CheckString(2)
+	found := L.NewTable()
+	s.scan(text, func(id int32) {
+		found.RawSetInt(int(id), glua.LTrue)
+	})
+	L.Push(found)
+	return 1
+}
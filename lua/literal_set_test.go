@@ -0,0 +1,46 @@
+package lua
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestRequiredLiteral verifies the pre-filter literal is one every
+// match must contain, and that patterns without one yield "".
+func TestRequiredLiteral(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`^(\w+) tells you: (.*)$`, " tells you: "},
+		{`^HP: (\d+)/(\d+)`, "HP: "},
+		{`\bgoblin\b`, "goblin"},
+		{`(?:falls dead)+`, "falls dead"},
+		{`(ab){2,}x`, "ab"},
+		{`you (?:hit|miss) the rat`, " the rat"},
+		{`(foo)?bar`, "bar"},
+		{`(?i)goblin`, ""},
+		{`hit|miss`, ""},
+		{`(rat)*`, ""},
+		{`^\d+$`, ""},
+		{`(unclosed`, ""},
+	}
+	for _, tt := range tests {
+		if got := requiredLiteral(tt.pattern); got != tt.want {
+			t.Errorf("requiredLiteral(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+// TestLiteralSetScan verifies one scan reports every literal present,
+// including overlapping ones and one nested inside another.
+func TestLiteralSetScan(t *testing.T) {
+	set := newLiteralSet([]string{"he", "she", "his", "hers", "", "xyz"})
+	var got []int
+	set.scan("ushers and his", func(id int32) { got = append(got, int(id)) })
+	sort.Ints(got)
+	if want := []int{1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("scan = %v, want %v", got, want)
+	}
+}
@@ -1,7 +1,6 @@
 package lua
 
 import (
-	"sort"
 	"strings"
 	"testing"
 
@@ -11,17 +10,10 @@ import (
 // loadCoreScripts loads the embedded core into a fresh engine, in
 // order — what Session.boot does after /reload.
 func loadCoreScripts(engine *Engine) error {
-	entries, err := CoreScripts.ReadDir("core")
+	files, err := CoreOrder(CoreScripts, "core")
 	if err != nil {
 		return err
 	}
-	var files []string
-	for _, e := range entries {
-		if !e.IsDir() {
-			files = append(files, e.Name())
-		}
-	}
-	sort.Strings(files)
 	for _, f := range files {
 		content, err := CoreScripts.ReadFile("core/" + f)
 		if err != nil {
@@ -34,6 +26,16 @@ func loadCoreScripts(engine *Engine) error {
 	return nil
 }
 
+func countLogged(host *MockHost, substr string) int {
+	n := 0
+	for _, w := range host.LogWrites {
+		if strings.Contains(w, substr) {
+			n++
+		}
+	}
+	return n
+}
+
 func logContains(host *MockHost, substr string) bool {
 	for _, w := range host.LogWrites {
 		if strings.Contains(w, substr) {
@@ -124,6 +126,67 @@ func TestLogRawModeSurvivesReload(t *testing.T) {
 	}
 }
 
+// TestLogOriginFilter verifies the origin option picks which lines are
+// logged, that script output is opt-in, and that the choice survives
+// a reload.
+func TestLogOriginFilter(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("t", `rune.echo("chrome before")`); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.DoString("t", `rune.log.start("test.log")`); err != nil {
+		t.Fatal(err)
+	}
+	engine.OnEcho("look")
+	if err := engine.DoString("t", `rune.echo("chrome by default")`); err != nil {
+		t.Fatal(err)
+	}
+	if !logContains(host, "look") || logContains(host, "chrome") {
+		t.Errorf("default log should keep echoes and skip script output: %q", host.LogWrites)
+	}
+
+	if err := engine.DoString("t", `rune.log.start("test.log", { origin = { "server", "script" } })`); err != nil {
+		t.Fatal(err)
+	}
+	host.LogWrites = nil
+	engine.OnEcho("north")
+	engine.OnOutput(text.NewLine("You go north."))
+	if err := engine.DoString("t", `rune.echo("[Map] updated")`); err != nil {
+		t.Fatal(err)
+	}
+	if logContains(host, "> north") {
+		t.Errorf("echo logged despite origin filter: %q", host.LogWrites)
+	}
+	if !logContains(host, "You go north.") || !logContains(host, "[Map] updated") {
+		t.Errorf("server and script lines should be logged: %q", host.LogWrites)
+	}
+
+	engine.Close()
+	engine2 := NewEngine(host)
+	if err := engine2.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadCoreScripts(engine2); err != nil {
+		t.Fatal(err)
+	}
+	defer engine2.Close()
+
+	host.LogWrites = nil
+	engine2.OnEcho("south")
+	if err := engine2.DoString("t", `rune.echo("[Map] after reload")`); err != nil {
+		t.Fatal(err)
+	}
+	if logContains(host, "south") || !logContains(host, "[Map] after reload") {
+		t.Errorf("origin filter must survive reload: %q", host.LogWrites)
+	}
+
+	if err := engine2.DoString("t", `rune.log.start("x.log", { origin = "prompt" })`); err == nil {
+		t.Error("an unknown origin should raise")
+	}
+}
+
 func TestLogStartBadOptsRaises(t *testing.T) {
 	engine, _, cleanup := setupTest(t)
 	defer cleanup()
@@ -132,3 +195,52 @@ func TestLogStartBadOptsRaises(t *testing.T) {
 		t.Error("non-table opts should raise")
 	}
 }
+
+func TestConnectionBannersAreLogged(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	host.Term = Terminal{Width: 60}
+	if err := engine.DoString("t", `rune.log.start("test.log")`); err != nil {
+		t.Fatal(err)
+	}
+	host.DrainPrintCalls()
+
+	engine.CallHook("connected", "mud.example.com:4000")
+	engine.CallHook("disconnected", "reset", "read: connection reset by peer")
+
+	prints := host.DrainPrintCalls()
+	if len(prints) != 2 {
+		t.Fatalf("expected 2 banners, got %q", prints)
+	}
+	for i, want := range []string{
+		"──── Connected to mud.example.com:4000 ── ",
+		"──── Disconnected (connection reset: read: connection reset by peer) ── ",
+	} {
+		if !strings.Contains(text.StripANSI(prints[i]), want) {
+			t.Errorf("banner %d = %q, want it to contain %q", i, prints[i], want)
+		}
+		if !logContains(host, want) {
+			t.Errorf("banner %q not logged: %q", want, host.LogWrites)
+		}
+	}
+	if got := text.Width(text.StripANSI(prints[0])); got != 60 {
+		t.Errorf("connect banner is %d cells wide, want the terminal's 60", got)
+	}
+
+	// A log that takes script output gets the echoed banner, once.
+	assertLua(t, engine, `rune.log.stop(); rune.log.start("script.log", { origin = { "server", "script" } })`)
+	host.LogWrites = nil
+	engine.CallHook("connected", "mud.example.com:4000")
+	host.DrainPrintCalls()
+	if n := countLogged(host, "Connected to mud.example.com:4000"); n != 1 {
+		t.Errorf("banner logged %d times with script origin: %q", n, host.LogWrites)
+	}
+
+	// false restores the plain one-line notices, which are not logged.
+	assertLua(t, engine, `rune.config.banner = false`)
+	engine.CallHook("connected", "mud.example.com:4000")
+	if prints := host.DrainPrintCalls(); len(prints) != 1 || prints[0] != "[System] Connected to mud.example.com:4000" {
+		t.Errorf("plain notice = %q", prints)
+	}
+}
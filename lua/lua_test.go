@@ -1,14 +1,13 @@
 package lua
 
 import (
-	"sort"
 	"testing"
 
 	"github.com/mmcdole/rune/text"
 )
 
 // setupTest creates a test environment and returns a cleanup function
-func setupTest(t *testing.T) (*Engine, *MockHost, func()) {
+func setupTest(t testing.TB) (*Engine, *MockHost, func()) {
 	t.Helper()
 
 	host := NewMockHost()
@@ -20,20 +19,11 @@ func setupTest(t *testing.T) (*Engine, *MockHost, func()) {
 	}
 
 	// Load core scripts (mimicking Session.boot())
-	entries, err := CoreScripts.ReadDir("core")
+	files, err := CoreOrder(CoreScripts, "core")
 	if err != nil {
-		t.Fatal("Failed to read core scripts:", err)
+		t.Fatal("Failed to order core scripts:", err)
 	}
 
-	var files []string
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			files = append(files, entry.Name())
-		}
-	}
-	// Sort for consistent load order (mirrors Session.loadCoreScripts)
-	sort.Strings(files)
-
 	for _, file := range files {
 		content, err := CoreScripts.ReadFile("core/" + file)
 		if err != nil {
@@ -0,0 +1,67 @@
+package lua
+
+// Macros (77_macros.lua): recording typed lines, saving them to the
+// durable store, and delayed playback through rune.send.
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMacroRecordAndPlay verifies typed lines (but not slash commands)
+// are recorded, saved to rune.store on stop, and replayed through
+// rune.send one macro_delay apart; stop halts a playback mid-way.
+func TestMacroRecordAndPlay(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+	engine.UpdateState(ClientState{Connected: true, Address: "mud.example.com:4000"})
+
+	assertLua(t, engine, `rune.alias.exact("k", "kill rat")`)
+	engine.OnInput("/macro record fight")
+	engine.OnInput("k")
+	engine.OnInput("/echo not recorded")
+	engine.OnInput("#2 loot;wield sword")
+	engine.OnInput("/macro stop")
+	host.DrainNetworkCalls()
+
+	if got := host.StoreData["macros"]; got != `{"fight":["k","#2 loot;wield sword"]}` {
+		t.Fatalf("stored macros = %s", got)
+	}
+
+	assertLua(t, engine, `rune.config.macro_delay = 2; assert(rune.macro.play("fight"))`)
+	if sent := host.DrainNetworkCalls(); !reflect.DeepEqual(sent, []string{"kill rat"}) {
+		t.Fatalf("first step sent %v, want [kill rat]", sent)
+	}
+	wakes := host.DrainScheduledTimers()
+	if len(wakes) != 1 || wakes[0].Duration.Seconds() != 2 {
+		t.Fatalf("scheduled = %+v, want one 2s step", wakes)
+	}
+	engine.OnTimer(wakes[0].ID)
+	if sent := host.DrainNetworkCalls(); !reflect.DeepEqual(sent, []string{"loot", "loot", "wield sword"}) {
+		t.Fatalf("second step sent %v", sent)
+	}
+	if len(host.DrainScheduledTimers()) != 0 {
+		t.Fatal("playback scheduled past the last line")
+	}
+
+	// Stop with nothing recording halts a playback in progress.
+	assertLua(t, engine, `rune.macro.play("fight")`)
+	host.DrainNetworkCalls()
+	wakes = host.DrainScheduledTimers()
+	assertLua(t, engine, `local name, err = rune.macro.stop(); assert(name == nil and err == nil)`)
+	engine.OnTimer(wakes[0].ID)
+	if sent := host.DrainNetworkCalls(); len(sent) != 0 {
+		t.Fatalf("halted playback sent %v", sent)
+	}
+
+	assertLua(t, engine, `
+		assert(select(2, rune.macro.stop()) == "not recording")
+		assert(rune.macro.play("missing") == nil)
+		local list = rune.macro.list()
+		assert(#list == 1 and list[1].name == "fight" and list[1].count == 2)
+		assert(rune.macro.remove("fight") and rune.macro.get("fight") == nil)
+	`)
+	if _, ok := host.StoreData["macros"]; ok {
+		t.Fatal("removing the last macro left the store key")
+	}
+}
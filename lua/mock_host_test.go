@@ -1,11 +1,14 @@
 package lua
 
 import (
+	"fmt"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/mmcdole/rune/input"
+	"github.com/mmcdole/rune/text"
 	"github.com/mmcdole/rune/ui"
 )
 
@@ -17,16 +20,44 @@ type MockHost struct {
 	mu sync.Mutex
 
 	// Captured calls
-	SendCalls       []string
-	PrintCalls      []string
-	QuitCalled      bool
-	ConnectCalls    []string
-	DisconnectCalls int
-	ReloadCalls     int
-	PaneCalls       []struct{ Op, Name, Data string }
-	PickerCalls     []ui.ShowPickerMsg
-	ClipboardCalls  []string
-	ScheduledTimers []struct {
+	SendCalls            []string
+	PrintCalls           []string
+	Recent               []text.Line // what RecentLines reads from
+	Term                 Terminal    // what Terminal reports
+	QuitCalled           bool
+	ConnectCalls         []string
+	DisconnectCalls      int
+	ReloadCalls          int
+	PaneCalls            []struct{ Op, Name, Data string }
+	PanesShown           map[string]bool // visibility as set_visible/toggle left it
+	Unread               map[string]int
+	PickerCalls          []ui.ShowPickerMsg
+	ClipboardCalls       []string
+	OpenURLCalls         []string
+	CopyModeCalls        int
+	JumpToInputCalls     int
+	ClearScreenCalls     int
+	ClearScrollbackCalls int
+	DedupeCalls          []bool
+	OutputLimit          int // the server-line cap set through rune.ui.throttle
+	FlushIntervals       []time.Duration
+	GrepCalls            []ui.GrepScrollbackMsg
+	WrapCalls            []bool
+	SplitCalls           []bool
+	Themes               []ui.Theme
+	ScrollColumnsCalls   []struct {
+		Name string
+		Cols int
+	}
+	ScrollPagesCalls []struct {
+		Name  string
+		Pages float64
+	}
+	ClearPromptCalls int
+	BellCalls        []struct{ Audible, Visual bool }
+	NotifyCalls      []struct{ Title, Body string }
+	SoundCalls       []string
+	ScheduledTimers  []struct {
 		ID       int
 		Duration time.Duration
 		Repeat   bool
@@ -35,9 +66,19 @@ type MockHost struct {
 	// Timer ID generation
 	nextTimerID int
 
+	// Heartbeat setting (see Host.SetTick)
+	TickInterval time.Duration
+	TickPaused   bool
+
 	// When set, Send fails with this error instead of recording the call
 	SendErr error
 
+	// When set, OpenURL fails with this error instead of recording the call
+	OpenURLErr error
+
+	// When set, Notify fails with this error instead of recording the call
+	NotifyErr error
+
 	// When set, OpenEditor delegates here (e.g. to simulate a slow editor)
 	OpenEditorFn func(initial string) (string, bool)
 
@@ -49,26 +90,79 @@ type MockHost struct {
 	LogActive bool
 	LogWrites []string
 
+	// Raw traffic trace (see Host.TraceStart)
+	TracePath string
+
 	// Durable store capture (see Host.StoreSet); raw JSON values
 	StoreData map[string]string
 
+	// Secret store (see Host.SecretSet)
+	Secrets map[string]string
+
 	// GMCP capture (see Host.GMCPSend)
 	GMCPSends      []struct{ Package, Data string }
 	GMCPErr        error // when set, GMCPSend fails with this error
 	GMCPNegotiated bool  // what GMCPActive reports
 
+	// Latency probe capture (see Host.NetPing)
+	Pings   int
+	PingErr error    // when set, NetPing fails with this error
+	Stats   NetStats // what NetStats reports
+
+	// NEW-ENVIRON variables (see Host.SetEnviron): the last set pushed
+	Environ map[string]string
+
+	// Line length limits pushed (see Host.SetMaxLine)
+	MaxLines []int
+
+	// MXP acceptance pushed (see Host.SetMXP): the last value
+	MXP bool
+
 	// HTTP capture (see Host.HTTPRequest)
 	HTTPCalls []MockHTTPCall
 
+	// Program runs (see Host.Exec)
+	ExecCalls []MockExecCall
+
+	// Bar render requests (see Host.MarkBarsDirty)
+	BarsDirty int
+
+	// Option negotiation capture (see Host.TelnetNegotiate)
+	Negotiations []struct {
+		Command string
+		Option  byte
+	}
+	Abandoned []byte // options passed to TelnetAbandon
+
+	// Prompt detection (see Host.SetPromptDetect): the last setting
+	PromptDetect struct {
+		Mode, Pattern string
+		Stable        time.Duration
+	}
+
 	// Input line state (see Host.GetInput/SetInput); mirrors the real
 	// UI, where SetInput moves the cursor to the end of the text
 	InputText   string
 	InputCursor int
 	InputMode   input.SubmissionMode
+	// Set by rune.input.prompt / rune.input.placeholder / rune.input.mask / rune.input.ghost
+	InputPrompt      string
+	InputPlaceholder string
+	InputMasked      bool
+	InputGhost       string
+	InputWordBreak   string
+	InputQueue       int
 
 	// Command history returned by GetHistory, oldest first
 	History        []string
 	HistoryEntries []input.Submission
+
+	// Script change watching (see Host.SetAutoReload)
+	AutoReloadOn bool
+
+	// History policy pushed (see Host.SetHistoryPolicy)
+	HistorySize   int
+	HistoryDedupe string
 }
 
 // MockHTTPCall records one Host.HTTPRequest invocation.
@@ -77,6 +171,12 @@ type MockHTTPCall struct {
 	Req HTTPRequest
 }
 
+// MockExecCall records one Host.Exec invocation.
+type MockExecCall struct {
+	ID  int
+	Req ExecRequest
+}
+
 func NewMockHost() *MockHost {
 	return &MockHost{}
 }
@@ -97,6 +197,19 @@ func (m *MockHost) Print(text string) {
 	m.PrintCalls = append(m.PrintCalls, text)
 }
 
+func (m *MockHost) RecentLines(n int) []text.Line {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n = min(n, len(m.Recent))
+	return append([]text.Line(nil), m.Recent[len(m.Recent)-n:]...)
+}
+
+func (m *MockHost) Terminal() Terminal {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Term
+}
+
 func (m *MockHost) Quit() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -131,6 +244,71 @@ func (m *MockHost) GMCPActive() bool {
 	return m.GMCPNegotiated
 }
 
+func (m *MockHost) NetPing() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.PingErr != nil {
+		return m.PingErr
+	}
+	m.Pings++
+	return nil
+}
+
+func (m *MockHost) NetStats() NetStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Stats
+}
+
+func (m *MockHost) SetEnviron(vars map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Environ = vars
+}
+
+func (m *MockHost) SetMaxLine(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.MaxLines = append(m.MaxLines, n)
+}
+
+func (m *MockHost) SetMXP(on bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.MXP = on
+}
+
+func (m *MockHost) TelnetNegotiate(command string, option byte) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Negotiations = append(m.Negotiations, struct {
+		Command string
+		Option  byte
+	}{command, option})
+	return true, nil
+}
+
+func (m *MockHost) TelnetAbandon(option byte) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Abandoned = append(m.Abandoned, option)
+	return true
+}
+
+func (m *MockHost) SetPromptDetect(mode, pattern string, stable time.Duration) error {
+	switch mode {
+	case "auto", "ga", "regex", "timeout":
+	default:
+		return fmt.Errorf("unknown prompt detection mode %q", mode)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PromptDetect.Mode = mode
+	m.PromptDetect.Pattern = pattern
+	m.PromptDetect.Stable = stable
+	return nil
+}
+
 func (m *MockHost) Reload() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -141,6 +319,12 @@ func (m *MockHost) RefreshBars() {
 	// No-op for tests
 }
 
+func (m *MockHost) MarkBarsDirty() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.BarsDirty++
+}
+
 func (m *MockHost) PaneCreate(name string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -157,12 +341,26 @@ func (m *MockHost) PaneToggle(name string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.PaneCalls = append(m.PaneCalls, struct{ Op, Name, Data string }{"toggle", name, ""})
+	if m.PanesShown == nil {
+		m.PanesShown = make(map[string]bool)
+	}
+	m.PanesShown[name] = !m.PanesShown[name]
 }
 
 func (m *MockHost) PaneSetVisible(name string, visible bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.PaneCalls = append(m.PaneCalls, struct{ Op, Name, Data string }{"set_visible", name, strconv.FormatBool(visible)})
+	if m.PanesShown == nil {
+		m.PanesShown = make(map[string]bool)
+	}
+	m.PanesShown[name] = visible
+}
+
+func (m *MockHost) PaneVisible(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.PanesShown[name]
 }
 
 func (m *MockHost) PaneClear(name string) {
@@ -171,6 +369,40 @@ func (m *MockHost) PaneClear(name string) {
 	m.PaneCalls = append(m.PaneCalls, struct{ Op, Name, Data string }{"clear", name, ""})
 }
 
+func (m *MockHost) PaneResize(name string, height int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PaneCalls = append(m.PaneCalls, struct{ Op, Name, Data string }{"resize", name, strconv.Itoa(height)})
+}
+
+func (m *MockHost) PaneLimit(name string, lines, bytes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PaneCalls = append(m.PaneCalls, struct{ Op, Name, Data string }{"limit", name, fmt.Sprintf("%d %d", lines, bytes)})
+}
+
+func (m *MockHost) PaneGrow(name string, rows int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PaneCalls = append(m.PaneCalls, struct{ Op, Name, Data string }{"grow", name, strconv.Itoa(rows)})
+}
+
+func (m *MockHost) PaneUnread() map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	unread := make(map[string]int, len(m.Unread))
+	for name, n := range m.Unread {
+		unread[name] = n
+	}
+	return unread
+}
+
+func (m *MockHost) PaneFocus(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PaneCalls = append(m.PaneCalls, struct{ Op, Name, Data string }{"focus", name, ""})
+}
+
 func (m *MockHost) OnConfigChange() {
 	// No-op for tests - config change notifications not tracked
 }
@@ -187,6 +419,117 @@ func (m *MockHost) ClipboardSet(text string) {
 	m.ClipboardCalls = append(m.ClipboardCalls, text)
 }
 
+func (m *MockHost) Notify(title, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.NotifyErr != nil {
+		return m.NotifyErr
+	}
+	m.NotifyCalls = append(m.NotifyCalls, struct{ Title, Body string }{title, body})
+	return nil
+}
+
+func (m *MockHost) PlaySound(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.SoundCalls = append(m.SoundCalls, path)
+	return nil
+}
+
+func (m *MockHost) ClearPrompt() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ClearPromptCalls++
+}
+
+func (m *MockHost) Bell(audible, visual bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.BellCalls = append(m.BellCalls, struct{ Audible, Visual bool }{audible, visual})
+}
+
+func (m *MockHost) CopyMode() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.CopyModeCalls++
+}
+
+func (m *MockHost) JumpToInput() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.JumpToInputCalls++
+}
+
+func (m *MockHost) ClearScreen() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ClearScreenCalls++
+}
+
+func (m *MockHost) ClearScrollback() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ClearScrollbackCalls++
+}
+
+func (m *MockHost) SetDedupe(on bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.DedupeCalls = append(m.DedupeCalls, on)
+}
+
+func (m *MockHost) SetOutputRate(linesPerSecond int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.OutputLimit = linesPerSecond
+}
+
+func (m *MockHost) OutputRate() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.OutputLimit
+}
+
+func (m *MockHost) SetFlushInterval(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.FlushIntervals = append(m.FlushIntervals, d)
+}
+
+func (m *MockHost) GrepScrollback(req ui.GrepScrollbackMsg) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.GrepCalls = append(m.GrepCalls, req)
+}
+
+func (m *MockHost) SetTheme(theme ui.Theme) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Themes = append(m.Themes, theme)
+}
+
+func (m *MockHost) SetSplit(on bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.SplitCalls = append(m.SplitCalls, on)
+}
+
+func (m *MockHost) SetWrap(on bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.WrapCalls = append(m.WrapCalls, on)
+}
+
+func (m *MockHost) OpenURL(url string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.OpenURLErr != nil {
+		return m.OpenURLErr
+	}
+	m.OpenURLCalls = append(m.OpenURLCalls, url)
+	return nil
+}
+
 func (m *MockHost) GetHistory() []string {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -259,6 +602,41 @@ func (m *MockHost) StoreDelete(key string) error {
 	return nil
 }
 
+func (m *MockHost) SecretSet(name, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Secrets == nil {
+		m.Secrets = make(map[string]string)
+	}
+	m.Secrets[name] = value
+	return nil
+}
+
+func (m *MockHost) SecretGet(name string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.Secrets[name]
+	return v, ok
+}
+
+func (m *MockHost) SecretDelete(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.Secrets, name)
+	return nil
+}
+
+func (m *MockHost) SecretNames() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.Secrets))
+	for name := range m.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (m *MockHost) AddToHistory(cmd string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -268,6 +646,42 @@ func (m *MockHost) AddToHistory(cmd string) {
 	}
 }
 
+func (m *MockHost) ForgetHistory(text string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.History) - 1; i >= 0; i-- {
+		if m.History[i] == text {
+			m.History = append(m.History[:i], m.History[i+1:]...)
+			break
+		}
+	}
+	for i := len(m.HistoryEntries) - 1; i >= 0; i-- {
+		if m.HistoryEntries[i].Text == text {
+			m.HistoryEntries = append(m.HistoryEntries[:i], m.HistoryEntries[i+1:]...)
+			break
+		}
+	}
+}
+
+func (m *MockHost) SetAutoReload(on bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.AutoReloadOn = on
+}
+
+func (m *MockHost) AutoReload() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.AutoReloadOn
+}
+
+func (m *MockHost) SetHistoryPolicy(size int, dedupe string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.HistorySize = size
+	m.HistoryDedupe = dedupe
+}
+
 func (m *MockHost) LogStart(path string) (string, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -300,12 +714,39 @@ func (m *MockHost) LogStatus() (string, bool) {
 	return m.LogPath, m.LogActive
 }
 
+func (m *MockHost) TraceStart(path string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.TracePath = path
+	return path, nil
+}
+
+func (m *MockHost) TraceStop() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	was := m.TracePath != ""
+	m.TracePath = ""
+	return was
+}
+
+func (m *MockHost) TraceStatus() (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.TracePath, m.TracePath != ""
+}
+
 func (m *MockHost) HTTPRequest(id int, req HTTPRequest) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.HTTPCalls = append(m.HTTPCalls, MockHTTPCall{ID: id, Req: req})
 }
 
+func (m *MockHost) Exec(id int, req ExecRequest) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ExecCalls = append(m.ExecCalls, MockExecCall{ID: id, Req: req})
+}
+
 func (m *MockHost) GetInput() string {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -347,6 +788,42 @@ func (m *MockHost) InputSetCursor(pos int) {
 	m.InputCursor = input.ClampByteCursor(m.InputText, pos)
 }
 
+func (m *MockHost) InputSetPrompt(prompt string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.InputPrompt = prompt
+}
+
+func (m *MockHost) InputSetPlaceholder(text string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.InputPlaceholder = text
+}
+
+func (m *MockHost) InputSetMask(on bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.InputMasked = on
+}
+
+func (m *MockHost) InputSetGhost(text string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.InputGhost = text
+}
+
+func (m *MockHost) InputSetWordBreak(chars string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.InputWordBreak = chars
+}
+
+func (m *MockHost) InputSetQueue(limit int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.InputQueue = limit
+}
+
 func (m *MockHost) OpenEditor(initial string) (string, bool) {
 	if m.OpenEditorFn != nil {
 		return m.OpenEditorFn(initial)
@@ -362,6 +839,24 @@ func (m *MockHost) PaneScrollDown(name string, lines int) {
 	// No-op for tests
 }
 
+func (m *MockHost) PaneScrollColumns(name string, cols int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ScrollColumnsCalls = append(m.ScrollColumnsCalls, struct {
+		Name string
+		Cols int
+	}{name, cols})
+}
+
+func (m *MockHost) PaneScrollPages(name string, pages float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ScrollPagesCalls = append(m.ScrollPagesCalls, struct {
+		Name  string
+		Pages float64
+	}{name, pages})
+}
+
 func (m *MockHost) PaneScrollToTop(name string) {
 	// No-op for tests
 }
@@ -404,6 +899,19 @@ func (m *MockHost) TimerCancelAll() {
 	// No-op for tests
 }
 
+func (m *MockHost) SetTick(interval time.Duration, paused bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.TickInterval = interval
+	m.TickPaused = paused
+}
+
+func (m *MockHost) TickStatus() (time.Duration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.TickInterval, m.TickPaused
+}
+
 // Helper methods for tests
 
 func (m *MockHost) DrainNetworkCalls() []string {
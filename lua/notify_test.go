@@ -0,0 +1,50 @@
+package lua
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNotifyReachesHost(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	err := engine.DoString("test", `
+		assert(rune.notify("Tell", "Bob tells you: hi") == true)
+		assert(rune.notify("Ping") == true)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(host.NotifyCalls) != 2 ||
+		host.NotifyCalls[0].Title != "Tell" || host.NotifyCalls[0].Body != "Bob tells you: hi" ||
+		host.NotifyCalls[1].Body != "" {
+		t.Errorf("got notify calls %+v", host.NotifyCalls)
+	}
+}
+
+func TestNotifyFailureWarnsOnce(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	host.NotifyErr = errors.New("no desktop notification backend found")
+	err := engine.DoString("test", `
+		for i = 1, 3 do
+			local ok, err = rune.notify("Tell", "hi")
+			assert(ok == nil and err == "no desktop notification backend found")
+		end
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	warnings := 0
+	for _, p := range host.PrintCalls {
+		if strings.Contains(p, "no desktop notification backend found") {
+			warnings++
+		}
+	}
+	if warnings != 1 {
+		t.Errorf("got %d warnings, want 1: %q", warnings, host.PrintCalls)
+	}
+}
@@ -1,6 +1,13 @@
 package lua
 
-import "testing"
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/rune/text"
+)
 
 // rune.pane.show/hide are idempotent setters over one Go primitive;
 // what can silently break is the wrapper-to-flag mapping, so pin it.
@@ -33,3 +40,296 @@ func TestPaneShowHideReachHostWithVisibility(t *testing.T) {
 		}
 	}
 }
+
+// The resize binds act on whichever pane the UI has focused, so they
+// must reach the host with an empty name rather than guessing one.
+func TestPaneResizeReachesHost(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	script := `
+		rune.pane.resize("chat", 15)
+		rune.pane.resize("chat")
+		rune.pane.focus("chat")
+		rune.pane.grow("chat", 2)
+	`
+	if err := engine.DoString("test", script); err != nil {
+		t.Fatalf("script failed: %v", err)
+	}
+	engine.HandleKeyBind("ctrl+up")
+	engine.HandleKeyBind("ctrl+down")
+
+	want := []struct{ Op, Name, Data string }{
+		{"resize", "chat", "15"},
+		{"resize", "chat", "0"},
+		{"focus", "chat", ""},
+		{"grow", "chat", "2"},
+		{"grow", "", "1"},
+		{"grow", "", "-1"},
+	}
+	if len(host.PaneCalls) != len(want) {
+		t.Fatalf("got %d pane calls, want %d: %v", len(host.PaneCalls), len(want), host.PaneCalls)
+	}
+	for i, w := range want {
+		if host.PaneCalls[i] != w {
+			t.Errorf("call %d: got %v, want %v", i, host.PaneCalls[i], w)
+		}
+	}
+
+	if err := engine.DoString("test", `rune.pane.resize("chat", 0)`); err == nil {
+		t.Error("resize to 0 should raise")
+	}
+}
+
+// rune.pane.limit passes 0 for a budget left out and rejects
+// non-positive ones.
+func TestPaneLimitReachesHost(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		rune.pane.limit("combat", 200, 65536)
+		rune.pane.limit("chat", nil, 4096)
+	`)
+	want := []struct{ Op, Name, Data string }{
+		{"limit", "combat", "200 65536"},
+		{"limit", "chat", "0 4096"},
+	}
+	if !reflect.DeepEqual(host.PaneCalls, want) {
+		t.Fatalf("pane calls = %v, want %v", host.PaneCalls, want)
+	}
+	if err := engine.DoString("test", `rune.pane.limit("chat", 0)`); err == nil {
+		t.Error("a zero line budget should raise")
+	}
+}
+
+// The half-page binds go through page_up/page_down as signed page
+// fractions; rune.ui.scroll_step validates and reports the step.
+func TestPanePageScrollAndStep(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	engine.HandleKeyBind("ctrl+pageup")
+	engine.HandleKeyBind("ctrl+pagedown")
+	if err := engine.DoString("pages", `rune.pane.page_up("main")`); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []float64{-0.5, 0.5, -1}
+	if len(host.ScrollPagesCalls) != len(want) {
+		t.Fatalf("ScrollPagesCalls = %v, want pages %v", host.ScrollPagesCalls, want)
+	}
+	for i, c := range host.ScrollPagesCalls {
+		if c.Name != "main" || c.Pages != want[i] {
+			t.Errorf("call %d = %+v, want main %v", i, c, want[i])
+		}
+	}
+
+	assertLua(t, engine, `
+		assert(rune.ui.scroll_step() == 20)
+		assert(rune.ui.scroll_step(5) == 5 and rune.ui.scroll_step() == 5)
+		assert(not pcall(rune.ui.scroll_step, 0))
+		assert(not pcall(rune.ui.scroll_step, 2.5))
+	`)
+}
+
+// rune.ui.flush_interval reports the batch window, passes a valid one
+// to the host in milliseconds, and rejects values outside 4-1000.
+func TestFlushInterval(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		assert(rune.ui.flush_interval() == 16)
+		assert(rune.ui.flush_interval(50) == 50 and rune.ui.flush_interval() == 50)
+		assert(not pcall(rune.ui.flush_interval, 2))
+		assert(not pcall(rune.ui.flush_interval, 5000))
+		assert(not pcall(rune.ui.flush_interval, 7.5))
+	`)
+	if got := host.FlushIntervals; len(got) != 1 || got[0] != 50*time.Millisecond {
+		t.Fatalf("FlushIntervals = %v, want [50ms]", got)
+	}
+}
+
+// A pane's layout entry carries its border and title settings; an
+// unknown border raises.
+func TestLayoutPaneBorder(t *testing.T) {
+	engine, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `rune.ui.layout({
+		top = { { name = "chat", height = 8, border = "both", title = false } },
+		bottom = { "input" },
+	})`)
+	if got := engine.GetLayout().Top[0]; got.Border != "both" || !got.NoTitle || got.Height != 8 {
+		t.Fatalf("chat entry = %+v", got)
+	}
+	if err := engine.DoString("bad", `rune.ui.layout({ top = { { name = "chat", border = "left" } } })`); err == nil {
+		t.Fatal("an unknown border should raise")
+	}
+}
+
+// rune.scrollback.grep readies a named pane before asking the host for
+// the dump, and /grep reports a bad pattern instead of raising.
+func TestScrollbackGrep(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		rune.scrollback.grep("exit", { pane = "found", limit = 5, color = "yellow" })
+		assert(not pcall(rune.scrollback.grep, "("))
+		assert(not pcall(rune.scrollback.grep, "x", { limit = 0 }))
+		assert(not pcall(rune.scrollback.grep, "x", { color = "plaid" }))
+	`)
+	engine.OnInput("/grep quest hint")
+	engine.OnInput("/grep [")
+
+	if len(host.GrepCalls) != 2 {
+		t.Fatalf("GrepCalls = %+v, want 2", host.GrepCalls)
+	}
+	first, second := host.GrepCalls[0], host.GrepCalls[1]
+	if first.Pattern.String() != "exit" || first.Pane != "found" || first.Limit != 5 || first.Highlight != "\x1b[33m" {
+		t.Errorf("grep call = %+v", first)
+	}
+	if second.Pattern.String() != "quest hint" || second.Pane != "" || second.Limit != 100 || second.Highlight != "\x1b[7m" {
+		t.Errorf("/grep call = %+v", second)
+	}
+	want := []struct{ Op, Name, Data string }{
+		{"create", "found", ""}, {"clear", "found", ""}, {"set_visible", "found", "true"},
+	}
+	if !reflect.DeepEqual(host.PaneCalls, want) {
+		t.Errorf("pane calls = %v, want %v", host.PaneCalls, want)
+	}
+	if printed := strings.Join(host.DrainPrintCalls(), "\n"); !strings.Contains(printed, "[Error]") {
+		t.Errorf("bad /grep pattern printed %q", printed)
+	}
+}
+
+// rune.scrollback.lines returns the host's newest lines, oldest first,
+// optionally stripped, and rejects a bad count.
+func TestScrollbackLines(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+	host.Recent = []text.Line{text.NewLine("You are in a field."), text.NewLine("\x1b[1;32mExits: north\x1b[0m")}
+
+	assertLua(t, engine, `
+		local raw = rune.scrollback.lines(1)
+		assert(#raw == 1 and raw[1] == "\27[1;32mExits: north\27[0m", raw[1])
+		local clean = rune.scrollback.lines(5, { clean = true })
+		assert(#clean == 2 and clean[1] == "You are in a field." and clean[2] == "Exits: north")
+		assert(#rune.scrollback.lines(0) == 0)
+		assert(not pcall(rune.scrollback.lines, -1))
+		assert(not pcall(rune.scrollback.lines))
+	`)
+}
+
+// rune.scrollback.lines with an origin keeps the newest lines from
+// there, skipping the others however recent.
+func TestScrollbackLinesByOrigin(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+	line := func(raw string, origin text.Origin) text.Line {
+		l := text.NewLine(raw)
+		l.Origin = origin
+		return l
+	}
+	host.Recent = []text.Line{
+		line("You are in a field.", text.OriginServer),
+		line("> look", text.OriginEcho),
+		line("[Log] started", text.OriginScript),
+		line("A rabbit hops by.", text.OriginServer),
+		line("> north", text.OriginEcho),
+	}
+
+	assertLua(t, engine, `
+		local server = rune.scrollback.lines(5, { origin = "server" })
+		assert(#server == 2 and server[1] == "You are in a field." and server[2] == "A rabbit hops by.")
+		local echo = rune.scrollback.lines(1, { origin = "echo" })
+		assert(#echo == 1 and echo[1] == "> north", echo[1])
+		assert(rune.scrollback.lines(5, { origin = "script" })[1] == "[Log] started")
+		assert(not pcall(rune.scrollback.lines, 5, { origin = "prompt" }))
+	`)
+}
+
+// TestPaneInputRouting verifies a focused pane takes typed lines
+// (prefix or handler), lets slash commands through, names itself in
+// the input prompt, and that cycling skips hidden panes.
+func TestPaneInputRouting(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		notes = {}
+		rune.pane.input("chat", "ct")
+		rune.pane.input("notes", function(text) table.insert(notes, text) end)
+		rune.pane.show("chat")
+	`)
+	assertLua(t, engine, `assert(rune.pane.cycle_input() == "chat")`)
+	if !strings.Contains(host.InputPrompt, "chat") {
+		t.Fatalf("prompt = %q", host.InputPrompt)
+	}
+	engine.OnInput("hello all")
+	engine.OnInput("/echo still a command")
+	if sent := host.DrainNetworkCalls(); !reflect.DeepEqual(sent, []string{"ct hello all"}) {
+		t.Fatalf("sent %v", sent)
+	}
+
+	// notes is hidden, so the cycle goes back to main.
+	assertLua(t, engine, `assert(rune.pane.cycle_input() == nil)`)
+	if host.InputPrompt != "> " {
+		t.Fatalf("prompt not restored: %q", host.InputPrompt)
+	}
+	engine.OnInput("look")
+	if sent := host.DrainNetworkCalls(); !reflect.DeepEqual(sent, []string{"look"}) {
+		t.Fatalf("main input sent %v", sent)
+	}
+
+	assertLua(t, engine, `
+		assert(rune.pane.input_focus("notes") == "notes")
+		assert(rune.pane.visible("notes"), "focusing should show the pane")
+	`)
+	engine.OnInput("buy bread")
+	assertLua(t, engine, `
+		assert(notes[1] == "buy bread")
+		rune.pane.input("notes", nil)
+		assert(rune.pane.input_focus() == nil, "removing the target should return to main")
+	`)
+	if sent := host.DrainNetworkCalls(); len(sent) != 0 {
+		t.Fatalf("handler pane sent %v", sent)
+	}
+}
+
+// TestPaneInputHandlerErrorsAreIsolated verifies a failing pane handler
+// is disabled on its own while the pane-input hook keeps routing, so
+// the pane's lines never reach the server unprefixed.
+func TestPaneInputHandlerErrorsAreIsolated(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		rune.pane.input("notes", function() error("boom") end)
+		rune.pane.input_focus("notes")
+	`)
+	host.DrainPrintCalls()
+	for i := 0; i < 5; i++ {
+		engine.OnInput("note this")
+	}
+	printed := strings.Join(host.DrainPrintCalls(), "\n")
+	if !strings.Contains(printed, `Pane input "notes"`) || !strings.Contains(printed, "disabled") {
+		t.Fatalf("errors not reported against the pane: %q", printed)
+	}
+	if sent := host.DrainNetworkCalls(); len(sent) != 0 {
+		t.Fatalf("sent %v", sent)
+	}
+	assertLua(t, engine, `
+		for _, h in ipairs(rune.hooks.list()) do
+			if h.name == "pane-input" then
+				assert(h.enabled, "pane-input hook was disabled")
+			end
+		end
+		got = nil
+		rune.pane.input("notes", function(text) got = text end)
+	`)
+	engine.OnInput("fixed")
+	assertLua(t, engine, `assert(got == "fixed")`)
+}
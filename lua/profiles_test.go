@@ -0,0 +1,68 @@
+package lua
+
+// Profiles (66_profiles.lua): per-profile init.lua loading on ready,
+// explicit switching, and selection by the connected world.
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestProfileLoadAndSelect verifies the active profile's init.lua runs
+// on ready, rune.profile switches through the session store and a
+// reload, and connecting to a world selects its profile.
+func TestProfileLoadAndSelect(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	engine.SetConfigDir(dir)
+	for _, name := range []string{"aard", "arctic"} {
+		pdir := filepath.Join(dir, "profiles", name)
+		if err := os.MkdirAll(pdir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		code := "loaded_profile = '" + name + "'\n"
+		if err := os.WriteFile(filepath.Join(pdir, "init.lua"), []byte(code), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// No profile: ready loads nothing.
+	engine.CallHook("ready")
+	assertLua(t, engine, `assert(loaded_profile == nil)`)
+
+	assertLua(t, engine, `assert(rune.profile("aard"))`)
+	if host.SessionStore["profile"] != "aard" || host.ReloadCalls != 1 {
+		t.Fatalf("session profile = %q, reloads = %d", host.SessionStore["profile"], host.ReloadCalls)
+	}
+	engine.CallHook("ready")
+	assertLua(t, engine, `assert(loaded_profile == "aard")`)
+
+	// Same profile again does not reload; bad names are refused.
+	assertLua(t, engine, `assert(rune.profile("aard") == true)`)
+	assertLua(t, engine, `assert(rune.profile("../x") == nil)`)
+	if host.ReloadCalls != 1 {
+		t.Fatalf("reloads = %d, want 1", host.ReloadCalls)
+	}
+
+	// A world selects the profile named after it, or its profile field.
+	engine.OnInput("/world add arctic mud.arcticmud.org 2700")
+	engine.CallHook("connected", "mud.arcticmud.org:2700")
+	if host.SessionStore["profile"] != "arctic" || host.ReloadCalls != 2 {
+		t.Fatalf("after connect: profile = %q, reloads = %d", host.SessionStore["profile"], host.ReloadCalls)
+	}
+	if err := engine.DoString("world", `rune.world.add("aardwolf", "aardmud.org:4000", { profile = "aard" })`); err != nil {
+		t.Fatal(err)
+	}
+	engine.CallHook("connected", "aardmud.org:4000")
+	if host.SessionStore["profile"] != "aard" {
+		t.Fatalf("profile field: profile = %q", host.SessionStore["profile"])
+	}
+
+	// An unknown address leaves the profile alone; false clears it.
+	engine.CallHook("connected", "elsewhere.org:23")
+	assertLua(t, engine, `assert(rune.profiles.current() == "aard")`)
+	assertLua(t, engine, `assert(rune.profile(false) == true and rune.profiles.current() == nil)`)
+}
@@ -0,0 +1,117 @@
+package lua
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mmcdole/rune/text"
+)
+
+func TestPromptGagKeepsTriggers(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("setup", `
+		seen, hp = 0, nil
+		rune.trigger.regex("^(\\d+)hp", function(m) hp = m[1] end)
+		rune.on_prompt(function() seen = seen + 1 end)
+		rune.prompt.gag()
+	`); err != nil {
+		t.Fatal(err)
+	}
+	if host.ClearPromptCalls != 1 {
+		t.Errorf("gag should clear the showing prompt, ClearPromptCalls = %d", host.ClearPromptCalls)
+	}
+
+	if _, show := engine.OnPrompt(text.NewLine("100hp 50sp>")); show {
+		t.Error("gagged prompt should not be shown")
+	}
+	assertLua(t, engine, `
+		assert(seen == 1, "on_prompt handler runs before the gag: " .. seen)
+		assert(hp == "100", "prompt trigger should still fire: " .. tostring(hp))
+		assert(rune.prompt.gagged())
+	`)
+
+	if err := engine.DoString("ungag", `rune.prompt.ungag()`); err != nil {
+		t.Fatal(err)
+	}
+	if got, show := engine.OnPrompt(text.NewLine("90hp 50sp>")); !show || got != "90hp 50sp>" {
+		t.Errorf("OnPrompt after ungag = %q, %v", got, show)
+	}
+}
+
+func TestOnPromptRewritesAndGags(t *testing.T) {
+	engine, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("setup", `
+		rune.on_prompt(function(line)
+			if line:raw():find("secret") then return false end
+			return "> "
+		end)
+	`); err != nil {
+		t.Fatal(err)
+	}
+	if got, show := engine.OnPrompt(text.NewLine("HP:100>")); !show || got != "> " {
+		t.Errorf("rewritten prompt = %q, %v", got, show)
+	}
+	if _, show := engine.OnPrompt(text.NewLine("secret>")); show {
+		t.Error("handler returning false should gag the prompt")
+	}
+	if err := engine.DoString("bad", `rune.on_prompt("nope")`); err == nil {
+		t.Error("rune.on_prompt with a non-function should error")
+	}
+}
+
+// TestPromptDetectReachesHost verifies rune.prompt.detect passes the
+// mode, pattern, and timeout through, and reports bad settings.
+func TestPromptDetectReachesHost(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `assert(rune.prompt.detect("timeout", { ms = 400 }))`)
+	if host.PromptDetect.Mode != "timeout" || host.PromptDetect.Stable != 400*time.Millisecond {
+		t.Fatalf("detect = %+v, want timeout/400ms", host.PromptDetect)
+	}
+	assertLua(t, engine, `assert(rune.prompt.detect("regex", { pattern = "^<\\d+hp> $" }))`)
+	if host.PromptDetect.Mode != "regex" || host.PromptDetect.Pattern != `^<\d+hp> $` {
+		t.Fatalf("detect = %+v, want the regex pattern", host.PromptDetect)
+	}
+	assertLua(t, engine, `
+		assert(rune.prompt.detect("regex") == nil)
+		assert(rune.prompt.detect("guess") == nil)
+	`)
+	if host.PromptDetect.Mode != "regex" {
+		t.Fatalf("a refused setting replaced the mode: %+v", host.PromptDetect)
+	}
+}
+
+// TestPromptTrigger verifies prompt triggers see prompts only, get the
+// captures, and still fire when prompts are gagged.
+func TestPromptTrigger(t *testing.T) {
+	engine, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		hp, mp, fired = nil, nil, 0
+		rune.prompt.trigger("^\\[HP=(\\d+) MP=(\\d+)\\]", function(m, ctx)
+			hp, mp = tonumber(m[1]), tonumber(m[2])
+			fired = fired + 1
+			assert(ctx.type == "prompt" and ctx.line:clean() == m[0] .. ">")
+		end, { name = "vitals" })
+		rune.prompt.gag()
+	`)
+	engine.OnOutput(text.NewLine("[HP=1 MP=1]> is not a prompt"))
+	engine.OnPrompt(text.NewLine("\x1b[32m[HP=100 MP=50]\x1b[0m>"))
+	assertLua(t, engine, `
+		assert(fired == 1 and hp == 100 and mp == 50, fired .. " " .. tostring(hp))
+		assert(rune.prompt.list()[1].hits == 1)
+		rune.prompt.disable("vitals")
+	`)
+	engine.OnPrompt(text.NewLine("[HP=90 MP=50]>"))
+	assertLua(t, engine, `assert(hp == 100, "disabled prompt trigger fired")`)
+
+	if err := engine.DoString("bad", `rune.prompt.trigger("(", function() end)`); err == nil {
+		t.Error("an invalid pattern should raise")
+	}
+}
@@ -0,0 +1,79 @@
+package lua
+
+// Command queue (78_queue.lua): commands held until a script sends
+// them, previewed in the "queue" bar and edited through a picker.
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mmcdole/rune/text"
+)
+
+// TestQueueSendsInOrderAndPreviews verifies queued commands go out one
+// per next() through rune.send, that move/remove/front reorder them,
+// and that the bar shows what is pending.
+func TestQueueSendsInOrderAndPreviews(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if got := engine.RenderBars(80, true)["queue"]; got.Left != "" {
+		t.Fatalf("empty queue bar = %+v", got)
+	}
+
+	assertLua(t, engine, `
+		rune.alias.exact("k", "kill rat")
+		rune.queue.add("k")
+		rune.queue.add("bash")
+		rune.queue.add("loot")
+		assert(rune.queue.add("stand", { front = true }) == 1)
+		assert(rune.queue.move(4, 2))
+		assert(rune.queue.remove(3) == "k")
+		local list = rune.queue.list()
+		assert(#list == 3 and list[1] == "stand" and list[2] == "loot" and list[3] == "bash")
+	`)
+	if got := engine.RenderBars(80, false)["queue"]; !strings.Contains(got.Left, "Queue 3: stand | loot | bash") {
+		t.Fatalf("queue bar = %+v", got)
+	}
+
+	assertLua(t, engine, `rune.queue.add("say 日本語です")`)
+	got := engine.RenderBars(20, true)["queue"].Left
+	if !strings.Contains(got, "Queue 4: stand | ...") || text.Width(got) > 20 {
+		t.Fatalf("narrow queue bar = %q (%d cells)", got, text.Width(got))
+	}
+	if got := engine.RenderBars(42, true)["queue"].Left; !strings.Contains(got, "| say 日本...") || text.Width(got) > 42 {
+		t.Fatalf("wide-rune queue bar = %q (%d cells)", got, text.Width(got))
+	}
+	assertLua(t, engine, `assert(rune.queue.remove(4) == "say 日本語です")`)
+
+	assertLua(t, engine, `
+		assert(rune.queue.next() == "stand")
+		assert(rune.queue.next() == "loot")
+		rune.queue.add("k", { front = true })
+		assert(rune.queue.next() == "k")
+		assert(rune.queue.count() == 1 and rune.queue.clear() == 1)
+		assert(rune.queue.next() == nil)
+	`)
+	if sent := host.DrainNetworkCalls(); !reflect.DeepEqual(sent, []string{"stand", "loot", "kill rat"}) {
+		t.Fatalf("sent %v", sent)
+	}
+}
+
+// TestQueueEditPicksAction verifies the edit picker acts on the chosen
+// command: here, firing the second one ahead of the first.
+func TestQueueEditPicksAction(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `rune.queue.add("bash"); rune.queue.add("flee"); rune.queue.edit()`)
+	engine.ExecutePickerCallback(host.PickerCalls[0].CallbackID, "2")
+	if title := host.PickerCalls[1].Title; title != "flee" {
+		t.Fatalf("action picker title = %q, want the command", title)
+	}
+	engine.ExecutePickerCallback(host.PickerCalls[1].CallbackID, "2") // Fire now
+	if sent := host.DrainNetworkCalls(); !reflect.DeepEqual(sent, []string{"flee"}) {
+		t.Fatalf("sent %v, want [flee]", sent)
+	}
+	assertLua(t, engine, `local list = rune.queue.list(); assert(#list == 1 and list[1] == "bash")`)
+}
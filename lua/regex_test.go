@@ -27,3 +27,29 @@ func TestCompiledRegexObject(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestRegexGsubGmatch verifies replace-all with group references and
+// iterate-all in rune.regex.match's captures shape.
+func TestRegexGsubGmatch(t *testing.T) {
+	engine, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("regex_all", `
+		local out, n = rune.regex.gsub("(\\w+)=(\\d+)", "hp=10 mp=5", "${2}:$1")
+		assert(out == "10:hp 5:mp" and n == 2, out .. " " .. n)
+		out, n = rune.regex.gsub("x", "abc", "y")
+		assert(out == "abc" and n == 0)
+
+		local all = rune.regex.gmatch("(\\w+)=(\\d+)", "hp=10 mp=5")
+		assert(#all == 2)
+		assert(all[1][0] == "hp=10" and all[1][1] == "hp" and all[1][2] == "10")
+		assert(all[2][0] == "mp=5" and all[2][2] == "5")
+		assert(#rune.regex.gmatch("\\d", "none") == 0)
+
+		local re = assert(rune.regex.compile("\\d"))
+		assert(re:gmatch("a1b2")[2][1] == "2")
+		assert(select(2, re:gsub("a1b2", "#")) == 2)
+	`); err != nil {
+		t.Fatal(err)
+	}
+}
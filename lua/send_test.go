@@ -213,3 +213,39 @@ func TestOneArgumentInputHookStillObservesVerbatim(t *testing.T) {
 
 	assertCommands(t, host, []string{"observed:one\ntwo", "one", "two"})
 }
+
+func TestSendHookRewritesAndCancelsEachLine(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("setup", `
+		rune.alias.exact("k", "kill rat;loot")
+		seen = {}
+		rune.hooks.on("send", function(cmd)
+			table.insert(seen, cmd)
+			if cmd == "loot" then
+				return false
+			end
+			-- A line sent from a send handler goes straight out.
+			if cmd == "north" then
+				rune.send_raw("map")
+			end
+			return (cmd:gsub("rat", "goblin"))
+		end)
+	`); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	// Aliases and splitting happen first; the hook sees each line once.
+	engine.OnInput("k;#2 north")
+	assertCommands(t, host, []string{"kill goblin", "map", "north", "map", "north"})
+
+	// Queued sends pass the hook too, and a cancelled line reports false.
+	assertLua(t, engine, `
+		rune.queue.add("rat")
+		rune.queue.next()
+		assert(rune.send_raw("loot") == false)
+		assert(table.concat(seen, ",") == "kill rat,loot,north,north,rat,loot", table.concat(seen, ","))
+	`)
+	assertCommands(t, host, []string{"goblin"})
+}
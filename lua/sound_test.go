@@ -0,0 +1,34 @@
+package lua
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mmcdole/rune/text"
+)
+
+func TestTriggerSoundOption(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	err := engine.DoString("test", `
+		assert(rune.sound("ping.wav") == true)
+		rune.trigger.regex("^\\w+ tells you: ", nil, { sound = "tell.wav" })
+		rune.trigger.starts("BEGIN", nil, { sound = "scroll.wav", span = { to = "^END" } })
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"Bob tells you: hi", "You say hi", "BEGIN", "middle", "END"} {
+		engine.OnOutput(text.NewLine(line))
+	}
+	if want := []string{"ping.wav", "tell.wav", "scroll.wav"}; !slices.Equal(host.SoundCalls, want) {
+		t.Errorf("got sounds %q, want %q", host.SoundCalls, want)
+	}
+
+	err = engine.DoString("test", `rune.trigger.exact("x", nil, { sound = true })`)
+	if err == nil || !strings.Contains(err.Error(), "trigger sound must be a file path") {
+		t.Errorf("expected sound option error, got %v", err)
+	}
+}
@@ -14,7 +14,7 @@ import (
 // session event loop does.
 
 // assertLua runs a Lua assert() block and fails the test on error.
-func assertLua(t *testing.T, engine *Engine, code string) {
+func assertLua(t testing.TB, engine *Engine, code string) {
 	t.Helper()
 	if err := engine.DoString("assert", code); err != nil {
 		t.Fatal(err)
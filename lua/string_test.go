@@ -0,0 +1,53 @@
+package lua
+
+import "testing"
+
+// TestStringHelpers pins rune.string (07_string.lua): plain-text
+// matching, split field rules, and display-width padding for colored
+// and multibyte input, and width-aware truncation.
+func TestStringHelpers(t *testing.T) {
+	engine, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("string_helpers", `
+		local S = rune.string
+		local function join(t) return "[" .. table.concat(t, "|") .. "]" end
+
+		assert(join(S.split("a,,b", ",")) == "[a||b]", join(S.split("a,,b", ",")))
+		assert(join(S.split("a.b.c", ".")) == "[a|b|c]", "sep is plain, not a pattern")
+		assert(join(S.split("a::b", "::")) == "[a|b]", "multi-char sep")
+		assert(join(S.split("", ",")) == "[]" and #S.split("", ",") == 1, "empty input is one empty field")
+		assert(join(S.split("  look   north ")) == "[look|north]", "whitespace split")
+		assert(not pcall(S.split, "a", ""), "empty sep must raise")
+
+		assert(S.trim("  hi there \t\n") == "hi there", "trim")
+		assert(S.trim("") == "", "trim empty")
+
+		assert(S.starts_with("HP: 100", "HP:"), "starts_with")
+		assert(not S.starts_with("HP", "HP:"), "starts_with longer prefix")
+		assert(S.starts_with("%d", "%"), "starts_with is plain")
+		assert(S.ends_with("file.lua", ".lua"), "ends_with")
+		assert(S.ends_with("x", ""), "ends_with empty suffix")
+		assert(not S.ends_with("lua", "file.lua"), "ends_with longer suffix")
+
+		local red = rune.style.red("ok")
+		assert(S.width(red) == 2, "colored width: " .. S.width(red))
+		assert(S.width("héllo") == 5, "multibyte width: " .. S.width("héllo"))
+		assert(S.width("日本") == 4, "wide runes take two cells")
+
+		assert(S.pad(red, 5) == red .. "   ", "colored pad counts visible cells")
+		assert(S.pad("héllo", 7) == "héllo  ", "multibyte pad")
+		assert(S.pad("日本", 6, "right") == "  日本", "wide right pad")
+		assert(S.pad("ab", 7, "center") == "  ab   ", "center pad: [" .. S.pad("ab", 7, "center") .. "]")
+		assert(S.pad("toolong", 3) == "toolong", "never truncates")
+		assert(not pcall(S.pad, "x", 5, "middle"), "bad align must raise")
+
+		assert(S.truncate("look north", 7) == "look...", "truncate")
+		assert(S.truncate("look", 4) == "look", "fits unchanged")
+		assert(S.truncate("héllo wörld", 7) == "héll...", "multibyte truncate: " .. S.truncate("héllo wörld", 7))
+		assert(S.truncate("日本語です", 8) == "日本...", "wide truncate: " .. S.truncate("日本語です", 8))
+		assert(S.truncate("look north", 5, "~") == "look~", "custom tail")
+	`); err != nil {
+		t.Fatal(err)
+	}
+}
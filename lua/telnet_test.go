@@ -0,0 +1,91 @@
+package lua
+
+// Telnet option negotiation (71_telnet.lua): per-option handlers and
+// the will/wont/do/dont bridge to Host.TelnetNegotiate.
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTelnetHandlersAndNegotiate verifies handlers run only for their
+// option, in priority order, and that the negotiation calls reach the
+// host with the command and option.
+func TestTelnetHandlersAndNegotiate(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		seen = {}
+		rune.telnet.on(70, function(command, option)
+			table.insert(seen, "late " .. command .. " " .. option)
+		end, { priority = 60 })
+		rune.telnet.on(70, function(command)
+			table.insert(seen, "early " .. command)
+		end, { name = "mssp", priority = 10 })
+		rune.telnet.on(24, function() table.insert(seen, "ttype") end)
+	`)
+	engine.OnTelnet("will", 70)
+	engine.OnTelnet("dont", 31)
+	assertLua(t, engine, `
+		assert(table.concat(seen, ",") == "early will,late will 70", table.concat(seen, ","))
+		rune.telnet.disable("mssp")
+	`)
+	engine.OnTelnet("wont", 70)
+	assertLua(t, engine, `assert(seen[3] == "late wont 70" and seen[4] == nil)`)
+
+	assertLua(t, engine, `
+		assert(rune.telnet.will(201) == true)
+		rune.telnet.do_(70)
+		rune.telnet["do"](86)
+		rune.telnet.dont(70)
+		rune.telnet.wont(201)
+	`)
+	var got []string
+	for _, n := range host.Negotiations {
+		got = append(got, fmt.Sprintf("%s %d", n.Command, n.Option))
+	}
+	if strings.Join(got, ",") != "will 201,do 70,do 86,dont 70,wont 201" {
+		t.Fatalf("negotiations = %v", got)
+	}
+
+	if err := engine.DoString("test", `rune.telnet.will(256)`); err == nil {
+		t.Fatal("expected an error for an option past 255")
+	}
+}
+
+// TestTelnetNegotiationTimeout verifies an unanswered DO is abandoned
+// when its timer fires - handlers get "timeout" and the hook fires -
+// while an answered one is settled and its timer ignored.
+func TestTelnetNegotiationTimeout(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		seen = {}
+		rune.telnet.on(70, function(command) table.insert(seen, command) end)
+		rune.hooks.on("telnet_timeout", function(option, command)
+			table.insert(seen, "hook " .. option .. " " .. command)
+		end)
+		assert(rune.telnet.timeout(5) == 5)
+		assert(not pcall(rune.telnet.timeout, 0))
+		rune.telnet.do_(86)
+		rune.telnet.do_(70)
+	`)
+	if n := len(host.ScheduledTimers); n != 2 || host.ScheduledTimers[1].Duration != 5*time.Second {
+		t.Fatalf("scheduled timers = %+v, want two of 5s", host.ScheduledTimers)
+	}
+	answered, unanswered := host.ScheduledTimers[0].ID, host.ScheduledTimers[1].ID
+
+	engine.OnTelnet("will", 86)
+	engine.OnTimer(answered) // cancelled: a late wake-up does nothing
+	engine.OnTimer(unanswered)
+	if len(host.Abandoned) != 1 || host.Abandoned[0] != 70 {
+		t.Fatalf("abandoned = %v, want [70]", host.Abandoned)
+	}
+	assertLua(t, engine, `
+		assert(table.concat(seen, ",") == "timeout,hook 70 do", table.concat(seen, ","))
+	`)
+}
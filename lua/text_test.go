@@ -0,0 +1,47 @@
+package lua
+
+import "testing"
+
+// TestTextHelpers pins rune.text (05_style.lua): color specs map to
+// the expected SGR codes, wrap resets, strip round-trips, and unknown
+// specs raise.
+func TestTextHelpers(t *testing.T) {
+	engine, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("text_helpers", `
+		local E = "\027["
+		local cases = {
+			{"red", E .. "31m"},
+			{"RED", E .. "31m"},
+			{"black", E .. "30m"},
+			{"bright_red", E .. "91m"},
+			{"bright_white", E .. "97m"},
+			{"gray", E .. "90m"},
+			{"grey", E .. "90m"},
+			{"reset", E .. "0m"},
+			{"inverse", E .. "7m"},
+			{"bold", E .. "1m"},
+			{208, E .. "38;5;208m"},
+			{"0", E .. "38;5;0m"},
+		}
+		for _, c in ipairs(cases) do
+			local got = rune.text.color(c[1])
+			assert(got == c[2], tostring(c[1]) .. ": got " .. got:gsub("\027", "ESC"))
+		end
+
+		local s = rune.text.wrap("hi", "cyan")
+		assert(s == E .. "36mhi" .. E .. "0m", "wrap: " .. s:gsub("\027", "ESC"))
+		assert(rune.text.strip(s) == "hi", "strip: " .. rune.text.strip(s))
+		assert(rune.text.wrap(42, 9) == E .. "38;5;9m42" .. E .. "0m", "wrap tostrings")
+
+		for _, bad in ipairs({"purple", "bright_gray", 256, -1, 1.5, true}) do
+			local ok, err = pcall(rune.text.color, bad)
+			assert(not ok and err:find("unknown color"), "color accepted " .. tostring(bad))
+			ok = pcall(rune.text.wrap, "x", bad)
+			assert(not ok, "wrap accepted " .. tostring(bad))
+		end
+	`); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,44 @@
+package lua
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mmcdole/rune/ui"
+)
+
+func TestUITheme(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("test", `
+		rune.ui.theme({ selected = "#303030", match = "bright_yellow", pane_border = 240, rule = "═" })
+		rune.ui.theme()
+	`); err != nil {
+		t.Fatalf("script failed: %v", err)
+	}
+	want := []ui.Theme{{Selected: "#303030", Match: "11", PaneBorder: "240", Rule: "═"}, {}}
+	if len(host.Themes) != len(want) {
+		t.Fatalf("got %d themes, want %d: %+v", len(host.Themes), len(want), host.Themes)
+	}
+	for i, w := range want {
+		if host.Themes[i] != w {
+			t.Errorf("theme %d = %+v, want %+v", i, host.Themes[i], w)
+		}
+	}
+
+	for script, msg := range map[string]string{
+		`rune.ui.theme({ selectd = "blue" })`:  `unknown theme key "selectd"`,
+		`rune.ui.theme({ selected = "teal" })`: `selected: unknown color "teal"`,
+		`rune.ui.theme("dark")`:                "expected a table or nil",
+		`rune.ui.theme({ rule = "＝" })`:        "one single-width character",
+	} {
+		err := engine.DoString("test", script)
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%s: error = %v, want %q", script, err, msg)
+		}
+	}
+	if len(host.Themes) != len(want) {
+		t.Error("a rejected theme must not reach the host")
+	}
+}
@@ -2,11 +2,16 @@ package lua
 
 // Trigger semantics (50_triggers.lua): the variant matrix for match
 // types, handles, raw/ANSI matching, capture substitution, and
-// rewrite chaining. Span triggers live in span_test.go; registry
+// rewrite chaining, and one-shot/waiting triggers. Span triggers live in span_test.go; registry
 // semantics (upsert, priority, once) in registry_test.go; the e2e
 // wiring proof in test/e2e/scenarios/output.json.
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	"github.com/mmcdole/rune/text"
+)
 
 func TestTriggerMatchTypes(t *testing.T) {
 	runFeatureCases(t, []featureCase{
@@ -292,3 +297,324 @@ func TestTriggerRewriteChaining(t *testing.T) {
 		},
 	})
 }
+
+// Line mutators change the object in place, so hooks and triggers
+// compose on one line: a color from a trigger survives a later hook's
+// replace of other text, and :gag() stops the chain like false.
+func TestLineMutatorsCompose(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("setup", `
+		rune.trigger.contains('goblin', function(m, ctx) ctx.line:color('goblin', 'yellow') end)
+		rune.hooks.on('output', function(line)
+			line:replace(line:raw():gsub('attacks', 'bites'))
+		end, {priority = 150})
+		rune.hooks.on('output', function(line)
+			rune.send_raw(line:text())
+			if line:text():find('spam') then line:gag() end
+		end, {priority = 160})
+		rune.hooks.on('output', function(line) rune.send_raw('after gag check') end, {priority = 170})
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	got, show := engine.OnOutput(text.NewLine("\x1b[32mA goblin attacks!\x1b[0m"))
+	want := "\x1b[32mA \x1b[33mgoblin\x1b[0m\x1b[32m bites!\x1b[0m"
+	if !show || got != want {
+		t.Errorf("OnOutput = %q, %v; want %q, true", got, show, want)
+	}
+
+	host.SendCalls = nil
+	if got, show := engine.OnOutput(text.NewLine("spam spam")); show || got != "" {
+		t.Errorf("gagged line = %q, %v; want hidden", got, show)
+	}
+	if sent := host.SendCalls; len(sent) != 1 || sent[0] != "spam spam" {
+		t.Errorf("sent = %v; want only the gagging handler to run", sent)
+	}
+
+	assertLua(t, engine, `
+		local l = rune.line.new('a b a')
+		assert(l:color('a', 'red') == l)
+		assert(l:raw() == '\27[31ma\27[0m b \27[31ma\27[0m', l:raw())
+		assert(l:text() == 'a b a')
+		assert(not pcall(l.color, l, 'a', 'nosuchcolor'))
+	`)
+}
+
+// TestLineOrigin verifies line:origin() names where a line came from,
+// and that rewriting or coloring the line keeps it.
+func TestLineOrigin(t *testing.T) {
+	engine, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		origins = {}
+		rune.hooks.on("output", function(line) table.insert(origins, line:origin()) end)
+	`)
+	engine.OnOutput(text.NewLine("You are in a field."))
+	assertLua(t, engine, `
+		assert(origins[1] == "server", tostring(origins[1]))
+		local l = rune.line.new("> look", "echo")
+		assert(l:origin() == "echo")
+		l:replace("> north"):color("north", "red")
+		assert(l:origin() == "echo", "mutators keep the origin")
+		assert(rune.line.new("x"):origin() == "server")
+		assert(rune.line.new("x", "script"):origin() == "script")
+		assert(not pcall(rune.line.new, "x", "prompt"))
+	`)
+}
+
+// TestTriggerOriginKeepsEchoesOut verifies triggers match server lines
+// only by default, so an echoed command cannot fire them, and that the
+// origin option opts a trigger into echoes.
+func TestTriggerOriginKeepsEchoesOut(t *testing.T) {
+	engine, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		server_hits, echo_origins = 0, {}
+		rune.trigger.exact("kill orc", function() server_hits = server_hits + 1 end)
+		rune.trigger.exact("kill orc", function(m, ctx)
+			table.insert(echo_origins, ctx.line:origin())
+		end, { origin = { "server", "echo" } })
+		assert(not pcall(rune.trigger.exact, "x", "y", { origin = "prompt" }))
+		assert(not pcall(rune.trigger.exact, "x", "y", { origin = {} }))
+	`)
+	engine.OnEcho("kill orc")
+	assertLua(t, engine, `
+		assert(server_hits == 0, "an echo fired a server trigger")
+		assert(#echo_origins == 1 and echo_origins[1] == "echo", tostring(echo_origins[1]))
+	`)
+	engine.OnOutput(text.NewLine("kill orc"))
+	assertLua(t, engine, `
+		assert(server_hits == 1)
+		assert(#echo_origins == 2 and echo_origins[2] == "server")
+	`)
+}
+
+// TestTriggerColorsScriptOutput verifies a trigger opted into script
+// output colours rune.echo lines, and that a print from inside the
+// print hook is shown as is rather than dispatched again.
+func TestTriggerColorsScriptOutput(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		rune.trigger.contains("[Alert]", function(m, ctx)
+			ctx.line:color("[Alert]", "red")
+		end, { origin = "script" })
+		rune.hooks.on("print", function(line)
+			if line:text() == "ping" then rune.echo("pong") end
+		end)
+	`)
+	host.DrainPrintCalls()
+	if err := engine.DoString("t", `rune.echo("[Alert] low health"); rune.echo("ping")`); err != nil {
+		t.Fatal(err)
+	}
+	prints := host.DrainPrintCalls()
+	if len(prints) != 3 {
+		t.Fatalf("prints = %q, want 3", prints)
+	}
+	if !strings.Contains(prints[0], "\x1b[") || text.StripANSI(prints[0]) != "[Alert] low health" {
+		t.Errorf("script output not coloured: %q", prints[0])
+	}
+	if prints[1] != "pong" || prints[2] != "ping" {
+		t.Errorf("nested print = %q, want pong then ping", prints[1:])
+	}
+
+	if out, _ := engine.OnOutput(text.NewLine("[Alert] from the server")); strings.Contains(out, "\x1b[") {
+		t.Errorf("a script-only trigger coloured server output: %q", out)
+	}
+}
+
+func TestTriggerOnce(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("setup", `
+		rune.trigger.once("^You are (\\w+)\\.$", "eat %1")
+	`); err != nil {
+		t.Fatal(err)
+	}
+	engine.OnOutput(text.NewLine("You are hungry."))
+	engine.OnOutput(text.NewLine("You are thirsty."))
+	if sent := host.DrainNetworkCalls(); len(sent) != 1 || sent[0] != "eat hungry" {
+		t.Errorf("once trigger sent %v, want [eat hungry]", sent)
+	}
+	assertLua(t, engine, `assert(rune.trigger.count() == 0, "once trigger not removed")`)
+}
+
+// TestTriggerWholeWord verifies whole_word keeps triggers and
+// line:color from matching inside longer words, for every mode that
+// can match mid-line, and that captures still number from the user's
+// pattern.
+func TestTriggerWholeWord(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		rune.trigger.contains("or", "contains", { whole_word = true })
+		rune.trigger.contains("c++", "symbol", { whole_word = true })
+		rune.trigger.starts("go", "starts", { whole_word = true })
+		rune.trigger.regex("(\\w+) ore", "regex %1", { whole_word = true })
+	`)
+	engine.OnOutput(text.NewLine("A sword of gold lies here."))
+	engine.OnOutput(text.NewLine("gold or silver"))
+	engine.OnOutput(text.NewLine("I like c++!"))
+	engine.OnOutput(text.NewLine("iron ores"))
+	engine.OnOutput(text.NewLine("go north, iron ore"))
+	if got := strings.Join(host.DrainNetworkCalls(), "|"); got != "contains|symbol|starts|regex iron" {
+		t.Fatalf("sent %q", got)
+	}
+
+	assertLua(t, engine, `
+		local l = rune.line.new("or sword")
+		l:color("or", "red", { whole_word = true })
+		assert(l:raw() == "\27[31mor\27[0m sword", l:raw())
+		assert(rune.trigger.list()[1].whole_word)
+	`)
+}
+
+// TestTriggerStats verifies each trigger counts its matches - not lines
+// it skipped while disabled - and that /triggers stats lists the most
+// matched first.
+func TestTriggerStats(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		hungry = rune.trigger.contains("hungry", function() end)
+		rune.trigger.starts("You", function() end, { name = "you" })
+		rune.trigger.exact("never", function() end, { name = "idle" })
+		assert(rune.trigger.stats("idle").hits == 0 and rune.trigger.stats("idle").last_hit == nil)
+	`)
+	engine.OnOutput(text.NewLine("You are hungry."))
+	engine.OnOutput(text.NewLine("You are thirsty."))
+	assertLua(t, engine, `rune.trigger.disable("you")`)
+	engine.OnOutput(text.NewLine("You are sleepy."))
+	assertLua(t, engine, `
+		local s = rune.trigger.stats(hungry)
+		assert(s.hits == 1 and type(s.last_hit) == "number", "hungry")
+		assert(rune.trigger.stats("you").hits == 2, "disabled trigger counted")
+		assert(rune.trigger.list()[2].hits == 2)
+		rune.trigger.remove("idle")
+		assert(rune.trigger.stats("idle") == nil and rune.trigger.stats("nope") == nil)
+	`)
+
+	engine.OnInput("/triggers stats")
+	items := host.PickerCalls[0].Items
+	if len(items) != 2 || items[0].Text != "you: You" || !strings.HasPrefix(items[0].Description, "2 hits, ") {
+		t.Fatalf("stats picker items = %+v", items)
+	}
+}
+
+func TestTriggerWait(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("setup", `
+		calls = {}
+		rune.trigger.wait("^You are (\\w+)\\.$", 5, function(m)
+			calls[#calls + 1] = m and m[1] or "timeout"
+		end)
+	`); err != nil {
+		t.Fatal(err)
+	}
+	timers := host.DrainScheduledTimers()
+	if len(timers) != 1 || timers[0].Duration.Seconds() != 5 || timers[0].Repeat {
+		t.Fatalf("expected one 5s one-shot timer, got %+v", timers)
+	}
+
+	// A match fires once and cancels the timeout.
+	engine.OnOutput(text.NewLine("You are hungry."))
+	engine.OnOutput(text.NewLine("You are thirsty."))
+	engine.OnTimer(timers[0].ID)
+	assertLua(t, engine, `
+		assert(#calls == 1 and calls[1] == "hungry", "calls: " .. table.concat(calls, ","))
+		assert(rune.trigger.count() == 0, "wait trigger not removed")
+		assert(rune.timer.count() == 0, "timeout not cancelled")
+	`)
+}
+
+func TestTriggerWaitTimeout(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := engine.DoString("setup", `
+		calls = {}
+		rune.trigger.wait("You are hungry", 5, function(m)
+			calls[#calls + 1] = m == nil and "timeout" or "match"
+		end)
+	`); err != nil {
+		t.Fatal(err)
+	}
+	timers := host.DrainScheduledTimers()
+	if len(timers) != 1 {
+		t.Fatalf("expected one timer, got %+v", timers)
+	}
+
+	engine.OnTimer(timers[0].ID)
+	engine.OnOutput(text.NewLine("You are hungry."))
+	assertLua(t, engine, `
+		assert(#calls == 1 and calls[1] == "timeout", "calls: " .. table.concat(calls, ","))
+		assert(rune.trigger.count() == 0, "timed-out trigger not removed")
+	`)
+
+	// Removing a waiting trigger by hand cancels its timeout silently.
+	if err := engine.DoString("remove", `
+		local h = rune.trigger.wait("never", 5, function() calls[#calls + 1] = "late" end)
+		h:remove()
+		assert(rune.timer.count() == 0, "timeout survived removal")
+	`); err != nil {
+		t.Fatal(err)
+	}
+	for _, tm := range host.DrainScheduledTimers() {
+		engine.OnTimer(tm.ID)
+	}
+	assertLua(t, engine, `assert(#calls == 1, "removed wait fired: " .. table.concat(calls, ","))`)
+
+	for _, bad := range []string{
+		`rune.trigger.wait("x", 0, function() end)`,
+		`rune.trigger.wait("x", 5, "not a function")`,
+		`rune.trigger.wait("(", 5, function() end)`,
+	} {
+		if err := engine.DoString("bad", bad); err == nil {
+			t.Errorf("%s should raise", bad)
+		}
+	}
+}
+
+// BenchmarkTriggerDispatch500 feeds ordinary output through 500
+// triggers of mixed kinds - the load of an automation-heavy character -
+// where almost every line matches nothing.
+func BenchmarkTriggerDispatch500(b *testing.B) {
+	engine, _, cleanup := setupTest(b)
+	defer cleanup()
+
+	assertLua(b, engine, `
+		for i = 1, 250 do
+			rune.trigger.regex("^(\\w+) tells you: spell" .. i .. " (\\w+)$", function() end)
+		end
+		for i = 1, 100 do
+			rune.trigger.starts("You feel effect" .. i, function() end)
+		end
+		for i = 1, 100 do
+			rune.trigger.contains("bleeding" .. i, function() end)
+		end
+		for i = 1, 50 do
+			rune.trigger.regex("^HP: (\\d+)/(\\d+) SP" .. i, function() end)
+		end
+	`)
+	lines := []text.Line{
+		text.NewLine("The goblin hits you hard."),
+		text.NewLine("Bob tells you: hello there"),
+		text.NewLine("You feel a little better."),
+		text.NewLine("A cold wind blows from the north."),
+		text.NewLine("HP: 120/140 MP: 30/50"),
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		engine.OnOutput(lines[i%len(lines)])
+	}
+}
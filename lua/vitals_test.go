@@ -0,0 +1,68 @@
+package lua
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mmcdole/rune/text"
+)
+
+// TestVitalsMapsFieldsAcrossPackages verifies rune.vitals merges
+// fields from several packages, maps them onto the configured gauges,
+// and renders the registered bar with the low-value color.
+func TestVitalsMapsFieldsAcrossPackages(t *testing.T) {
+	engine, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	setup := `
+		rune.vitals.setup({
+			packages = { "Char.Vitals", "Char.MaxStats" },
+			gauges = {
+				{ label = "HP", current = "hp", max = "maxhp" },
+				{ name = "mana", label = "SP", current = "mana", max = "maxmana", color = "cyan" },
+				{ label = "Fat", current = "fatigue", max = 100, low = false },
+			},
+			width = 4,
+		})
+	`
+	if err := engine.DoString("setup", setup); err != nil {
+		t.Fatal(err)
+	}
+
+	// Aardwolf-style: current values and maxima arrive separately, and
+	// values may be strings.
+	engine.OnGMCP("Char.Vitals", `{"hp":"40","mana":100,"fatigue":5}`)
+	engine.OnGMCP("Char.MaxStats", `{"maxhp":200,"maxmana":100}`)
+
+	assertLua(t, engine, `
+		local hp = rune.vitals.get("hp")
+		assert(hp and hp.current == 40 and hp.max == 200, "hp not mapped")
+		assert(rune.vitals.get().mana.max == 100, "gauge name not honored")
+		assert(rune.vitals.get("fatigue").max == 100, "constant max not used")
+		assert(rune.vitals.fields().hp == "40", "raw field not kept")
+	`)
+
+	bar := engine.RenderBars(80, false)["vitals"]
+	got := text.StripANSI(bar.Left)
+	want := "HP █░░░ 40/200  SP ████ 100/100  Fat ░░░░ 5/100"
+	if got != want {
+		t.Errorf("vitals bar = %q, want %q", got, want)
+	}
+	// 40/200 is under the default 25% threshold: HP turns red.
+	if !strings.HasPrefix(bar.Left, "HP \x1b[31m█") {
+		t.Errorf("low HP gauge not red: %q", bar.Left)
+	}
+
+	// Setup again replaces the handlers rather than stacking them.
+	if err := engine.DoString("resetup", `rune.vitals.setup({ gauges = { { current = "hp", max = "maxhp" } } })`); err != nil {
+		t.Fatal(err)
+	}
+	assertLua(t, engine, `
+		assert(rune.vitals.get("hp") == nil, "fields survived setup")
+		local n = 0
+		for _, h in ipairs(rune.gmcp.list()) do
+			if h.name and h.name:find("^vitals:") then n = n + 1 end
+		end
+		assert(n == 1, "expected 1 vitals handler, got " .. n)
+	`)
+}
@@ -5,7 +5,12 @@ package lua
 // MockHost. The e2e wiring proofs live in
 // test/e2e/scenarios/connection.json.
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mmcdole/rune/text"
+)
 
 // TestConnectCommandForms verifies the /connect argument shapes:
 // host+port, host+port+tls scheme, and the single host:port form.
@@ -78,3 +83,132 @@ func TestReconnectUsesStoredAddress(t *testing.T) {
 		t.Errorf("connect calls = %v, want the stored address (scheme intact)", host.ConnectCalls)
 	}
 }
+
+// rune.config.max_line is read on every connect, so a change made in
+// init.lua (or later) reaches the network before the next link.
+func TestConnectPushesMaxLine(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		rune.connect("a:1")
+		rune.config.max_line = 4096
+		rune.connect("b:2")
+		rune.config.max_line = 0
+		rune.connect("c:3")
+	`)
+	if got := host.MaxLines; !reflect.DeepEqual(got, []int{65536, 4096, 0}) {
+		t.Fatalf("MaxLines = %v", got)
+	}
+}
+
+// TestOnConnectSendSteps verifies a login sequence sends its commands
+// a delay apart, holds a wait step until the line arrives, and only
+// runs for the address it names.
+func TestOnConnectSendSteps(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		rune.on_connect_send({
+			"alice",
+			{ wait = "^Password:", send = "pass;word" },
+			"look",
+		}, { address = "mud:1", delay = 2 })
+	`)
+	engine.CallHook("connected", "other:2")
+	if sent := host.DrainNetworkCalls(); len(sent) != 0 {
+		t.Fatalf("sent %v to another address", sent)
+	}
+
+	engine.CallHook("connected", "mud:1")
+	if sent := host.DrainNetworkCalls(); !reflect.DeepEqual(sent, []string{"alice"}) {
+		t.Fatalf("first step sent %v", sent)
+	}
+	if timers := host.DrainScheduledTimers(); len(timers) != 1 || timers[0].Duration.Seconds() != 10 {
+		t.Fatalf("a wait step should listen at once with its timeout, scheduled %+v", timers)
+	}
+	engine.OnOutput(text.NewLine("Password:"))
+	if sent := host.DrainNetworkCalls(); !reflect.DeepEqual(sent, []string{"pass;word"}) {
+		t.Fatalf("wait step sent %v", sent)
+	}
+	timers := host.DrainScheduledTimers()
+	if len(timers) != 1 || timers[0].Duration.Seconds() != 2 {
+		t.Fatalf("scheduled %+v, want one 2s delay", timers)
+	}
+	engine.OnTimer(timers[0].ID)
+	if sent := host.DrainNetworkCalls(); !reflect.DeepEqual(sent, []string{"look"}) {
+		t.Fatalf("last step sent %v", sent)
+	}
+}
+
+// TestWorldLoginStopsAndResumes verifies a world's on_connect steps
+// stop on disconnect, and pick up where they left off after the
+// reload a profile switch on connect causes.
+func TestWorldLoginStopsAndResumes(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `assert(rune.world.add("w", "w:3", { on_connect = { "bob", "hunter2", "look" } }))`)
+	engine.CallHook("connected", "w:3")
+	engine.CallHook("disconnected", "closed", "")
+	for _, tm := range host.DrainScheduledTimers() {
+		engine.OnTimer(tm.ID)
+	}
+	if sent := host.DrainNetworkCalls(); !reflect.DeepEqual(sent, []string{"bob"}) {
+		t.Fatalf("sent %v, want the sequence stopped after bob", sent)
+	}
+	if _, ok := host.SessionStore["login"]; ok {
+		t.Fatal("disconnect left login progress behind")
+	}
+
+	engine.CallHook("connected", "w:3")
+	host.DrainNetworkCalls()
+	host.DrainScheduledTimers()
+	if err := engine.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadCoreScripts(engine); err != nil {
+		t.Fatal(err)
+	}
+	engine.UpdateState(ClientState{Connected: true, Address: "w:3"})
+	engine.CallHook("ready")
+	if sent := host.DrainNetworkCalls(); !reflect.DeepEqual(sent, []string{"hunter2"}) {
+		t.Fatalf("resumed with %v, want the step the reload interrupted", sent)
+	}
+	for _, tm := range host.DrainScheduledTimers() {
+		engine.OnTimer(tm.ID)
+	}
+	if sent := host.DrainNetworkCalls(); !reflect.DeepEqual(sent, []string{"look"}) {
+		t.Fatalf("resumed sequence then sent %v", sent)
+	}
+	if _, ok := host.SessionStore["login"]; ok {
+		t.Fatal("finished sequence left progress behind")
+	}
+}
+
+// TestOnConnectSendSecret verifies a secret step sends the stored
+// value, and that a missing secret stops the sequence.
+func TestOnConnectSendSecret(t *testing.T) {
+	engine, host, cleanup := setupTest(t)
+	defer cleanup()
+
+	assertLua(t, engine, `
+		rune.on_connect_send({ { wait = "^Password:", secret = "pw" }, "look" })
+	`)
+	engine.CallHook("connected", "mud:1")
+	engine.OnOutput(text.NewLine("Password:"))
+	for _, tm := range host.DrainScheduledTimers() {
+		engine.OnTimer(tm.ID)
+	}
+	if sent := host.DrainNetworkCalls(); len(sent) != 0 {
+		t.Fatalf("sent %v without the secret", sent)
+	}
+
+	assertLua(t, engine, `assert(rune.secret.set("pw", "hunter2"))`)
+	engine.CallHook("connected", "mud:1")
+	engine.OnOutput(text.NewLine("Password:"))
+	if sent := host.DrainNetworkCalls(); !reflect.DeepEqual(sent, []string{"hunter2"}) {
+		t.Fatalf("sent %v", sent)
+	}
+}
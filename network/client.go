@@ -6,6 +6,7 @@ import (
 	"compress/zlib"
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -13,6 +14,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 )
 
@@ -31,6 +33,43 @@ type TCPClient struct {
 	// Last known window size, retained across connections so NAWS
 	// can answer immediately on the next connect.
 	width, height int
+
+	// Socket tuning applied to each new connection.
+	tcp TCPOptions
+
+	// Script-defined NEW-ENVIRON variables (see SetEnviron), handed to
+	// each new connection's handshake.
+	environ map[string]string
+
+	// Longest server line passed on whole (see SetMaxLine).
+	maxLine int
+
+	// Whether new connections accept MXP (see SetMXP).
+	mxp bool
+
+	// Raw traffic trace (see trace.go); nil when not tracing. Loaded
+	// on every read and write, so the disabled path is one atomic load.
+	trace atomic.Pointer[trace]
+
+	// Prompt detection for connections without GA/EOR marks (see
+	// prompt.go); nil means PromptAuto.
+	promptDetect atomic.Pointer[PromptDetect]
+}
+
+// TCPOptions tunes the socket of each new connection.
+type TCPOptions struct {
+	// NoDelay disables Nagle's algorithm. A MUD client's writes are
+	// single short commands; coalescing them only adds latency.
+	NoDelay bool
+	// KeepAlive is the TCP keepalive probe period for detecting
+	// dropped connections. Zero or negative disables keepalive.
+	KeepAlive time.Duration
+}
+
+// DefaultTCPOptions returns the socket tuning used unless configured:
+// Nagle off and a 30s keepalive.
+func DefaultTCPOptions() TCPOptions {
+	return TCPOptions{NoDelay: true, KeepAlive: 30 * time.Second}
 }
 
 // outMsg is a queued write. line messages are user commands (CRLF
@@ -62,15 +101,39 @@ type connection struct {
 	// Identity negotiation responder (TTYPE/MTTS, NAWS, CHARSET, MNES)
 	hs *handshake
 
+	// parserMu guards the parser's option table: the read loop parses
+	// under it, and Negotiate initiates from the session goroutine.
+	parserMu sync.Mutex
+
 	// Prompt-mode evidence: set once the first IAC GA or EOR arrives.
 	// Mode keys on received terminators, not negotiation state -
 	// options promise behavior, marks demonstrate it.
 	promptTerminated atomic.Bool
 
+	// Pending stability countdown for PromptTimeout; only the read
+	// loop arms it.
+	promptTimer *time.Timer
+
+	// emitMu is held while the read loop processes a batch and while
+	// the prompt timer checks and emits, so a timed prompt can never
+	// land between a read's lines or after the line that completed it.
+	emitMu sync.Mutex
+
 	gmcpActive atomic.Bool // GMCP negotiated on this connection
 
 	localEcho atomic.Bool
 
+	// binaryOut is TRANSMIT-BINARY for what we send (the server's DO):
+	// input lines go out verbatim instead of NVT-normalized. The
+	// receive direction lives in the output buffer (SetBinary).
+	binaryOut atomic.Bool
+
+	// Traffic counters and ping state (see stats.go). pingSent is the
+	// UnixNano send time of the unanswered ping, 0 when none is out;
+	// latency is the last measured round trip in nanoseconds.
+	bytesIn, bytesOut atomic.Int64
+	pingSent, latency atomic.Int64
+
 	// Buffered queue for outgoing data specific to this connection.
 	// writeLoop is the ONLY goroutine that writes to conn (and the only
 	// one that touches write deadlines); everything else enqueues here.
@@ -86,6 +149,72 @@ func NewTCPClient() *TCPClient {
 	return &TCPClient{
 		// Small buffer - let TCP backpressure handle flow control
 		outputChan: make(chan Output, 256),
+		tcp:        DefaultTCPOptions(),
+		maxLine:    DefaultMaxLine,
+	}
+}
+
+// SetTCPOptions replaces the socket tuning. It applies from the next
+// Connect; the current connection keeps the options it was opened with.
+func (c *TCPClient) SetTCPOptions(opts TCPOptions) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tcp = opts
+}
+
+// SetEnviron replaces the script-defined NEW-ENVIRON variables,
+// reported alongside the built-in MNES ones (and overriding them by
+// name). It applies to the current connection at once, for the
+// server's next SEND, and to every later one.
+func (c *TCPClient) SetEnviron(vars map[string]string) {
+	c.mu.Lock()
+	c.environ = normalizeEnviron(vars)
+	env := c.environ
+	cx := c.current
+	c.mu.Unlock()
+
+	if cx != nil {
+		cx.hs.setEnviron(env)
+	}
+}
+
+// SetMaxLine sets the length, in bytes, past which a server line is
+// split into several (0 never splits). It applies to the current
+// connection at once and to every later one.
+func (c *TCPClient) SetMaxLine(n int) {
+	c.mu.Lock()
+	c.maxLine = n
+	cx := c.current
+	c.mu.Unlock()
+
+	if cx != nil {
+		cx.output.SetMaxLine(n)
+	}
+}
+
+// SetMXP chooses whether MXP (option 91) is accepted when the server
+// offers it. It applies from the next Connect: MXP is noisy, so it is
+// refused like any unsupported option unless scripts turn it on.
+func (c *TCPClient) SetMXP(on bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mxp = on
+}
+
+// applyTCPOptions configures a freshly dialed socket. Connections that
+// are not plain TCP (tests, overrides through other transports) are
+// left untouched.
+func applyTCPOptions(conn net.Conn, opts TCPOptions) {
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return
+	}
+	tcpConn.SetNoDelay(opts.NoDelay)
+	if opts.KeepAlive > 0 {
+		tcpConn.SetKeepAlive(true)
+		tcpConn.SetKeepAlivePeriod(opts.KeepAlive)
+	} else {
+		tcpConn.SetKeepAlive(false)
 	}
 }
 
@@ -148,18 +277,15 @@ func (c *TCPClient) Connect(ctx context.Context, address string) error {
 		c.current.close()
 	}
 
-	// Dial with context to respect app shutdown during connection attempts
-	var d net.Dialer
+	// Dial with context to respect app shutdown during connection attempts.
+	// KeepAlive -1 stops the dialer applying its own default; the
+	// configured period is set by applyTCPOptions.
+	d := net.Dialer{KeepAlive: -1}
 	conn, err := d.DialContext(ctx, "tcp", dialOverride(hostport))
 	if err != nil {
 		return err
 	}
-
-	// Configure TCP KeepAlive (for detecting dropped connections)
-	if tcpConn, ok := conn.(*net.TCPConn); ok {
-		tcpConn.SetKeepAlive(true)
-		tcpConn.SetKeepAlivePeriod(30 * time.Second)
-	}
+	applyTCPOptions(conn, c.tcp)
 
 	if useTLS {
 		host, _, splitErr := net.SplitHostPort(hostport)
@@ -178,17 +304,22 @@ func (c *TCPClient) Connect(ctx context.Context, address string) error {
 	}
 
 	// Create the new connection object
+	compat := defaultCompatibility()
+	if c.mxp {
+		compat.Support(OptMXP)
+	}
 	cx := &connection{
 		conn:      conn,
 		reader:    conn,
 		raw:       conn,
-		hs:        newHandshake(useTLS, c.width, c.height),
-		parser:    NewParser(defaultCompatibility()),
+		hs:        newHandshake(useTLS, c.width, c.height, c.environ),
+		parser:    NewParser(compat),
 		output:    NewOutputBuffer(TelnetModeUnterminated),
 		sendQueue: make(chan outMsg, 4096),
 		done:      make(chan struct{}),
 	}
 	cx.localEcho.Store(true)
+	cx.output.SetMaxLine(c.maxLine)
 
 	// Set as current and start workers
 	c.current = cx
@@ -291,6 +422,113 @@ func (c *TCPClient) SendGMCP(pkg, data string) error {
 	}
 }
 
+// negotiationNames spells the negotiation commands the way
+// OutputNegotiation reports them and Negotiate takes them.
+var negotiationNames = map[byte]string{
+	CmdWILL: "will",
+	CmdWONT: "wont",
+	CmdDO:   "do",
+	CmdDONT: "dont",
+}
+
+// kernelOptions are negotiated by the client itself (MXP behind
+// SetMXP); a script negotiating one would fight it over the option's
+// state.
+var kernelOptions = map[byte]bool{
+	OptBinary:     true,
+	OptEcho:       true,
+	OptSGA:        true,
+	OptEOR:        true,
+	OptTTYPE:      true,
+	OptNAWS:       true,
+	OptCharset:    true,
+	OptNewEnviron: true,
+	OptMCCP2:      true,
+	OptMCCP3:      true,
+	OptGMCP:       true,
+	OptMXP:        true,
+}
+
+// unsupportedOptions change how the byte stream itself is framed or
+// encoded. Nothing in the client can honor them, scripts included,
+// so agreeing to one would leave the connection unreadable.
+var unsupportedOptions = map[byte]bool{
+	OptLinemode:       true,
+	OptAuthentication: true,
+	OptEncrypt:        true,
+	OptEXOPL:          true,
+}
+
+// Negotiate initiates negotiation of an option on the current
+// connection: command is "will", "wont", "do" or "dont". WILL and DO
+// first declare the option supported on that side, so the parser
+// accepts the server's answer instead of refusing it; the declaration
+// lasts for the connection. Options the client negotiates itself are
+// refused, as are WILL and DO for options it cannot honor. Reports
+// false when the option is already in the requested state and nothing
+// was sent.
+func (c *TCPClient) Negotiate(command string, option byte) (bool, error) {
+	if kernelOptions[option] {
+		return false, fmt.Errorf("option %d is negotiated by the client itself", option)
+	}
+	if unsupportedOptions[option] && (command == "will" || command == "do") {
+		return false, fmt.Errorf("option %d is not supported", option)
+	}
+
+	c.mu.Lock()
+	cx := c.current
+	c.mu.Unlock()
+
+	if cx == nil {
+		return false, fmt.Errorf("not connected")
+	}
+
+	cx.parserMu.Lock()
+	var ev *TelnetEvent
+	switch command {
+	case "will":
+		cx.parser.Options.SupportLocal(option)
+		ev = cx.parser.Will(option)
+	case "wont":
+		ev = cx.parser.Wont(option)
+	case "do":
+		cx.parser.Options.SupportRemote(option)
+		ev = cx.parser.Do(option)
+	case "dont":
+		ev = cx.parser.Dont(option)
+	default:
+		cx.parserMu.Unlock()
+		return false, fmt.Errorf("unknown negotiation command %q (want will, wont, do or dont)", command)
+	}
+	cx.parserMu.Unlock()
+
+	if ev == nil {
+		return false, nil
+	}
+	select {
+	case cx.sendQueue <- outMsg{data: ev.Data}:
+		return true, nil
+	default:
+		return false, fmt.Errorf("send buffer full (network stalled?)")
+	}
+}
+
+// AbandonNegotiation gives up on an unanswered WILL or DO sent
+// through Negotiate: the option is treated as unsupported for the rest
+// of the connection. Reports whether a negotiation was outstanding.
+func (c *TCPClient) AbandonNegotiation(option byte) bool {
+	c.mu.Lock()
+	cx := c.current
+	c.mu.Unlock()
+
+	if cx == nil {
+		return false
+	}
+	cx.parserMu.Lock()
+	defer cx.parserMu.Unlock()
+	return cx.parser.Abandon(option)
+}
+
 // Output returns the stable output channel.
 func (c *TCPClient) Output() <-chan Output {
 	return c.outputChan
@@ -319,8 +557,17 @@ func (c *TCPClient) readLoop(cx *connection) {
 	for {
 		n, err := cx.reader.Read(buf)
 
-		if n > 0 && !c.processIncoming(cx, buf[:n]) {
-			return
+		if n > 0 {
+			cx.bytesIn.Add(int64(n))
+			if t := c.trace.Load(); t != nil {
+				t.record('<', buf[:n])
+			}
+			cx.emitMu.Lock()
+			ok := c.processIncoming(cx, buf[:n])
+			cx.emitMu.Unlock()
+			if !ok {
+				return
+			}
 		}
 
 		if err != nil {
@@ -345,8 +592,9 @@ func (c *TCPClient) readLoop(cx *connection) {
 
 			if isCurrent {
 				// Send disconnect notification - this may block briefly, that's OK
+				reason, detail := disconnectReason(err)
 				select {
-				case c.outputChan <- Output{Kind: OutputDisconnect}:
+				case c.outputChan <- Output{Kind: OutputDisconnect, Reason: reason, Payload: detail}:
 				case <-cx.done:
 				}
 				cx.shutdown()
@@ -356,6 +604,22 @@ func (c *TCPClient) readLoop(cx *connection) {
 	}
 }
 
+// disconnectReason classifies the read error that ended a connection,
+// returning the error text for anything but a clean close.
+func disconnectReason(err error) (DisconnectReason, string) {
+	if errors.Is(err, io.EOF) {
+		return DisconnectClosed, ""
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) {
+		return DisconnectReset, err.Error()
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return DisconnectTimeout, err.Error()
+	}
+	return DisconnectError, err.Error()
+}
+
 // processIncoming feeds bytes to the parser and dispatches the
 // resulting events. Returns false when the connection is done and the
 // read loop should exit.
@@ -367,7 +631,11 @@ func (c *TCPClient) processIncoming(cx *connection, data []byte) bool {
 	var mccpRest []byte
 	sawText := false
 
-	for _, ev := range cx.parser.Receive(data) {
+	cx.parserMu.Lock()
+	events := cx.parser.Receive(data)
+	cx.parserMu.Unlock()
+
+	for _, ev := range events {
 		switch ev.Kind {
 		case TelnetEventDataSend:
 			// Negotiation replies go through the send queue so a
@@ -430,6 +698,11 @@ func (c *TCPClient) processIncoming(cx *connection, data []byte) bool {
 					cx.gmcpActive.Store(false)
 				}
 			}
+			select {
+			case c.outputChan <- Output{Kind: OutputNegotiation, Payload: negotiationNames[ev.Command], Option: ev.Option}:
+			case <-cx.done:
+				return false
+			}
 
 		case TelnetEventSubnegotiation:
 			switch ev.Option {
@@ -437,6 +710,10 @@ func (c *TCPClient) processIncoming(cx *connection, data []byte) bool {
 				startMCCP = true
 			case OptGMCP:
 				pkg, payload := splitGMCP(ev.Data)
+				if pkg == pingPackage {
+					// Still forwarded: scripts may watch the reply too.
+					cx.pingAnswered()
+				}
 				if pkg != "" {
 					select {
 					case c.outputChan <- Output{Kind: OutputGMCP, Package: pkg, Payload: payload}:
@@ -452,6 +729,15 @@ func (c *TCPClient) processIncoming(cx *connection, data []byte) bool {
 				}
 			}
 
+		case TelnetEventOverflow:
+			msg := fmt.Sprintf("telnet: discarded a subnegotiation for option %d over %d bytes",
+				ev.Option, cx.parser.maxSubnegotiation())
+			select {
+			case c.outputChan <- Output{Kind: OutputError, Payload: msg}:
+			case <-cx.done:
+				return false
+			}
+
 		case TelnetEventDecompressImmediate:
 			// Raw compressed bytes that followed IAC SB 86 IAC SE in
 			// the same read. Always the final event of a batch.
@@ -466,13 +752,8 @@ func (c *TCPClient) processIncoming(cx *connection, data []byte) bool {
 	// "HP:100> " + IAC GA read - once from the peek, once from the GA
 	// flush - and the session committed the duplicate to scrollback.
 	if sawText && cx.telnetMode() == TelnetModeUnterminated {
-		prompt := cx.output.Prompt(false)
-		if prompt != "" {
-			select {
-			case c.outputChan <- Output{Kind: OutputPrompt, Payload: prompt}:
-			case <-cx.done:
-				return false
-			}
+		if !c.peekPrompt(cx) {
+			return false
 		}
 	}
 
@@ -532,6 +813,32 @@ func splitGMCP(data []byte) (pkg, payload string) {
 	return msg, ""
 }
 
+// nvtLine applies the NVT end-of-line rules (RFC 854) to an outgoing
+// line: a bare CR becomes CR NUL and a bare LF becomes CR LF. Data
+// without either comes back unchanged, without copying.
+func nvtLine(data []byte) []byte {
+	if bytes.IndexByte(data, '\r') < 0 && bytes.IndexByte(data, '\n') < 0 {
+		return data
+	}
+	out := make([]byte, 0, len(data)+4)
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; c {
+		case '\r':
+			if i+1 < len(data) && data[i+1] == '\n' {
+				out = append(out, '\r', '\n')
+				i++
+			} else {
+				out = append(out, '\r', 0)
+			}
+		case '\n':
+			out = append(out, '\r', '\n')
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
 // writeLoop handles outgoing data for a specific connection.
 // It is the sole writer to the socket, so write deadlines cannot race.
 func (c *TCPClient) writeLoop(cx *connection) {
@@ -545,18 +852,27 @@ func (c *TCPClient) writeLoop(cx *connection) {
 				// Clear prompt buffer before sending - in unterminated mode,
 				// the server will reprint the prompt after echoing our input
 				cx.output.InputSent()
+				if !cx.binaryOut.Load() {
+					data = nvtLine(data)
+				}
 				// Line data is text: double IAC bytes so the server
-				// reads them as data, not commands. Raw messages are
-				// protocol frames and pass through untouched.
+				// reads them as data, not commands - in binary mode
+				// too. Raw messages are protocol frames and pass
+				// through untouched.
 				if bytes.IndexByte(data, CmdIAC) >= 0 {
 					data = EscapeIAC(data)
 				}
 				data = append(data, '\r', '\n')
 			}
 
+			if t := c.trace.Load(); t != nil {
+				t.record('>', data)
+			}
+
 			cx.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
-			_, err := cx.conn.Write(data)
+			n, err := cx.conn.Write(data)
 			cx.conn.SetWriteDeadline(time.Time{})
+			cx.bytesOut.Add(int64(n))
 
 			if err != nil {
 				// Write failed - close the connection to trigger readLoop cleanup
@@ -580,7 +896,8 @@ func (cx *connection) shutdown() {
 	})
 }
 
-// applyNegotiation updates local echo state from telnet negotiation.
+// applyNegotiation updates local echo and binary transmission state
+// from telnet negotiation.
 // EOR/SGA negotiation deliberately does not drive prompt mode: WILL is
 // a promise about future marks and DO concerns our own output, so a
 // server could negotiate either and still send unterminated prompts.
@@ -597,6 +914,17 @@ func (cx *connection) applyNegotiation(cmd, opt byte) {
 			// Server won't echo or wants us to echo - enable local echo
 			cx.localEcho.Store(true)
 		}
+	case OptBinary:
+		switch cmd {
+		case CmdWILL:
+			cx.output.SetBinary(true)
+		case CmdWONT:
+			cx.output.SetBinary(false)
+		case CmdDO:
+			cx.binaryOut.Store(true)
+		case CmdDONT:
+			cx.binaryOut.Store(false)
+		}
 	}
 }
 
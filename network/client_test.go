@@ -12,6 +12,8 @@ import (
 	"crypto/x509/pkix"
 	"math/big"
 	"net"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
@@ -264,6 +266,41 @@ func TestIdentityNegotiationLoopback(t *testing.T) {
 	}
 }
 
+// TestReconnectRenegotiatesFromScratch verifies a second connect on
+// the same client negotiates GMCP and NAWS anew rather than carrying
+// option state over from the first link.
+func TestReconnectRenegotiatesFromScratch(t *testing.T) {
+	negotiate := func(done chan struct{}) func(t *testing.T, conn net.Conn) {
+		return func(t *testing.T, conn net.Conn) {
+			defer close(done)
+			conn.Write([]byte{CmdIAC, CmdWILL, OptGMCP})
+			expectBytes(t, conn, []byte{CmdIAC, CmdDO, OptGMCP}, "DO GMCP")
+			conn.Write([]byte{CmdIAC, CmdDO, OptNAWS})
+			expectBytes(t, conn, subnegFrame(OptNAWS, []byte{0, 100, 0, 30}), "NAWS 100x30")
+		}
+	}
+
+	c := NewTCPClient()
+	t.Cleanup(c.Disconnect)
+	c.SetWindowSize(100, 30)
+	for i := 1; i <= 2; i++ {
+		done := make(chan struct{})
+		addr := telnetServer(t, negotiate(done))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := c.Connect(ctx, addr)
+		cancel()
+		if err != nil {
+			t.Fatalf("connect %d: %v", i, err)
+		}
+		nextOutput(t, c, OutputGMCPEnabled, "GMCP enabled notification")
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("connect %d: server negotiation did not complete", i)
+		}
+	}
+}
+
 // --- MCCP2 (Phase 2) ---
 
 // TestMCCP2DecompressAndResume verifies the full MCCP2 lifecycle:
@@ -350,6 +387,41 @@ func TestMCCP2CorruptStreamDisconnects(t *testing.T) {
 	nextOutput(t, c, OutputDisconnect, "disconnect on corrupt stream")
 }
 
+// TestDisconnectReason verifies the disconnect event says whether the
+// server closed the connection cleanly or reset it.
+func TestDisconnectReason(t *testing.T) {
+	cases := []struct {
+		name  string
+		close func(conn net.Conn)
+		want  DisconnectReason
+	}{
+		{"closed", func(conn net.Conn) { conn.Close() }, DisconnectClosed},
+		{"reset", func(conn net.Conn) {
+			// Zero linger makes Close send RST instead of FIN.
+			conn.(*net.TCPConn).SetLinger(0)
+			conn.Close()
+		}, DisconnectReset},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			connected := make(chan struct{})
+			addr := telnetServer(t, func(t *testing.T, conn net.Conn) {
+				<-connected
+				tc.close(conn)
+			})
+			c := connectLoopback(t, addr)
+			close(connected)
+			out := nextOutput(t, c, OutputDisconnect, "disconnect")
+			if out.Reason != tc.want {
+				t.Fatalf("reason = %q (%q), want %q", out.Reason, out.Payload, tc.want)
+			}
+			if tc.want == DisconnectClosed && out.Payload != "" {
+				t.Fatalf("clean close carried error text %q", out.Payload)
+			}
+		})
+	}
+}
+
 // --- GMCP (Phase 3) ---
 
 func TestGMCPLoopback(t *testing.T) {
@@ -385,6 +457,56 @@ func TestGMCPLoopback(t *testing.T) {
 	}
 }
 
+// TestNegotiateArbitraryOption verifies Negotiate declares an option
+// the parser does not know, so the server's answer is accepted and
+// reported as OutputNegotiation, and that options the client
+// negotiates itself or cannot honor are refused.
+func TestNegotiateArbitraryOption(t *testing.T) {
+	const optMSSP = 70
+	addr := telnetServer(t, func(t *testing.T, conn net.Conn) {
+		expectBytes(t, conn, []byte{CmdIAC, CmdDO, optMSSP}, "DO MSSP")
+		conn.Write([]byte{CmdIAC, CmdWILL, optMSSP})
+
+		buf := make([]byte, 1)
+		conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		conn.Read(buf)
+	})
+
+	c := connectLoopback(t, addr)
+	if sent, err := c.Negotiate("dont", optMSSP); sent || err != nil {
+		t.Fatalf("DONT on a disabled option = (%v, %v), want nothing sent", sent, err)
+	}
+	if _, err := c.Negotiate("maybe", optMSSP); err == nil {
+		t.Fatal("expected an error for an unknown command")
+	}
+	if sent, err := c.Negotiate("do", optMSSP); !sent || err != nil {
+		t.Fatalf("Negotiate(do) = (%v, %v), want sent", sent, err)
+	}
+
+	out := nextOutput(t, c, OutputNegotiation, "WILL MSSP")
+	if out.Payload != "will" || out.Option != optMSSP {
+		t.Fatalf("negotiation = (%q, %d), want (will, %d)", out.Payload, out.Option, optMSSP)
+	}
+	if sent, err := c.Negotiate("dont", optMSSP); !sent || err != nil {
+		t.Fatalf("DONT on the enabled option = (%v, %v), want sent", sent, err)
+	}
+
+	for _, tc := range []struct {
+		command string
+		option  byte
+	}{
+		{"will", OptNAWS}, {"dont", OptGMCP}, {"do", OptMCCP3}, {"do", OptMXP},
+		{"will", OptEncrypt}, {"do", OptLinemode},
+	} {
+		if sent, err := c.Negotiate(tc.command, tc.option); sent || err == nil {
+			t.Errorf("Negotiate(%s, %d) = (%v, %v), want refused", tc.command, tc.option, sent, err)
+		}
+	}
+	if sent, err := c.Negotiate("dont", OptEncrypt); sent || err != nil {
+		t.Errorf("DONT on an unsupported option = (%v, %v), want nothing sent", sent, err)
+	}
+}
+
 // TestGMCPSendRequiresNegotiation verifies sends fail cleanly before
 // the server has negotiated GMCP.
 func TestGMCPSendRequiresNegotiation(t *testing.T) {
@@ -400,6 +522,44 @@ func TestGMCPSendRequiresNegotiation(t *testing.T) {
 	}
 }
 
+// TestMXPAcceptedOnlyWhenEnabled verifies MXP is refused by default
+// and accepted, and reported, once SetMXP turns it on.
+func TestMXPAcceptedOnlyWhenEnabled(t *testing.T) {
+	for _, on := range []bool{false, true} {
+		reply := []byte{CmdIAC, CmdWONT, OptMXP}
+		if on {
+			reply = []byte{CmdIAC, CmdWILL, OptMXP}
+		}
+		done := make(chan struct{})
+		addr := telnetServer(t, func(t *testing.T, conn net.Conn) {
+			defer close(done)
+			conn.Write([]byte{CmdIAC, CmdDO, OptMXP})
+			expectBytes(t, conn, reply, "MXP reply")
+		})
+
+		c := NewTCPClient()
+		t.Cleanup(c.Disconnect)
+		c.SetMXP(on)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := c.Connect(ctx, addr); err != nil {
+			cancel()
+			t.Fatalf("connect: %v", err)
+		}
+		cancel()
+		if on {
+			out := nextOutput(t, c, OutputNegotiation, "DO MXP")
+			if out.Payload != "do" || out.Option != OptMXP {
+				t.Fatalf("negotiation = (%q, %d), want (do, %d)", out.Payload, out.Option, OptMXP)
+			}
+		}
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("server never saw the MXP reply (enabled=%v)", on)
+		}
+	}
+}
+
 // --- prompt emission ---
 
 // TestSendEscapesIAC verifies outgoing line data doubles IAC bytes so
@@ -424,6 +584,41 @@ func TestSendEscapesIAC(t *testing.T) {
 	}
 }
 
+// TestBinaryModeSendsVerbatim verifies NVT lines normalize a bare CR
+// to CR NUL, while after TRANSMIT-BINARY is negotiated both ways lines
+// pass through verbatim - IAC doubling still applies - and received
+// bare CRs stay inside the line.
+func TestBinaryModeSendsVerbatim(t *testing.T) {
+	done := make(chan struct{})
+	addr := telnetServer(t, func(t *testing.T, conn net.Conn) {
+		defer close(done)
+		expectBytes(t, conn, []byte{'a', '\r', 0, 'b', '\r', '\n'}, "NVT line")
+
+		conn.Write([]byte{CmdIAC, CmdWILL, OptBinary, CmdIAC, CmdDO, OptBinary})
+		expectBytes(t, conn, []byte{CmdIAC, CmdDO, OptBinary}, "DO BINARY")
+		conn.Write([]byte("x\ry\r\n"))
+
+		expectBytes(t, conn, []byte{'a', '\r', 0xFF, 0xFF, 'b', '\r', '\n'}, "binary line")
+	})
+
+	c := connectLoopback(t, addr)
+	if err := c.Send("a\rb"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if out := nextOutput(t, c, OutputLine, "binary line from server"); out.Payload != "x\ry" {
+		t.Fatalf("line = %q, want the bare CR kept", out.Payload)
+	}
+	if err := c.Send("a\r\xffb"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server never saw the binary line")
+	}
+}
+
 // TestUnterminatedPromptSurvivesPromptlessNegotiation pins the
 // prompt-mode policy: negotiating SGA or EOR is not evidence of prompt
 // termination (WILL is a promise, DO concerns our output), so a server
@@ -549,3 +744,120 @@ func TestPromptEmittedOncePerGABatch(t *testing.T) {
 		}
 	}
 }
+
+// TestPromptDetectModes drives each prompt-detection strategy against
+// a GA-less server whose line is split across reads: only "auto"
+// mistakes the fragment for a prompt, "ga" shows no prompt at all,
+// and "regex" and "timeout" show only the real one.
+func TestPromptDetectModes(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		detect PromptDetect
+		want   []string
+	}{
+		{"auto", PromptDetect{}, []string{"You are hun", "<100hp> "}},
+		{"ga", PromptDetect{Mode: PromptGA}, nil},
+		{"regex", PromptDetect{Mode: PromptRegex, Match: func(s string) bool {
+			return strings.HasPrefix(s, "<") && strings.HasSuffix(s, "> ")
+		}}, []string{"<100hp> "}},
+		{"timeout", PromptDetect{Mode: PromptTimeout, Stable: 100 * time.Millisecond}, []string{"<100hp> "}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			addr := telnetServer(t, func(t *testing.T, conn net.Conn) {
+				time.Sleep(50 * time.Millisecond) // let the test set the mode
+				conn.Write([]byte("You are hun"))
+				time.Sleep(30 * time.Millisecond)
+				conn.Write([]byte("gry.\r\n<100hp> "))
+				time.Sleep(300 * time.Millisecond)
+				conn.Write([]byte("\r\nmarker\r\n"))
+
+				buf := make([]byte, 1)
+				conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+				conn.Read(buf)
+			})
+
+			c := connectLoopback(t, addr)
+			c.SetPromptDetect(tc.detect)
+			var prompts []string
+			deadline := time.After(5 * time.Second)
+			for {
+				select {
+				case out := <-c.Output():
+					switch out.Kind {
+					case OutputPrompt:
+						prompts = append(prompts, out.Payload)
+					case OutputLine:
+						if out.Payload == "marker" {
+							if !slices.Equal(prompts, tc.want) {
+								t.Fatalf("prompts = %q, want %q", prompts, tc.want)
+							}
+							return
+						}
+					case OutputDisconnect:
+						t.Fatal("connection dropped while waiting for marker")
+					}
+				case <-deadline:
+					t.Fatal("timed out waiting for marker line")
+				}
+			}
+		})
+	}
+}
+
+func TestTCPOptionsApplyFromNextConnect(t *testing.T) {
+	c := NewTCPClient()
+	if c.tcp != DefaultTCPOptions() {
+		t.Fatalf("new client options = %+v, want defaults %+v", c.tcp, DefaultTCPOptions())
+	}
+	if !c.tcp.NoDelay || c.tcp.KeepAlive != 30*time.Second {
+		t.Errorf("defaults = %+v, want NoDelay and 30s keepalive", c.tcp)
+	}
+
+	opts := TCPOptions{NoDelay: false, KeepAlive: 0}
+	c.SetTCPOptions(opts)
+	addr := telnetServer(t, func(t *testing.T, conn net.Conn) {
+		conn.Write([]byte("hello\n"))
+	})
+	if err := c.Connect(context.Background(), addr); err != nil {
+		t.Fatalf("Connect with keepalive disabled: %v", err)
+	}
+	defer c.Disconnect()
+	if out := nextOutput(t, c, OutputLine, "greeting"); out.Payload != "hello" {
+		t.Errorf("greeting = %q, want hello", out.Payload)
+	}
+}
+
+// TestPingMeasuresLatency verifies a GMCP Core.Ping reply sets the
+// connection's latency and that the reply still reaches the session.
+func TestPingMeasuresLatency(t *testing.T) {
+	addr := telnetServer(t, func(t *testing.T, conn net.Conn) {
+		conn.Write([]byte{CmdIAC, CmdWILL, OptGMCP})
+		expectBytes(t, conn, subnegFrame(OptGMCP, []byte("Core.Ping")), "client Core.Ping")
+		time.Sleep(20 * time.Millisecond)
+		conn.Write(subnegFrame(OptGMCP, []byte("Core.Ping")))
+		buf := make([]byte, 1)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		conn.Read(buf)
+	})
+
+	c := connectLoopback(t, addr)
+	nextOutput(t, c, OutputGMCPEnabled, "GMCP enabled notification")
+	if got := c.Stats().Latency; got != 0 {
+		t.Fatalf("Latency before any reply = %v, want 0", got)
+	}
+	if err := c.Ping(); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+
+	out := nextOutput(t, c, OutputGMCP, "Core.Ping reply")
+	if out.Package != "Core.Ping" {
+		t.Fatalf("GMCP message = %q, want Core.Ping", out.Package)
+	}
+	st := c.Stats()
+	if st.Latency < 20*time.Millisecond {
+		t.Errorf("Latency = %v, want >= 20ms", st.Latency)
+	}
+	if st.BytesIn == 0 || st.BytesOut == 0 {
+		t.Errorf("byte counters = in %d, out %d, want both > 0", st.BytesIn, st.BytesOut)
+	}
+}
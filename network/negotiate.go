@@ -2,6 +2,7 @@ package network
 
 import (
 	"bytes"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -60,10 +61,11 @@ type handshake struct {
 	height     int
 	ttypeIndex int
 	nawsActive bool
+	environ    map[string]string // script-defined variables, by upper-case name
 }
 
-func newHandshake(tls bool, width, height int) *handshake {
-	return &handshake{tls: tls, width: width, height: height}
+func newHandshake(tls bool, width, height int, environ map[string]string) *handshake {
+	return &handshake{tls: tls, width: width, height: height, environ: environ}
 }
 
 // mtts computes the MTTS bitvector. Honesty rule: every bit here must
@@ -116,6 +118,27 @@ func (h *handshake) onSubnegotiation(opt byte, data []byte) [][]byte {
 	return nil
 }
 
+// setEnviron replaces the script-defined NEW-ENVIRON variables. The
+// map is shared read-only with TCPClient; callers never mutate it.
+func (h *handshake) setEnviron(environ map[string]string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.environ = environ
+}
+
+// normalizeEnviron copies vars keyed by upper-case name, the way MNES
+// servers ask for them and environValueLocked looks them up.
+func normalizeEnviron(vars map[string]string) map[string]string {
+	if len(vars) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(vars))
+	for name, value := range vars {
+		out[strings.ToUpper(name)] = value
+	}
+	return out
+}
+
 // setWindowSize records the new size and returns a NAWS frame to send
 // if the option is currently active, nil otherwise.
 func (h *handshake) setWindowSize(width, height int) []byte {
@@ -200,10 +223,15 @@ func charsetReply(data []byte) [][]byte {
 	return [][]byte{subnegFrame(OptCharset, []byte{charsetRejected})}
 }
 
-// environValueLocked returns the MNES value for a variable, or
-// ok=false for variables we do not define. Caller holds h.mu.
+// environValueLocked returns the value for a variable - a script-set
+// one first, then the MNES built-ins - or ok=false for variables we do
+// not define. Caller holds h.mu.
 func (h *handshake) environValueLocked(name string) (string, bool) {
-	switch strings.ToUpper(name) {
+	name = strings.ToUpper(name)
+	if value, ok := h.environ[name]; ok {
+		return value, true
+	}
+	switch name {
 	case "CLIENT_NAME":
 		return clientName(), true
 	case "CLIENT_VERSION":
@@ -225,7 +253,8 @@ var mnesVars = []string{"CLIENT_NAME", "CLIENT_VERSION", "CHARSET", "MTTS", "TER
 // environReplyLocked answers NEW-ENVIRON SEND per RFC 1572 / MNES:
 // requested variables get VALUE entries (echoing the VAR/USERVAR type
 // they were requested with); unknown ones are echoed without a VALUE;
-// an empty SEND gets every variable we define. Caller holds h.mu.
+// an empty SEND gets every variable we define, the built-ins first and
+// then script-set ones by name. Caller holds h.mu.
 func (h *handshake) environReplyLocked(data []byte) [][]byte {
 	if len(data) < 1 || data[0] != environSEND {
 		return nil
@@ -247,6 +276,16 @@ func (h *handshake) environReplyLocked(data []byte) [][]byte {
 		for _, name := range mnesVars {
 			appendVar(environVAR, name)
 		}
+		var extra []string
+		for name := range h.environ {
+			if !slices.Contains(mnesVars, name) {
+				extra = append(extra, name)
+			}
+		}
+		slices.Sort(extra)
+		for _, name := range extra {
+			appendVar(environVAR, name)
+		}
 	} else {
 		for _, req := range requested {
 			appendVar(req.kind, req.name)
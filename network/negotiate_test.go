@@ -19,7 +19,7 @@ func ttypeIS(answer string) []byte {
 // TestTTYPECycle verifies the MTTS terminal-type cycle: client name,
 // then terminal, then "MTTS <bits>" repeated on further SENDs.
 func TestTTYPECycle(t *testing.T) {
-	h := newHandshake(false, 80, 24)
+	h := newHandshake(false, 80, 24, nil)
 	send := []byte{CmdSEND}
 
 	want := [][]byte{
@@ -39,7 +39,7 @@ func TestTTYPECycle(t *testing.T) {
 // TestTTYPEReportsTLSBit verifies the MTTS SSL bit is set only on TLS
 // connections - the bits must reflect real capabilities.
 func TestTTYPEReportsTLSBit(t *testing.T) {
-	h := newHandshake(true, 80, 24)
+	h := newHandshake(true, 80, 24, nil)
 	send := []byte{CmdSEND}
 
 	h.onSubnegotiation(OptTTYPE, send) // name
@@ -54,7 +54,7 @@ func TestTTYPEReportsTLSBit(t *testing.T) {
 
 // TestTTYPEIgnoresNonSend verifies garbage subnegotiations produce no reply.
 func TestTTYPEIgnoresNonSend(t *testing.T) {
-	h := newHandshake(false, 80, 24)
+	h := newHandshake(false, 80, 24, nil)
 	if frames := h.onSubnegotiation(OptTTYPE, []byte{CmdIS, 'x'}); frames != nil {
 		t.Fatalf("expected no reply to TTYPE IS, got %v", frames)
 	}
@@ -66,7 +66,7 @@ func TestTTYPEIgnoresNonSend(t *testing.T) {
 // TestNAWSReportsSizeOnDO verifies DO NAWS gets an immediate
 // big-endian size report and resizes re-send while active.
 func TestNAWSReportsSizeOnDO(t *testing.T) {
-	h := newHandshake(false, 120, 40)
+	h := newHandshake(false, 120, 40, nil)
 
 	frames := h.onNegotiation(CmdDO, OptNAWS)
 	want := subnegFrame(OptNAWS, []byte{0, 120, 0, 40})
@@ -91,7 +91,7 @@ func TestNAWSReportsSizeOnDO(t *testing.T) {
 // TestNAWSEscapesIACWidth verifies a size byte of 255 is IAC-escaped
 // inside the subnegotiation (RFC 1073 + telnet framing).
 func TestNAWSEscapesIACWidth(t *testing.T) {
-	h := newHandshake(false, 255, 24)
+	h := newHandshake(false, 255, 24, nil)
 	frames := h.onNegotiation(CmdDO, OptNAWS)
 
 	// Payload [0, 255, 0, 24] -> 255 doubled on the wire
@@ -104,7 +104,7 @@ func TestNAWSEscapesIACWidth(t *testing.T) {
 // TestNAWSDefaultsWhenSizeUnknown verifies a connection that has never
 // seen a resize reports 80x24 instead of 0x0.
 func TestNAWSDefaultsWhenSizeUnknown(t *testing.T) {
-	h := newHandshake(false, 0, 0)
+	h := newHandshake(false, 0, 0, nil)
 	frames := h.onNegotiation(CmdDO, OptNAWS)
 	want := subnegFrame(OptNAWS, []byte{0, 80, 0, 24})
 	if len(frames) != 1 || !bytes.Equal(frames[0], want) {
@@ -115,7 +115,7 @@ func TestNAWSDefaultsWhenSizeUnknown(t *testing.T) {
 // TestCharsetAcceptsUTF8 verifies REQUEST handling: UTF-8 accepted
 // (case-insensitively), otherwise rejected, TTABLE prefix skipped.
 func TestCharsetAcceptsUTF8(t *testing.T) {
-	h := newHandshake(false, 80, 24)
+	h := newHandshake(false, 80, 24, nil)
 	accepted := subnegFrame(OptCharset, append([]byte{charsetAccepted}, []byte("UTF-8")...))
 	rejected := subnegFrame(OptCharset, []byte{charsetRejected})
 
@@ -146,7 +146,7 @@ func TestCharsetAcceptsUTF8(t *testing.T) {
 // TestEnvironSendAllVariables verifies an empty SEND returns every
 // MNES variable with values.
 func TestEnvironSendAllVariables(t *testing.T) {
-	h := newHandshake(false, 80, 24)
+	h := newHandshake(false, 80, 24, nil)
 	frames := h.onSubnegotiation(OptNewEnviron, []byte{environSEND})
 	if len(frames) != 1 {
 		t.Fatalf("expected one IS reply, got %v", frames)
@@ -176,7 +176,7 @@ func TestEnvironSendAllVariables(t *testing.T) {
 // echoed with the type they were requested as, and unknown variables
 // come back without a VALUE.
 func TestEnvironSendSpecificVariables(t *testing.T) {
-	h := newHandshake(false, 80, 24)
+	h := newHandshake(false, 80, 24, nil)
 
 	request := []byte{environSEND, environVAR}
 	request = append(request, []byte("CLIENT_NAME")...)
@@ -208,6 +208,48 @@ func TestEnvironSendSpecificVariables(t *testing.T) {
 	}
 }
 
+// TestEnvironScriptVariables verifies script-set variables answer a
+// specific request, override a built-in of the same name, and follow
+// the built-ins (sorted) in a SEND-all reply.
+func TestEnvironScriptVariables(t *testing.T) {
+	h := newHandshake(false, 80, 24, nil)
+	h.setEnviron(normalizeEnviron(map[string]string{
+		"ipaddress":   "203.0.113.7",
+		"CLIENT_NAME": "MUDLET",
+		"CLIENT":      "rune",
+	}))
+
+	var payload []byte
+	appendVar := func(kind byte, name, value string) {
+		payload = append(payload, kind)
+		payload = append(payload, []byte(name)...)
+		payload = append(payload, environVALUE)
+		payload = append(payload, []byte(value)...)
+	}
+
+	request := []byte{environSEND, environUSERVAR}
+	request = append(request, []byte("IPADDRESS")...)
+	frames := h.onSubnegotiation(OptNewEnviron, request)
+	payload = []byte{environIS}
+	appendVar(environUSERVAR, "IPADDRESS", "203.0.113.7")
+	if want := subnegFrame(OptNewEnviron, payload); len(frames) != 1 || !bytes.Equal(frames[0], want) {
+		t.Fatalf("SEND IPADDRESS reply:\n got %v\nwant %v", frames, want)
+	}
+
+	frames = h.onSubnegotiation(OptNewEnviron, []byte{environSEND})
+	payload = []byte{environIS}
+	appendVar(environVAR, "CLIENT_NAME", "MUDLET")
+	appendVar(environVAR, "CLIENT_VERSION", version.Number)
+	appendVar(environVAR, "CHARSET", "UTF-8")
+	appendVar(environVAR, "MTTS", strconv.Itoa(mttsPlain))
+	appendVar(environVAR, "TERMINAL_TYPE", "XTERM")
+	appendVar(environVAR, "CLIENT", "rune")
+	appendVar(environVAR, "IPADDRESS", "203.0.113.7")
+	if want := subnegFrame(OptNewEnviron, payload); len(frames) != 1 || !bytes.Equal(frames[0], want) {
+		t.Fatalf("SEND-all reply:\n got %v\nwant %v", frames, want)
+	}
+}
+
 // TestEnvironEscapeQuoting pins the ESC policy: ESC quotes the next
 // byte unconditionally, as a lexical rule. Inside a name the quoted
 // marker byte becomes data; before any VAR/USERVAR the quoted byte has
@@ -253,8 +295,8 @@ func TestEnvironEscapeQuoting(t *testing.T) {
 // dissolves entirely into quoted garbage is treated as an empty SEND:
 // the reply is the full identity set, which is public by design.
 func TestEnvironGarbageRequestGetsSendAll(t *testing.T) {
-	all := newHandshake(false, 80, 24).onSubnegotiation(OptNewEnviron, []byte{environSEND})
-	got := newHandshake(false, 80, 24).onSubnegotiation(OptNewEnviron,
+	all := newHandshake(false, 80, 24, nil).onSubnegotiation(OptNewEnviron, []byte{environSEND})
+	got := newHandshake(false, 80, 24, nil).onSubnegotiation(OptNewEnviron,
 		[]byte{environSEND, environESC, environVAR, 'X'})
 	if len(all) != 1 || len(got) != 1 || !bytes.Equal(got[0], all[0]) {
 		t.Fatalf("garbage SEND reply:\n got %v\nwant the send-all reply %v", got, all)
@@ -265,7 +307,7 @@ func TestEnvironGarbageRequestGetsSendAll(t *testing.T) {
 // name carrying a quoted marker byte is echoed with the byte re-quoted,
 // so the reply stays inside the RFC 1572 grammar.
 func TestEnvironReplyRequotesEchoedNames(t *testing.T) {
-	h := newHandshake(false, 80, 24)
+	h := newHandshake(false, 80, 24, nil)
 	request := []byte{environSEND, environVAR, 'B', environESC, environVAR, 'G'}
 
 	frames := h.onSubnegotiation(OptNewEnviron, request)
@@ -6,14 +6,29 @@ type OutputKind int
 const (
 	OutputLine        OutputKind = iota // Complete line from server
 	OutputPrompt                        // Partial line/prompt (GA/EOR terminated or unterminated)
-	OutputDisconnect                    // Connection closed
+	OutputDisconnect                    // Connection closed (Reason says why, Payload holds the error text)
 	OutputGMCP                          // GMCP message (Package + raw JSON Payload)
 	OutputGMCPEnabled                   // GMCP negotiation completed for this connection
+	OutputError                         // Protocol problem worth reporting (Payload is the message)
+	OutputNegotiation                   // Option negotiation received (Payload is "will"/"wont"/"do"/"dont")
 )
 
 // Output represents data emitted by the network layer.
 type Output struct {
 	Kind    OutputKind
-	Payload string // Line content, or raw JSON for GMCP (may be empty)
-	Package string // GMCP package name (e.g. "Char.Vitals"); GMCP only
+	Payload string           // Line content, or raw JSON for GMCP (may be empty)
+	Package string           // GMCP package name (e.g. "Char.Vitals"); GMCP only
+	Option  byte             // Telnet option code; negotiation only
+	Reason  DisconnectReason // Why the connection ended; disconnect only
 }
+
+// DisconnectReason classifies why a connection ended.
+type DisconnectReason string
+
+const (
+	DisconnectUser    DisconnectReason = "user"    // Closed on request (/disconnect); set by the session
+	DisconnectClosed  DisconnectReason = "closed"  // Server closed the connection cleanly
+	DisconnectReset   DisconnectReason = "reset"   // Connection reset or aborted by the peer
+	DisconnectTimeout DisconnectReason = "timeout" // Peer stopped answering (keepalive or deadline)
+	DisconnectError   DisconnectReason = "error"   // Any other read error
+)
@@ -0,0 +1,104 @@
+package network
+
+import (
+	"time"
+)
+
+// PromptMode selects how a connection that has not sent GA/EOR marks
+// finds its prompts. Once a mark arrives the connection switches to
+// terminated prompts (markPromptTerminated) whatever the mode.
+type PromptMode int
+
+const (
+	// PromptAuto shows any text left over after a read as the prompt:
+	// the classic heuristic, which also catches a line cut by a read
+	// boundary.
+	PromptAuto PromptMode = iota
+	// PromptGA trusts only GA/EOR marks; leftover text waits for its
+	// newline.
+	PromptGA
+	// PromptRegex shows leftover text only when Match accepts it.
+	PromptRegex
+	// PromptTimeout shows leftover text once it has sat unchanged for
+	// Stable - a line cut by a read boundary is completed first.
+	PromptTimeout
+)
+
+// PromptDetect configures prompt detection for connections without
+// GA/EOR marks (see SetPromptDetect).
+type PromptDetect struct {
+	Mode PromptMode
+	// Match decides, for PromptRegex, whether pending text is a
+	// prompt. It receives the raw text, escape sequences and all.
+	Match func(pending string) bool
+	// Stable is how long pending text must stay unchanged before
+	// PromptTimeout shows it.
+	Stable time.Duration
+}
+
+// SetPromptDetect replaces the prompt-detection strategy. It applies
+// to the current connection from its next read, and to every later
+// one.
+func (c *TCPClient) SetPromptDetect(d PromptDetect) {
+	c.promptDetect.Store(&d)
+}
+
+// peekPrompt surfaces pending unterminated text as a prompt according
+// to the detection strategy. Runs once per read batch, on the read
+// loop. Returns false when the connection is done.
+func (c *TCPClient) peekPrompt(cx *connection) bool {
+	var d PromptDetect
+	if p := c.promptDetect.Load(); p != nil {
+		d = *p
+	}
+
+	switch d.Mode {
+	case PromptGA:
+		return true
+	case PromptTimeout:
+		cx.armPromptTimer(c, d.Stable)
+		return true
+	}
+
+	prompt := cx.output.Prompt(false)
+	if prompt == "" || (d.Mode == PromptRegex && (d.Match == nil || !d.Match(prompt))) {
+		return true
+	}
+	select {
+	case c.outputChan <- Output{Kind: OutputPrompt, Payload: prompt}:
+		return true
+	case <-cx.done:
+		return false
+	}
+}
+
+// armPromptTimer (re)starts the stability countdown for the pending
+// text. The timer fires on its own goroutine; a read, an input line,
+// or a GA/EOR mark in the meantime makes it stale. It runs under
+// emitMu, so the read loop cannot emit while it checks and sends.
+func (cx *connection) armPromptTimer(c *TCPClient, stable time.Duration) {
+	if cx.promptTimer != nil {
+		cx.promptTimer.Stop()
+	}
+	gen := cx.output.Generation()
+	cx.promptTimer = time.AfterFunc(stable, func() {
+		cx.emitMu.Lock()
+		defer cx.emitMu.Unlock()
+		select {
+		case <-cx.done:
+			return
+		default:
+		}
+		if cx.telnetMode() != TelnetModeUnterminated {
+			return
+		}
+		prompt := cx.output.PromptAt(gen)
+		if prompt == "" {
+			return
+		}
+		select {
+		case c.outputChan <- Output{Kind: OutputPrompt, Payload: prompt}:
+		case <-cx.done:
+		}
+	})
+}
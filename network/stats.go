@@ -0,0 +1,77 @@
+package network
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Stats describes the current connection's traffic and measured
+// latency. The zero value is what a disconnected client reports.
+type Stats struct {
+	// BytesIn counts bytes handed to the telnet parser (after MCCP2
+	// decompression, the same stream a trace records); BytesOut counts
+	// bytes written to the socket.
+	BytesIn  int64
+	BytesOut int64
+	// Latency is the round trip of the last answered ping; zero until
+	// the server has answered one (see Ping).
+	Latency time.Duration
+}
+
+// pingPackage is the GMCP ping from the Core package: the client sends
+// it (optionally carrying its average latency in milliseconds) and the
+// server answers with an empty Core.Ping.
+const pingPackage = "Core.Ping"
+
+// Stats returns the traffic counters and latency of the current
+// connection.
+func (c *TCPClient) Stats() Stats {
+	c.mu.Lock()
+	cx := c.current
+	c.mu.Unlock()
+	if cx == nil {
+		return Stats{}
+	}
+	return Stats{
+		BytesIn:  cx.bytesIn.Load(),
+		BytesOut: cx.bytesOut.Load(),
+		Latency:  time.Duration(cx.latency.Load()),
+	}
+}
+
+// Ping sends a GMCP Core.Ping; the server's reply sets Stats.Latency.
+// GMCP is out of band, so a ping never touches the text stream and
+// cannot disturb prompt detection. While a ping is unanswered further
+// pings are still sent but the clock keeps running from the first, so
+// a late reply measures the whole stall rather than the last interval.
+// Fails like SendGMCP when disconnected or without GMCP.
+func (c *TCPClient) Ping() error {
+	c.mu.Lock()
+	cx := c.current
+	c.mu.Unlock()
+	if cx == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	var payload string
+	if ms := time.Duration(cx.latency.Load()).Milliseconds(); ms > 0 {
+		payload = strconv.FormatInt(ms, 10)
+	}
+	sent := cx.pingSent.CompareAndSwap(0, time.Now().UnixNano())
+	if err := c.SendGMCP(pingPackage, payload); err != nil {
+		if sent {
+			cx.pingSent.Store(0)
+		}
+		return err
+	}
+	return nil
+}
+
+// pingAnswered records the round trip of the outstanding ping, if
+// any. Called from readLoop for every Core.Ping the server sends.
+func (cx *connection) pingAnswered() {
+	if sent := cx.pingSent.Swap(0); sent != 0 {
+		cx.latency.Store(time.Now().UnixNano() - sent)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"strings"
 	"sync"
+	"unicode/utf8"
 )
 
 // Telnet command codes.
@@ -16,6 +17,13 @@ const (
 	CmdDO   byte = 253 // Do use option
 	CmdDONT byte = 254 // Don't use option
 	CmdNOP  byte = 241 // No operation
+	CmdDM   byte = 242 // Data mark (the Synch signal)
+	CmdBRK  byte = 243 // Break
+	CmdIP   byte = 244 // Interrupt process
+	CmdAO   byte = 245 // Abort output
+	CmdAYT  byte = 246 // Are you there
+	CmdEC   byte = 247 // Erase character
+	CmdEL   byte = 248 // Erase line
 	CmdSB   byte = 250 // Subnegotiation begin
 	CmdSE   byte = 240 // Subnegotiation end
 	CmdIS   byte = 0   // Subnegotiation IS
@@ -70,6 +78,7 @@ const (
 	OptMSSP           byte = 70
 	OptMCCP2          byte = 86
 	OptMCCP3          byte = 87
+	OptMXP            byte = 91
 	OptZMP            byte = 93
 	OptEXOPL          byte = 255
 	OptGMCP           byte = 201
@@ -85,6 +94,9 @@ const (
 	TelnetEventNegotiation
 	TelnetEventSubnegotiation
 	TelnetEventDecompressImmediate
+	// A subnegotiation outgrew Parser.MaxSubnegotiation and was
+	// discarded; Option is the subnegotiated option.
+	TelnetEventOverflow
 )
 
 // TelnetEvent carries parser output.
@@ -201,23 +213,90 @@ func (t *CompatibilityTable) ResetStates() {
 	}
 }
 
+// DefaultMaxSubnegotiation bounds a single subnegotiation, framing
+// included. Real GMCP payloads (room maps, inventories) stay well
+// under it.
+const DefaultMaxSubnegotiation = 1 << 20
+
 // Parser is a telnet protocol parser.
 type Parser struct {
 	Options CompatibilityTable
-	buffer  []byte
+	// MaxSubnegotiation caps how many bytes of one subnegotiation are
+	// buffered while waiting for IAC SE. A longer one is discarded
+	// with a TelnetEventOverflow and parsing resumes as normal text,
+	// so a server that never terminates IAC SB cannot grow memory
+	// without bound. It bounds the whole carry-over buffer too: only an
+	// incomplete command is kept between reads, and every other command
+	// is at most three bytes. Zero means DefaultMaxSubnegotiation.
+	MaxSubnegotiation int
+	buffer            []byte
+	// awaiting marks negotiations we started (Will, Do) that the
+	// server has not answered yet, as awaitLocal/awaitRemote bits per
+	// option. The answer is an acknowledgement: it is reported but not
+	// replied to, so the exchange cannot loop.
+	awaiting [256]byte
 }
 
+// Bits in Parser.awaiting.
+const (
+	awaitLocal  byte = 1      // sent WILL, no DO or DONT yet
+	awaitRemote byte = 1 << 1 // sent DO, no WILL or WONT yet
+)
+
 func NewParser(table CompatibilityTable) *Parser {
 	return &Parser{
-		Options: table,
-		buffer:  make([]byte, 0, 128),
+		Options:           table,
+		MaxSubnegotiation: DefaultMaxSubnegotiation,
+		buffer:            make([]byte, 0, 128),
+	}
+}
+
+func (p *Parser) maxSubnegotiation() int {
+	if p.MaxSubnegotiation <= 0 {
+		return DefaultMaxSubnegotiation
 	}
+	return p.MaxSubnegotiation
 }
 
 func NewParserDefault() *Parser {
 	return NewParser(NewCompatibilityTable())
 }
 
+// Reset returns the parser to the state of a fresh connection: a
+// partial command left in the buffer is dropped and every option's
+// negotiated state is cleared, keeping the support flags. A stale
+// RemoteState would make the parser treat the next server's WILL as a
+// repeat and skip the reply. TCPClient.Connect builds a new parser per
+// connection; anything that reuses one across links must call Reset.
+func (p *Parser) Reset() {
+	p.buffer = p.buffer[:0]
+	p.Options.ResetStates()
+	p.awaiting = [256]byte{}
+}
+
+// Abandon gives up on an unanswered Will or Do for option: the option
+// is treated as unsupported on this connection (an optimistic WILL is
+// withdrawn locally, without telling the server) and the support
+// declaration is dropped, so a late answer is refused rather than
+// half-accepted. Reports whether anything was awaiting an answer.
+func (p *Parser) Abandon(option byte) bool {
+	waiting := p.awaiting[option]
+	if waiting == 0 {
+		return false
+	}
+	p.awaiting[option] = 0
+	entry := p.Options.Get(option)
+	if waiting&awaitLocal != 0 {
+		entry.Local = false
+		entry.LocalState = false
+	}
+	if waiting&awaitRemote != 0 {
+		entry.Remote = false
+	}
+	p.Options.Set(option, entry)
+	return true
+}
+
 func (p *Parser) Receive(data []byte) []TelnetEvent {
 	p.buffer = append(p.buffer, data...)
 	return p.process()
@@ -283,6 +362,7 @@ func (p *Parser) Will(option byte) *TelnetEvent {
 	if entry.Local && !entry.LocalState {
 		entry.LocalState = true
 		p.Options.Set(option, entry)
+		p.awaiting[option] |= awaitLocal
 		ev := p.Negotiate(CmdWILL, option)
 		return &ev
 	}
@@ -305,6 +385,7 @@ func (p *Parser) Wont(option byte) *TelnetEvent {
 func (p *Parser) Do(option byte) *TelnetEvent {
 	entry := p.Options.Get(option)
 	if entry.Remote && !entry.RemoteState {
+		p.awaiting[option] |= awaitRemote
 		ev := p.Negotiate(CmdDO, option)
 		return &ev
 	}
@@ -346,12 +427,14 @@ const (
 	evIAC
 	evNeg
 	evSub
+	evOverflow
 )
 
 type parsedSlice struct {
 	kind      eventType
 	buf       []byte
 	remaining []byte
+	option    byte // evOverflow: the discarded subnegotiation's option
 }
 
 func (p *Parser) process() []TelnetEvent {
@@ -386,6 +469,8 @@ func (p *Parser) process() []TelnetEvent {
 			out = append(out, p.processCommand(ev.buf)...)
 		case evSub:
 			out = append(out, p.processSub(ev.buf, ev.remaining)...)
+		case evOverflow:
+			out = append(out, TelnetEvent{Kind: TelnetEventOverflow, Option: ev.option})
 		}
 		i++
 	}
@@ -408,9 +493,17 @@ func (p *Parser) extract() []parsedSlice {
 	var subOpt byte
 	buf := p.buffer
 	p.buffer = nil // Take ownership of buffer
+	limit := p.maxSubnegotiation()
 
 	for i := 0; i < len(buf); i++ {
 		val := buf[i]
+		if (state == stateSubOpt || state == stateSubIAC) && i+1-cmdBegin > limit {
+			// Unterminated or oversized: drop what was gathered and
+			// resync, reading this byte as normal data.
+			res = append(res, parsedSlice{kind: evOverflow, option: subOpt})
+			state = stateNormal
+			cmdBegin = i
+		}
 		switch state {
 		case stateNormal:
 			if val == CmdIAC {
@@ -428,7 +521,9 @@ func (p *Parser) extract() []parsedSlice {
 				res = append(res, parsedSlice{kind: evNone, buf: buf[cmdBegin:i]})
 				state = stateNormal
 				cmdBegin = i + 1
-			case CmdGA, CmdEOR, CmdNOP:
+			case CmdGA, CmdEOR, CmdNOP, CmdDM, CmdBRK, CmdIP, CmdAO, CmdAYT, CmdEC, CmdEL:
+				// Single-byte commands: complete as soon as the code
+				// arrives, so the next byte is never taken for an option.
 				res = append(res, parsedSlice{kind: evIAC, buf: buf[cmdBegin : i+1]})
 				state = stateNormal
 				cmdBegin = i + 1
@@ -496,6 +591,14 @@ func (p *Parser) processCommand(buf []byte) []TelnetEvent {
 		if cmd != CmdSE {
 			if len(buf) == 2 {
 				out = append(out, TelnetEvent{Kind: TelnetEventIAC, Command: cmd})
+				if cmd == CmdAYT {
+					// Answer in band but out of the text stream: a
+					// visible reply would reach the MUD as a command.
+					out = append(out, TelnetEvent{
+						Kind: TelnetEventDataSend,
+						Data: []byte{CmdIAC, CmdNOP},
+					})
+				}
 			} else if len(buf) == 3 {
 				out = append(out, p.processNegotiation(buf[1], buf[2])...)
 			}
@@ -548,6 +651,26 @@ func (p *Parser) processNegotiation(command, opt byte) []TelnetEvent {
 	entry := p.Options.Get(opt)
 	var responses []TelnetEvent
 
+	// The answer to a WILL or DO we sent: record it and report it,
+	// without the reply an unsolicited request would get.
+	switch {
+	case command == CmdWILL && p.awaiting[opt]&awaitRemote != 0,
+		command == CmdDO && p.awaiting[opt]&awaitLocal != 0:
+		if command == CmdWILL {
+			p.awaiting[opt] &^= awaitRemote
+			entry.RemoteState = true
+		} else {
+			p.awaiting[opt] &^= awaitLocal
+			entry.LocalState = true
+		}
+		p.Options.Set(opt, entry)
+		return []TelnetEvent{{Kind: TelnetEventNegotiation, Command: command, Option: opt}}
+	case command == CmdWONT:
+		p.awaiting[opt] &^= awaitRemote
+	case command == CmdDONT:
+		p.awaiting[opt] &^= awaitLocal
+	}
+
 	switch command {
 	case CmdWILL:
 		if entry.Remote && !entry.RemoteState {
@@ -642,22 +765,46 @@ const (
 // next read decides whether it pairs with a following \n, and a delimiter
 // pair split across reads is completed via pendingPartner instead of
 // emitting a spurious empty line.
+// In binary mode (TRANSMIT-BINARY from the server) the stream is 8-bit
+// clean and none of that normalization applies: \n alone ends a line,
+// and \r passes through verbatim except as the first half of \r\n.
+// A line longer than maxLine bytes is cut into maxLine-byte lines, so
+// a server sending megabytes without a newline cannot grow one pending
+// line (or one scrollback row) without bound.
 // The mutex is required: the read loop parses into the buffer while
 // the write loop calls InputSent to drop a pending prompt.
 type OutputBuffer struct {
 	mu      sync.Mutex
 	buffer  bytes.Buffer
 	mode    TelnetMode
+	binary  bool
+	maxLine int
 	newData bool
 	// pendingPartner is the second byte of a delimiter pair whose first
 	// byte was already consumed at the end of a previous read ('\r' after
 	// an emitted \n, or '\n' after a held \r dropped by Prompt/InputSent).
 	// If the next read starts with it, it is swallowed.
 	pendingPartner byte
+	// gen counts changes to the pending text, so a delayed prompt
+	// peek can tell whether anything moved since it was armed.
+	gen uint64
 }
 
+// DefaultMaxLine is the longest line, in bytes, an OutputBuffer passes
+// on whole unless SetMaxLine says otherwise. Real MUD lines are a few
+// hundred bytes; art and maps a few thousand.
+const DefaultMaxLine = 64 << 10
+
 func NewOutputBuffer(mode TelnetMode) *OutputBuffer {
-	return &OutputBuffer{mode: mode}
+	return &OutputBuffer{mode: mode, maxLine: DefaultMaxLine}
+}
+
+// SetMaxLine sets the line length, in bytes, past which lines are
+// split; 0 or less never splits.
+func (o *OutputBuffer) SetMaxLine(n int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.maxLine = max(n, 0)
 }
 
 func (o *OutputBuffer) SetMode(mode TelnetMode) {
@@ -666,9 +813,21 @@ func (o *OutputBuffer) SetMode(mode TelnetMode) {
 	o.mode = mode
 }
 
+// SetBinary switches binary line splitting on or off for the data
+// received from now on.
+func (o *OutputBuffer) SetBinary(on bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.binary = on
+	o.pendingPartner = 0
+}
+
 func (o *OutputBuffer) Receive(data []byte) []string {
 	o.mu.Lock()
 	defer o.mu.Unlock()
+	if o.binary {
+		return o.receiveBinary(data)
+	}
 	if o.pendingPartner != 0 {
 		if len(data) > 0 && data[0] == o.pendingPartner {
 			data = data[1:]
@@ -680,6 +839,7 @@ func (o *OutputBuffer) Receive(data []byte) []string {
 	}
 	o.buffer.Write(data)
 	o.newData = true
+	o.gen++
 	buf := o.buffer.Bytes()
 	var lines []string
 	last := 0
@@ -687,7 +847,7 @@ func (o *OutputBuffer) Receive(data []byte) []string {
 	for i := 0; i < len(buf); i++ {
 		switch buf[i] {
 		case '\n':
-			lines = append(lines, string(buf[last:i]))
+			lines = o.appendLine(lines, buf[last:i])
 			if i+1 < len(buf) && buf[i+1] == '\r' {
 				i++ // \n\r pair
 			} else if i+1 == len(buf) {
@@ -701,7 +861,7 @@ func (o *OutputBuffer) Receive(data []byte) []string {
 				// next read shows its neighbor
 				break
 			}
-			lines = append(lines, string(buf[last:i]))
+			lines = o.appendLine(lines, buf[last:i])
 			if buf[i+1] == '\n' {
 				i++ // \r\n pair
 			}
@@ -709,21 +869,102 @@ func (o *OutputBuffer) Receive(data []byte) []string {
 		}
 	}
 
+	return o.keepRemaining(lines, buf, last)
+}
+
+// receiveBinary splits on \n alone; a \r directly before it belongs
+// to the terminator. Called with o.mu held.
+func (o *OutputBuffer) receiveBinary(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	o.buffer.Write(data)
+	o.newData = true
+	o.gen++
+	buf := o.buffer.Bytes()
+	var lines []string
+	last := 0
+	for i, c := range buf {
+		if c != '\n' {
+			continue
+		}
+		end := i
+		if end > last && buf[end-1] == '\r' {
+			end--
+		}
+		lines = o.appendLine(lines, buf[last:end])
+		last = i + 1
+	}
+	return o.keepRemaining(lines, buf, last)
+}
+
+// appendLine appends line to lines, cut into maxLine-byte pieces when
+// it is longer. Called with o.mu held.
+func (o *OutputBuffer) appendLine(lines []string, line []byte) []string {
+	for o.maxLine > 0 && len(line) > o.maxLine {
+		n := cutPoint(line, o.maxLine)
+		lines = append(lines, string(line[:n]))
+		line = line[n:]
+	}
+	return append(lines, string(line))
+}
+
+// keepRemaining leaves buf[last:], the unterminated text, in the
+// buffer. Past maxLine, all but the last maxLine bytes or less are
+// cut off and emitted as lines of their own; the rest waits for its
+// newline as usual, so a held \r stays held. Called with o.mu held.
+func (o *OutputBuffer) keepRemaining(lines []string, buf []byte, last int) []string {
+	for o.maxLine > 0 && len(buf)-last > o.maxLine {
+		n := cutPoint(buf[last:], o.maxLine)
+		lines = append(lines, string(buf[last:last+n]))
+		last += n
+	}
 	if last > 0 {
 		remaining := buf[last:]
 		o.buffer.Reset()
 		o.buffer.Write(remaining)
 	}
-
 	return lines
 }
 
+// cutPoint is where to split line at most limit bytes in: at limit,
+// or a little before it when that would cut a UTF-8 character apart.
+func cutPoint(line []byte, limit int) int {
+	for n := limit; n > 0 && n > limit-utf8.UTFMax; n-- {
+		if utf8.RuneStart(line[n]) {
+			return n
+		}
+	}
+	return limit
+}
+
 // Prompt returns any pending (unterminated) text. Clears buffer if consume is true.
 // A held trailing \r (a possible half of \r\n) is never part of the prompt
 // text; on consume its \n partner, if it arrives next, is still swallowed.
+// Likewise a multibyte UTF-8 character cut off by the end of a read is
+// left out rather than shown as replacement characters; on consume it
+// stays buffered so the next read completes it.
 func (o *OutputBuffer) Prompt(consume bool) string {
 	o.mu.Lock()
 	defer o.mu.Unlock()
+	return o.prompt(consume)
+}
+
+// PromptAt is Prompt(false) for pending text still at generation gen
+// (see Generation), and "" once anything has added to or consumed it.
+// The check and the read are one step, so an input line or a read in
+// between cannot slip past the check.
+func (o *OutputBuffer) PromptAt(gen uint64) string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.gen != gen {
+		return ""
+	}
+	return o.prompt(false)
+}
+
+// prompt implements Prompt. Called with o.mu held.
+func (o *OutputBuffer) prompt(consume bool) string {
 	if o.buffer.Len() == 0 {
 		return ""
 	}
@@ -732,9 +973,16 @@ func (o *OutputBuffer) Prompt(consume bool) string {
 	if heldCR {
 		text = text[:len(text)-1]
 	}
+	partial := ""
+	if !heldCR {
+		n := completeUTF8(text)
+		text, partial = text[:n], text[n:]
+	}
 	if consume {
 		o.buffer.Reset()
+		o.buffer.WriteString(partial)
 		o.newData = false
+		o.gen++
 		if heldCR {
 			o.pendingPartner = '\n'
 		}
@@ -742,6 +990,37 @@ func (o *OutputBuffer) Prompt(consume bool) string {
 	return text
 }
 
+// completeUTF8 returns the length of s without a trailing multibyte
+// UTF-8 sequence that has started but not finished - the bytes a read
+// boundary split off. Invalid bytes are not trimmed; they decode to
+// replacement characters as usual.
+func completeUTF8(s string) int {
+	// A sequence is at most 4 bytes, so only the last 3 can be an
+	// unfinished one.
+	for i := len(s) - 1; i >= 0 && i >= len(s)-3; i-- {
+		c := s[i]
+		if c < 0x80 {
+			return len(s) // ASCII: nothing pending
+		}
+		if c >= 0xC0 { // lead byte
+			if !utf8.FullRuneInString(s[i:]) {
+				return i
+			}
+			return len(s)
+		}
+		// continuation byte: keep looking for its lead
+	}
+	return len(s)
+}
+
+// Generation returns a counter that moves whenever the pending text
+// is added to or consumed.
+func (o *OutputBuffer) Generation() uint64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.gen
+}
+
 func (o *OutputBuffer) HasNewData() bool {
 	o.mu.Lock()
 	defer o.mu.Unlock()
@@ -759,6 +1038,7 @@ func (o *OutputBuffer) InputSent() {
 		}
 		o.buffer.Reset()
 		o.newData = false
+		o.gen++
 	}
 }
 
@@ -773,8 +1053,10 @@ func (o *OutputBuffer) Clear() {
 	defer o.mu.Unlock()
 	o.buffer.Reset()
 	o.mode = TelnetModeUnterminated
+	o.binary = false
 	o.newData = false
 	o.pendingPartner = 0
+	o.gen++
 }
 
 // defaultCompatibility advertises ONLY options the client actually
@@ -785,6 +1067,7 @@ func (o *OutputBuffer) Clear() {
 // options here only together with their implementation.
 func defaultCompatibility() CompatibilityTable {
 	t := NewCompatibilityTable()
+	t.Support(OptBinary)          // TRANSMIT-BINARY: 8-bit clean data, either direction (client.go)
 	t.SupportRemote(OptEcho)      // WILL/WONT ECHO toggles local echo (client.go); we never echo to the server
 	t.Support(OptSGA)             // Suppress Go Ahead: full-duplex handshake
 	t.SupportRemote(OptEOR)       // End of Record: servers mark prompts; we never send them
@@ -2,8 +2,11 @@ package network
 
 import (
 	"bytes"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
+	"unicode/utf8"
 )
 
 // Helper to build a subnegotiation sequence
@@ -53,6 +56,77 @@ func TestParserHandlesSplitDoNegotiation(t *testing.T) {
 	}
 }
 
+// Ensure Reset returns a parser to a fresh connection's state: a
+// partial command is dropped and an option negotiated on the old link
+// is answered again instead of being treated as a repeat.
+func TestParserResetRenegotiates(t *testing.T) {
+	parser := NewParser(DefaultCompatibility())
+	willGMCP := []byte{CmdIAC, CmdWILL, OptGMCP}
+	doGMCP := []byte{CmdIAC, CmdDO, OptGMCP}
+
+	reply := func(events []TelnetEvent) []byte {
+		for _, ev := range events {
+			if ev.Kind == TelnetEventDataSend {
+				return ev.Data
+			}
+		}
+		return nil
+	}
+
+	if got := reply(parser.Receive(willGMCP)); !bytes.Equal(got, doGMCP) {
+		t.Fatalf("first WILL GMCP: reply %v, want %v", got, doGMCP)
+	}
+	if got := reply(parser.Receive(willGMCP)); got != nil {
+		t.Fatalf("repeated WILL GMCP on the same link: reply %v, want none", got)
+	}
+
+	parser.Receive([]byte{CmdIAC, CmdSB}) // a frame cut off by the drop
+	parser.Reset()
+
+	if entry := parser.Options.Get(OptGMCP); entry.RemoteState || !entry.Remote {
+		t.Fatalf("GMCP after Reset = %+v, want supported and not negotiated", entry)
+	}
+	events := parser.Receive(append([]byte("hi"), willGMCP...))
+	if got := reply(events); !bytes.Equal(got, doGMCP) {
+		t.Fatalf("WILL GMCP after Reset: reply %v, want %v", got, doGMCP)
+	}
+	if events[0].Kind != TelnetEventDataReceive || string(events[0].Data) != "hi" {
+		t.Fatalf("first event after Reset = %+v, want the text (stale SB dropped)", events[0])
+	}
+}
+
+// TestParserAnswerAndAbandon verifies the server's answer to a DO we
+// sent is reported without a reply, and that an abandoned WILL stops
+// counting as enabled and refuses a late answer.
+func TestParserAnswerAndAbandon(t *testing.T) {
+	parser := NewParserDefault()
+	const opt = 200
+
+	parser.Options.SupportRemote(opt)
+	parser.Do(opt)
+	events := parser.Receive([]byte{CmdIAC, CmdWILL, opt})
+	if len(events) != 1 || events[0].Kind != TelnetEventNegotiation || events[0].Command != CmdWILL {
+		t.Fatalf("WILL answering our DO = %+v, want one Negotiation and no reply", events)
+	}
+	if !parser.Options.Get(opt).RemoteState {
+		t.Fatal("answered DO did not enable the option remotely")
+	}
+	if parser.Abandon(opt) {
+		t.Fatal("Abandon reported an answered negotiation as outstanding")
+	}
+
+	parser.Options.SupportLocal(opt)
+	parser.Will(opt)
+	if !parser.Abandon(opt) {
+		t.Fatal("Abandon did not find the unanswered WILL")
+	}
+	if entry := parser.Options.Get(opt); entry.Local || entry.LocalState {
+		t.Fatalf("after Abandon = %+v, want unsupported locally", entry)
+	}
+	events = parser.Receive([]byte{CmdIAC, CmdDO, opt})
+	assertReply(t, events, []byte{CmdIAC, CmdWONT, opt}, "late DO", opt)
+}
+
 // Ensure the default table refuses options the client does not
 // implement. Accepting MCCP3 without a compressor corrupts the
 // stream; accepting an option we cannot subnegotiate leaves the
@@ -73,14 +147,14 @@ func TestDefaultCompatibilityRefusesUnimplementedOptions(t *testing.T) {
 // in the direction each is actually used.
 func TestDefaultCompatibilityAcceptsImplementedOptions(t *testing.T) {
 	// Server-offered options: server sends WILL, we must DO.
-	for _, opt := range []byte{OptMCCP2, OptGMCP, OptEcho, OptSGA, OptEOR} {
+	for _, opt := range []byte{OptBinary, OptMCCP2, OptGMCP, OptEcho, OptSGA, OptEOR} {
 		parser := NewParser(DefaultCompatibility())
 		events := parser.Receive([]byte{CmdIAC, CmdWILL, opt})
 		assertReply(t, events, []byte{CmdIAC, CmdDO, opt}, "WILL", opt)
 	}
 
 	// Client-answered options: server sends DO, we must WILL.
-	for _, opt := range []byte{OptTTYPE, OptNAWS, OptCharset, OptNewEnviron} {
+	for _, opt := range []byte{OptBinary, OptTTYPE, OptNAWS, OptCharset, OptNewEnviron} {
 		parser := NewParser(DefaultCompatibility())
 		events := parser.Receive([]byte{CmdIAC, CmdDO, opt})
 		assertReply(t, events, []byte{CmdIAC, CmdWILL, opt}, "DO", opt)
@@ -132,11 +206,11 @@ func TestParser(t *testing.T) {
 		}
 	}
 
-	// Test IAC DO GMCP - since we already called Will(GMCP), LocalState is true,
-	// so receiving DO should produce no events (already enabled)
+	// Test IAC DO GMCP - the answer to our Will(GMCP): reported as a
+	// negotiation, but not replied to (no second WILL)
 	events = parser.Receive([]byte{CmdIAC, CmdDO, OptGMCP})
-	if len(events) != 0 {
-		t.Errorf("Expected 0 events for DO GMCP (already enabled), got %d", len(events))
+	if len(events) != 1 || events[0].Kind != TelnetEventNegotiation {
+		t.Errorf("Expected 1 Negotiation event for DO GMCP (our WILL answered), got %+v", events)
 	}
 
 	// Test IAC DO for unsupported option (200) + data
@@ -494,6 +568,24 @@ func TestOutputBufferNewlineVariants(t *testing.T) {
 	}
 }
 
+// In binary mode only \n ends a line: bare \r and \n\r pass through
+// verbatim, and a \r\n split across reads still ends one line.
+func TestOutputBufferBinary(t *testing.T) {
+	ob := NewOutputBuffer(TelnetModeUnterminated)
+	ob.SetBinary(true)
+	lines := ob.Receive([]byte("a\rb\r\nc\n\rd\nx\r"))
+	lines = append(lines, ob.Receive([]byte("\ny\xff\n"))...)
+	want := []string{"a\rb", "c", "\rd", "x", "y\xff"}
+	if !slices.Equal(lines, want) {
+		t.Fatalf("lines = %q, want %q", lines, want)
+	}
+
+	ob.SetBinary(false)
+	if lines := ob.Receive([]byte("p\rq\n")); !slices.Equal(lines, []string{"p", "q"}) {
+		t.Fatalf("after binary off, lines = %q, want bare CR splitting back", lines)
+	}
+}
+
 // Delimiter pairs split across reads must neither delay lines nor emit
 // spurious empty ones, whatever the TCP fragmentation.
 func TestOutputBufferFragmentation(t *testing.T) {
@@ -509,6 +601,10 @@ func TestOutputBufferFragmentation(t *testing.T) {
 		{"HeldCRIsNotPrompt", []string{"abc\r"}, nil, "abc"},
 		{"CRLFBytewise", []string{"a", "\r", "\n", "b", "\r", "\n"}, []string{"a", "b"}, ""},
 		{"LFCRBytewise", []string{"a", "\n", "\r", "b", "\n"}, []string{"a", "b"}, ""},
+		{"RuneSplitInLine", []string{"caf\xc3", "\xa9 ok\n"}, []string{"café ok"}, ""},
+		{"RuneSplitHeldFromPrompt", []string{"Сила: \xd0"}, nil, "Сила: "},
+		{"RuneSplitTwiceHeld", []string{"日\xe6", "\x9c"}, nil, "日"},
+		{"InvalidByteNotHeld", []string{"abc\xff"}, nil, "abc\xff"},
 	}
 
 	for _, tt := range tests {
@@ -549,6 +645,39 @@ func TestOutputBufferPromptConsumeHeldCR(t *testing.T) {
 	}
 }
 
+// Consuming a prompt that ends mid-character keeps the partial bytes,
+// so the next read completes the character instead of both halves
+// turning into replacement characters.
+func TestOutputBufferPromptConsumeSplitRune(t *testing.T) {
+	ob := NewOutputBuffer(TelnetModeTerminatedPrompt)
+	ob.Receive([]byte("日本\xe8\xaa"))
+	if got := ob.Prompt(true); got != "日本" {
+		t.Fatalf("Prompt: expected %q, got %q", "日本", got)
+	}
+	ob.Receive([]byte("\x9e> "))
+	if got := ob.Prompt(true); got != "語> " {
+		t.Fatalf("Prompt after completion: expected %q, got %q", "語> ", got)
+	}
+}
+
+// A four-byte emoji split across reads never reaches a prompt peek as
+// a replacement character: the peek withholds it until it completes.
+func TestOutputBufferPromptPeekSplitEmoji(t *testing.T) {
+	emoji := []byte("🐉")
+	ob := NewOutputBuffer(TelnetModeUnterminated)
+	for split := 1; split < len(emoji); split++ {
+		ob.Clear()
+		ob.Receive(append([]byte("Dragon "), emoji[:split]...))
+		if got := ob.Prompt(false); strings.ContainsRune(got, utf8.RuneError) || got != "Dragon " {
+			t.Fatalf("split at %d: peek = %q, want %q", split, got, "Dragon ")
+		}
+		ob.Receive(emoji[split:])
+		if got := ob.Prompt(false); got != "Dragon 🐉" {
+			t.Fatalf("split at %d: completed peek = %q, want %q", split, got, "Dragon 🐉")
+		}
+	}
+}
+
 func TestNegotiationWILL(t *testing.T) {
 	parser := NewParserDefault()
 	parser.Options.SupportRemote(OptEcho)
@@ -738,6 +867,74 @@ func TestNOPCommand(t *testing.T) {
 	}
 }
 
+func TestSingleByteCommands(t *testing.T) {
+	for _, cmd := range []byte{CmdDM, CmdBRK, CmdIP, CmdAO, CmdEC, CmdEL} {
+		parser := NewParserDefault()
+
+		// The byte after the command is data, not an option code.
+		events := parser.Receive([]byte{CmdIAC, cmd, 'x'})
+		if len(events) != 2 {
+			t.Fatalf("command %d: expected 2 events, got %d", cmd, len(events))
+		}
+		if events[0].Kind != TelnetEventIAC || events[0].Command != cmd {
+			t.Errorf("command %d: first event = %+v, want IAC event", cmd, events[0])
+		}
+		if events[1].Kind != TelnetEventDataReceive || string(events[1].Data) != "x" {
+			t.Errorf("command %d: second event = %+v, want data \"x\"", cmd, events[1])
+		}
+	}
+}
+
+func TestAYTCommand(t *testing.T) {
+	parser := NewParserDefault()
+
+	events := parser.Receive([]byte{CmdIAC, CmdAYT})
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	if events[0].Kind != TelnetEventIAC || events[0].Command != CmdAYT {
+		t.Errorf("Expected AYT IAC event, got %+v", events[0])
+	}
+	if events[1].Kind != TelnetEventDataSend || !bytes.Equal(events[1].Data, []byte{CmdIAC, CmdNOP}) {
+		t.Errorf("Expected IAC NOP reply, got %+v", events[1])
+	}
+}
+
+// Ensure a subnegotiation that never ends is dropped once it passes
+// MaxSubnegotiation: the buffer stays bounded however much arrives,
+// an overflow event reports it, and parsing resumes.
+func TestSubnegotiationOverflowBounded(t *testing.T) {
+	parser := NewParser(DefaultCompatibility())
+	parser.MaxSubnegotiation = 64
+	parser.Receive([]byte{CmdIAC, CmdWILL, OptGMCP})
+
+	overflows := 0
+	chunk := bytes.Repeat([]byte("x"), 1000)
+	parser.Receive([]byte{CmdIAC, CmdSB, OptGMCP})
+	for i := 0; i < 1000; i++ { // ~1 MB, never terminated
+		for _, ev := range parser.Receive(chunk) {
+			if ev.Kind == TelnetEventOverflow {
+				overflows++
+				if ev.Option != OptGMCP {
+					t.Errorf("overflow option = %d, want GMCP", ev.Option)
+				}
+			}
+		}
+		if len(parser.buffer) > parser.MaxSubnegotiation {
+			t.Fatalf("buffer grew to %d bytes, cap %d", len(parser.buffer), parser.MaxSubnegotiation)
+		}
+	}
+	if overflows != 1 {
+		t.Errorf("overflow events = %d, want 1", overflows)
+	}
+
+	// Resynced: a well-formed subnegotiation still parses.
+	events := parser.Receive(subnegFrame(OptGMCP, []byte("Core.Ping")))
+	if len(events) != 1 || events[0].Kind != TelnetEventSubnegotiation || string(events[0].Data) != "Core.Ping" {
+		t.Fatalf("after overflow: events %+v, want the GMCP subnegotiation", events)
+	}
+}
+
 func TestSubnegotiationMethod(t *testing.T) {
 	parser := NewParserDefault()
 	parser.Options.SupportLocal(OptGMCP)
@@ -839,6 +1036,28 @@ func TestOutputBufferInputSent(t *testing.T) {
 	}
 }
 
+// TestOutputBufferPromptAt verifies PromptAt shows pending text only
+// while nothing has touched it since its generation was read.
+func TestOutputBufferPromptAt(t *testing.T) {
+	ob := NewOutputBuffer(TelnetModeUnterminated)
+	ob.Receive([]byte("HP:100> "))
+	gen := ob.Generation()
+	if got := ob.PromptAt(gen); got != "HP:100> " {
+		t.Fatalf("PromptAt(current) = %q", got)
+	}
+
+	ob.Receive([]byte("more"))
+	if got := ob.PromptAt(gen); got != "" {
+		t.Errorf("PromptAt after a read = %q, want nothing", got)
+	}
+	gen = ob.Generation()
+	ob.InputSent()
+	ob.Receive([]byte("HP:100> more"))
+	if got := ob.PromptAt(gen); got != "" {
+		t.Errorf("PromptAt after an input line = %q, want nothing", got)
+	}
+}
+
 func TestOutputBufferClearResetsNewData(t *testing.T) {
 	ob := NewOutputBuffer(TelnetModeTerminatedPrompt)
 	ob.Receive([]byte("data"))
@@ -884,3 +1103,52 @@ func TestOutputBufferConcurrentAccess(t *testing.T) {
 	}()
 	wg.Wait()
 }
+
+// TestOutputBufferSplitsOverlongLines feeds 1MB without a newline in
+// 4KB reads: it must come out as DefaultMaxLine-sized lines with
+// nothing lost, and the pending text must never outgrow the limit.
+func TestOutputBufferSplitsOverlongLines(t *testing.T) {
+	ob := NewOutputBuffer(TelnetModeUnterminated)
+	data := bytes.Repeat([]byte("0123456789abcdef"), 1<<16) // 1MB
+
+	var lines []string
+	for i := 0; i < len(data); i += 4096 {
+		lines = append(lines, ob.Receive(data[i:i+4096])...)
+		if ob.Len() > DefaultMaxLine {
+			t.Fatalf("pending text grew to %d bytes", ob.Len())
+		}
+	}
+	lines = append(lines, ob.Receive([]byte("\n"))...)
+
+	if want := len(data) / DefaultMaxLine; len(lines) != want {
+		t.Fatalf("got %d lines, want %d", len(lines), want)
+	}
+	for i, line := range lines {
+		if len(line) != DefaultMaxLine {
+			t.Fatalf("line %d is %d bytes", i, len(line))
+		}
+	}
+	if strings.Join(lines, "") != string(data) {
+		t.Fatal("split lines do not rejoin to the input")
+	}
+}
+
+// TestOutputBufferSplitKeepsCharacters checks a split never cuts a
+// UTF-8 character in two, a terminated overlong line is split too,
+// and 0 turns splitting off.
+func TestOutputBufferSplitKeepsCharacters(t *testing.T) {
+	ob := NewOutputBuffer(TelnetModeUnterminated)
+	ob.SetMaxLine(4)
+
+	got := ob.Receive([]byte("abcé€xy\r\nok\n"))
+	want := []string{"abc", "é", "€x", "y", "ok"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	ob.SetMaxLine(0)
+	long := strings.Repeat("x", 100)
+	if got := ob.Receive([]byte(long + "\n")); len(got) != 1 || got[0] != long {
+		t.Fatalf("with no limit got %q", got)
+	}
+}
@@ -0,0 +1,121 @@
+package network
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// trace records raw socket traffic for protocol debugging: every byte
+// the telnet parser receives and every byte writeLoop puts on the
+// wire, one record per read or write. Printable ASCII is kept as-is
+// and everything else is hex-escaped, so negotiation sequences read
+// directly ("\xff\xfd\x18" is IAC DO TTYPE). With MCCP2 active the
+// read side records the decompressed stream - what the parser sees.
+//
+// The read and write loops record concurrently, hence the mutex.
+type trace struct {
+	mu   sync.Mutex
+	file *os.File
+	path string
+}
+
+// record appends one traffic record. dir is '<' for bytes received
+// and '>' for bytes sent. A failed write closes the trace; the client
+// notices on the next record via TraceStatus.
+func (t *trace) record(dir byte, data []byte) {
+	var b strings.Builder
+	b.Grow(len(data)*2 + 32)
+	b.WriteString(time.Now().Format("15:04:05.000"))
+	b.WriteByte(' ')
+	b.WriteByte(dir)
+	b.WriteByte(' ')
+	for i, c := range data {
+		switch {
+		case c == '\\':
+			b.WriteString(`\\`)
+		case c >= 0x20 && c < 0x7f:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		}
+		// Break after LF so multi-line reads stay readable; the
+		// continuation indent keeps records visually grouped.
+		if c == '\n' && i+1 < len(data) {
+			b.WriteString("\n               ")
+		}
+	}
+	b.WriteByte('\n')
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.file == nil {
+		return
+	}
+	if _, err := t.file.WriteString(b.String()); err != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
+
+func (t *trace) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
+
+func (t *trace) active() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.file != nil
+}
+
+// StartTrace begins recording raw traffic to path (append, parent
+// directories created), replacing any active trace. The trace spans
+// connections: it keeps recording across disconnect and reconnect
+// until StopTrace. Returns the absolute path.
+func (c *TCPClient) StartTrace(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(abs, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(f, "--- Trace started %s ---\n", time.Now().Format("2006-01-02 15:04:05"))
+	if old := c.trace.Swap(&trace{file: f, path: abs}); old != nil {
+		old.close()
+	}
+	return abs, nil
+}
+
+// StopTrace ends the active trace. Reports whether one was recording.
+func (c *TCPClient) StopTrace() bool {
+	t := c.trace.Swap(nil)
+	if t == nil {
+		return false
+	}
+	wasActive := t.active()
+	t.close()
+	return wasActive
+}
+
+// TraceStatus returns the active trace path. A trace whose file
+// failed mid-session reports inactive.
+func (c *TCPClient) TraceStatus() (string, bool) {
+	t := c.trace.Load()
+	if t == nil || !t.active() {
+		return "", false
+	}
+	return t.path, true
+}
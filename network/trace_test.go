@@ -0,0 +1,82 @@
+package network
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTraceRecordEscapesNonPrintable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.log")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := &trace{file: f, path: path}
+	tr.record('<', []byte{CmdIAC, CmdDO, OptTTYPE})
+	tr.record('>', []byte("look\\here\r\nnorth"))
+	tr.close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		` < \xff\xfd\x18` + "\n",
+		` > look\\here\x0d\x0a` + "\n               north\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("trace missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestTraceRecordsBothDirections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "trace.log")
+	c := NewTCPClient()
+	t.Cleanup(c.Disconnect)
+	resolved, err := c.StartTrace(path)
+	if err != nil {
+		t.Fatalf("StartTrace: %v", err)
+	}
+	if got, ok := c.TraceStatus(); !ok || got != resolved {
+		t.Fatalf("TraceStatus = %q, %v; want %q, true", got, ok, resolved)
+	}
+
+	done := make(chan struct{})
+	addr := telnetServer(t, func(t *testing.T, conn net.Conn) {
+		defer close(done)
+		conn.Write([]byte{CmdIAC, CmdDO, OptTTYPE})
+		expectBytes(t, conn, []byte{CmdIAC, CmdWILL, OptTTYPE}, "WILL TTYPE")
+	})
+	if err := c.Connect(t.Context(), addr); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("server script did not complete")
+	}
+
+	if !c.StopTrace() {
+		t.Error("StopTrace reported no active trace")
+	}
+	if c.StopTrace() {
+		t.Error("second StopTrace reported an active trace")
+	}
+
+	data, err := os.ReadFile(resolved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{`< \xff\xfd\x18`, `> \xff\xfb\x18`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("trace missing %q:\n%s", want, got)
+		}
+	}
+}
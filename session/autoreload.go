@@ -0,0 +1,112 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// autoReloadInterval is how often watched scripts are checked. A
+// change reloads only once a later check finds nothing newer, so an
+// editor that writes a file in several steps causes one reload.
+const autoReloadInterval = time.Second
+
+// fileStamp is what a check compares; a missing file has the zero
+// stamp, so creating or deleting one counts as a change.
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+func stampOf(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{mod: info.ModTime(), size: info.Size()}
+}
+
+// scriptWatch polls the user scripts the last boot loaded, for
+// rune.autoreload. Polling rather than OS notifications: the set is a
+// handful of files, and editors that save by renaming a temp file over
+// the original would need their directories watched anyway.
+type scriptWatch struct {
+	on      bool
+	stamps  map[string]fileStamp // by absolute path
+	changed string               // a file changed since the last reload, waiting for a quiet check
+	checked time.Time
+}
+
+// watch replaces the watched set with paths and the other .lua files
+// beside them - the modules their require calls find first.
+func (w *scriptWatch) watch(paths []string) {
+	w.stamps = make(map[string]fileStamp)
+	w.changed = ""
+	for _, path := range paths {
+		w.stamps[path] = stampOf(path)
+		siblings, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.lua"))
+		for _, sibling := range siblings {
+			w.stamps[sibling] = stampOf(sibling)
+		}
+	}
+}
+
+// poll checks the watched files when a check is due, returning a file
+// that changed once the change has settled.
+func (w *scriptWatch) poll(now time.Time) (string, bool) {
+	if !w.on || now.Sub(w.checked) < autoReloadInterval {
+		return "", false
+	}
+	w.checked = now
+	moved := false
+	for path, old := range w.stamps {
+		if cur := stampOf(path); cur != old {
+			w.stamps[path] = cur
+			moved = true
+			if w.changed == "" {
+				w.changed = path
+			}
+		}
+	}
+	if moved || w.changed == "" {
+		return "", false
+	}
+	path := w.changed
+	w.changed = ""
+	return path, true
+}
+
+// SetAutoReload implements lua.Host. The setting lives here, not in
+// the VM, so it stays on across the reloads it causes.
+func (s *Session) SetAutoReload(on bool) {
+	s.scripts.on = on
+	if on {
+		s.watchScripts()
+	}
+}
+
+// AutoReload implements lua.Host.
+func (s *Session) AutoReload() bool {
+	return s.scripts.on
+}
+
+// watchScripts points the watch at what the current VM loaded, plus
+// init.lua even when it does not exist yet, so creating it counts.
+func (s *Session) watchScripts() {
+	if !s.scripts.on {
+		return
+	}
+	initPath, err := filepath.Abs(filepath.Join(s.config.ConfigDir, "init.lua"))
+	if err != nil {
+		initPath = filepath.Join(s.config.ConfigDir, "init.lua")
+	}
+	s.scripts.watch(append([]string{initPath}, s.engine.LoadedScripts()...))
+}
+
+// checkScripts reloads when a watched script has changed. Runs on the
+// bar tick.
+func (s *Session) checkScripts(now time.Time) {
+	if path, ok := s.scripts.poll(now); ok {
+		s.reload(path)
+	}
+}
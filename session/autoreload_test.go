@@ -0,0 +1,82 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeScript writes a script with a modification time of at, so
+// checks see a change however fast the test runs.
+func writeScript(t *testing.T, path, content string, at time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, at, at); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestAutoReloadOnScriptChange verifies a changed init.lua, or a module
+// beside it, reloads once the change has settled, and that nothing
+// happens with autoreload off.
+func TestAutoReloadOnScriptChange(t *testing.T) {
+	s, _, uiMock := newTestSession(t)
+	dir := s.config.ConfigDir
+	start := time.Now()
+	writeScript(t, filepath.Join(dir, "helper.lua"), "return 1", start)
+	writeScript(t, filepath.Join(dir, "init.lua"), `version = require("helper")`, start)
+	s.Reload()
+	awaitAsyncResult(t, s)
+	uiMock.drainPrinted()
+
+	if err := s.engine.DoString("on", `assert(rune.autoreload(true) == true)`); err != nil {
+		t.Fatal(err)
+	}
+	now := start
+	check := func() {
+		now = now.Add(autoReloadInterval)
+		s.checkScripts(now)
+	}
+	expectReload := func(want string) {
+		t.Helper()
+		check()
+		if len(s.asyncResults) != 0 {
+			t.Fatal("reloaded before the change settled")
+		}
+		check()
+		awaitAsyncResult(t, s)
+		printed := uiMock.drainPrinted()
+		if !contains(printed, want+" changed - reloading") {
+			t.Errorf("no reload notice for %s", want)
+		}
+		if contains(printed, "Reloading scripts...") {
+			t.Errorf("reload of %s announced twice: %q", want, printed)
+		}
+	}
+
+	check()
+	writeScript(t, filepath.Join(dir, "init.lua"), `version = require("helper") + 1`, start.Add(time.Minute))
+	expectReload("init.lua")
+	if err := s.engine.DoString("v2", `assert(version == 2, tostring(version))`); err != nil {
+		t.Fatal(err)
+	}
+
+	writeScript(t, filepath.Join(dir, "helper.lua"), "return 10", start.Add(2*time.Minute))
+	expectReload("helper.lua")
+	if err := s.engine.DoString("v11", `assert(version == 11, tostring(version))`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.engine.DoString("off", `assert(rune.autoreload(false) == false)`); err != nil {
+		t.Fatal(err)
+	}
+	writeScript(t, filepath.Join(dir, "init.lua"), "version = 0", start.Add(3*time.Minute))
+	check()
+	check()
+	if len(s.asyncResults) != 0 {
+		t.Fatal("reloaded with autoreload off")
+	}
+}
@@ -0,0 +1,100 @@
+package session
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestExecRoundTrip(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh on this system")
+	}
+	s, _, _ := newTestSession(t)
+
+	err := s.engine.DoString("test", `
+		rune.config.exec_allow = { "sh" }
+		rune.exec("sh", { "-c", "echo out; echo err >&2; exit 3" }, function(res, err)
+			rune.session.set("stdout", tostring(res and res.stdout))
+			rune.session.set("stderr", tostring(res and res.stderr))
+			rune.session.set("code", tostring(res and res.code))
+			rune.session.set("err", tostring(err))
+		end)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	awaitAsyncResult(t, s)
+
+	for key, want := range map[string]string{
+		"stdout": "out\n",
+		"stderr": "err\n",
+		"code":   "3", // a non-zero exit is a result, not an error
+		"err":    "nil",
+	} {
+		if v, _ := s.SessionGet(key); v != want {
+			t.Errorf("%s = %q, want %q", key, v, want)
+		}
+	}
+}
+
+func TestExecTimeoutAndMissingProgram(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("no sleep on this system")
+	}
+	s, _, _ := newTestSession(t)
+
+	err := s.engine.DoString("test", `
+		rune.config.exec_allow = { "sleep", "no-such-program-rune" }
+		rune.exec("sleep", { 5 }, function(res, err)
+			rune.session.set("slow", tostring(err))
+		end, { timeout = 0.1 })
+		rune.exec("no-such-program-rune", function(res, err)
+			rune.session.set("missing", tostring(err))
+		end)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	awaitAsyncResult(t, s)
+	awaitAsyncResult(t, s)
+
+	if v, _ := s.SessionGet("slow"); !strings.Contains(v, "timed out") {
+		t.Errorf("slow err = %q, want a timeout", v)
+	}
+	if v, _ := s.SessionGet("missing"); v == "nil" || v == "" {
+		t.Errorf("missing err = %q, want an error", v)
+	}
+}
+
+// TestExecPrimitiveChecksAllowlist verifies the allowlist holds for a
+// script calling rune._exec.run directly, past rune.exec's own check.
+func TestExecPrimitiveChecksAllowlist(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh on this system")
+	}
+	s, _, _ := newTestSession(t)
+
+	err := s.engine.DoString("test", `
+		rune.config.exec_allow = {}
+		rune.execs._deliver = function(id, res, err)
+			rune.session.set("ran", tostring(res ~= nil))
+			rune.session.set("err", tostring(err))
+		end
+		rune._exec.run(1, { command = "sh", args = { "-c", "echo pwned" } })
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	awaitAsyncResult(t, s)
+
+	if v, _ := s.SessionGet("ran"); v != "false" {
+		t.Errorf("ran = %q, want the program refused", v)
+	}
+	if v, _ := s.SessionGet("err"); !strings.Contains(v, "exec_allow") {
+		t.Errorf("err = %q, want an allowlist error", v)
+	}
+}
@@ -42,3 +42,48 @@ func TestGMCPEnabledTriggersHandshake(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+// TestReconnectKeepsScriptState verifies a drop and reconnect to the
+// same server fires "reconnected" without rebuilding the VM: triggers
+// and globals from before the drop still work, and the handshake
+// re-sends the GMCP subscriptions.
+func TestReconnectKeepsScriptState(t *testing.T) {
+	s, net, _ := newTestSession(t)
+
+	if err := s.engine.DoString("setup", `
+		reconnects, hits = 0, 0
+		rune.hooks.on("reconnected", function(addr)
+			reconnects = reconnects + 1
+			assert(addr == "mud.example.com:4000", addr)
+		end)
+		rune.trigger.starts("You are hungry", function() hits = hits + 1 end)
+		rune.gmcp.subscribe("Char")
+		rune.connect("mud.example.com:4000")
+	`); err != nil {
+		t.Fatal(err)
+	}
+	drainConnect(t, s)
+	if err := s.engine.DoString("first", `assert(reconnects == 0)`); err != nil {
+		t.Fatal(err)
+	}
+
+	s.handleNetworkOutput(network.Output{Kind: network.OutputDisconnect, Reason: network.DisconnectReset})
+	if err := s.engine.DoString("again", `rune.connect("mud.example.com:4000")`); err != nil {
+		t.Fatal(err)
+	}
+	drainConnect(t, s)
+
+	net.gmcpActive = true
+	s.handleNetworkOutput(network.Output{Kind: network.OutputGMCPEnabled})
+	if sent := net.drainGMCPSent(); len(sent) != 2 || sent[1].Data != `["Char 1"]` {
+		t.Errorf("handshake after reconnect = %v", sent)
+	}
+
+	serverLine(s, "You are hungry.")
+	if err := s.engine.DoString("check", `
+		assert(reconnects == 1, "reconnected fired " .. reconnects .. " times")
+		assert(hits == 1, "trigger fired " .. hits .. " times")
+	`); err != nil {
+		t.Error(err)
+	}
+}
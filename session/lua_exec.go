@@ -0,0 +1,92 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"time"
+
+	"github.com/mmcdole/rune/lua"
+)
+
+const (
+	execDefaultTimeout = 10 * time.Second
+	// Output past this on either stream fails the run, like an
+	// oversized HTTP body: scripts consume it as a Lua string.
+	execMaxOutputBytes = 1 << 20
+	// How long Wait gives a killed program's leftover children to
+	// release its pipes before closing them anyway.
+	execWaitDelay = time.Second
+)
+
+// Exec implements lua.Host. The program runs in its own goroutine and
+// the outcome comes back through the async-result channel, so the Lua
+// callback executes on the session goroutine under the watchdog, the
+// same way HTTPRequest delivers.
+func (s *Session) Exec(id int, req lua.ExecRequest) {
+	go func() {
+		res, err := runExec(req)
+		errMsg := ""
+		if err != nil {
+			errMsg = err.Error()
+		}
+		s.asyncResults <- func() {
+			s.engine.OnExecResult(id, res, errMsg)
+		}
+	}()
+}
+
+func runExec(req lua.ExecRequest) (*lua.ExecResult, error) {
+	if !slices.Contains(req.Allow, req.Command) {
+		return nil, fmt.Errorf("%s is not in rune.config.exec_allow", req.Command)
+	}
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = execDefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr cappedBuffer
+	cmd := exec.CommandContext(ctx, req.Command, req.Args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = execWaitDelay
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s: timed out after %s", req.Command, timeout)
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+	if stdout.over || stderr.over {
+		return nil, fmt.Errorf("%s: output exceeds the %d MB cap", req.Command, execMaxOutputBytes>>20)
+	}
+	return &lua.ExecResult{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+		Code:   cmd.ProcessState.ExitCode(),
+	}, nil
+}
+
+// cappedBuffer keeps the first execMaxOutputBytes written to it and
+// notes whether more arrived. It never refuses a write, so a chatty
+// program is not blocked (or killed by a broken pipe) mid-run.
+type cappedBuffer struct {
+	bytes.Buffer
+	over bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := execMaxOutputBytes - b.Len(); len(p) > room {
+		b.Buffer.Write(p[:max(room, 0)])
+		b.over = true
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
@@ -1,6 +1,10 @@
 package session
 
-import "github.com/mmcdole/rune/input"
+import (
+	"slices"
+
+	"github.com/mmcdole/rune/input"
+)
 
 // GetHistory implements lua.Host.
 // It is the compatibility projection used by rune.history.get().
@@ -25,16 +29,65 @@ func (s *Session) AddToHistory(cmd string) {
 	s.addHistorySubmission(input.Command(cmd))
 }
 
-// addHistorySubmission records the immutable submission as one history item.
-// Adjacent entries dedupe only when both text and interpretation match.
+// ForgetHistory implements lua.Host. /secret set uses it so the
+// credential it just read from the input line does not stay in
+// recall; older entries with the same text are someone else's.
+func (s *Session) ForgetHistory(text string) {
+	for i := len(s.historyEntries) - 1; i >= 0; i-- {
+		if s.historyEntries[i].Text == text {
+			s.historyEntries = slices.Delete(s.historyEntries, i, i+1)
+			return
+		}
+	}
+}
+
+// SetHistoryPolicy implements lua.Host. Up/Down and the Ctrl+R picker
+// both read this one store, so the policy holds for every way of
+// recalling history.
+func (s *Session) SetHistoryPolicy(size int, dedupe string) {
+	s.historyLimit = size
+	s.historyDedupe = dedupe
+	if dedupe == "global" {
+		// Keep the newest copy of each entry, as adding would have.
+		last := make(map[input.Submission]int, len(s.historyEntries))
+		for i, entry := range s.historyEntries {
+			last[entry] = i
+		}
+		kept := s.historyEntries[:0]
+		for i, entry := range s.historyEntries {
+			if last[entry] == i {
+				kept = append(kept, entry)
+			}
+		}
+		clear(s.historyEntries[len(kept):])
+		s.historyEntries = kept
+	}
+	s.trimHistory()
+}
+
+// addHistorySubmission records the immutable submission as one history
+// item. Entries are duplicates only when both text and interpretation
+// match; which duplicates are dropped is the policy's dedupe setting.
 func (s *Session) addHistorySubmission(entry input.Submission) {
 	if entry.Text == "" {
 		return
 	}
-	if len(s.historyEntries) > 0 && s.historyEntries[len(s.historyEntries)-1] == entry {
-		return
+	switch s.historyDedupe {
+	case "adjacent":
+		if len(s.historyEntries) > 0 && s.historyEntries[len(s.historyEntries)-1] == entry {
+			return
+		}
+	case "global":
+		s.historyEntries = slices.DeleteFunc(s.historyEntries, func(e input.Submission) bool {
+			return e == entry
+		})
 	}
 	s.historyEntries = append(s.historyEntries, entry)
+	s.trimHistory()
+}
+
+// trimHistory drops the oldest entries past the size limit.
+func (s *Session) trimHistory() {
 	if len(s.historyEntries) > s.historyLimit {
 		s.historyEntries = s.historyEntries[len(s.historyEntries)-s.historyLimit:]
 	}
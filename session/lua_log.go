@@ -57,7 +57,7 @@ func (s *Session) LogWrite(line string) {
 	if _, err := s.logFile.WriteString(line + "\n"); err != nil {
 		path := s.logPath
 		s.LogStop()
-		s.ui.Print(text.Red(fmt.Sprintf("[Log] write to %s failed (%v) - logging stopped", path, err)))
+		s.print(text.Red(fmt.Sprintf("[Log] write to %s failed (%v) - logging stopped", path, err)))
 	}
 }
 
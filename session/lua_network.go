@@ -2,7 +2,13 @@ package session
 
 import (
 	"context"
+	"fmt"
+	"regexp"
 	"time"
+
+	"github.com/mmcdole/rune/lua"
+	"github.com/mmcdole/rune/network"
+	"github.com/mmcdole/rune/text"
 )
 
 // Connect implements lua.Host.
@@ -10,7 +16,10 @@ import (
 // may block on the async-result channel (lossless delivery) because
 // the session loop keeps draining while the dial is in flight.
 func (s *Session) Connect(addr string) {
+	s.clientState.Connection = "connecting"
+	s.engine.UpdateState(s.clientState)
 	s.engine.CallHook("connecting", addr)
+	s.invalidateBars()
 	go func() {
 		// Create a timeout context for the dial attempt.
 		// We use a separate context because if the Session cancels,
@@ -23,28 +32,51 @@ func (s *Session) Connect(addr string) {
 			if err != nil {
 				s.clientState.Connected = false
 				s.clientState.Address = ""
+				s.clientState.Connection = "disconnected"
 				s.engine.UpdateState(s.clientState)
 				s.engine.CallHook("error", err.Error())
 			} else {
 				s.clientState.Connected = true
 				s.clientState.Address = addr
+				s.clientState.Connection = "connected"
+				s.clientState.DisconnectReason = ""
+				s.clientState.ConnectedAt = time.Now()
 				s.engine.UpdateState(s.clientState)
 				s.engine.CallHook("connected", addr)
+				// Coming back to the same server keeps the VM as it was:
+				// triggers, timers and GMCP subscriptions carry over, and
+				// the handshake re-sends Core.Supports.Set once GMCP is
+				// negotiated again.
+				if addr == s.lastAddr {
+					s.engine.CallHook("reconnected", addr)
+				}
+				s.lastAddr = addr
 			}
-			s.pushBarUpdates()
+			s.invalidateBars()
 		}
 	}()
 }
 
 // Disconnect implements lua.Host.
 func (s *Session) Disconnect() {
+	s.closeConnection(network.DisconnectUser, "")
+}
+
+// closeConnection tears the connection down and reports why it ended:
+// on request, or as the network layer saw it (detail is the error
+// text, empty for a clean close).
+func (s *Session) closeConnection(reason network.DisconnectReason, detail string) {
 	s.engine.CallHook("disconnecting")
 	s.net.Disconnect()
+	s.mxpActive = false
 	s.clientState.Connected = false
 	s.clientState.Address = ""
+	s.clientState.Connection = "disconnected"
+	s.clientState.DisconnectReason = string(reason)
+	s.clientState.ConnectedAt = time.Time{}
 	s.engine.UpdateState(s.clientState)
-	s.engine.CallHook("disconnected")
-	s.pushBarUpdates()
+	s.engine.CallHook("disconnected", string(reason), detail)
+	s.invalidateBars()
 }
 
 // Send implements lua.Host.
@@ -61,3 +93,86 @@ func (s *Session) GMCPSend(pkg, data string) error {
 func (s *Session) GMCPActive() bool {
 	return s.net.GMCPActive()
 }
+
+// NetPing implements lua.Host.
+func (s *Session) NetPing() error {
+	return s.net.Ping()
+}
+
+// NetStats implements lua.Host.
+func (s *Session) NetStats() lua.NetStats {
+	st := s.net.Stats()
+	return lua.NetStats{BytesIn: st.BytesIn, BytesOut: st.BytesOut, Latency: st.Latency}
+}
+
+// SetEnviron implements lua.Host.
+func (s *Session) SetEnviron(vars map[string]string) {
+	s.net.SetEnviron(vars)
+}
+
+// SetMaxLine implements lua.Host.
+func (s *Session) SetMaxLine(n int) {
+	s.net.SetMaxLine(n)
+}
+
+// SetMXP implements lua.Host.
+func (s *Session) SetMXP(on bool) {
+	s.net.SetMXP(on)
+}
+
+// TelnetNegotiate implements lua.Host.
+func (s *Session) TelnetNegotiate(command string, option byte) (bool, error) {
+	return s.net.Negotiate(command, option)
+}
+
+// TelnetAbandon implements lua.Host.
+func (s *Session) TelnetAbandon(option byte) bool {
+	return s.net.AbandonNegotiation(option)
+}
+
+// SetPromptDetect implements lua.Host. A regex pattern matches the
+// pending text with escape sequences stripped, as triggers see it.
+func (s *Session) SetPromptDetect(mode, pattern string, stable time.Duration) error {
+	var d network.PromptDetect
+	switch mode {
+	case "auto":
+		d.Mode = network.PromptAuto
+	case "ga":
+		d.Mode = network.PromptGA
+	case "regex":
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		d.Mode = network.PromptRegex
+		d.Match = func(pending string) bool {
+			return re.MatchString(text.StripANSI(pending))
+		}
+	case "timeout":
+		if stable <= 0 {
+			return fmt.Errorf("timeout must be positive")
+		}
+		d.Mode = network.PromptTimeout
+		d.Stable = stable
+	default:
+		return fmt.Errorf("unknown prompt detection mode %q (use auto, ga, regex, or timeout)", mode)
+	}
+	s.net.SetPromptDetect(d)
+	return nil
+}
+
+// TraceStart implements lua.Host. The trace is network-owned: it
+// records below the telnet parser and outlives connections and /reload.
+func (s *Session) TraceStart(path string) (string, error) {
+	return s.net.StartTrace(expandHome(path))
+}
+
+// TraceStop implements lua.Host.
+func (s *Session) TraceStop() bool {
+	return s.net.StopTrace()
+}
+
+// TraceStatus implements lua.Host.
+func (s *Session) TraceStatus() (string, bool) {
+	return s.net.TraceStatus()
+}
@@ -0,0 +1,116 @@
+package session
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// The secret store (lua.Host implementation): name→credential, backed
+// by <config>/secrets.json, apart from init.lua and store.json so
+// neither a shared config nor a pasted store leaks a password. The
+// file is written owner-only (0600) and values are base64-encoded:
+// that keeps them out of a casual glance or grep, but it is not
+// encryption - the file permissions are the protection. Loaded once
+// in New, written through atomically like store.json. All methods run
+// on the session goroutine - no locking.
+
+// loadSecrets reads secrets.json into memory. Called from New. Like
+// store.json, a corrupt file is preserved as secrets.json.bak and
+// reported at boot.
+func (s *Session) loadSecrets() {
+	s.secrets = make(map[string]string)
+	s.secretsPath = filepath.Join(s.config.ConfigDir, "secrets.json")
+
+	data, err := os.ReadFile(s.secretsPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.secretsLoadErr = fmt.Errorf("reading %s: %w", s.secretsPath, err)
+		}
+		return
+	}
+	var encoded map[string]string
+	err = json.Unmarshal(data, &encoded)
+	for name, value := range encoded {
+		plain, decodeErr := base64.StdEncoding.DecodeString(value)
+		if decodeErr != nil {
+			err = fmt.Errorf("secret %q: %w", name, decodeErr)
+			break
+		}
+		s.secrets[name] = string(plain)
+	}
+	if err != nil {
+		backup := s.secretsPath + ".bak"
+		if renameErr := os.Rename(s.secretsPath, backup); renameErr != nil {
+			backup = "(backup failed: " + renameErr.Error() + ")"
+		}
+		s.secretsLoadErr = fmt.Errorf("%s is corrupt (%v); preserved as %s, starting empty", s.secretsPath, err, backup)
+		s.secrets = make(map[string]string)
+	}
+}
+
+// saveSecrets writes the whole secret store atomically, owner-only.
+func (s *Session) saveSecrets() error {
+	dir := filepath.Dir(s.secretsPath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	encoded := make(map[string]string, len(s.secrets))
+	for name, value := range s.secrets {
+		encoded[name] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	data, err := json.MarshalIndent(encoded, "", "  ")
+	if err != nil {
+		return err
+	}
+	// CreateTemp opens 0600, and the rename carries that mode over
+	// whatever the old file had.
+	tmp, err := os.CreateTemp(dir, "secrets-*.json.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.secretsPath)
+}
+
+// SecretSet implements lua.Host.
+func (s *Session) SecretSet(name, value string) error {
+	s.secrets[name] = value
+	return s.saveSecrets()
+}
+
+// SecretGet implements lua.Host.
+func (s *Session) SecretGet(name string) (string, bool) {
+	value, ok := s.secrets[name]
+	return value, ok
+}
+
+// SecretDelete implements lua.Host.
+func (s *Session) SecretDelete(name string) error {
+	if _, ok := s.secrets[name]; !ok {
+		return nil
+	}
+	delete(s.secrets, name)
+	return s.saveSecrets()
+}
+
+// SecretNames implements lua.Host.
+func (s *Session) SecretNames() []string {
+	names := make([]string, 0, len(s.secrets))
+	for name := range s.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,48 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSecretEntryStaysOffDisk verifies /secret set keeps the value out
+// of scrollback and history, writes it owner-only and not in plain
+// text, and that it survives a restart.
+func TestSecretEntryStaysOffDisk(t *testing.T) {
+	dir := t.TempDir()
+
+	s1, _, uiMock := newTestSessionInDir(t, dir)
+	userInput(s1, "/secret set viking")
+	userInput(s1, "hunter2")
+	if printed := uiMock.drainPrinted(); contains(printed, "hunter2") {
+		t.Errorf("value reached scrollback: %q", printed)
+	}
+	for _, cmd := range s1.GetHistory() {
+		if cmd == "hunter2" {
+			t.Error("value kept in history")
+		}
+	}
+
+	path := filepath.Join(dir, "secrets.json")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("secrets.json not written: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("secrets.json mode = %o, want 600", perm)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Errorf("secrets.json holds the value in plain text: %s", data)
+	}
+
+	s2, _, _ := newTestSessionInDir(t, dir)
+	if err := s2.engine.DoString("read", `assert(rune.secret.get("viking") == "hunter2")`); err != nil {
+		t.Fatalf("secret did not survive restart: %v", err)
+	}
+}
@@ -3,5 +3,5 @@ package session
 // OnConfigChange implements lua.Host.
 func (s *Session) OnConfigChange() {
 	s.pushBindsAndLayout()
-	s.pushBarUpdates()
+	s.invalidateBars()
 }
@@ -17,22 +17,34 @@ func (s *Session) Quit() {
 // goroutine (called from inside a Lua dispatch), so blocking on the
 // async-result channel here would deadlock the loop that drains it.
 func (s *Session) Reload() {
-	s.engine.CallHook("reloading")
+	s.reload("")
+}
+
+// reload is Reload with the script whose change caused it, passed to
+// the reloading hook ("" for /reload) so Lua words the notice.
+func (s *Session) reload(changed string) {
+	s.engine.CallHook("reloading", changed)
 	select {
 	case s.asyncResults <- func() {
 		if err := s.boot(); err != nil {
-			s.ui.Print(text.Red(fmt.Sprintf("Reload Failed: %v", err)))
+			s.print(text.Red(fmt.Sprintf("Reload Failed: %v", err)))
 		} else {
 			s.engine.CallHook("reloaded")
 		}
 	}:
 	default:
-		s.ui.Print(text.Red("Reload Failed: event queue full"))
+		s.print(text.Red("Reload Failed: event queue full"))
 	}
 }
 
-// RefreshBars forces an immediate bar refresh.
-// Called from Lua when bar state changes and we don't want to wait for the ticker.
+// RefreshBars implements lua.Host: every bar re-renders now, without
+// waiting for the bar tick, and whatever was pending is covered.
 func (s *Session) RefreshBars() {
-	s.pushBarUpdates()
+	s.renderBars(true)
+}
+
+// MarkBarsDirty implements lua.Host: the bars Lua marked dirty
+// re-render on the next bar tick.
+func (s *Session) MarkBarsDirty() {
+	s.barsDirty = true
 }
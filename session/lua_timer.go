@@ -21,3 +21,46 @@ func (s *Session) TimerCancel(id int) {
 func (s *Session) TimerCancelAll() {
 	s.timer.CancelAll()
 }
+
+// defaultTickInterval is the heartbeat period until a script sets one.
+const defaultTickInterval = time.Second
+
+// SetTick implements lua.Host. It runs on the session goroutine, so
+// swapping the ticker cannot race the event loop reading it.
+func (s *Session) SetTick(interval time.Duration, paused bool) {
+	s.tickInterval = interval
+	s.tickPaused = paused
+	s.resetTick()
+}
+
+// TickStatus implements lua.Host.
+func (s *Session) TickStatus() (time.Duration, bool) {
+	return s.tickInterval, s.tickPaused
+}
+
+// resetTick replaces the heartbeat ticker to match the current
+// setting. Before Run it only records the setting: boot runs scripts
+// ahead of the event loop, which starts the ticker itself.
+func (s *Session) resetTick() {
+	s.stopTick()
+	if !s.tickLive || s.tickPaused || s.tickInterval <= 0 {
+		return
+	}
+	s.tickTicker = time.NewTicker(s.tickInterval)
+}
+
+func (s *Session) stopTick() {
+	if s.tickTicker != nil {
+		s.tickTicker.Stop()
+		s.tickTicker = nil
+	}
+}
+
+// tickC is the heartbeat lane for processEvents; nil (never ready)
+// while paused.
+func (s *Session) tickC() <-chan time.Time {
+	if s.tickTicker == nil {
+		return nil
+	}
+	return s.tickTicker.C
+}
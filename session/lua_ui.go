@@ -1,6 +1,8 @@
 package session
 
 import (
+	"time"
+
 	"github.com/mmcdole/rune/input"
 	"github.com/mmcdole/rune/text"
 	"github.com/mmcdole/rune/ui"
@@ -10,40 +12,184 @@ import (
 // server text, so display sanitization applies here too (issue #69);
 // rune.style output is SGR and passes through untouched.
 func (s *Session) Print(msg string) {
-	s.ui.Print(text.SanitizeDisplay(msg))
+	s.print(text.SanitizeDisplay(msg))
+}
+
+// paneActivity mirrors one pane's visibility so writes to a hidden
+// pane can be counted. Every visibility change goes through the pane
+// calls below, so the mirror stays exact without asking the UI. Bars
+// show the counts (PaneUnread), so a change re-renders them.
+type paneActivity struct {
+	visible bool
+	unread  int // writes while hidden
+}
+
+// pane returns the mirror for name, creating it the way the UI's
+// PaneManager auto-creates on write.
+func (s *Session) pane(name string) *paneActivity {
+	p, ok := s.panes[name]
+	if !ok {
+		p = &paneActivity{}
+		s.panes[name] = p
+	}
+	return p
 }
 
 // PaneCreate implements lua.Host.
 func (s *Session) PaneCreate(name string) {
+	s.pane(name)
 	s.ui.CreatePane(name)
 }
 
 // PaneWrite implements lua.Host. Sanitized like Print: pane content is
 // often trigger-captured server text.
 func (s *Session) PaneWrite(name, msg string) {
+	if p := s.pane(name); !p.visible {
+		p.unread++
+		s.invalidateBars()
+	}
 	s.ui.WritePane(name, text.SanitizeDisplay(msg))
 }
 
-// PaneToggle implements lua.Host.
+// PaneToggle implements lua.Host. Like the UI, toggling a pane that
+// was never created or written is a no-op.
 func (s *Session) PaneToggle(name string) {
+	if p, ok := s.panes[name]; ok {
+		p.visible = !p.visible
+		if p.visible {
+			p.unread = 0
+		}
+		s.invalidateBars()
+	}
 	s.ui.TogglePane(name)
 }
 
 // PaneSetVisible implements lua.Host.
 func (s *Session) PaneSetVisible(name string, visible bool) {
+	if p, ok := s.panes[name]; ok {
+		p.visible = visible
+		if visible {
+			p.unread = 0
+		}
+		s.invalidateBars()
+	}
 	s.ui.SetPaneVisible(name, visible)
 }
 
-// PaneClear implements lua.Host.
+// PaneClear implements lua.Host. Clearing discards the unread lines
+// along with the rest.
 func (s *Session) PaneClear(name string) {
+	if p, ok := s.panes[name]; ok {
+		p.unread = 0
+		s.invalidateBars()
+	}
 	s.ui.ClearPane(name)
 }
 
+// PaneUnread implements lua.Host: hidden panes with writes since
+// they were last shown.
+func (s *Session) PaneUnread() map[string]int {
+	unread := make(map[string]int)
+	for name, p := range s.panes {
+		if p.unread > 0 {
+			unread[name] = p.unread
+		}
+	}
+	return unread
+}
+
+// PaneVisible implements lua.Host, from the mirror.
+func (s *Session) PaneVisible(name string) bool {
+	p, ok := s.panes[name]
+	return ok && p.visible
+}
+
+// PaneResize implements lua.Host.
+func (s *Session) PaneResize(name string, height int) {
+	s.ui.ResizePane(name, height)
+}
+
+// PaneGrow implements lua.Host.
+func (s *Session) PaneGrow(name string, rows int) {
+	s.ui.GrowPane(name, rows)
+}
+
+// PaneLimit implements lua.Host. Like a write, it creates the pane.
+func (s *Session) PaneLimit(name string, lines, bytes int) {
+	s.pane(name)
+	s.ui.LimitPane(name, lines, bytes)
+}
+
+// PaneFocus implements lua.Host.
+func (s *Session) PaneFocus(name string) {
+	s.ui.FocusPane(name)
+}
+
 // ClipboardSet implements lua.Host.
 func (s *Session) ClipboardSet(text string) {
 	s.ui.SetClipboard(text)
 }
 
+// ClearPrompt implements lua.Host.
+func (s *Session) ClearPrompt() {
+	s.clearPrompt()
+}
+
+// Bell implements lua.Host.
+func (s *Session) Bell(audible, visual bool) {
+	s.ui.Bell(audible, visual)
+}
+
+// CopyMode implements lua.Host.
+func (s *Session) CopyMode() {
+	s.ui.EnterCopyMode()
+}
+
+// JumpToInput implements lua.Host.
+func (s *Session) JumpToInput() {
+	s.ui.JumpToInput()
+}
+
+// ClearScreen implements lua.Host.
+func (s *Session) ClearScreen() {
+	s.ui.ClearScreen()
+}
+
+// ClearScrollback implements lua.Host.
+func (s *Session) ClearScrollback() {
+	s.ui.ClearScrollback()
+}
+
+// SetDedupe implements lua.Host.
+func (s *Session) SetDedupe(on bool) {
+	s.ui.SetDedupe(on)
+}
+
+// SetFlushInterval implements lua.Host.
+func (s *Session) SetFlushInterval(d time.Duration) {
+	s.ui.SetFlushInterval(d)
+}
+
+// GrepScrollback implements lua.Host.
+func (s *Session) GrepScrollback(req ui.GrepScrollbackMsg) {
+	s.ui.GrepScrollback(req)
+}
+
+// SetTheme implements lua.Host.
+func (s *Session) SetTheme(theme ui.Theme) {
+	s.ui.SetTheme(theme)
+}
+
+// SetWrap implements lua.Host.
+func (s *Session) SetWrap(on bool) {
+	s.ui.SetWrap(on)
+}
+
+// SetSplit implements lua.Host.
+func (s *Session) SetSplit(on bool) {
+	s.ui.SetSplit(on)
+}
+
 // ShowPicker implements lua.Host.
 func (s *Session) ShowPicker(opts ui.ShowPickerMsg) {
 	s.ui.ShowPicker(opts)
@@ -83,6 +229,38 @@ func (s *Session) InputSetCursor(pos int) {
 	s.ui.InputSetCursor(input.ByteCursorToRune(s.currentInput, pos))
 }
 
+// InputSetPrompt implements lua.Host. Sanitized like Print, so a
+// themed prompt keeps its colors but cannot move the cursor.
+func (s *Session) InputSetPrompt(prompt string) {
+	s.ui.InputSetPrompt(text.SanitizeDisplay(prompt))
+}
+
+// InputSetPlaceholder implements lua.Host.
+func (s *Session) InputSetPlaceholder(hint string) {
+	s.ui.InputSetPlaceholder(text.SanitizeDisplay(hint))
+}
+
+// InputSetMask implements lua.Host.
+func (s *Session) InputSetMask(on bool) {
+	s.ui.InputSetMask(on)
+}
+
+// InputSetGhost implements lua.Host. Plain text only: accepting the
+// ghost types it into the input, where escapes have no place.
+func (s *Session) InputSetGhost(ghost string) {
+	s.ui.InputSetGhost(text.StripANSI(ghost))
+}
+
+// InputSetWordBreak implements lua.Host.
+func (s *Session) InputSetWordBreak(chars string) {
+	s.ui.InputSetWordBreak(chars)
+}
+
+// InputSetQueue implements lua.Host.
+func (s *Session) InputSetQueue(limit int) {
+	s.ui.InputSetQueue(limit)
+}
+
 // OpenEditor implements lua.Host.
 func (s *Session) OpenEditor(initial string) (string, bool) {
 	return s.ui.OpenEditor(initial)
@@ -98,6 +276,16 @@ func (s *Session) PaneScrollDown(name string, lines int) {
 	s.ui.PaneScrollDown(name, lines)
 }
 
+// PaneScrollColumns implements lua.Host.
+func (s *Session) PaneScrollColumns(name string, cols int) {
+	s.ui.PaneScrollColumns(name, cols)
+}
+
+// PaneScrollPages implements lua.Host.
+func (s *Session) PaneScrollPages(name string, pages float64) {
+	s.ui.PaneScrollPages(name, pages)
+}
+
 // PaneScrollToTop implements lua.Host.
 func (s *Session) PaneScrollToTop(name string) {
 	s.ui.PaneScrollToTop(name)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/mmcdole/rune/input"
 	"github.com/mmcdole/rune/network"
@@ -23,6 +24,12 @@ type mockNetwork struct {
 	localEcho   bool
 	windowW     int
 	windowH     int
+	tcp         network.TCPOptions
+	environ     map[string]string
+	prompt      network.PromptDetect
+	tracePath   string
+	pings       int
+	stats       network.Stats
 }
 
 var _ Network = (*mockNetwork)(nil)
@@ -92,6 +99,78 @@ func (m *mockNetwork) SetWindowSize(width, height int) {
 	m.windowW, m.windowH = width, height
 }
 
+func (m *mockNetwork) SetTCPOptions(opts network.TCPOptions) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tcp = opts
+}
+
+func (m *mockNetwork) SetEnviron(vars map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.environ = vars
+}
+
+func (m *mockNetwork) SetMaxLine(n int) {}
+
+func (m *mockNetwork) SetMXP(on bool) {}
+
+func (m *mockNetwork) SetPromptDetect(d network.PromptDetect) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prompt = d
+}
+
+func (m *mockNetwork) Negotiate(command string, option byte) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.connected {
+		return false, errors.New("not connected")
+	}
+	return true, nil
+}
+
+func (m *mockNetwork) AbandonNegotiation(option byte) bool {
+	return false
+}
+
+func (m *mockNetwork) StartTrace(path string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tracePath = path
+	return path, nil
+}
+
+func (m *mockNetwork) StopTrace() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	was := m.tracePath != ""
+	m.tracePath = ""
+	return was
+}
+
+func (m *mockNetwork) TraceStatus() (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.tracePath, m.tracePath != ""
+}
+
+func (m *mockNetwork) Ping() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.connected {
+		return errors.New("not connected")
+	}
+	m.pings++
+	return nil
+}
+
+func (m *mockNetwork) Stats() network.Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
 func (m *mockNetwork) drainGMCPSent() []struct{ Package, Data string } {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -118,6 +197,7 @@ type mockUI struct {
 	inputModes  []input.Submission
 	inputCursor []int
 	bindsPushed map[string]bool // last UpdateBinds payload
+	barPushes   int             // UpdateBars calls
 	input       chan input.Submission
 	outbound    chan ui.UIEvent
 	done        chan struct{}
@@ -175,7 +255,19 @@ func (m *mockUI) SetInputSubmission(submission input.Submission) {
 	m.inputModes = append(m.inputModes, submission)
 }
 
-func (m *mockUI) UpdateBars(content map[string]ui.BarContent) {}
+func (m *mockUI) UpdateBars(content map[string]ui.BarContent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.barPushes++
+}
+
+func (m *mockUI) drainBarPushes() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := m.barPushes
+	m.barPushes = 0
+	return n
+}
 func (m *mockUI) UpdateBinds(keys map[string]bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -191,23 +283,46 @@ func (m *mockUI) pushedBinds() map[string]bool {
 
 func (m *mockUI) ShowPicker(opts ui.ShowPickerMsg)         {}
 func (m *mockUI) SetClipboard(text string)                 {}
+func (m *mockUI) EnterCopyMode()                           {}
+func (m *mockUI) JumpToInput()                             {}
+func (m *mockUI) ClearScreen()                             {}
+func (m *mockUI) ClearScrollback()                         {}
+func (m *mockUI) SetDedupe(on bool)                        {}
+func (m *mockUI) SetFlushInterval(d time.Duration)         {}
+func (m *mockUI) GrepScrollback(req ui.GrepScrollbackMsg)  {}
+func (m *mockUI) SetWrap(on bool)                          {}
+func (m *mockUI) SetSplit(on bool)                         {}
+func (m *mockUI) SetTheme(theme ui.Theme)                  {}
+func (m *mockUI) Bell(audible, visual bool)                {}
 func (m *mockUI) CreatePane(name string)                   {}
 func (m *mockUI) WritePane(name, text string)              {}
 func (m *mockUI) TogglePane(name string)                   {}
 func (m *mockUI) SetPaneVisible(name string, visible bool) {}
 func (m *mockUI) ClearPane(name string)                    {}
+func (m *mockUI) ResizePane(name string, height int)       {}
+func (m *mockUI) GrowPane(name string, rows int)           {}
+func (m *mockUI) LimitPane(name string, lines, bytes int)  {}
+func (m *mockUI) FocusPane(name string)                    {}
 
 func (m *mockUI) InputSetCursor(pos int) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.inputCursor = append(m.inputCursor, pos)
 }
+func (m *mockUI) InputSetPrompt(prompt string)             {}
+func (m *mockUI) InputSetPlaceholder(text string)          {}
+func (m *mockUI) InputSetMask(on bool)                     {}
+func (m *mockUI) InputSetGhost(text string)                {}
+func (m *mockUI) InputSetWordBreak(chars string)           {}
+func (m *mockUI) InputSetQueue(limit int)                  {}
 func (m *mockUI) OpenEditor(initial string) (string, bool) { return "", false }
 
-func (m *mockUI) PaneScrollUp(name string, lines int)   {}
-func (m *mockUI) PaneScrollDown(name string, lines int) {}
-func (m *mockUI) PaneScrollToTop(name string)           {}
-func (m *mockUI) PaneScrollToBottom(name string)        {}
+func (m *mockUI) PaneScrollUp(name string, lines int)        {}
+func (m *mockUI) PaneScrollDown(name string, lines int)      {}
+func (m *mockUI) PaneScrollColumns(name string, cols int)    {}
+func (m *mockUI) PaneScrollPages(name string, pages float64) {}
+func (m *mockUI) PaneScrollToTop(name string)                {}
+func (m *mockUI) PaneScrollToBottom(name string)             {}
 
 func (m *mockUI) drainPrinted() []string {
 	m.mu.Lock()
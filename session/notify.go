@@ -0,0 +1,139 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/mmcdole/rune/text"
+)
+
+// Desktop notifications are capped at notifyBurst per notifyWindow,
+// so a chatty trigger cannot bury the desktop. Excess calls are
+// dropped, not queued: a late notification is worse than none.
+const (
+	notifyBurst   = 5
+	notifyWindow  = 10 * time.Second
+	notifyTimeout = 15 * time.Second
+)
+
+var (
+	errNoNotifier        = errors.New("no desktop notification backend found (install notify-send)")
+	errNotifyRateLimited = errors.New("notifications rate-limited; dropping until the burst passes")
+	errNoMacNotifier     = errors.New("no desktop notification backend found (terminal-notifier or osascript)")
+	errNoWindowsNotifier = errors.New("no desktop notification backend found (powershell)")
+)
+
+// notifyLimiter is a sliding-window rate limit. Session-goroutine
+// only, like the rest of Session's state.
+type notifyLimiter struct {
+	sent []time.Time
+}
+
+// allow reports whether a notification may go out at now, recording
+// it if so.
+func (l *notifyLimiter) allow(now time.Time) bool {
+	kept := l.sent[:0]
+	for _, t := range l.sent {
+		if now.Sub(t) < notifyWindow {
+			kept = append(kept, t)
+		}
+	}
+	l.sent = kept
+	if len(l.sent) >= notifyBurst {
+		return false
+	}
+	l.sent = append(l.sent, now)
+	return true
+}
+
+// Notify implements lua.Host. The backend runs on its own goroutine so
+// a slow notification daemon never stalls the event loop; a failure to
+// run it comes back through asyncResults to the "error" hook.
+func (s *Session) Notify(title, body string) error {
+	n, err := findNotifier(runtime.GOOS, text.StripANSI(title), text.StripANSI(body), exec.LookPath)
+	if err != nil {
+		return err
+	}
+	if !s.notifyLimit.allow(time.Now()) {
+		return errNotifyRateLimited
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, n.path, n.args...)
+		if n.env != nil {
+			cmd.Env = append(os.Environ(), n.env...)
+		}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			msg := "notify: " + err.Error()
+			if len(out) > 0 {
+				msg += ": " + string(out)
+			}
+			s.asyncResults <- func() {
+				s.engine.CallHook("error", msg)
+			}
+		}
+	}()
+	return nil
+}
+
+// notifier is a resolved notification command: the backend binary,
+// its arguments, and extra environment variables.
+type notifier struct {
+	path string
+	args []string
+	env  []string
+}
+
+// findNotifier picks the platform's notification backend, looked up
+// with lookPath. Title and body travel as separate arguments (or, for
+// PowerShell, environment variables) - never spliced into a script -
+// so server text in a notification cannot inject commands.
+func findNotifier(goos, title, body string, lookPath func(string) (string, error)) (notifier, error) {
+	switch goos {
+	case "darwin":
+		if path, err := lookPath("terminal-notifier"); err == nil {
+			return notifier{path: path, args: []string{"-title", title, "-message", body}}, nil
+		}
+		if path, err := lookPath("osascript"); err == nil {
+			return notifier{path: path, args: []string{
+				"-e", "on run argv",
+				"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+				"-e", "end run",
+				title, body,
+			}}, nil
+		}
+		return notifier{}, errNoMacNotifier
+	case "windows":
+		path, err := lookPath("powershell")
+		if err != nil {
+			return notifier{}, errNoWindowsNotifier
+		}
+		return notifier{
+			path: path,
+			args: []string{"-NoProfile", "-NonInteractive", "-Command", windowsBalloonScript},
+			env:  []string{"RUNE_NOTIFY_TITLE=" + title, "RUNE_NOTIFY_BODY=" + body},
+		}, nil
+	default:
+		path, err := lookPath("notify-send")
+		if err != nil {
+			return notifier{}, errNoNotifier
+		}
+		return notifier{path: path, args: []string{"--app-name=rune", "--", title, body}}, nil
+	}
+}
+
+// windowsBalloonScript shows a tray balloon (a toast on Windows 10+)
+// using only the framework assemblies every install has. It waits out
+// the balloon before disposing the icon, or the notification vanishes.
+const windowsBalloonScript = `Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(5000, $env:RUNE_NOTIFY_TITLE, $env:RUNE_NOTIFY_BODY, 'Info')
+Start-Sleep -Seconds 6
+$n.Dispose()`
@@ -0,0 +1,67 @@
+package session
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNotifyLimiterSlidingWindow(t *testing.T) {
+	var l notifyLimiter
+	start := time.Now()
+
+	for i := 0; i < notifyBurst; i++ {
+		if !l.allow(start.Add(time.Duration(i) * time.Millisecond)) {
+			t.Fatalf("notification %d within burst refused", i)
+		}
+	}
+	if l.allow(start.Add(time.Second)) {
+		t.Fatal("notification past the burst allowed")
+	}
+	// Once the first send ages out of the window, one slot frees up.
+	if !l.allow(start.Add(notifyWindow)) {
+		t.Fatal("slot not freed after the window passed")
+	}
+	if l.allow(start.Add(notifyWindow)) {
+		t.Fatal("only one slot should have freed")
+	}
+}
+
+func TestFindNotifier(t *testing.T) {
+	have := func(names ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			if slices.Contains(names, name) {
+				return "/usr/bin/" + name, nil
+			}
+			return "", errors.New("not found")
+		}
+	}
+
+	n, err := findNotifier("linux", "Tell", "Bob: hi", have("notify-send"))
+	if err != nil || n.path != "/usr/bin/notify-send" ||
+		!slices.Equal(n.args, []string{"--app-name=rune", "--", "Tell", "Bob: hi"}) {
+		t.Errorf("linux: got %+v, %v", n, err)
+	}
+
+	n, err = findNotifier("darwin", "Tell", "hi", have("terminal-notifier", "osascript"))
+	if err != nil || n.path != "/usr/bin/terminal-notifier" {
+		t.Errorf("darwin should prefer terminal-notifier, got %+v, %v", n, err)
+	}
+	n, err = findNotifier("darwin", "Tell", "hi", have("osascript"))
+	if err != nil || n.path != "/usr/bin/osascript" || !slices.Equal(n.args[len(n.args)-2:], []string{"Tell", "hi"}) {
+		t.Errorf("darwin osascript fallback: got %+v, %v", n, err)
+	}
+
+	// PowerShell gets the text through the environment, never the script.
+	n, err = findNotifier("windows", "Tell", "'; rm -r ~", have("powershell"))
+	if err != nil || !slices.Contains(n.env, "RUNE_NOTIFY_BODY='; rm -r ~") {
+		t.Errorf("windows: got %+v, %v", n, err)
+	}
+
+	for _, goos := range []string{"linux", "darwin", "windows"} {
+		if _, err := findNotifier(goos, "t", "b", have()); err == nil {
+			t.Errorf("%s: expected an error with no backend installed", goos)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package session
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// OpenURL implements lua.Host. Links come from server text, so only
+// absolute http(s) URLs are opened: file: or custom-scheme URLs could
+// launch arbitrary local handlers. The opener runs detached; its exit
+// status says nothing useful (xdg-open returns before the browser
+// loads), so only a failure to start it is reported.
+func (s *Session) OpenURL(rawURL string) error {
+	u, err := checkOpenableURL(rawURL)
+	if err != nil {
+		return err
+	}
+	cmd := openerCommand(runtime.GOOS, u)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("open %s: %w", u, err)
+	}
+	go cmd.Wait() // reap the child
+	return nil
+}
+
+// checkOpenableURL validates a URL for the OS opener and returns it in
+// canonical form.
+func checkOpenableURL(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("refusing to open %q: only http and https URLs are opened", rawURL)
+	}
+	return u.String(), nil
+}
+
+// openerCommand builds the platform's "open this URL" command. On
+// Windows the URL handler is invoked directly: "cmd /c start" would
+// re-parse the URL and treat & as a command separator.
+func openerCommand(goos, u string) *exec.Cmd {
+	switch goos {
+	case "darwin":
+		return exec.Command("open", u)
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		return exec.Command("xdg-open", u)
+	}
+}
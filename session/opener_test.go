@@ -0,0 +1,48 @@
+package session
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCheckOpenableURL(t *testing.T) {
+	ok := map[string]string{
+		"https://example.com/a?b=c": "https://example.com/a?b=c",
+		"HTTP://example.com":        "http://example.com",
+		" https://example.com ":     "https://example.com",
+	}
+	for in, want := range ok {
+		got, err := checkOpenableURL(in)
+		if err != nil || got != want {
+			t.Errorf("checkOpenableURL(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{
+		"file:///etc/passwd",
+		"javascript:alert(1)",
+		"ssh://host",
+		"https://",
+		"example.com",
+		"",
+	} {
+		if _, err := checkOpenableURL(in); err == nil {
+			t.Errorf("checkOpenableURL(%q) accepted", in)
+		}
+	}
+}
+
+func TestOpenerCommand(t *testing.T) {
+	const u = "https://example.com/?a=1&b=2"
+	cases := map[string][]string{
+		"linux":   {"xdg-open", u},
+		"freebsd": {"xdg-open", u},
+		"darwin":  {"open", u},
+		"windows": {"rundll32", "url.dll,FileProtocolHandler", u},
+	}
+	for goos, want := range cases {
+		if got := openerCommand(goos, u).Args; !slices.Equal(got, want) {
+			t.Errorf("openerCommand(%q) args = %q, want %q", goos, got, want)
+		}
+	}
+}
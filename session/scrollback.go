@@ -0,0 +1,76 @@
+package session
+
+import (
+	"strings"
+
+	"github.com/mmcdole/rune/text"
+)
+
+// maxRecentLines bounds the output mirror, and so how far back
+// rune.scrollback.lines can read.
+const maxRecentLines = 1000
+
+// recentLines mirrors the newest lines sent to the output, ANSI and
+// all, with where each came from, so Lua can read them back
+// synchronously: the scrollback itself lives in the UI, on the other
+// side of a message channel. It is a ring; the oldest line is
+// overwritten once it is full.
+type recentLines struct {
+	lines []recentLine
+	next  int // slot the next line goes in
+	count int
+}
+
+type recentLine struct {
+	raw    string
+	origin text.Origin
+}
+
+func (r *recentLines) add(msg string, origin text.Origin) {
+	if r.lines == nil {
+		r.lines = make([]recentLine, maxRecentLines)
+	}
+	// One entry per line, split as the UI splits rows.
+	msg = strings.ReplaceAll(strings.ReplaceAll(msg, "\r\n", "\n"), "\r", "\n")
+	for _, line := range strings.Split(msg, "\n") {
+		r.lines[r.next] = recentLine{raw: line, origin: origin}
+		r.next = (r.next + 1) % maxRecentLines
+		r.count = min(r.count+1, maxRecentLines)
+	}
+}
+
+// last returns up to n of the newest lines, oldest first.
+func (r *recentLines) last(n int) []text.Line {
+	n = min(max(n, 0), r.count)
+	out := make([]text.Line, n)
+	for i := range out {
+		entry := r.lines[(r.next-n+i+maxRecentLines)%maxRecentLines]
+		out[i] = text.NewLine(entry.raw)
+		out[i].Origin = entry.origin
+	}
+	return out
+}
+
+// print sends client or script text to the output and records it in
+// the mirror.
+func (s *Session) print(msg string) {
+	s.show(msg, text.OriginScript)
+}
+
+// show sends text from origin to the output and records it in the
+// mirror.
+func (s *Session) show(msg string, origin text.Origin) {
+	s.recent.add(msg, origin)
+	s.ui.Print(msg)
+}
+
+// echo is print for local echoes.
+func (s *Session) echo(msg string) {
+	s.recent.add(msg, text.OriginEcho)
+	s.ui.Echo(msg)
+}
+
+// RecentLines implements lua.Host.
+func (s *Session) RecentLines(n int) []text.Line {
+	return s.recent.last(n)
+}
@@ -5,10 +5,8 @@ import (
 	"embed"
 	"encoding/json"
 	"fmt"
-	"io/fs"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"time"
 
@@ -33,6 +31,18 @@ type Network interface {
 	SendGMCP(pkg, data string) error
 	GMCPActive() bool
 	SetWindowSize(width, height int)
+	SetTCPOptions(opts network.TCPOptions)
+	SetEnviron(vars map[string]string)
+	SetMaxLine(n int)
+	SetMXP(on bool)
+	SetPromptDetect(d network.PromptDetect)
+	Negotiate(command string, option byte) (bool, error)
+	AbandonNegotiation(option byte) bool
+	StartTrace(path string) (string, error)
+	StopTrace() bool
+	TraceStatus() (string, bool)
+	Ping() error
+	Stats() network.Stats
 	Output() <-chan network.Output
 	LocalEchoEnabled() bool
 }
@@ -42,8 +52,14 @@ var _ Network = (*network.TCPClient)(nil)
 // Config holds session configuration
 type Config struct {
 	CoreScripts   embed.FS // Embedded core Lua scripts
-	ConfigDir     string   // Directory for all of Rune's files (init.lua, store.json, worlds, logs)
+	ConfigDir     string   // Directory for all of Rune's files (init.lua, store.json, secrets.json, worlds, logs)
 	ConnectTarget string   // CLI connect target (world, host port, or address)
+	Profile       string   // CLI profile; seeds the "profile" session key (66_profiles.lua)
+	Mouse         bool     // The UI reports mouse events (rune.term.mouse)
+
+	// TCP tunes the socket of each connection (Nagle, keepalive).
+	// Nil keeps the network layer's defaults.
+	TCP *network.TCPOptions
 }
 
 // Session is the central actor/orchestrator that owns the Lua state and
@@ -59,9 +75,13 @@ type Session struct {
 	// Scripting
 	engine *lua.Engine
 
-	// History
+	// History (see lua_history.go)
 	historyEntries []input.Submission
 	historyLimit   int
+	historyDedupe  string // "adjacent", "global", or "none"
+
+	// User scripts checked for changes (see autoreload.go)
+	scripts scriptWatch
 
 	// Reload-surviving Lua state (see lua_session.go)
 	sessionStore map[string]string
@@ -71,10 +91,39 @@ type Session struct {
 	storePath    string
 	storeLoadErr error // corrupt/unreadable store.json, reported at boot
 
+	// Server lines shown per second (see throttle.go)
+	throttle outputThrottle
+
+	// MXP tag conversion, on while the server has MXP negotiated
+	mxp       text.MXP
+	mxpActive bool
+
+	// Credentials backed by <config>/secrets.json (see lua_secret.go)
+	secrets        map[string]string
+	secretsPath    string
+	secretsLoadErr error // corrupt/unreadable secrets.json, reported at boot
+
 	// Active session log (see lua_log.go); survives /reload
 	logFile *os.File
 	logPath string
 
+	// Desktop notification rate limit (see notify.go); survives /reload
+	// so a reload cannot reset a storm's budget.
+	notifyLimit notifyLimiter
+
+	// Sound playback (see sound.go): whether a player is running, and
+	// when the last one started.
+	soundPlaying bool
+	soundStarted time.Time
+
+	// The newest output lines, for rune.scrollback.lines (see
+	// scrollback.go); survives /reload.
+	recent recentLines
+
+	// Pane visibility and unread counts, mirrored from the pane calls
+	// Lua makes (see lua_ui.go); survives /reload with the panes.
+	panes map[string]*paneActivity
+
 	// Channels
 	// asyncResults marshals work from producer goroutines (dial, HTTP,
 	// deferred reload) back onto the session goroutine, which runs each
@@ -85,14 +134,28 @@ type Session struct {
 	asyncResults chan func()
 	timerEvents  chan timer.Event
 	barTicker    *time.Ticker
+	tickTicker   *time.Ticker // nil while the heartbeat is paused
 
 	// State
 	lastPrompt    string
+	lastAddr      string // last address connected to, for "reconnected"
+	termColors    int    // detected at startup (see term.go)
 	connectTarget string // CLI connect target; consumed on first boot only
 	config        Config
 	clientState   lua.ClientState
 	currentInput  string // Tracked so Lua can query via rune.input.get()
 	currentCursor int    // Zero-based UTF-8 byte offset exposed to Lua
+
+	// Bar rendering (see renderBars): coalesced onto barTicker.
+	barsDirty    bool      // Lua marked some bar dirty; render those
+	barsStale    bool      // client state changed; render every bar
+	barsRendered time.Time // last full render, for the fallback refresh
+
+	// Heartbeat (see lua_timer.go): set from Lua, kept across /reload.
+	tickInterval time.Duration
+	tickPaused   bool
+	tickCount    int
+	tickLive     bool // Run has started; SetTick may start the ticker
 }
 
 // New creates a new Session. It is passive - no goroutines start here.
@@ -108,13 +171,27 @@ func New(net Network, uiInstance ui.UI, cfg Config) *Session {
 		config:         cfg,
 		historyEntries: make([]input.Submission, 0, 10000),
 		historyLimit:   10000,
+		historyDedupe:  "adjacent",
 		sessionStore:   make(map[string]string),
+		panes:          make(map[string]*paneActivity),
+		tickInterval:   defaultTickInterval,
+		termColors:     detectColors(os.Getenv),
+	}
+
+	if cfg.TCP != nil {
+		net.SetTCPOptions(*cfg.TCP)
 	}
 
 	s.engine = lua.NewEngine(s)
 	s.clientState.ScrollMode = "live"
+	s.clientState.ScrollPercent = 100
+	s.clientState.Connection = "disconnected"
 	s.connectTarget = cfg.ConnectTarget
+	if cfg.Profile != "" {
+		s.sessionStore["profile"] = cfg.Profile
+	}
 	s.loadStore()
+	s.loadSecrets()
 
 	return s
 }
@@ -131,17 +208,21 @@ func (s *Session) Run(ctx context.Context) error {
 		if s.barTicker != nil {
 			s.barTicker.Stop()
 		}
+		s.stopTick()
 		s.timer.Stop()
 		s.net.Disconnect()
 		s.LogStop()
+		s.net.StopTrace()
 		s.ui.Quit()
 	}()
 
 	if err := s.boot(); err != nil {
-		s.ui.Print(text.Red(fmt.Sprintf("[System] Boot Error: %v", err)))
+		s.print(text.Red(fmt.Sprintf("[System] Boot Error: %v", err)))
 	}
 
-	s.barTicker = time.NewTicker(250 * time.Millisecond)
+	s.barTicker = time.NewTicker(barTickInterval)
+	s.tickLive = true
+	s.resetTick()
 
 	eventLoopDone := make(chan struct{})
 	go func() {
@@ -170,7 +251,8 @@ func (s *Session) Run(ctx context.Context) error {
 //	ui.Input()     submitted input, command or verbatim     -> handleSubmission
 //	net.Output()   server lines/prompts/GMCP/disconnect     -> handleNetworkOutput
 //	timerEvents    due Lua timers                           -> engine.OnTimer
-//	barTicker      250ms bar repaint tick                   -> pushBarUpdates
+//	barTicker      250ms bar repaint tick, if anything due  -> onBarTick
+//	tickTicker     script heartbeat (rune.tick)             -> engine.OnTick
 //	asyncResults   continuations of Session's own async work -> run the closure
 //
 // Every lane is drained on this one goroutine, so handlers - and the
@@ -198,8 +280,11 @@ func (s *Session) processEvents(ctx context.Context) {
 			s.handleSubmission(submission)
 		case evt := <-s.timerEvents:
 			s.engine.OnTimer(evt.ID)
-		case <-s.barTicker.C:
-			s.pushBarUpdates()
+		case now := <-s.barTicker.C:
+			s.onBarTick(now)
+		case <-s.tickC():
+			s.tickCount++
+			s.engine.OnTick(s.tickCount)
 		case msg := <-s.ui.Outbound():
 			s.handleUIMessage(msg)
 		}
@@ -214,26 +299,46 @@ func (s *Session) handleNetworkOutput(out network.Output) {
 	case network.OutputPrompt:
 		s.handleServerPrompt(out.Payload)
 	case network.OutputDisconnect:
-		s.Disconnect()
+		s.closeConnection(out.Reason, out.Payload)
 	case network.OutputGMCP:
 		s.engine.OnGMCP(out.Package, out.Payload)
+		s.invalidateBars()
 	case network.OutputGMCPEnabled:
 		s.engine.CallHook("gmcp_enabled")
+	case network.OutputError:
+		s.engine.CallHook("error", out.Payload)
+	case network.OutputNegotiation:
+		if out.Option == network.OptMXP {
+			// Accepted only when rune.config.mxp was on at connect
+			// (TCPClient.SetMXP); a refusal switches it back off.
+			s.mxpActive = out.Payload == "will" || out.Payload == "do"
+			s.mxp = text.MXP{}
+		}
+		s.engine.OnTelnet(out.Payload, out.Option)
 	}
 }
 
 // handleServerLine processes a complete server line.
 func (s *Session) handleServerLine(payload string) {
-	line := text.NewLine(payload)
+	// SanitizeDisplay below drops screen clears unconditionally; the
+	// "clear" hook reports the attempt first, so Lua policy can act on
+	// it (clear the viewport, reset a pane) before the line shows.
+	if text.HasScreenClear(payload) {
+		s.engine.CallHook("clear", payload)
+	}
+	line := text.NewLine(s.convertMXP(payload))
 	if modified, show := s.engine.OnOutput(line); show {
-		// Display egress owns terminal safety: strip everything but
-		// SGR so server clear/cursor sequences cannot wipe UI chrome
-		// (issue #69). Lua hooks above saw the raw line.
-		s.ui.Print(text.SanitizeDisplay(modified))
+		admit, closed := s.throttle.admit(time.Now())
+		s.reportThrottled(closed)
+		if admit {
+			// Display egress owns terminal safety: strip everything
+			// but SGR so server clear/cursor sequences cannot wipe UI
+			// chrome (issue #69). Lua hooks above saw the raw line.
+			s.show(s.sanitize(modified), text.OriginServer)
+		}
 	}
 	// Server line ends the prompt overlay
-	s.lastPrompt = ""
-	s.ui.SetPrompt("")
+	s.clearPrompt()
 }
 
 // handleServerPrompt processes a prompt snapshot. It replaces the overlay
@@ -243,15 +348,47 @@ func (s *Session) handleServerLine(payload string) {
 // GA/EOR prompt superseding another is a repaint and gets the same
 // treatment. Only input submission commits the active prompt
 // (handleSubmission).
+//
+// A gagged prompt (a "prompt" hook returned false, or rune.prompt.gag
+// is on) clears the overlay: the previous snapshot must not linger, and
+// nothing is left for handleSubmission to commit.
 func (s *Session) handleServerPrompt(payload string) {
-	line := text.NewLine(payload)
+	modified, show := s.engine.OnPrompt(text.NewLine(s.convertMXP(payload)))
+	if !show {
+		s.clearPrompt()
+		return
+	}
 	// Sanitized before storing so the overlay and the later
 	// scrollback commit (handleSubmission) both stay chrome-safe.
-	modified := text.SanitizeDisplay(s.engine.OnPrompt(line))
+	modified = s.sanitize(modified)
 	s.lastPrompt = modified
 	s.ui.SetPrompt(modified)
 }
 
+// convertMXP turns a server line's MXP tags into links and strips the
+// rest while MXP is negotiated, so hooks see the text as displayed.
+func (s *Session) convertMXP(payload string) string {
+	if !s.mxpActive {
+		return payload
+	}
+	return s.mxp.Line(payload)
+}
+
+// sanitize is SanitizeDisplay, keeping the send links convertMXP made
+// while MXP is negotiated.
+func (s *Session) sanitize(line string) string {
+	if s.mxpActive {
+		return text.SanitizeMXPDisplay(line)
+	}
+	return text.SanitizeDisplay(line)
+}
+
+// clearPrompt drops the prompt overlay without committing it.
+func (s *Session) clearPrompt() {
+	s.lastPrompt = ""
+	s.ui.SetPrompt("")
+}
+
 // handleSubmission processes an immutable input snapshot. Command submissions
 // retain Rune's normal aliases, delimiters, repeats, and slash commands;
 // verbatim submissions bypass that interpretation and send physical lines as
@@ -259,9 +396,8 @@ func (s *Session) handleServerPrompt(payload string) {
 func (s *Session) handleSubmission(submission input.Submission) {
 	// Commit prompt to scrollback before processing input.
 	if s.lastPrompt != "" {
-		s.ui.Print(s.lastPrompt)
-		s.lastPrompt = ""
-		s.ui.SetPrompt("")
+		s.show(s.lastPrompt, text.OriginServer)
+		s.clearPrompt()
 	}
 	s.addHistorySubmission(submission)
 	if s.net.LocalEchoEnabled() {
@@ -278,7 +414,7 @@ func (s *Session) handleSubmission(submission input.Submission) {
 			// the safe display projection; canonical bytes remain untouched
 			// here for history and the wire.
 			if styled, show := s.engine.OnEcho(line); show {
-				s.ui.Echo(styled)
+				s.echo(styled)
 			}
 		}
 	}
@@ -289,9 +425,13 @@ func (s *Session) handleSubmission(submission input.Submission) {
 // boot loads the VM state.
 func (s *Session) boot() error {
 	if s.storeLoadErr != nil {
-		s.ui.Print(text.Red("[System] " + s.storeLoadErr.Error()))
+		s.print(text.Red("[System] " + s.storeLoadErr.Error()))
 		s.storeLoadErr = nil
 	}
+	if s.secretsLoadErr != nil {
+		s.print(text.Red("[System] " + s.secretsLoadErr.Error()))
+		s.secretsLoadErr = nil
+	}
 	if err := s.initLua(); err != nil {
 		return err
 	}
@@ -303,9 +443,10 @@ func (s *Session) boot() error {
 	// the binds/layout push, leaving a half-dead client. Each failure
 	// is reported individually and the rest of boot proceeds.
 	s.loadUserScript()
+	s.watchScripts()
 	s.engine.CallHook("ready")
 	s.pushBindsAndLayout()
-	s.pushBarUpdates()
+	s.renderBars(true)
 
 	// CLI connect target: routed through the /connect command so a
 	// world name, "host port", or address all resolve identically.
@@ -324,24 +465,20 @@ func (s *Session) initLua() error {
 		return err
 	}
 	s.engine.SetConfigDir(s.config.ConfigDir)
+	// The fresh VM starts from default state; a reload mid-connection
+	// must still see the connection (and its uptime).
+	s.engine.UpdateState(s.clientState)
 	return nil
 }
 
-// loadCoreScripts loads embedded core Lua scripts in sorted order.
+// loadCoreScripts loads embedded core Lua scripts in dependency order
+// (see lua.CoreOrder).
 func (s *Session) loadCoreScripts() error {
-	entries, err := fs.ReadDir(s.config.CoreScripts, "core")
+	files, err := lua.CoreOrder(s.config.CoreScripts, "core")
 	if err != nil {
 		return fmt.Errorf("reading core scripts: %w", err)
 	}
 
-	var files []string
-	for _, e := range entries {
-		if !e.IsDir() {
-			files = append(files, e.Name())
-		}
-	}
-	sort.Strings(files)
-
 	for _, file := range files {
 		content, err := s.config.CoreScripts.ReadFile("core/" + file)
 		if err != nil {
@@ -370,8 +507,8 @@ func (s *Session) loadUserScript() {
 // directly (not via the "error" hook) so it is visible even when the
 // failed script broke the hook system.
 func (s *Session) reportScriptError(name string, err error) {
-	s.ui.Print(text.Red(fmt.Sprintf("[Script Error] %s: %v", name, err)))
-	s.ui.Print(text.Red("  the rest of the client loaded normally - fix the script and /reload"))
+	s.print(text.Red(fmt.Sprintf("[Script Error] %s: %v", name, err)))
+	s.print(text.Red("  the rest of the client loaded normally - fix the script and /reload"))
 }
 
 // handleKeyBind executes a Lua key binding.
@@ -380,14 +517,45 @@ func (s *Session) handleKeyBind(key string) {
 	s.engine.HandleKeyBind(key)
 }
 
-// pushBarUpdates renders all Lua bars and pushes to UI.
-func (s *Session) pushBarUpdates() {
+// Bar rendering is coalesced: nothing calls into Lua until a bar is
+// due, and then at most once per barTickInterval however many changes
+// piled up. barRefreshInterval is the fallback full render for bars
+// that show the time rather than any state a change would flag.
+const (
+	barTickInterval    = 250 * time.Millisecond
+	barRefreshInterval = time.Second
+)
+
+// invalidateBars schedules a full render on the next bar tick, for
+// changes any bar may show: client state, width, GMCP data.
+func (s *Session) invalidateBars() {
+	s.barsStale = true
+}
+
+// onBarTick renders the bars if anything is due.
+func (s *Session) onBarTick(now time.Time) {
+	s.flushThrottle(now)
+	s.checkScripts(now)
+	full := s.barsStale || now.Sub(s.barsRendered) >= barRefreshInterval
+	if full || s.barsDirty {
+		s.renderBars(full)
+	}
+}
+
+// renderBars renders the Lua bars - every one when full, otherwise
+// only the dirty ones - and pushes them to the UI.
+func (s *Session) renderBars(full bool) {
 	width := s.clientState.Width
 	if width <= 0 {
 		width = 80
 	}
 
-	content := s.engine.RenderBars(width)
+	s.barsDirty = false
+	if full {
+		s.barsStale = false
+		s.barsRendered = time.Now()
+	}
+	content := s.engine.RenderBars(width, full)
 	if content != nil {
 		s.ui.UpdateBars(content)
 	}
@@ -418,18 +586,36 @@ func (s *Session) handleUIMessage(msg ui.UIEvent) {
 		s.clientState.Height = m.Height
 		s.net.SetWindowSize(m.Width, m.Height)
 		s.engine.UpdateState(s.clientState)
-		s.pushBarUpdates()
+		s.invalidateBars()
 	case ui.ScrollStateChangedMsg:
 		s.clientState.ScrollMode = m.Mode
 		s.clientState.ScrollLines = m.NewLines
+		s.clientState.ScrollPercent = m.Percent
+		s.engine.UpdateState(s.clientState)
+		s.invalidateBars()
+	case ui.InputDroppedMsg:
+		s.clientState.InputDropped = m.Total
 		s.engine.UpdateState(s.clientState)
-		s.pushBarUpdates()
+		s.invalidateBars()
 	case ui.PickerSelectMsg:
 		s.handlePickerResult(m.CallbackID, m.Value, m.Accepted)
 	case ui.InputChangedMsg:
 		s.currentInput = m.Text
 		s.currentCursor = input.RuneCursorToByte(m.Text, m.Cursor)
 		s.engine.CallHook("input_changed", m.Text)
+	case ui.LinkClickedMsg:
+		url, source := m.URL, "text"
+		if m.Explicit {
+			source = "hyperlink"
+			if cmd, ok := strings.CutPrefix(m.URL, text.SendLinkScheme); ok {
+				url, source = cmd, "send"
+			}
+		}
+		s.engine.CallHook("link_clicked", url, source)
+	case ui.CopySelectionMsg:
+		s.engine.CallHook("copy", m.Text)
+	case ui.MultilinePasteMsg:
+		s.engine.CallHook("paste", m.Text)
 	case ui.CursorMovedMsg:
 		s.currentCursor = input.RuneCursorToByte(s.currentInput, m.Cursor)
 		// No Lua hook - cursor-only changes don't need Lua processing
@@ -1,8 +1,11 @@
 package session
 
 import (
+	"fmt"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/mmcdole/rune/input"
 	"github.com/mmcdole/rune/lua"
@@ -109,19 +112,104 @@ func TestPromptCommitOrdering(t *testing.T) {
 	}
 }
 
+// A prompt gagged by a Lua prompt handler clears the overlay and is
+// never committed to scrollback.
+func TestGaggedPromptClearsOverlay(t *testing.T) {
+	s, net, uiMock := newTestSession(t)
+	net.connected = true
+
+	serverPrompt(s, "HP:100>")
+	if err := s.engine.DoString("gag", `rune.prompt.gag()`); err != nil {
+		t.Fatal(err)
+	}
+	serverPrompt(s, "HP:90>")
+	if prompts := uiMock.drainPrompts(); len(prompts) == 0 || prompts[len(prompts)-1] != "" {
+		t.Fatalf("expected gagged prompt to clear the overlay, got %v", prompts)
+	}
+
+	userInput(s, "north")
+	if printed := uiMock.drainPrinted(); contains(printed, "HP:") {
+		t.Errorf("gagged prompt committed on input: %v", printed)
+	}
+}
+
 func TestDisconnectEventUpdatesStateAndNotifiesLua(t *testing.T) {
 	s, net, uiMock := newTestSession(t)
 	net.connected = true
 	s.clientState.Connected = true
 
-	s.handleNetworkOutput(network.Output{Kind: network.OutputDisconnect})
+	s.handleNetworkOutput(network.Output{
+		Kind:    network.OutputDisconnect,
+		Reason:  network.DisconnectReset,
+		Payload: "read: connection reset by peer",
+	})
 
 	if s.clientState.Connected {
 		t.Error("clientState still connected after disconnect")
 	}
-	if printed := uiMock.drainPrinted(); !contains(printed, "Disconnected") {
-		t.Errorf("expected disconnect notice, got %v", printed)
+	if printed := uiMock.drainPrinted(); !contains(printed, "Disconnected (connection reset: read: connection reset by peer)") {
+		t.Errorf("expected disconnect notice with the reason, got %v", printed)
 	}
+	if err := s.engine.DoString("check", `assert(rune.state.disconnect_reason == "reset")`); err != nil {
+		t.Fatal(err)
+	}
+
+	// A requested disconnect says so, without a label.
+	s.Disconnect()
+	if err := s.engine.DoString("check", `assert(rune.state.disconnect_reason == "user")`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rune.state follows a connection through its phases: connecting
+// while the dial is in flight, then connected with the address, then
+// disconnected.
+func TestConnectionStateVisibleToLua(t *testing.T) {
+	s, _, _ := newTestSession(t)
+
+	check := func(want string) {
+		t.Helper()
+		if err := s.engine.DoString("check", want); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check(`assert(rune.state.connection == "disconnected" and not rune.state.connected)`)
+
+	s.Connect("mud.example.com:4000")
+	check(`assert(rune.state.connection == "connecting" and not rune.state.connected)`)
+	drainConnect(t, s)
+	check(`assert(rune.state.connection == "connected" and rune.state.connected)`)
+	check(`assert(rune.state.address == "mud.example.com:4000")`)
+
+	s.Disconnect()
+	check(`assert(rune.state.connection == "disconnected" and rune.state.address == "")`)
+}
+
+// TestUptimeSurvivesReload verifies rune.state carries the connect
+// time through a reload mid-connection, and clears it on disconnect.
+func TestUptimeSurvivesReload(t *testing.T) {
+	s, _, _ := newTestSession(t)
+
+	check := func(want string) {
+		t.Helper()
+		if err := s.engine.DoString("check", want); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check(`assert(rune.state.uptime == 0 and rune.state.uptime_text == "")`)
+
+	s.Connect("mud.example.com:4000")
+	drainConnect(t, s)
+	s.clientState.ConnectedAt = s.clientState.ConnectedAt.Add(-(time.Hour + 2*time.Minute + 3*time.Second))
+	s.Reload()
+	cb := <-s.asyncResults // reload is deferred
+	cb()
+	check(`assert(rune.state.connected, "connection lost across reload")`)
+	check(`assert(rune.state.uptime >= 3723 and rune.state.uptime < 3730, rune.state.uptime)`)
+	check(`assert(rune.state.uptime_text:match("^01:02:0%d$"), rune.state.uptime_text)`)
+
+	s.Disconnect()
+	check(`assert(rune.state.connected_at == 0 and rune.state.uptime_text == "")`)
 }
 
 // Reload must be deferred through the event queue - it tears down the
@@ -221,6 +309,54 @@ func TestHistoryPreservesModeAndDedupesWholeSubmission(t *testing.T) {
 	}
 }
 
+// TestHistoryDedupeModes pins the three dedupe policies, and that
+// changing the policy reshapes the entries already kept.
+func TestHistoryDedupeModes(t *testing.T) {
+	cmds := []string{"a", "b", "a", "a", "c", "b"}
+	for _, tc := range []struct {
+		dedupe string
+		want   string
+	}{
+		{"adjacent", "a b a c b"},
+		{"global", "a c b"},
+		{"none", "a b a a c b"},
+	} {
+		s, _, _ := newTestSession(t)
+		s.SetHistoryPolicy(10, tc.dedupe)
+		for _, cmd := range cmds {
+			s.AddToHistory(cmd)
+		}
+		if got := strings.Join(s.GetHistory(), " "); got != tc.want {
+			t.Errorf("%s: history = %q, want %q", tc.dedupe, got, tc.want)
+		}
+	}
+
+	s, _, _ := newTestSession(t)
+	s.SetHistoryPolicy(10, "none")
+	for _, cmd := range cmds {
+		s.AddToHistory(cmd)
+	}
+	s.SetHistoryPolicy(10, "global")
+	if got := strings.Join(s.GetHistory(), " "); got != "a c b" {
+		t.Errorf("switching to global: history = %q, want the newest copies", got)
+	}
+	s.SetHistoryPolicy(2, "global")
+	if got := strings.Join(s.GetHistory(), " "); got != "c b" {
+		t.Errorf("shrinking: history = %q, want the two newest", got)
+	}
+
+	// Boot pushes the policy again; a history with nothing to drop
+	// must come through whole.
+	s, _, _ = newTestSession(t)
+	for _, cmd := range []string{"a", "b", "c"} {
+		s.AddToHistory(cmd)
+	}
+	s.SetHistoryPolicy(100, "global")
+	if got := strings.Join(s.GetHistory(), " "); got != "a b c" {
+		t.Errorf("global without duplicates: history = %q, want a b c", got)
+	}
+}
+
 func TestSetInputSubmissionForwardsExplicitMode(t *testing.T) {
 	s, _, uiMock := newTestSession(t)
 	want := input.Verbatim("café;still data")
@@ -334,3 +470,176 @@ func TestSubmissionEchoVisualizesControlsWithoutChangingWireData(t *testing.T) {
 		}
 	}
 }
+
+// TestPaneUnreadSurvivesReload verifies the session's pane mirror:
+// writes count only while a pane is hidden, showing it clears the
+// count, and the counts outlive /reload along with the panes.
+func TestPaneUnreadSurvivesReload(t *testing.T) {
+	s, _, _ := newTestSession(t)
+
+	if err := s.engine.DoString("panes", `
+		rune.pane.write("chat", "one")
+		rune.pane.write("chat", "two")
+		rune.pane.create("tells")
+		rune.pane.show("tells")
+		rune.pane.write("tells", "hi")
+		rune.pane.write("quiet", "x")
+		rune.pane.show("quiet")
+	`); err != nil {
+		t.Fatalf("script failed: %v", err)
+	}
+	if got := s.PaneUnread(); len(got) != 1 || got["chat"] != 2 {
+		t.Fatalf("PaneUnread() = %v, want chat=2 only", got)
+	}
+
+	if err := s.boot(); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if err := s.engine.DoString("check", `
+		assert(rune.pane.unread("chat") == 2)
+		assert(rune.pane.unread("tells") == 0)
+		rune.pane.toggle("chat")
+		assert(next(rune.pane.unread()) == nil)
+	`); err != nil {
+		t.Fatalf("after reload: %v", err)
+	}
+}
+
+// TestBarTickRendersOnlyWhenDue verifies the bar tick leaves Lua alone
+// until something is due: a script marking a bar dirty, a state
+// change, or the fallback refresh for clocks.
+func TestBarTickRendersOnlyWhenDue(t *testing.T) {
+	s, _, uiMock := newTestSession(t)
+	uiMock.drainBarPushes()
+
+	now := s.barsRendered
+	s.onBarTick(now.Add(barTickInterval))
+	if n := uiMock.drainBarPushes(); n != 0 {
+		t.Fatalf("idle tick pushed bars %d times", n)
+	}
+
+	if err := s.engine.DoString("dirty", `rune.ui.bar_dirty("status")`); err != nil {
+		t.Fatal(err)
+	}
+	s.onBarTick(now.Add(2 * barTickInterval))
+	s.onBarTick(now.Add(3 * barTickInterval))
+	if n := uiMock.drainBarPushes(); n != 1 {
+		t.Fatalf("dirty bar pushed %d times, want once", n)
+	}
+
+	s.handleUIMessage(ui.ScrollStateChangedMsg{Mode: "scrolled", NewLines: 3})
+	if n := uiMock.drainBarPushes(); n != 0 {
+		t.Fatalf("state change rendered before the tick (%d pushes)", n)
+	}
+	s.onBarTick(now.Add(3 * barTickInterval))
+	if n := uiMock.drainBarPushes(); n != 1 {
+		t.Fatalf("state change pushed %d times, want once", n)
+	}
+
+	s.onBarTick(s.barsRendered.Add(barRefreshInterval))
+	if n := uiMock.drainBarPushes(); n != 1 {
+		t.Fatalf("fallback refresh pushed %d times, want once", n)
+	}
+}
+
+// TestScrollbackLinesMirrorOutput verifies rune.scrollback.lines reads
+// what was displayed - triggers' rewrites, not gagged lines, echoes,
+// and script prints split into lines, each tagged with its origin - and
+// that the mirror keeps only the newest maxRecentLines.
+func TestScrollbackLinesMirrorOutput(t *testing.T) {
+	s, net, _ := newTestSession(t)
+	net.connected = true
+
+	if err := s.engine.DoString("setup", `
+		rune.trigger.exact("secret", function(m, ctx) ctx.line:gag() end)
+		rune.trigger.exact("old", function(m, ctx) ctx.line:replace("\27[31mnew\27[0m") end)
+	`); err != nil {
+		t.Fatal(err)
+	}
+	serverLine(s, "secret")
+	serverLine(s, "old")
+	userInput(s, "look")
+	if err := s.engine.DoString("print", `rune.echo("one\ntwo")`); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, line := range s.RecentLines(4) {
+		got = append(got, line.Origin.String()+" "+line.Raw)
+	}
+	want := []string{"server \x1b[31mnew\x1b[0m", "echo \x1b[32m> look\x1b[0m", "script one", "script two"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("RecentLines(4) = %q, want %q", got, want)
+	}
+	if err := s.engine.DoString("lua", `
+		local clean = rune.scrollback.lines(3, { clean = true })
+		assert(#clean == 3 and clean[1] == "> look", clean[1])
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < maxRecentLines+5; i++ {
+		serverLine(s, fmt.Sprintf("line %d", i))
+	}
+	all := s.RecentLines(maxRecentLines + 100)
+	if len(all) != maxRecentLines || all[0].Raw != "line 5" || all[len(all)-1].Raw != fmt.Sprintf("line %d", maxRecentLines+4) {
+		t.Fatalf("full mirror holds %d lines, %q .. %q", len(all), all[0].Raw, all[len(all)-1].Raw)
+	}
+}
+
+// TestMXPLinesBecomeSendLinks pins the MXP pipeline: tags pass through
+// untouched until MXP is negotiated, then become send links a click
+// turns into a command, and a refusal switches conversion back off.
+func TestMXPLinesBecomeSendLinks(t *testing.T) {
+	s, net, uiMock := newTestSession(t)
+	net.connected = true
+	const line = "\x1b[1zExits: <send>north</send>"
+
+	serverLine(s, line)
+	if printed := uiMock.drainPrinted(); !contains(printed, "<send>north</send>") {
+		t.Fatalf("before negotiation: printed %q, want the tags untouched", printed)
+	}
+
+	s.handleNetworkOutput(network.Output{Kind: network.OutputNegotiation, Payload: "do", Option: network.OptMXP})
+	serverLine(s, line)
+	link := "\x1b]8;;send:north\x1b\\"
+	if printed := uiMock.drainPrinted(); !contains(printed, "Exits: "+link) {
+		t.Fatalf("after negotiation: printed %q, want a send link", printed)
+	}
+
+	s.handleUIMessage(ui.LinkClickedMsg{URL: "send:north", Explicit: true})
+	if sent := net.drainSent(); !reflect.DeepEqual(sent, []string{"north"}) {
+		t.Fatalf("click sent %q, want [north]", sent)
+	}
+
+	s.handleNetworkOutput(network.Output{Kind: network.OutputNegotiation, Payload: "dont", Option: network.OptMXP})
+	serverLine(s, line)
+	if printed := uiMock.drainPrinted(); contains(printed, link) {
+		t.Fatalf("after refusal: printed %q, want no send link", printed)
+	}
+}
+
+// TestRefreshBarsRendersNow verifies an explicit rune.ui.refresh_bars
+// renders at once, covering any dirty bar, rather than waiting for the
+// tick the way rune.ui.bar_dirty does.
+func TestRefreshBarsRendersNow(t *testing.T) {
+	s, _, uiMock := newTestSession(t)
+	uiMock.drainBarPushes()
+
+	if err := s.engine.DoString("dirty", `rune.ui.bar_dirty("status")`); err != nil {
+		t.Fatal(err)
+	}
+	if n := uiMock.drainBarPushes(); n != 0 {
+		t.Fatalf("bar_dirty rendered before the tick (%d pushes)", n)
+	}
+	if err := s.engine.DoString("refresh", `rune.ui.refresh_bars()`); err != nil {
+		t.Fatal(err)
+	}
+	if n := uiMock.drainBarPushes(); n != 1 {
+		t.Fatalf("refresh_bars pushed %d times, want once", n)
+	}
+	s.onBarTick(s.barsRendered.Add(barTickInterval))
+	if n := uiMock.drainBarPushes(); n != 0 {
+		t.Fatalf("tick after refresh pushed %d times, want nothing left due", n)
+	}
+}
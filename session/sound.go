@@ -0,0 +1,116 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// One sound plays at a time, and a new one must wait soundGap after
+// the last started: a trigger matching a burst of lines plays once,
+// not as a pile of overlapping players. Excess calls are dropped, like
+// notifications.
+const (
+	soundGap     = 250 * time.Millisecond
+	soundTimeout = 30 * time.Second
+)
+
+var (
+	errNoPlayer        = errors.New("no audio player found (install pulseaudio-utils or alsa-utils)")
+	errNoMacPlayer     = errors.New("no audio player found (afplay)")
+	errNoWindowsPlayer = errors.New("no audio player found (powershell)")
+	errSoundBusy       = errors.New("a sound is already playing; dropped")
+)
+
+// PlaySound implements lua.Host. A relative path resolves against the
+// config directory. The player runs on its own goroutine; a failure to
+// run it comes back through asyncResults to the "error" hook.
+func (s *Session) PlaySound(path string) error {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.config.ConfigDir, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	p, err := findPlayer(runtime.GOOS, path, exec.LookPath)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if s.soundPlaying || now.Sub(s.soundStarted) < soundGap {
+		return errSoundBusy
+	}
+	s.soundPlaying = true
+	s.soundStarted = now
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), soundTimeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, p.path, p.args...)
+		if p.env != nil {
+			cmd.Env = append(os.Environ(), p.env...)
+		}
+		out, err := cmd.CombinedOutput()
+		s.asyncResults <- func() {
+			s.soundPlaying = false
+			if err != nil {
+				msg := "sound: " + err.Error()
+				if len(out) > 0 {
+					msg += ": " + string(out)
+				}
+				s.engine.CallHook("error", msg)
+			}
+		}
+	}()
+	return nil
+}
+
+// findPlayer picks the platform's audio player, looked up with
+// lookPath. The file travels as an argument (or, for PowerShell, an
+// environment variable) - never spliced into a script. It reuses the
+// notifier shape: a command, its arguments, extra environment.
+func findPlayer(goos, path string, lookPath func(string) (string, error)) (notifier, error) {
+	switch goos {
+	case "darwin":
+		bin, err := lookPath("afplay")
+		if err != nil {
+			return notifier{}, errNoMacPlayer
+		}
+		return notifier{path: bin, args: []string{path}}, nil
+	case "windows":
+		bin, err := lookPath("powershell")
+		if err != nil {
+			return notifier{}, errNoWindowsPlayer
+		}
+		return notifier{
+			path: bin,
+			args: []string{"-NoProfile", "-NonInteractive", "-Command", windowsSoundScript},
+			env:  []string{"RUNE_SOUND_PATH=" + path},
+		}, nil
+	default:
+		// paplay handles most formats through PulseAudio/PipeWire;
+		// aplay (ALSA) is the fallback, WAV only.
+		for _, name := range []string{"paplay", "aplay"} {
+			if bin, err := lookPath(name); err == nil {
+				if name == "aplay" {
+					return notifier{path: bin, args: []string{"-q", "--", path}}, nil
+				}
+				return notifier{path: bin, args: []string{"--", path}}, nil
+			}
+		}
+		return notifier{}, errNoPlayer
+	}
+}
+
+// windowsSoundScript plays a WAV file synchronously, so the process
+// lives as long as the sound and the busy flag clears when it ends.
+const windowsSoundScript = `(New-Object System.Media.SoundPlayer $env:RUNE_SOUND_PATH).PlaySync()`
@@ -0,0 +1,49 @@
+package session
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestFindPlayer(t *testing.T) {
+	have := func(names ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			if slices.Contains(names, name) {
+				return "/usr/bin/" + name, nil
+			}
+			return "", errors.New("not found")
+		}
+	}
+
+	p, err := findPlayer("linux", "/snd/tell.wav", have("paplay", "aplay"))
+	if err != nil || p.path != "/usr/bin/paplay" || !slices.Equal(p.args, []string{"--", "/snd/tell.wav"}) {
+		t.Errorf("linux should prefer paplay, got %+v, %v", p, err)
+	}
+	p, err = findPlayer("linux", "/snd/tell.wav", have("aplay"))
+	if err != nil || p.path != "/usr/bin/aplay" {
+		t.Errorf("linux aplay fallback: got %+v, %v", p, err)
+	}
+
+	// PowerShell gets the path through the environment, never the script.
+	p, err = findPlayer("windows", `C:\snd\'; rm -r ~.wav`, have("powershell"))
+	if err != nil || !slices.Contains(p.env, `RUNE_SOUND_PATH=C:\snd\'; rm -r ~.wav`) {
+		t.Errorf("windows: got %+v, %v", p, err)
+	}
+
+	for _, goos := range []string{"linux", "darwin", "windows"} {
+		if _, err := findPlayer(goos, "/snd/tell.wav", have()); err == nil {
+			t.Errorf("%s: expected an error with no player installed", goos)
+		}
+	}
+}
+
+func TestPlaySoundMissingFile(t *testing.T) {
+	s, _, _ := newTestSession(t)
+	if err := s.PlaySound("no-such-sound.wav"); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+	if s.soundPlaying {
+		t.Fatal("a failed call marked a sound as playing")
+	}
+}
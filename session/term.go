@@ -0,0 +1,38 @@
+package session
+
+import (
+	"strings"
+
+	"github.com/mmcdole/rune/lua"
+)
+
+// detectColors reads how many colors the terminal shows from the
+// environment, the way terminals advertise it: $COLORTERM for
+// truecolor, then $TERM. A dumb or unset $TERM gets none.
+func detectColors(getenv func(string) string) int {
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return 1 << 24
+	}
+	term := strings.ToLower(getenv("TERM"))
+	switch {
+	case term == "" || term == "dumb":
+		return 0
+	case strings.Contains(term, "truecolor"), strings.Contains(term, "direct"):
+		return 1 << 24
+	case strings.Contains(term, "256color"):
+		return 256
+	}
+	return 16
+}
+
+// Terminal implements lua.Host. Colors are detected once at startup;
+// the size is the latest the UI reported.
+func (s *Session) Terminal() lua.Terminal {
+	return lua.Terminal{
+		Colors: s.termColors,
+		Mouse:  s.config.Mouse,
+		Width:  s.clientState.Width,
+		Height: s.clientState.Height,
+	}
+}
@@ -0,0 +1,42 @@
+package session
+
+import "testing"
+
+func TestDetectColors(t *testing.T) {
+	tests := []struct {
+		colorterm, term string
+		want            int
+	}{
+		{"truecolor", "xterm-256color", 1 << 24},
+		{"24bit", "", 1 << 24},
+		{"", "xterm-direct", 1 << 24},
+		{"", "screen-256color", 256},
+		{"", "xterm", 16},
+		{"", "linux", 16},
+		{"", "dumb", 0},
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		env := map[string]string{"COLORTERM": tt.colorterm, "TERM": tt.term}
+		if got := detectColors(func(k string) string { return env[k] }); got != tt.want {
+			t.Errorf("COLORTERM=%q TERM=%q: colors = %d, want %d", tt.colorterm, tt.term, got, tt.want)
+		}
+	}
+}
+
+// TestTermReflectsSession verifies rune.term reads the detected colors,
+// the mouse setting, and the size the UI last reported.
+func TestTermReflectsSession(t *testing.T) {
+	s, _, _ := newTestSession(t)
+	s.termColors = 256
+	s.config.Mouse = true
+	s.clientState.Width, s.clientState.Height = 120, 40
+
+	if err := s.engine.DoString("term", `
+		assert(rune.term.colors == 256 and rune.term.mouse == true)
+		assert(rune.term.width == 120 and rune.term.height == 40)
+		assert(not pcall(function() rune.term.colors = 16 end))
+	`); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,80 @@
+package session
+
+import (
+	"strconv"
+	"time"
+)
+
+// outputThrottle caps how many server lines reach the UI each second
+// (rune.ui.throttle). Rendering a flood - spam spells, mass channel
+// traffic - is CPU-bound even when TCP flow control keeps the socket
+// sane, so past the cap lines are still run through Lua (triggers,
+// logging) but not printed. Each second that suppressed lines is
+// reported to Lua as a "throttled" hook, whose core handler prints the
+// summary. Windows are fixed one-second spans starting at the first
+// line after a quiet one. Lives on the session, so it survives /reload.
+type outputThrottle struct {
+	rate        int // lines per second; 0 = unlimited
+	windowStart time.Time
+	shown       int
+	suppressed  int
+}
+
+const throttleWindow = time.Second
+
+// admit reports whether a line arriving at now may be printed, and
+// how many lines the window it closes suppressed (to report first).
+func (t *outputThrottle) admit(now time.Time) (show bool, closed int) {
+	if t.rate <= 0 {
+		return true, 0
+	}
+	closed = t.expire(now)
+	if t.windowStart.IsZero() {
+		t.windowStart = now
+	}
+	if t.shown < t.rate {
+		t.shown++
+		return true, closed
+	}
+	t.suppressed++
+	return false, closed
+}
+
+// expire ends the current window if it is over, returning how many
+// lines it suppressed.
+func (t *outputThrottle) expire(now time.Time) int {
+	if t.windowStart.IsZero() || now.Sub(t.windowStart) < throttleWindow {
+		return 0
+	}
+	n := t.suppressed
+	t.windowStart = time.Time{}
+	t.shown = 0
+	t.suppressed = 0
+	return n
+}
+
+// SetOutputRate implements lua.Host. Changing the cap starts a fresh
+// window; lines suppressed so far are still reported.
+func (s *Session) SetOutputRate(linesPerSecond int) {
+	n := s.throttle.suppressed
+	s.throttle = outputThrottle{rate: max(linesPerSecond, 0)}
+	s.reportThrottled(n)
+}
+
+// OutputRate implements lua.Host.
+func (s *Session) OutputRate() int {
+	return s.throttle.rate
+}
+
+// flushThrottle reports a finished window's suppressed lines once the
+// flood stops, rather than waiting for the next server line. Called
+// on the bar tick.
+func (s *Session) flushThrottle(now time.Time) {
+	s.reportThrottled(s.throttle.expire(now))
+}
+
+func (s *Session) reportThrottled(n int) {
+	if n > 0 {
+		s.engine.CallHook("throttled", strconv.Itoa(n))
+	}
+}
@@ -0,0 +1,64 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestThrottleCapsServerLines verifies rune.ui.throttle holds server
+// lines past the cap off screen while triggers still see them, and
+// that the summary follows once the second is up.
+func TestThrottleCapsServerLines(t *testing.T) {
+	s, _, uiMock := newTestSession(t)
+	if err := s.engine.DoString("setup", `
+		seen = 0
+		rune.trigger.regex("^spam", function() seen = seen + 1 end)
+		assert(rune.ui.throttle(2) == 2)
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		serverLine(s, fmt.Sprintf("spam %d", i))
+	}
+	printed := uiMock.drainPrinted()
+	if !contains(printed, "spam 2") || contains(printed, "spam 3") {
+		t.Fatalf("printed %q, want only the first two lines", printed)
+	}
+	if err := s.engine.DoString("check", `assert(seen == 5, seen)`); err != nil {
+		t.Fatalf("triggers missed suppressed lines: %v", err)
+	}
+
+	// The flood stops: the bar tick reports the window once it ends.
+	s.onBarTick(time.Now())
+	if printed := uiMock.drainPrinted(); contains(printed, "suppressed") {
+		t.Fatalf("summary before the window ended: %q", printed)
+	}
+	s.throttle.windowStart = s.throttle.windowStart.Add(-throttleWindow)
+	s.onBarTick(time.Now())
+	if printed := uiMock.drainPrinted(); !contains(printed, "(3 lines suppressed)") {
+		t.Fatalf("summary = %q", printed)
+	}
+
+	// Without the summary, and with the cap lifted, nothing is held.
+	if err := s.engine.DoString("quiet", `rune.ui.throttle(1, { summary = false })`); err != nil {
+		t.Fatal(err)
+	}
+	serverLine(s, "one")
+	serverLine(s, "two")
+	s.throttle.windowStart = s.throttle.windowStart.Add(-throttleWindow)
+	serverLine(s, "three")
+	if printed := uiMock.drainPrinted(); contains(printed, "two") || contains(printed, "suppressed") || !contains(printed, "three") {
+		t.Fatalf("printed %q", printed)
+	}
+	if err := s.engine.DoString("off", `assert(rune.ui.throttle(false) == 0)`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		serverLine(s, "free")
+	}
+	if printed := uiMock.drainPrinted(); len(printed) != 3 {
+		t.Fatalf("uncapped printed %q", printed)
+	}
+}
@@ -210,15 +210,38 @@ func (m *mockUI) UpdateBinds(keys map[string]bool)            {}
 func (m *mockUI) UpdateLayout(top, bottom []ui.LayoutEntry)   {}
 func (m *mockUI) ShowPicker(opts ui.ShowPickerMsg)            {}
 func (m *mockUI) SetClipboard(text string)                    {}
+func (m *mockUI) EnterCopyMode()                              {}
+func (m *mockUI) JumpToInput()                                {}
+func (m *mockUI) ClearScreen()                                {}
+func (m *mockUI) ClearScrollback()                            {}
+func (m *mockUI) SetDedupe(on bool)                           {}
+func (m *mockUI) SetFlushInterval(d time.Duration)            {}
+func (m *mockUI) GrepScrollback(req ui.GrepScrollbackMsg)     {}
+func (m *mockUI) SetWrap(on bool)                             {}
+func (m *mockUI) SetSplit(on bool)                            {}
+func (m *mockUI) SetTheme(theme ui.Theme)                     {}
+func (m *mockUI) Bell(audible, visual bool)                   {}
 func (m *mockUI) CreatePane(name string)                      {}
 func (m *mockUI) WritePane(name, text string)                 {}
 func (m *mockUI) TogglePane(name string)                      {}
 func (m *mockUI) SetPaneVisible(name string, visible bool)    {}
 func (m *mockUI) ClearPane(name string)                       {}
+func (m *mockUI) ResizePane(name string, height int)          {}
+func (m *mockUI) GrowPane(name string, rows int)              {}
+func (m *mockUI) LimitPane(name string, lines, bytes int)     {}
+func (m *mockUI) FocusPane(name string)                       {}
 func (m *mockUI) InputSetCursor(pos int)                      {}
+func (m *mockUI) InputSetPrompt(prompt string)                {}
+func (m *mockUI) InputSetPlaceholder(text string)             {}
+func (m *mockUI) InputSetMask(on bool)                        {}
+func (m *mockUI) InputSetGhost(text string)                   {}
+func (m *mockUI) InputSetWordBreak(chars string)              {}
+func (m *mockUI) InputSetQueue(limit int)                     {}
 func (m *mockUI) OpenEditor(initial string) (string, bool)    { return "", false }
 func (m *mockUI) PaneScrollUp(name string, lines int)         {}
 func (m *mockUI) PaneScrollDown(name string, lines int)       {}
+func (m *mockUI) PaneScrollColumns(name string, cols int)     {}
+func (m *mockUI) PaneScrollPages(name string, pages float64)  {}
 func (m *mockUI) PaneScrollToTop(name string)                 {}
 func (m *mockUI) PaneScrollToBottom(name string)              {}
 
@@ -0,0 +1,61 @@
+package text
+
+import (
+	"regexp"
+	"strings"
+)
+
+// sgrPattern matches SGR (color/attribute) sequences - the only
+// escapes Highlight has to work around.
+var sgrPattern = regexp.MustCompile(`\x1b\[[0-9;:]*m`)
+
+// Highlight wraps spans of a line in the on escape sequence. Spans are
+// byte ranges of the line's clean text (what patterns match against),
+// sorted and non-overlapping, as regexp FindAllStringIndex returns
+// them; they are mapped back to raw so the line's own colors survive
+// around them. Inside a span the highlight is re-asserted after any
+// SGR the server sent, and after it the server's styling in effect at
+// that point is restored.
+func Highlight(raw string, spans [][2]int, on string) string {
+	if len(spans) == 0 {
+		return raw
+	}
+	var offsets []int
+	stripANSI(raw, &offsets)
+
+	var b strings.Builder
+	last := 0
+	for _, sp := range spans {
+		if sp[0] < 0 || sp[0] >= sp[1] || sp[1] > len(offsets) {
+			continue
+		}
+		start, end := offsets[sp[0]], offsets[sp[1]-1]+1
+		if start < last {
+			continue
+		}
+		b.WriteString(raw[last:start])
+		b.WriteString(on)
+		b.WriteString(sgrPattern.ReplaceAllStringFunc(raw[start:end], func(m string) string {
+			return m + on
+		}))
+		b.WriteString("\x1b[0m")
+		b.WriteString(activeSGR(raw[:end]))
+		last = end
+	}
+	b.WriteString(raw[last:])
+	return b.String()
+}
+
+// activeSGR returns the SGR sequences of s since its last reset:
+// replaying them re-establishes the styling in effect at the end of s.
+func activeSGR(s string) string {
+	var b strings.Builder
+	for _, m := range sgrPattern.FindAllString(s, -1) {
+		if m == "\x1b[0m" || m == "\x1b[m" {
+			b.Reset()
+			continue
+		}
+		b.WriteString(m)
+	}
+	return b.String()
+}
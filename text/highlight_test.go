@@ -0,0 +1,33 @@
+package text
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	const on = "\x1b[33m"
+	cases := []struct {
+		name  string
+		raw   string
+		spans [][2]int
+		want  string
+	}{
+		{"plain", "a goblin here", [][2]int{{2, 8}}, "a \x1b[33mgoblin\x1b[0m here"},
+		{"no spans", "text", nil, "text"},
+		{"two spans", "ab ab", [][2]int{{0, 2}, {3, 5}}, "\x1b[33mab\x1b[0m \x1b[33mab\x1b[0m"},
+		{"restores server color", "\x1b[32mthe goblin dies\x1b[0m", [][2]int{{4, 10}},
+			"\x1b[32mthe \x1b[33mgoblin\x1b[0m\x1b[32m dies\x1b[0m"},
+		{"reasserts inside span", "go\x1b[31mblin\x1b[0m", [][2]int{{0, 6}},
+			"\x1b[33mgo\x1b[31m\x1b[33mblin\x1b[0m\x1b[31m\x1b[0m"},
+		{"multibyte", "→ östlich", [][2]int{{4, 12}}, "→ \x1b[33möstlich\x1b[0m"},
+		{"out of range ignored", "abc", [][2]int{{1, 9}}, "abc"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Highlight(tc.raw, tc.spans, on); got != tc.want {
+				t.Errorf("Highlight(%q) = %q, want %q", tc.raw, got, tc.want)
+			}
+			if got, want := StripANSI(Highlight(tc.raw, tc.spans, on)), StripANSI(tc.raw); got != want {
+				t.Errorf("clean text changed: %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -2,17 +2,47 @@ package text
 
 import "strings"
 
-// Line represents a server line with both raw (ANSI) and clean (stripped) versions.
+// Line represents an output line with both raw (ANSI) and clean (stripped) versions.
 type Line struct {
-	Raw   string // Original line with ANSI codes
-	Clean string // ANSI-stripped version
+	Raw    string // Original line with ANSI codes
+	Clean  string // ANSI-stripped version
+	Origin Origin // Where the line came from; the zero value is the server
 }
 
-// NewLine creates a Line from raw text, automatically stripping ANSI codes.
+// NewLine creates a server Line from raw text, automatically stripping ANSI codes.
 func NewLine(raw string) Line {
 	return Line{Raw: raw, Clean: StripANSI(raw)}
 }
 
+// Origin is where a line in the output came from.
+type Origin uint8
+
+const (
+	OriginServer Origin = iota // sent by the server
+	OriginEcho                 // the local echo of typed input
+	OriginScript               // printed by a script (rune.echo) or the client itself
+)
+
+var originNames = [...]string{"server", "echo", "script"}
+
+// String returns the name Lua sees: "server", "echo", or "script".
+func (o Origin) String() string {
+	if int(o) < len(originNames) {
+		return originNames[o]
+	}
+	return "unknown"
+}
+
+// ParseOrigin returns the Origin named s, as String spells it.
+func ParseOrigin(s string) (Origin, bool) {
+	for i, name := range originNames {
+		if name == s {
+			return Origin(i), true
+		}
+	}
+	return 0, false
+}
+
 // Stripper states. ANSI sequences are pure ASCII, so the machine can
 // operate on bytes; UTF-8 continuation bytes (>= 0x80) only appear in
 // text state and pass through untouched.
@@ -33,8 +63,21 @@ const (
 // "consume until a letter" stripper swallows real text after any of
 // those.
 func StripANSI(s string) string {
+	return stripANSI(s, nil)
+}
+
+// stripANSI is StripANSI, optionally recording in offsets the raw
+// index of every byte it keeps: (*offsets)[i] is where clean byte i
+// came from, which is how Highlight maps clean matches back to raw.
+func stripANSI(s string, offsets *[]int) string {
 	var b strings.Builder
 	b.Grow(len(s))
+	keep := func(i int) {
+		b.WriteByte(s[i])
+		if offsets != nil {
+			*offsets = append(*offsets, i)
+		}
+	}
 
 	state := stText
 	for i := 0; i < len(s); i++ {
@@ -44,7 +87,7 @@ func StripANSI(s string) string {
 			if c == 0x1b {
 				state = stEsc
 			} else {
-				b.WriteByte(c)
+				keep(i)
 			}
 
 		case stEsc:
@@ -75,7 +118,7 @@ func StripANSI(s string) string {
 				state = stEsc
 			case c < 0x20:
 				// Terminals execute C0 controls embedded in CSI
-				b.WriteByte(c)
+				keep(i)
 			default:
 				// Parameter (0x30-0x3F) or intermediate (0x20-0x2F) byte
 			}
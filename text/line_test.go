@@ -31,6 +31,9 @@ func TestStripANSI(t *testing.T) {
 		{"empty", "", ""},
 		{"only escape", "\x1b[0m", ""},
 		{"back to back", "\x1b[1m\x1b[31mX\x1b[0m", "X"},
+		{"256-color fg/bg", "\x1b[38;5;208;48;5;17mX\x1b[0m", "X"},
+		{"truecolor fg/bg", "\x1b[38;2;255;0;0;48;2;0;0;255mX\x1b[0m", "X"},
+		{"truecolor colon form", "\x1b[38:2::255:0:0mX\x1b[39m", "X"},
 	}
 
 	for _, tc := range cases {
@@ -0,0 +1,412 @@
+package text
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SendLinkScheme prefixes the OSC 8 URI of a link the MXP converter
+// made from a <send> tag ("send:look sword"). A click on one sends the
+// command to the server instead of opening a browser.
+const SendLinkScheme = "send:"
+
+// IsSendURI reports whether s is a send link: SendLinkScheme followed
+// by a non-empty command free of control bytes.
+func IsSendURI(s string) bool {
+	cmd, ok := strings.CutPrefix(s, SendLinkScheme)
+	if !ok || cmd == "" {
+		return false
+	}
+	for i := 0; i < len(cmd); i++ {
+		if cmd[i] < 0x20 || cmd[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// MXP line modes, set by "ESC [ N z". Only secure lines may use the
+// send and link tags; locked lines are not parsed at all.
+const (
+	mxpOpen = iota
+	mxpSecure
+	mxpLocked
+)
+
+// MXP converts lines from a server speaking MXP (the MUD eXtension
+// Protocol, telnet option 91) into display text. It supports the two
+// tags MUDs actually lean on:
+//
+//	<send href="look sword">a sword</send>   -> underlined send link
+//	<send>north</send>                       -> sends "north"
+//	<a href="https://example.com">site</a>   -> OSC 8 web link
+//
+// Send links are OSC 8 links with SendLinkScheme; a href listing
+// several commands ("look|get") keeps the first, and "&text;" in it
+// stands for the tag's text. A send with the prompt flag (meant to
+// fill the input line, not send) stays plain text. Every other tag,
+// including element definitions and comments, is stripped, and
+// entities (&lt;, &#39;, ...) are decoded. The line mode sequences
+// themselves are consumed.
+//
+// The zero value is ready to use. A session keeps one per connection
+// because a locked mode change (ESC [ 5-7 z) carries over to later
+// lines; the other modes last until the end of the line.
+type MXP struct {
+	mode int // mode each line starts in
+}
+
+// mxpLink is a send or link tag waiting for its closing tag. start is
+// where its text begins in the converted line.
+type mxpLink struct {
+	tag   string
+	href  string
+	start int
+}
+
+// Line converts one line (without its line ending). A send or link
+// tag left open at the end of the line closes there.
+func (m *MXP) Line(s string) string {
+	mode := m.mode
+	tempSecure := false
+	var link *mxpLink
+	out := make([]byte, 0, len(s))
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == 0x1b:
+			if n, end, ok := mxpModeSequence(s, i); ok {
+				switch n {
+				case 0, 1, 2:
+					mode = n
+				case 3:
+					if link != nil {
+						out = link.close(out)
+						link = nil
+					}
+					m.mode = mxpOpen
+					mode = mxpOpen
+				case 4:
+					tempSecure = true
+				case 5, 6, 7:
+					m.mode = n - 5
+					mode = m.mode
+				}
+				i = end
+				continue
+			}
+			if end, ok := oscEnd(s, i); ok {
+				// Only links the converter makes may carry the send
+				// scheme; a server writing its own would bypass the
+				// mode and tag checks.
+				if !isSendOSC(s[i:end]) {
+					out = append(out, s[i:end]...)
+				}
+				i = end
+				continue
+			}
+			out = append(out, c)
+			i++
+
+		case mode == mxpLocked:
+			out = append(out, c)
+			i++
+
+		case c == '<':
+			end := mxpTagEnd(s, i)
+			if end < 0 {
+				out = append(out, c)
+				i++
+				continue
+			}
+			secure := mode == mxpSecure || tempSecure
+			tempSecure = false
+			out, link = mxpTag(out, s[i+1:end-1], secure, link)
+			i = end
+
+		case c == '&':
+			if r, n := mxpEntity(s[i:]); n > 0 {
+				out = utf8.AppendRune(out, r)
+				i += n
+				continue
+			}
+			out = append(out, c)
+			i++
+
+		default:
+			out = append(out, c)
+			i++
+		}
+	}
+	if link != nil {
+		out = link.close(out)
+	}
+	return string(out)
+}
+
+// mxpTag applies the tag with body (the text between < and >) to out,
+// returning the updated output and open link.
+func mxpTag(out []byte, body string, secure bool, link *mxpLink) ([]byte, *mxpLink) {
+	if strings.HasPrefix(body, "!") {
+		return out, link // <!ELEMENT ...>, <!-- ... -->
+	}
+	closing := strings.HasPrefix(body, "/")
+	body = strings.TrimPrefix(body, "/")
+	n := 0
+	for n < len(body) && (isAlnum(body[n]) || body[n] == '_' || body[n] == '-') {
+		n++
+	}
+	name := strings.ToLower(body[:n])
+
+	if closing {
+		if link != nil && name == link.tag {
+			return link.close(out), nil
+		}
+		return out, link
+	}
+	if link != nil || !secure || (name != "send" && name != "a") {
+		return out, link
+	}
+
+	named, positional := mxpAttrs(body[n:])
+	href, ok := named["href"]
+	if !ok && len(positional) > 0 {
+		href = positional[0]
+	}
+	switch name {
+	case "a":
+		if !IsWebURL(href) {
+			return out, link
+		}
+	case "send":
+		if _, prompt := named["prompt"]; prompt {
+			return out, link
+		}
+	}
+	return out, &mxpLink{tag: name, href: href, start: len(out)}
+}
+
+// close wraps the link's text, out[l.start:], in its OSC 8 link. A
+// send whose command comes out empty is left as plain text.
+func (l *mxpLink) close(out []byte) []byte {
+	text := StripANSI(string(out[l.start:]))
+	uri := l.href
+	tail := string(out[l.start:])
+	if l.tag == "send" {
+		cmd := uri
+		if cmd == "" {
+			cmd = text
+		}
+		cmd = strings.ReplaceAll(cmd, "&text;", text)
+		cmd, _, _ = strings.Cut(cmd, "|")
+		cmd = strings.TrimSpace(strings.Map(func(r rune) rune {
+			if r < 0x20 || r == 0x7f {
+				return -1
+			}
+			return r
+		}, cmd))
+		if cmd == "" {
+			return out
+		}
+		uri = SendLinkScheme + cmd
+		tail = Underline(tail)
+	}
+
+	out = append(out[:l.start], "\x1b]8;;"+uri+"\x1b\\"...)
+	out = append(out, tail...)
+	return append(out, "\x1b]8;;\x1b\\"...)
+}
+
+// mxpAttrs splits a tag's attribute text into named values
+// (href="look", keys lowercased) and positional ones ("look"). The
+// bare flag "prompt" is recorded as a named key with no value.
+func mxpAttrs(s string) (map[string]string, []string) {
+	named := make(map[string]string)
+	var positional []string
+	for i := 0; i < len(s); {
+		if s[i] == ' ' || s[i] == '\t' || s[i] == '/' {
+			i++
+			continue
+		}
+		if s[i] == '"' || s[i] == '\'' {
+			v, next := mxpQuoted(s, i)
+			positional = append(positional, v)
+			i = next
+			continue
+		}
+		j := i
+		for j < len(s) && s[j] != ' ' && s[j] != '\t' && s[j] != '=' {
+			j++
+		}
+		word := s[i:j]
+		if j < len(s) && s[j] == '=' {
+			j++
+			var v string
+			if j < len(s) && (s[j] == '"' || s[j] == '\'') {
+				v, j = mxpQuoted(s, j)
+			} else {
+				k := j
+				for j < len(s) && s[j] != ' ' && s[j] != '\t' {
+					j++
+				}
+				v = mxpDecode(s[k:j])
+			}
+			named[strings.ToLower(word)] = v
+		} else if strings.EqualFold(word, "prompt") {
+			named["prompt"] = ""
+		} else {
+			positional = append(positional, mxpDecode(word))
+		}
+		i = j
+	}
+	return named, positional
+}
+
+// mxpQuoted reads the quoted value starting at s[i], returning it
+// decoded and the index after its closing quote.
+func mxpQuoted(s string, i int) (string, int) {
+	q := s[i]
+	end := strings.IndexByte(s[i+1:], q)
+	if end < 0 {
+		return mxpDecode(s[i+1:]), len(s)
+	}
+	return mxpDecode(s[i+1 : i+1+end]), i + 2 + end
+}
+
+// mxpDecode replaces the entities in s.
+func mxpDecode(s string) string {
+	if !strings.Contains(s, "&") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if r, n := mxpEntity(s[i:]); n > 0 {
+			b.WriteRune(r)
+			i += n
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// mxpEntities are the named entities MXP servers send.
+var mxpEntities = map[string]rune{
+	"lt": '<', "gt": '>', "amp": '&', "quot": '"', "apos": '\'', "nbsp": ' ',
+}
+
+// mxpEntity decodes the entity s starts with ("&lt;", "&#39;",
+// "&#x27;"), returning the rune and the entity's length, or 0 when s
+// does not start with one. Numeric entities naming a control character
+// are refused: decoding "&#27;" to ESC would let a server forge escape
+// sequences past the converter.
+func mxpEntity(s string) (rune, int) {
+	end := strings.IndexByte(s, ';')
+	if len(s) < 3 || s[0] != '&' || end < 2 || end > 10 {
+		return 0, 0
+	}
+	name := s[1:end]
+	if num, ok := strings.CutPrefix(name, "#"); ok {
+		base := 10
+		if hex, ok := strings.CutPrefix(strings.ToLower(num), "x"); ok {
+			num, base = hex, 16
+		}
+		v, err := strconv.ParseUint(num, base, 32)
+		r := rune(v)
+		if err != nil || !utf8.ValidRune(r) || r < 0x20 || r >= 0x7f && r < 0xa0 {
+			return 0, 0
+		}
+		return r, end + 1
+	}
+	if r, ok := mxpEntities[strings.ToLower(name)]; ok {
+		return r, end + 1
+	}
+	return 0, 0
+}
+
+// mxpModeSequence reports whether s[i:] starts with a line mode
+// sequence (ESC [ N z), returning N and the index after it.
+func mxpModeSequence(s string, i int) (n, end int, ok bool) {
+	j := i + 2
+	if j > len(s) || s[i+1] != '[' {
+		return 0, 0, false
+	}
+	for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+		j++
+	}
+	if j == i+2 || j >= len(s) || s[j] != 'z' {
+		return 0, 0, false
+	}
+	n, err := strconv.Atoi(s[i+2 : j])
+	if err != nil {
+		return 0, 0, false
+	}
+	return n, j + 1, true
+}
+
+// oscEnd reports whether s[i:] starts an OSC string (ESC ]), returning
+// the index after its BEL or ESC \ terminator, or len(s) when it runs
+// to the end of the line.
+func oscEnd(s string, i int) (int, bool) {
+	if i+1 >= len(s) || s[i+1] != ']' {
+		return 0, false
+	}
+	for j := i + 2; j < len(s); j++ {
+		if s[j] == 0x07 {
+			return j + 1, true
+		}
+		if s[j] == 0x1b && j+1 < len(s) && s[j+1] == '\\' {
+			return j + 2, true
+		}
+	}
+	return len(s), true
+}
+
+// isSendOSC reports whether the OSC string osc opens a send link.
+func isSendOSC(osc string) bool {
+	rest, ok := strings.CutPrefix(osc, "\x1b]8;")
+	if !ok {
+		return false
+	}
+	_, uri, _ := strings.Cut(rest, ";")
+	return strings.HasPrefix(uri, SendLinkScheme)
+}
+
+// mxpTagEnd returns the index after the '>' closing the tag that opens
+// at s[i], or -1 when s[i] does not open one. A tag name must follow
+// the '<' directly, so "a < b" stays text; quoted attribute values may
+// contain '>'.
+func mxpTagEnd(s string, i int) int {
+	j := i + 1
+	if j < len(s) && s[j] == '/' {
+		j++
+	}
+	if j >= len(s) || !(isAlpha(s[j]) || s[j] == '!' && s[j-1] == '<') {
+		return -1
+	}
+	var quote byte
+	for ; j < len(s); j++ {
+		switch c := s[j]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return j + 1
+		}
+	}
+	return -1
+}
+
+func isAlpha(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isAlnum(c byte) bool {
+	return isAlpha(c) || c >= '0' && c <= '9'
+}
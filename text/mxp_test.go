@@ -0,0 +1,93 @@
+package text
+
+import "testing"
+
+func TestMXPLine(t *testing.T) {
+	const (
+		secure = "\x1b[1z"
+		send   = "\x1b]8;;send:"
+		st     = "\x1b\\"
+		ul     = "\x1b[4m"
+		endUL  = "\x1b[24m\x1b]8;;\x1b\\"
+	)
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text", "You see a sword.", "You see a sword."},
+		{"send href", secure + `You see <send href="get sword">a sword</send>.`,
+			"You see " + send + "get sword" + st + ul + "a sword" + endUL + "."},
+		{"send positional", secure + `<send "look">here</send>`, send + "look" + st + ul + "here" + endUL},
+		{"send text is command", secure + "<send>north</send>", send + "north" + st + ul + "north" + endUL},
+		{"first of a command list", secure + `<send "look &text;|get &text;">sword</send>`,
+			send + "look sword" + st + ul + "sword" + endUL},
+		{"colored send text", secure + "<send>\x1b[32mnorth\x1b[0m</send>",
+			send + "north" + st + ul + "\x1b[32mnorth\x1b[0m" + endUL},
+		{"unclosed send closes at end of line", secure + "<send>north",
+			send + "north" + st + ul + "north" + endUL},
+		{"prompt flag stays text", secure + `<send "tell bob " prompt>bob</send>`, "bob"},
+		{"web link", secure + `<a href="https://example.com">site</a>`,
+			"\x1b]8;;https://example.com" + st + "site\x1b]8;;\x1b\\"},
+		{"non-web link stays text", secure + `<a href="file:///etc/passwd">site</a>`, "site"},
+
+		// Open lines (the default) may not use send or link tags.
+		{"open line strips send", `<send "quit">free gold</send>`, "free gold"},
+		{"temp secure", "\x1b[4z<send>north</send> <send>south</send>",
+			send + "north" + st + ul + "north" + endUL + " south"},
+		{"locked line untouched", "\x1b[2z<b>&lt;</b>", "<b>&lt;</b>"},
+
+		{"other tags stripped", secure + "<b><color fore=red>Hi</color></b><br>", "Hi"},
+		{"element definitions stripped", `<!ELEMENT Ex '<send>' FLAG="RoomExit">Exits`, "Exits"},
+		{"quoted greater-than", secure + `<send href="a>b">x</send>`, send + "a>b" + st + ul + "x" + endUL},
+		{"entities", "&lt;tag&gt; &amp; &quot;q&quot; &#65;&#x42;", `<tag> & "q" AB`},
+		{"unknown entity kept", "AT&T; &bogus;", "AT&T; &bogus;"},
+		{"control entity refused", "&#27;]8;;send:quit", "&#27;]8;;send:quit"},
+		{"less-than as text", "5 < 6 and 7 > 3", "5 < 6 and 7 > 3"},
+		{"server send links dropped", "\x1b]8;;send:quit\x1b\\click\x1b]8;;\x1b\\",
+			"click\x1b]8;;\x1b\\"},
+		{"other osc kept", "\x1b]8;;https://x.org\x1b\\x", "\x1b]8;;https://x.org\x1b\\x"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var m MXP
+			if got := m.Line(tc.in); got != tc.want {
+				t.Errorf("Line(%q)\n got %q\nwant %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+// TestMXPModes verifies line modes last one line while locked modes
+// carry over, and that reset returns to open.
+func TestMXPModes(t *testing.T) {
+	var m MXP
+	tag := "<send>north</send>"
+	link := "\x1b]8;;send:north\x1b\\\x1b[4mnorth\x1b[24m\x1b]8;;\x1b\\"
+
+	steps := []struct{ in, want string }{
+		{"\x1b[1z" + tag, link},
+		{tag, "north"},
+		{"\x1b[6z" + tag, link},
+		{tag, link},
+		{"\x1b[3z" + tag, "north"},
+		{"\x1b[7z<b>x</b>", "<b>x</b>"},
+		{"<b>x</b>", "<b>x</b>"},
+		{"\x1b[3z<b>x</b>", "x"},
+	}
+	for i, s := range steps {
+		if got := m.Line(s.in); got != s.want {
+			t.Errorf("step %d: Line(%q) = %q, want %q", i, s.in, got, s.want)
+		}
+	}
+}
+
+func TestSanitizeMXPDisplayKeepsSendLinks(t *testing.T) {
+	line := "\x1b]8;;send:look\x1b\\here\x1b]8;;\x1b\\"
+	if got := SanitizeMXPDisplay(line); got != line {
+		t.Errorf("SanitizeMXPDisplay = %q, want %q", got, line)
+	}
+	if got := SanitizeDisplay(line); got != "here\x1b]8;;\x1b\\" {
+		t.Errorf("SanitizeDisplay = %q, want the send link dropped", got)
+	}
+}
@@ -2,6 +2,11 @@ package text
 
 import "strings"
 
+// maxHyperlinkBytes bounds how much of an OSC 8 string is buffered;
+// longer ones are dropped like any other OSC. Real URLs are far
+// shorter, and a server must not be able to grow the buffer unbounded.
+const maxHyperlinkBytes = 2048
+
 // SanitizeDisplay makes server text safe for a row-based display: it
 // keeps printable text and SGR color sequences (CSI ... 'm' with plain
 // numeric parameters) and drops every other escape sequence and
@@ -15,16 +20,35 @@ import "strings"
 // and LF pass through - later display stages own tab expansion and
 // line splitting.
 //
+// OSC 8 hyperlinks to http(s) URLs are the one string sequence kept,
+// re-emitted in canonical form (ESC ] 8 ; ; URI ESC \) without their
+// params, so the UI can open them on click. Links to other schemes
+// keep their text and lose the link.
+//
 // The state machine mirrors StripANSI (line.go), which owns the notes
 // on why each sequence class is parsed the way it is; this variant
 // re-emits SGR sequences instead of dropping them.
 func SanitizeDisplay(s string) string {
+	return sanitizeDisplay(s, false)
+}
+
+// SanitizeMXPDisplay is SanitizeDisplay for a line that went through
+// the MXP converter: it also keeps the send links (SendLinkScheme) the
+// converter made. The converter drops any the server wrote itself, so
+// only tags it parsed become clickable commands.
+func SanitizeMXPDisplay(s string) string {
+	return sanitizeDisplay(s, true)
+}
+
+func sanitizeDisplay(s string, sendLinks bool) string {
 	var b strings.Builder
 	b.Grow(len(s))
 
 	state := stText
 	var params strings.Builder // parameter bytes of the CSI being scanned
 	sgr := false               // CSI still qualifies as plain SGR
+	var osc strings.Builder    // body of the OSC being scanned
+	inOSC := false             // string sequence is an OSC (ESC ])
 	for i := 0; i < len(s); i++ {
 		c := s[i]
 		switch state {
@@ -48,6 +72,8 @@ func SanitizeDisplay(s string) string {
 				sgr = true
 			case c == ']' || c == 'P' || c == 'X' || c == '^' || c == '_':
 				state = stString
+				inOSC = c == ']'
+				osc.Reset()
 			case c == 0x1b:
 				// ESC ESC: restart sequence detection
 			case c >= 0x20 && c <= 0x2f:
@@ -83,21 +109,114 @@ func SanitizeDisplay(s string) string {
 			switch c {
 			case 0x07:
 				state = stText
+				if inOSC {
+					writeHyperlink(&b, osc.String(), sendLinks)
+				}
 			case 0x1b:
 				state = stStringEsc
+			default:
+				if inOSC && osc.Len() < maxHyperlinkBytes {
+					osc.WriteByte(c)
+				} else {
+					inOSC = false
+				}
 			}
 
 		case stStringEsc:
 			switch c {
 			case '\\':
 				state = stText
+				if inOSC {
+					writeHyperlink(&b, osc.String(), sendLinks)
+				}
 			case 0x1b:
 				// Still a candidate ST terminator
 			default:
 				state = stString
+				inOSC = false
 			}
 		}
 	}
 
 	return b.String()
 }
+
+// writeHyperlink re-emits an OSC 8 body ("8;params;URI") in canonical
+// form when it opens an http(s) link - or a send link, when sendLinks
+// is set - or closes one (empty URI). Any other OSC, or a link with a
+// control byte or another scheme, is dropped.
+func writeHyperlink(b *strings.Builder, body string, sendLinks bool) {
+	rest, ok := strings.CutPrefix(body, "8;")
+	if !ok {
+		return
+	}
+	_, uri, ok := strings.Cut(rest, ";")
+	if !ok {
+		return
+	}
+	if uri != "" && !IsWebURL(uri) && !(sendLinks && IsSendURI(uri)) {
+		return
+	}
+	b.WriteString("\x1b]8;;")
+	b.WriteString(uri)
+	b.WriteString("\x1b\\")
+}
+
+// IsWebURL reports whether s is an absolute http or https URL made of
+// printable ASCII - the only links rune will hand to the OS opener.
+func IsWebURL(s string) bool {
+	lower := strings.ToLower(s)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] <= 0x20 || s[i] >= 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// HasScreenClear reports whether s contains a sequence that clears the
+// whole screen on a terminal: erase-display 2 or 3 (CSI 2 J, CSI 3 J),
+// a full reset (ESC c), or cursor-home directly followed by erase-below
+// (CSI H CSI J, the curses idiom). SanitizeDisplay drops all of them;
+// the session checks the raw line first so the attempt can still be
+// reported (the "clear" hook).
+func HasScreenClear(s string) bool {
+	home := false // the previous sequence was a bare cursor-home
+	for i := 0; i < len(s); i++ {
+		if s[i] != 0x1b || i+1 >= len(s) {
+			home = false
+			continue
+		}
+		switch s[i+1] {
+		case 'c':
+			return true
+		case '[':
+			j := i + 2
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == ';') {
+				j++
+			}
+			if j >= len(s) {
+				return false
+			}
+			params := s[i+2 : j]
+			switch s[j] {
+			case 'J':
+				if params == "2" || params == "3" || home && (params == "" || params == "0") {
+					return true
+				}
+				home = false
+			case 'H':
+				home = params == "" || params == "1;1"
+			default:
+				home = false
+			}
+			i = j
+		default:
+			home = false
+		}
+	}
+	return false
+}
@@ -1,6 +1,9 @@
 package text
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestSanitizeDisplay(t *testing.T) {
 	cases := []struct {
@@ -35,6 +38,14 @@ func TestSanitizeDisplay(t *testing.T) {
 		{"osc title bel", "\x1b]0;window title\x07visible", "visible"},
 		{"osc title st", "\x1b]0;window title\x1b\\visible", "visible"},
 		{"dcs string", "\x1bPsome payload\x1b\\visible", "visible"},
+
+		// OSC 8 hyperlinks survive for http(s), canonicalized.
+		{"osc 8 link st", "\x1b]8;;https://example.com\x1b\\site\x1b]8;;\x1b\\", "\x1b]8;;https://example.com\x1b\\site\x1b]8;;\x1b\\"},
+		{"osc 8 link bel", "\x1b]8;;http://example.com\x07site\x1b]8;;\x07", "\x1b]8;;http://example.com\x1b\\site\x1b]8;;\x1b\\"},
+		{"osc 8 params dropped", "\x1b]8;id=1;https://example.com\x1b\\site", "\x1b]8;;https://example.com\x1b\\site"},
+		{"osc 8 other scheme dropped", "\x1b]8;;file:///etc/passwd\x1b\\site", "site"},
+		{"osc 8 control in uri dropped", "\x1b]8;;https://a\x08b\x1b\\site", "site"},
+		{"osc 8 interrupted by esc", "\x1b]8;;https://example.com\x1bXsite\x1b\\visible", "visible"},
 		{"charset designation", "\x1b(Btext", "text"},
 		{"two-char escape", "\x1b7saved\x1b8", "saved"},
 
@@ -66,3 +77,53 @@ func TestSanitizeDisplay(t *testing.T) {
 		})
 	}
 }
+
+func TestSanitizeDisplayDropsOversizedHyperlink(t *testing.T) {
+	long := "\x1b]8;;https://example.com/" + strings.Repeat("a", maxHyperlinkBytes) + "\x1b\\site"
+	if got := SanitizeDisplay(long); got != "site" {
+		t.Errorf("oversized hyperlink kept: %q", got)
+	}
+}
+
+func TestHasScreenClear(t *testing.T) {
+	cases := []struct {
+		in   string
+		want bool
+	}{
+		{"\x1b[2Jtext", true},
+		{"\x1b[3J", true},
+		{"text\x1bcmore", true},
+		{"\x1b[H\x1b[J", true},
+		{"\x1b[1;1H\x1b[0J", true},
+		{"\x1b[31m\x1b[H\x1b[2J\x1b[0m", true},
+		{"plain text", false},
+		{"\x1b[31mred\x1b[0m", false},
+		{"before\x1b[Jafter", false}, // erase below, not from home
+		{"\x1b[Hx\x1b[J", false},     // text between home and erase
+		{"\x1b[3;1H\x1b[J", false},   // not the home position
+		{"\x1b[2Kline", false},       // erase line
+		{"\x1b[2", false},            // truncated
+	}
+	for _, c := range cases {
+		if got := HasScreenClear(c.in); got != c.want {
+			t.Errorf("HasScreenClear(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestIsWebURL(t *testing.T) {
+	cases := map[string]bool{
+		"https://example.com/a?b=c": true,
+		"HTTP://EXAMPLE.COM":        true,
+		"ftp://example.com":         false,
+		"javascript:alert(1)":       false,
+		"https://exa mple.com":      false,
+		"https://exämple.com":       false,
+		"":                          false,
+	}
+	for in, want := range cases {
+		if got := IsWebURL(in); got != want {
+			t.Errorf("IsWebURL(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package text
+
+import (
+	"regexp"
+	"strings"
+)
+
+// bareURL matches an http(s) URL run in plain text. Trailing
+// punctuation is trimmed afterwards (see trimURL).
+var bareURL = regexp.MustCompile(`(?i)https?://[^\s<>"'` + "`" + `]+`)
+
+// FindURLs returns the byte spans [start, end) of the bare http(s)
+// URLs in plain (escape-free) text, with trailing sentence punctuation
+// trimmed. Only spans that pass IsWebURL are returned.
+func FindURLs(s string) [][2]int {
+	var spans [][2]int
+	for _, m := range bareURL.FindAllStringIndex(s, -1) {
+		url := trimURL(s[m[0]:m[1]])
+		if !IsWebURL(url) {
+			continue
+		}
+		spans = append(spans, [2]int{m[0], m[0] + len(url)})
+	}
+	return spans
+}
+
+// trimURL drops sentence punctuation a bare URL picked up ("see
+// https://example.com."), keeping a closing paren that balances one
+// inside the URL (wiki-style "Foo_(bar)").
+func trimURL(url string) string {
+	for len(url) > 0 {
+		last := url[len(url)-1]
+		switch last {
+		case '.', ',', ';', ':', '!', '?', ']', '}':
+		case ')':
+			if strings.Count(url, "(") >= strings.Count(url, ")") {
+				return url
+			}
+		default:
+			return url
+		}
+		url = url[:len(url)-1]
+	}
+	return url
+}
@@ -0,0 +1,34 @@
+package text
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"None", "You are standing in a field.", nil},
+		{"One", "see https://example.com now", []string{"https://example.com"}},
+		{"TrailingPunctuation", "visit http://aard.org.", []string{"http://aard.org"}},
+		{"BalancedParen", "wiki https://w.org/Foo_(bar)", []string{"https://w.org/Foo_(bar)"}},
+		{"UnbalancedParen", "(see https://w.org/x)", []string{"https://w.org/x"}},
+		{"Several", "http://a.org and https://b.org/p?q=1", []string{"http://a.org", "https://b.org/p?q=1"}},
+		{"SchemeOnly", "https:// alone", nil},
+		{"NonASCII", "https://例え.jp", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, span := range FindURLs(tt.in) {
+				got = append(got, tt.in[span[0]:span[1]])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindURLs(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package text
+
+import (
+	"github.com/charmbracelet/x/ansi"
+	"github.com/mattn/go-runewidth"
+)
+
+// Width returns the display width of s in terminal cells: ANSI escape
+// sequences are zero-width and East Asian wide runes take two cells.
+// The UI measures rows with it and Lua pads with it (rune.string.pad),
+// so script-side alignment and rendering agree.
+func Width(s string) int {
+	return runewidth.StringWidth(StripANSI(s))
+}
+
+// Truncate cuts s to at most width cells by the same measure as Width,
+// ending with tail when it cuts. It never splits a UTF-8 sequence or a
+// wide rune, and the ANSI codes of the kept text survive.
+func Truncate(s string, width int, tail string) string {
+	if Width(s) <= width {
+		return s
+	}
+	return ansi.Truncate(s, max(width, 0), tail)
+}
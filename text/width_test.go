@@ -0,0 +1,63 @@
+package text
+
+import "testing"
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want int
+	}{
+		{"ASCII", "look", 4},
+		{"Colored", "\x1b[31mred\x1b[0m", 3},
+		{"Multibyte", "héllo", 5},
+		{"Wide", "日本語", 6},
+		{"ColoredWide", "\x1b[1;32m日本\x1b[0m!", 5},
+
+		// Extended colors: the digits and separators of 256-color and
+		// truecolor parameters must not count as text.
+		{"Fg256", "\x1b[38;5;208mHP\x1b[0m", 2},
+		{"Bg256", "\x1b[48;5;17mHP\x1b[0m", 2},
+		{"FgTruecolor", "\x1b[38;2;255;128;0mHP\x1b[0m", 2},
+		{"BgTruecolor", "\x1b[48;2;0;0;139mHP\x1b[0m", 2},
+		{"ColonSubparams", "\x1b[38:2::255:128:0mHP\x1b[39m", 2},
+		{"CombinedAttributes", "\x1b[1;4;38;5;196;48;2;10;20;30mHP 100%\x1b[22;24m", 7},
+		{"ResetsBetween", "\x1b[38;5;46m123\x1b[0m/\x1b[38;2;1;2;3m456\x1b[m", 7},
+		{"TruecolorWide", "\x1b[38;2;200;200;200m日本\x1b[0m", 4},
+		{"Empty", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Width(tt.in); got != tt.want {
+				t.Errorf("Width(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		width int
+		want  string
+	}{
+		{"Fits", "look", 4, "look"},
+		{"ASCII", "look north", 7, "look..."},
+		{"Multibyte", "héllo wörld", 7, "héll..."},
+		{"WideBoundary", "日本語です", 8, "日本..."},
+		{"Colored", "\x1b[31mred alert\x1b[0m", 6, "\x1b[31mred...\x1b[0m"},
+		{"TooNarrow", "look", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Truncate(tt.in, tt.width, "...")
+			if got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+			}
+			if Width(got) > tt.width {
+				t.Errorf("Truncate(%q, %d) is %d cells wide", tt.in, tt.width, Width(got))
+			}
+		})
+	}
+}
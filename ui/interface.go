@@ -1,6 +1,10 @@
 package ui
 
-import "github.com/mmcdole/rune/input"
+import (
+	"time"
+
+	"github.com/mmcdole/rune/input"
+)
 
 // UI defines the contract for the terminal display layer.
 // Implementation lives in the same package (BubbleTeaUI).
@@ -25,19 +29,42 @@ type UI interface {
 	// Components
 	ShowPicker(opts ShowPickerMsg)
 	SetClipboard(text string)
+	EnterCopyMode()
+	JumpToInput()
+	ClearScreen()
+	ClearScrollback()
+	SetDedupe(on bool)
+	SetFlushInterval(d time.Duration)
+	GrepScrollback(req GrepScrollbackMsg)
+	SetWrap(on bool)
+	SetSplit(on bool)
+	SetTheme(theme Theme)
+	Bell(audible, visual bool)
 	CreatePane(name string)
 	WritePane(name, text string)
 	TogglePane(name string)
 	SetPaneVisible(name string, visible bool)
 	ClearPane(name string)
+	ResizePane(name string, height int)
+	GrowPane(name string, rows int)
+	LimitPane(name string, lines, bytes int)
+	FocusPane(name string)
 
 	// Input primitives. Cursor positions are zero-based rune offsets.
 	InputSetCursor(pos int)
+	InputSetPrompt(prompt string)
+	InputSetPlaceholder(text string)
+	InputSetMask(on bool)
+	InputSetGhost(text string)
+	InputSetWordBreak(chars string)
+	InputSetQueue(limit int)
 	OpenEditor(initial string) (string, bool)
 
 	// Pane scrolling primitives for Lua
 	PaneScrollUp(name string, lines int)
 	PaneScrollDown(name string, lines int)
+	PaneScrollColumns(name string, cols int)
+	PaneScrollPages(name string, pages float64)
 	PaneScrollToTop(name string)
 	PaneScrollToBottom(name string)
 }
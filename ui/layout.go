@@ -4,6 +4,11 @@ package ui
 type LayoutEntry struct {
 	Name   string // Component name (e.g., "input", "status", pane name)
 	Height int    // Explicit height in lines (0 = intrinsic/auto)
+	// Panes only. Border is "top", "bottom", "both" or "none"; ""
+	// borders the side facing the output (below a top-docked pane,
+	// above a bottom-docked one). NoTitle leaves the name out.
+	Border  string
+	NoTitle bool
 }
 
 // LayoutConfig declares which components go in each dock.
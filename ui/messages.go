@@ -1,6 +1,11 @@
 package ui
 
-import "github.com/mmcdole/rune/input"
+import (
+	"regexp"
+	"time"
+
+	"github.com/mmcdole/rune/input"
+)
 
 // UIEvent is implemented by all messages sent from UI to Session.
 // This provides compile-time type safety for the outbound channel.
@@ -48,6 +53,34 @@ type PaneClearMsg struct {
 	Name string
 }
 
+// PaneResizeMsg sets a pane's height in lines, header and border
+// included. An empty Name targets the focused pane.
+type PaneResizeMsg struct {
+	Name   string
+	Height int
+}
+
+// PaneGrowMsg grows a pane by Rows lines (negative shrinks). An empty
+// Name targets the focused pane.
+type PaneGrowMsg struct {
+	Name string
+	Rows int
+}
+
+// PaneLimitMsg sets a pane's buffer budget: at most Lines lines and
+// Bytes bytes of them, 0 keeping that budget as it was.
+type PaneLimitMsg struct {
+	Name  string
+	Lines int
+	Bytes int
+}
+
+// PaneFocusMsg makes a named pane the target of PaneGrowMsg and
+// PaneResizeMsg without a name.
+type PaneFocusMsg struct {
+	Name string
+}
+
 // --- Push-based UI Messages (Session -> UI) ---
 
 // UpdateBindsMsg pushes the current set of bound keys from Session to UI.
@@ -82,14 +115,25 @@ type WindowSizeChangedMsg struct {
 func (WindowSizeChangedMsg) uiEvent() {}
 
 // ScrollStateChangedMsg notifies Session of scroll state changes.
-// Session uses this to update rune.state.scroll_mode/scroll_lines.
+// Session uses this to update rune.state.scroll_mode/scroll_lines/
+// scroll_percent.
 type ScrollStateChangedMsg struct {
 	Mode     string // "live" or "scrolled"
 	NewLines int    // Lines behind live (when scrolled)
+	Percent  int    // Position in the scrollback: 0 oldest, 100 newest
 }
 
 func (ScrollStateChangedMsg) uiEvent() {}
 
+// InputDroppedMsg notifies Session that input was dropped because the
+// engine was busy and the input queue was full. Total counts every
+// drop since start; Session uses it to update rune.state.input_dropped.
+type InputDroppedMsg struct {
+	Total int
+}
+
+func (InputDroppedMsg) uiEvent() {}
+
 // InputChangedMsg notifies Session of input content changes. Cursor is a
 // zero-based rune offset from the input widget.
 type InputChangedMsg struct {
@@ -107,6 +151,33 @@ type CursorMovedMsg struct {
 
 func (CursorMovedMsg) uiEvent() {}
 
+// LinkClickedMsg notifies Session that the user clicked a URL in the
+// output viewport. Explicit is true for an OSC 8 hyperlink, false for
+// a bare URL detected in the text; Lua decides whether to open either.
+type LinkClickedMsg struct {
+	URL      string
+	Explicit bool
+}
+
+func (LinkClickedMsg) uiEvent() {}
+
+// CopySelectionMsg carries the rows selected in copy mode, as plain
+// text, to Session. Lua decides where they go (clipboard or screen).
+type CopySelectionMsg struct {
+	Text string
+}
+
+func (CopySelectionMsg) uiEvent() {}
+
+// MultilinePasteMsg reports a bracketed paste spanning several lines,
+// after it has landed in the verbatim composer. Lua's paste policy
+// (rune.config.paste) decides whether the draft stays there.
+type MultilinePasteMsg struct {
+	Text string
+}
+
+func (MultilinePasteMsg) uiEvent() {}
+
 // --- Picker Messages (Session -> UI) ---
 
 // ShowPickerMsg requests the UI to display a picker overlay.
@@ -122,12 +193,84 @@ type ShowPickerMsg struct {
 	// a space - for pickers over single-token items (slash commands) where
 	// a space means the user has committed and is typing arguments.
 	DismissOnSpace bool
+	// MaxResults caps how many fuzzy matches a query keeps (0 = all).
+	// Large lists (history) stay responsive per keystroke; an empty
+	// query still lists every item.
+	MaxResults int
+	// Query seeds a modal picker's search field.
+	Query string
+	// Match, when set, is the modal picker's matching: "fuzzy" or
+	// "prefix" (items starting with the query). Setting it also lets
+	// Ctrl+R flip between the two and shows the active one in the
+	// header; empty means fuzzy with no toggle.
+	Match string
 }
 
 // SetClipboardMsg asks the terminal to set the system clipboard
 // (OSC 52). Sent from Session when Lua calls rune.clipboard.set().
 type SetClipboardMsg string
 
+// BellMsg rings the bell (rune.bell): Audible writes BEL to the
+// terminal, Visual briefly inverts the bars.
+type BellMsg struct {
+	Audible bool
+	Visual  bool
+}
+
+// EnterCopyModeMsg starts copy mode: keyboard selection over the
+// output viewport. Sent from Session when Lua calls rune.ui.copy_mode().
+type EnterCopyModeMsg struct{}
+
+// SetDedupeMsg turns collapsing of repeated server lines on or off.
+// Sent from Session when Lua calls rune.ui.dedupe().
+type SetDedupeMsg bool
+
+// GrepScrollbackMsg dumps the output rows matching Pattern, with each
+// match wrapped in the Highlight escape sequence, under a Label line
+// that gains the match count. Only the newest Limit matches are
+// written, oldest first. They go to pane Pane, or to the output when
+// Pane is "". Sent from Session when Lua calls rune.scrollback.grep().
+type GrepScrollbackMsg struct {
+	Pattern   *regexp.Regexp
+	Highlight string
+	Label     string
+	Pane      string
+	Limit     int
+}
+
+// SetFlushIntervalMsg sets how long server output is batched before it
+// renders. Sent from Session when Lua calls rune.ui.flush_interval().
+type SetFlushIntervalMsg time.Duration
+
+// SetWrapMsg turns soft-wrapping of new output rows on or off; unwrapped
+// rows are clipped and can be scrolled horizontally. Sent from Session
+// when Lua calls rune.ui.wrap().
+type SetWrapMsg bool
+
+// SetSplitMsg turns the split output view on or off: while scrolled
+// back, the newest output keeps showing below a divider. Sent from
+// Session when Lua calls rune.ui.split().
+type SetSplitMsg bool
+
+// SetThemeMsg recolors the TUI's own chrome. Sent from Session when
+// Lua calls rune.ui.theme(); an empty Theme restores the defaults.
+type SetThemeMsg Theme
+
+// JumpToInputMsg scrolls the output viewport back to where the last
+// submitted command's output starts. Sent from Session when Lua calls
+// rune.ui.jump_to_input().
+type JumpToInputMsg struct{}
+
+// ClearScreenMsg scrolls everything in the output viewport out of
+// view, the way a terminal clears its screen: older rows stay in
+// scrollback. Sent from Session when Lua calls rune.ui.clear_screen().
+type ClearScreenMsg struct{}
+
+// ClearScrollbackMsg drops every row of the output viewport,
+// scrollback included, and any rows still batched. Sent from Session
+// when Lua calls rune._ui.clear_scrollback().
+type ClearScrollbackMsg struct{}
+
 // SetInputMsg sets the input line content.
 // Sent from Session when Lua calls rune.input.set().
 type SetInputMsg string
@@ -153,6 +296,28 @@ func (PickerSelectMsg) uiEvent() {}
 // InputSetCursorMsg sets the widget cursor to a zero-based rune offset.
 type InputSetCursorMsg int
 
+// InputPromptMsg replaces the text drawn before the input line.
+type InputPromptMsg string
+
+// InputPlaceholderMsg sets the hint shown while the input line is empty.
+type InputPlaceholderMsg string
+
+// InputMaskMsg turns masking of the input line on or off: while on,
+// each typed character draws as '*' (for passwords).
+type InputMaskMsg bool
+
+// InputGhostMsg sets the suggested completion of the input line,
+// drawn dimmed past what is typed ("" clears it).
+type InputGhostMsg string
+
+// InputWordBreakMsg sets the characters that end a word, besides
+// whitespace, for the composer's word motion and deletion.
+type InputWordBreakMsg string
+
+// InputQueueMsg sets how many submissions may wait for a busy engine
+// before new ones are dropped (0 drops at once).
+type InputQueueMsg int
+
 // --- Pane Scrolling Messages (Session -> UI) ---
 
 // PaneScrollUpMsg scrolls a pane up by N lines.
@@ -167,6 +332,22 @@ type PaneScrollDownMsg struct {
 	Lines int
 }
 
+// PaneScrollColumnsMsg scrolls a pane horizontally by Cols columns
+// (negative = left). Only "main", the output viewport, scrolls
+// sideways.
+type PaneScrollColumnsMsg struct {
+	Name string
+	Cols int
+}
+
+// PaneScrollPagesMsg scrolls a pane by Pages times its height
+// (negative = up), so a half page stays half a page as the layout
+// changes. Only "main", the output viewport, scrolls by pages.
+type PaneScrollPagesMsg struct {
+	Name  string
+	Pages float64
+}
+
 // PaneScrollToTopMsg scrolls a pane to the top.
 type PaneScrollToTopMsg struct {
 	Name string
@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mmcdole/rune/text"
+)
+
+// Theme recolors the chrome the TUI draws itself: picker overlays,
+// pane headers and borders, and the input's rules. Each field holds a
+// color as returned by ParseColor; empty keeps the default.
+type Theme struct {
+	OverlayBorder string // picker frame
+	Selected      string // background of the selected picker row
+	SelectedText  string // text of the selected picker row
+	Text          string // text of other picker rows
+	Match         string // fuzzy-matched characters
+	Muted         string // picker headers, hints, composer gutter
+	PaneHeader    string // background of a pane's title
+	PaneTitle     string // text of a pane's title
+	PaneBorder    string // rule under a pane
+	Separator     string // input rules and the "separator" component
+
+	// Rule is the glyph the input's rules and the separator repeat,
+	// one cell wide; empty keeps "─". Set with the "rule" key.
+	Rule string
+}
+
+// Set assigns the value for a rune.ui.theme key: a color for
+// "overlay_border", "pane_header" and the rest (see ParseColor), a
+// glyph for "rule" (see ParseRule).
+func (t *Theme) Set(key, value string) error {
+	var field *string
+	switch key {
+	case "overlay_border":
+		field = &t.OverlayBorder
+	case "selected":
+		field = &t.Selected
+	case "selected_text":
+		field = &t.SelectedText
+	case "text":
+		field = &t.Text
+	case "match":
+		field = &t.Match
+	case "muted":
+		field = &t.Muted
+	case "pane_header":
+		field = &t.PaneHeader
+	case "pane_title":
+		field = &t.PaneTitle
+	case "pane_border":
+		field = &t.PaneBorder
+	case "separator":
+		field = &t.Separator
+	case "rule":
+		glyph, err := ParseRule(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		t.Rule = glyph
+		return nil
+	default:
+		return fmt.Errorf("unknown theme key %q", key)
+	}
+	c, err := ParseColor(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	*field = c
+	return nil
+}
+
+// colorNames are the 16 ANSI colors by name, as their palette index.
+var colorNames = map[string]int{
+	"black": 0, "red": 1, "green": 2, "yellow": 3,
+	"blue": 4, "magenta": 5, "cyan": 6, "white": 7,
+	"gray": 8, "grey": 8, "bright_black": 8,
+	"bright_red": 9, "bright_green": 10, "bright_yellow": 11,
+	"bright_blue": 12, "bright_magenta": 13, "bright_cyan": 14,
+	"bright_white": 15,
+}
+
+// ParseColor normalizes a theme color to what the renderer accepts: a
+// 256-color palette index ("62"), a hex color ("#5f5fd7" or "#fff"),
+// or one of the 16 ANSI names ("blue", "bright_red"), which become
+// their palette index.
+func ParseColor(s string) (string, error) {
+	c := strings.ToLower(strings.TrimSpace(s))
+	if n, ok := colorNames[c]; ok {
+		return strconv.Itoa(n), nil
+	}
+	if n, err := strconv.Atoi(c); err == nil {
+		if n < 0 || n > 255 {
+			return "", fmt.Errorf("color %q out of range 0-255", s)
+		}
+		return c, nil
+	}
+	if hex, ok := strings.CutPrefix(c, "#"); ok && (len(hex) == 3 || len(hex) == 6) {
+		if _, err := strconv.ParseUint(hex, 16, 32); err == nil {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown color %q (want a name, 0-255, or #rrggbb)", s)
+}
+
+// ParseRule checks a rule glyph: exactly one character that takes one
+// terminal cell, so a rule of it spans the width exactly. A space
+// draws a blank rule.
+func ParseRule(s string) (string, error) {
+	if utf8.RuneCountInString(s) != 1 || text.Width(s) != 1 {
+		return "", fmt.Errorf("rule %q must be one single-width character", s)
+	}
+	return s, nil
+}
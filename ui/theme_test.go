@@ -0,0 +1,44 @@
+package ui
+
+import "testing"
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"blue", "4", true},
+		{"Bright_Red", "9", true},
+		{"grey", "8", true},
+		{"62", "62", true},
+		{"#5F5FD7", "#5f5fd7", true},
+		{"#fff", "#fff", true},
+		{"256", "", false},
+		{"-1", "", false},
+		{"#ggg", "", false},
+		{"#12345", "", false},
+		{"teal", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, err := ParseColor(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseColor(%q) = %q, %v; want %q (ok=%v)", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	for _, ok := range []string{"─", "═", " ", "-", "·"} {
+		if _, err := ParseRule(ok); err != nil {
+			t.Errorf("ParseRule(%q) = %v, want accepted", ok, err)
+		}
+	}
+	// Wide, zero-width, multi-character, and empty glyphs would make
+	// the rule overshoot or undershoot the width.
+	for _, bad := range []string{"━━", "＝", "\u0301", "\t", ""} {
+		if _, err := ParseRule(bad); err == nil {
+			t.Errorf("ParseRule(%q) accepted, want an error", bad)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/mmcdole/rune/ui"
+)
+
+// handleCopyKey drives copy mode, which traps the keyboard until the
+// selection is copied or cancelled (tmux-style; vi keys alongside the
+// arrows). The input line is left untouched throughout.
+//
+//	up/k down/j     move the cursor a row
+//	pgup pgdown     move a page
+//	home/g end/G    oldest / newest row
+//	v, space        start the selection at the cursor (again: clear it)
+//	enter, y        copy the selection and leave
+//	esc, q, ctrl+c  leave without copying
+func (m *Model) handleCopyKey(msg tea.KeyMsg) {
+	key := ""
+	if msg.Type == tea.KeyRunes && !msg.Alt {
+		key = string(msg.Runes)
+	}
+	switch {
+	case msg.Type == tea.KeyUp || key == "k":
+		m.viewport.CopyMove(-1)
+	case msg.Type == tea.KeyDown || key == "j":
+		m.viewport.CopyMove(1)
+	case msg.Type == tea.KeyPgUp:
+		m.viewport.CopyPage(-1)
+	case msg.Type == tea.KeyPgDown:
+		m.viewport.CopyPage(1)
+	case msg.Type == tea.KeyHome || key == "g":
+		m.viewport.CopyMove(-m.scrollback.Count())
+	case msg.Type == tea.KeyEnd || key == "G":
+		m.viewport.CopyMove(m.scrollback.Count())
+	case msg.Type == tea.KeySpace || key == "v" || key == " ":
+		m.viewport.CopyToggleMark()
+	case msg.Type == tea.KeyEnter || key == "y":
+		m.sendOutbound(ui.CopySelectionMsg{Text: m.viewport.CopySelection()})
+		m.viewport.ExitCopyMode()
+	case msg.Type == tea.KeyEsc || msg.Type == tea.KeyCtrlC || key == "q":
+		m.viewport.ExitCopyMode()
+	default:
+		return
+	}
+	m.updateScrollState()
+}
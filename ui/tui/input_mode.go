@@ -195,11 +195,21 @@ func (c *inputController) handleNormalKey(msg tea.KeyMsg) {
 		return
 	}
 
+	// A showing ghost (rune.input.ghost) takes Right and Tab ahead of
+	// any bind: both accept it, as in fish.
+	if (msg.Type == tea.KeyRight || msg.Type == tea.KeyTab) && !msg.Alt {
+		oldValue, oldCursor := c.input.Value(), c.input.Position()
+		if c.input.AcceptGhost() {
+			c.reportInputUpdate(oldValue, oldCursor)
+			return
+		}
+	}
+
 	keyStr := keyToString(msg)
 	if keyStr != "" && c.isBound(keyStr) {
 		// Alt-modified runes are chords, not typing: they never reach
 		// the input widget, so the empty-input guard doesn't apply.
-		isPrintable := msg.Type == tea.KeyRunes && !msg.Alt
+		isPrintable := (msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace) && !msg.Alt
 		if !isPrintable || c.input.Value() == "" {
 			c.notify(ui.ExecuteBindMsg(keyStr))
 			return
@@ -274,6 +284,11 @@ func (c *inputController) handlePaste(msg tea.KeyMsg) {
 			c.closePicker(false, "")
 		}
 		c.mode = ModeCompose
+		// The composer already holds the paste; Lua may still turn a
+		// multi-line one into commands or an $EDITOR session.
+		if text := string(msg.Runes); strings.ContainsAny(text, "\r\n") {
+			c.notify(ui.MultilinePasteMsg{Text: text})
+		}
 		return
 	}
 	if wasInline {
@@ -378,11 +393,22 @@ func (c *inputController) handleModalKey(msg tea.KeyMsg) {
 		}
 
 	case tea.KeyRunes:
+		// Alt+1-9 picks the numbered row; a plain digit is part of the
+		// query, so searching for numbers still works.
+		if msg.Alt && len(msg.Runes) == 1 && msg.Runes[0] >= '1' && msg.Runes[0] <= '9' {
+			if item, ok := c.input.PickerVisible(int(msg.Runes[0] - '0')); ok {
+				c.closePicker(true, item.GetValue())
+			}
+			return
+		}
 		c.input.PickerFilter(c.input.PickerQuery() + string(msg.Runes))
 
 	case tea.KeySpace:
 		c.input.PickerFilter(c.input.PickerQuery() + " ")
 
+	case tea.KeyCtrlR:
+		c.input.PickerToggleMatch()
+
 	case tea.KeyBackspace:
 		query := []rune(c.input.PickerQuery())
 		if len(query) > 0 {
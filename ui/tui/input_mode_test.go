@@ -273,6 +273,27 @@ func TestMultilinePasteEntersComposerLosslessly(t *testing.T) {
 	}
 }
 
+// TestMultilinePasteReportsToLua verifies only a paste spanning lines is
+// reported for Lua's paste policy, and only after it is in the composer.
+func TestMultilinePasteReportsToLua(t *testing.T) {
+	h := newControllerHarness()
+	h.ctl.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("safe\x1b[31m"), Paste: true})
+	h.ctl.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("north\nsouth"), Paste: true})
+
+	var pastes []ui.MultilinePasteMsg
+	for _, ev := range h.events {
+		if p, ok := ev.(ui.MultilinePasteMsg); ok {
+			pastes = append(pastes, p)
+		}
+	}
+	if len(pastes) != 1 || pastes[0].Text != "north\nsouth" {
+		t.Fatalf("paste reports = %+v, want the multi-line paste only", pastes)
+	}
+	if !h.ctl.input.IsComposing() {
+		t.Fatal("multi-line paste should still land in the composer")
+	}
+}
+
 // TestCtrlJInsertsComposerNewline pins the portable terminal representation
 // of Ctrl+Enter. It inserts LF and transitions an ordinary draft into the
 // visible composer instead of submitting it or delegating to Lua.
@@ -588,6 +609,71 @@ func TestInlineTabReportsCompletedInput(t *testing.T) {
 	}
 }
 
+// A modal picker opened with a seeded query and prefix matching shows
+// only the items starting with it; Ctrl+R flips to fuzzy matching over
+// the same query and says so in the header.
+func TestModalPickerTogglesPrefixAndFuzzy(t *testing.T) {
+	h := newControllerHarness()
+	h.ctl.input.SetSize(60, 3)
+	h.ctl.ShowPicker(ui.ShowPickerMsg{
+		Title:      "History",
+		Items:      []ui.PickerItem{{Text: "say look", Value: "1"}, {Text: "look", Value: "2"}},
+		CallbackID: "cb",
+		Query:      "lo",
+		Match:      "prefix",
+	})
+
+	view := runetext.StripANSI(h.ctl.input.View())
+	if !strings.Contains(view, "History (prefix): lo") || strings.Contains(view, "say look") {
+		t.Fatalf("prefix picker view:\n%s", view)
+	}
+
+	h.ctl.HandleKey(tea.KeyMsg{Type: tea.KeyCtrlR})
+	view = runetext.StripANSI(h.ctl.input.View())
+	if !strings.Contains(view, "History (fuzzy): lo") || !strings.Contains(view, "say look") {
+		t.Fatalf("fuzzy picker view:\n%s", view)
+	}
+	if h.ctl.mode != ModePickerModal {
+		t.Fatal("toggling must keep the picker open")
+	}
+}
+
+// TestGhostAcceptedAheadOfBinds verifies a showing ghost is drawn past
+// the typed text and taken by Tab ahead of its bind, while one the
+// input no longer extends is neither drawn nor accepted.
+func TestGhostAcceptedAheadOfBinds(t *testing.T) {
+	h := newControllerHarness()
+	h.bound["tab"] = true
+	h.ctl.input.SetSize(60, 3)
+	h.ctl.input.SetGhost("cast fireball")
+
+	h.ctl.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("cast f")})
+	if view := runetext.StripANSI(h.ctl.input.View()); !strings.Contains(view, "cast fireball") {
+		t.Fatalf("ghost not drawn:\n%s", view)
+	}
+	h.ctl.HandleKey(tea.KeyMsg{Type: tea.KeyTab})
+	if got := h.ctl.input.Value(); got != "cast fireball" {
+		t.Fatalf("value = %q, want the accepted ghost", got)
+	}
+	if binds := h.executeBinds(); len(binds) != 0 {
+		t.Fatalf("tab reached the bind while a ghost showed: %v", binds)
+	}
+	if changes := h.inputChanges(); changes[len(changes)-1].Text != "cast fireball" {
+		t.Fatalf("accepted ghost not reported: %v", changes)
+	}
+
+	h.ctl.input.Reset()
+	h.ctl.input.SetGhost("cast fireball")
+	h.ctl.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("cast x")})
+	if ghost := h.ctl.input.Ghost(); ghost != "" {
+		t.Fatalf("diverged input still shows ghost %q", ghost)
+	}
+	h.ctl.HandleKey(tea.KeyMsg{Type: tea.KeyTab})
+	if binds := h.executeBinds(); len(binds) != 1 {
+		t.Fatalf("tab without a ghost should reach the bind, got %v", binds)
+	}
+}
+
 // TestReboundHomeOverridesInputCursor pins the override path the docs
 // promise: a user bind on "home" wins over the widget's cursor
 // movement even while a draft is in progress (non-printable bound keys
@@ -606,3 +692,29 @@ func TestReboundHomeOverridesInputCursor(t *testing.T) {
 		t.Fatalf("bound home moved the cursor to %d, want untouched at %d", pos, len("look"))
 	}
 }
+
+// Alt+digit in a modal picker selects that numbered row at once, while
+// a plain digit keeps filtering.
+func TestModalPickerAltDigitSelects(t *testing.T) {
+	h := newControllerHarness()
+	h.ctl.input.SetSize(60, 3)
+	h.ctl.ShowPicker(ui.ShowPickerMsg{
+		Items:      []ui.PickerItem{{Text: "room 1", Value: "a"}, {Text: "room 2", Value: "b"}, {Text: "room 3", Value: "c"}},
+		CallbackID: "cb",
+	})
+
+	h.ctl.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("3")})
+	if got := h.ctl.input.PickerQuery(); got != "3" || h.ctl.mode != ModePickerModal {
+		t.Fatalf("plain digit: query %q, mode %v", got, h.ctl.mode)
+	}
+	h.ctl.HandleKey(tea.KeyMsg{Type: tea.KeyBackspace})
+
+	h.ctl.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2"), Alt: true})
+	selects := h.pickerSelects()
+	if len(selects) != 1 || !selects[0].Accepted || selects[0].Value != "b" {
+		t.Fatalf("alt+2 settled %+v, want b accepted", selects)
+	}
+	if h.ctl.mode == ModePickerModal {
+		t.Fatal("alt+2 should close the picker")
+	}
+}
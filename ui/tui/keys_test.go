@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/mmcdole/rune/ui"
+)
+
+// TestKeyToStringNames pins the names Lua binds use for modifier
+// combinations, so a bind written against the docs keeps matching.
+func TestKeyToStringNames(t *testing.T) {
+	cases := []struct {
+		msg  tea.KeyMsg
+		want string
+	}{
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}, "j"},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1"), Alt: true}, "alt+1"},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x"), Alt: true}, "alt+x"},
+		{tea.KeyMsg{Type: tea.KeyCtrlR}, "ctrl+r"},
+		{tea.KeyMsg{Type: tea.KeyCtrlAt}, "ctrl+space"},
+		{tea.KeyMsg{Type: tea.KeySpace}, "space"},
+		{tea.KeyMsg{Type: tea.KeySpace, Alt: true}, "alt+space"},
+		{tea.KeyMsg{Type: tea.KeyF1}, "f1"},
+		{tea.KeyMsg{Type: tea.KeyF13}, "shift+f1"},
+		{tea.KeyMsg{Type: tea.KeyF20}, "shift+f8"},
+		{tea.KeyMsg{Type: tea.KeyF5, Alt: true}, "alt+f5"},
+		{tea.KeyMsg{Type: tea.KeyLeft, Alt: true}, "alt+left"},
+		{tea.KeyMsg{Type: tea.KeyShiftUp}, "shift+up"},
+		{tea.KeyMsg{Type: tea.KeyCtrlShiftRight}, "ctrl+shift+right"},
+		{tea.KeyMsg{Type: tea.KeyCtrlShiftHome}, "ctrl+shift+home"},
+		{tea.KeyMsg{Type: tea.KeyCtrlUp, Alt: true}, "alt+ctrl+up"},
+	}
+	for _, c := range cases {
+		if got := keyToString(c.msg); got != c.want {
+			t.Errorf("keyToString(%v) = %q, want %q", c.msg, got, c.want)
+		}
+	}
+}
+
+// TestBoundSpaceIsPrintable verifies a "space" bind, like any
+// printable hotkey, fires only on empty input so typing still works.
+func TestBoundSpaceIsPrintable(t *testing.T) {
+	h := newControllerHarness()
+	h.bound["space"] = true
+
+	h.ctl.HandleKey(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	h.ctl.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("say")})
+	h.ctl.HandleKey(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+
+	if binds := h.executeBinds(); len(binds) != 1 || binds[0] != ui.ExecuteBindMsg("space") {
+		t.Fatalf("binds = %v, want one space bind on the empty input", binds)
+	}
+	if got := h.ctl.input.Value(); got != "say " {
+		t.Fatalf("value = %q, want the typed space kept", got)
+	}
+}
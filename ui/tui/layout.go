@@ -32,18 +32,26 @@ func (m *Model) getWidget(name string) widget.Widget {
 	return nil
 }
 
-// layoutDock sizes and renders one dock's widgets in a single pass,
-// returning the joined view and the dock's total height. A widget with
-// PreferredHeight 0 (hidden bar, collapsed pane) is skipped entirely.
-func (m *Model) layoutDock(entries []ui.LayoutEntry) (string, int) {
-	var parts []string
-	totalHeight := 0
+// dockItem is one widget of a dock with the height it will render at.
+type dockItem struct {
+	w widget.Widget
+	h int
+}
 
+// measureDock sizes one dock's widgets, skipping any with
+// PreferredHeight 0 (hidden bar, collapsed pane). A pane's pinned
+// height (rune.pane.resize) wins over its layout entry's. bottom says
+// which dock this is, for the side a pane's border faces.
+func (m *Model) measureDock(entries []ui.LayoutEntry, bottom bool) []dockItem {
+	var items []dockItem
 	for _, entry := range entries {
 		w := m.getWidget(entry.Name)
 		if w == nil {
 			continue
 		}
+		if p, ok := w.(*widget.Pane); ok {
+			p.SetFrame(paneFrame(entry, bottom))
+		}
 
 		// Width can affect intrinsic height (notably soft-wrapped composer
 		// text), so make the current width available before asking for it.
@@ -58,13 +66,86 @@ func (m *Model) layoutDock(entries []ui.LayoutEntry) (string, int) {
 		if h == 0 {
 			h = preferred
 		}
+		if p, ok := w.(*widget.Pane); ok && p.FixedHeight() > 0 {
+			h = p.FixedHeight()
+		}
+		items = append(items, dockItem{w: w, h: h})
+	}
+	return items
+}
+
+// paneFrame resolves a layout entry's border setting. By default a
+// pane gets one rule, between it and the output: a second rule
+// against the screen edge or the input would only double up.
+func paneFrame(entry ui.LayoutEntry, bottom bool) widget.Frame {
+	f := widget.Frame{Title: !entry.NoTitle}
+	switch entry.Border {
+	case "top":
+		f.Top = true
+	case "bottom":
+		f.Bottom = true
+	case "both":
+		f.Top, f.Bottom = true, true
+	case "none":
+	default:
+		f.Top, f.Bottom = bottom, !bottom
+	}
+	return f
+}
+
+// fitDocks shrinks panes until the docks leave the viewport at least
+// one row: the focused pane first (so growing it past the screen just
+// stops), then the rest from the last docked. Panes keep their
+// minimum height; bars and the input line are never shrunk.
+func (m *Model) fitDocks(top, bottom []dockItem) {
+	excess := dockHeight(top) + dockHeight(bottom) - (m.height - 1)
+	if excess <= 0 {
+		return
+	}
+	var order []*dockItem
+	for _, dock := range [][]dockItem{bottom, top} {
+		for i := len(dock) - 1; i >= 0; i-- {
+			p, ok := dock[i].w.(*widget.Pane)
+			if !ok {
+				continue
+			}
+			if p.Name == m.panes.Focused() {
+				order = append([]*dockItem{&dock[i]}, order...)
+			} else {
+				order = append(order, &dock[i])
+			}
+		}
+	}
+	for _, item := range order {
+		cut := min(excess, item.h-widget.MinPaneHeight)
+		if cut <= 0 {
+			continue
+		}
+		item.h -= cut
+		excess -= cut
+		if excess == 0 {
+			return
+		}
+	}
+}
 
-		w.SetSize(m.width, h)
-		parts = append(parts, w.View())
-		totalHeight += h
+func dockHeight(items []dockItem) int {
+	total := 0
+	for _, item := range items {
+		total += item.h
 	}
+	return total
+}
 
-	return strings.Join(parts, "\n"), totalHeight
+// renderDock renders measured widgets at their heights, returning the
+// joined view and the dock's total height.
+func renderDock(items []dockItem, width int) (string, int) {
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		item.w.SetSize(width, item.h)
+		parts = append(parts, item.w.View())
+	}
+	return strings.Join(parts, "\n"), dockHeight(items)
 }
 
 // View implements tea.Model.
@@ -76,8 +157,10 @@ func (m *Model) View() string {
 
 	// Calculate layout fresh each render - guarantees no stale dimensions
 	cfg := m.getLayout()
-	topView, topHeight := m.layoutDock(cfg.Top)
-	bottomView, bottomHeight := m.layoutDock(cfg.Bottom)
+	top, bottom := m.measureDock(cfg.Top, false), m.measureDock(cfg.Bottom, true)
+	m.fitDocks(top, bottom)
+	topView, topHeight := renderDock(top, m.width)
+	bottomView, bottomHeight := renderDock(bottom, m.width)
 
 	viewportHeight := m.height - topHeight - bottomHeight
 	if viewportHeight < 1 {
@@ -86,6 +169,7 @@ func (m *Model) View() string {
 	// The viewport spans the full terminal width; splitRows wraps
 	// appended rows to the same m.width.
 	m.viewport.SetSize(m.width, viewportHeight)
+	m.viewportTop = topHeight
 
 	var parts []string
 	if topView != "" {
@@ -1,7 +1,9 @@
 package tui
 
 import (
+	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -16,20 +18,52 @@ import (
 	"github.com/mmcdole/rune/ui/tui/widget"
 )
 
-// tickMsg closes a 16ms output batch window: the first server line
-// after an idle period renders immediately and opens the window; lines
-// arriving inside it are batched to prevent excessive renders on fast
-// MUD output. Ticks are scheduled on demand only - an idle client has
-// no standing timer and zero wakeups.
+// tickMsg closes an output batch window (16ms by default): the first
+// server line after an idle period renders immediately and opens the
+// window; lines arriving inside it are batched to prevent excessive
+// renders on fast MUD output. Ticks are scheduled on demand only - an
+// idle client has no standing timer and zero wakeups.
 type tickMsg time.Time
 
-// doTick returns a command that closes the batch window after 16ms.
-func doTick() tea.Cmd {
-	return tea.Tick(16*time.Millisecond, func(t time.Time) tea.Msg {
+// Batch window bounds. A longer window renders less often, which helps
+// slow terminals and SSH; the minimum keeps a flood from turning the
+// tick chain into a busy loop (rune.ui.flush_interval sets it).
+const (
+	defaultFlushInterval = 16 * time.Millisecond
+	minFlushInterval     = 4 * time.Millisecond
+	maxFlushInterval     = time.Second
+)
+
+// minFlushPressure is the fewest batched rows that flush a window
+// early; a taller window raises it to a screenful.
+const minFlushPressure = 50
+
+// doTick returns a command that closes the batch window after d.
+func doTick(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg {
 		return tickMsg(t)
 	})
 }
 
+// flashDuration is how long a visual bell keeps the bars inverted:
+// long enough to catch the eye, short enough not to hide the status.
+const flashDuration = 150 * time.Millisecond
+
+// flashEndMsg reverts the visual bell started as flash number seq. A
+// bell rung mid-flash starts a new flash; the older timer is ignored.
+type flashEndMsg int
+
+// inputRetryInterval is how often queued input is offered to a busy
+// engine again.
+const inputRetryInterval = 10 * time.Millisecond
+
+// defaultInputQueue is how many submissions may wait for a busy engine
+// before new ones are dropped (rune.input.queue changes it).
+const defaultInputQueue = 256
+
+// inputRetryMsg retries the queued input. At most one is in flight.
+type inputRetryMsg struct{}
+
 // Model is the main Bubble Tea model for the TUI. It routes messages
 // between the session and the widgets; input-mode policy lives in the
 // inputController, layout and rendering in layout.go.
@@ -42,6 +76,32 @@ type Model struct {
 	viewport   *widget.Viewport
 	input      *widget.Input
 	panes      *widget.PaneManager
+	// flashSeq numbers visual bells; flashing is true while the bars
+	// are inverted.
+	flashSeq int
+	flashing bool
+	// inputMark is the absolute scrollback row (ScrollbackBuffer.Base)
+	// the last submitted command's output starts at, for jumping back
+	// to "where I last typed"; -1 before the first submission.
+	inputMark int
+	// dedupe collapses runs of identical server lines into one row
+	// with an " (xN)" counter (rune.ui.dedupe). The run is the single
+	// row runText at absolute row runAt, seen runCount times.
+	dedupe   bool
+	runText  string
+	runAt    int
+	runCount int
+	// grepDumps are the absolute row ranges [start, end) that greps
+	// wrote to the output; a later grep skips them, so it never
+	// matches its own earlier results.
+	grepDumps [][2]int
+	// nowrap keeps each new output line on one row, clipped and
+	// horizontally scrollable, instead of soft-wrapping it
+	// (rune.ui.wrap(false)).
+	nowrap bool
+	// viewportTop is the screen row where the viewport began in the
+	// last View (the top dock's height), for mapping mouse clicks.
+	viewportTop int
 
 	// Input-mode state machine (normal / modal picker / inline picker)
 	inputCtl *inputController
@@ -67,13 +127,23 @@ type Model struct {
 	// idle->hot transition and re-armed only from handleTick while
 	// output is still flowing.
 	flushScheduled bool
+	// flushInterval is the batch window length.
+	flushInterval time.Duration
+	// queuedInput holds submissions the engine had no room for, oldest
+	// first; they are retried every inputRetryInterval and always go
+	// out before newer input. inputQueueLimit caps it, droppedInputs
+	// counts the submissions refused once it was full.
+	queuedInput     []input.Submission
+	inputQueueLimit int
+	inputRetrying   bool
+	droppedInputs   int
 }
 
 // NewModel creates a new TUI model.
 func NewModel(inputChan chan<- input.Submission, outbound chan<- ui.UIEvent) *Model {
 	styles := style.DefaultStyles()
 	scrollback := widget.NewScrollbackBuffer(100000)
-	viewport := widget.NewViewport(scrollback)
+	viewport := widget.NewViewport(scrollback, styles)
 	input := widget.NewInput(styles)
 	panes := widget.NewPaneManager(styles)
 
@@ -85,12 +155,16 @@ func NewModel(inputChan chan<- input.Submission, outbound chan<- ui.UIEvent) *Mo
 		inputChan:  inputChan,
 		outbound:   outbound,
 		widgets:    make(map[string]widget.Widget),
+		inputMark:  -1,
+
+		flushInterval:   defaultFlushInterval,
+		inputQueueLimit: defaultInputQueue,
 	}
 	m.inputCtl = newInputController(input, m.sendOutbound, m.sendLine, m.isBound, m.handleScrollKey)
 
 	// Register static widgets
 	m.widgets["input"] = input
-	m.widgets["separator"] = widget.NewSeparator()
+	m.widgets["separator"] = widget.NewSeparator(styles)
 
 	return m
 }
@@ -110,8 +184,16 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tickMsg:
 		return m.handleTick()
 	case tea.KeyMsg:
+		if m.viewport.InCopyMode() {
+			m.handleCopyKey(msg)
+			return m, nil
+		}
 		m.inputCtl.HandleKey(msg)
-		return m, nil
+		return m, m.scheduleInputRetry()
+	case inputRetryMsg:
+		m.inputRetrying = false
+		m.drainInput()
+		return m, m.scheduleInputRetry()
 	case tea.MouseMsg:
 		return m.handleMouse(msg)
 
@@ -124,7 +206,8 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleServerOutput(msg)
 
 	// Pane operations
-	case ui.PaneCreateMsg, ui.PaneWriteMsg, ui.PaneToggleMsg, ui.PaneSetVisibleMsg, ui.PaneClearMsg:
+	case ui.PaneCreateMsg, ui.PaneWriteMsg, ui.PaneToggleMsg, ui.PaneSetVisibleMsg, ui.PaneClearMsg,
+		ui.PaneResizeMsg, ui.PaneGrowMsg, ui.PaneLimitMsg, ui.PaneFocusMsg:
 		return m.handlePaneMsg(msg)
 
 	// Input control
@@ -142,6 +225,84 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ui.InputSetCursorMsg:
 		m.input.SetCursor(int(msg))
 		return m, nil
+	case ui.InputPromptMsg:
+		m.input.SetPrompt(string(msg))
+		return m, nil
+	case ui.InputPlaceholderMsg:
+		m.input.SetPlaceholder(string(msg))
+		return m, nil
+	case ui.InputMaskMsg:
+		m.input.SetMasked(bool(msg))
+		return m, nil
+	case ui.InputGhostMsg:
+		m.input.SetGhost(string(msg))
+		return m, nil
+	case ui.InputWordBreakMsg:
+		m.input.SetWordBreak(string(msg))
+		return m, nil
+	case ui.InputQueueMsg:
+		m.inputQueueLimit = max(int(msg), 0)
+		return m, nil
+
+	case ui.BellMsg:
+		return m, m.ring(msg)
+	case flashEndMsg:
+		if int(msg) == m.flashSeq {
+			m.setFlash(false)
+		}
+		return m, nil
+	case ui.JumpToInputMsg:
+		if m.inputMark >= 0 && m.viewport.ScrollToLine(m.inputMark) {
+			m.updateScrollState()
+		}
+		return m, nil
+	case ui.ClearScreenMsg:
+		// Blank rows push the screen into scrollback, as a terminal's
+		// clear does; the next output starts on an empty viewport. The
+		// terminal height covers the viewport whatever the layout.
+		m.flushPending()
+		m.appendRows(make([]string, m.height)...)
+		return m, nil
+	case ui.ClearScrollbackMsg:
+		// Batched rows go too: they arrived before the clear. The
+		// dedupe run ends with the row it was counting on.
+		m.pendingRows = nil
+		m.runText = ""
+		m.viewport.ExitCopyMode()
+		m.scrollback.Clear()
+		m.viewport.GotoBottom()
+		m.updateScrollState()
+		return m, nil
+	case ui.SetThemeMsg:
+		styles := style.DefaultStyles().WithTheme(ui.Theme(msg))
+		m.input.SetStyles(styles)
+		m.viewport.SetStyles(styles)
+		m.panes.SetStyles(styles)
+		if sep, ok := m.widgets["separator"].(*widget.Separator); ok {
+			sep.SetStyles(styles)
+		}
+		return m, nil
+	case ui.SetWrapMsg:
+		m.nowrap = !bool(msg)
+		return m, nil
+	case ui.SetSplitMsg:
+		m.viewport.SetSplit(bool(msg))
+		return m, nil
+	case ui.SetDedupeMsg:
+		m.dedupe = bool(msg)
+		m.runText = ""
+		return m, nil
+	case ui.SetFlushIntervalMsg:
+		m.flushInterval = min(max(time.Duration(msg), minFlushInterval), maxFlushInterval)
+		return m, nil
+	case ui.GrepScrollbackMsg:
+		m.grepScrollback(msg)
+		return m, nil
+	case ui.EnterCopyModeMsg:
+		if m.viewport.EnterCopyMode() {
+			m.updateScrollState()
+		}
+		return m, nil
 
 	// Clipboard (from Lua). OSC 52 asks the terminal emulator to set
 	// the system clipboard; it renders nothing, so it bypasses the
@@ -169,6 +330,17 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.panes.Get(msg.Name).ScrollDown(msg.Lines)
 		}
 		return m, nil
+	case ui.PaneScrollColumnsMsg:
+		if msg.Name == "main" {
+			m.viewport.ScrollColumns(msg.Cols)
+		}
+		return m, nil
+	case ui.PaneScrollPagesMsg:
+		if msg.Name == "main" {
+			m.viewport.ScrollPages(msg.Pages)
+			m.updateScrollState()
+		}
+		return m, nil
 	case ui.PaneScrollToTopMsg:
 		if msg.Name == "main" {
 			m.viewport.GotoTop()
@@ -209,7 +381,14 @@ func (m *Model) handleTick() (tea.Model, tea.Cmd) {
 	}
 	m.flushPending()
 	m.flushScheduled = true
-	return m, doTick()
+	return m, doTick(m.flushInterval)
+}
+
+// flushPressure is how many batched rows flush a window before its
+// tick: once a screenful is waiting, holding it longer only makes the
+// eventual render jump further.
+func (m *Model) flushPressure() int {
+	return max(m.height, minFlushPressure)
 }
 
 // flushPending appends all batched server rows to the scrollback.
@@ -257,6 +436,7 @@ func (m *Model) syncBars(content map[string]ui.BarContent) {
 		}
 		if bar, isBar := w.(*widget.Bar); isBar {
 			bar.SetContent(barContent)
+			bar.SetFlash(m.flashing)
 		}
 	}
 
@@ -266,17 +446,25 @@ func (m *Model) syncBars(content map[string]ui.BarContent) {
 func (m *Model) handleServerOutput(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case ui.PrintLineMsg:
-		rows := splitRows(string(msg), m.width)
+		rows := splitRows(string(msg), m.wrapWidth())
+		if m.dedupe && m.repeatLine(rows) {
+			return m, nil
+		}
 		if m.flushScheduled {
-			// Inside a batch window: coalesce with the burst.
+			// Inside a batch window: coalesce with the burst, unless
+			// the burst has grown past the pressure mark. The window's
+			// tick still comes and flushes whatever follows.
 			m.pendingRows = append(m.pendingRows, rows...)
+			if len(m.pendingRows) >= m.flushPressure() {
+				m.flushPending()
+			}
 			return m, nil
 		}
 		// Idle: render this line now and open a batch window so a
 		// following burst coalesces instead of rendering line-by-line.
 		m.appendRows(rows...)
 		m.flushScheduled = true
-		return m, doTick()
+		return m, doTick(m.flushInterval)
 	case ui.EchoLineMsg:
 		// Flush batched server lines first so the echo cannot render
 		// ahead of output that arrived before it.
@@ -292,6 +480,38 @@ func (m *Model) handleServerOutput(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// nextRow is the absolute row number (ScrollbackBuffer.Base) the next
+// appended row will get, counting rows still batched.
+func (m *Model) nextRow() int {
+	return m.scrollback.Base() + m.scrollback.Count() + len(m.pendingRows)
+}
+
+// repeatLine handles dedupe for one server line. A single-row line
+// identical to the run on the newest row bumps the run's counter and
+// rewrites that row in place, batched or not, and reports true. Any
+// other line starts a new run (if it is one row) and reports false,
+// leaving it to be appended. Echoes and notices end a run simply by
+// landing after it.
+func (m *Model) repeatLine(rows []string) bool {
+	if len(rows) != 1 {
+		m.runText = ""
+		return false
+	}
+	if rows[0] != m.runText || m.runAt != m.nextRow()-1 {
+		m.runText, m.runAt, m.runCount = rows[0], m.nextRow(), 1
+		return false
+	}
+	m.runCount++
+	row := fmt.Sprintf("%s (x%d)", m.runText, m.runCount)
+	if n := len(m.pendingRows); n > 0 {
+		m.pendingRows[n-1] = row
+	} else {
+		m.scrollback.SetLast(row)
+		m.viewport.OnLastRowChanged()
+	}
+	return true
+}
+
 func (m *Model) handlePaneMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case ui.PaneCreateMsg:
@@ -304,17 +524,110 @@ func (m *Model) handlePaneMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.panes.SetVisible(msg.Name, msg.Visible)
 	case ui.PaneClearMsg:
 		m.panes.Clear(msg.Name)
+	case ui.PaneResizeMsg:
+		m.panes.Resize(msg.Name, msg.Height)
+	case ui.PaneGrowMsg:
+		m.panes.Grow(msg.Name, msg.Rows)
+	case ui.PaneLimitMsg:
+		m.panes.Limit(msg.Name, msg.Lines, msg.Bytes)
+	case ui.PaneFocusMsg:
+		m.panes.Focus(msg.Name)
 	}
 	return m, nil
 }
 
+// grepScrollback handles rune.scrollback.grep. Rows match one at a
+// time, so a line soft-wrapped across rows matches only within a row.
+// The newest matches are kept; the pane or output gets them oldest
+// first, under the label and the count. Rows earlier greps wrote to
+// the output are skipped.
+func (m *Model) grepScrollback(msg ui.GrepScrollbackMsg) {
+	m.flushPending()
+	base := m.scrollback.Base()
+	m.grepDumps = slices.DeleteFunc(m.grepDumps, func(r [2]int) bool { return r[1] <= base })
+	dump := len(m.grepDumps) - 1 // the newest dump not yet passed
+	var hits []string
+	total := 0
+	for i := m.scrollback.Count() - 1; i >= 0; i-- {
+		for dump >= 0 && m.grepDumps[dump][0] > base+i {
+			dump--
+		}
+		if dump >= 0 && base+i < m.grepDumps[dump][1] {
+			continue
+		}
+		row := m.scrollback.At(i)
+		var spans [][2]int
+		for _, sp := range msg.Pattern.FindAllStringIndex(text.StripANSI(row), -1) {
+			if sp[0] < sp[1] {
+				spans = append(spans, [2]int{sp[0], sp[1]})
+			}
+		}
+		if len(spans) == 0 {
+			continue
+		}
+		total++
+		if len(hits) < msg.Limit {
+			hits = append(hits, text.Highlight(row, spans, msg.Highlight))
+		}
+	}
+
+	summary := fmt.Sprintf("%s - %d matches", msg.Label, total)
+	if total == 1 {
+		summary = msg.Label + " - 1 match"
+	}
+	if total > len(hits) {
+		summary += fmt.Sprintf(", newest %d shown", len(hits))
+	}
+	out := make([]string, 0, len(hits)+1)
+	out = append(out, summary)
+	for i := len(hits) - 1; i >= 0; i-- {
+		out = append(out, hits[i])
+	}
+	if msg.Pane == "" {
+		start := m.scrollback.Base() + m.scrollback.Count()
+		m.appendMessage(strings.Join(out, "\n"))
+		m.grepDumps = append(m.grepDumps, [2]int{start, m.scrollback.Base() + m.scrollback.Count()})
+		return
+	}
+	for _, line := range out {
+		m.panes.Write(msg.Pane, line)
+	}
+}
+
+// ring handles rune.bell. BEL goes to stderr like the OSC 52 writes:
+// bubbletea owns stdout, and the terminal hears both. The flash
+// inverts every bar for flashDuration.
+func (m *Model) ring(msg ui.BellMsg) tea.Cmd {
+	if msg.Audible {
+		os.Stderr.WriteString("\a") //nolint:errcheck // best-effort, like the clipboard write
+	}
+	if !msg.Visual {
+		return nil
+	}
+	m.flashSeq++
+	m.setFlash(true)
+	seq := m.flashSeq
+	return tea.Tick(flashDuration, func(time.Time) tea.Msg {
+		return flashEndMsg(seq)
+	})
+}
+
+func (m *Model) setFlash(on bool) {
+	m.flashing = on
+	for _, w := range m.widgets {
+		if bar, isBar := w.(*widget.Bar); isBar {
+			bar.SetFlash(on)
+		}
+	}
+}
+
 // wheelScrollLines is how far one mouse-wheel tick scrolls the main
 // viewport. Matches the common terminal-emulator default.
 const wheelScrollLines = 3
 
-// handleMouse scrolls the main viewport on wheel events. The terminal
-// mouse is captured for this (which is why text selection needs
-// shift+drag); everything else is ignored.
+// handleMouse scrolls the main viewport on wheel events and reports
+// left clicks on links. The terminal mouse is captured for this (which
+// is why text selection needs shift+drag); everything else is ignored.
 func (m *Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 	if msg.Action != tea.MouseActionPress {
 		return m, nil
@@ -326,10 +639,27 @@ func (m *Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 	case tea.MouseButtonWheelDown:
 		m.viewport.ScrollDown(wheelScrollLines)
 		m.updateScrollState()
+	case tea.MouseButtonLeft:
+		m.clickLink(msg.X, msg.Y)
 	}
 	return m, nil
 }
 
+// clickLink sends a LinkClickedMsg when screen cell (x, y) lies on a
+// link in the output viewport. The row is taken from the last painted
+// frame, not recomputed from the scroll offset, so a click while
+// scrolled back (or just as new output lands) opens the link under the
+// pointer rather than whatever now occupies that buffer line.
+func (m *Model) clickLink(x, y int) {
+	row, ok := m.viewport.RowAt(y - m.viewportTop)
+	if !ok {
+		return
+	}
+	if link, ok := util.LinkAt(row, x); ok {
+		m.sendOutbound(ui.LinkClickedMsg{URL: link.URL, Explicit: link.Explicit})
+	}
+}
+
 // splitRows shapes a message into physical scrollback rows: one row
 // per line break, tabs expanded per row so columns restart on every
 // row, rows wider than the terminal word-wrapped. Rows are final at
@@ -355,12 +685,23 @@ func (m *Model) appendRows(rows ...string) {
 
 // appendMessage shapes text into rows and appends them.
 func (m *Model) appendMessage(text string) {
-	m.appendRows(splitRows(text, m.width)...)
+	m.appendRows(splitRows(text, m.wrapWidth())...)
 }
 
-// sendLine offers a submitted input snapshot to the session. It rejects
-// oversized verbatim drafts or a busy engine with a visible warning rather
-// than blocking the render loop; false tells the controller to retain them.
+// wrapWidth is the width new rows wrap at: the window width, or 0
+// (no wrapping) with rune.ui.wrap(false).
+func (m *Model) wrapWidth() int {
+	if m.nowrap {
+		return 0
+	}
+	return m.width
+}
+
+// sendLine offers a submitted input snapshot to the session. Input the
+// busy engine has no room for waits in a bounded queue, retried on a
+// timer, rather than blocking the render loop. Oversized verbatim drafts
+// and input arriving with the queue full are rejected with a visible
+// warning; false tells the controller to retain them.
 func (m *Model) sendLine(submission input.Submission) bool {
 	if submission.Mode == input.ModeVerbatim {
 		lineCount := 1 + strings.Count(submission.Text, "\n")
@@ -369,15 +710,57 @@ func (m *Model) sendLine(submission input.Submission) bool {
 			return false
 		}
 	}
+	// Queued input goes first, or commands would reorder.
+	if len(m.queuedInput) == 0 && m.offerInput(submission) {
+		return true
+	}
+	if len(m.queuedInput) >= m.inputQueueLimit {
+		m.droppedInputs++
+		m.sendOutbound(ui.InputDroppedMsg{Total: m.droppedInputs})
+		m.appendMessage(text.Red("[WARNING] Input not sent - engine lagging"))
+		return false
+	}
+	m.queuedInput = append(m.queuedInput, submission)
+	return true
+}
+
+// offerInput hands a submission to the session without blocking.
+func (m *Model) offerInput(submission input.Submission) bool {
 	select {
 	case m.inputChan <- submission:
+		// Batched rows arrived before the submission, so the command's
+		// echo and output start after them.
+		m.inputMark = m.scrollback.Base() + m.scrollback.Count() + len(m.pendingRows)
 		return true
 	default:
-		m.appendMessage(text.Red("[WARNING] Input not sent - engine lagging"))
 		return false
 	}
 }
 
+// drainInput sends queued submissions, oldest first, until the engine
+// is full again.
+func (m *Model) drainInput() {
+	sent := 0
+	for sent < len(m.queuedInput) && m.offerInput(m.queuedInput[sent]) {
+		sent++
+	}
+	m.queuedInput = m.queuedInput[sent:]
+	if len(m.queuedInput) == 0 {
+		m.queuedInput = nil
+	}
+}
+
+// scheduleInputRetry arms the retry timer while input is queued.
+func (m *Model) scheduleInputRetry() tea.Cmd {
+	if len(m.queuedInput) == 0 || m.inputRetrying {
+		return nil
+	}
+	m.inputRetrying = true
+	return tea.Tick(inputRetryInterval, func(time.Time) tea.Msg {
+		return inputRetryMsg{}
+	})
+}
+
 const (
 	maxVerbatimBytes = 256 * 1024
 	maxVerbatimLines = 1000
@@ -411,7 +794,11 @@ func (m *Model) updateScrollState() {
 	if mode != widget.ModeLive {
 		modeStr = "scrolled"
 	}
-	m.sendOutbound(ui.ScrollStateChangedMsg{Mode: modeStr, NewLines: newLines})
+	m.sendOutbound(ui.ScrollStateChangedMsg{
+		Mode:     modeStr,
+		NewLines: newLines,
+		Percent:  m.viewport.ScrollPercent(),
+	})
 }
 
 // handleScrollKey handles viewport scrolling keys.
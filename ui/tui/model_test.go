@@ -2,11 +2,15 @@ package tui
 
 import (
 	"fmt"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/mmcdole/rune/input"
+	"github.com/mmcdole/rune/text"
 	"github.com/mmcdole/rune/ui"
 	"github.com/mmcdole/rune/ui/tui/widget"
 )
@@ -127,6 +131,37 @@ func TestBurstCoalescesInBatchWindow(t *testing.T) {
 	}
 }
 
+// TestBurstFlushesEarlyUnderPressure verifies a window that has
+// batched a screenful flushes without waiting for its tick, and that
+// the configured interval is clamped to its floor.
+func TestBurstFlushesEarlyUnderPressure(t *testing.T) {
+	m := newBareModel(t)
+
+	next, _ := m.Update(ui.SetFlushIntervalMsg(time.Millisecond))
+	m = next.(*Model)
+	if m.flushInterval != minFlushInterval {
+		t.Fatalf("flush interval = %v, want clamped to %v", m.flushInterval, minFlushInterval)
+	}
+
+	next, _ = m.Update(ui.PrintLineMsg("opens the window"))
+	m = next.(*Model)
+	for i := 1; i < minFlushPressure; i++ {
+		next, _ = m.Update(ui.PrintLineMsg(fmt.Sprintf("line %d", i)))
+		m = next.(*Model)
+	}
+	if got := m.scrollback.Count(); got != 1 {
+		t.Fatalf("below the pressure mark, scrollback has %d lines, want 1", got)
+	}
+	next, _ = m.Update(ui.PrintLineMsg("one too many"))
+	m = next.(*Model)
+	if got := m.scrollback.Count(); got != minFlushPressure+1 {
+		t.Fatalf("at the pressure mark, scrollback has %d lines, want %d", got, minFlushPressure+1)
+	}
+	if !m.flushScheduled {
+		t.Fatal("an early flush must leave the window's tick armed")
+	}
+}
+
 // TestTickStopsWhenOutputGoesQuiet is the no-perpetual-tick regression
 // guard: a tick that flushed lines re-arms the window, and the first
 // tick that finds nothing pending ends the chain, so an idle client
@@ -209,6 +244,36 @@ func TestMultiLinePrintSplitsIntoRows(t *testing.T) {
 	wantScrollback(t, m, "row 1", "row 2", "row 3")
 }
 
+// TestWrapOffKeepsLinesWhole verifies rune.ui.wrap(false): a line
+// wider than the window stays one row, and shift+right scrolling of
+// the main pane slices it sideways.
+func TestWrapOffKeepsLinesWhole(t *testing.T) {
+	m := newBareModel(t)
+	wide := strings.Repeat("a", 80) + strings.Repeat("b", 20)
+
+	next, _ := m.Update(ui.SetWrapMsg(false))
+	m = next.(*Model)
+	next, _ = m.Update(ui.PrintLineMsg(wide))
+	m = next.(*Model)
+	wantScrollback(t, m, wide)
+
+	next, _ = m.Update(ui.PaneScrollColumnsMsg{Name: "main", Cols: 20})
+	m = next.(*Model)
+	m.View()
+	for y := 0; ; y++ {
+		row, ok := m.viewport.RowAt(y)
+		if !ok {
+			t.Fatal("wide row not painted")
+		}
+		if strings.Contains(row, "a") {
+			if !strings.HasSuffix(row, strings.Repeat("b", 20)) {
+				t.Errorf("scrolled row = %q, want it to end in the b run", row)
+			}
+			break
+		}
+	}
+}
+
 // TestMultiLinePrintSplitsInsideBatchWindow verifies the batched path
 // splits too: a multi-line Print arriving inside an open window lands
 // as individual rows when the tick flushes.
@@ -317,6 +382,73 @@ func TestVerbatimSubmissionAtLimitsIsAccepted(t *testing.T) {
 	}
 }
 
+// TestBusyEngineQueuesInput verifies input the engine has no room for
+// waits in order and is sent by the retry tick, and that only input
+// arriving with the queue full is dropped, warned about, and counted.
+func TestBusyEngineQueuesInput(t *testing.T) {
+	inputChan := make(chan input.Submission, 1)
+	outbound := make(chan ui.UIEvent, 8)
+	m := NewModel(inputChan, outbound)
+	m.Update(ui.InputQueueMsg(2))
+
+	for _, line := range []string{"one", "two", "three"} {
+		if !m.sendLine(input.Command(line)) {
+			t.Fatalf("%q rejected before the queue was full", line)
+		}
+	}
+	if m.sendLine(input.Command("four")) {
+		t.Fatal("submission accepted with the queue full")
+	}
+	if got := (<-outbound).(ui.InputDroppedMsg); got.Total != 1 {
+		t.Fatalf("dropped total = %d, want 1", got.Total)
+	}
+	if !strings.Contains(m.scrollback.At(m.scrollback.Count()-1), "engine lagging") {
+		t.Fatal("drop was not warned about")
+	}
+
+	// Freeing room lets the retry drain the queue in order.
+	for _, want := range []string{"one", "two", "three"} {
+		m.Update(inputRetryMsg{})
+		if got := <-inputChan; got.Text != want {
+			t.Fatalf("sent %q, want %q", got.Text, want)
+		}
+	}
+	if len(m.queuedInput) != 0 {
+		t.Fatalf("queue not drained: %d left", len(m.queuedInput))
+	}
+	if _, cmd := m.Update(inputRetryMsg{}); cmd != nil {
+		t.Fatal("retry re-armed with nothing queued")
+	}
+}
+
+// TestBoundKeyTogglesPane verifies the pane-toggle round trip: a key
+// bound in Lua reaches the session as ExecuteBindMsg, and the toggle
+// the bind sends back shows and hides the pane in the layout.
+func TestBoundKeyTogglesPane(t *testing.T) {
+	inputChan := make(chan input.Submission, 16)
+	outbound := make(chan ui.UIEvent, 64)
+	m := NewModel(inputChan, outbound)
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m.Update(ui.UpdateBindsMsg{"f2": true})
+	m.Update(ui.UpdateLayoutMsg{Top: []ui.LayoutEntry{{Name: "chat", Height: 3}}})
+	m.Update(ui.PaneCreateMsg{Name: "chat"})
+	m.Update(ui.PaneWriteMsg{Name: "chat", Text: "Bob says hi"})
+	for len(outbound) > 0 {
+		<-outbound
+	}
+
+	for i, want := range []bool{true, false} {
+		m.Update(tea.KeyMsg{Type: tea.KeyF2})
+		if got := <-outbound; got != ui.ExecuteBindMsg("f2") {
+			t.Fatalf("press %d sent %#v, want the bind", i+1, got)
+		}
+		m.Update(ui.PaneToggleMsg{Name: "chat"})
+		if shown := strings.Contains(m.View(), "Bob says hi"); shown != want {
+			t.Fatalf("press %d: pane shown = %v, want %v", i+1, shown, want)
+		}
+	}
+}
+
 // TestBarCannotClobberBuiltinWidget verifies a Lua bar named after a
 // built-in widget ("input", "separator") neither replaces it nor
 // deletes it when the bar is later removed.
@@ -566,3 +698,381 @@ func TestHomeEndEditInputWhileCtrlVariantsScroll(t *testing.T) {
 		t.Fatalf("input draft = %q, want %q", got, typed)
 	}
 }
+
+// TestMouseClickOnLinkReportsURL verifies a left click on a URL in the
+// viewport is reported to the session, mapped through the top dock.
+func TestMouseClickOnLinkReportsURL(t *testing.T) {
+	inputChan := make(chan input.Submission, 16)
+	outbound := make(chan ui.UIEvent, 64)
+	m := NewModel(inputChan, outbound)
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m = next.(*Model)
+	next, _ = m.Update(ui.EchoLineMsg("docs at https://example.com/docs today"))
+	m = next.(*Model)
+	m.View()
+
+	y := -1
+	for row := 0; row < 24; row++ {
+		if r, ok := m.viewport.RowAt(row); ok && strings.Contains(r, "https://") {
+			y = m.viewportTop + row
+		}
+	}
+	if y < 0 {
+		t.Fatal("link row not rendered")
+	}
+	for len(outbound) > 0 {
+		<-outbound
+	}
+
+	miss := tea.MouseMsg{X: 2, Y: y, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+	m.Update(miss)
+	hit := tea.MouseMsg{X: 10, Y: y, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+	m.Update(hit)
+
+	if len(outbound) != 1 {
+		t.Fatalf("expected one outbound event, got %d", len(outbound))
+	}
+	got, ok := (<-outbound).(ui.LinkClickedMsg)
+	if !ok || got.URL != "https://example.com/docs" || got.Explicit {
+		t.Errorf("got %#v, want bare link to https://example.com/docs", got)
+	}
+}
+
+// newCopyModeModel builds a sized, painted model with ten lines of
+// output and returns it with its drained outbound channel.
+func newCopyModeModel(t *testing.T) (*Model, chan ui.UIEvent) {
+	t.Helper()
+
+	outbound := make(chan ui.UIEvent, 256)
+	m := NewModel(make(chan input.Submission, 16), outbound)
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m = next.(*Model)
+	for i := 0; i < 10; i++ {
+		m.Update(ui.EchoLineMsg(fmt.Sprintf("line %d", i)))
+	}
+	m.View()
+	for len(outbound) > 0 {
+		<-outbound
+	}
+	return m, outbound
+}
+
+// TestCopyModeTrapsKeysAndReportsSelection verifies copy mode owns the
+// keyboard - keys move the selection instead of editing the input - and
+// that y hands the selected rows to the session.
+func TestCopyModeTrapsKeysAndReportsSelection(t *testing.T) {
+	m, outbound := newCopyModeModel(t)
+
+	m.Update(ui.EnterCopyModeMsg{})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+
+	if m.viewport.InCopyMode() {
+		t.Fatal("y should leave copy mode")
+	}
+	if got := m.input.Value(); got != "" {
+		t.Errorf("copy-mode keys reached the input: %q", got)
+	}
+	var sel *ui.CopySelectionMsg
+	for len(outbound) > 0 {
+		if msg, ok := (<-outbound).(ui.CopySelectionMsg); ok {
+			sel = &msg
+		}
+	}
+	if sel == nil || sel.Text != "line 7\nline 8\nline 9" {
+		t.Errorf("got selection %+v, want the last three lines", sel)
+	}
+}
+
+func TestCopyModeEscapeCancels(t *testing.T) {
+	m, outbound := newCopyModeModel(t)
+
+	m.Update(ui.EnterCopyModeMsg{})
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	if m.viewport.InCopyMode() || m.viewport.Mode() != widget.ModeLive {
+		t.Fatal("escape should leave copy mode and return to live")
+	}
+	for len(outbound) > 0 {
+		if _, ok := (<-outbound).(ui.CopySelectionMsg); ok {
+			t.Error("escape should not copy")
+		}
+	}
+}
+
+// TestVisualBellFlashesBars verifies a visual bell inverts the bars
+// until its own flash timer ends; a stale timer from an earlier bell
+// does not cut a newer flash short.
+func TestVisualBellFlashesBars(t *testing.T) {
+	m := newBareModel(t)
+	m.Update(ui.UpdateBarsMsg{"status": {Left: "HP 100"}})
+	bar := m.widgets["status"].(*widget.Bar)
+	bar.SetSize(20, 1)
+
+	_, cmd := m.Update(ui.BellMsg{Visual: true})
+	if cmd == nil || !strings.HasPrefix(bar.View(), "\x1b[7m") {
+		t.Fatalf("visual bell did not invert the bar: %q", bar.View())
+	}
+	m.Update(ui.BellMsg{Visual: true})
+	m.Update(flashEndMsg(1))
+	if !strings.HasPrefix(bar.View(), "\x1b[7m") {
+		t.Fatal("stale flash timer reverted a newer flash")
+	}
+	m.Update(flashEndMsg(2))
+	if strings.HasPrefix(bar.View(), "\x1b[7m") {
+		t.Fatal("flash did not revert")
+	}
+
+	if _, cmd := m.Update(ui.BellMsg{Audible: true}); cmd != nil {
+		t.Error("audible-only bell should not start a flash")
+	}
+}
+
+// TestJumpToInputScrollsToLastCommand verifies the input marker: a
+// submission records where its output will start, and a jump after a
+// flood of output brings that row back to the top of the view.
+func TestJumpToInputScrollsToLastCommand(t *testing.T) {
+	m, _ := newCopyModeModel(t)
+
+	m.Update(ui.JumpToInputMsg{})
+	if m.viewport.Mode() != widget.ModeLive {
+		t.Fatal("jump before any command should do nothing")
+	}
+
+	if !m.sendLine(input.Submission{Text: "kill rat"}) {
+		t.Fatal("submission rejected")
+	}
+	m.Update(ui.EchoLineMsg("> kill rat"))
+	for i := 0; i < 50; i++ {
+		m.Update(ui.EchoLineMsg(fmt.Sprintf("spam %d", i)))
+	}
+
+	m.Update(ui.JumpToInputMsg{})
+	m.View()
+	row, _ := m.viewport.RowAt(0)
+	if m.viewport.Mode() != widget.ModeScrolled || !strings.Contains(row, "> kill rat") {
+		t.Errorf("want the command echo at the top, got mode %v row %q", m.viewport.Mode(), row)
+	}
+}
+
+// TestDedupeCollapsesRepeatedLines verifies repeats rewrite the run's
+// row in place - batched or already in scrollback - and that other
+// output ends the run.
+func TestDedupeCollapsesRepeatedLines(t *testing.T) {
+	m := newBareModel(t)
+	m.Update(ui.SetDedupeMsg(true))
+
+	for i := 0; i < 3; i++ {
+		m.Update(ui.PrintLineMsg("It is pitch black."))
+	}
+	m.handleTick()
+	m.Update(ui.PrintLineMsg("It is pitch black."))
+	m.handleTick()
+	m.Update(ui.EchoLineMsg("> look"))
+	m.Update(ui.PrintLineMsg("It is pitch black."))
+	m.Update(ui.PrintLineMsg("You hear a noise."))
+	m.handleTick()
+
+	wantScrollback(t, m, "It is pitch black. (x4)", "> look", "It is pitch black.", "You hear a noise.")
+
+	m.Update(ui.SetDedupeMsg(false))
+	m.Update(ui.PrintLineMsg("You hear a noise."))
+	m.handleTick()
+	if got := m.scrollback.At(m.scrollback.Count() - 1); got != "You hear a noise." {
+		t.Errorf("dedupe off should append repeats verbatim, got %q", got)
+	}
+}
+
+// TestClearScreenScrollsOutputOutOfView verifies a clear pushes a full
+// viewport of blank rows after any batched output, so nothing older is
+// visible but scrollback keeps it.
+func TestClearScreenScrollsOutputOutOfView(t *testing.T) {
+	m := newBareModel(t)
+	m.Update(ui.PrintLineMsg("old screen"))
+	m.Update(ui.PrintLineMsg("still batched"))
+	m.Update(ui.ClearScreenMsg{})
+	m.Update(ui.PrintLineMsg("new screen"))
+	m.handleTick()
+
+	m.View()
+	if got, want := m.scrollback.Count(), 3+m.height; got != want {
+		t.Fatalf("scrollback has %d rows, want %d", got, want)
+	}
+	if got := m.scrollback.At(1); got != "still batched" {
+		t.Errorf("batched row flushed out of order: %q", got)
+	}
+	if strings.Contains(m.viewport.View(), "old screen") || strings.Contains(m.viewport.View(), "still batched") {
+		t.Error("output before the clear is still visible")
+	}
+	if !strings.Contains(m.viewport.View(), "new screen") {
+		t.Error("output after the clear is not visible")
+	}
+}
+
+// TestClearScrollbackDropsEverything verifies a hard clear drops
+// buffered and batched rows alike and leaves the view live, and that
+// output after it starts afresh.
+func TestClearScrollbackDropsEverything(t *testing.T) {
+	m := newBareModel(t)
+	m.Update(ui.PrintLineMsg("old screen"))
+	m.Update(ui.PrintLineMsg("still batched"))
+	m.viewport.ScrollUp(1)
+	m.Update(ui.ClearScrollbackMsg{})
+	m.Update(ui.PrintLineMsg("new screen"))
+	m.handleTick()
+
+	m.View()
+	if got := m.scrollback.Count(); got != 1 {
+		t.Fatalf("scrollback has %d rows, want 1", got)
+	}
+	if m.viewport.Mode() != widget.ModeLive {
+		t.Error("view should be live after a hard clear")
+	}
+	if v := m.viewport.View(); strings.Contains(v, "old screen") || !strings.Contains(v, "new screen") {
+		t.Errorf("view after clear = %q", v)
+	}
+}
+
+// TestPaneResizeHonorsViewportFloor verifies a pinned pane height wins
+// over the layout entry, unnamed grows hit the pane last shown, and
+// growing past the screen stops with one viewport row left.
+func TestPaneResizeHonorsViewportFloor(t *testing.T) {
+	m := newBareModel(t)
+	m.Update(ui.UpdateLayoutMsg{
+		Top:    []ui.LayoutEntry{{Name: "chat", Height: 10}},
+		Bottom: []ui.LayoutEntry{{Name: "input"}},
+	})
+	m.Update(ui.PaneCreateMsg{Name: "chat"})
+	m.Update(ui.PaneSetVisibleMsg{Name: "chat", Visible: true})
+	m.View()
+	tallest := m.height - 1 - m.getWidget("input").PreferredHeight()
+
+	steps := []struct {
+		msg  tea.Msg
+		want int
+	}{
+		{ui.PaneGrowMsg{Rows: 3}, 13},
+		{ui.PaneResizeMsg{Name: "chat", Height: 40}, tallest},
+		{ui.PaneGrowMsg{Rows: -1}, tallest - 1},
+		{ui.PaneResizeMsg{Name: "chat", Height: 1}, widget.MinPaneHeight},
+		{ui.PaneResizeMsg{Name: "chat"}, 10},
+	}
+	for _, s := range steps {
+		m.Update(s.msg)
+		m.View()
+		if m.viewportTop != s.want {
+			t.Errorf("after %#v pane is %d rows, want %d", s.msg, m.viewportTop, s.want)
+		}
+	}
+}
+
+// TestPaneBorderFacesOutput verifies a docked pane's single default
+// rule sits between it and the output - below a top-docked pane,
+// above a bottom-docked one - and that border/title override it.
+func TestPaneBorderFacesOutput(t *testing.T) {
+	m := newBareModel(t)
+	for _, name := range []string{"chat", "log"} {
+		m.Update(ui.PaneCreateMsg{Name: name})
+		m.Update(ui.PaneSetVisibleMsg{Name: name, Visible: true})
+		m.Update(ui.PaneWriteMsg{Name: name, Text: name + " text"})
+	}
+	rows := func() []string {
+		return strings.Split(text.StripANSI(m.View()), "\n")
+	}
+	isRule := func(row string) bool { return strings.HasPrefix(row, "─") }
+
+	m.Update(ui.UpdateLayoutMsg{
+		Top:    []ui.LayoutEntry{{Name: "chat", Height: 3}},
+		Bottom: []ui.LayoutEntry{{Name: "log", Height: 3, NoTitle: true}, {Name: "input"}},
+	})
+	got := rows()
+	if !strings.Contains(got[0], "chat text") || !strings.HasPrefix(got[2], " chat ─") {
+		t.Errorf("top pane rows = %q, want content then a titled rule below", got[:3])
+	}
+	log := len(got) - 1 - m.getWidget("input").PreferredHeight() - 2
+	if !isRule(got[log]) || strings.Contains(got[log], "log") || !strings.Contains(got[log+1], "log text") {
+		t.Errorf("bottom pane rows = %q, want an untitled rule above the content", got[log:log+3])
+	}
+
+	m.Update(ui.UpdateLayoutMsg{
+		Top:    []ui.LayoutEntry{{Name: "chat", Height: 4, Border: "both"}},
+		Bottom: []ui.LayoutEntry{{Name: "input"}},
+	})
+	got = rows()
+	if !strings.HasPrefix(got[0], " chat ─") || !isRule(got[3]) || m.viewportTop != 4 {
+		t.Errorf("border both = %q (viewport at %d), want titled rule, content, rule", got[:4], m.viewportTop)
+	}
+}
+
+// TestGrepScrollbackDumpsMatches verifies a grep flushes batched rows
+// first, keeps the newest Limit matches oldest first under a counted
+// label, highlights within the server's colors, and writes to a pane
+// when one is named.
+func TestGrepScrollbackDumpsMatches(t *testing.T) {
+	m := newBareModel(t)
+	for _, line := range []string{"Exits: north", "A rat.", "\x1b[33mExits: south\x1b[0m", "Exits: east"} {
+		m.Update(ui.PrintLineMsg(line))
+	}
+	req := ui.GrepScrollbackMsg{
+		Pattern:   regexp.MustCompile(`Exits`),
+		Highlight: "\x1b[7m",
+		Label:     "[Grep] Exits",
+		Limit:     2,
+	}
+
+	req.Pane = "found"
+	m.Update(req)
+	want := []string{
+		"[Grep] Exits - 3 matches, newest 2 shown",
+		"\x1b[33m\x1b[7mExits\x1b[0m\x1b[33m: south\x1b[0m",
+		"\x1b[7mExits\x1b[0m: east",
+	}
+	if got := m.panes.Get("found").Lines(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("pane = %q, want %q", got, want)
+	}
+
+	req.Pane, req.Limit = "", 10
+	m.Update(req)
+	if got := m.scrollback.At(4); got != "[Grep] Exits - 3 matches" {
+		t.Fatalf("summary row = %q", got)
+	}
+	if got := m.scrollback.At(5); got != "\x1b[7mExits\x1b[0m: north" {
+		t.Fatalf("first match row = %q", got)
+	}
+
+	// A second grep skips the first one's output.
+	m.Update(req)
+	if got := m.scrollback.At(8); got != "[Grep] Exits - 3 matches" {
+		t.Fatalf("second summary row = %q, want the first dump skipped", got)
+	}
+}
+
+// TestInputMaskHidesTypedText verifies a masked input line draws
+// typed characters as '*' and no ghost, and shows them again once
+// masking is off.
+func TestInputMaskHidesTypedText(t *testing.T) {
+	m := newBareModel(t)
+	next, _ := m.Update(ui.InputMaskMsg(true))
+	m = next.(*Model)
+	next, _ = m.Update(ui.InputGhostMsg("hunter2 and more"))
+	m = next.(*Model)
+	for _, r := range "hunter2" {
+		next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = next.(*Model)
+	}
+
+	if got := m.input.Value(); got != "hunter2" {
+		t.Fatalf("input = %q, want the typed text kept", got)
+	}
+	if view := m.input.View(); strings.Contains(view, "hunter") || !strings.Contains(view, "*******") {
+		t.Fatalf("masked input drew %q", view)
+	}
+
+	next, _ = m.Update(ui.InputMaskMsg(false))
+	m = next.(*Model)
+	if view := m.input.View(); !strings.Contains(view, "hunter2") {
+		t.Fatalf("unmasked input drew %q", view)
+	}
+}
@@ -4,11 +4,41 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/mmcdole/rune/ui"
 )
 
-// RenderBorder returns a horizontal border line with dim styling.
-func RenderBorder(width int) string {
-	return "\x1b[90m" + strings.Repeat("─", width) + "\x1b[0m"
+// RenderBorder returns a horizontal border line of the rule glyph:
+// dim gray, or the theme's separator color.
+func (s Styles) RenderBorder(width int) string {
+	return s.renderRule(strings.Repeat(s.ruleGlyph(), max(width, 0)))
+}
+
+// RenderBorderLabel returns RenderBorder's line with label centered
+// in it, in the rule's color. At least two glyphs stay on each side,
+// so a narrow width yields a longer line for the caller to clip.
+func (s Styles) RenderBorderLabel(width int, label string) string {
+	glyph := s.ruleGlyph()
+	w := lipgloss.Width(label)
+	left := max((width-w)/2, 2)
+	right := max(width-left-w, 2)
+	return s.renderRule(strings.Repeat(glyph, left) + label + strings.Repeat(glyph, right))
+}
+
+func (s Styles) ruleGlyph() string {
+	if s.Rule == "" {
+		return "─"
+	}
+	return s.Rule
+}
+
+// renderRule colors line as a rule: dim gray, or the theme's
+// separator color.
+func (s Styles) renderRule(line string) string {
+	if s.Border == nil {
+		return "\x1b[90m" + line + "\x1b[0m"
+	}
+	return s.Border.Render(line)
 }
 
 // Styles holds the lipgloss styles the widgets render with. Server
@@ -33,6 +63,11 @@ type Styles struct {
 	// Misc
 	Muted   lipgloss.Style
 	Warning lipgloss.Style
+	// Border styles the input's rules and the separator; nil draws
+	// them in the terminal's own dim gray.
+	Border *lipgloss.Style
+	// Rule is the one-cell glyph those rules repeat; empty means "─".
+	Rule string
 }
 
 // DefaultStyles returns the default style configuration.
@@ -76,3 +111,39 @@ func DefaultStyles() Styles {
 			Foreground(lipgloss.Color("220")),
 	}
 }
+
+// WithTheme returns the styles with the theme's colors applied over
+// them; empty theme fields keep the current color.
+func (s Styles) WithTheme(t ui.Theme) Styles {
+	fg := func(st lipgloss.Style, c string) lipgloss.Style {
+		if c == "" {
+			return st
+		}
+		return st.Foreground(lipgloss.Color(c))
+	}
+	bg := func(st lipgloss.Style, c string) lipgloss.Style {
+		if c == "" {
+			return st
+		}
+		return st.Background(lipgloss.Color(c))
+	}
+
+	if t.OverlayBorder != "" {
+		s.OverlayBorder = s.OverlayBorder.BorderForeground(lipgloss.Color(t.OverlayBorder))
+	}
+	s.OverlaySelected = fg(bg(s.OverlaySelected, t.Selected), t.SelectedText)
+	s.OverlayNormal = fg(s.OverlayNormal, t.Text)
+	s.OverlayMatch = fg(s.OverlayMatch, t.Match)
+	s.OverlayMatchSelected = fg(bg(s.OverlayMatchSelected, t.Selected), t.Match)
+	s.Muted = fg(s.Muted, t.Muted)
+	s.PaneHeader = fg(bg(s.PaneHeader, t.PaneHeader), t.PaneTitle)
+	s.PaneBorder = fg(s.PaneBorder, t.PaneBorder)
+	if t.Separator != "" {
+		border := lipgloss.NewStyle().Foreground(lipgloss.Color(t.Separator))
+		s.Border = &border
+	}
+	if t.Rule != "" {
+		s.Rule = t.Rule
+	}
+	return s
+}
@@ -6,6 +6,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
@@ -155,6 +156,26 @@ func (b *BubbleTeaUI) ClearPane(name string) {
 	b.send(ui.PaneClearMsg{Name: name})
 }
 
+// ResizePane sets a pane's height in lines.
+func (b *BubbleTeaUI) ResizePane(name string, height int) {
+	b.send(ui.PaneResizeMsg{Name: name, Height: height})
+}
+
+// LimitPane sets a pane's line and byte budget.
+func (b *BubbleTeaUI) LimitPane(name string, lines, bytes int) {
+	b.send(ui.PaneLimitMsg{Name: name, Lines: lines, Bytes: bytes})
+}
+
+// GrowPane grows (or, negative, shrinks) a pane by N lines.
+func (b *BubbleTeaUI) GrowPane(name string, rows int) {
+	b.send(ui.PaneGrowMsg{Name: name, Rows: rows})
+}
+
+// FocusPane makes a named pane the target of unnamed resizes.
+func (b *BubbleTeaUI) FocusPane(name string) {
+	b.send(ui.PaneFocusMsg{Name: name})
+}
+
 // --- Push-based messages from Session to UI ---
 
 // UpdateBars sends rendered bar content from Session to UI.
@@ -182,6 +203,61 @@ func (b *BubbleTeaUI) SetClipboard(text string) {
 	b.send(ui.SetClipboardMsg(text))
 }
 
+// Bell rings the terminal bell and/or flashes the bars.
+func (b *BubbleTeaUI) Bell(audible, visual bool) {
+	b.send(ui.BellMsg{Audible: audible, Visual: visual})
+}
+
+// EnterCopyMode starts keyboard selection over the output viewport.
+func (b *BubbleTeaUI) EnterCopyMode() {
+	b.send(ui.EnterCopyModeMsg{})
+}
+
+// JumpToInput scrolls the output viewport back to the last command.
+func (b *BubbleTeaUI) JumpToInput() {
+	b.send(ui.JumpToInputMsg{})
+}
+
+// ClearScreen scrolls the visible output out of view.
+func (b *BubbleTeaUI) ClearScreen() {
+	b.send(ui.ClearScreenMsg{})
+}
+
+// ClearScrollback drops all output, scrollback included.
+func (b *BubbleTeaUI) ClearScrollback() {
+	b.send(ui.ClearScrollbackMsg{})
+}
+
+// SetDedupe turns collapsing of repeated server lines on or off.
+func (b *BubbleTeaUI) SetDedupe(on bool) {
+	b.send(ui.SetDedupeMsg(on))
+}
+
+// SetFlushInterval sets the output batch window.
+func (b *BubbleTeaUI) SetFlushInterval(d time.Duration) {
+	b.send(ui.SetFlushIntervalMsg(d))
+}
+
+// GrepScrollback dumps the scrollback rows matching a pattern.
+func (b *BubbleTeaUI) GrepScrollback(req ui.GrepScrollbackMsg) {
+	b.send(req)
+}
+
+// SetTheme recolors the TUI's own chrome.
+func (b *BubbleTeaUI) SetTheme(theme ui.Theme) {
+	b.send(ui.SetThemeMsg(theme))
+}
+
+// SetWrap turns soft-wrapping of new output rows on or off.
+func (b *BubbleTeaUI) SetWrap(on bool) {
+	b.send(ui.SetWrapMsg(on))
+}
+
+// SetSplit turns the split output view on or off.
+func (b *BubbleTeaUI) SetSplit(on bool) {
+	b.send(ui.SetSplitMsg(on))
+}
+
 // SetInput sets the input line content.
 func (b *BubbleTeaUI) SetInput(text string) {
 	b.send(ui.SetInputMsg(text))
@@ -199,6 +275,37 @@ func (b *BubbleTeaUI) InputSetCursor(pos int) {
 	b.send(ui.InputSetCursorMsg(pos))
 }
 
+// InputSetPrompt replaces the text drawn before the input line.
+func (b *BubbleTeaUI) InputSetPrompt(prompt string) {
+	b.send(ui.InputPromptMsg(prompt))
+}
+
+// InputSetPlaceholder sets the hint shown while the input is empty.
+func (b *BubbleTeaUI) InputSetPlaceholder(text string) {
+	b.send(ui.InputPlaceholderMsg(text))
+}
+
+// InputSetMask turns masking of the input line on or off.
+func (b *BubbleTeaUI) InputSetMask(on bool) {
+	b.send(ui.InputMaskMsg(on))
+}
+
+// InputSetGhost sets the suggested completion of the input line.
+func (b *BubbleTeaUI) InputSetGhost(text string) {
+	b.send(ui.InputGhostMsg(text))
+}
+
+// InputSetWordBreak sets the characters that end a word besides
+// whitespace.
+func (b *BubbleTeaUI) InputSetWordBreak(chars string) {
+	b.send(ui.InputWordBreakMsg(chars))
+}
+
+// InputSetQueue sets how many submissions may wait for a busy engine.
+func (b *BubbleTeaUI) InputSetQueue(limit int) {
+	b.send(ui.InputQueueMsg(limit))
+}
+
 // OpenEditor opens $EDITOR with the given initial text.
 // Returns the edited content and whether the edit was successful.
 func (b *BubbleTeaUI) OpenEditor(initial string) (string, bool) {
@@ -285,6 +392,16 @@ func (b *BubbleTeaUI) PaneScrollDown(name string, lines int) {
 	b.send(ui.PaneScrollDownMsg{Name: name, Lines: lines})
 }
 
+// PaneScrollColumns scrolls a pane horizontally by N columns.
+func (b *BubbleTeaUI) PaneScrollColumns(name string, cols int) {
+	b.send(ui.PaneScrollColumnsMsg{Name: name, Cols: cols})
+}
+
+// PaneScrollPages scrolls a pane by a fraction of its height.
+func (b *BubbleTeaUI) PaneScrollPages(name string, pages float64) {
+	b.send(ui.PaneScrollPagesMsg{Name: name, Pages: pages})
+}
+
 // PaneScrollToTop scrolls a pane to the top.
 func (b *BubbleTeaUI) PaneScrollToTop(name string) {
 	b.send(ui.PaneScrollToTopMsg{Name: name})
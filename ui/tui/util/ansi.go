@@ -4,13 +4,12 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/x/ansi"
-	"github.com/mattn/go-runewidth"
 	"github.com/mmcdole/rune/text"
 )
 
 // VisibleLen returns the visible display width of a string (excluding ANSI codes).
 func VisibleLen(s string) int {
-	return runewidth.StringWidth(text.StripANSI(s))
+	return text.Width(s)
 }
 
 // SplitLines splits text into lines, treating lone CR and CRLF as
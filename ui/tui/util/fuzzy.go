@@ -1,9 +1,11 @@
 package util
 
 import (
+	"container/heap"
 	"sort"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // Match represents a scored fuzzy match result.
@@ -14,17 +16,91 @@ type Match struct {
 	Positions []int  // Matched character positions (for highlighting)
 }
 
+// ScoringConfig holds the weights FuzzyScore ranks matches by. All
+// are points; the zero value scores every match as 1, so start from
+// DefaultScoring and change what needs tuning.
+type ScoringConfig struct {
+	// StartBonus rewards a match beginning early in the text, less
+	// StartDecay per rune it starts in; it is what ranks a picker
+	// item's name above a hit in its description.
+	StartBonus int
+	StartDecay int
+	// StringStart, WordBoundary, and CamelCase reward a matched rune
+	// at the start of the text, after a separator (space / _ - .), or
+	// at a lower-to-upper case change.
+	StringStart  int
+	WordBoundary int
+	CamelCase    int
+	// Consecutive rewards a matched rune right after the previous one.
+	Consecutive int
+	// GapStart and GapExtend penalize a run of unmatched runes between
+	// two matched ones: GapStart once, GapExtend per rune.
+	GapStart  int
+	GapExtend int
+}
+
+// DefaultScoring is the weighting FuzzyScore and FuzzyFilter use.
+var DefaultScoring = ScoringConfig{
+	StartBonus:   50,
+	StartDecay:   3,
+	StringStart:  16,
+	WordBoundary: 8,
+	CamelCase:    7,
+	Consecutive:  8,
+	GapStart:     3,
+	GapExtend:    1,
+}
+
 // FuzzyFilter filters and ranks items by fuzzy match quality against pattern.
 // Returns matches sorted by score (best first). Empty pattern returns all items.
 //
 // Pattern is split on spaces - each term must match (AND logic), but order doesn't matter.
 // This matches fzf behavior: "test this" matches "this is a test".
 func FuzzyFilter(pattern string, items []string) []Match {
+	return FuzzyFilterN(pattern, items, 0)
+}
+
+// PrefixFilterN keeps the items that start with prefix, in their
+// original order, at most limit of them (limit <= 0 means no limit).
+// Positions cover the prefix, so it highlights like a fuzzy match.
+// This is the zsh-style recall the Up arrow does, for pickers.
+func PrefixFilterN(prefix string, items []string, limit int) []Match {
+	positions := make([]int, utf8.RuneCountInString(prefix))
+	for i := range positions {
+		positions[i] = i
+	}
+	var matches []Match
+	for i, item := range items {
+		if limit > 0 && len(matches) >= limit {
+			break
+		}
+		if strings.HasPrefix(item, prefix) {
+			matches = append(matches, Match{Index: i, Text: item, Score: 1, Positions: positions})
+		}
+	}
+	return matches
+}
+
+// FuzzyFilterN is FuzzyFilter keeping only the best limit matches
+// (limit <= 0 means no limit). Every item is still scored, but the
+// kept set lives in a bounded heap, so a keystroke over a large
+// history costs O(n log limit) instead of a full sort. The result
+// order is identical to the first limit entries of FuzzyFilter.
+func FuzzyFilterN(pattern string, items []string, limit int) []Match {
+	return DefaultScoring.FilterN(pattern, items, limit)
+}
+
+// FilterN is FuzzyFilterN ranking by c's weights.
+func (c ScoringConfig) FilterN(pattern string, items []string, limit int) []Match {
 	if pattern == "" {
-		// No pattern - return all items with zero score, original order
-		matches := make([]Match, len(items))
-		for i, item := range items {
-			matches[i] = Match{Index: i, Text: item, Score: 0}
+		// No pattern - return items with zero score, original order
+		n := len(items)
+		if limit > 0 && limit < n {
+			n = limit
+		}
+		matches := make([]Match, n)
+		for i := range matches {
+			matches[i] = Match{Index: i, Text: items[i], Score: 0}
 		}
 		return matches
 	}
@@ -32,40 +108,69 @@ func FuzzyFilter(pattern string, items []string) []Match {
 	// Split pattern into terms (fzf-style: space separates AND terms)
 	terms := strings.Fields(pattern)
 
-	var matches []Match
+	var kept matchHeap
 	for i, item := range items {
-		score, positions := fuzzyScoreMulti(terms, item)
-		if score > 0 {
-			matches = append(matches, Match{
-				Index:     i,
-				Text:      item,
-				Score:     score,
-				Positions: positions,
-			})
+		score, positions := c.scoreMulti(terms, item)
+		if score <= 0 {
+			continue
+		}
+		m := Match{
+			Index:     i,
+			Text:      item,
+			Score:     score,
+			Positions: positions,
+		}
+		if limit <= 0 {
+			kept = append(kept, m)
+		} else if len(kept) < limit {
+			heap.Push(&kept, m)
+		} else if ranksBefore(m, kept[0]) {
+			kept[0] = m
+			heap.Fix(&kept, 0)
 		}
 	}
 
-	// Sort by score descending, then by original index for stability
-	sort.SliceStable(matches, func(i, j int) bool {
-		if matches[i].Score != matches[j].Score {
-			return matches[i].Score > matches[j].Score
-		}
-		return matches[i].Index < matches[j].Index
+	matches := []Match(kept)
+	sort.Slice(matches, func(i, j int) bool {
+		return ranksBefore(matches[i], matches[j])
 	})
-
 	return matches
 }
 
-// fuzzyScoreMulti scores text against multiple terms (AND logic).
+// ranksBefore orders matches by score descending, then by original
+// index so equal scores keep their input order.
+func ranksBefore(a, b Match) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return a.Index < b.Index
+}
+
+// matchHeap is a min-heap on rank: the root is the worst kept match,
+// the one a better candidate evicts.
+type matchHeap []Match
+
+func (h matchHeap) Len() int           { return len(h) }
+func (h matchHeap) Less(i, j int) bool { return ranksBefore(h[j], h[i]) }
+func (h matchHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *matchHeap) Push(x any)        { *h = append(*h, x.(Match)) }
+func (h *matchHeap) Pop() any {
+	old := *h
+	m := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return m
+}
+
+// scoreMulti scores text against multiple terms (AND logic).
 // All terms must match for a positive score.
-func fuzzyScoreMulti(terms []string, text string) (int, []int) {
+func (c ScoringConfig) scoreMulti(terms []string, text string) (int, []int) {
 	if len(terms) == 0 {
 		return 0, nil
 	}
 
 	// Single term - use regular scoring
 	if len(terms) == 1 {
-		return FuzzyScore(terms[0], text)
+		return c.Score(terms[0], text)
 	}
 
 	// Multiple terms - all must match
@@ -74,7 +179,7 @@ func fuzzyScoreMulti(terms []string, text string) (int, []int) {
 	positionSet := make(map[int]bool) // Avoid duplicate positions
 
 	for _, term := range terms {
-		score, positions := FuzzyScore(term, text)
+		score, positions := c.Score(term, text)
 		if score == 0 {
 			return 0, nil // Term didn't match - fail
 		}
@@ -100,6 +205,11 @@ func fuzzyScoreMulti(terms []string, text string) (int, []int) {
 // Uses fzf-style algorithm: forward scan to verify match exists,
 // then backward scan to find the tightest cluster of matches.
 func FuzzyScore(pattern, text string) (int, []int) {
+	return DefaultScoring.Score(pattern, text)
+}
+
+// Score is FuzzyScore weighting by c.
+func (c ScoringConfig) Score(pattern, text string) (int, []int) {
 	if pattern == "" || text == "" {
 		return 0, nil
 	}
@@ -143,30 +253,30 @@ func FuzzyScore(pattern, text string) (int, []int) {
 
 	// Big bonus for match starting early in the string
 	// This heavily favors command name matches over description matches
-	score += max(0, 50-firstPos*3)
+	score += max(0, c.StartBonus-firstPos*c.StartDecay)
 
 	for i, pos := range positions {
 		// Boundary bonuses
 		if pos == 0 {
-			score += 16 // Start of string
+			score += c.StringStart
 		} else {
 			prevChar := textRunes[pos-1]
 			if prevChar == ' ' || prevChar == '/' || prevChar == '_' || prevChar == '-' || prevChar == '.' {
-				score += 8 // Word boundary
+				score += c.WordBoundary
 			} else if unicode.IsLower(prevChar) && unicode.IsUpper(textRunes[pos]) {
-				score += 7 // CamelCase boundary
+				score += c.CamelCase
 			}
 		}
 
 		// Consecutive match bonus
 		if i > 0 && positions[i] == positions[i-1]+1 {
-			score += 8
+			score += c.Consecutive
 		}
 
 		// Gap penalty
 		if i > 0 && positions[i] > positions[i-1]+1 {
 			gap := positions[i] - positions[i-1] - 1
-			score -= 3 + gap // Gap start + extension
+			score -= c.GapStart + gap*c.GapExtend
 		}
 	}
 
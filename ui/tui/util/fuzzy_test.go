@@ -0,0 +1,106 @@
+package util
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestFuzzyFilterNMatchesFullRanking(t *testing.T) {
+	items := []string{
+		"say hello", "look", "look north", "score", "lock door",
+		"kill orc", "look south", "loot corpse", "hello", "slook",
+	}
+	full := FuzzyFilter("lo", items)
+	for limit := 1; limit <= len(full)+1; limit++ {
+		got := FuzzyFilterN("lo", items, limit)
+		want := full[:min(limit, len(full))]
+		if !slices.EqualFunc(got, want, func(a, b Match) bool {
+			return a.Index == b.Index && a.Score == b.Score
+		}) {
+			t.Errorf("limit %d: got %v, want %v", limit, got, want)
+		}
+	}
+}
+
+func TestFuzzyFilterNEmptyPattern(t *testing.T) {
+	items := []string{"a", "b", "c"}
+	if got := FuzzyFilterN("", items, 2); len(got) != 2 || got[0].Index != 0 || got[1].Index != 1 {
+		t.Errorf("FuzzyFilterN(\"\", 2) = %v, want first two items", got)
+	}
+	if got := FuzzyFilterN("", items, 0); len(got) != 3 {
+		t.Errorf("FuzzyFilterN(\"\", 0) = %d items, want 3", len(got))
+	}
+}
+
+func TestFuzzyFilterNEqualScoresKeepInputOrder(t *testing.T) {
+	items := []string{"north", "north", "north", "north"}
+	got := FuzzyFilterN("nor", items, 2)
+	if len(got) != 2 || got[0].Index != 0 || got[1].Index != 1 {
+		t.Errorf("got %v, want indexes 0 and 1", got)
+	}
+}
+
+// TestScoringConfigShiftsRanking verifies the weights steer ranking:
+// without the start bias a description hit keeps its input order
+// ahead of a name hit, and without run and gap weights an earlier
+// scattered match beats a later tight one.
+func TestScoringConfigShiftsRanking(t *testing.T) {
+	ranked := func(c ScoringConfig, pattern string, items []string) []string {
+		var got []string
+		for _, m := range c.FilterN(pattern, items, 0) {
+			got = append(got, m.Text)
+		}
+		return got
+	}
+
+	items := []string{"help  how to connect", "connect  open a connection"}
+	if got := ranked(DefaultScoring, "con", items); got[0] != items[1] {
+		t.Errorf("default: %q, want the name match first", got)
+	}
+	flat := DefaultScoring
+	flat.StartBonus, flat.StringStart = 0, flat.WordBoundary
+	if got := ranked(flat, "con", items); got[0] != items[0] {
+		t.Errorf("no start bias: %q, want input order", got)
+	}
+
+	items = []string{"xaxb", "xxab"}
+	if got := ranked(DefaultScoring, "ab", items); got[0] != "xxab" {
+		t.Errorf("default: %q, want the consecutive match first", got)
+	}
+	loose := DefaultScoring
+	loose.Consecutive, loose.GapStart, loose.GapExtend = 0, 0, 0
+	if got := ranked(loose, "ab", items); got[0] != "xaxb" {
+		t.Errorf("no run weights: %q, want the earlier match first", got)
+	}
+
+}
+
+// historyFixture is a 10k-entry command history shaped like real play:
+// many repeats of short commands plus unique long ones.
+func historyFixture() []string {
+	cmds := []string{"look", "north", "kill orc", "say hello there", "cast 'cure light' self", "get all corpse"}
+	items := make([]string, 10000)
+	for i := range items {
+		if i%3 == 0 {
+			items[i] = fmt.Sprintf("tell friend%d meet me at the fountain in %d", i, i%60)
+		} else {
+			items[i] = cmds[i%len(cmds)]
+		}
+	}
+	return items
+}
+
+func BenchmarkFuzzyFilterHistory(b *testing.B) {
+	items := historyFixture()
+	for b.Loop() {
+		FuzzyFilter("tel fou", items)
+	}
+}
+
+func BenchmarkFuzzyFilterNHistory(b *testing.B) {
+	items := historyFixture()
+	for b.Loop() {
+		FuzzyFilterN("tel fou", items, 50)
+	}
+}
@@ -0,0 +1,128 @@
+package util
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/mmcdole/rune/text"
+)
+
+// Link is a URL on a rendered row, spanning visible cell columns
+// [Start, End).
+type Link struct {
+	URL   string
+	Start int
+	End   int
+	// Explicit is true for an OSC 8 hyperlink the server marked up,
+	// false for a bare URL detected in the text.
+	Explicit bool
+}
+
+// LinkAt returns the link covering cell column col of a rendered row.
+// OSC 8 hyperlinks take precedence over bare URLs in the text.
+func LinkAt(row string, col int) (Link, bool) {
+	for _, l := range findLinks(row) {
+		if col >= l.Start && col < l.End {
+			return l, true
+		}
+	}
+	return Link{}, false
+}
+
+// findLinks walks a row once, tracking the cell column of every text
+// byte: OSC 8 spans become explicit links, and a regexp pass over the
+// plain text finds bare URLs that do not overlap one. Other escape
+// sequences are zero-width and skipped.
+func findLinks(row string) []Link {
+	var links []Link
+	var plain strings.Builder
+	var cols []int // cell column of each byte in plain
+	col := 0
+	open := -1 // index in links of the OSC 8 span being read
+
+	closeOpen := func() {
+		if open >= 0 {
+			links[open].End = col
+			if links[open].Start == col {
+				links = links[:open]
+			}
+			open = -1
+		}
+	}
+
+	for i := 0; i < len(row); {
+		if row[i] == 0x1b && i+1 < len(row) {
+			switch row[i+1] {
+			case '[':
+				j := i + 2
+				for j < len(row) && (row[j] < 0x40 || row[j] > 0x7e) {
+					j++
+				}
+				i = j + 1
+				continue
+			case ']':
+				body, next := oscString(row, i+2)
+				if rest, ok := strings.CutPrefix(body, "8;"); ok {
+					if _, uri, ok := strings.Cut(rest, ";"); ok {
+						closeOpen()
+						if uri != "" {
+							links = append(links, Link{URL: uri, Start: col, Explicit: true})
+							open = len(links) - 1
+						}
+					}
+				}
+				i = next
+				continue
+			}
+		}
+		r, size := utf8.DecodeRuneInString(row[i:])
+		for k := 0; k < size; k++ {
+			cols = append(cols, col)
+		}
+		plain.WriteString(row[i : i+size])
+		col += runewidth.RuneWidth(r)
+		i += size
+	}
+	closeOpen()
+
+	s := plain.String()
+	colAt := func(i int) int {
+		if i < len(cols) {
+			return cols[i]
+		}
+		return col
+	}
+	explicit := len(links)
+	for _, span := range text.FindURLs(s) {
+		l := Link{URL: s[span[0]:span[1]], Start: colAt(span[0]), End: colAt(span[1])}
+		overlaps := false
+		for _, e := range links[:explicit] {
+			if l.Start < e.End && e.Start < l.End {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			links = append(links, l)
+		}
+	}
+	return links
+}
+
+// oscString returns the body of the OSC string starting at i and the
+// index just past its terminator (BEL or ESC \). An unterminated
+// string runs to the end of the row.
+func oscString(row string, i int) (string, int) {
+	for j := i; j < len(row); j++ {
+		switch row[j] {
+		case 0x07:
+			return row[i:j], j + 1
+		case 0x1b:
+			if j+1 < len(row) && row[j+1] == '\\' {
+				return row[i:j], j + 2
+			}
+		}
+	}
+	return row[i:], len(row)
+}
@@ -0,0 +1,66 @@
+package util
+
+import "testing"
+
+func TestLinkAt(t *testing.T) {
+	const osc = "\x1b]8;;https://example.com/help\x1b\\help page\x1b]8;;\x1b\\"
+	tests := []struct {
+		name     string
+		row      string
+		col      int
+		want     string
+		explicit bool
+	}{
+		{"BareURL", "see https://example.com now", 6, "https://example.com", false},
+		{"BareURLFirstCell", "see https://example.com now", 4, "https://example.com", false},
+		{"TrailingPeriodTrimmed", "visit http://aard.org.", 10, "http://aard.org", false},
+		{"BalancedParenKept", "wiki https://w.org/Foo_(bar) x", 10, "https://w.org/Foo_(bar)", false},
+		{"UnbalancedParenTrimmed", "(see https://w.org/x)", 10, "https://w.org/x", false},
+		// SGR codes are zero-width: column 4 is still the 'h' of https.
+		{"ColoredURL", "see \x1b[36mhttps://example.com\x1b[0m", 4, "https://example.com", false},
+		{"WideRunesBefore", "地図 https://example.com", 5, "https://example.com", false},
+		{"OSC8Span", "read the " + osc + " first", 12, "https://example.com/help", true},
+		{"OSC8LastCell", "read the " + osc + " first", 17, "https://example.com/help", true},
+		{"OSC8BelTerminated", "\x1b]8;;https://a.org\x07go\x1b]8;;\x07", 1, "https://a.org", true},
+		// An unclosed span (the close was clipped off) runs to row end.
+		{"OSC8Unclosed", "\x1b]8;;https://a.org\x1b\\clipped te", 9, "https://a.org", true},
+		// OSC 8 text that is itself a URL resolves to the marked-up target.
+		{"OSC8WinsOverText", "\x1b]8;;https://real.org\x1b\\https://shown.org\x1b]8;;\x1b\\", 3, "https://real.org", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := LinkAt(tt.row, tt.col)
+			if !ok {
+				t.Fatalf("LinkAt(%q, %d) found no link", tt.row, tt.col)
+			}
+			if got.URL != tt.want || got.Explicit != tt.explicit {
+				t.Errorf("LinkAt(%q, %d) = %+v, want %q (explicit=%v)", tt.row, tt.col, got, tt.want, tt.explicit)
+			}
+		})
+	}
+}
+
+func TestLinkAtMisses(t *testing.T) {
+	const osc = "read the \x1b]8;;https://example.com\x1b\\help\x1b]8;;\x1b\\ first"
+	tests := []struct {
+		name string
+		row  string
+		col  int
+	}{
+		{"NoURL", "You are standing in a field.", 5},
+		{"BeforeURL", "see https://example.com", 3},
+		{"AfterURL", "see https://example.com now", 23},
+		{"BeforeOSC8", osc, 8},
+		{"AfterOSC8", osc, 13},
+		{"SchemeOnly", "https:// alone", 2},
+		{"OtherScheme", "ftp://example.com", 2},
+		{"EmptyRow", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, ok := LinkAt(tt.row, tt.col); ok {
+				t.Errorf("LinkAt(%q, %d) = %+v, want no link", tt.row, tt.col, got)
+			}
+		})
+	}
+}
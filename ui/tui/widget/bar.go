@@ -15,6 +15,7 @@ type Bar struct {
 	name    string
 	content ui.BarContent
 	width   int
+	flash   bool
 }
 
 // NewBar creates a new bar renderer.
@@ -29,8 +30,22 @@ func (b *Bar) SetContent(content ui.BarContent) {
 	b.content = content
 }
 
+// SetFlash turns the visual-bell inversion on or off.
+func (b *Bar) SetFlash(on bool) {
+	b.flash = on
+}
+
 // View implements Widget.
 func (b *Bar) View() string {
+	row := b.layout()
+	if b.flash {
+		return invertRow(row, b.width)
+	}
+	return row
+}
+
+// layout places the left, center, and right sections across the width.
+func (b *Bar) layout() string {
 	left := b.content.Left
 	center := b.content.Center
 	right := b.content.Right
@@ -0,0 +1,57 @@
+package widget
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mmcdole/rune/text"
+	"github.com/mmcdole/rune/ui"
+	"github.com/mmcdole/rune/ui/tui/util"
+)
+
+// TestBarAlignsExtendedColors verifies the bar measures sections by
+// cells, not bytes: 256-color and truecolor parameters are all digits
+// and separators, and miscounting them shifts the right section on
+// every repaint whose colors change.
+func TestBarAlignsExtendedColors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content ui.BarContent
+		want    string // stripped row
+	}{
+		{
+			"Plain",
+			ui.BarContent{Left: "HP 100", Right: "LIVE"},
+			"HP 100" + strings.Repeat(" ", 20) + "LIVE",
+		},
+		{
+			"Fg256",
+			ui.BarContent{Left: "\x1b[38;5;208mHP 100\x1b[0m", Right: "\x1b[38;5;244mLIVE\x1b[0m"},
+			"HP 100" + strings.Repeat(" ", 20) + "LIVE",
+		},
+		{
+			"Truecolor",
+			ui.BarContent{Left: "\x1b[38;2;255;64;0;48;2;0;0;0mHP 100\x1b[0m", Right: "\x1b[1;38;2;9;9;9mLIVE\x1b[m"},
+			"HP 100" + strings.Repeat(" ", 20) + "LIVE",
+		},
+		{
+			"CenterWithColonForm",
+			ui.BarContent{Left: "a", Center: "\x1b[38:2::1:2:3mmid\x1b[39m", Right: "\x1b[48;5;17mz\x1b[0m"},
+			"a" + strings.Repeat(" ", 12) + "mid" + strings.Repeat(" ", 13) + "z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBar("status")
+			b.SetSize(30, 1)
+			b.SetContent(tt.content)
+			row := b.View()
+			if got := util.VisibleLen(row); got != 30 {
+				t.Errorf("row is %d cells wide, want 30", got)
+			}
+			if got := text.StripANSI(row); got != tt.want {
+				t.Errorf("row = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
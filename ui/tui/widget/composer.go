@@ -25,6 +25,8 @@ type Composer struct {
 	cursor  int
 	goalCol int // retained display column during vertical movement; -1 = unset
 	topRow  int // first visual row shown by the input viewport
+	// wordBreak ends a word besides whitespace (rune.input.word_break).
+	wordBreak string
 }
 
 func newComposer(text string, cursor int) *Composer {
@@ -146,21 +148,27 @@ func (c *Composer) DocEnd() {
 	c.goalCol = -1
 }
 
+// isBreak reports whether r separates words: whitespace, or one of
+// the configured word-break characters.
+func (c *Composer) isBreak(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(c.wordBreak, r)
+}
+
 func (c *Composer) WordLeft() {
-	for c.cursor > 0 && unicode.IsSpace(c.text[c.cursor-1]) {
+	for c.cursor > 0 && c.isBreak(c.text[c.cursor-1]) {
 		c.cursor--
 	}
-	for c.cursor > 0 && !unicode.IsSpace(c.text[c.cursor-1]) {
+	for c.cursor > 0 && !c.isBreak(c.text[c.cursor-1]) {
 		c.cursor--
 	}
 	c.goalCol = -1
 }
 
 func (c *Composer) WordRight() {
-	for c.cursor < len(c.text) && !unicode.IsSpace(c.text[c.cursor]) {
+	for c.cursor < len(c.text) && !c.isBreak(c.text[c.cursor]) {
 		c.cursor++
 	}
-	for c.cursor < len(c.text) && unicode.IsSpace(c.text[c.cursor]) {
+	for c.cursor < len(c.text) && c.isBreak(c.text[c.cursor]) {
 		c.cursor++
 	}
 	c.goalCol = -1
@@ -304,6 +304,24 @@ func TestComposerAltArrowsMoveByWord(t *testing.T) {
 	}
 }
 
+func TestComposerWordBreakMatchesInputSetting(t *testing.T) {
+	in := newComposerInput(40)
+	in.SetWordBreak("'-")
+	draft := "cast 'word-of-recall'\nsay"
+	in.BeginCompose(draft, len([]rune("cast 'word-of-recall'")))
+
+	if !in.UpdateComposer(tea.KeyMsg{Type: tea.KeyCtrlW}) {
+		t.Fatal("Ctrl+W should be handled locally")
+	}
+	if got, want := in.Value(), "cast 'word-of-\nsay"; got != want {
+		t.Fatalf("Value = %q, want %q", got, want)
+	}
+	in.UpdateComposer(tea.KeyMsg{Type: tea.KeyLeft, Alt: true})
+	if got, want := in.Position(), len([]rune("cast 'word-")); got != want {
+		t.Fatalf("Alt+Left Position = %d, want %d", got, want)
+	}
+}
+
 func TestComposerTinyWidthsDoNotPanicOrLeakTabs(t *testing.T) {
 	for width := 0; width <= 8; width++ {
 		t.Run(string(rune('0'+width)), func(t *testing.T) {
@@ -0,0 +1,131 @@
+package widget
+
+import (
+	"strings"
+
+	"github.com/mmcdole/rune/text"
+)
+
+// selection is a copy-mode selection over scrollback rows, in absolute
+// row numbers (ScrollbackBuffer.Base) so eviction cannot shift it onto
+// other rows. Until a mark is set it covers only the cursor row.
+type selection struct {
+	cursor int
+	anchor int
+	marked bool
+}
+
+// bounds returns the first and last selected rows.
+func (s *selection) bounds() (int, int) {
+	if !s.marked {
+		return s.cursor, s.cursor
+	}
+	return min(s.anchor, s.cursor), max(s.anchor, s.cursor)
+}
+
+func (s *selection) contains(row int) bool {
+	lo, hi := s.bounds()
+	return row >= lo && row <= hi
+}
+
+// EnterCopyMode starts a selection on the bottom visible row and pins
+// the view, so new output does not scroll the rows being selected away
+// (it piles up as "new lines" as when scrolled back). Reports false
+// when there is nothing to select.
+func (v *Viewport) EnterCopyMode() bool {
+	if v.buffer.Count() == 0 {
+		return false
+	}
+	_, bottom := v.visibleRows()
+	v.sel = &selection{cursor: bottom}
+	v.mode = ModeScrolled
+	v.cacheValid = false
+	return true
+}
+
+// ExitCopyMode ends copy mode, returning to live when the view was
+// never scrolled away from the newest output.
+func (v *Viewport) ExitCopyMode() {
+	if v.sel == nil {
+		return
+	}
+	v.sel = nil
+	if v.offset == 0 {
+		v.GotoBottom()
+	}
+	v.cacheValid = false
+}
+
+// InCopyMode reports whether a copy-mode selection is active.
+func (v *Viewport) InCopyMode() bool {
+	return v.sel != nil
+}
+
+// CopyMove moves the selection cursor by delta rows (negative = up,
+// toward older output), clamped to the buffer, scrolling to keep the
+// cursor on screen.
+func (v *Viewport) CopyMove(delta int) {
+	if v.sel == nil {
+		return
+	}
+	base := v.buffer.Base()
+	v.sel.cursor = min(max(v.sel.cursor+delta, base), base+v.buffer.Count()-1)
+	if v.sel.anchor < base {
+		v.sel.anchor = base
+	}
+
+	top, bottom := v.visibleRows()
+	switch {
+	case v.sel.cursor < top:
+		v.offset += top - v.sel.cursor
+	case v.sel.cursor > bottom:
+		v.offset = max(v.offset-(v.sel.cursor-bottom), 0)
+	}
+	v.cacheValid = false
+}
+
+// CopyPage moves the selection cursor by one page.
+func (v *Viewport) CopyPage(pages int) {
+	v.CopyMove(pages * max(v.height-1, 1))
+}
+
+// CopyToggleMark starts the selection at the cursor row, or clears it
+// back to the cursor row alone.
+func (v *Viewport) CopyToggleMark() {
+	if v.sel == nil {
+		return
+	}
+	v.sel.marked = !v.sel.marked
+	v.sel.anchor = v.sel.cursor
+	v.cacheValid = false
+}
+
+// CopySelection returns the selected rows as plain text, one line per
+// row with trailing spaces trimmed. Soft-wrapped lines come back as
+// the rows they were wrapped into.
+func (v *Viewport) CopySelection() string {
+	if v.sel == nil {
+		return ""
+	}
+	base := v.buffer.Base()
+	lo, hi := v.sel.bounds()
+	lo = max(lo, base)
+	var lines []string
+	for row := lo; row <= hi; row++ {
+		lines = append(lines, strings.TrimRight(text.StripANSI(v.buffer.At(row-base)), " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// visibleRows returns the absolute numbers of the first and last
+// scrollback rows the current offset shows.
+func (v *Viewport) visibleRows() (int, int) {
+	contentHeight := v.height
+	if v.mode == ModeLive && v.prompt != "" {
+		contentHeight--
+	}
+	end := v.buffer.Count() - min(v.offset, v.buffer.Count())
+	start := max(end-max(contentHeight, 1), 0)
+	base := v.buffer.Base()
+	return base + start, base + end - 1
+}
@@ -0,0 +1,110 @@
+package widget
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mmcdole/rune/ui/tui/style"
+)
+
+func TestCopyModeStartsOnBottomRow(t *testing.T) {
+	v, _ := newTestViewport(40, 3, "a", "b", "\x1b[31mc\x1b[0m  ")
+	v.SetPrompt("> ")
+
+	if !v.EnterCopyMode() {
+		t.Fatal("EnterCopyMode refused a non-empty buffer")
+	}
+	if got := v.CopySelection(); got != "c" {
+		t.Errorf("initial selection = %q, want the bottom row, plain and trimmed", got)
+	}
+	if v.Mode() != ModeScrolled {
+		t.Error("copy mode should pin the view")
+	}
+
+	v.ExitCopyMode()
+	if v.InCopyMode() || v.Mode() != ModeLive {
+		t.Error("leaving copy mode unscrolled should return to live")
+	}
+}
+
+func TestCopyModeRefusesEmptyBuffer(t *testing.T) {
+	v, _ := newTestViewport(40, 3)
+	if v.EnterCopyMode() {
+		t.Error("EnterCopyMode accepted an empty buffer")
+	}
+}
+
+func TestCopyModeMarkSelectsRange(t *testing.T) {
+	v, _ := newTestViewport(40, 5, "one", "two", "three", "four")
+	v.EnterCopyMode()
+
+	v.CopyMove(-1)
+	v.CopyToggleMark()
+	v.CopyMove(-2)
+	if got, want := v.CopySelection(), "one\ntwo\nthree"; got != want {
+		t.Errorf("selection = %q, want %q", got, want)
+	}
+
+	// Selected rows render highlighted, the rest untouched.
+	rows := viewRows(v)
+	for i, want := range []bool{false, true, true, true, false} {
+		if got := strings.HasPrefix(rows[i], "\x1b[7m"); got != want {
+			t.Errorf("row %d highlighted = %v, want %v (%q)", i, got, want, rows[i])
+		}
+	}
+
+	// A second toggle drops back to the cursor row alone.
+	v.CopyToggleMark()
+	if got := v.CopySelection(); got != "one" {
+		t.Errorf("selection after unmark = %q, want %q", got, "one")
+	}
+}
+
+func TestCopyModeCursorScrollsView(t *testing.T) {
+	var lines []string
+	for i := 0; i < 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	v, _ := newTestViewport(40, 3, lines...)
+	v.EnterCopyMode()
+
+	v.CopyMove(-5)
+	if rows := viewRows(v); !strings.Contains(rows[0], "line 14") {
+		t.Errorf("cursor above the view should scroll it up, top row %q", rows[0])
+	}
+	v.CopyMove(-100)
+	if got := v.CopySelection(); got != "line 0" {
+		t.Errorf("cursor clamped to %q, want oldest row", got)
+	}
+	v.CopyMove(100)
+	if got := v.CopySelection(); got != "line 19" {
+		t.Errorf("cursor clamped to %q, want newest row", got)
+	}
+}
+
+// TestCopyModeSurvivesNewOutput verifies the selection is anchored on
+// rows, not screen positions: output arriving mid-selection (even
+// output that evicts old rows from the ring) leaves it on the same text.
+func TestCopyModeSurvivesNewOutput(t *testing.T) {
+	buf := NewScrollbackBuffer(10)
+	v := NewViewport(buf, style.DefaultStyles())
+	v.SetSize(40, 3)
+	for i := 0; i < 10; i++ {
+		buf.Append(fmt.Sprintf("row %d", i))
+		v.OnNewRows(1)
+	}
+	v.EnterCopyMode()
+	v.CopyMove(-1)
+
+	for i := 10; i < 14; i++ {
+		buf.Append(fmt.Sprintf("row %d", i))
+		v.OnNewRows(1)
+	}
+	if got := v.CopySelection(); got != "row 8" {
+		t.Errorf("selection after new output = %q, want %q", got, "row 8")
+	}
+	if got := buf.Base(); got != 4 {
+		t.Errorf("Base() = %d after 4 evictions, want 4", got)
+	}
+}
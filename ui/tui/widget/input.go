@@ -23,10 +23,24 @@ type Input struct {
 	styles    style.Styles
 
 	// State
-	pickerActive   bool
+	pickerActive bool
+	// pickerTitle and pickerMatch rebuild a modal picker's header
+	// when Ctrl+R flips its matching; pickerMatch is "" for pickers
+	// that did not ask for a toggle.
+	pickerTitle    string
+	pickerMatch    string
 	discardPending bool
 	width          int
 	height         int
+
+	// ghost is the suggested completion of the line (rune.input.ghost).
+	// It is offered through textinput's own suggestion rendering, and
+	// only while it extends the typed text with the cursor at the end.
+	ghost string
+
+	// wordBreak is the characters that end a word besides whitespace
+	// (rune.input.word_break), handed to each composer.
+	wordBreak string
 }
 
 // NewInput creates a new input widget.
@@ -36,6 +50,7 @@ func NewInput(styles style.Styles) *Input {
 	ti.Prompt = "> "
 	ti.CharLimit = 0
 	ti.Width = 80
+	ti.ShowSuggestions = true
 	ti.Focus()
 
 	return &Input{
@@ -62,6 +77,7 @@ func (i *Input) UpdateTextInput(msg tea.Msg) tea.Cmd {
 
 	var cmd tea.Cmd
 	i.textinput, cmd = i.textinput.Update(msg)
+	i.syncGhost()
 	return cmd
 }
 
@@ -91,10 +107,46 @@ func (i *Input) View() string {
 func (i *Input) SetSize(width, height int) {
 	i.width = width
 	i.height = height
-	i.textinput.Width = width - 2 // Account for prompt
+	i.textinput.Width = max(width-util.VisibleLen(i.textinput.Prompt), 1) // Account for prompt
 	i.picker.SetWidth(width)
 }
 
+// SetStyles replaces the styles of the input and its picker
+// (rune.ui.theme).
+func (i *Input) SetStyles(styles style.Styles) {
+	i.styles = styles
+	i.picker.SetStyles(styles)
+}
+
+// SetPrompt replaces the text drawn before the input line; it may
+// carry SGR styling. The usable width shrinks or grows to match.
+func (i *Input) SetPrompt(prompt string) {
+	i.textinput.Prompt = prompt
+	i.SetSize(i.width, i.height)
+}
+
+// SetPlaceholder sets the dimmed hint shown while the line is empty.
+func (i *Input) SetPlaceholder(text string) {
+	i.textinput.Placeholder = text
+}
+
+// SetMasked turns masking on or off: while on, the line draws each
+// character as '*' and offers no ghost, and a paste that would open
+// the composer goes into the line instead, so nothing typed shows.
+func (i *Input) SetMasked(on bool) {
+	if on {
+		i.textinput.EchoMode = textinput.EchoPassword
+	} else {
+		i.textinput.EchoMode = textinput.EchoNormal
+	}
+	i.syncGhost()
+}
+
+// Masked reports whether the line is masked.
+func (i *Input) Masked() bool {
+	return i.textinput.EchoMode == textinput.EchoPassword
+}
+
 // PreferredHeight implements Widget.
 func (i *Input) PreferredHeight() int {
 	h := 3 // normal: top border + input + bottom border
@@ -110,7 +162,7 @@ func (i *Input) PreferredHeight() int {
 }
 
 func (i *Input) borderLine() string {
-	return style.RenderBorder(i.width)
+	return i.styles.RenderBorder(i.width)
 }
 
 // Value returns the current input text.
@@ -140,6 +192,7 @@ func (i *Input) SetValue(s string) {
 		return
 	}
 	i.textinput.SetValue(s)
+	i.syncGhost()
 }
 
 // CursorEnd moves the cursor to the end.
@@ -149,6 +202,7 @@ func (i *Input) CursorEnd() {
 		return
 	}
 	i.textinput.CursorEnd()
+	i.syncGhost()
 }
 
 // Position returns the cursor position.
@@ -166,6 +220,7 @@ func (i *Input) SetCursor(pos int) {
 		return
 	}
 	i.textinput.SetCursor(pos)
+	i.syncGhost()
 }
 
 // Reset clears the input.
@@ -175,8 +230,63 @@ func (i *Input) Reset() {
 		i.composer = nil
 	}
 	i.discardPending = false
+	i.ghost = ""
 	i.textinput.SetValue("")
 	i.textinput.SetCursor(0)
+	i.syncGhost()
+}
+
+// SetGhost sets the suggested completion of the line; "" clears it.
+// The ghost is the whole suggested line, not just the missing tail.
+func (i *Input) SetGhost(text string) {
+	i.ghost = text
+	i.syncGhost()
+}
+
+// SetWordBreak sets the characters that end a word besides whitespace
+// for the composer's word motion and deletion. The one-line input
+// needs none: its word keys are Lua bindings that apply the same set.
+func (i *Input) SetWordBreak(chars string) {
+	i.wordBreak = chars
+	if i.composer != nil {
+		i.composer.wordBreak = chars
+	}
+}
+
+// Ghost returns the part of the ghost drawn past the typed text, or
+// "" when none is showing.
+func (i *Input) Ghost() string {
+	if s := i.textinput.CurrentSuggestion(); s != "" {
+		return s[len(i.textinput.Value()):]
+	}
+	return ""
+}
+
+// AcceptGhost types the showing ghost into the line, leaving the
+// cursor at the end. Reports whether there was one.
+func (i *Input) AcceptGhost() bool {
+	if i.Ghost() == "" {
+		return false
+	}
+	i.textinput.SetValue(i.ghost)
+	i.textinput.CursorEnd()
+	i.syncGhost()
+	return true
+}
+
+// syncGhost offers the ghost to textinput when it applies: a plain
+// line with no picker open, the cursor at the end, and typed text the
+// ghost extends. textinput matches suggestions case-insensitively and
+// only when it handles a key, so the match is made here instead.
+func (i *Input) syncGhost() {
+	value := i.textinput.Value()
+	if i.ghost != "" && i.composer == nil && !i.pickerActive && !i.Masked() &&
+		len(i.ghost) > len(value) && strings.HasPrefix(i.ghost, value) &&
+		i.textinput.Position() == len([]rune(value)) {
+		i.textinput.SetSuggestions([]string{i.ghost})
+		return
+	}
+	i.textinput.SetSuggestions(nil)
 }
 
 // IsComposing reports whether the lossless structured-text editor is active.
@@ -188,6 +298,7 @@ func (i *Input) IsComposing() bool {
 // It does not submit and it never routes the text through bubbles/textinput.
 func (i *Input) BeginCompose(text string, cursor int) {
 	i.composer = newComposer(text, cursor)
+	i.composer.wordBreak = i.wordBreak
 	i.discardPending = false
 }
 
@@ -207,6 +318,7 @@ func (i *Input) EndCompose() bool {
 	i.discardPending = false
 	i.textinput.SetValue(value)
 	i.textinput.SetCursor(pos)
+	i.syncGhost()
 	return true
 }
 
@@ -221,7 +333,7 @@ func (i *Input) InsertPaste(text string) tea.Cmd {
 		i.composer.Insert(text)
 		return nil
 	}
-	if RequiresComposer(text) {
+	if RequiresComposer(text) && !i.Masked() {
 		value := i.textinput.Value()
 		cursor := i.textinput.Position()
 		i.BeginCompose(value, cursor)
@@ -232,6 +344,7 @@ func (i *Input) InsertPaste(text string) tea.Cmd {
 	var cmd tea.Cmd
 	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text), Paste: true}
 	i.textinput, cmd = i.textinput.Update(msg)
+	i.syncGhost()
 	return cmd
 }
 
@@ -394,25 +507,62 @@ func (i *Input) composeFooter(help string) string {
 // controller; the widget only renders the overlay.
 func (i *Input) ShowPicker(opts ui.ShowPickerMsg) {
 	i.picker.SetItems(opts.Items)
+	i.picker.SetMaxResults(opts.MaxResults)
 	i.pickerActive = true
+	i.syncGhost()
+
+	i.picker.SetPrefix(opts.Match == "prefix")
+	// Typing filters an inline picker, so only a modal one gets the
+	// alt+digit quick-select numbers.
+	i.picker.SetNumbered(!opts.Inline)
 
 	if opts.Inline {
+		i.pickerTitle, i.pickerMatch = "", ""
 		i.picker.SetHeader("")
 		i.picker.Filter(i.textinput.Value())
 	} else {
-		header := opts.Title
-		if header != "" {
-			header += ": "
-		}
-		i.picker.SetHeader(header)
-		i.picker.Filter("")
+		i.pickerTitle, i.pickerMatch = opts.Title, opts.Match
+		i.setPickerHeader()
+		i.picker.Filter(opts.Query)
+	}
+}
+
+// setPickerHeader renders a modal picker's header: the title, and the
+// active matching when the picker can toggle it ("History (prefix): ").
+func (i *Input) setPickerHeader() {
+	header := i.pickerTitle
+	if i.pickerMatch != "" {
+		header += " (" + i.pickerMatch + ")"
+	}
+	if header != "" {
+		header += ": "
+	}
+	i.picker.SetHeader(header)
+}
+
+// PickerToggleMatch flips a modal picker that asked for it between
+// fuzzy and prefix matching, keeping the query. Reports whether the
+// picker had a toggle.
+func (i *Input) PickerToggleMatch() bool {
+	if i.pickerMatch == "" {
+		return false
+	}
+	if i.pickerMatch == "prefix" {
+		i.pickerMatch = "fuzzy"
+	} else {
+		i.pickerMatch = "prefix"
 	}
+	i.picker.SetPrefix(i.pickerMatch == "prefix")
+	i.setPickerHeader()
+	i.picker.Filter(i.picker.Query())
+	return true
 }
 
 // HidePicker closes the picker.
 func (i *Input) HidePicker() {
 	i.pickerActive = false
 	i.picker.Reset()
+	i.syncGhost()
 }
 
 // PickerSelectUp moves picker selection up.
@@ -430,6 +580,11 @@ func (i *Input) PickerSelected() (ui.PickerItem, bool) {
 	return i.picker.Selected()
 }
 
+// PickerVisible returns the item on visible picker row n (1-based).
+func (i *Input) PickerVisible(n int) (ui.PickerItem, bool) {
+	return i.picker.Visible(n)
+}
+
 // PickerFilter updates the picker filter.
 func (i *Input) PickerFilter(query string) {
 	i.picker.Filter(query)
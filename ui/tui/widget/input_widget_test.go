@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/mmcdole/rune/text"
 	"github.com/mmcdole/rune/ui"
 	"github.com/mmcdole/rune/ui/tui/style"
 )
@@ -30,6 +31,46 @@ func TestInputViewIsBorderedField(t *testing.T) {
 	}
 }
 
+// TestInputPromptAndPlaceholder verifies a styled prompt replaces
+// "> ", shrinks the usable width by its visible cells only, and the
+// placeholder shows on an empty line.
+func TestInputPromptAndPlaceholder(t *testing.T) {
+	in := newTestInput(40)
+	in.SetPrompt("\x1b[36m» \x1b[0m")
+	in.SetPlaceholder("type a command")
+
+	if in.textinput.Width != 38 {
+		t.Errorf("input width = %d, want 38 (40 less the 2-cell prompt)", in.textinput.Width)
+	}
+	row := text.StripANSI(strings.Split(in.View(), "\n")[1])
+	if !strings.HasPrefix(row, "» ") || !strings.Contains(row, "ype a command") {
+		t.Errorf("empty input row = %q, want the prompt then the placeholder", row)
+	}
+
+	in.SetValue("look")
+	row = text.StripANSI(strings.Split(in.View(), "\n")[1])
+	if strings.Contains(row, "command") || !strings.Contains(row, "» look") {
+		t.Errorf("input row = %q, want the typed text and no placeholder", row)
+	}
+}
+
+// TestInputRulesFollowThemeGlyph verifies the input's rules keep the
+// default dim "─" and switch to a themed glyph, still one cell per
+// column.
+func TestInputRulesFollowThemeGlyph(t *testing.T) {
+	in := newTestInput(10)
+	if got := strings.Split(in.View(), "\n")[0]; got != "\x1b[90m"+strings.Repeat("─", 10)+"\x1b[0m" {
+		t.Errorf("default rule = %q", got)
+	}
+
+	in.SetStyles(style.DefaultStyles().WithTheme(ui.Theme{Rule: "═"}))
+	for _, row := range []string{strings.Split(in.View(), "\n")[0], strings.Split(in.View(), "\n")[2]} {
+		if got := text.StripANSI(row); got != strings.Repeat("═", 10) {
+			t.Errorf("themed rule = %q, want 10 ═", got)
+		}
+	}
+}
+
 func TestInputValueAndCursorRoundTrip(t *testing.T) {
 	in := newTestInput(40)
 
@@ -14,52 +14,163 @@ var _ Widget = (*Pane)(nil)
 // Pane represents a named buffer that can be shown/hidden.
 //
 // Lines are stored as written (logical lines) and soft-wrapped to the
-// pane width at render time, so a resize re-fits everything. Scrolling
-// is tracked as a logical-line offset from the newest line; while
-// scrolled the view stays anchored on the same history, new writes are
-// counted, and the header shows a scroll indicator.
+// pane width at render time, so a resize re-fits everything. The
+// buffer keeps the newest lines within a line and a byte budget
+// (SetLimit). Scrolling is tracked as a logical-line offset from the
+// newest line; while scrolled the view stays anchored on the same
+// history, new writes are counted, and the header shows a scroll
+// indicator.
 type Pane struct {
 	Name     string
-	Lines    []string
+	buf      *paneBuffer
 	Visible  bool
 	height   int // Number of content lines to show when visible
 	styles   style.Styles
 	width    int
 	offset   int // logical lines scrolled back from the newest (0 = live)
 	newLines int // writes that arrived while scrolled
+	fixed    int // height set by Resize/Grow, header and border included; 0 = the layout decides
+	frame    Frame
+}
+
+// Frame says which edges of a pane get a rule and whether the pane's
+// name is shown in one. The title (and the scroll indicator) sits in
+// the top rule, or the bottom rule when there is no top one.
+type Frame struct {
+	Top, Bottom bool
+	Title       bool
+}
+
+// rows is how many lines the frame's rules take.
+func (f Frame) rows() int {
+	n := 0
+	if f.Top {
+		n++
+	}
+	if f.Bottom {
+		n++
+	}
+	return n
+}
+
+// Default pane buffer budget: the newest lines kept, and the bytes
+// those lines may take between them (rune.pane.limit).
+const (
+	DefaultPaneLines = 1000
+	DefaultPaneBytes = 1 << 20
+)
+
+// paneBuffer is a ring of a pane's logical lines. Appending past
+// either budget evicts the oldest lines; the newest line is always
+// kept, however long. The ring grows as lines arrive rather than
+// being sized to the line budget up front, so a script's generous
+// budget costs memory only once it is used.
+type paneBuffer struct {
+	lines    []string
+	head     int
+	count    int
+	bytes    int // total length of the held lines
+	maxLines int
+	maxBytes int
+}
+
+func newPaneBuffer(maxLines, maxBytes int) *paneBuffer {
+	return &paneBuffer{maxLines: maxLines, maxBytes: maxBytes}
+}
+
+// Append adds a line, evicting as the budgets require.
+func (b *paneBuffer) Append(line string) {
+	if b.count == len(b.lines) {
+		if len(b.lines) < b.maxLines {
+			b.grow()
+		} else {
+			b.dropOldest()
+		}
+	}
+	b.lines[(b.head+b.count)%len(b.lines)] = line
+	b.count++
+	b.bytes += len(line)
+	for b.bytes > b.maxBytes && b.count > 1 {
+		b.dropOldest()
+	}
+}
+
+// grow doubles the ring, up to the line budget, unrolling it so the
+// oldest line is first again.
+func (b *paneBuffer) grow() {
+	lines := make([]string, min(max(2*len(b.lines), 64), b.maxLines))
+	for i := range b.count {
+		lines[i] = b.At(i)
+	}
+	b.lines, b.head = lines, 0
+}
+
+func (b *paneBuffer) dropOldest() {
+	b.bytes -= len(b.lines[b.head])
+	b.lines[b.head] = ""
+	b.head = (b.head + 1) % len(b.lines)
+	b.count--
 }
 
+// Len returns the number of lines held.
+func (b *paneBuffer) Len() int {
+	return b.count
+}
+
+// At returns line i, 0 being the oldest.
+func (b *paneBuffer) At(i int) string {
+	return b.lines[(b.head+i)%len(b.lines)]
+}
+
+// Reset drops every line.
+func (b *paneBuffer) Reset() {
+	clear(b.lines)
+	b.head, b.count, b.bytes = 0, 0, 0
+}
+
+// MinPaneHeight is the smallest height a pane shrinks to: one content
+// line and a rule on each side.
+const MinPaneHeight = 3
+
 // NewPane creates a new pane widget.
 func NewPane(name string, styles style.Styles) *Pane {
 	return &Pane{
 		Name:    name,
-		Lines:   make([]string, 0, 100),
+		buf:     newPaneBuffer(DefaultPaneLines, DefaultPaneBytes),
 		Visible: false,
 		height:  10,
 		styles:  styles,
+		frame:   Frame{Top: true, Bottom: true, Title: true},
 	}
 }
 
+// SetFrame sets the pane's rules; the layout chooses them from where
+// the pane is docked. The content height is kept, so the pane's total
+// height follows the number of rules.
+func (p *Pane) SetFrame(f Frame) {
+	p.frame = f
+}
+
 // visibleRows renders exactly p.height rows of wrapped content for the
 // current scroll position. The window is anchored at the logical line
-// end = len(Lines)-offset; when a deep scroll leaves it underfull, it
+// end = Len()-offset; when a deep scroll leaves it underfull, it
 // extends forward so the pane stays full whenever the buffer allows.
 func (p *Pane) visibleRows() []string {
-	end := len(p.Lines) - p.offset
+	end := p.buf.Len() - p.offset
 	if end < 0 {
 		end = 0
 	}
 
 	var rows []string
 	for i := end - 1; i >= 0 && len(rows) < p.height; i-- {
-		rows = append(util.WrapLine(p.Lines[i], p.width), rows...)
+		rows = append(util.WrapLine(p.buf.At(i), p.width), rows...)
 	}
 
 	if len(rows) >= p.height {
 		rows = rows[len(rows)-p.height:]
 	} else {
-		for i := end; i < len(p.Lines) && len(rows) < p.height; i++ {
-			rows = append(rows, util.WrapLine(p.Lines[i], p.width)...)
+		for i := end; i < p.buf.Len() && len(rows) < p.height; i++ {
+			rows = append(rows, util.WrapLine(p.buf.At(i), p.width)...)
 		}
 		if len(rows) > p.height {
 			rows = rows[:p.height]
@@ -79,48 +190,87 @@ func (p *Pane) View() string {
 	}
 
 	var parts []string
+	if p.frame.Top {
+		parts = append(parts, p.rule(true))
+	}
+	parts = append(parts, p.visibleRows()...)
+	if p.frame.Bottom {
+		parts = append(parts, p.rule(!p.frame.Top))
+	}
+	return strings.Join(parts, "\n")
+}
 
-	// Header, with a scroll indicator while off the live tail
-	// (mirrors the status bar's SCROLL/LIVE vocabulary).
-	label := " " + p.Name + " "
-	if p.offset > 0 {
-		if p.newLines > 0 {
-			label = fmt.Sprintf(" %s · scroll +%d ", p.Name, p.newLines)
-		} else {
-			label = " " + p.Name + " · scroll "
+// rule renders one border row. The labelled one carries the title and,
+// while off the live tail, a scroll indicator (mirroring the status
+// bar's SCROLL/LIVE vocabulary) - shown even on an untitled pane, so
+// being scrolled back is never invisible.
+func (p *Pane) rule(labelled bool) string {
+	var label string
+	if labelled {
+		name := ""
+		if p.frame.Title {
+			name = p.Name + " · "
+		}
+		switch {
+		case p.offset > 0 && p.newLines > 0:
+			label = fmt.Sprintf(" %sscroll +%d ", name, p.newLines)
+		case p.offset > 0:
+			label = " " + name + "scroll "
+		case p.frame.Title:
+			label = " " + p.Name + " "
 		}
 	}
+	if label == "" {
+		return p.styles.PaneBorder.Render(strings.Repeat("─", p.width))
+	}
 	title := p.styles.PaneHeader.Render(label)
-	titlePad := p.width - util.VisibleLen(title)
-	if titlePad > 0 {
-		title += p.styles.PaneBorder.Render(strings.Repeat("─", titlePad))
+	if pad := p.width - util.VisibleLen(title); pad > 0 {
+		title += p.styles.PaneBorder.Render(strings.Repeat("─", pad))
 	}
-	parts = append(parts, title)
-	parts = append(parts, p.visibleRows()...)
-
-	// Bottom border
-	parts = append(parts, p.styles.PaneBorder.Render(strings.Repeat("─", p.width)))
-
-	return strings.Join(parts, "\n")
+	return title
 }
 
 // SetSize implements Widget.
 func (p *Pane) SetSize(width, height int) {
 	p.width = width
-	// Height includes header (1) + border (1), so content height = height - 2
-	if height > 2 {
-		p.height = height - 2
+	// Height includes the frame's rules; the rest is content.
+	if rules := p.frame.rows(); height > rules {
+		p.height = height - rules
 	} else if height > 0 {
 		p.height = height
 	}
 }
 
+// Resize pins the pane's height in lines, header and border included,
+// overriding the layout entry's height. Zero or less hands the height
+// back to the layout.
+func (p *Pane) Resize(height int) {
+	if height <= 0 {
+		p.fixed = 0
+		return
+	}
+	p.fixed = max(height, MinPaneHeight)
+}
+
+// Grow resizes the pane by n lines (negative shrinks) from the height
+// it was last laid out at, so growing past what the screen can fit
+// and then shrinking takes effect at once.
+func (p *Pane) Grow(n int) {
+	p.Resize(max(p.height+p.frame.rows()+n, MinPaneHeight))
+}
+
+// FixedHeight returns the height pinned by Resize or Grow, or 0 when
+// the layout decides.
+func (p *Pane) FixedHeight() int {
+	return p.fixed
+}
+
 // PreferredHeight implements Widget. Returns 0 if hidden.
 func (p *Pane) PreferredHeight() int {
 	if !p.Visible {
 		return 0
 	}
-	return p.height + 2 // content + header + border
+	return p.height + p.frame.rows()
 }
 
 // Write appends text as logical lines, one per line break. While
@@ -129,20 +279,44 @@ func (p *Pane) PreferredHeight() int {
 // indicator.
 func (p *Pane) Write(text string) {
 	for _, line := range util.SplitLines(text) {
-		p.Lines = append(p.Lines, util.ExpandTabs(line))
+		p.buf.Append(util.ExpandTabs(line))
 		if p.offset > 0 {
 			p.offset++
 			p.newLines++
 		}
 	}
-	if len(p.Lines) > 1000 {
-		p.Lines = p.Lines[len(p.Lines)-500:]
-		p.clampOffset()
+	p.clampOffset()
+}
+
+// Lines returns the pane's logical lines, oldest first.
+func (p *Pane) Lines() []string {
+	lines := make([]string, p.buf.Len())
+	for i := range lines {
+		lines[i] = p.buf.At(i)
+	}
+	return lines
+}
+
+// SetLimit sets the pane's buffer budget: at most maxLines lines and
+// maxBytes bytes of them; 0 keeps that budget's current value. The
+// newest lines that fit are kept.
+func (p *Pane) SetLimit(maxLines, maxBytes int) {
+	if maxLines <= 0 {
+		maxLines = p.buf.maxLines
+	}
+	if maxBytes <= 0 {
+		maxBytes = p.buf.maxBytes
+	}
+	old := p.buf
+	p.buf = newPaneBuffer(maxLines, maxBytes)
+	for i := max(old.Len()-maxLines, 0); i < old.Len(); i++ {
+		p.buf.Append(old.At(i))
 	}
+	p.clampOffset()
 }
 
 func (p *Pane) clampOffset() {
-	max := len(p.Lines) - 1
+	max := p.buf.Len() - 1
 	if max < 0 {
 		max = 0
 	}
@@ -170,7 +344,7 @@ func (p *Pane) ScrollDown(n int) {
 
 // ScrollToTop jumps to the oldest line.
 func (p *Pane) ScrollToTop() {
-	p.offset = len(p.Lines) - 1
+	p.offset = p.buf.Len() - 1
 	p.clampOffset()
 }
 
@@ -196,7 +370,7 @@ func (p *Pane) Toggle() {
 
 // Clear empties the pane.
 func (p *Pane) Clear() {
-	p.Lines = p.Lines[:0]
+	p.buf.Reset()
 	p.offset = 0
 	p.newLines = 0
 }
@@ -205,6 +379,9 @@ func (p *Pane) Clear() {
 type PaneManager struct {
 	panes  map[string]*Pane
 	styles style.Styles
+	// focused names the pane an unnamed Resize or Grow targets: the
+	// one last shown, or set with Focus.
+	focused string
 }
 
 // NewPaneManager creates a new pane manager.
@@ -215,6 +392,15 @@ func NewPaneManager(styles style.Styles) *PaneManager {
 	}
 }
 
+// SetStyles restyles every pane, and the ones created later
+// (rune.ui.theme).
+func (pm *PaneManager) SetStyles(styles style.Styles) {
+	pm.styles = styles
+	for _, p := range pm.panes {
+		p.styles = styles
+	}
+}
+
 // Create creates a new pane.
 func (pm *PaneManager) Create(name string) {
 	if _, exists := pm.panes[name]; exists {
@@ -236,18 +422,63 @@ func (pm *PaneManager) Write(name, text string) {
 	pm.Get(name).Write(text)
 }
 
-// Toggle toggles pane visibility.
+// Toggle toggles pane visibility. A pane toggled on takes focus.
 func (pm *PaneManager) Toggle(name string) {
 	if pane, exists := pm.panes[name]; exists {
 		pane.Toggle()
+		if pane.Visible {
+			pm.focused = name
+		}
 	}
 }
 
-// SetVisible shows or hides a pane.
+// SetVisible shows or hides a pane. A shown pane takes focus.
 func (pm *PaneManager) SetVisible(name string, visible bool) {
 	if pane, exists := pm.panes[name]; exists {
 		pane.SetVisible(visible)
+		if visible {
+			pm.focused = name
+		}
+	}
+}
+
+// Focus makes name the pane an unnamed Resize or Grow targets.
+func (pm *PaneManager) Focus(name string) {
+	pm.focused = name
+}
+
+// Focused returns the focused pane's name, or "" for none.
+func (pm *PaneManager) Focused() string {
+	return pm.focused
+}
+
+// Resize pins a pane's height (see Pane.Resize); "" is the focused
+// pane. Unknown panes are ignored.
+func (pm *PaneManager) Resize(name string, height int) {
+	if pane := pm.target(name); pane != nil {
+		pane.Resize(height)
+	}
+}
+
+// Grow grows or shrinks a pane by n lines (see Pane.Grow); "" is the
+// focused pane. Unknown panes are ignored.
+func (pm *PaneManager) Grow(name string, n int) {
+	if pane := pm.target(name); pane != nil {
+		pane.Grow(n)
+	}
+}
+
+func (pm *PaneManager) target(name string) *Pane {
+	if name == "" {
+		name = pm.focused
 	}
+	return pm.panes[name]
+}
+
+// Limit sets a pane's buffer budget (see Pane.SetLimit), creating the
+// pane if needed so the budget holds from its first write.
+func (pm *PaneManager) Limit(name string, maxLines, maxBytes int) {
+	pm.Get(name).SetLimit(maxLines, maxBytes)
 }
 
 // Clear clears a pane.
@@ -2,6 +2,8 @@ package widget
 
 import (
 	"fmt"
+	"math"
+	"slices"
 	"strings"
 	"testing"
 
@@ -58,10 +60,10 @@ func TestPaneMultilineWriteSplitsIntoLines(t *testing.T) {
 	p := newTestPane(t, 40, 5)
 	p.Write("a\rb\r\nc\nd")
 
-	if len(p.Lines) != 4 {
-		t.Fatalf("expected 4 logical lines, got %d: %q", len(p.Lines), p.Lines)
+	if len(p.Lines()) != 4 {
+		t.Fatalf("expected 4 logical lines, got %d: %q", len(p.Lines()), p.Lines())
 	}
-	for i, line := range p.Lines {
+	for i, line := range p.Lines() {
 		if strings.ContainsAny(line, "\r\n") {
 			t.Fatalf("stored line %d contains a line break: %q", i, line)
 		}
@@ -134,6 +136,26 @@ func TestPaneScrollUpShowsHistoryAndIndicator(t *testing.T) {
 	}
 }
 
+// TestPaneFrameUntitled verifies an untitled pane with only a bottom
+// rule takes one row for it and still shows the scroll indicator there.
+func TestPaneFrameUntitled(t *testing.T) {
+	p := newTestPane(t, 40, 2)
+	p.SetFrame(Frame{Bottom: true})
+	p.SetSize(40, 3)
+	for i := 1; i <= 10; i++ {
+		p.Write(fmt.Sprintf("line %d", i))
+	}
+
+	rows := strings.Split(p.View(), "\n")
+	if len(rows) != 3 || rows[0] != "line 9" || strings.Contains(rows[2], "test") {
+		t.Fatalf("untitled view = %q, want two content rows and a bare rule", rows)
+	}
+	p.ScrollUp(5)
+	if rule := strings.Split(p.View(), "\n")[2]; !strings.Contains(rule, "scroll") || strings.Contains(rule, "test") {
+		t.Errorf("scrolled rule = %q, want the indicator without a title", rule)
+	}
+}
+
 func TestPaneWritesWhileScrolledFreezeViewAndCount(t *testing.T) {
 	p := newTestPane(t, 40, 2)
 	for i := 1; i <= 6; i++ {
@@ -244,7 +266,7 @@ func TestPaneHiddenAnchorClampsWhenTrimmed(t *testing.T) {
 	p.SetVisible(true)
 
 	rows := contentRows(t, p)
-	if rows[0] != "line 502" {
+	if rows[0] != "line 2" {
 		t.Errorf("trimmed anchor should clamp to the oldest remaining line, got %q", rows)
 	}
 }
@@ -281,3 +303,54 @@ func TestClipRowTruncatesOverlongRows(t *testing.T) {
 		t.Errorf("ANSI row clipped to %d cols, want 20", got)
 	}
 }
+
+// TestPaneLimitEvictsByLinesAndBytes verifies the buffer drops oldest
+// lines one at a time past either budget, keeps an oversized newest
+// line, and that narrowing the budget keeps the newest lines.
+func TestPaneLimitEvictsByLinesAndBytes(t *testing.T) {
+	p := newTestPane(t, 40, 2)
+	p.SetLimit(3, 0)
+	for i := 1; i <= 5; i++ {
+		p.Write(fmt.Sprintf("line %d", i))
+	}
+	if got := p.Lines(); !slices.Equal(got, []string{"line 3", "line 4", "line 5"}) {
+		t.Fatalf("line budget: %q", got)
+	}
+
+	p.SetLimit(0, 12) // two 6-byte lines
+	if got := p.Lines(); !slices.Equal(got, []string{"line 4", "line 5"}) {
+		t.Fatalf("byte budget: %q", got)
+	}
+	p.Write(strings.Repeat("x", 20))
+	if got := p.Lines(); len(got) != 1 || got[0] != strings.Repeat("x", 20) {
+		t.Fatalf("oversized newest line: %q", got)
+	}
+	if p.buf.bytes != 20 {
+		t.Errorf("byte count = %d, want 20", p.buf.bytes)
+	}
+}
+
+// TestPaneLimitGrowsLazily verifies a huge line budget costs nothing
+// up front and that the ring keeps order as it grows past evictions.
+func TestPaneLimitGrowsLazily(t *testing.T) {
+	p := newTestPane(t, 40, 2)
+	p.SetLimit(math.MaxInt, 0)
+	if len(p.buf.lines) != 0 {
+		t.Fatalf("ring allocated %d slots before any write", len(p.buf.lines))
+	}
+	p.SetLimit(0, 30) // five 6-byte lines
+	for i := 1; i <= 200; i++ {
+		p.Write(fmt.Sprintf("l%5d", i))
+	}
+	want := []string{"l  196", "l  197", "l  198", "l  199", "l  200"}
+	if got := p.Lines(); !slices.Equal(got, want) {
+		t.Fatalf("lines = %q, want %q", got, want)
+	}
+	p.SetLimit(0, 1<<20)
+	for i := 201; i <= 300; i++ {
+		p.Write(fmt.Sprintf("l%5d", i))
+	}
+	if got := p.Lines(); len(got) != 105 || got[0] != "l  196" || got[104] != "l  300" {
+		t.Fatalf("after growth: %d lines, %q .. %q", len(got), got[0], got[len(got)-1])
+	}
+}
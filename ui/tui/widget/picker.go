@@ -1,6 +1,7 @@
 package widget
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/mmcdole/rune/text"
@@ -12,13 +13,18 @@ import (
 // PickerConfig holds picker configuration.
 type PickerConfig struct {
 	MaxVisible int
+	MaxResults int // Cap on fuzzy matches kept per query (0 = all)
 	Header     string
 	EmptyText  string
+	// Scoring weights fuzzy matches; the zero value means
+	// util.DefaultScoring.
+	Scoring util.ScoringConfig
 }
 
 // Picker is a fuzzy-filtering selector for PickerItems.
 type Picker struct {
 	items     []ui.PickerItem
+	search    []string // FilterValue of each item, built once per SetItems
 	filtered  []ui.PickerItem
 	matches   []util.Match
 	query     string
@@ -27,8 +33,17 @@ type Picker struct {
 	config    PickerConfig
 	styles    style.Styles
 	width     int
+	// prefix switches Filter from fuzzy matching to items that start
+	// with the query.
+	prefix bool
+	// numbered labels the first maxNumbered visible rows 1-9 for
+	// quick selection (see Visible).
+	numbered bool
 }
 
+// maxNumbered is how many visible rows get a quick-select number.
+const maxNumbered = 9
+
 // NewPicker creates a new picker.
 func NewPicker(config PickerConfig, styles style.Styles) *Picker {
 	if config.MaxVisible == 0 {
@@ -37,18 +52,41 @@ func NewPicker(config PickerConfig, styles style.Styles) *Picker {
 	if config.EmptyText == "" {
 		config.EmptyText = "No matches"
 	}
+	if config.Scoring == (util.ScoringConfig{}) {
+		config.Scoring = util.DefaultScoring
+	}
 	return &Picker{
 		config: config,
 		styles: styles,
 	}
 }
 
+// SetStyles replaces the picker's styles (rune.ui.theme).
+func (p *Picker) SetStyles(styles style.Styles) {
+	p.styles = styles
+}
+
 // SetItems sets the items to filter.
 func (p *Picker) SetItems(items []ui.PickerItem) {
 	p.items = items
+	p.search = make([]string, len(items))
+	for i, item := range items {
+		p.search[i] = item.FilterValue()
+	}
 	p.Reset()
 }
 
+// SetMaxResults caps the number of fuzzy matches kept per query.
+// Zero keeps every match.
+func (p *Picker) SetMaxResults(n int) {
+	p.config.MaxResults = n
+}
+
+// SetScoring replaces the fuzzy match weights; the caller re-filters.
+func (p *Picker) SetScoring(c util.ScoringConfig) {
+	p.config.Scoring = c
+}
+
 // SetWidth updates the picker width.
 func (p *Picker) SetWidth(w int) {
 	p.width = w
@@ -59,6 +97,22 @@ func (p *Picker) SetHeader(header string) {
 	p.config.Header = header
 }
 
+// SetPrefix switches between prefix (true) and fuzzy matching; the
+// caller re-filters.
+func (p *Picker) SetPrefix(prefix bool) {
+	p.prefix = prefix
+}
+
+// SetNumbered turns the quick-select numbers on visible rows on or off.
+func (p *Picker) SetNumbered(numbered bool) {
+	p.numbered = numbered
+}
+
+// Prefix reports whether the picker matches by prefix.
+func (p *Picker) Prefix() bool {
+	return p.prefix
+}
+
 // Query returns the current filter query.
 func (p *Picker) Query() string {
 	return p.query
@@ -77,13 +131,13 @@ func (p *Picker) Filter(query string) {
 		return
 	}
 
-	searchStrings := make([]string, len(p.items))
-	for i, item := range p.items {
-		searchStrings[i] = item.FilterValue()
+	var rawMatches []util.Match
+	if p.prefix {
+		rawMatches = util.PrefixFilterN(query, p.search, p.config.MaxResults)
+	} else {
+		rawMatches = p.config.Scoring.FilterN(query, p.search, p.config.MaxResults)
 	}
 
-	rawMatches := util.FuzzyFilter(query, searchStrings)
-
 	p.filtered = make([]ui.PickerItem, len(rawMatches))
 	p.matches = rawMatches
 	for i, match := range rawMatches {
@@ -146,6 +200,16 @@ func (p *Picker) Selected() (ui.PickerItem, bool) {
 	return p.filtered[p.selected], true
 }
 
+// Visible returns the item on visible row n (1-based), the row its
+// quick-select number labels.
+func (p *Picker) Visible(n int) (ui.PickerItem, bool) {
+	i := p.scrollOff + n - 1
+	if n < 1 || n > p.config.MaxVisible || i >= len(p.filtered) {
+		return ui.PickerItem{}, false
+	}
+	return p.filtered[i], true
+}
+
 // PreferredHeight returns the rendered height including border.
 func (p *Picker) PreferredHeight() int {
 	h := len(p.filtered)
@@ -202,7 +266,11 @@ func (p *Picker) View() string {
 			positions = p.matches[i].Positions
 		}
 
-		line := p.renderItem(item, contentWidth, selected, positions)
+		number := 0
+		if p.numbered && i-start < maxNumbered {
+			number = i - start + 1
+		}
+		line := p.renderItem(item, contentWidth, selected, positions, number)
 		lines = append(lines, line)
 	}
 
@@ -210,11 +278,19 @@ func (p *Picker) View() string {
 	return overlay.Width(frameWidth).Render(content)
 }
 
-func (p *Picker) renderItem(item ui.PickerItem, width int, selected bool, matches []int) string {
+// renderItem renders one row. number is its quick-select label, 0 for
+// none; a numbered picker pads unlabeled rows to keep them aligned.
+func (p *Picker) renderItem(item ui.PickerItem, width int, selected bool, matches []int, number int) string {
 	prefix := "  "
 	if selected {
 		prefix = "> "
 	}
+	label := ""
+	if number > 0 {
+		label = p.styles.Muted.Render(strconv.Itoa(number)) + " "
+	} else if p.numbered {
+		label = "  "
+	}
 
 	// Match positions from the fuzzy scorer are rune indices into
 	// FilterValue() (text, or "text description"); index by rune, not
@@ -278,5 +354,5 @@ func (p *Picker) renderItem(item ui.PickerItem, width int, selected bool, matche
 	if width < 1 {
 		width = 1
 	}
-	return clipRow(prefixStyled+result.String(), width)
+	return clipRow(label+prefixStyled+result.String(), width)
 }
@@ -155,3 +155,46 @@ func TestPickerRendersUntrustedTextAsOneSafeRow(t *testing.T) {
 		t.Fatalf("selected value = %q (%v), want exact raw value", selected.Value, ok)
 	}
 }
+
+func TestPickerMaxResultsCapsMatches(t *testing.T) {
+	p := newTestPicker(10, "look", "look north", "look south", "lock")
+	p.SetMaxResults(2)
+
+	p.Filter("lo")
+	if got := len(p.filtered); got != 2 {
+		t.Fatalf("filtered = %d items, want 2", got)
+	}
+	if sel, _ := p.Selected(); sel.Text != "look" {
+		t.Errorf("best match = %q, want look", sel.Text)
+	}
+
+	// The cap bounds fuzzy matches only; an empty query lists everything.
+	p.Filter("")
+	if got := len(p.filtered); got != 4 {
+		t.Errorf("unfiltered = %d items, want 4", got)
+	}
+}
+
+// TestPickerNumbersVisibleRows verifies a numbered picker labels the
+// visible rows from 1 whatever the scroll, and Visible resolves the
+// labels to the same items.
+func TestPickerNumbersVisibleRows(t *testing.T) {
+	p := newTestPicker(3, "alpha", "beta", "gamma", "delta")
+	p.SetNumbered(true)
+	for i := 0; i < 3; i++ {
+		p.SelectDown()
+	}
+
+	view := runetext.StripANSI(p.View())
+	for _, want := range []string{"1   beta", "2   gamma", "3 > delta"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+	if item, ok := p.Visible(1); !ok || item.Value != "beta" {
+		t.Errorf("Visible(1) = %v, %v; want beta", item, ok)
+	}
+	if _, ok := p.Visible(4); ok {
+		t.Error("Visible(4) should be past the window")
+	}
+}
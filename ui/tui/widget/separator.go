@@ -7,17 +7,23 @@ var _ Widget = (*Separator)(nil)
 
 // Separator renders a horizontal line.
 type Separator struct {
-	width int
+	width  int
+	styles style.Styles
 }
 
 // NewSeparator creates a new separator widget.
-func NewSeparator() *Separator {
-	return &Separator{}
+func NewSeparator(styles style.Styles) *Separator {
+	return &Separator{styles: styles}
+}
+
+// SetStyles replaces the styles (rune.ui.theme).
+func (s *Separator) SetStyles(styles style.Styles) {
+	s.styles = styles
 }
 
 // View implements Widget.
 func (s *Separator) View() string {
-	return style.RenderBorder(s.width)
+	return s.styles.RenderBorder(s.width)
 }
 
 // SetSize implements Widget.
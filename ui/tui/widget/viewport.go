@@ -1,23 +1,73 @@
 package widget
 
 import (
+	"fmt"
+	"math"
 	"strings"
 
 	"github.com/charmbracelet/x/ansi"
+	"github.com/mmcdole/rune/text"
+	"github.com/mmcdole/rune/ui/tui/style"
 	"github.com/mmcdole/rune/ui/tui/util"
 )
 
+// hyperlinkClose ends an OSC 8 hyperlink.
+const hyperlinkClose = "\x1b]8;;\x1b\\"
+
 // clipRow hard-truncates one row to the given width (ANSI-aware).
 // Every widget row must fit the terminal width: an overlong row wraps
 // at the terminal, adds a phantom physical line, and scrolls the whole
 // frame - corrupting the layout for everything above it.
+//
+// A row carrying an OSC 8 hyperlink is closed with an explicit link
+// end: wrapping or truncation can split a span from its close, and an
+// open link would otherwise bleed into every row painted after it.
 func clipRow(s string, width int) string {
-	if width < 1 || util.VisibleLen(s) <= width {
-		return s
+	// Every cell takes at least a byte, so a row no longer in bytes
+	// than the width fits without measuring.
+	if width >= 1 && len(s) > width && util.VisibleLen(s) > width {
+		s = ansi.Truncate(s, width, "")
+	}
+	if strings.Contains(s, "\x1b]8;") {
+		s += hyperlinkClose
+	}
+	return s
+}
+
+// invertRow renders a row in reverse video across the full width.
+// Colors and links are dropped so the band reads as one block.
+func invertRow(row string, width int) string {
+	plain := text.StripANSI(row)
+	if pad := width - util.VisibleLen(plain); pad > 0 {
+		plain += strings.Repeat(" ", pad)
+	}
+	return "\x1b[7m" + plain + "\x1b[0m"
+}
+
+// moreRow renders the scrolled-back indicator: the count of rows that
+// arrived below the view, centered in the theme's rule.
+func moreRow(styles style.Styles, count, width int) string {
+	label := fmt.Sprintf(" %d new lines below ", count)
+	if count == 1 {
+		label = " 1 new line below "
+	}
+	return clipRow(styles.RenderBorderLabel(width, label), width)
+}
+
+// splitRow renders the divider of a split view: the theme's rule,
+// carrying the count of rows that arrived since scrolling back.
+func splitRow(styles style.Styles, count, width int) string {
+	if count > 0 {
+		return moreRow(styles, count, width)
 	}
-	return ansi.Truncate(s, width, "")
+	return clipRow(styles.RenderBorder(width), width)
 }
 
+// minSplitHeight is the shortest viewport that splits: room for a
+// scrolled window and a live tail of two rows each, a divider, and
+// the prompt.
+const minSplitHeight = 6
+
 // Compile-time check that Viewport implements Widget
 var _ Widget = (*Viewport)(nil)
 
@@ -37,6 +87,7 @@ type ScrollbackBuffer struct {
 	tail     int
 	count    int
 	capacity int
+	appended int // rows ever appended; see Base
 }
 
 // NewScrollbackBuffer creates a new ring buffer.
@@ -54,6 +105,7 @@ func NewScrollbackBuffer(capacity int) *ScrollbackBuffer {
 func (sb *ScrollbackBuffer) Append(row string) {
 	sb.lines[sb.tail] = row
 	sb.tail = (sb.tail + 1) % sb.capacity
+	sb.appended++
 
 	if sb.count < sb.capacity {
 		sb.count++
@@ -62,11 +114,34 @@ func (sb *ScrollbackBuffer) Append(row string) {
 	}
 }
 
+// SetLast replaces the newest row in place. No-op on an empty buffer.
+func (sb *ScrollbackBuffer) SetLast(row string) {
+	if sb.count == 0 {
+		return
+	}
+	sb.lines[(sb.tail-1+sb.capacity)%sb.capacity] = row
+}
+
+// Clear drops every row. Absolute numbering carries on from where it
+// was, so marks on dropped rows simply stop resolving.
+func (sb *ScrollbackBuffer) Clear() {
+	clear(sb.lines)
+	sb.head, sb.tail, sb.count = 0, 0, 0
+}
+
 // Count returns the number of rows.
 func (sb *ScrollbackBuffer) Count() int {
 	return sb.count
 }
 
+// Base returns the absolute number of the oldest buffered row: rows
+// are numbered from 0 in append order, and At(i) is row Base()+i.
+// Absolute numbers stay valid as the ring evicts, so state anchored on
+// a row (a copy-mode selection) survives new output.
+func (sb *ScrollbackBuffer) Base() int {
+	return sb.appended - sb.count
+}
+
 // At retrieves a row by index (0 = oldest).
 func (sb *ScrollbackBuffer) At(i int) string {
 	if i < 0 || i >= sb.count {
@@ -87,96 +162,215 @@ type Viewport struct {
 	cacheValid bool
 	cachedView string
 	prompt     string
+	// frame holds the rows of the last rendered view, top to bottom
+	// (padding rows are ""). Clicks resolve against it rather than
+	// the live offset, so they hit the row the user actually saw
+	// even if output arrived since the last paint.
+	frame []string
+	// sel is the copy-mode selection; nil outside copy mode.
+	sel *selection
+	// marker is the absolute row ScrollToLine jumped to, drawn
+	// inverted while the view stays scrolled back; -1 for none.
+	marker int
+	// hOffset is the first cell column shown, for rows wider than the
+	// viewport (unwrapped output). Clamped at render so the widest
+	// visible row still reaches the right edge.
+	hOffset int
+	// split shows the live tail below a divider while scrolled back
+	// (SetSplit); the scrolled window takes the rows above it.
+	split bool
+	// styles draw the indicator and divider rules (rune.ui.theme).
+	styles style.Styles
+	// clipped caches buffer rows as last cut to the frame (shifted by
+	// hOffset, clipped to width), slotted by absolute row number, so a
+	// render after an append only shapes the rows it brought into
+	// view. Entries hold for clippedWidth and clippedCol.
+	clipped      []clippedRow
+	clippedWidth int
+	clippedCol   int
+}
+
+// clippedRow is one cached row: buffer row abs, ready for the frame.
+type clippedRow struct {
+	abs  int
+	text string
+	ok   bool
 }
 
 // NewViewport creates a viewport for the given buffer.
-func NewViewport(buffer *ScrollbackBuffer) *Viewport {
+func NewViewport(buffer *ScrollbackBuffer, styles style.Styles) *Viewport {
 	return &Viewport{
 		buffer: buffer,
 		mode:   ModeLive,
+		marker: -1,
+		styles: styles,
 	}
 }
 
+// SetStyles replaces the styles (rune.ui.theme).
+func (v *Viewport) SetStyles(styles style.Styles) {
+	v.styles = styles
+	v.cacheValid = false
+}
+
 // View implements Widget.
 func (v *Viewport) View() string {
 	if v.cacheValid {
 		return v.cachedView
 	}
 
-	if v.height <= 0 {
-		v.cachedView = ""
-		v.cacheValid = true
-		return v.cachedView
+	v.frame = v.frame[:0]
+	if v.height > 0 {
+		// Defensive: whatever happened to the offset, the frame must
+		// never grow taller than the assigned height. An offset beyond
+		// Count() would make the window end negative and pad more rows
+		// than fit.
+		v.offset = min(v.offset, v.buffer.Count())
+		if v.splitActive() {
+			v.layoutSplit()
+		} else {
+			v.layoutSingle()
+		}
 	}
 
-	var b strings.Builder
-	b.Grow(v.height * (v.width + 1))
+	v.cachedView = strings.Join(v.frame, "\n")
+	v.cacheValid = true
+	return v.cachedView
+}
 
+// layoutSingle frames one window over the buffer: the live tail with
+// the prompt, or the scrolled-back window.
+func (v *Viewport) layoutSingle() {
 	hasPrompt := v.mode == ModeLive && v.prompt != ""
 	contentHeight := v.height
 	if hasPrompt {
 		contentHeight--
 	}
 
-	if v.buffer.Count() == 0 {
-		for i := 0; i < contentHeight; i++ {
-			if i > 0 {
-				b.WriteByte('\n')
-			}
-		}
-		if hasPrompt {
-			if contentHeight > 0 {
-				b.WriteByte('\n')
-			}
-			b.WriteString(clipRow(v.prompt, v.width))
-		}
-		v.cachedView = b.String()
-		v.cacheValid = true
-		return v.cachedView
+	endIdx := v.buffer.Count() - v.offset
+	startIdx := max(endIdx-contentHeight, 0)
+
+	// Scrolled back with unseen output below: the bottom row becomes
+	// the "new lines below" indicator, reserved like the live prompt
+	// row. The window is laid out first, so the top of the view stays
+	// put when the indicator appears and only its last row gives way.
+	hasMore := v.mode == ModeScrolled && v.newLines > 0 && contentHeight > 1
+	if hasMore {
+		contentHeight--
+		endIdx = min(endIdx, startIdx+contentHeight)
 	}
 
-	// Defensive: whatever happened to the offset, the frame must never
-	// grow taller than the assigned height. An offset beyond Count()
-	// would make endIdx negative and the padding loop below emit more
-	// than contentHeight rows.
-	totalLines := v.buffer.Count()
-	if v.offset > totalLines {
-		v.offset = totalLines
+	v.clampColumns(v.widest(startIdx, endIdx))
+	v.appendWindow(startIdx, endIdx, contentHeight)
+	if hasMore {
+		v.frame = append(v.frame, moreRow(v.styles, v.newLines, v.width))
 	}
-	endIdx := totalLines - v.offset
-	if endIdx > totalLines {
-		endIdx = totalLines
+	if hasPrompt {
+		v.frame = append(v.frame, clipRow(v.prompt, v.width))
 	}
+}
 
-	startIdx := endIdx - contentHeight
-	if startIdx < 0 {
-		startIdx = 0
+// layoutSplit frames a split view: the scrolled-back window on top, a
+// divider, and the live tail with the prompt below, so output keeps
+// arriving in view while older rows are read.
+func (v *Viewport) layoutSplit() {
+	topHeight := v.scrolledHeight()
+	liveHeight := v.height - topHeight - 1
+	if v.prompt != "" {
+		liveHeight--
 	}
 
-	visibleCount := endIdx - startIdx
-	emptyLines := contentHeight - visibleCount
+	total := v.buffer.Count()
+	endIdx := total - v.offset
+	startIdx := max(endIdx-topHeight, 0)
+	liveStart := max(total-liveHeight, 0)
+
+	v.clampColumns(max(v.widest(startIdx, endIdx), v.widest(liveStart, total)))
+	v.appendWindow(startIdx, endIdx, topHeight)
+	v.frame = append(v.frame, splitRow(v.styles, v.newLines, v.width))
+	v.appendWindow(liveStart, total, liveHeight)
+	if v.prompt != "" {
+		v.frame = append(v.frame, clipRow(v.prompt, v.width))
+	}
+}
 
-	for i := 0; i < emptyLines; i++ {
-		if i > 0 {
-			b.WriteByte('\n')
+// appendWindow adds buffer rows [startIdx, endIdx) to the frame,
+// bottom-aligned in height rows with blank padding above.
+func (v *Viewport) appendWindow(startIdx, endIdx, height int) {
+	for i := endIdx - startIdx; i < height; i++ {
+		v.frame = append(v.frame, "")
+	}
+	for i := startIdx; i < endIdx; i++ {
+		row := v.clippedAt(i)
+		if v.sel != nil && v.sel.contains(v.buffer.Base()+i) ||
+			v.mode == ModeScrolled && v.buffer.Base()+i == v.marker {
+			row = invertRow(row, v.width)
 		}
+		v.frame = append(v.frame, row)
 	}
+}
 
-	for i := startIdx; i < endIdx; i++ {
-		if emptyLines > 0 || i > startIdx {
-			b.WriteByte('\n')
+// clippedAt returns buffer row i cut to the frame, from the cache when
+// it was shaped before at this width and column offset.
+func (v *Viewport) clippedAt(i int) string {
+	// Two windows (a split) plus slack: a row stays cached while it
+	// can still be on screen.
+	if need := 2*v.height + 2; len(v.clipped) < need || v.clippedWidth != v.width || v.clippedCol != v.hOffset {
+		v.clipped = make([]clippedRow, max(need, len(v.clipped)))
+		v.clippedWidth, v.clippedCol = v.width, v.hOffset
+	}
+	abs := v.buffer.Base() + i
+	slot := &v.clipped[abs%len(v.clipped)]
+	if slot.ok && slot.abs == abs {
+		return slot.text
+	}
+	row := v.buffer.At(i)
+	if v.hOffset > 0 {
+		// SGR state and open links before the cut carry over.
+		row = ansi.TruncateLeft(row, v.hOffset, "")
+	}
+	row = clipRow(row, v.width)
+	*slot = clippedRow{abs: abs, text: row, ok: true}
+	return row
+}
+
+// forgetClipped drops the cached shape of absolute row abs.
+func (v *Viewport) forgetClipped(abs int) {
+	if len(v.clipped) > 0 {
+		if slot := &v.clipped[abs%len(v.clipped)]; slot.abs == abs {
+			slot.ok = false
 		}
-		b.WriteString(clipRow(v.buffer.At(i), v.width))
 	}
+}
 
-	if hasPrompt {
-		b.WriteByte('\n')
-		b.WriteString(clipRow(v.prompt, v.width))
+// widest returns the widest of buffer rows [startIdx, endIdx), in
+// cells; 0 when the view is not scrolled sideways (nothing to clamp).
+func (v *Viewport) widest(startIdx, endIdx int) int {
+	widest := 0
+	if v.hOffset > 0 {
+		for i := startIdx; i < endIdx; i++ {
+			widest = max(widest, util.VisibleLen(v.buffer.At(i)))
+		}
 	}
+	return widest
+}
 
-	v.cachedView = b.String()
-	v.cacheValid = true
-	return v.cachedView
+// clampColumns keeps the sideways offset from scrolling past the
+// widest visible row.
+func (v *Viewport) clampColumns(widest int) {
+	if v.hOffset > 0 {
+		v.hOffset = min(v.hOffset, max(widest-v.width, 0))
+	}
+}
+
+// RowAt returns the row painted at line y of the last rendered view
+// (0 = top of the viewport). False when y is outside the frame or no
+// view has been rendered yet.
+func (v *Viewport) RowAt(y int) (string, bool) {
+	if y < 0 || y >= len(v.frame) {
+		return "", false
+	}
+	return v.frame[y], true
 }
 
 // SetSize implements Widget.
@@ -214,16 +408,54 @@ func (v *Viewport) OnNewRows(count int) {
 	}
 }
 
+// OnLastRowChanged is called when the newest buffered row was
+// rewritten in place (ScrollbackBuffer.SetLast).
+func (v *Viewport) OnLastRowChanged() {
+	v.forgetClipped(v.buffer.Base() + v.buffer.Count() - 1)
+	v.cacheValid = false
+}
+
 // maxOffset is the largest scroll offset that still fills the window
 // with buffered rows; 0 when the buffer fits the viewport.
 func (v *Viewport) maxOffset() int {
-	max := v.buffer.Count() - v.height
+	max := v.buffer.Count() - v.scrolledHeight()
 	if max < 0 {
 		max = 0
 	}
 	return max
 }
 
+// SetSplit turns the split view on or off: while scrolled back, the
+// newest output keeps showing in a live region at the bottom, below
+// the rows being read. Copy mode always uses the whole viewport.
+func (v *Viewport) SetSplit(on bool) {
+	if v.split != on {
+		v.split = on
+		v.offset = min(v.offset, v.maxOffset())
+		v.cacheValid = false
+	}
+}
+
+// Split reports whether the split view is on.
+func (v *Viewport) Split() bool {
+	return v.split
+}
+
+// splitActive reports whether the view renders split right now.
+func (v *Viewport) splitActive() bool {
+	return v.split && v.mode == ModeScrolled && v.sel == nil && v.height >= minSplitHeight
+}
+
+// scrolledHeight is the number of rows the scrolled-back window shows:
+// the whole viewport, or with the split on, what the live region (a
+// third of the viewport, with the prompt) and divider leave.
+func (v *Viewport) scrolledHeight() int {
+	if !v.split || v.sel != nil || v.height < minSplitHeight {
+		return v.height
+	}
+	return v.height - max(v.height/3, 3) - 1
+}
+
 // SetPrompt sets the server prompt.
 func (v *Viewport) SetPrompt(text string) {
 	if v.prompt != text {
@@ -234,7 +466,7 @@ func (v *Viewport) SetPrompt(text string) {
 
 // PageUp scrolls up one page.
 func (v *Viewport) PageUp() {
-	v.offset += v.height - 1
+	v.offset += v.scrolledHeight() - 1
 	if max := v.maxOffset(); v.offset > max {
 		v.offset = max
 	}
@@ -247,11 +479,9 @@ func (v *Viewport) PageUp() {
 
 // PageDown scrolls down one page.
 func (v *Viewport) PageDown() {
-	v.offset -= v.height - 1
+	v.offset -= v.scrolledHeight() - 1
 	if v.offset <= 0 {
-		v.offset = 0
-		v.mode = ModeLive
-		v.newLines = 0
+		v.goLive()
 	}
 	v.cacheValid = false
 }
@@ -273,19 +503,69 @@ func (v *Viewport) ScrollUp(lines int) {
 func (v *Viewport) ScrollDown(lines int) {
 	v.offset -= lines
 	if v.offset <= 0 {
-		v.offset = 0
-		v.mode = ModeLive
-		v.newLines = 0
+		v.goLive()
 	}
 	v.cacheValid = false
 }
 
+// ScrollPages scrolls by pages times the viewport height (negative =
+// up, toward older content), at least one line: 0.5 is a half page
+// however tall the layout makes the viewport.
+func (v *Viewport) ScrollPages(pages float64) {
+	lines := max(int(math.Round(math.Abs(pages)*float64(v.scrolledHeight()))), 1)
+	if pages < 0 {
+		v.ScrollUp(lines)
+	} else if pages > 0 {
+		v.ScrollDown(lines)
+	}
+}
+
 // GotoBottom returns to live mode.
 func (v *Viewport) GotoBottom() {
+	v.goLive()
+	v.cacheValid = false
+}
+
+// goLive follows the newest output again, dropping the count of rows
+// that arrived while scrolled, any ScrollToLine marker, and the
+// horizontal offset.
+func (v *Viewport) goLive() {
 	v.offset = 0
 	v.mode = ModeLive
 	v.newLines = 0
+	v.marker = -1
+	v.hOffset = 0
+}
+
+// ScrollColumns shifts the view sideways by cols cell columns
+// (negative = left), never left of column 0; the right bound is
+// applied at render against the visible rows.
+func (v *Viewport) ScrollColumns(cols int) {
+	if n := max(v.hOffset+cols, 0); n != v.hOffset {
+		v.hOffset = n
+		v.cacheValid = false
+	}
+}
+
+// ColumnOffset returns the first cell column shown.
+func (v *Viewport) ColumnOffset() int {
+	return v.hOffset
+}
+
+// ScrollToLine scrolls back so absolute row (see ScrollbackBuffer.Base)
+// is the top of the view, and marks it: the row is drawn inverted
+// until the view returns to live. Reports false, leaving the view
+// alone, when the row has been evicted or not yet written.
+func (v *Viewport) ScrollToLine(row int) bool {
+	i := row - v.buffer.Base()
+	if i < 0 || i >= v.buffer.Count() {
+		return false
+	}
+	v.offset = min(max(v.buffer.Count()-i-v.scrolledHeight(), 0), v.maxOffset())
+	v.mode = ModeScrolled
+	v.marker = row
 	v.cacheValid = false
+	return true
 }
 
 // GotoTop scrolls to the oldest line.
@@ -302,6 +582,17 @@ func (v *Viewport) Mode() ScrollMode {
 	return v.mode
 }
 
+// ScrollPercent reports how far down the scrollback the view is: 0 at
+// the oldest row, 100 showing the newest (or when everything fits).
+// Rounds down, so only the live view reads 100.
+func (v *Viewport) ScrollPercent() int {
+	max := v.maxOffset()
+	if max == 0 || v.offset <= 0 {
+		return 100
+	}
+	return (max - min(v.offset, max)) * 100 / max
+}
+
 // NewLineCount returns lines added while scrolled.
 func (v *Viewport) NewLineCount() int {
 	return v.newLines
@@ -2,15 +2,19 @@ package widget
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/mmcdole/rune/text"
+	"github.com/mmcdole/rune/ui"
+	"github.com/mmcdole/rune/ui/tui/style"
 	"github.com/mmcdole/rune/ui/tui/util"
 )
 
 func newTestViewport(width, height int, lines ...string) (*Viewport, *ScrollbackBuffer) {
 	buf := NewScrollbackBuffer(1000)
-	v := NewViewport(buf)
+	v := NewViewport(buf, style.DefaultStyles())
 	v.SetSize(width, height)
 	for _, l := range lines {
 		buf.Append(l)
@@ -95,8 +99,10 @@ func TestViewportScrollAnchorsWhileNewLinesArrive(t *testing.T) {
 	buf.Append("eight")
 	v.OnNewRows(1)
 
+	// The bottom row gives way to the new-lines indicator; the rest of
+	// the view stays anchored.
 	after := viewRows(v)
-	for i := range before {
+	for i := range before[:len(before)-1] {
 		if before[i] != after[i] {
 			t.Errorf("scrolled view moved: %q -> %q", before, after)
 		}
@@ -144,6 +150,27 @@ func TestViewportPagingClampsAndRestoresLive(t *testing.T) {
 	}
 }
 
+func TestViewportScrollPages(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	v, _ := newTestViewport(40, 4, lines...)
+
+	v.ScrollPages(-0.5)
+	if rows := viewRows(v); rows[3] != "line 18" || v.Mode() != ModeScrolled {
+		t.Errorf("half page up should move 2 of 4 rows, got %q", rows)
+	}
+	v.ScrollPages(-0.1) // rounds to nothing: still moves one line
+	if rows := viewRows(v); rows[3] != "line 17" {
+		t.Errorf("a tiny fraction should move one line, got %q", rows)
+	}
+	v.ScrollPages(1)
+	if v.Mode() != ModeLive {
+		t.Error("paging down past the newest row must restore live mode")
+	}
+}
+
 func TestViewportGotoTop(t *testing.T) {
 	v, _ := newTestViewport(40, 2, "one", "two", "three", "four")
 	v.GotoTop()
@@ -156,6 +183,34 @@ func TestViewportGotoTop(t *testing.T) {
 	}
 }
 
+func TestViewportScrollPercent(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 14; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	v, buf := newTestViewport(40, 4, lines...)
+
+	if got := v.ScrollPercent(); got != 100 {
+		t.Errorf("live percent = %d, want 100", got)
+	}
+	v.ScrollUp(5)
+	if got := v.ScrollPercent(); got != 50 {
+		t.Errorf("halfway percent = %d, want 50", got)
+	}
+	// New rows push the anchored view further from the newest.
+	for i := 0; i < 10; i++ {
+		buf.Append("more")
+		v.OnNewRows(1)
+	}
+	if got := v.ScrollPercent(); got != 25 {
+		t.Errorf("percent after new rows = %d, want 25", got)
+	}
+	v.GotoTop()
+	if got := v.ScrollPercent(); got != 0 {
+		t.Errorf("top percent = %d, want 0", got)
+	}
+}
+
 func TestViewportClipsOverlongRows(t *testing.T) {
 	long := strings.Repeat("x", 100)
 	styledLong := "\x1b[1;31m" + strings.Repeat("y", 100) + "\x1b[m"
@@ -169,6 +224,46 @@ func TestViewportClipsOverlongRows(t *testing.T) {
 	}
 }
 
+// TestViewportScrollColumns verifies sideways scrolling slices rows by
+// cell column with styling intact, stops once the widest visible row
+// reaches the right edge, and resets on returning to live.
+func TestViewportScrollColumns(t *testing.T) {
+	wide := "0123456789abcdefghij"
+	styled := "\x1b[31m" + strings.Repeat("r", 15) + "\x1b[m"
+	v, _ := newTestViewport(10, 3, wide, styled)
+	v.SetPrompt("")
+
+	v.ScrollColumns(4)
+	rows := viewRows(v)
+	if got := text.StripANSI(rows[1]); got != "456789abcd" {
+		t.Errorf("row after scrolling 4 = %q, want %q", got, "456789abcd")
+	}
+	if !strings.HasPrefix(rows[2], "\x1b[31m") {
+		t.Errorf("styled row lost its color after the cut: %q", rows[2])
+	}
+
+	v.ScrollColumns(100)
+	rows = viewRows(v)
+	if v.ColumnOffset() != 10 {
+		t.Errorf("ColumnOffset = %d, want clamped to 10", v.ColumnOffset())
+	}
+	if got := text.StripANSI(rows[1]); got != "abcdefghij" {
+		t.Errorf("row at the right edge = %q, want %q", got, "abcdefghij")
+	}
+
+	v.ScrollColumns(-100)
+	if v.ColumnOffset() != 0 {
+		t.Errorf("ColumnOffset = %d after scrolling far left, want 0", v.ColumnOffset())
+	}
+
+	v.ScrollColumns(5)
+	v.ScrollUp(1)
+	v.GotoBottom()
+	if v.ColumnOffset() != 0 {
+		t.Errorf("ColumnOffset = %d after returning to live, want 0", v.ColumnOffset())
+	}
+}
+
 func TestViewportEmptyBufferRendersBlankRows(t *testing.T) {
 	v, _ := newTestViewport(40, 3)
 	rows := viewRows(v)
@@ -189,7 +284,7 @@ func TestViewportEmptyBufferRendersBlankRows(t *testing.T) {
 // (issue #60).
 func TestViewportScrolledSurvivesRingBufferEviction(t *testing.T) {
 	buf := NewScrollbackBuffer(8)
-	v := NewViewport(buf)
+	v := NewViewport(buf, style.DefaultStyles())
 	v.SetSize(40, 3)
 	for i := 1; i <= 8; i++ {
 		buf.Append(fmt.Sprintf("line %d", i))
@@ -211,7 +306,7 @@ func TestViewportScrolledSurvivesRingBufferEviction(t *testing.T) {
 	if len(rows) != 3 {
 		t.Fatalf("View emitted %d rows, want exactly the assigned 3: %q", len(rows), rows)
 	}
-	want := []string{"line 33", "line 34", "line 35"}
+	want := []string{"line 33", "line 34"} // bottom row: the indicator
 	for i, w := range want {
 		if rows[i] != w {
 			t.Errorf("row %d = %q, want %q (view should pin to the oldest surviving rows)", i, rows[i], w)
@@ -228,6 +323,45 @@ func TestViewportScrolledSurvivesRingBufferEviction(t *testing.T) {
 	}
 }
 
+// While scrolled back, output arriving below is announced in the
+// bottom row with its count; returning live removes it.
+func TestViewportNewLinesIndicator(t *testing.T) {
+	v, buf := newTestViewport(30, 3, "one", "two", "three", "four", "five")
+	v.ScrollUp(2)
+	if rows := viewRows(v); rows[2] != "three" {
+		t.Fatalf("no indicator before new output, got %q", rows)
+	}
+
+	buf.Append("six")
+	v.OnNewRows(1)
+	rows := viewRows(v)
+	if len(rows) != 3 || rows[0] != "one" || rows[1] != "two" {
+		t.Fatalf("indicator should take only the bottom row, got %q", rows)
+	}
+	if got := text.StripANSI(rows[2]); got != "────── 1 new line below ──────" {
+		t.Errorf("indicator = %q", got)
+	}
+	if util.VisibleLen(rows[2]) != 30 {
+		t.Errorf("indicator width = %d, want 30", util.VisibleLen(rows[2]))
+	}
+
+	buf.Append("seven")
+	v.OnNewRows(1)
+	if got := text.StripANSI(viewRows(v)[2]); !strings.Contains(got, " 2 new lines below ") {
+		t.Errorf("indicator = %q, want the updated count", got)
+	}
+
+	v.SetStyles(style.DefaultStyles().WithTheme(ui.Theme{Rule: "═"}))
+	if got := text.StripANSI(viewRows(v)[2]); !strings.HasPrefix(got, "═══") {
+		t.Errorf("indicator = %q, want the theme's rule", got)
+	}
+
+	v.GotoBottom()
+	if rows := viewRows(v); rows[2] != "seven" {
+		t.Errorf("live view should drop the indicator, got %q", rows)
+	}
+}
+
 // View must never emit more rows than its height, whatever state the
 // offset is in - the frame-geometry backstop behind the OnNewRows clamp.
 func TestViewportViewNeverExceedsHeight(t *testing.T) {
@@ -259,3 +393,206 @@ func TestScrollbackBufferWrapsAtCapacity(t *testing.T) {
 		t.Error("out-of-range At should return empty string")
 	}
 }
+
+// TestViewportRowAtFollowsRenderedFrame verifies clicks map to the rows
+// last painted - padding, scrollback, and prompt alike - and that output
+// arriving after the paint does not shift what a click resolves to.
+func TestViewportRowAtFollowsRenderedFrame(t *testing.T) {
+	v, buf := newTestViewport(40, 4, "a", "b")
+	v.SetPrompt("> ")
+	v.View()
+
+	want := []string{"", "a", "b", "> "}
+	for y, w := range want {
+		if got, ok := v.RowAt(y); !ok || got != w {
+			t.Errorf("RowAt(%d) = %q, %v, want %q", y, got, ok, w)
+		}
+	}
+	if _, ok := v.RowAt(4); ok {
+		t.Error("RowAt past the frame should report false")
+	}
+
+	// Scrolled back, then new output lands before the next paint: the
+	// click still resolves against the frame the user saw.
+	for i := 0; i < 10; i++ {
+		buf.Append(fmt.Sprintf("new %d", i))
+		v.OnNewRows(1)
+	}
+	v.View()
+	v.ScrollUp(5)
+	v.View()
+	seen, _ := v.RowAt(0)
+	buf.Append("late")
+	v.OnNewRows(1)
+	if got, _ := v.RowAt(0); got != seen {
+		t.Errorf("RowAt(0) = %q before repaint, want painted %q", got, seen)
+	}
+	v.View()
+	if got, _ := v.RowAt(0); got != seen {
+		t.Errorf("RowAt(0) = %q after repaint, want anchored %q", got, seen)
+	}
+}
+
+func TestViewportClosesClippedHyperlink(t *testing.T) {
+	link := "\x1b]8;;https://example.com\x1b\\" + strings.Repeat("x", 20) + "\x1b]8;;\x1b\\"
+	v, _ := newTestViewport(10, 1, link)
+
+	row := viewRows(v)[0]
+	if !strings.HasSuffix(row, "\x1b]8;;\x1b\\") {
+		t.Errorf("clipped hyperlink row not closed: %q", row)
+	}
+	if got := util.VisibleLen(row); got != 10 {
+		t.Errorf("clipped row width = %d, want 10", got)
+	}
+}
+
+func TestViewportScrollToLineMarksRow(t *testing.T) {
+	var lines []string
+	for i := 0; i < 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	v, buf := newTestViewport(20, 4, lines...)
+
+	if !v.ScrollToLine(buf.Base() + 5) {
+		t.Fatal("ScrollToLine refused a buffered row")
+	}
+	rows := viewRows(v)
+	if v.Mode() != ModeScrolled || !strings.Contains(rows[0], "\x1b[7mline 5") {
+		t.Fatalf("want row 5 inverted at the top, got mode %v rows %q", v.Mode(), rows)
+	}
+	if rows[1] != "line 6" {
+		t.Errorf("rows after the marker should render normally, got %q", rows[1])
+	}
+
+	// Near the bottom the row cannot reach the top; it stays marked.
+	if !v.ScrollToLine(buf.Base() + 18) {
+		t.Fatal("ScrollToLine refused a buffered row")
+	}
+	if rows := viewRows(v); !strings.Contains(rows[2], "\x1b[7mline 18") {
+		t.Errorf("want row 18 marked third from top, got %q", rows)
+	}
+
+	v.GotoBottom()
+	for _, row := range viewRows(v) {
+		if strings.Contains(row, "\x1b[7m") {
+			t.Errorf("marker survived returning to live: %q", row)
+		}
+	}
+
+	if v.ScrollToLine(buf.Base()+20) || v.ScrollToLine(buf.Base()-1) {
+		t.Error("ScrollToLine accepted a row outside the buffer")
+	}
+}
+
+func TestViewportSplitKeepsLiveTailInView(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	v, buf := newTestViewport(30, 9, lines...)
+	v.SetPrompt("> ")
+	v.SetSplit(true)
+
+	// Live: the split stays closed.
+	if rows := viewRows(v); rows[7] != "line 20" || rows[8] != "> " {
+		t.Fatalf("live view = %q", rows)
+	}
+
+	v.ScrollUp(10)
+	buf.Append("line 21")
+	v.OnNewRows(1)
+	rows := viewRows(v)
+	if len(rows) != 9 {
+		t.Fatalf("split view has %d rows, want 9: %q", len(rows), rows)
+	}
+	want := []string{"line 6", "line 7", "line 8", "line 9", "line 10"}
+	for i, w := range want {
+		if rows[i] != w {
+			t.Errorf("top row %d = %q, want %q", i, rows[i], w)
+		}
+	}
+	if got := text.StripANSI(rows[5]); !strings.Contains(got, " 1 new line below ") {
+		t.Errorf("divider = %q, want the new-line count", got)
+	}
+	if rows[6] != "line 20" || rows[7] != "line 21" || rows[8] != "> " {
+		t.Errorf("live region = %q, want the newest rows and the prompt", rows[6:])
+	}
+
+	// With nothing new the divider is a plain rule, in the theme's.
+	quiet, _ := newTestViewport(30, 9, lines...)
+	quiet.SetSplit(true)
+	quiet.SetStyles(style.DefaultStyles().WithTheme(ui.Theme{Rule: "═"}))
+	quiet.ScrollUp(10)
+	if got := text.StripANSI(viewRows(quiet)[5]); got != strings.Repeat("═", 30) {
+		t.Errorf("quiet divider = %q, want the theme's rule", got)
+	}
+
+	// Paging moves the top window by its own height.
+	v.PageUp()
+	if rows := viewRows(v); rows[0] != "line 2" {
+		t.Errorf("after PageUp top row = %q, want line 2", rows[0])
+	}
+
+	// Copy mode and short viewports use the whole height.
+	v.EnterCopyMode()
+	if rows := viewRows(v); slices.Contains(rows, "line 21") {
+		t.Errorf("copy mode kept the split: %q", rows)
+	}
+	v.ExitCopyMode()
+	v.SetSize(30, 5)
+	if rows := viewRows(v); len(rows) != 5 || rows[4] == "> " {
+		t.Errorf("short viewport split: %q", rows)
+	}
+}
+
+// TestViewportCachedRowsMatchFreshRender verifies rows reused from the
+// clip cache render exactly as a fresh viewport shapes them, through
+// appends, in-place rewrites, sideways scrolls, and resizes.
+func TestViewportCachedRowsMatchFreshRender(t *testing.T) {
+	v, buf := newTestViewport(30, 6)
+	check := func(step string) {
+		t.Helper()
+		fresh := NewViewport(buf, style.DefaultStyles())
+		fresh.SetSize(v.width, v.height)
+		fresh.ScrollColumns(v.ColumnOffset())
+		if got, want := v.View(), fresh.View(); got != want {
+			t.Fatalf("%s: cached view\n%q\nwant\n%q", step, got, want)
+		}
+	}
+	for i := range 20 {
+		buf.Append(fmt.Sprintf("\x1b[3%dmrow %d %s\x1b[0m", i%8, i, strings.Repeat("=", i*3)))
+		v.OnNewRows(1)
+		check("append")
+	}
+	buf.SetLast("\x1b[31mrewritten last row, long enough to be clipped\x1b[0m")
+	v.OnLastRowChanged()
+	check("set last")
+	v.ScrollColumns(12)
+	check("scroll right")
+	v.ScrollColumns(-12)
+	check("scroll left")
+	v.SetSize(50, 9)
+	check("resize")
+}
+
+// BenchmarkViewportStream renders a stream at 1k and 10k lines/sec:
+// each frame appends the rows one 16ms tick brings, then paints.
+func BenchmarkViewportStream(b *testing.B) {
+	for _, rate := range []int{1000, 10000} {
+		b.Run(fmt.Sprintf("%d/s", rate), func(b *testing.B) {
+			v, buf := newTestViewport(160, 50)
+			rows := make([]string, rate*16/1000)
+			for i := range rows {
+				rows[i] = fmt.Sprintf("\x1b[32mThe orc\x1b[0m hits you \x1b[1;31mhard\x1b[0m (%d) %s", i, strings.Repeat("-", i%120))
+			}
+			b.ReportAllocs()
+			for b.Loop() {
+				for _, row := range rows {
+					buf.Append(row)
+				}
+				v.OnNewRows(len(rows))
+				v.View()
+			}
+		})
+	}
+}
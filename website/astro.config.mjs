@@ -78,6 +78,7 @@ export default defineConfig({
                 { label: 'Core', slug: 'reference/api/core' },
                 { label: 'State & Lines', slug: 'reference/api/state-lines' },
                 { label: 'rune.style', slug: 'reference/api/style' },
+                { label: 'rune.string', slug: 'reference/api/string' },
                 { label: 'rune.regex', slug: 'reference/api/regex' },
                 { label: 'rune.trigger', slug: 'reference/api/trigger' },
                 { label: 'rune.alias', slug: 'reference/api/alias' },
@@ -88,6 +89,7 @@ export default defineConfig({
                 { label: 'rune.group', slug: 'reference/api/group' },
                 { label: 'rune.gmcp', slug: 'reference/api/gmcp' },
                 { label: 'rune.http', slug: 'reference/api/http' },
+                { label: 'rune.exec', slug: 'reference/api/exec' },
                 { label: 'rune.input', slug: 'reference/api/input' },
                 { label: 'Storage', slug: 'reference/api/storage' },
                 { label: 'rune.log', slug: 'reference/api/log' },
@@ -95,6 +97,7 @@ export default defineConfig({
                 { label: 'rune.ui.picker', slug: 'reference/api/picker' },
                 { label: 'rune.pane', slug: 'reference/api/pane' },
                 { label: 'rune.clipboard', slug: 'reference/api/clipboard' },
+                { label: 'rune.link', slug: 'reference/api/link' },
               ],
             },
             { label: 'Slash Commands', slug: 'reference/slash-commands' },